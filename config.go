@@ -1,21 +1,62 @@
 package tugo
 
 import (
+	"context"
 	"time"
 
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgxpool"
 	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/cache"
+	"github.com/thienel/tugo/pkg/cluster"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/events"
+	"github.com/thienel/tugo/pkg/format"
+	"github.com/thienel/tugo/pkg/idgen"
+	"github.com/thienel/tugo/pkg/inbox"
+	"github.com/thienel/tugo/pkg/ingest"
+	"github.com/thienel/tugo/pkg/ipfilter"
+	"github.com/thienel/tugo/pkg/mcp"
+	"github.com/thienel/tugo/pkg/notify"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/quota"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/retention"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/search"
+	"github.com/thienel/tugo/pkg/snapshot"
+	"github.com/thienel/tugo/pkg/storage"
+	"github.com/thienel/tugo/pkg/storedquery"
+	"github.com/thienel/tugo/pkg/usage"
+	"github.com/thienel/tugo/pkg/vector"
+	"github.com/thienel/tugo/pkg/webhook"
+	"google.golang.org/grpc"
 )
 
 // Config holds the complete configuration for TuGo engine.
 type Config struct {
 	// DB is an existing sqlx database connection.
-	// Either DB or DatabaseURL must be provided.
+	// One of DB, PgxPool or DatabaseURL must be provided.
 	DB *sqlx.DB
 
-	// DatabaseURL is a PostgreSQL connection string.
+	// PgxPool is an existing pgx connection pool.
+	// When set, TuGo uses the pgx stdlib driver on top of this pool,
+	// enabling native LISTEN/NOTIFY for schema watching.
+	PgxPool *pgxpool.Pool
+
+	// DatabaseURL is a database connection string.
 	// Used when DB is nil to create a new connection.
+	// MySQL DSNs should include parseTime=true.
 	DatabaseURL string
 
+	// Driver selects the database backend: "postgres", "pgx", "mysql" or "sqlite".
+	// "pgx" connects through a pgxpool instead of lib/pq.
+	// SQLite requires the host to import a driver (e.g. mattn/go-sqlite3).
+	// When DB is provided, the dialect is derived from its driver name.
+	// Default: "postgres"
+	Driver string
+
 	// Discovery configures how tables are discovered and exposed.
 	Discovery DiscoveryConfig
 
@@ -36,6 +77,148 @@ type Config struct {
 
 	// SchemaWatch configures automatic schema change detection.
 	SchemaWatch SchemaWatchConfig
+
+	// Cluster coordinates the instances sharing the database: schema
+	// refreshes, policy cache invalidations, webhook pauses and config
+	// reloads reach every instance, and each background job runs on one
+	// instance at a time.
+	Cluster cluster.Config
+
+	// Query configures collection query execution.
+	Query QueryConfig
+
+	// Queries registers read-only SQL statements exposed at /queries/:name.
+	// Admins can add more at runtime through /admin/queries.
+	Queries []storedquery.Query
+
+	// RPC exposes PostgreSQL functions at /rpc/:function.
+	RPC RPCConfig
+
+	// GRPC serves the collections over gRPC on a separate port, for
+	// internal service-to-service consumers.
+	GRPC GRPCConfig
+
+	// Permissions configures how permissions are enforced.
+	Permissions PermissionsConfig
+
+	// Resilience configures retries and the circuit breaker for collection queries.
+	Resilience ResilienceConfig
+
+	// Logging configures request IDs and access logging. Every response
+	// carries an X-Request-ID header, error responses include the ID, and
+	// request log lines are tagged with it.
+	Logging requestlog.Config
+
+	// Usage tracks request counts, error rates and p95 latencies of the
+	// collection endpoints, reported at GET /admin/usage.
+	Usage usage.Config
+
+	// Quotas meters the collection requests and bytes of each
+	// authenticated user against monthly quotas per role, rejecting
+	// requests with 429 once one is used up. Usage is stored in
+	// tugo_quota_usage and served at GET /auth/usage and
+	// GET /admin/users/:id/usage.
+	Quotas quota.Config
+
+	// Migrations configures how Init runs TuGo's internal migrations.
+	Migrations MigrationsConfig
+
+	// Admin configures schema changes made through the admin API.
+	Admin AdminConfig
+
+	// Timestamps configures how timestamp fields are written: RFC 3339 with
+	// the zone offset, epoch milliseconds or naive local time. Requests can
+	// name their time zone with the X-Timezone header or the tz parameter.
+	// Default: RFC 3339 in UTC
+	Timestamps collection.TimestampConfig
+
+	// AutoFields names the columns filled in on every write from the server
+	// clock and the authenticated user, in collections that have them. Use
+	// schema.DefaultAutoFields for created_at, updated_at, created_by and
+	// updated_by. Client values for these columns are ignored.
+	// Default: none
+	AutoFields schema.AutoFields
+
+	// Notify configures email sending for record notifications and
+	// Engine.Notifier.
+	Notify NotifyConfig
+
+	// Retention schedules the collection retention rules.
+	Retention RetentionConfig
+
+	// Lifecycle schedules publishing the items of collection lifecycles
+	// whose publish time has passed.
+	Lifecycle LifecycleConfig
+
+	// Favorites configures GET /me/favorites, the items users starred in
+	// collections with Favorites.
+	Favorites FavoritesConfig
+
+	// Snapshots enables dumping collections to storage and restoring them
+	// through the admin API.
+	Snapshots snapshot.Config
+
+	// Webhooks post collection record events to HTTP endpoints. Deliveries
+	// are recorded in tugo_webhook_deliveries and can be inspected,
+	// redelivered and paused under /admin/webhooks.
+	Webhooks []webhook.Webhook
+
+	// Events records every create, update and delete as an ordered event
+	// in tugo_events, read with GET /events?since=cursor, and publishes
+	// events to brokers such as NATS or Kafka through Publishers.
+	Events events.Config
+
+	// Inbox keeps in-app notifications for users in tugo_notifications,
+	// served under /me/notifications: comment mentions and replies,
+	// lifecycle transitions, reviews of pending changes and failed webhook
+	// deliveries. Requires auth.
+	Inbox inbox.Config
+
+	// Ingest accepts third-party webhook payloads on POST /ingest/<name>,
+	// verifying their signatures and writing them into collections.
+	Ingest []ingest.Route
+
+	// MCP exposes collections as list, get and create tools for LLM agents
+	// over the Model Context Protocol at /mcp, with a JSON tool manifest at
+	// GET /mcp/tools.
+	MCP mcp.Config
+
+	// Search mirrors collections into Meilisearch or Elasticsearch through
+	// the change feed and can serve their ?search= queries.
+	Search search.Config
+
+	// Embedder computes the vectors of the collections' Embeddings fields,
+	// such as a client of an embeddings API.
+	Embedder vector.Embedder
+
+	// IDs configures the primary keys generated for collections with an
+	// IDStrategy, such as the snowflake node of this instance.
+	IDs idgen.Config
+
+	// GeoIP resolves client countries for the AllowCountries and
+	// DenyCountries of the Mount IP rules.
+	GeoIP ipfilter.GeoResolver
+
+	// Formats enables XML and MessagePack collection responses, chosen by the
+	// Accept header, and request bodies of those content types. JSON remains
+	// the default.
+	Formats format.Config
+
+	// OnError is called for every 5xx response from TuGo routes with the
+	// underlying error and the request's collection, action, user and
+	// redacted details, for reporting to services such as Sentry.
+	OnError func(ctx context.Context, err error, meta requestlog.RequestMeta)
+
+	// Cache is the key-value store shared by TuGo's caches, such as
+	// cache.NewRedisStore for a cache shared between instances. When set, it
+	// also stores sessions unless Auth.SessionStore is given.
+	// Default: an in-memory LRU store
+	Cache cache.Store
+
+	// ConfigSource loads the configuration applied by POST /admin/config/reload,
+	// for example by re-reading a config file. See Engine.Reload for the
+	// settings that can change without a restart.
+	ConfigSource func(ctx context.Context) (Config, error)
 }
 
 // DiscoveryConfig configures table discovery behavior.
@@ -70,11 +253,290 @@ type CollectionItemConfig struct {
 	// PublicFields limits which fields are visible.
 	// nil means all fields are visible.
 	PublicFields []string
+
+	// StatementTimeout overrides Query.StatementTimeout for this collection.
+	StatementTimeout time.Duration
+
+	// RequestTimeout overrides Query.RequestTimeout for this collection,
+	// giving slow collections a budget of their own.
+	RequestTimeout time.Duration
+
+	// DefaultLimit overrides Query.DefaultLimit for this collection.
+	DefaultLimit int
+
+	// MaxLimit overrides Query.MaxLimit for this collection.
+	MaxLimit int
+
+	// History stores full previous versions of records on update and delete,
+	// exposed under /:collection/:id/revisions with a restore endpoint.
+	History bool
+
+	// Comments enables threaded comments on items under
+	// /:collection/:id/comments, stored in tugo_comments. Reading and
+	// posting comments needs read access to the item.
+	Comments bool
+
+	// Favorites lets users star items with POST /:collection/:id/favorite,
+	// stored in tugo_favorites and listed by GET /me/favorites. Starring
+	// needs read access to the item.
+	Favorites bool
+
+	// MaxOffset overrides Query.MaxOffset for this collection.
+	MaxOffset int
+
+	// MaxExpand overrides Query.MaxExpand for this collection.
+	MaxExpand int
+
+	// SearchFields lists the fields with an index suited to pattern matching,
+	// such as pg_trgm. When set, like filters on other fields are rejected.
+	SearchFields []string
+
+	// DefaultFilter filters every list of the collection, mapping "field"
+	// or "field:op" to a value as in filter[field:op]=value, such as
+	// {"status:ne": "archived"}. A list filtering a field itself, or
+	// using a saved view that does, replaces the defaults of that field.
+	// Init fails when the filters do not fit the schema.
+	DefaultFilter map[string]string
+
+	// DefaultSort orders lists that give no sort, as in ?sort=-created_at.
+	// Init fails when it does not fit the schema.
+	DefaultSort string
+
+	// Aliases renames columns in the API, such as {"usr_nm": "username"}.
+	// Items are read and written under the alias, and filter[...], sort
+	// and fields accept it. Other settings name columns.
+	Aliases map[string]string
+
+	// MaxBodyBytes overrides Query.MaxBodyBytes for this collection.
+	MaxBodyBytes int64
+
+	// AutoFields overrides Config.AutoFields for this collection; an empty
+	// value turns auto-filling off.
+	AutoFields *schema.AutoFields
+
+	// ImmutableFields lists fields that are set on create and cannot be
+	// changed afterwards, such as a slug or tenant ID. Updates that change
+	// them fail validation regardless of the caller's permissions.
+	ImmutableFields []string
+
+	// Slugs maps slug fields to the fields they are generated from, such as
+	// {"slug": "title"}. On create a missing slug is built from its source
+	// and suffixed with -2, -3 and so on until no other item uses it.
+	Slugs map[string]string
+
+	// Embeddings maps pgvector fields to the text fields they embed, such
+	// as {"embedding": "body"}. When Config.Embedder is set, writes that
+	// change the text field recompute the vector unless it is given.
+	Embeddings map[string]string
+
+	// IDStrategy generates the primary key on create when the client sends
+	// none: "uuidv7", "ulid", "ksuid" or "snowflake". It applies only to a
+	// single primary key column without a database default whose type
+	// holds the IDs, such as uuid or text for UUIDv7 and bigint for
+	// snowflake IDs. The schema API reports it as id_generation.
+	IDStrategy string
+
+	// Notifications send templated email when items are created, updated
+	// or deleted. They require Config.Notify.Mailer.
+	Notifications []notify.Rule
+
+	// Retention deletes items whose timestamp field is older than a maximum
+	// age, such as {Field: "created_at", MaxAge: 90 * 24 * time.Hour}, on
+	// the Config.Retention schedule.
+	Retention *retention.Rule
+
+	// StrictParams overrides Query.StrictParams for this collection.
+	StrictParams *bool
+
+	// Translations lists fields whose values are also stored per locale in
+	// a <table>_translations table with item_id and locale columns. Reads
+	// with ?locale= or Accept-Language return the translated values, and
+	// updates with ?locale= write them.
+	Translations []string
+
+	// Money marks decimal or integer fields as amounts of money, with a
+	// fixed currency or a currency field, such as {"price": {Currency:
+	// "USD"}}. They are read and written as {"amount": "12.50",
+	// "currency": "USD"} and filtered in minor units.
+	Money map[string]schema.Money
+
+	// DuplicateMatch lists the fields POST /:collection/find-duplicates
+	// compares with a candidate item, exactly, case-insensitively or by
+	// trigram similarity, such as {Field: "name", Mode: "similar"}.
+	DuplicateMatch []schema.DuplicateMatch
+
+	// Public lists the actions of the collection allowed without
+	// authentication when auth is enabled: "read", "create", "update" or
+	// "delete". Authenticated requests still carry their user, and
+	// permission.Middleware lets anonymous ones through. It applies to
+	// routes mounted with MountWithAuth.
+	Public []string
+
+	// Approval stores creates and updates from roles other than admin and
+	// its BypassRoles as pending changes in tugo_pending_changes, such as
+	// {BypassRoles: []string{"editor"}}. Reviewers approve or reject them
+	// at /admin/approvals; approved changes are applied then.
+	Approval *schema.Approval
+
+	// Lifecycle moves items through states such as draft → review →
+	// published. The status field changes only through POST
+	// /:collection/:id/transition, limited per transition to Roles, and
+	// anonymous readers of public collections see published items only.
+	// Use &schema.Lifecycle{} for schema.DefaultLifecycle.
+	Lifecycle *schema.Lifecycle
+}
+
+// QueryConfig configures collection query execution.
+type QueryConfig struct {
+	// StatementTimeout aborts collection queries running longer than this.
+	// PostgreSQL applies it with SET LOCAL statement_timeout; other
+	// databases use a context deadline. Timeouts are reported as 504.
+	// Default: 0 (no timeout)
+	StatementTimeout time.Duration
+
+	// RequestTimeout is the deadline of each collection request, covering
+	// every query it runs. Queries still running are canceled, and the
+	// request fails with 504 REQUEST_TIMEOUT.
+	// Default: 0 (no deadline)
+	RequestTimeout time.Duration
+
+	// DefaultLimit is the page size when a request omits ?limit.
+	// Default: 20
+	DefaultLimit int
+
+	// MaxLimit caps the ?limit a client may request.
+	// Default: 100
+	MaxLimit int
+
+	// MaxOffset rejects list pages starting beyond this many rows, as large
+	// offsets scan every skipped row.
+	// Default: 0 (unlimited)
+	MaxOffset int
+
+	// MaxExpand caps the number of relations a request may expand.
+	// Default: 0 (unlimited)
+	MaxExpand int
+
+	// SlowQueryThreshold logs list queries running longer than this together
+	// with their EXPLAIN plan and request parameters.
+	// Default: 0 (disabled)
+	SlowQueryThreshold time.Duration
+
+	// ExpandStrategy selects how list requests expand to-one relations:
+	// "query" runs one extra query per relation, "join" embeds related rows
+	// in the list query as JSON, and "auto" joins for pages of up to
+	// ExpandJoinMaxRows rows.
+	// Default: "query"
+	ExpandStrategy string
+
+	// ExpandJoinMaxRows is the largest page the "auto" strategy joins for.
+	// Default: 100
+	ExpandJoinMaxRows int
+
+	// MaxExportRows caps the rows returned by GET /:collection/export.
+	// Default: 10000
+	MaxExportRows int
+
+	// CopyThreshold makes batch creates of more than this many rows use COPY
+	// on PostgreSQL instead of multi-row INSERTs. A negative value disables COPY.
+	// Default: 500
+	CopyThreshold int
+
+	// MaxBatchItems caps the items of one batch create.
+	// Default: 10000
+	MaxBatchItems int
+
+	// MaxAffected caps the items one update by filter affects
+	// unless the request confirms their number with confirm_count.
+	// Default: 1000
+	MaxAffected int
+
+	// MaxBodyBytes caps collection request bodies; larger ones are rejected
+	// with 413. A negative value removes the limit.
+	// Default: 10MB
+	MaxBodyBytes int64
+
+	// DecimalsAsNumbers writes numeric/decimal fields as JSON numbers with
+	// their exact digits instead of strings. Clients must then parse them
+	// without rounding through floating point.
+	// Default: false (strings)
+	DecimalsAsNumbers bool
+
+	// StrictParams rejects list and export requests with unknown query
+	// parameters or malformed filter[...] keys with 400, listing the valid
+	// ones, instead of ignoring them.
+	// Default: false
+	StrictParams bool
+
+	// TokenSecret signs the query tokens of POST /:collection/query-token.
+	// Instances behind a load balancer need the same secret. If empty, each
+	// instance uses a random one and tokens stop working on restart.
+	TokenSecret string
+
+	// TokenTTL is how long query tokens are valid.
+	// Default: 0 (no expiry)
+	TokenTTL time.Duration
+}
+
+// RPCConfig configures database function endpoints.
+type RPCConfig struct {
+	// Enabled exposes functions and procedures matching Prefix.
+	// Requires PostgreSQL.
+	Enabled bool
+
+	// Prefix selects the functions to expose; it is stripped from the route name.
+	// Default: "api_fn_"
+	Prefix string
+}
+
+// GRPCConfig configures the gRPC API, a generic Query/Mutate service
+// described in pkg/grpcapi/tugo.proto.
+type GRPCConfig struct {
+	// Addr is the address the gRPC server listens on, such as ":9090".
+	// Empty disables the gRPC API.
+	Addr string
+
+	// Permissions checks calls against the collection policies, as
+	// permission.Middleware does for REST routes. Nil skips the checks.
+	Permissions *permission.Checker
+
+	// Options configure the server, such as TLS credentials or interceptors.
+	Options []grpc.ServerOption
+}
+
+// PermissionsConfig configures permission enforcement.
+type PermissionsConfig struct {
+	// Mode selects where permissions are enforced: "app" applies them in the
+	// permission middleware, "rls" passes the user to PostgreSQL and relies on
+	// row-level security policies. RLS mode requires PostgreSQL.
+	// Default: "app"
+	Mode string
+
+	// RLS configures the database role and session variables in RLS mode.
+	RLS permission.RLSConfig
+
+	// Checker checks the collections a request touches besides the one of
+	// its route, such as the children a duplicate copies, against their
+	// policies. Give it the checker passed to permission.Middleware.
+	// Default: Favorites.Permissions
+	Checker *permission.Checker
+}
+
+// ResilienceConfig configures how collection queries handle database failures.
+type ResilienceConfig struct {
+	// Retry retries serialization failures, deadlocks and failed connection
+	// attempts with jittered exponential backoff.
+	Retry collection.RetryConfig
+
+	// Breaker fails requests fast with 503 after repeated connection
+	// failures, until a probe reaches the database again.
+	Breaker collection.BreakerConfig
 }
 
 // AuthConfig configures authentication.
 type AuthConfig struct {
-	// Methods lists enabled authentication methods: "jwt", "cookie", "totp".
+	// Methods lists enabled authentication methods: "jwt", "cookie",
+	// "totp", "hmac".
 	Methods []string
 
 	// JWT configures JWT authentication.
@@ -86,6 +548,11 @@ type AuthConfig struct {
 	// TOTP configures time-based one-time passwords.
 	TOTP TOTPConfig
 
+	// Signatures configures HMAC-signed requests, enabled by the "hmac"
+	// method, for server-to-server clients that should not hold bearer
+	// tokens. Nonces default to Config.Cache when set.
+	Signatures auth.SignatureConfig
+
 	// CustomUserStore allows injecting a custom UserStore implementation.
 	// If provided, TuGo will use this instead of the default DBUserStore.
 	// This enables apps to use custom user tables and add business logic.
@@ -131,6 +598,25 @@ type AuthConfig struct {
 	//	})
 	//
 	CustomUserStore any // Must implement auth.UserStore interface
+
+	// ClaimColumns lists columns of tugo_users added to tokens as custom
+	// claims, such as "department_id". Policies read them with filter
+	// variables like $CLAIM.department_id.
+	ClaimColumns []string
+
+	// ClaimsEnricher returns further custom claims for a user, overriding
+	// ClaimColumns.
+	ClaimsEnricher auth.ClaimsEnricher
+
+	// Account configures PATCH /auth/me. Email change confirmations are
+	// mailed with Notify.Mailer unless Account.Mailer is set, and pending
+	// changes are stored in tugo_email_changes.
+	Account auth.AccountConfig
+
+	// SessionStore stores cookie sessions, for example
+	// auth.NewRedisSessionStore(client) to keep lookups off the database.
+	// Default: Config.Cache when set, else the tugo_sessions table
+	SessionStore auth.SessionStore
 }
 
 // JWTConfig configures JWT authentication.
@@ -183,37 +669,108 @@ type TOTPConfig struct {
 	Digits int
 }
 
+// NotifyConfig configures email notifications.
+type NotifyConfig struct {
+	// Mailer delivers messages, such as notify.NewSMTPMailer,
+	// notify.NewSESMailer or notify.NewSendGridMailer. Nil disables email.
+	Mailer notify.Mailer
+
+	// From is the sender address of every message.
+	From string
+
+	// TemplateDir reads templates from files in a directory instead of the
+	// tugo_templates table. See notify.DirStore for the file layout.
+	TemplateDir string
+
+	// Templates overrides where templates are read from.
+	Templates notify.TemplateStore
+}
+
+// MigrationsConfig configures internal migrations.
+type MigrationsConfig struct {
+	// LockTimeout bounds the wait while another instance holds the
+	// migration lock, after which Init fails.
+	// Default: 1 minute
+	LockTimeout time.Duration
+
+	// StrictChecksums fails Init when an applied migration's file has
+	// changed, instead of logging a warning.
+	StrictChecksums bool
+}
+
+// AdminConfig configures schema changes made through the admin API.
+// Dropping a collection or field needs a confirmation token from a first
+// request, and copies the data to a tugo_snapshot_ table beforehand.
+type AdminConfig struct {
+	// DisableDestructive rejects dropping collections and fields.
+	DisableDestructive bool
+
+	// ConfirmationTTL is how long a drop confirmation token is valid.
+	// Default: 5 minutes
+	ConfirmationTTL time.Duration
+
+	// ConfirmationSecret signs confirmation tokens. Instances behind a load
+	// balancer need the same secret. If empty, each instance uses a random
+	// one.
+	ConfirmationSecret string
+
+	// DisableSnapshots drops tables and columns without copying them first.
+	DisableSnapshots bool
+}
+
+// RetentionConfig configures when retention rules run.
+type RetentionConfig struct {
+	// Interval is the time between scheduled runs.
+	// Default: 1 hour
+	Interval time.Duration
+
+	// DryRun makes scheduled runs log the rows they would delete instead
+	// of deleting them.
+	DryRun bool
+}
+
+// LifecycleConfig configures when scheduled items are published.
+type LifecycleConfig struct {
+	// PublishInterval is the time between checks for items due to be
+	// published.
+	// Default: 1 minute
+	PublishInterval time.Duration
+}
+
+// FavoritesConfig configures the listing of users' favorites.
+type FavoritesConfig struct {
+	// Permissions checks the items GET /me/favorites lists against the
+	// collection read policies, as permission.Middleware does on collection
+	// routes. Nil lists every favorited item that still exists.
+	Permissions *permission.Checker
+}
+
 // StorageConfig configures file storage.
 type StorageConfig struct {
 	// Default is the default storage provider name.
+	// Default: the only configured provider, or "local".
 	Default string
 
 	// Providers maps names to storage provider implementations.
-	Providers map[string]StorageProvider
-}
+	// Use this to plug in custom backends implementing storage.Provider.
+	Providers map[string]storage.Provider
 
-// StorageProvider is the interface for file storage backends.
-type StorageProvider interface {
-	// Upload stores a file and returns the storage path.
-	Upload(ctx any, file any, filename string, opts *UploadOptions) (string, error)
+	// Local maps names to built-in local filesystem providers.
+	Local map[string]LocalStorageConfig
 
-	// Download retrieves a file by its storage path.
-	Download(ctx any, path string) (any, error)
-
-	// Delete removes a file by its storage path.
-	Delete(ctx any, path string) error
-
-	// GetURL returns a public URL for the file.
-	GetURL(path string) string
+	// MinIO maps names to built-in MinIO/S3-compatible providers.
+	MinIO map[string]storage.MinIOConfig
 }
 
-// UploadOptions provides options for file uploads.
-type UploadOptions struct {
-	// ContentType is the MIME type.
-	ContentType string
+// LocalStorageConfig configures a built-in local filesystem provider.
+type LocalStorageConfig struct {
+	// BasePath is the directory where files are stored.
+	// Default: "./uploads"
+	BasePath string
 
-	// MaxSize is the maximum file size in bytes.
-	MaxSize int64
+	// BaseURL is the public URL prefix for stored files.
+	// Default: "/api/v1/files"
+	BaseURL string
 }
 
 // ServerConfig configures the HTTP server for standalone mode.
@@ -242,6 +799,26 @@ type MountOptions struct {
 	// RequireAdminAuth requires admin role for admin routes.
 	// Default: true
 	RequireAdminAuth bool
+
+	// PerCollectionMiddleware maps collection names to middleware run on
+	// all their endpoints, such as a rate limiter for an expensive
+	// "reports" collection, without affecting other collections.
+	PerCollectionMiddleware map[string][]gin.HandlerFunc
+
+	// PerEndpointMiddleware maps collection names and endpoint names, such
+	// as collection.EndpointList, to middleware run after the collection's.
+	PerEndpointMiddleware map[string]map[string][]gin.HandlerFunc
+
+	// IPRule restricts the client addresses of all mounted routes.
+	IPRule ipfilter.Rule
+
+	// AdminIPRule restricts the client addresses of admin routes, such as
+	// to office and VPN ranges. It also applies to MountAdmin.
+	AdminIPRule ipfilter.Rule
+
+	// CollectionIPRules maps collection names to rules restricting their
+	// client addresses, checked before PerCollectionMiddleware.
+	CollectionIPRules map[string]ipfilter.Rule
 }
 
 // DefaultMountOptions returns default mount options.
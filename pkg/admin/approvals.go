@@ -0,0 +1,122 @@
+package admin
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// Approval listing page sizes.
+const (
+	DefaultApprovalLimit = 50
+	MaxApprovalLimit     = 500
+)
+
+// ReviewRequest is the optional body of the approve and reject endpoints.
+type ReviewRequest struct {
+	Note string `json:"note"`
+}
+
+// ApproveResponse is an approved change with the item it wrote.
+type ApproveResponse struct {
+	Change *collection.PendingChange `json:"change"`
+	Item   map[string]any            `json:"item"`
+}
+
+// SetApprovals enables the approval endpoints.
+func (h *Handler) SetApprovals(service *collection.Service) {
+	h.approvals = service
+}
+
+// ListApprovals handles GET /admin/approvals. It lists pending changes
+// oldest first, or those with ?status=approved or rejected, optionally of
+// one ?collection.
+func (h *Handler) ListApprovals(c *gin.Context) {
+	filter := collection.ChangeFilter{
+		Status:     c.DefaultQuery("status", collection.ChangeStatusPending),
+		Collection: c.Query("collection"),
+		Limit:      DefaultApprovalLimit,
+	}
+	switch filter.Status {
+	case collection.ChangeStatusPending, collection.ChangeStatusApproved, collection.ChangeStatusRejected:
+	default:
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("status must be pending, approved or rejected"))
+		return
+	}
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			h.writeError(c, apperror.ErrBadRequest.WithMessage("limit must be a positive integer"))
+			return
+		}
+		filter.Limit = min(n, MaxApprovalLimit)
+	}
+
+	changes, err := h.approvals.ListChanges(c.Request.Context(), filter)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(changes))
+}
+
+// GetApproval handles GET /admin/approvals/:id.
+func (h *Handler) GetApproval(c *gin.Context) {
+	change, err := h.approvals.GetChange(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(change))
+}
+
+// ApproveChange handles POST /admin/approvals/:id/approve, applying the
+// change.
+func (h *Handler) ApproveChange(c *gin.Context) {
+	req, ok := h.reviewRequest(c)
+	if !ok {
+		return
+	}
+
+	change, item, err := h.approvals.ApproveChange(c.Request.Context(), c.Param("id"), req.Note)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(ApproveResponse{Change: change, Item: item}))
+}
+
+// RejectChange handles POST /admin/approvals/:id/reject.
+func (h *Handler) RejectChange(c *gin.Context) {
+	req, ok := h.reviewRequest(c)
+	if !ok {
+		return
+	}
+
+	change, err := h.approvals.RejectChange(c.Request.Context(), c.Param("id"), req.Note)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(change))
+}
+
+// reviewRequest binds the optional body of a review.
+func (h *Handler) reviewRequest(c *gin.Context) (ReviewRequest, bool) {
+	var req ReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil && !errors.Is(err, io.EOF) {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body"))
+		return req, false
+	}
+	return req, true
+}
@@ -0,0 +1,31 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/cluster"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// ClusterStatus is the response of GET /admin/cluster.
+type ClusterStatus struct {
+	Node   string          `json:"node"`
+	Leases []cluster.Lease `json:"leases"`
+}
+
+// SetCluster enables the cluster endpoint.
+func (h *Handler) SetCluster(node *cluster.Node) {
+	h.cluster = node
+}
+
+// GetCluster handles GET /admin/cluster, reporting this instance's node ID
+// and the leases held by any instance.
+func (h *Handler) GetCluster(c *gin.Context) {
+	leases, err := h.cluster.Leases(c.Request.Context())
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(ClusterStatus{Node: h.cluster.ID(), Leases: leases}))
+}
@@ -0,0 +1,170 @@
+package admin
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// DefaultConfirmationTTL is how long a drop confirmation token is valid.
+const DefaultConfirmationTTL = 5 * time.Minute
+
+// SnapshotPrefix starts the names of the tables holding copies of dropped
+// tables and columns. It is outside the discovery prefix, so snapshots are
+// not served as collections.
+const SnapshotPrefix = "tugo_snapshot_"
+
+// maxIdentifierLength is PostgreSQL's limit, the lowest of the dialects.
+const maxIdentifierLength = 63
+
+// confirmer issues and checks the tokens confirming a drop. A token is
+// signed for the object it drops and carries its expiry, so any instance
+// sharing the secret accepts it.
+type confirmer struct {
+	key []byte
+	ttl time.Duration
+}
+
+// newConfirmer creates a confirmer, with a random key when secret is empty.
+func newConfirmer(secret string, ttl time.Duration) *confirmer {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	if ttl <= 0 {
+		ttl = DefaultConfirmationTTL
+	}
+	return &confirmer{key: key, ttl: ttl}
+}
+
+// issue returns a token confirming a drop of object and its expiry.
+func (c *confirmer) issue(object string, now time.Time) (string, time.Time) {
+	expires := now.Add(c.ttl).Truncate(time.Second)
+	exp := strconv.FormatInt(expires.Unix(), 10)
+	return exp + "." + c.sign(object, exp), expires
+}
+
+// valid reports whether token confirms a drop of object at now.
+func (c *confirmer) valid(token, object string, now time.Time) bool {
+	exp, sig, ok := strings.Cut(token, ".")
+	if !ok {
+		return false
+	}
+	unix, err := strconv.ParseInt(exp, 10, 64)
+	if err != nil || now.Unix() > unix {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(c.sign(object, exp)))
+}
+
+// sign returns the signature of object and an expiry.
+func (c *confirmer) sign(object, exp string) string {
+	mac := hmac.New(sha256.New, c.key)
+	mac.Write([]byte(object + "|" + exp))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// confirmDrop reports whether a drop of object may run, writing the error
+// response when it may not. Drops are refused when destructive changes are
+// disabled. Otherwise the confirm parameter must echo a token issued for
+// the same object by an earlier request; requests without a valid token get
+// a new one in a 428 response.
+func (h *Handler) confirmDrop(c *gin.Context, object string) bool {
+	if h.config.DisableDestructive {
+		response.JSON(c, http.StatusForbidden, response.FromAppError(
+			apperror.ErrForbidden.WithMessage("Destructive schema changes are disabled"),
+		))
+		return false
+	}
+
+	now := time.Now()
+	token := c.Query("confirm")
+	if token != "" && h.confirmations.valid(token, object, now) {
+		return true
+	}
+
+	message := "Repeat the request with confirm set to the token to drop " + object
+	if token != "" {
+		message = "Confirmation token is invalid or expired; repeat the request with the new token to drop " + object
+	}
+	issued, expires := h.confirmations.issue(object, now)
+	response.JSON(c, http.StatusPreconditionRequired, response.FromAppError(
+		apperror.ErrConfirmationRequired.WithMessage(message).WithDetails(gin.H{
+			"object":     object,
+			"token":      issued,
+			"expires_at": expires.UTC(),
+		}),
+	))
+	return false
+}
+
+// snapshot copies columns of table, or all of them when none are given, to
+// a new snapshot table and returns its name. It does nothing when snapshots
+// are disabled.
+func (h *Handler) snapshot(ctx context.Context, table string, columns ...string) (string, error) {
+	if h.config.DisableSnapshots {
+		return "", nil
+	}
+
+	quote := h.executor.dialect.QuoteIdent
+	name := snapshotName(table, columns, time.Now())
+	selected := "*"
+	if len(columns) > 0 {
+		quoted := make([]string, len(columns))
+		for i, column := range columns {
+			quoted[i] = quote(column)
+		}
+		selected = strings.Join(quoted, ", ")
+	}
+	sql := fmt.Sprintf("CREATE TABLE %s AS SELECT %s FROM %s", quote(name), selected, quote(table))
+	if err := h.executor.Execute(ctx, sql); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// dropSnapshot removes a snapshot taken for a drop that failed.
+func (h *Handler) dropSnapshot(ctx context.Context, name string) {
+	if name == "" {
+		return
+	}
+	if err := h.executor.Execute(ctx, "DROP TABLE "+h.executor.dialect.QuoteIdent(name)); err != nil {
+		requestlog.Logger(ctx, h.logger).Warnw("Failed to remove snapshot", "snapshot", name, "error", err)
+	}
+}
+
+// snapshotName returns the name of a snapshot of table taken at now. A
+// column snapshot is named after its last column.
+func snapshotName(table string, columns []string, now time.Time) string {
+	base := SnapshotPrefix + table
+	if len(columns) > 0 {
+		base += "_" + columns[len(columns)-1]
+	}
+	suffix := "_" + now.UTC().Format("20060102150405")
+	if len(base)+len(suffix) > maxIdentifierLength {
+		base = base[:maxIdentifierLength-len(suffix)]
+	}
+	return base + suffix
+}
+
+// snapshotColumns returns the columns kept when a field is dropped: the
+// field itself, after the primary key so rows can be matched up again.
+func snapshotColumns(primaryKey, field string) []string {
+	if primaryKey == "" || primaryKey == field {
+		return []string{field}
+	}
+	return []string{primaryKey, field}
+}
@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/migrate"
+)
+
+// AdminVersionPrefix starts the tugo_migrations version of schema changes
+// applied through the admin API, keeping them apart from file migrations.
+const AdminVersionPrefix = "admin_"
+
+// nonTransactional matches statements that cannot run inside a transaction.
+var nonTransactional = regexp.MustCompile(`(?i)\bCONCURRENTLY\b|^\s*VACUUM\b`)
+
+// SchemaExecutor executes schema modification SQL.
+type SchemaExecutor struct {
+	db      *sqlx.DB
+	dialect dialect.Dialect
+}
+
+// NewSchemaExecutor creates a new schema executor from sqlx.DB.
+func NewSchemaExecutor(db *sqlx.DB) *SchemaExecutor {
+	return &SchemaExecutor{db: db, dialect: dialect.ForDriver(db.DriverName())}
+}
+
+// Execute executes SQL statements in one transaction, so a failing statement
+// leaves the schema unchanged. Scripts with a statement that cannot run in a
+// transaction, such as CREATE INDEX CONCURRENTLY, and scripts on MySQL, where
+// DDL commits implicitly, run statement by statement instead.
+func (e *SchemaExecutor) Execute(ctx context.Context, sql string) error {
+	return e.execute(ctx, sql, nil)
+}
+
+// Apply executes a migration like Execute and records it in tugo_migrations,
+// in the same transaction when there is one.
+func (e *SchemaExecutor) Apply(ctx context.Context, m *Migration) error {
+	return e.execute(ctx, m.UpSQL, func(ctx context.Context, exec sqlx.ExecerContext, elapsed time.Duration) error {
+		query := e.db.Rebind(`
+			INSERT INTO tugo_migrations (version, name, checksum, execution_ms)
+			VALUES (?, ?, ?, ?)
+		`)
+		version := AdminVersionPrefix + time.Now().UTC().Format("20060102150405.000000")
+		if _, err := exec.ExecContext(ctx, query, version, m.Name, migrate.Checksum(m.UpSQL), elapsed.Milliseconds()); err != nil {
+			return fmt.Errorf("failed to record migration: %w", err)
+		}
+		return nil
+	})
+}
+
+// execute runs the statements of sql and then record, when it is set.
+func (e *SchemaExecutor) execute(ctx context.Context, sql string, record func(context.Context, sqlx.ExecerContext, time.Duration) error) error {
+	statements := migrate.SplitStatements(sql)
+	start := time.Now()
+
+	if !e.transactional(statements) {
+		for i, stmt := range statements {
+			if _, err := e.db.ExecContext(ctx, stmt); err != nil {
+				if i > 0 {
+					return fmt.Errorf("statement %d of %d failed, the earlier ones stay applied: %w", i+1, len(statements), err)
+				}
+				return err
+			}
+		}
+		if record != nil {
+			return record(ctx, e.db, time.Since(start))
+		}
+		return nil
+	}
+
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
+	if record != nil {
+		if err := record(ctx, tx, time.Since(start)); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// transactional reports whether statements can run in one transaction.
+func (e *SchemaExecutor) transactional(statements []string) bool {
+	if e.dialect.Name() == dialect.MySQL {
+		return false
+	}
+	for _, stmt := range statements {
+		if nonTransactional.MatchString(stmt) {
+			return false
+		}
+	}
+	return true
+}
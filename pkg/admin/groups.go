@@ -0,0 +1,256 @@
+package admin
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// GroupRequest is the body of POST and PATCH /admin/groups.
+type GroupRequest struct {
+	Name        *string `json:"name"`
+	Description *string `json:"description"`
+}
+
+// GroupMemberRequest is the body of POST /admin/groups/:id/members.
+type GroupMemberRequest struct {
+	UserID string `json:"user_id" binding:"required"`
+}
+
+// GroupPolicyRequest is the body of POST /admin/groups/:id/permissions,
+// setting the group's policy for a collection ("*" for all) and action.
+type GroupPolicyRequest struct {
+	Collection       string          `json:"collection" binding:"required"`
+	Action           string          `json:"action" binding:"required"`
+	Filter           json.RawMessage `json:"filter"`
+	FieldPermissions json.RawMessage `json:"field_permissions"`
+	Validation       json.RawMessage `json:"validation"`
+	Presets          json.RawMessage `json:"presets"`
+}
+
+// ListGroups handles GET /admin/groups.
+func (h *Handler) ListGroups(c *gin.Context) {
+	groups, err := h.permissions.Groups().List(c.Request.Context())
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(groups))
+}
+
+// GetGroup handles GET /admin/groups/:id.
+func (h *Handler) GetGroup(c *gin.Context) {
+	group, err := h.permissions.Groups().Get(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(group))
+}
+
+// CreateGroup handles POST /admin/groups.
+func (h *Handler) CreateGroup(c *gin.Context) {
+	var req GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.Name == nil || strings.TrimSpace(*req.Name) == "" {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body: name is required"))
+		return
+	}
+
+	group := &permission.Group{Name: strings.TrimSpace(*req.Name)}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if err := h.permissions.Groups().Create(c.Request.Context(), group); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(group))
+}
+
+// UpdateGroup handles PATCH /admin/groups/:id.
+func (h *Handler) UpdateGroup(c *gin.Context) {
+	var req GroupRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	group, err := h.permissions.Groups().Get(ctx, c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	if req.Name != nil {
+		if strings.TrimSpace(*req.Name) == "" {
+			h.writeError(c, apperror.ErrBadRequest.WithMessage("Name cannot be empty"))
+			return
+		}
+		group.Name = strings.TrimSpace(*req.Name)
+	}
+	if req.Description != nil {
+		group.Description = *req.Description
+	}
+	if err := h.permissions.Groups().Update(ctx, group); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(group))
+}
+
+// DeleteGroup handles DELETE /admin/groups/:id, removing the group's
+// memberships and policies with it.
+func (h *Handler) DeleteGroup(c *gin.Context) {
+	if err := h.permissions.Groups().Delete(c.Request.Context(), c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"id":      c.Param("id"),
+		"deleted": true,
+	}))
+}
+
+// ListGroupMembers handles GET /admin/groups/:id/members.
+func (h *Handler) ListGroupMembers(c *gin.Context) {
+	ctx := c.Request.Context()
+	if _, err := h.permissions.Groups().Get(ctx, c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	members, err := h.permissions.Groups().Members(ctx, c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(members))
+}
+
+// AddGroupMember handles POST /admin/groups/:id/members.
+func (h *Handler) AddGroupMember(c *gin.Context) {
+	var req GroupMemberRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body: user_id is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	if h.users != nil {
+		if _, err := h.users.GetByID(ctx, req.UserID); err != nil {
+			h.writeError(c, err)
+			return
+		}
+	}
+
+	member, err := h.permissions.Groups().AddMember(ctx, c.Param("id"), req.UserID)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(member))
+}
+
+// RemoveGroupMember handles DELETE /admin/groups/:id/members/:user_id.
+func (h *Handler) RemoveGroupMember(c *gin.Context) {
+	if err := h.permissions.Groups().RemoveMember(c.Request.Context(), c.Param("id"), c.Param("user_id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"group_id": c.Param("id"),
+		"user_id":  c.Param("user_id"),
+		"deleted":  true,
+	}))
+}
+
+// ListGroupPolicies handles GET /admin/groups/:id/permissions.
+func (h *Handler) ListGroupPolicies(c *gin.Context) {
+	ctx := c.Request.Context()
+	if _, err := h.permissions.Groups().Get(ctx, c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	policies, err := h.permissions.Groups().Policies(ctx, c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(policies))
+}
+
+// SetGroupPolicy handles POST /admin/groups/:id/permissions, creating or
+// replacing the group's policy for a collection and action.
+func (h *Handler) SetGroupPolicy(c *gin.Context) {
+	var req GroupPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body: collection and action are required"))
+		return
+	}
+
+	action := permission.Action(req.Action)
+	switch action {
+	case permission.ActionCreate, permission.ActionRead, permission.ActionUpdate, permission.ActionDelete:
+	default:
+		h.writeError(c, apperror.ErrBadRequest.WithMessagef("Invalid action '%s'", req.Action))
+		return
+	}
+
+	policy := &permission.Policy{
+		GroupID:          c.Param("id"),
+		Collection:       req.Collection,
+		Action:           action,
+		Filter:           nullRaw(req.Filter),
+		FieldPermissions: nullRaw(req.FieldPermissions),
+		Validation:       nullRaw(req.Validation),
+		Presets:          nullRaw(req.Presets),
+	}
+	if _, err := permission.ParsePolicy(policy); err != nil {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid policy: "+err.Error()))
+		return
+	}
+
+	if err := h.permissions.Groups().SetPolicy(c.Request.Context(), policy); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(policy))
+}
+
+// DeleteGroupPolicy handles DELETE /admin/groups/:id/permissions/:permission_id.
+func (h *Handler) DeleteGroupPolicy(c *gin.Context) {
+	if err := h.permissions.Groups().DeletePolicy(c.Request.Context(), c.Param("id"), c.Param("permission_id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"id":      c.Param("permission_id"),
+		"deleted": true,
+	}))
+}
+
+// nullRaw drops JSON null, leaving the policy field unset.
+func nullRaw(data json.RawMessage) json.RawMessage {
+	if string(data) == "null" {
+		return nil
+	}
+	return data
+}
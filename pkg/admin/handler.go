@@ -4,13 +4,26 @@ import (
 	"context"
 	"net/http"
 	"strings"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/jmoiron/sqlx"
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/cluster"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/jobs"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/quota"
+	"github.com/thienel/tugo/pkg/requestlog"
 	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/retention"
 	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/snapshot"
+	"github.com/thienel/tugo/pkg/storedquery"
+	"github.com/thienel/tugo/pkg/usage"
 	"github.com/thienel/tugo/pkg/validation"
+	"github.com/thienel/tugo/pkg/webhook"
 	"go.uber.org/zap"
 )
 
@@ -19,6 +32,27 @@ type Handler struct {
 	schemaManager *schema.Manager
 	executor      *SchemaExecutor
 	migrationGen  *MigrationGenerator
+	views         *collection.Service
+	approvals     *collection.Service
+	queries       *storedquery.Service
+	webhooks      *webhook.Dispatcher
+	rls           *permission.RLS
+	permissions   *permission.Checker
+	users         auth.UserStore
+	rlsDB         *sqlx.DB
+	statsDB       *sqlx.DB
+	usage         *usage.Tracker
+	cluster       *cluster.Node
+	jobs          *jobs.Runner
+	locks         *collection.Locks
+	quotas        *quota.Meter
+	sessions      auth.SessionStore
+	retention     *retention.Enforcer
+	snapshots     *snapshot.Service
+	meta          *schema.MetaStore
+	reload        func(ctx context.Context) ([]string, error)
+	refresh       func(ctx context.Context) error
+	confirmations *confirmer
 	logger        *zap.SugaredLogger
 	config        HandlerConfig
 }
@@ -35,14 +69,30 @@ type HandlerConfig struct {
 
 	// TablePrefix is the prefix for new tables.
 	TablePrefix string
+
+	// DisableDestructive rejects dropping collections and fields.
+	DisableDestructive bool
+
+	// ConfirmationTTL is how long a drop confirmation token is valid.
+	// Default: 5 minutes
+	ConfirmationTTL time.Duration
+
+	// ConfirmationSecret signs confirmation tokens. Instances behind a load
+	// balancer need the same secret. If empty, a random one is used.
+	ConfirmationSecret string
+
+	// DisableSnapshots drops tables and columns without first copying them
+	// to a tugo_snapshot_ table.
+	DisableSnapshots bool
 }
 
 // DefaultHandlerConfig returns default handler configuration.
 func DefaultHandlerConfig() HandlerConfig {
 	return HandlerConfig{
-		MigrationsDir: "",
-		AutoExecute:   true,
-		TablePrefix:   "api_",
+		MigrationsDir:   "",
+		AutoExecute:     true,
+		TablePrefix:     "api_",
+		ConfirmationTTL: DefaultConfirmationTTL,
 	}
 }
 
@@ -57,6 +107,7 @@ func NewHandler(schemaManager *schema.Manager, executor *SchemaExecutor, logger
 		schemaManager: schemaManager,
 		executor:      executor,
 		migrationGen:  migrationGen,
+		confirmations: newConfirmer(config.ConfirmationSecret, config.ConfirmationTTL),
 		logger:        logger,
 		config:        config,
 	}
@@ -65,10 +116,12 @@ func NewHandler(schemaManager *schema.Manager, executor *SchemaExecutor, logger
 // ListCollections handles GET /admin/collections.
 func (h *Handler) ListCollections(c *gin.Context) {
 	collections := h.schemaManager.ListCollections()
+	metas := h.listMeta(c.Request.Context())
 
 	result := make([]CollectionInfo, 0, len(collections))
 	for _, col := range collections {
-		result = append(result, toCollectionInfo(col))
+		relations, _ := h.schemaManager.Relations(col.Name)
+		result = append(result, toCollectionInfo(col, relations, metas[col.Name]))
 	}
 
 	c.JSON(http.StatusOK, response.Success(result))
@@ -80,20 +133,37 @@ func (h *Handler) GetCollection(c *gin.Context) {
 
 	collection, err := h.schemaManager.GetCollection(name)
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
+			apperror.ErrCollectionNotFound.WithMessage("Collection not found: " + name),
+		))
+		return
+	}
+
+	relations, _ := h.schemaManager.Relations(name)
+	metas := h.listMeta(c.Request.Context())
+	c.JSON(http.StatusOK, response.Success(toCollectionInfo(collection, relations, metas[name])))
+}
+
+// GetRelations handles GET /admin/collections/:name/relations.
+func (h *Handler) GetRelations(c *gin.Context) {
+	name := c.Param("name")
+
+	relations, err := h.schemaManager.Relations(name)
+	if err != nil {
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrCollectionNotFound.WithMessage("Collection not found: " + name),
 		))
 		return
 	}
 
-	c.JSON(http.StatusOK, response.Success(toCollectionInfo(collection)))
+	c.JSON(http.StatusOK, response.Success(relations))
 }
 
 // CreateCollection handles POST /admin/collections.
 func (h *Handler) CreateCollection(c *gin.Context) {
 	var req CreateCollectionRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
@@ -101,16 +171,16 @@ func (h *Handler) CreateCollection(c *gin.Context) {
 
 	// Validate collection name
 	if err := validation.ValidateCollectionName(req.Name); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrValidation.WithMessage(err.Error()),
 		))
 		return
 	}
 
-	// Validate field names
+	// Validate field names and references
 	for _, field := range req.Fields {
-		if err := validation.ValidateFieldName(field.Name); err != nil {
-			c.JSON(http.StatusBadRequest, response.FromAppError(
+		if err := validateFieldDef(field); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
 				apperror.ErrValidation.WithMessage(err.Error()),
 			))
 			return
@@ -130,8 +200,9 @@ func (h *Handler) CreateCollection(c *gin.Context) {
 		var err error
 		migration, err = h.migrationGen.GenerateCreateTable(req)
 		if err != nil {
-			h.logger.Errorw("Failed to generate migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to generate migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to generate migration"),
 			))
 			return
@@ -140,30 +211,23 @@ func (h *Handler) CreateCollection(c *gin.Context) {
 
 	// Execute if auto-execute is enabled
 	if h.config.AutoExecute && h.executor != nil {
-		sql := ""
-		if migration != nil {
-			sql = migration.UpSQL
-		} else if h.migrationGen != nil {
-			m, _ := h.migrationGen.GenerateCreateTable(req)
-			sql = m.UpSQL
-		} else {
-			// Generate SQL directly
-			m := &MigrationGenerator{}
-			mm, _ := m.GenerateCreateTable(req)
-			sql = mm.UpSQL
-		}
-
-		if err := h.executor.Execute(c.Request.Context(), sql); err != nil {
-			h.logger.Errorw("Failed to execute migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+		if migration == nil {
+			gen := &MigrationGenerator{}
+			migration, _ = gen.GenerateCreateTable(req)
+		}
+
+		if err := h.executor.Apply(c.Request.Context(), migration); err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to execute migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to create table: " + err.Error()),
 			))
 			return
 		}
 
 		// Refresh schema
-		if err := h.schemaManager.Refresh(c.Request.Context()); err != nil {
-			h.logger.Warnw("Failed to refresh schema after create", "error", err)
+		if err := h.refreshSchema(c.Request.Context()); err != nil {
+			requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to refresh schema after create", "error", err)
 		}
 	}
 
@@ -188,15 +252,15 @@ func (h *Handler) AddField(c *gin.Context) {
 
 	var req AddFieldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
 	}
 
-	// Validate field name
-	if err := validation.ValidateFieldName(req.Field.Name); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+	// Validate field name and reference
+	if err := validateFieldDef(req.Field); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrValidation.WithMessage(err.Error()),
 		))
 		return
@@ -205,7 +269,7 @@ func (h *Handler) AddField(c *gin.Context) {
 	// Check collection exists
 	collection, err := h.schemaManager.GetCollection(collectionName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrCollectionNotFound.WithMessage("Collection not found"),
 		))
 		return
@@ -216,8 +280,9 @@ func (h *Handler) AddField(c *gin.Context) {
 	if h.migrationGen != nil {
 		migration, err = h.migrationGen.GenerateAddColumn(collection.TableName, req.Field)
 		if err != nil {
-			h.logger.Errorw("Failed to generate migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to generate migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to generate migration"),
 			))
 			return
@@ -226,26 +291,23 @@ func (h *Handler) AddField(c *gin.Context) {
 
 	// Execute if auto-execute is enabled
 	if h.config.AutoExecute && h.executor != nil {
-		sql := ""
-		if migration != nil {
-			sql = migration.UpSQL
-		} else {
-			m := &MigrationGenerator{}
-			mm, _ := m.GenerateAddColumn(collection.TableName, req.Field)
-			sql = mm.UpSQL
-		}
-
-		if err := h.executor.Execute(c.Request.Context(), sql); err != nil {
-			h.logger.Errorw("Failed to execute migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+		if migration == nil {
+			gen := &MigrationGenerator{}
+			migration, _ = gen.GenerateAddColumn(collection.TableName, req.Field)
+		}
+
+		if err := h.executor.Apply(c.Request.Context(), migration); err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to execute migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to add field: " + err.Error()),
 			))
 			return
 		}
 
 		// Refresh schema
-		if err := h.schemaManager.Refresh(c.Request.Context()); err != nil {
-			h.logger.Warnw("Failed to refresh schema after add field", "error", err)
+		if err := h.refreshSchema(c.Request.Context()); err != nil {
+			requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to refresh schema after add field", "error", err)
 		}
 	}
 
@@ -271,28 +333,45 @@ func (h *Handler) AlterField(c *gin.Context) {
 
 	var req AlterFieldRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
 	}
 
-	// Check collection exists
+	// Validate new name
+	if req.NewName != nil {
+		if err := validation.ValidateFieldName(*req.NewName); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrValidation.WithMessage(err.Error()),
+			))
+			return
+		}
+	}
+
+	// Check collection and field exist
 	collection, err := h.schemaManager.GetCollection(collectionName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrCollectionNotFound.WithMessage("Collection not found"),
 		))
 		return
 	}
+	if !hasField(collection, fieldName) {
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
+			apperror.ErrNotFound.WithMessage("Field not found: " + fieldName),
+		))
+		return
+	}
 
 	// Generate migration if configured
 	var migration *Migration
 	if h.migrationGen != nil {
 		migration, err = h.migrationGen.GenerateAlterColumn(collection.TableName, fieldName, req)
 		if err != nil {
-			h.logger.Errorw("Failed to generate migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to generate migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to generate migration"),
 			))
 			return
@@ -301,26 +380,26 @@ func (h *Handler) AlterField(c *gin.Context) {
 
 	// Execute if auto-execute is enabled
 	if h.config.AutoExecute && h.executor != nil {
-		sql := ""
-		if migration != nil {
-			sql = migration.UpSQL
-		} else {
-			m := &MigrationGenerator{}
-			mm, _ := m.GenerateAlterColumn(collection.TableName, fieldName, req)
-			sql = mm.UpSQL
-		}
-
-		if err := h.executor.Execute(c.Request.Context(), sql); err != nil {
-			h.logger.Errorw("Failed to execute migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+		if migration == nil {
+			gen := &MigrationGenerator{}
+			migration, _ = gen.GenerateAlterColumn(collection.TableName, fieldName, req)
+		}
+
+		if err := h.executor.Apply(c.Request.Context(), migration); err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to execute migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to alter field: " + err.Error()),
 			))
 			return
 		}
+		if req.NewName != nil {
+			h.renameMeta(c.Request.Context(), collectionName, fieldName, *req.NewName)
+		}
 
 		// Refresh schema
-		if err := h.schemaManager.Refresh(c.Request.Context()); err != nil {
-			h.logger.Warnw("Failed to refresh schema after alter field", "error", err)
+		if err := h.refreshSchema(c.Request.Context()); err != nil {
+			requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to refresh schema after alter field", "error", err)
 		}
 	}
 
@@ -344,22 +423,35 @@ func (h *Handler) DeleteField(c *gin.Context) {
 	collectionName := c.Param("name")
 	fieldName := c.Param("field")
 
-	// Check collection exists
+	// Check collection and field exist
 	collection, err := h.schemaManager.GetCollection(collectionName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrCollectionNotFound.WithMessage("Collection not found"),
 		))
 		return
 	}
+	if !hasField(collection, fieldName) {
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
+			apperror.ErrNotFound.WithMessage("Field not found: " + fieldName),
+		))
+		return
+	}
+
+	// Dropping needs confirmation
+	if h.config.AutoExecute && h.executor != nil && !h.confirmDrop(c, collection.TableName+"."+fieldName) {
+		return
+	}
 
 	// Generate migration if configured
 	var migration *Migration
+	var snapshot string
 	if h.migrationGen != nil {
 		migration, err = h.migrationGen.GenerateDropColumn(collection.TableName, fieldName)
 		if err != nil {
-			h.logger.Errorw("Failed to generate migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to generate migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to generate migration"),
 			))
 			return
@@ -368,26 +460,36 @@ func (h *Handler) DeleteField(c *gin.Context) {
 
 	// Execute if auto-execute is enabled
 	if h.config.AutoExecute && h.executor != nil {
-		sql := ""
-		if migration != nil {
-			sql = migration.UpSQL
-		} else {
-			m := &MigrationGenerator{}
-			mm, _ := m.GenerateDropColumn(collection.TableName, fieldName)
-			sql = mm.UpSQL
-		}
-
-		if err := h.executor.Execute(c.Request.Context(), sql); err != nil {
-			h.logger.Errorw("Failed to execute migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+		if migration == nil {
+			gen := &MigrationGenerator{}
+			migration, _ = gen.GenerateDropColumn(collection.TableName, fieldName)
+		}
+
+		// Keep a copy of the dropped data
+		snapshot, err = h.snapshot(c.Request.Context(), collection.TableName, snapshotColumns(collection.PrimaryKey, fieldName)...)
+		if err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to snapshot before drop", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
+				apperror.ErrInternalServer.WithMessage("Failed to snapshot field: " + err.Error()),
+			))
+			return
+		}
+
+		if err := h.executor.Apply(c.Request.Context(), migration); err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to execute migration", "error", err)
+			h.dropSnapshot(c.Request.Context(), snapshot)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to delete field: " + err.Error()),
 			))
 			return
 		}
+		h.forgetMeta(c.Request.Context(), collectionName, fieldName)
 
 		// Refresh schema
-		if err := h.schemaManager.Refresh(c.Request.Context()); err != nil {
-			h.logger.Warnw("Failed to refresh schema after delete field", "error", err)
+		if err := h.refreshSchema(c.Request.Context()); err != nil {
+			requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to refresh schema after delete field", "error", err)
 		}
 	}
 
@@ -395,6 +497,9 @@ func (h *Handler) DeleteField(c *gin.Context) {
 		"field":   fieldName,
 		"deleted": h.config.AutoExecute,
 	}
+	if snapshot != "" {
+		result["snapshot"] = snapshot
+	}
 	if migration != nil {
 		result["migration"] = gin.H{
 			"version":   migration.Version,
@@ -413,19 +518,26 @@ func (h *Handler) DeleteCollection(c *gin.Context) {
 	// Check collection exists
 	collection, err := h.schemaManager.GetCollection(collectionName)
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrCollectionNotFound.WithMessage("Collection not found"),
 		))
 		return
 	}
 
+	// Dropping needs confirmation
+	if h.config.AutoExecute && h.executor != nil && !h.confirmDrop(c, collection.TableName) {
+		return
+	}
+
 	// Generate migration if configured
 	var migration *Migration
+	var snapshot string
 	if h.migrationGen != nil {
 		migration, err = h.migrationGen.GenerateDropTable(collection.TableName)
 		if err != nil {
-			h.logger.Errorw("Failed to generate migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to generate migration", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to generate migration"),
 			))
 			return
@@ -434,26 +546,36 @@ func (h *Handler) DeleteCollection(c *gin.Context) {
 
 	// Execute if auto-execute is enabled
 	if h.config.AutoExecute && h.executor != nil {
-		sql := ""
-		if migration != nil {
-			sql = migration.UpSQL
-		} else {
-			m := &MigrationGenerator{}
-			mm, _ := m.GenerateDropTable(collection.TableName)
-			sql = mm.UpSQL
-		}
-
-		if err := h.executor.Execute(c.Request.Context(), sql); err != nil {
-			h.logger.Errorw("Failed to execute migration", "error", err)
-			c.JSON(http.StatusInternalServerError, response.FromAppError(
+		if migration == nil {
+			gen := &MigrationGenerator{}
+			migration, _ = gen.GenerateDropTable(collection.TableName)
+		}
+
+		// Keep a copy of the dropped data
+		snapshot, err = h.snapshot(c.Request.Context(), collection.TableName)
+		if err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to snapshot before drop", "error", err)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
+				apperror.ErrInternalServer.WithMessage("Failed to snapshot collection: " + err.Error()),
+			))
+			return
+		}
+
+		if err := h.executor.Apply(c.Request.Context(), migration); err != nil {
+			_ = c.Error(err)
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to execute migration", "error", err)
+			h.dropSnapshot(c.Request.Context(), snapshot)
+			response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 				apperror.ErrInternalServer.WithMessage("Failed to delete collection: " + err.Error()),
 			))
 			return
 		}
+		h.forgetMeta(c.Request.Context(), collectionName, "")
 
 		// Refresh schema
-		if err := h.schemaManager.Refresh(c.Request.Context()); err != nil {
-			h.logger.Warnw("Failed to refresh schema after delete collection", "error", err)
+		if err := h.refreshSchema(c.Request.Context()); err != nil {
+			requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to refresh schema after delete collection", "error", err)
 		}
 	}
 
@@ -461,6 +583,9 @@ func (h *Handler) DeleteCollection(c *gin.Context) {
 		"name":    collectionName,
 		"deleted": h.config.AutoExecute,
 	}
+	if snapshot != "" {
+		result["snapshot"] = snapshot
+	}
 	if migration != nil {
 		result["migration"] = gin.H{
 			"version":   migration.Version,
@@ -474,9 +599,10 @@ func (h *Handler) DeleteCollection(c *gin.Context) {
 
 // SyncSchema handles POST /admin/sync-schema.
 func (h *Handler) SyncSchema(c *gin.Context) {
-	if err := h.schemaManager.Refresh(c.Request.Context()); err != nil {
-		h.logger.Errorw("Failed to sync schema", "error", err)
-		c.JSON(http.StatusInternalServerError, response.FromAppError(
+	if err := h.refreshSchema(c.Request.Context()); err != nil {
+		_ = c.Error(err)
+		requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to sync schema", "error", err)
+		response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 			apperror.ErrInternalServer.WithMessage("Failed to sync schema"),
 		))
 		return
@@ -494,24 +620,139 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/collections", h.ListCollections)
 	rg.POST("/collections", h.CreateCollection)
 	rg.GET("/collections/:name", h.GetCollection)
+	rg.GET("/collections/:name/relations", h.GetRelations)
 	rg.DELETE("/collections/:name", h.DeleteCollection)
-	rg.POST("/collections/:name/fields", h.AddField)
-	rg.PATCH("/collections/:name/fields/:field", h.AlterField)
-	rg.DELETE("/collections/:name/fields/:field", h.DeleteField)
+	rg.POST("/collections/:name/fields", h.lockWrites(h.AddField))
+	rg.PATCH("/collections/:name/fields/:field", h.lockWrites(h.AlterField))
+	rg.DELETE("/collections/:name/fields/:field", h.lockWrites(h.DeleteField))
 	rg.POST("/sync-schema", h.SyncSchema)
+
+	if h.locks != nil {
+		rg.GET("/locks", h.ListLocks)
+		rg.POST("/collections/:name/lock", h.LockCollection)
+		rg.DELETE("/collections/:name/lock", h.UnlockCollection)
+	}
+
+	if h.statsDB != nil {
+		rg.GET("/collections/:name/stats", h.GetCollectionStats)
+		rg.GET("/db/health", h.GetDBHealth)
+	}
+
+	if h.meta != nil {
+		rg.GET("/collections/:name/meta", h.GetMeta)
+		rg.PUT("/collections/:name/meta", h.SetCollectionMeta)
+		rg.PUT("/collections/:name/fields/:field/meta", h.SetFieldMeta)
+	}
+
+	if h.views != nil {
+		rg.GET("/collections/:name/views", h.ListViews)
+		rg.POST("/collections/:name/views", h.CreateView)
+		rg.GET("/collections/:name/views/:view", h.GetView)
+		rg.PATCH("/collections/:name/views/:view", h.UpdateView)
+		rg.DELETE("/collections/:name/views/:view", h.DeleteView)
+	}
+
+	if h.approvals != nil {
+		rg.GET("/approvals", h.ListApprovals)
+		rg.GET("/approvals/:id", h.GetApproval)
+		rg.POST("/approvals/:id/approve", h.ApproveChange)
+		rg.POST("/approvals/:id/reject", h.RejectChange)
+	}
+
+	if h.queries != nil {
+		rg.GET("/queries", h.ListQueries)
+		rg.POST("/queries", h.CreateQuery)
+		rg.GET("/queries/:query", h.GetQuery)
+		rg.PATCH("/queries/:query", h.UpdateQuery)
+		rg.DELETE("/queries/:query", h.DeleteQuery)
+	}
+
+	if h.usage != nil {
+		rg.GET("/usage", h.GetUsage)
+	}
+
+	if h.cluster != nil {
+		rg.GET("/cluster", h.GetCluster)
+	}
+
+	if h.jobs != nil {
+		rg.GET("/jobs", h.ListJobs)
+		rg.POST("/jobs/:name/disable", h.DisableJob)
+		rg.POST("/jobs/:name/enable", h.EnableJob)
+	}
+
+	if h.quotas != nil {
+		rg.GET("/users/:id/usage", h.GetUserUsage)
+	}
+
+	if h.sessions != nil {
+		rg.GET("/users/:id/sessions", h.ListUserSessions)
+		rg.DELETE("/users/:id/sessions", h.DeleteUserSessions)
+	}
+
+	if h.retention != nil {
+		rg.GET("/retention", h.GetRetention)
+		rg.POST("/retention/run", h.RunRetention)
+		rg.GET("/retention/archives", h.ListArchives)
+		rg.POST("/retention/:collection/restore", h.RestoreArchive)
+	}
+
+	if h.snapshots != nil {
+		rg.POST("/collections/:name/snapshot", h.CreateSnapshot)
+		rg.GET("/collections/:name/snapshots", h.ListSnapshots)
+		rg.DELETE("/collections/:name/snapshots/:id", h.DeleteSnapshot)
+		rg.POST("/collections/:name/restore", h.RestoreSnapshot)
+	}
+
+	if h.webhooks != nil {
+		rg.GET("/webhooks", h.ListWebhooks)
+		rg.GET("/webhooks/:id/deliveries", h.ListDeliveries)
+		rg.POST("/webhooks/:id/deliveries/:delivery/redeliver", h.Redeliver)
+		rg.POST("/webhooks/:id/disable", h.DisableWebhook)
+		rg.POST("/webhooks/:id/enable", h.EnableWebhook)
+	}
+
+	if h.permissions != nil {
+		rg.POST("/permissions/simulate", h.SimulatePermissions)
+		rg.GET("/groups", h.ListGroups)
+		rg.POST("/groups", h.CreateGroup)
+		rg.GET("/groups/:id", h.GetGroup)
+		rg.PATCH("/groups/:id", h.UpdateGroup)
+		rg.DELETE("/groups/:id", h.DeleteGroup)
+		rg.GET("/groups/:id/members", h.ListGroupMembers)
+		rg.POST("/groups/:id/members", h.AddGroupMember)
+		rg.DELETE("/groups/:id/members/:user_id", h.RemoveGroupMember)
+		rg.GET("/groups/:id/permissions", h.ListGroupPolicies)
+		rg.POST("/groups/:id/permissions", h.SetGroupPolicy)
+		rg.DELETE("/groups/:id/permissions/:permission_id", h.DeleteGroupPolicy)
+	}
+
+	if h.rls != nil {
+		rg.GET("/rls/policies", h.GetRLSPolicies)
+		rg.POST("/rls/apply", h.ApplyRLSPolicies)
+	}
+
+	if h.reload != nil {
+		rg.POST("/config/reload", h.ReloadConfig)
+	}
 }
 
-// toCollectionInfo converts a schema.Collection to CollectionInfo.
-func toCollectionInfo(col *schema.Collection) CollectionInfo {
+// toCollectionInfo converts a schema.Collection, its relations and its
+// metadata, which may be nil, to CollectionInfo.
+func toCollectionInfo(col *schema.Collection, relations []schema.Relation, meta *schema.CollectionMeta) CollectionInfo {
 	fields := make([]FieldInfo, 0, len(col.Fields))
 	for _, f := range col.Fields {
 		var defaultVal *string
 		if f.DefaultValue != nil {
 			defaultVal = f.DefaultValue
 		}
+		dataType := f.DataType
+		if _, ok := col.Money[f.Name]; ok {
+			dataType = "money"
+		}
 		fields = append(fields, FieldInfo{
 			Name:         f.Name,
-			Type:         f.DataType,
+			Type:         dataType,
 			PostgresType: f.PostgresType,
 			Required:     !f.IsNullable,
 			Unique:       f.IsUnique,
@@ -519,44 +760,24 @@ func toCollectionInfo(col *schema.Collection) CollectionInfo {
 			Default:      defaultVal,
 			MaxLength:    f.MaxLength,
 		})
+		if meta != nil {
+			if fieldMeta, ok := meta.Fields[f.Name]; ok {
+				fields[len(fields)-1].Meta = &fieldMeta
+			}
+		}
 	}
 
-	return CollectionInfo{
-		Name:       col.Name,
-		TableName:  col.TableName,
-		Enabled:    col.Enabled,
-		Fields:     fields,
-		PrimaryKey: col.PrimaryKey,
+	info := CollectionInfo{
+		Name:         col.Name,
+		TableName:    col.TableName,
+		Enabled:      col.Enabled,
+		Fields:       fields,
+		PrimaryKey:   col.PrimaryKey,
+		IDGeneration: col.IDGeneration,
+		Relations:    relations,
 	}
-}
-
-// SchemaExecutor executes schema modification SQL.
-type SchemaExecutor struct {
-	db DBExecutor
-}
-
-// DBExecutor is the interface for executing SQL statements.
-type DBExecutor interface {
-	ExecContext(ctx context.Context, query string, args ...any) error
-}
-
-// sqlxDBWrapper wraps sqlx.DB to implement DBExecutor.
-type sqlxDBWrapper struct {
-	db *sqlx.DB
-}
-
-// ExecContext implements DBExecutor.
-func (w *sqlxDBWrapper) ExecContext(ctx context.Context, query string, args ...any) error {
-	_, err := w.db.ExecContext(ctx, query, args...)
-	return err
-}
-
-// NewSchemaExecutor creates a new schema executor from sqlx.DB.
-func NewSchemaExecutor(db *sqlx.DB) *SchemaExecutor {
-	return &SchemaExecutor{db: &sqlxDBWrapper{db: db}}
-}
-
-// Execute executes SQL statements.
-func (e *SchemaExecutor) Execute(ctx context.Context, sql string) error {
-	return e.db.ExecContext(ctx, sql)
+	if meta != nil {
+		info.Meta = &meta.Meta
+	}
+	return info
 }
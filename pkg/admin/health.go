@@ -0,0 +1,323 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// Database health thresholds.
+const (
+	// DefaultLongQuery is how long a statement runs before it is reported.
+	DefaultLongQuery = 30 * time.Second
+
+	// saturationWarning is the share of connections in use that is
+	// reported as a warning.
+	saturationWarning = 0.9
+
+	// replicationLagWarning is the replica lag reported as a warning.
+	replicationLagWarning = 30 * time.Second
+
+	// bloatWarning is the dead tuple ratio reported as a warning.
+	bloatWarning = 0.2
+
+	// maxHealthRows caps the queries, locks and tables listed.
+	maxHealthRows = 20
+)
+
+// DBHealth reports the state of the database for operators. Sections the
+// database cannot report are omitted.
+type DBHealth struct {
+	Dialect string `json:"dialect"`
+
+	// Status is "ok", or "degraded" when there are warnings.
+	Status   string   `json:"status"`
+	Warnings []string `json:"warnings"`
+
+	Pool        PoolHealth        `json:"pool"`
+	Server      *ServerHealth     `json:"server,omitempty"`
+	LongQueries []LongQuery       `json:"long_queries,omitempty"`
+	Locks       []BlockedLock     `json:"blocked_locks,omitempty"`
+	Replication *ReplicationState `json:"replication,omitempty"`
+	Bloat       []TableBloat      `json:"bloat,omitempty"`
+}
+
+// PoolHealth is the state of this instance's connection pool.
+type PoolHealth struct {
+	MaxOpen        int     `json:"max_open"`
+	Open           int     `json:"open"`
+	InUse          int     `json:"in_use"`
+	Idle           int     `json:"idle"`
+	WaitCount      int64   `json:"wait_count"`
+	WaitDurationMs float64 `json:"wait_duration_ms"`
+
+	// Saturation is InUse over MaxOpen, omitted when the pool is unbounded.
+	Saturation *float64 `json:"saturation,omitempty"`
+}
+
+// ServerHealth is the use of the server's connections by all clients.
+type ServerHealth struct {
+	MaxConnections  int     `db:"max_connections" json:"max_connections"`
+	UsedConnections int     `db:"used_connections" json:"used_connections"`
+	Saturation      float64 `db:"-" json:"saturation"`
+}
+
+// LongQuery is a statement running longer than the threshold.
+type LongQuery struct {
+	PID       int64   `db:"pid" json:"pid"`
+	Seconds   float64 `db:"seconds" json:"seconds"`
+	State     *string `db:"state" json:"state,omitempty"`
+	WaitEvent *string `db:"wait_event" json:"wait_event,omitempty"`
+	Query     string  `db:"query" json:"query"`
+}
+
+// BlockedLock is a session waiting for a lock another session holds.
+type BlockedLock struct {
+	BlockedPID    int64   `db:"blocked_pid" json:"blocked_pid"`
+	BlockingPID   int64   `db:"blocking_pid" json:"blocking_pid"`
+	WaitSeconds   float64 `db:"wait_seconds" json:"wait_seconds"`
+	BlockedQuery  string  `db:"blocked_query" json:"blocked_query"`
+	BlockingQuery string  `db:"blocking_query" json:"blocking_query"`
+}
+
+// ReplicationState reports replication as seen from this server: the lag
+// of its replicas on a primary, or its own lag on a replica.
+type ReplicationState struct {
+	Role       string       `json:"role"`
+	LagSeconds *float64     `json:"lag_seconds,omitempty"`
+	Replicas   []ReplicaLag `json:"replicas,omitempty"`
+}
+
+// ReplicaLag is a replica connected to the primary.
+type ReplicaLag struct {
+	Name       string   `db:"name" json:"name"`
+	State      string   `db:"state" json:"state"`
+	LagSeconds *float64 `db:"lag_seconds" json:"lag_seconds,omitempty"`
+}
+
+// TableBloat estimates the space held by a table's dead tuples.
+type TableBloat struct {
+	Table       string  `db:"table_name" json:"table"`
+	LiveTuples  int64   `db:"live_tuples" json:"live_tuples"`
+	DeadTuples  int64   `db:"dead_tuples" json:"dead_tuples"`
+	DeadRatio   float64 `db:"dead_ratio" json:"dead_ratio"`
+	WastedBytes int64   `db:"wasted_bytes" json:"wasted_bytes"`
+}
+
+// GetDBHealth handles GET /admin/db/health. ?long_query sets how long a
+// statement runs before it is listed, such as "10s".
+func (h *Handler) GetDBHealth(c *gin.Context) {
+	longQuery := DefaultLongQuery
+	if raw := c.Query("long_query"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("long_query must be a positive duration such as 10s"),
+			))
+			return
+		}
+		longQuery = d
+	}
+
+	health, err := dbHealth(c.Request.Context(), h.statsDB, longQuery)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(health))
+}
+
+// dbHealth gathers the diagnostics the database offers and derives
+// warnings from them.
+func dbHealth(ctx context.Context, db *sqlx.DB, longQuery time.Duration) (*DBHealth, error) {
+	d := dialect.ForDriver(db.DriverName())
+	health := &DBHealth{Dialect: d.Name(), Pool: poolHealth(db)}
+
+	var err error
+	switch d.Name() {
+	case dialect.Postgres:
+		err = postgresHealth(ctx, db, health, longQuery)
+	case dialect.MySQL:
+		err = mysqlHealth(ctx, db, health, longQuery)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	health.Warnings = healthWarnings(health, longQuery)
+	health.Status = "ok"
+	if len(health.Warnings) > 0 {
+		health.Status = "degraded"
+	}
+	return health, nil
+}
+
+// poolHealth reads the state of the connection pool.
+func poolHealth(db *sqlx.DB) PoolHealth {
+	stats := db.Stats()
+	pool := PoolHealth{
+		MaxOpen:        stats.MaxOpenConnections,
+		Open:           stats.OpenConnections,
+		InUse:          stats.InUse,
+		Idle:           stats.Idle,
+		WaitCount:      stats.WaitCount,
+		WaitDurationMs: float64(stats.WaitDuration.Microseconds()) / 1000,
+	}
+	if stats.MaxOpenConnections > 0 {
+		saturation := float64(stats.InUse) / float64(stats.MaxOpenConnections)
+		pool.Saturation = &saturation
+	}
+	return pool
+}
+
+// healthWarnings lists the problems a health report shows.
+func healthWarnings(health *DBHealth, longQuery time.Duration) []string {
+	warnings := make([]string, 0)
+	if s := health.Pool.Saturation; s != nil && *s >= saturationWarning {
+		warnings = append(warnings, fmt.Sprintf("Connection pool is %.0f%% in use", *s*100))
+	}
+	if health.Server != nil && health.Server.Saturation >= saturationWarning {
+		warnings = append(warnings, fmt.Sprintf("Server connections are %.0f%% in use", health.Server.Saturation*100))
+	}
+	if n := len(health.LongQueries); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d statements running longer than %s", n, longQuery))
+	}
+	if n := len(health.Locks); n > 0 {
+		warnings = append(warnings, fmt.Sprintf("%d sessions waiting for locks", n))
+	}
+	if r := health.Replication; r != nil {
+		if r.LagSeconds != nil && *r.LagSeconds >= replicationLagWarning.Seconds() {
+			warnings = append(warnings, fmt.Sprintf("Replica is %.0fs behind the primary", *r.LagSeconds))
+		}
+		for _, replica := range r.Replicas {
+			if replica.LagSeconds != nil && *replica.LagSeconds >= replicationLagWarning.Seconds() {
+				warnings = append(warnings, fmt.Sprintf("Replica %s is %.0fs behind", replica.Name, *replica.LagSeconds))
+			}
+		}
+	}
+	for _, table := range health.Bloat {
+		if table.DeadRatio >= bloatWarning {
+			warnings = append(warnings, fmt.Sprintf("Table %s is %.0f%% dead tuples", table.Table, table.DeadRatio*100))
+		}
+	}
+	return warnings
+}
+
+// postgresHealth reads connections, activity, locks and replication from
+// the pg_stat views, and bloat estimates from pg_stat_user_tables.
+func postgresHealth(ctx context.Context, db *sqlx.DB, health *DBHealth, longQuery time.Duration) error {
+	var server ServerHealth
+	query := `
+		SELECT current_setting('max_connections')::int AS max_connections,
+			(SELECT COUNT(*) FROM pg_stat_activity WHERE backend_type = 'client backend') AS used_connections
+	`
+	if err := db.GetContext(ctx, &server, query); err != nil {
+		return fmt.Errorf("failed to read connections: %w", err)
+	}
+	if server.MaxConnections > 0 {
+		server.Saturation = float64(server.UsedConnections) / float64(server.MaxConnections)
+	}
+	health.Server = &server
+
+	query = `
+		SELECT pid, EXTRACT(EPOCH FROM now() - query_start)::float8 AS seconds, state, wait_event, query
+		FROM pg_stat_activity
+		WHERE state <> 'idle' AND pid <> pg_backend_pid() AND query_start < now() - make_interval(secs => $1)
+		ORDER BY query_start
+		LIMIT $2
+	`
+	if err := db.SelectContext(ctx, &health.LongQueries, query, longQuery.Seconds(), maxHealthRows); err != nil {
+		return fmt.Errorf("failed to read running queries: %w", err)
+	}
+
+	query = `
+		SELECT blocked.pid AS blocked_pid, blocking.pid AS blocking_pid,
+			COALESCE(EXTRACT(EPOCH FROM now() - blocked.query_start), 0)::float8 AS wait_seconds,
+			blocked.query AS blocked_query, blocking.query AS blocking_query
+		FROM pg_stat_activity blocked
+		CROSS JOIN LATERAL unnest(pg_blocking_pids(blocked.pid)) AS b(pid)
+		JOIN pg_stat_activity blocking ON blocking.pid = b.pid
+		ORDER BY wait_seconds DESC
+		LIMIT $1
+	`
+	if err := db.SelectContext(ctx, &health.Locks, query, maxHealthRows); err != nil {
+		return fmt.Errorf("failed to read locks: %w", err)
+	}
+
+	replication := &ReplicationState{Role: "primary"}
+	var inRecovery bool
+	if err := db.GetContext(ctx, &inRecovery, "SELECT pg_is_in_recovery()"); err != nil {
+		return fmt.Errorf("failed to read recovery state: %w", err)
+	}
+	if inRecovery {
+		replication.Role = "replica"
+		query = "SELECT EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())::float8"
+		if err := db.GetContext(ctx, &replication.LagSeconds, query); err != nil {
+			return fmt.Errorf("failed to read replication lag: %w", err)
+		}
+	} else {
+		query = `
+			SELECT COALESCE(application_name, client_addr::text, '') AS name, state,
+				EXTRACT(EPOCH FROM replay_lag)::float8 AS lag_seconds
+			FROM pg_stat_replication
+			ORDER BY name
+		`
+		if err := db.SelectContext(ctx, &replication.Replicas, query); err != nil {
+			return fmt.Errorf("failed to read replicas: %w", err)
+		}
+	}
+	if inRecovery || len(replication.Replicas) > 0 {
+		health.Replication = replication
+	}
+
+	query = `
+		SELECT s.relname AS table_name, s.n_live_tup AS live_tuples, s.n_dead_tup AS dead_tuples,
+			s.n_dead_tup::float8 / (s.n_live_tup + s.n_dead_tup) AS dead_ratio,
+			(pg_relation_size(s.relid) * s.n_dead_tup / (s.n_live_tup + s.n_dead_tup))::bigint AS wasted_bytes
+		FROM pg_stat_user_tables s
+		WHERE s.n_dead_tup > 0
+		ORDER BY wasted_bytes DESC, dead_tuples DESC
+		LIMIT $1
+	`
+	if err := db.SelectContext(ctx, &health.Bloat, query, maxHealthRows); err != nil {
+		return fmt.Errorf("failed to read bloat estimates: %w", err)
+	}
+	return nil
+}
+
+// mysqlHealth reads connections and running statements from the server
+// status and information_schema.PROCESSLIST.
+func mysqlHealth(ctx context.Context, db *sqlx.DB, health *DBHealth, longQuery time.Duration) error {
+	var server ServerHealth
+	query := `
+		SELECT @@max_connections AS max_connections,
+			(SELECT COUNT(*) FROM information_schema.PROCESSLIST) AS used_connections
+	`
+	if err := db.GetContext(ctx, &server, query); err != nil {
+		return fmt.Errorf("failed to read connections: %w", err)
+	}
+	if server.MaxConnections > 0 {
+		server.Saturation = float64(server.UsedConnections) / float64(server.MaxConnections)
+	}
+	health.Server = &server
+
+	query = `
+		SELECT ID AS pid, TIME AS seconds, STATE AS state, NULL AS wait_event, COALESCE(INFO, '') AS query
+		FROM information_schema.PROCESSLIST
+		WHERE COMMAND NOT IN ('Sleep', 'Daemon', 'Binlog Dump') AND ID <> CONNECTION_ID() AND TIME >= ?
+		ORDER BY TIME DESC
+		LIMIT ?
+	`
+	if err := db.SelectContext(ctx, &health.LongQueries, query, int64(longQuery.Seconds()), maxHealthRows); err != nil {
+		return fmt.Errorf("failed to read running queries: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,40 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/jobs"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SetJobs enables the background job endpoints.
+func (h *Handler) SetJobs(runner *jobs.Runner) {
+	h.jobs = runner
+}
+
+// ListJobs handles GET /admin/jobs, reporting each job's schedule and its
+// last and next runs on this instance.
+func (h *Handler) ListJobs(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(h.jobs.Jobs()))
+}
+
+// DisableJob handles POST /admin/jobs/:name/disable.
+func (h *Handler) DisableJob(c *gin.Context) {
+	status, err := h.jobs.Disable(c.Param("name"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(status))
+}
+
+// EnableJob handles POST /admin/jobs/:name/enable.
+func (h *Handler) EnableJob(c *gin.Context) {
+	status, err := h.jobs.Enable(c.Param("name"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(status))
+}
@@ -0,0 +1,92 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SetLocks enables the collection lock endpoints. Field changes applied
+// through the admin API then lock their collection while they run.
+func (h *Handler) SetLocks(locks *collection.Locks) {
+	h.locks = locks
+}
+
+// ListLocks handles GET /admin/locks.
+func (h *Handler) ListLocks(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(h.locks.List()))
+}
+
+// LockCollection handles POST /admin/collections/:name/lock, freezing
+// writes to the collection until it is unlocked or the duration passes.
+func (h *Handler) LockCollection(c *gin.Context) {
+	var req LockCollectionRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("Invalid request body"),
+			))
+			return
+		}
+	}
+
+	var d time.Duration
+	if req.Duration != "" {
+		var err error
+		if d, err = time.ParseDuration(req.Duration); err != nil || d <= 0 {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("duration must be a positive duration such as \"30m\""),
+			))
+			return
+		}
+	}
+
+	coll, err := h.schemaManager.GetCollection(c.Param("name"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(h.locks.Lock(coll.Name, req.Reason, d)))
+}
+
+// UnlockCollection handles DELETE /admin/collections/:name/lock.
+func (h *Handler) UnlockCollection(c *gin.Context) {
+	coll, err := h.schemaManager.GetCollection(c.Param("name"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	if !h.locks.Unlock(coll.Name) {
+		h.writeError(c, apperror.ErrNotFound.WithMessagef("Collection '%s' is not locked", coll.Name))
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{"collection": coll.Name, "unlocked": true}))
+}
+
+// lockWrites wraps a schema change handler so the collection is locked
+// while the change is applied, and unlocked when it completes or after
+// collection.DefaultLockDuration.
+func (h *Handler) lockWrites(handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if h.locks == nil || !h.config.AutoExecute {
+			handler(c)
+			return
+		}
+		coll, err := h.schemaManager.GetCollection(c.Param("name"))
+		if err != nil {
+			handler(c)
+			return
+		}
+		_ = h.locks.Hold(c.Request.Context(), coll.Name, "schema change", collection.DefaultLockDuration, func(context.Context) error {
+			handler(c)
+			return nil
+		})
+	}
+}
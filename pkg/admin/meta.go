@@ -0,0 +1,120 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// SetMetaStore enables the collection metadata endpoints and includes the
+// metadata in collection details.
+func (h *Handler) SetMetaStore(store *schema.MetaStore) {
+	h.meta = store
+}
+
+// GetMeta handles GET /admin/collections/:name/meta.
+func (h *Handler) GetMeta(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := h.schemaManager.GetCollection(name); err != nil {
+		h.writeError(c, apperror.ErrCollectionNotFound.WithMessage("Collection not found: "+name))
+		return
+	}
+
+	meta, err := h.meta.Get(c.Request.Context(), name)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(meta))
+}
+
+// SetCollectionMeta handles PUT /admin/collections/:name/meta.
+func (h *Handler) SetCollectionMeta(c *gin.Context) {
+	name := c.Param("name")
+	if _, err := h.schemaManager.GetCollection(name); err != nil {
+		h.writeError(c, apperror.ErrCollectionNotFound.WithMessage("Collection not found: "+name))
+		return
+	}
+	h.setMeta(c, name, "")
+}
+
+// SetFieldMeta handles PUT /admin/collections/:name/fields/:field/meta.
+func (h *Handler) SetFieldMeta(c *gin.Context) {
+	name := c.Param("name")
+	fieldName := c.Param("field")
+	collection, err := h.schemaManager.GetCollection(name)
+	if err != nil {
+		h.writeError(c, apperror.ErrCollectionNotFound.WithMessage("Collection not found: "+name))
+		return
+	}
+	if !hasField(collection, fieldName) {
+		h.writeError(c, apperror.ErrNotFound.WithMessage("Field not found: "+fieldName))
+		return
+	}
+	h.setMeta(c, name, fieldName)
+}
+
+// setMeta replaces the metadata of a collection or field with the request body.
+func (h *Handler) setMeta(c *gin.Context, collection, field string) {
+	var req MetaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body"))
+		return
+	}
+
+	meta := &schema.Meta{
+		Collection:   collection,
+		Field:        field,
+		DisplayName:  req.DisplayName,
+		Description:  req.Description,
+		Icon:         req.Icon,
+		DisplayOrder: req.DisplayOrder,
+		Hidden:       req.Hidden,
+	}
+	if err := h.meta.Set(c.Request.Context(), meta); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(meta))
+}
+
+// listMeta returns the metadata of all collections, or nil when metadata is
+// not enabled or cannot be loaded.
+func (h *Handler) listMeta(ctx context.Context) map[string]*schema.CollectionMeta {
+	if h.meta == nil {
+		return nil
+	}
+	metas, err := h.meta.List(ctx)
+	if err != nil {
+		requestlog.Logger(ctx, h.logger).Warnw("Failed to load collection metadata", "error", err)
+		return nil
+	}
+	return metas
+}
+
+// forgetMeta removes the metadata of a dropped collection or field.
+func (h *Handler) forgetMeta(ctx context.Context, collection, field string) {
+	if h.meta == nil {
+		return
+	}
+	if err := h.meta.Delete(ctx, collection, field); err != nil {
+		requestlog.Logger(ctx, h.logger).Warnw("Failed to remove collection metadata", "collection", collection, "field", field, "error", err)
+	}
+}
+
+// renameMeta moves the metadata of a renamed field.
+func (h *Handler) renameMeta(ctx context.Context, collection, field, newName string) {
+	if h.meta == nil {
+		return
+	}
+	if err := h.meta.RenameField(ctx, collection, field, newName); err != nil {
+		requestlog.Logger(ctx, h.logger).Warnw("Failed to rename field metadata", "collection", collection, "field", field, "error", err)
+	}
+}
@@ -1,11 +1,16 @@
 package admin
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"time"
+
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
 )
 
 // MigrationGenerator generates SQL migration files.
@@ -37,7 +42,7 @@ func (g *MigrationGenerator) GenerateCreateTable(req CreateCollectionRequest) (*
 
 	// Build UP migration
 	var upBuilder strings.Builder
-	upBuilder.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", tableName))
+	upBuilder.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", quoteIdent(tableName)))
 
 	var columns []string
 	var constraints []string
@@ -49,13 +54,11 @@ func (g *MigrationGenerator) GenerateCreateTable(req CreateCollectionRequest) (*
 		if field.References != nil {
 			fkName := fmt.Sprintf("fk_%s_%s", tableName, field.Name)
 			fk := fmt.Sprintf("    CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s)",
-				fkName, field.Name, field.References.Table, field.References.Column)
-			if field.References.OnDelete != "" {
-				fk += " ON DELETE " + field.References.OnDelete
-			}
-			if field.References.OnUpdate != "" {
-				fk += " ON UPDATE " + field.References.OnUpdate
+				quoteIdent(fkName), quoteIdent(field.Name), quoteIdent(field.References.Table), quoteIdent(field.References.Column))
+			if err := validateReference(field.References); err != nil {
+				return nil, err
 			}
+			fk += referenceActions(field.References)
 			constraints = append(constraints, fk)
 		}
 	}
@@ -71,12 +74,12 @@ func (g *MigrationGenerator) GenerateCreateTable(req CreateCollectionRequest) (*
 	for _, field := range req.Fields {
 		if field.Unique && !field.Primary {
 			idxName := fmt.Sprintf("idx_%s_%s", tableName, field.Name)
-			upBuilder.WriteString(fmt.Sprintf("\nCREATE UNIQUE INDEX %s ON %s(%s);\n", idxName, tableName, field.Name))
+			upBuilder.WriteString(fmt.Sprintf("\nCREATE UNIQUE INDEX %s ON %s(%s);\n", quoteIdent(idxName), quoteIdent(tableName), quoteIdent(field.Name)))
 		}
 	}
 
 	// Build DOWN migration
-	downSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", tableName)
+	downSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", quoteIdent(tableName))
 
 	return g.createMigration("create_"+req.Name, upBuilder.String(), downSQL)
 }
@@ -88,27 +91,24 @@ func (g *MigrationGenerator) GenerateAddColumn(tableName string, field FieldDef)
 	}
 
 	colDef := buildColumnDef(field)
-	upSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", tableName, colDef)
+	upSQL := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s;\n", quoteIdent(tableName), colDef)
 
 	if field.Unique {
 		idxName := fmt.Sprintf("idx_%s_%s", tableName, field.Name)
-		upSQL += fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s(%s);\n", idxName, tableName, field.Name)
+		upSQL += fmt.Sprintf("CREATE UNIQUE INDEX %s ON %s(%s);\n", quoteIdent(idxName), quoteIdent(tableName), quoteIdent(field.Name))
 	}
 
 	if field.References != nil {
 		fkName := fmt.Sprintf("fk_%s_%s", tableName, field.Name)
 		upSQL += fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s FOREIGN KEY (%s) REFERENCES %s(%s)",
-			tableName, fkName, field.Name, field.References.Table, field.References.Column)
-		if field.References.OnDelete != "" {
-			upSQL += " ON DELETE " + field.References.OnDelete
+			quoteIdent(tableName), quoteIdent(fkName), quoteIdent(field.Name), quoteIdent(field.References.Table), quoteIdent(field.References.Column))
+		if err := validateReference(field.References); err != nil {
+			return nil, err
 		}
-		if field.References.OnUpdate != "" {
-			upSQL += " ON UPDATE " + field.References.OnUpdate
-		}
-		upSQL += ";\n"
+		upSQL += referenceActions(field.References) + ";\n"
 	}
 
-	downSQL := fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", tableName, field.Name)
+	downSQL := fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", quoteIdent(tableName), quoteIdent(field.Name))
 
 	return g.createMigration(fmt.Sprintf("add_%s_to_%s", field.Name, tableName), upSQL, downSQL)
 }
@@ -119,7 +119,7 @@ func (g *MigrationGenerator) GenerateDropColumn(tableName, columnName string) (*
 		tableName = "api_" + tableName
 	}
 
-	upSQL := fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", tableName, columnName)
+	upSQL := fmt.Sprintf("ALTER TABLE %s DROP COLUMN IF EXISTS %s;\n", quoteIdent(tableName), quoteIdent(columnName))
 	downSQL := "-- Cannot automatically restore dropped column\n-- Manual intervention required\n"
 
 	return g.createMigration(fmt.Sprintf("drop_%s_from_%s", columnName, tableName), upSQL, downSQL)
@@ -136,28 +136,28 @@ func (g *MigrationGenerator) GenerateAlterColumn(tableName, columnName string, r
 
 	if req.Type != nil {
 		pgType := GetPostgresType(*req.Type, req.MaxLength, nil, nil)
-		upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", tableName, columnName, pgType))
+		upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE %s;", quoteIdent(tableName), quoteIdent(columnName), pgType))
 		downParts = append(downParts, "-- Type change requires manual rollback")
 	}
 
 	if req.Required != nil {
 		if *req.Required {
-			upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", tableName, columnName))
-			downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", tableName, columnName))
+			upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", quoteIdent(tableName), quoteIdent(columnName)))
+			downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", quoteIdent(tableName), quoteIdent(columnName)))
 		} else {
-			upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", tableName, columnName))
-			downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", tableName, columnName))
+			upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL;", quoteIdent(tableName), quoteIdent(columnName)))
+			downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL;", quoteIdent(tableName), quoteIdent(columnName)))
 		}
 	}
 
 	if req.Default != nil {
-		upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %v;", tableName, columnName, req.Default))
-		downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", tableName, columnName))
+		upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s;", quoteIdent(tableName), quoteIdent(columnName), formatDefault(req.Default)))
+		downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT;", quoteIdent(tableName), quoteIdent(columnName)))
 	}
 
 	if req.NewName != nil && *req.NewName != columnName {
-		upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", tableName, columnName, *req.NewName))
-		downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", tableName, *req.NewName, columnName))
+		upParts = append(upParts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", quoteIdent(tableName), quoteIdent(columnName), quoteIdent(*req.NewName)))
+		downParts = append(downParts, fmt.Sprintf("ALTER TABLE %s RENAME COLUMN %s TO %s;", quoteIdent(tableName), quoteIdent(*req.NewName), quoteIdent(columnName)))
 	}
 
 	upSQL := strings.Join(upParts, "\n") + "\n"
@@ -172,7 +172,7 @@ func (g *MigrationGenerator) GenerateDropTable(tableName string) (*Migration, er
 		tableName = "api_" + tableName
 	}
 
-	upSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", tableName)
+	upSQL := fmt.Sprintf("DROP TABLE IF EXISTS %s;\n", quoteIdent(tableName))
 	downSQL := "-- Cannot automatically restore dropped table\n-- Manual intervention required\n"
 
 	return g.createMigration("drop_"+tableName, upSQL, downSQL)
@@ -217,7 +217,7 @@ func (g *MigrationGenerator) createMigration(name, upSQL, downSQL string) (*Migr
 func buildColumnDef(field FieldDef) string {
 	var parts []string
 
-	parts = append(parts, field.Name)
+	parts = append(parts, quoteIdent(field.Name))
 	parts = append(parts, GetPostgresType(field.Type, field.MaxLength, field.Precision, field.Scale))
 
 	if field.Primary {
@@ -238,17 +238,92 @@ func buildColumnDef(field FieldDef) string {
 	return strings.Join(parts, " ")
 }
 
-// formatDefault formats a default value for SQL.
+// formatDefault formats a default value for SQL. Values other than
+// numbers and booleans are written as string literals.
 func formatDefault(value interface{}) string {
 	switch v := value.(type) {
-	case string:
-		return fmt.Sprintf("'%s'", strings.ReplaceAll(v, "'", "''"))
+	case nil:
+		return "NULL"
 	case bool:
 		if v {
 			return "TRUE"
 		}
 		return "FALSE"
-	default:
+	case float64, float32, int, int32, int64, json.Number:
 		return fmt.Sprintf("%v", v)
+	case string:
+		return quoteLiteral(v)
+	default:
+		return quoteLiteral(fmt.Sprintf("%v", v))
+	}
+}
+
+// quoteLiteral quotes a string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// quoteIdent quotes an identifier. Generated migrations use PostgreSQL
+// syntax, whose double-quoted identifiers SQLite accepts as well.
+func quoteIdent(name string) string {
+	return dialect.PostgresDialect{}.QuoteIdent(name)
+}
+
+// validateFieldDef checks the name and reference of a new field.
+func validateFieldDef(field FieldDef) error {
+	if err := validation.ValidateFieldName(field.Name); err != nil {
+		return err
+	}
+	if field.References != nil {
+		return validateReference(field.References)
+	}
+	return nil
+}
+
+// hasField reports whether a collection has a field.
+func hasField(collection *schema.Collection, name string) bool {
+	for _, field := range collection.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// referentialActions are the ON DELETE and ON UPDATE actions of foreign keys.
+var referentialActions = map[string]bool{
+	"CASCADE":     true,
+	"SET NULL":    true,
+	"SET DEFAULT": true,
+	"RESTRICT":    true,
+	"NO ACTION":   true,
+}
+
+// validateReference checks the names and actions of a foreign key.
+func validateReference(ref *ForeignRef) error {
+	if err := validation.ValidateCollectionName(ref.Table); err != nil {
+		return fmt.Errorf("invalid referenced table: %s", ref.Table)
+	}
+	if err := validation.ValidateFieldName(ref.Column); err != nil {
+		return fmt.Errorf("invalid referenced column: %s", ref.Column)
+	}
+	for _, action := range []string{ref.OnDelete, ref.OnUpdate} {
+		if action != "" && !referentialActions[strings.ToUpper(action)] {
+			return fmt.Errorf("invalid referential action: %s", action)
+		}
+	}
+	return nil
+}
+
+// referenceActions returns the ON DELETE and ON UPDATE clauses of a
+// validated foreign key.
+func referenceActions(ref *ForeignRef) string {
+	clauses := ""
+	if ref.OnDelete != "" {
+		clauses += " ON DELETE " + strings.ToUpper(ref.OnDelete)
+	}
+	if ref.OnUpdate != "" {
+		clauses += " ON UPDATE " + strings.ToUpper(ref.OnUpdate)
 	}
+	return clauses
 }
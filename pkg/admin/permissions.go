@@ -0,0 +1,104 @@
+package admin
+
+import (
+	"database/sql"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SimulateRequest is the body of POST /admin/permissions/simulate. Exactly
+// one of UserID and Role names who makes the request.
+type SimulateRequest struct {
+	UserID string         `json:"user_id"`
+	Role   string         `json:"role"`
+	Method string         `json:"method" binding:"required"`
+	Path   string         `json:"path" binding:"required"`
+	Body   map[string]any `json:"body"`
+}
+
+// SimulateResponse is the decision of a simulated request and who made it.
+type SimulateResponse struct {
+	User *auth.User `json:"user"`
+	*permission.Simulation
+}
+
+// SetPermissions enables the permission simulation endpoint. users looks up
+// the users named by ID.
+func (h *Handler) SetPermissions(checker *permission.Checker, users auth.UserStore) {
+	h.permissions = checker
+	h.users = users
+}
+
+// SimulatePermissions handles POST /admin/permissions/simulate. It reports
+// whether a user or role may make a request, with the row filter, field
+// permissions and presets that would apply, without running the request.
+// A role is simulated with a user without ID, username or email, so filter
+// variables naming them resolve to empty values.
+func (h *Handler) SimulatePermissions(c *gin.Context) {
+	var req SimulateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Invalid request body: method and path are required"))
+		return
+	}
+	if (req.UserID == "") == (req.Role == "") {
+		h.writeError(c, apperror.ErrBadRequest.WithMessage("Exactly one of user_id and role is required"))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user := &auth.User{Role: req.Role}
+	if req.UserID != "" {
+		if h.users == nil {
+			h.writeError(c, apperror.ErrBadRequest.WithMessage("Users cannot be looked up without auth; simulate a role instead"))
+			return
+		}
+		found, err := h.users.GetByID(ctx, req.UserID)
+		if err != nil {
+			h.writeError(c, err)
+			return
+		}
+		user = found
+	} else {
+		roleID, err := h.permissions.RoleID(ctx, req.Role)
+		if errors.Is(err, sql.ErrNoRows) {
+			h.writeError(c, apperror.ErrNotFound.WithMessage("Role not found: "+req.Role))
+			return
+		}
+		if err != nil {
+			h.writeError(c, apperror.ErrInternalServer.WithError(err))
+			return
+		}
+		user.RoleID = roleID
+	}
+
+	path := req.Path
+	if i := strings.IndexAny(path, "?#"); i >= 0 {
+		path = path[:i]
+	}
+	sim, err := h.permissions.Simulate(ctx, user, req.Method, path, req.Body, h.fieldNames)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(SimulateResponse{User: user, Simulation: sim}))
+}
+
+// fieldNames returns the fields of a collection, or nil if it does not exist.
+func (h *Handler) fieldNames(name string) []string {
+	col, err := h.schemaManager.GetCollection(name)
+	if err != nil {
+		return nil
+	}
+	names := make([]string, len(col.Fields))
+	for i, f := range col.Fields {
+		names[i] = f.Name
+	}
+	return names
+}
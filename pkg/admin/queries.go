@@ -0,0 +1,117 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/storedquery"
+)
+
+// SetQueryService enables the stored query endpoints.
+func (h *Handler) SetQueryService(service *storedquery.Service) {
+	h.queries = service
+}
+
+// ListQueries handles GET /admin/queries.
+func (h *Handler) ListQueries(c *gin.Context) {
+	queries, err := h.queries.List(c.Request.Context())
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(queries))
+}
+
+// GetQuery handles GET /admin/queries/:query.
+func (h *Handler) GetQuery(c *gin.Context) {
+	q, err := h.queries.Get(c.Request.Context(), c.Param("query"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(q))
+}
+
+// CreateQuery handles POST /admin/queries.
+func (h *Handler) CreateQuery(c *gin.Context) {
+	var req CreateQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Invalid request body"),
+		))
+		return
+	}
+
+	q, err := h.queries.Create(c.Request.Context(), &storedquery.Query{
+		Name:        req.Name,
+		Description: req.Description,
+		SQL:         req.SQL,
+		Params:      req.Params,
+		Roles:       req.Roles,
+		MaxRows:     req.MaxRows,
+	})
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(q))
+}
+
+// UpdateQuery handles PATCH /admin/queries/:query.
+func (h *Handler) UpdateQuery(c *gin.Context) {
+	var req UpdateQueryRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Invalid request body"),
+		))
+		return
+	}
+
+	q, err := h.queries.Get(c.Request.Context(), c.Param("query"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	if req.Description != nil {
+		q.Description = *req.Description
+	}
+	if req.SQL != nil {
+		q.SQL = *req.SQL
+	}
+	if req.Params != nil {
+		q.Params = *req.Params
+	}
+	if req.Roles != nil {
+		q.Roles = *req.Roles
+	}
+	if req.MaxRows != nil {
+		q.MaxRows = *req.MaxRows
+	}
+
+	q, err = h.queries.Update(c.Request.Context(), q)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(q))
+}
+
+// DeleteQuery handles DELETE /admin/queries/:query.
+func (h *Handler) DeleteQuery(c *gin.Context) {
+	if err := h.queries.Delete(c.Request.Context(), c.Param("query")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"name":    c.Param("query"),
+		"deleted": true,
+	}))
+}
@@ -0,0 +1,21 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/quota"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SetQuotas enables the quota usage endpoint.
+func (h *Handler) SetQuotas(meter *quota.Meter) {
+	h.quotas = meter
+}
+
+// GetUserUsage handles GET /admin/users/:id/usage, returning a user's
+// usage and quota in the current month. The quota is that of the role the
+// user was last metered with, or the default one.
+func (h *Handler) GetUserUsage(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(h.quotas.Usage(c.Param("id"), "")))
+}
@@ -0,0 +1,55 @@
+package admin
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SetConfigReloader enables the config reload endpoint. reload loads the
+// current configuration, applies it to the running engine and returns the
+// changed settings that take effect on restart.
+func (h *Handler) SetConfigReloader(reload func(ctx context.Context) ([]string, error)) {
+	h.reload = reload
+}
+
+// SetSchemaRefresher sets how the schema is refreshed after changes made
+// through the admin API, such as one also refreshing other instances.
+// Without it, only the schema manager is refreshed.
+func (h *Handler) SetSchemaRefresher(refresh func(ctx context.Context) error) {
+	h.refresh = refresh
+}
+
+// refreshSchema rediscovers the schema.
+func (h *Handler) refreshSchema(ctx context.Context) error {
+	if h.refresh != nil {
+		return h.refresh(ctx)
+	}
+	return h.schemaManager.Refresh(ctx)
+}
+
+// ReloadConfig handles POST /admin/config/reload.
+func (h *Handler) ReloadConfig(c *gin.Context) {
+	restart, err := h.reload(c.Request.Context())
+	if err != nil {
+		if _, ok := apperror.AsAppError(err); !ok {
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to reload config", "error", err)
+			err = apperror.ErrInternalServer.WithMessage("Failed to reload config")
+		}
+		h.writeError(c, err)
+		return
+	}
+
+	if restart == nil {
+		restart = []string{}
+	}
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"reloaded":         true,
+		"collections":      len(h.schemaManager.ListCollections()),
+		"restart_required": restart,
+	}))
+}
@@ -0,0 +1,108 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/retention"
+)
+
+// RetentionReport lists the retention rules and what a run did or would do.
+type RetentionReport struct {
+	Rules   map[string]retention.Rule `json:"rules"`
+	Results []retention.Result        `json:"results"`
+}
+
+// SetRetention enables the retention endpoints.
+func (h *Handler) SetRetention(enforcer *retention.Enforcer) {
+	h.retention = enforcer
+}
+
+// GetRetention handles GET /admin/retention, a dry run of the retention
+// rules.
+func (h *Handler) GetRetention(c *gin.Context) {
+	h.runRetention(c, true)
+}
+
+// RunRetention handles POST /admin/retention/run. With ?dry_run=true
+// nothing is deleted.
+func (h *Handler) RunRetention(c *gin.Context) {
+	dryRun := false
+	if raw := c.Query("dry_run"); raw != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(raw); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("dry_run must be true or false"),
+			))
+			return
+		}
+	}
+	h.runRetention(c, dryRun)
+}
+
+// runRetention runs the rules and writes the report. Failures of single
+// collections are reported in their results.
+func (h *Handler) runRetention(c *gin.Context, dryRun bool) {
+	results, err := h.retention.Run(c.Request.Context(), dryRun)
+	if err != nil {
+		h.logger.Warnw("Retention run failed", "dry_run", dryRun, "error", err)
+	}
+
+	c.JSON(http.StatusOK, response.Success(RetentionReport{
+		Rules:   h.retention.Rules(),
+		Results: results,
+	}))
+}
+
+// Archive listing page sizes.
+const (
+	DefaultArchiveLimit = 50
+	MaxArchiveLimit     = 500
+)
+
+// ListArchives handles GET /admin/retention/archives.
+func (h *Handler) ListArchives(c *gin.Context) {
+	limit := DefaultArchiveLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("limit must be a positive integer"),
+			))
+			return
+		}
+		limit = min(n, MaxArchiveLimit)
+	}
+
+	entries, err := h.retention.Archives(c.Request.Context(), c.Query("collection"), limit)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(entries))
+}
+
+// RestoreArchive handles POST /admin/retention/:collection/restore.
+func (h *Handler) RestoreArchive(c *gin.Context) {
+	var req retention.RestoreRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("Invalid request body"),
+			))
+			return
+		}
+	}
+
+	restored, err := h.retention.Restore(c.Request.Context(), c.Param("collection"), req)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{"restored": restored}))
+}
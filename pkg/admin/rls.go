@@ -0,0 +1,87 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// RLSPolicies is the response of the policy endpoints.
+type RLSPolicies struct {
+	Statements []string `json:"statements"`
+	Applied    bool     `json:"applied"`
+}
+
+// SetRLS enables the row-level security policy endpoints.
+func (h *Handler) SetRLS(rls *permission.RLS, db *sqlx.DB) {
+	h.rls = rls
+	h.rlsDB = db
+}
+
+// GetRLSPolicies handles GET /admin/rls/policies.
+// It returns the statements that would be applied without running them.
+func (h *Handler) GetRLSPolicies(c *gin.Context) {
+	statements, err := h.generatePolicies(c)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(RLSPolicies{Statements: statements}))
+}
+
+// ApplyRLSPolicies handles POST /admin/rls/apply.
+// It regenerates policies from tugo_permissions and applies them in one transaction.
+func (h *Handler) ApplyRLSPolicies(c *gin.Context) {
+	ctx := c.Request.Context()
+	statements, err := h.generatePolicies(c)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	tx, err := h.rlsDB.BeginTxx(ctx, nil)
+	if err != nil {
+		h.writeError(c, apperror.ErrInternalServer.WithError(err))
+		return
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to apply RLS policy", "statement", stmt, "error", err)
+			h.writeError(c, apperror.ErrInternalServer.WithMessage("Failed to apply policies: "+err.Error()))
+			return
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		h.writeError(c, apperror.ErrInternalServer.WithError(err))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Infow("RLS policies applied", "statements", len(statements))
+	c.JSON(http.StatusOK, response.Success(RLSPolicies{Statements: statements, Applied: true}))
+}
+
+// generatePolicies builds policy statements for all discovered collections.
+func (h *Handler) generatePolicies(c *gin.Context) ([]string, error) {
+	tables := make(map[string]permission.PolicyTable)
+	for _, col := range h.schemaManager.ListCollections() {
+		types := make(map[string]string, len(col.Fields))
+		for _, f := range col.Fields {
+			types[f.Name] = f.PostgresType
+		}
+		tables[col.Name] = permission.PolicyTable{Name: col.TableName, Types: types}
+	}
+
+	statements, err := h.rls.GeneratePolicies(c.Request.Context(), h.rlsDB, tables)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return statements, nil
+}
@@ -0,0 +1,49 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SetSessions enables the user session endpoints.
+func (h *Handler) SetSessions(store auth.SessionStore) {
+	h.sessions = store
+}
+
+// ListUserSessions handles GET /admin/users/:id/sessions, listing a user's
+// active sessions.
+func (h *Handler) ListUserSessions(c *gin.Context) {
+	manager, ok := h.sessions.(auth.SessionManager)
+	if !ok {
+		response.JSON(c, http.StatusNotImplemented, response.Error(
+			"NOT_IMPLEMENTED",
+			"The session store cannot list sessions",
+		))
+		return
+	}
+
+	sessions, err := manager.ListByUserID(c.Request.Context(), c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(auth.SessionInfos(sessions, "")))
+}
+
+// DeleteUserSessions handles DELETE /admin/users/:id/sessions, signing a
+// user out of all their sessions.
+func (h *Handler) DeleteUserSessions(c *gin.Context) {
+	if err := h.sessions.DeleteByUserID(c.Request.Context(), c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"user_id": c.Param("id"),
+		"revoked": true,
+	}))
+}
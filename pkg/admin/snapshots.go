@@ -0,0 +1,96 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/snapshot"
+)
+
+// CreateSnapshotRequest is the body of POST /admin/collections/:name/snapshot.
+type CreateSnapshotRequest struct {
+	Note string `json:"note"`
+}
+
+// RestoreSnapshotRequest is the body of POST /admin/collections/:name/restore.
+type RestoreSnapshotRequest struct {
+	Snapshot string `json:"snapshot" binding:"required"`
+	Mode     string `json:"mode"`
+}
+
+// SetSnapshots enables the snapshot endpoints.
+func (h *Handler) SetSnapshots(service *snapshot.Service) {
+	h.snapshots = service
+}
+
+// userID returns the ID of the request's user, or nil.
+func userID(c *gin.Context) *string {
+	if user, ok := auth.GetUserFromContext(c.Request.Context()); ok && user != nil && user.ID != "" {
+		return &user.ID
+	}
+	return nil
+}
+
+// CreateSnapshot handles POST /admin/collections/:name/snapshot.
+func (h *Handler) CreateSnapshot(c *gin.Context) {
+	var req CreateSnapshotRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("Invalid request body"),
+			))
+			return
+		}
+	}
+
+	snap, err := h.snapshots.Create(c.Request.Context(), c.Param("name"), req.Note, userID(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(snap))
+}
+
+// ListSnapshots handles GET /admin/collections/:name/snapshots.
+func (h *Handler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.snapshots.List(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(snapshots))
+}
+
+// DeleteSnapshot handles DELETE /admin/collections/:name/snapshots/:id.
+func (h *Handler) DeleteSnapshot(c *gin.Context) {
+	if err := h.snapshots.Delete(c.Request.Context(), c.Param("name"), c.Param("id")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// RestoreSnapshot handles POST /admin/collections/:name/restore.
+func (h *Handler) RestoreSnapshot(c *gin.Context) {
+	var req RestoreSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("snapshot is required"),
+		))
+		return
+	}
+
+	result, err := h.snapshots.Restore(c.Request.Context(), c.Param("name"), req.Snapshot, req.Mode, userID(c))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(result))
+}
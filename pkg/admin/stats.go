@@ -0,0 +1,188 @@
+package admin
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// maxSlowQueries caps the statements listed in collection statistics.
+const maxSlowQueries = 10
+
+// CollectionStats describes a collection's table for operators. Fields
+// the database cannot report are omitted.
+type CollectionStats struct {
+	Collection string `json:"collection"`
+	Table      string `json:"table"`
+
+	// EstimatedRows comes from planner statistics; ExactRows from COUNT(*)
+	// when requested with ?exact=true.
+	EstimatedRows *int64 `json:"estimated_rows,omitempty"`
+	ExactRows     *int64 `json:"exact_rows,omitempty"`
+
+	TableBytes *int64 `json:"table_bytes,omitempty"`
+	IndexBytes *int64 `json:"index_bytes,omitempty"`
+	TotalBytes *int64 `json:"total_bytes,omitempty"`
+
+	LiveTuples     *int64   `json:"live_tuples,omitempty"`
+	DeadTuples     *int64   `json:"dead_tuples,omitempty"`
+	DeadTupleRatio *float64 `json:"dead_tuple_ratio,omitempty"`
+
+	// SeqScans far above IndexScans suggests a missing index.
+	SeqScans   *int64 `json:"seq_scans,omitempty"`
+	IndexScans *int64 `json:"index_scans,omitempty"`
+
+	LastVacuum      *time.Time `json:"last_vacuum,omitempty"`
+	LastAutovacuum  *time.Time `json:"last_autovacuum,omitempty"`
+	LastAnalyze     *time.Time `json:"last_analyze,omitempty"`
+	LastAutoanalyze *time.Time `json:"last_autoanalyze,omitempty"`
+
+	// SlowQueries lists the statements on the table with the highest mean
+	// time, from pg_stat_statements when the extension is installed.
+	SlowQueries []QueryStat `json:"slow_queries,omitempty"`
+}
+
+// QueryStat is a statement's timing from pg_stat_statements.
+type QueryStat struct {
+	Query   string  `db:"query" json:"query"`
+	Calls   int64   `db:"calls" json:"calls"`
+	MeanMs  float64 `db:"mean_ms" json:"mean_ms"`
+	MaxMs   float64 `db:"max_ms" json:"max_ms"`
+	TotalMs float64 `db:"total_ms" json:"total_ms"`
+}
+
+// SetStatsDB enables the collection statistics endpoint.
+func (h *Handler) SetStatsDB(db *sqlx.DB) {
+	h.statsDB = db
+}
+
+// GetCollectionStats handles GET /admin/collections/:name/stats.
+func (h *Handler) GetCollectionStats(c *gin.Context) {
+	name := c.Param("name")
+
+	collection, err := h.schemaManager.GetCollection(name)
+	if err != nil {
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
+			apperror.ErrCollectionNotFound.WithMessage("Collection not found: " + name),
+		))
+		return
+	}
+
+	exact := false
+	if raw := c.Query("exact"); raw != "" {
+		if exact, err = strconv.ParseBool(raw); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("exact must be true or false"),
+			))
+			return
+		}
+	}
+
+	stats, err := collectionStats(c.Request.Context(), h.statsDB, collection, exact)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(stats))
+}
+
+// collectionStats gathers the statistics the database offers for a collection.
+func collectionStats(ctx context.Context, db *sqlx.DB, collection *schema.Collection, exact bool) (*CollectionStats, error) {
+	stats := &CollectionStats{Collection: collection.Name, Table: collection.TableName}
+
+	var err error
+	switch dialect.ForDriver(db.DriverName()).Name() {
+	case dialect.Postgres:
+		err = postgresStats(ctx, db, stats)
+	case dialect.MySQL:
+		err = mysqlStats(ctx, db, stats)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if exact {
+		var count int64
+		countSQL := query.BuildCountDialect(dialect.ForDriver(db.DriverName()), collection.TableName, nil, "")
+		if err := db.GetContext(ctx, &count, countSQL); err != nil {
+			return nil, fmt.Errorf("failed to count rows: %w", err)
+		}
+		stats.ExactRows = &count
+	}
+	return stats, nil
+}
+
+// postgresStats reads sizes and maintenance state from pg_class and
+// pg_stat_user_tables, and the slowest statements from pg_stat_statements.
+func postgresStats(ctx context.Context, db *sqlx.DB, stats *CollectionStats) error {
+	query := `
+		SELECT c.reltuples::bigint, pg_relation_size(c.oid), pg_indexes_size(c.oid), pg_total_relation_size(c.oid),
+			s.n_live_tup, s.n_dead_tup, s.seq_scan, s.idx_scan,
+			s.last_vacuum, s.last_autovacuum, s.last_analyze, s.last_autoanalyze
+		FROM pg_class c
+		LEFT JOIN pg_stat_user_tables s ON s.relid = c.oid
+		WHERE c.oid = to_regclass($1)
+	`
+	err := db.QueryRowContext(ctx, query, stats.Table).Scan(
+		&stats.EstimatedRows, &stats.TableBytes, &stats.IndexBytes, &stats.TotalBytes,
+		&stats.LiveTuples, &stats.DeadTuples, &stats.SeqScans, &stats.IndexScans,
+		&stats.LastVacuum, &stats.LastAutovacuum, &stats.LastAnalyze, &stats.LastAutoanalyze,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to read table statistics: %w", err)
+	}
+
+	// reltuples is -1 until the table is first analyzed
+	if stats.EstimatedRows != nil && *stats.EstimatedRows < 0 {
+		stats.EstimatedRows = stats.LiveTuples
+	}
+	if stats.LiveTuples != nil && stats.DeadTuples != nil {
+		if total := *stats.LiveTuples + *stats.DeadTuples; total > 0 {
+			ratio := float64(*stats.DeadTuples) / float64(total)
+			stats.DeadTupleRatio = &ratio
+		}
+	}
+
+	// pg_stat_statements is optional; without it no statements are listed
+	query = `
+		SELECT query, calls, mean_exec_time AS mean_ms, max_exec_time AS max_ms, total_exec_time AS total_ms
+		FROM pg_stat_statements
+		WHERE query ILIKE $1
+		ORDER BY mean_exec_time DESC
+		LIMIT $2
+	`
+	var slow []QueryStat
+	if err := db.SelectContext(ctx, &slow, query, "%"+stats.Table+"%", maxSlowQueries); err == nil {
+		stats.SlowQueries = slow
+	}
+	return nil
+}
+
+// mysqlStats reads the row estimate and sizes from information_schema.
+func mysqlStats(ctx context.Context, db *sqlx.DB, stats *CollectionStats) error {
+	query := `
+		SELECT TABLE_ROWS, DATA_LENGTH, INDEX_LENGTH
+		FROM information_schema.TABLES
+		WHERE TABLE_SCHEMA = DATABASE() AND TABLE_NAME = ?
+	`
+	err := db.QueryRowContext(ctx, query, stats.Table).Scan(&stats.EstimatedRows, &stats.TableBytes, &stats.IndexBytes)
+	if err != nil {
+		return fmt.Errorf("failed to read table statistics: %w", err)
+	}
+	if stats.TableBytes != nil && stats.IndexBytes != nil {
+		total := *stats.TableBytes + *stats.IndexBytes
+		stats.TotalBytes = &total
+	}
+	return nil
+}
@@ -1,5 +1,12 @@
 package admin
 
+import (
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/idgen"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/storedquery"
+)
+
 // CreateCollectionRequest is the request body for creating a collection.
 type CreateCollectionRequest struct {
 	Name   string        `json:"name" binding:"required"`
@@ -43,25 +50,53 @@ type AlterFieldRequest struct {
 	NewName   *string     `json:"new_name,omitempty"`
 }
 
+// CreateViewRequest is the request body for creating a saved view.
+type CreateViewRequest struct {
+	Name        string               `json:"name" binding:"required"`
+	Description string               `json:"description,omitempty"`
+	Query       collection.ViewQuery `json:"query"`
+	Roles       []string             `json:"roles,omitempty"`
+}
+
+// UpdateViewRequest is the request body for updating a saved view.
+type UpdateViewRequest struct {
+	Description *string               `json:"description,omitempty"`
+	Query       *collection.ViewQuery `json:"query,omitempty"`
+	Roles       *[]string             `json:"roles,omitempty"`
+}
+
+// MetaRequest is the request body for setting collection or field metadata.
+type MetaRequest struct {
+	DisplayName  string `json:"display_name"`
+	Description  string `json:"description"`
+	Icon         string `json:"icon"`
+	DisplayOrder *int   `json:"display_order"`
+	Hidden       bool   `json:"hidden"`
+}
+
 // CollectionInfo represents collection information for admin endpoints.
 type CollectionInfo struct {
-	Name       string      `json:"name"`
-	TableName  string      `json:"table_name"`
-	Enabled    bool        `json:"enabled"`
-	Fields     []FieldInfo `json:"fields"`
-	PrimaryKey string      `json:"primary_key"`
+	Name         string            `json:"name"`
+	TableName    string            `json:"table_name"`
+	Enabled      bool              `json:"enabled"`
+	Fields       []FieldInfo       `json:"fields"`
+	PrimaryKey   string            `json:"primary_key"`
+	IDGeneration *idgen.Properties `json:"id_generation,omitempty"`
+	Relations    []schema.Relation `json:"relations"`
+	Meta         *schema.Meta      `json:"meta,omitempty"`
 }
 
 // FieldInfo represents field information for admin endpoints.
 type FieldInfo struct {
-	Name         string  `json:"name"`
-	Type         string  `json:"type"`
-	PostgresType string  `json:"postgres_type"`
-	Required     bool    `json:"required"`
-	Unique       bool    `json:"unique"`
-	Primary      bool    `json:"primary"`
-	Default      *string `json:"default,omitempty"`
-	MaxLength    *int    `json:"max_length,omitempty"`
+	Name         string       `json:"name"`
+	Type         string       `json:"type"`
+	PostgresType string       `json:"postgres_type"`
+	Required     bool         `json:"required"`
+	Unique       bool         `json:"unique"`
+	Primary      bool         `json:"primary"`
+	Default      *string      `json:"default,omitempty"`
+	MaxLength    *int         `json:"max_length,omitempty"`
+	Meta         *schema.Meta `json:"meta,omitempty"`
 }
 
 // TypeMapping maps abstract types to PostgreSQL types.
@@ -73,6 +108,7 @@ var TypeMapping = map[string]string{
 	"bigint":    "BIGINT",
 	"float":     "DOUBLE PRECISION",
 	"decimal":   "DECIMAL",
+	"money":     "DECIMAL(19,4)",
 	"boolean":   "BOOLEAN",
 	"date":      "DATE",
 	"time":      "TIME",
@@ -114,3 +150,38 @@ func itoa(i int) string {
 	}
 	return string(b)
 }
+
+// CreateQueryRequest is the request body for registering a stored query.
+type CreateQueryRequest struct {
+	Name        string              `json:"name" binding:"required"`
+	Description string              `json:"description,omitempty"`
+	SQL         string              `json:"sql" binding:"required"`
+	Params      []storedquery.Param `json:"params,omitempty"`
+	Roles       []string            `json:"roles,omitempty"`
+	MaxRows     int                 `json:"max_rows,omitempty"`
+}
+
+// UpdateQueryRequest is the request body for updating a stored query.
+type UpdateQueryRequest struct {
+	Description *string              `json:"description,omitempty"`
+	SQL         *string              `json:"sql,omitempty"`
+	Params      *[]storedquery.Param `json:"params,omitempty"`
+	Roles       *[]string            `json:"roles,omitempty"`
+	MaxRows     *int                 `json:"max_rows,omitempty"`
+}
+
+// DisableWebhookRequest is the request body for pausing a webhook.
+type DisableWebhookRequest struct {
+	// Duration is a Go duration such as "30m"; empty pauses until enabled.
+	Duration string `json:"duration,omitempty"`
+}
+
+// LockCollectionRequest is the request body for locking a collection.
+type LockCollectionRequest struct {
+	// Duration is a Go duration such as "30m"; empty locks for
+	// collection.DefaultLockDuration.
+	Duration string `json:"duration,omitempty"`
+
+	// Reason tells clients why writes are refused.
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,54 @@
+package admin
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/usage"
+)
+
+// DefaultUsageStep is the width of the reported usage buckets.
+const DefaultUsageStep = time.Hour
+
+// SetUsage enables the usage endpoint.
+func (h *Handler) SetUsage(tracker *usage.Tracker) {
+	h.usage = tracker
+}
+
+// GetUsage handles GET /admin/usage. since is a duration back from now or
+// an RFC 3339 time and defaults to the tracker's retention; step is the
+// width of the reported buckets; collection limits the report to one
+// collection.
+func (h *Handler) GetUsage(c *gin.Context) {
+	now := time.Now()
+	since := now.Add(-h.usage.Retention())
+	if raw := c.Query("since"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 {
+			since = now.Add(-d)
+		} else if t, err := time.Parse(time.RFC3339, raw); err == nil {
+			since = t
+		} else {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("since must be a positive duration or an RFC 3339 time"),
+			))
+			return
+		}
+	}
+
+	step := DefaultUsageStep
+	if raw := c.Query("step"); raw != "" {
+		d, err := time.ParseDuration(raw)
+		if err != nil || d <= 0 {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("step must be a positive duration"),
+			))
+			return
+		}
+		step = d
+	}
+
+	c.JSON(http.StatusOK, response.Success(h.usage.Report(since, step, c.Query("collection"))))
+}
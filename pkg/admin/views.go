@@ -0,0 +1,123 @@
+package admin
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// SetViewService enables the saved view endpoints.
+func (h *Handler) SetViewService(service *collection.Service) {
+	h.views = service
+}
+
+// ListViews handles GET /admin/collections/:name/views.
+func (h *Handler) ListViews(c *gin.Context) {
+	views, err := h.views.ListViews(c.Request.Context(), c.Param("name"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(views))
+}
+
+// GetView handles GET /admin/collections/:name/views/:view.
+func (h *Handler) GetView(c *gin.Context) {
+	view, err := h.views.GetView(c.Request.Context(), c.Param("name"), c.Param("view"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(view))
+}
+
+// CreateView handles POST /admin/collections/:name/views.
+func (h *Handler) CreateView(c *gin.Context) {
+	var req CreateViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Invalid request body"),
+		))
+		return
+	}
+
+	view, err := h.views.CreateView(c.Request.Context(), &collection.View{
+		Collection:  c.Param("name"),
+		Name:        req.Name,
+		Description: req.Description,
+		Query:       req.Query,
+		Roles:       req.Roles,
+	})
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response.Success(view))
+}
+
+// UpdateView handles PATCH /admin/collections/:name/views/:view.
+func (h *Handler) UpdateView(c *gin.Context) {
+	var req UpdateViewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Invalid request body"),
+		))
+		return
+	}
+
+	view, err := h.views.GetView(c.Request.Context(), c.Param("name"), c.Param("view"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	if req.Description != nil {
+		view.Description = *req.Description
+	}
+	if req.Query != nil {
+		view.Query = *req.Query
+	}
+	if req.Roles != nil {
+		view.Roles = *req.Roles
+	}
+
+	view, err = h.views.UpdateView(c.Request.Context(), view)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(view))
+}
+
+// DeleteView handles DELETE /admin/collections/:name/views/:view.
+func (h *Handler) DeleteView(c *gin.Context) {
+	if err := h.views.DeleteView(c.Request.Context(), c.Param("name"), c.Param("view")); err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(gin.H{
+		"name":    c.Param("view"),
+		"deleted": true,
+	}))
+}
+
+// writeError converts service errors to HTTP responses.
+func (h *Handler) writeError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if appErr, ok := apperror.AsAppError(err); ok {
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+}
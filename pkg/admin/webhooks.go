@@ -0,0 +1,106 @@
+package admin
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/webhook"
+)
+
+// Delivery history page sizes.
+const (
+	DefaultDeliveryLimit = 50
+	MaxDeliveryLimit     = 500
+)
+
+// SetWebhooks enables the webhook inspection endpoints.
+func (h *Handler) SetWebhooks(dispatcher *webhook.Dispatcher) {
+	h.webhooks = dispatcher
+}
+
+// ListWebhooks handles GET /admin/webhooks.
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(h.webhooks.Webhooks()))
+}
+
+// ListDeliveries handles GET /admin/webhooks/:id/deliveries.
+func (h *Handler) ListDeliveries(c *gin.Context) {
+	limit := DefaultDeliveryLimit
+	if raw := c.Query("limit"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("limit must be a positive integer"),
+			))
+			return
+		}
+		limit = min(n, MaxDeliveryLimit)
+	}
+
+	deliveries, err := h.webhooks.Deliveries(c.Request.Context(), c.Param("id"), limit)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(deliveries))
+}
+
+// Redeliver handles POST /admin/webhooks/:id/deliveries/:delivery/redeliver.
+func (h *Handler) Redeliver(c *gin.Context) {
+	delivery, err := h.webhooks.Redeliver(c.Request.Context(), c.Param("id"), c.Param("delivery"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(delivery))
+}
+
+// DisableWebhook handles POST /admin/webhooks/:id/disable.
+func (h *Handler) DisableWebhook(c *gin.Context) {
+	var req DisableWebhookRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("Invalid request body"),
+			))
+			return
+		}
+	}
+
+	var until time.Time
+	if req.Duration != "" {
+		d, err := time.ParseDuration(req.Duration)
+		if err != nil || d <= 0 {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("duration must be a positive duration such as \"30m\""),
+			))
+			return
+		}
+		until = time.Now().Add(d)
+	}
+
+	status, err := h.webhooks.Disable(c.Param("id"), until)
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(status))
+}
+
+// EnableWebhook handles POST /admin/webhooks/:id/enable.
+func (h *Handler) EnableWebhook(c *gin.Context) {
+	status, err := h.webhooks.Enable(c.Param("id"))
+	if err != nil {
+		h.writeError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(status))
+}
@@ -80,8 +80,18 @@ const (
 	CodeNotFound        = "NOT_FOUND"
 	CodeConflict        = "CONFLICT"
 	CodeInternalServer  = "INTERNAL_ERROR"
+	CodeRequestCanceled = "REQUEST_CANCELED"
+	CodeTimeout         = "TIMEOUT"
+	CodeRequestTimeout  = "REQUEST_TIMEOUT"
+	CodeUnavailable     = "SERVICE_UNAVAILABLE"
+	CodeTooLarge        = "PAYLOAD_TOO_LARGE"
+	CodeLocked          = "LOCKED"
 )
 
+// StatusClientClosedRequest is the non-standard status used when the client
+// disconnects before the response is written.
+const StatusClientClosedRequest = 499
+
 // Standard errors
 var (
 	ErrBadRequest = &AppError{
@@ -138,6 +148,54 @@ var (
 		HTTPStatus: http.StatusInternalServerError,
 	}
 
+	ErrRequestCanceled = &AppError{
+		Code:       "REQUEST_CANCELED",
+		Message:    "Request was canceled",
+		HTTPStatus: StatusClientClosedRequest,
+	}
+
+	ErrTimeout = &AppError{
+		Code:       "TIMEOUT",
+		Message:    "Query timed out",
+		HTTPStatus: http.StatusGatewayTimeout,
+	}
+
+	ErrRequestTimeout = &AppError{
+		Code:       "REQUEST_TIMEOUT",
+		Message:    "Request timed out",
+		HTTPStatus: http.StatusGatewayTimeout,
+	}
+
+	ErrServiceUnavailable = &AppError{
+		Code:       "SERVICE_UNAVAILABLE",
+		Message:    "Database is unavailable",
+		HTTPStatus: http.StatusServiceUnavailable,
+	}
+
+	ErrPayloadTooLarge = &AppError{
+		Code:       "PAYLOAD_TOO_LARGE",
+		Message:    "Request body is too large",
+		HTTPStatus: http.StatusRequestEntityTooLarge,
+	}
+
+	ErrLocked = &AppError{
+		Code:       "LOCKED",
+		Message:    "Collection is locked",
+		HTTPStatus: http.StatusLocked,
+	}
+
+	ErrConfirmationRequired = &AppError{
+		Code:       "CONFIRMATION_REQUIRED",
+		Message:    "Confirmation required",
+		HTTPStatus: http.StatusPreconditionRequired,
+	}
+
+	ErrPendingApproval = &AppError{
+		Code:       "PENDING_APPROVAL",
+		Message:    "Change is awaiting approval",
+		HTTPStatus: http.StatusAccepted,
+	}
+
 	ErrCollectionNotFound = &AppError{
 		Code:       "COLLECTION_NOT_FOUND",
 		Message:    "Collection not found",
@@ -0,0 +1,323 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// Defaults for AccountConfig.
+const (
+	DefaultEmailChangeTemplate = "email_change"
+	DefaultEmailChangeTTL      = 24 * time.Hour
+)
+
+// MinPasswordLength is the shortest password PATCH /auth/me accepts.
+const MinPasswordLength = 8
+
+// EmailSender mails a template to addresses, such as a notify.Notifier.
+type EmailSender interface {
+	Send(ctx context.Context, templateName string, to []string, data any) error
+}
+
+// UserUpdate holds the account fields to change. Nil fields are kept.
+type UserUpdate struct {
+	Username *string
+	Email    *string
+	Metadata map[string]any
+}
+
+// UserUpdater is a UserStore that updates accounts, serving PATCH
+// /auth/me. DBUserStore implements it.
+type UserUpdater interface {
+	// UpdateUser updates a user's username, email and metadata.
+	UpdateUser(ctx context.Context, userID string, update UserUpdate) error
+}
+
+// EmailChange is an email change awaiting confirmation from the new
+// address.
+type EmailChange struct {
+	UserID    string    `db:"user_id"`
+	Email     string    `db:"email"`
+	ExpiresAt time.Time `db:"expires_at"`
+}
+
+// EmailChangeData is the data of the email change template.
+type EmailChangeData struct {
+	User      *User
+	Email     string
+	Token     string
+	ExpiresAt time.Time
+}
+
+// AccountConfig configures account management.
+type AccountConfig struct {
+	// Mailer sends email change confirmations. Email changes are rejected
+	// without one.
+	Mailer EmailSender
+
+	// EmailChanges stores pending email changes.
+	EmailChanges *EmailChangeStore
+
+	// EmailChangeTemplate is the template mailed to the new address, with
+	// EmailChangeData.
+	// Default: "email_change"
+	EmailChangeTemplate string
+
+	// EmailChangeTTL is how long confirmation tokens are valid.
+	// Default: 24 hours
+	EmailChangeTTL time.Duration
+}
+
+// UpdateMeRequest represents an account update request.
+type UpdateMeRequest struct {
+	Username *string        `json:"username"`
+	Email    *string        `json:"email"`
+	Password *string        `json:"password"`
+	Metadata map[string]any `json:"metadata"`
+
+	// CurrentPassword is required to change the email or password.
+	CurrentPassword string `json:"current_password"`
+}
+
+// UpdateMeResponse is the updated user, with the email awaiting
+// confirmation, if any.
+type UpdateMeResponse struct {
+	*User
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// ConfirmEmailRequest represents an email change confirmation request.
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// hashToken returns the hex SHA-256 of a token, as stored.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// UpdateMe handles PATCH /auth/me requests. Usernames and metadata change
+// at once; metadata keys set to null are removed. Passwords change once the
+// current one is given. A new email is mailed a confirmation token and
+// changes once it is confirmed at POST /auth/email/confirm.
+func (h *Handler) UpdateMe(c *gin.Context) {
+	current := GetUser(c)
+	if current == nil {
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		return
+	}
+	updater, ok := h.userStore.(UserUpdater)
+	if !ok {
+		response.JSON(c, http.StatusNotImplemented, response.Error(
+			"NOT_IMPLEMENTED",
+			"The user store cannot update accounts",
+		))
+		return
+	}
+
+	var req UpdateMeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Invalid request body"),
+		))
+		return
+	}
+
+	ctx := c.Request.Context()
+	user, err := h.userStore.GetByID(ctx, current.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	update, err := h.accountUpdate(ctx, user, req)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	var pendingEmail string
+	if req.Email != nil && *req.Email != user.Email {
+		pendingEmail = *req.Email
+	}
+	if pendingEmail != "" && (h.account.Mailer == nil || h.account.EmailChanges == nil) {
+		response.JSON(c, http.StatusNotImplemented, response.Error(
+			"NOT_IMPLEMENTED",
+			"Email changes require a mailer",
+		))
+		return
+	}
+
+	if req.Password != nil {
+		hash, err := HashPassword(*req.Password)
+		if err != nil {
+			h.handleError(c, apperror.ErrInternalServer.WithError(err))
+			return
+		}
+		if err := h.userStore.UpdatePassword(ctx, user.ID, hash); err != nil {
+			h.handleError(c, err)
+			return
+		}
+	}
+	if update.Username != nil || update.Metadata != nil {
+		if err := updater.UpdateUser(ctx, user.ID, update); err != nil {
+			h.handleError(c, err)
+			return
+		}
+	}
+	if pendingEmail != "" {
+		if err := h.requestEmailChange(ctx, user, pendingEmail); err != nil {
+			h.handleError(c, err)
+			return
+		}
+	}
+
+	user, err = h.userStore.GetByID(ctx, user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(UpdateMeResponse{User: user, PendingEmail: pendingEmail}))
+}
+
+// accountUpdate validates an account update request.
+func (h *Handler) accountUpdate(ctx context.Context, user *User, req UpdateMeRequest) (UserUpdate, error) {
+	var update UserUpdate
+	errs := apperror.NewValidationErrors()
+
+	if req.Username != nil {
+		username := strings.TrimSpace(*req.Username)
+		switch {
+		case username == "":
+			errs.Add("username", "Username is required")
+		case len(username) > 100:
+			errs.Add("username", "Username must be at most 100 characters")
+		case username != user.Username:
+			update.Username = &username
+		}
+	}
+
+	if req.Email != nil && *req.Email != user.Email {
+		if addr, err := mail.ParseAddress(*req.Email); err != nil || addr.Address != *req.Email {
+			errs.Add("email", "Invalid email address")
+		}
+	}
+
+	if req.Password != nil && len(*req.Password) < MinPasswordLength {
+		errs.Add("password", "Password must be at least 8 characters")
+	}
+
+	if req.Metadata != nil {
+		update.Metadata = make(map[string]any, len(user.Metadata)+len(req.Metadata))
+		for key, value := range user.Metadata {
+			update.Metadata[key] = value
+		}
+		for key, value := range req.Metadata {
+			if value == nil {
+				delete(update.Metadata, key)
+				continue
+			}
+			update.Metadata[key] = value
+		}
+	}
+
+	if errs.HasErrors() {
+		return update, apperror.ErrValidation.WithMessage(errs.Error()).WithDetails(errs.Errors)
+	}
+
+	if update.Username != nil {
+		if other, err := h.userStore.GetByUsername(ctx, *update.Username); err == nil && other.ID != user.ID {
+			return update, apperror.ErrConflict.WithMessage("Username is already taken")
+		}
+	}
+	if req.Email != nil && *req.Email != user.Email {
+		if other, err := h.userStore.GetByEmail(ctx, *req.Email); err == nil && other.ID != user.ID {
+			return update, apperror.ErrConflict.WithMessage("Email is already in use")
+		}
+	}
+
+	// Changing how the account signs in needs the current password
+	if (req.Email != nil && *req.Email != user.Email) || req.Password != nil {
+		if req.CurrentPassword == "" {
+			return update, apperror.ErrBadRequest.WithMessage("Current password is required")
+		}
+		hash, err := h.userStore.GetPasswordHash(ctx, user.ID)
+		if err != nil {
+			return update, err
+		}
+		if !CheckPassword(req.CurrentPassword, hash) {
+			return update, apperror.ErrInvalidCredentials
+		}
+	}
+
+	return update, nil
+}
+
+// requestEmailChange stores a pending email change and mails its
+// confirmation token to the new address.
+func (h *Handler) requestEmailChange(ctx context.Context, user *User, email string) error {
+	token, err := generateSecureToken(32)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	change := &EmailChange{
+		UserID:    user.ID,
+		Email:     email,
+		ExpiresAt: time.Now().Add(h.account.EmailChangeTTL),
+	}
+	if err := h.account.EmailChanges.Create(ctx, change, token); err != nil {
+		return err
+	}
+
+	data := EmailChangeData{User: user, Email: email, Token: token, ExpiresAt: change.ExpiresAt}
+	if err := h.account.Mailer.Send(ctx, h.account.EmailChangeTemplate, []string{email}, data); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// ConfirmEmail handles POST /auth/email/confirm requests, applying the
+// email change of a confirmation token.
+func (h *Handler) ConfirmEmail(c *gin.Context) {
+	var req ConfirmEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Token is required"),
+		))
+		return
+	}
+
+	ctx := c.Request.Context()
+	change, err := h.account.EmailChanges.Consume(ctx, req.Token)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	// The address may have been taken since the change was requested
+	if other, err := h.userStore.GetByEmail(ctx, change.Email); err == nil && other.ID != change.UserID {
+		h.handleError(c, apperror.ErrConflict.WithMessage("Email is already in use"))
+		return
+	}
+	if err := h.userStore.(UserUpdater).UpdateUser(ctx, change.UserID, UserUpdate{Email: &change.Email}); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	user, err := h.userStore.GetByID(ctx, change.UserID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(user))
+}
@@ -0,0 +1,127 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/cache"
+)
+
+// CacheSessionStore implements SessionStore on a cache.Store, so session
+// lookups do not reach the database. Sessions expire with their cache entries.
+type CacheSessionStore struct {
+	store cache.Store
+}
+
+// NewCacheSessionStore creates a session store backed by a cache.
+func NewCacheSessionStore(store cache.Store) *CacheSessionStore {
+	return &CacheSessionStore{store: store}
+}
+
+// NewRedisSessionStore creates a session store backed by Redis.
+// Keys are prefixed with "tugo:".
+func NewRedisSessionStore(client redis.UniversalClient) *CacheSessionStore {
+	return NewCacheSessionStore(cache.NewRedisStore(client, "tugo:"))
+}
+
+// sessionKey returns the cache key of a session.
+func sessionKey(token string) string {
+	return "session:" + token
+}
+
+// revokedKey returns the cache key holding when a user's sessions were revoked.
+func revokedKey(userID string) string {
+	return "session_revoked:" + userID
+}
+
+// Create creates a new session.
+func (s *CacheSessionStore) Create(ctx context.Context, session *Session) error {
+	if time.Until(session.ExpiresAt) <= 0 {
+		return nil
+	}
+	if err := s.put(ctx, session); err != nil {
+		return err
+	}
+	return s.addToIndex(ctx, session)
+}
+
+// put stores a session until it expires.
+func (s *CacheSessionStore) put(ctx context.Context, session *Session) error {
+	ttl := time.Until(session.ExpiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(session)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	if err := s.store.Set(ctx, sessionKey(session.Token), data, ttl); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// GetByToken retrieves a session by token.
+// Sessions created before their user's sessions were revoked are not found.
+func (s *CacheSessionStore) GetByToken(ctx context.Context, token string) (*Session, error) {
+	data, err := s.store.Get(ctx, sessionKey(token))
+	if err != nil {
+		if errors.Is(err, cache.ErrMiss) {
+			return nil, apperror.ErrNotFound.WithMessage("Session not found")
+		}
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	var session Session
+	if err := json.Unmarshal(data, &session); err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	revoked, err := s.store.Get(ctx, revokedKey(session.UserID))
+	switch {
+	case errors.Is(err, cache.ErrMiss):
+	case err != nil:
+		return nil, apperror.ErrInternalServer.WithError(err)
+	default:
+		if at, _ := strconv.ParseInt(string(revoked), 10, 64); session.CreatedAt.UnixNano() <= at {
+			_ = s.store.Delete(ctx, sessionKey(token))
+			return nil, apperror.ErrNotFound.WithMessage("Session not found")
+		}
+	}
+
+	return &session, nil
+}
+
+// Delete deletes a session.
+func (s *CacheSessionStore) Delete(ctx context.Context, token string) error {
+	if err := s.store.Delete(ctx, sessionKey(token)); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// DeleteByUserID deletes all sessions for a user. Rather than tracking each
+// user's tokens, it records the time of revocation; older sessions are
+// rejected and removed when next looked up.
+func (s *CacheSessionStore) DeleteByUserID(ctx context.Context, userID string) error {
+	now := strconv.FormatInt(time.Now().UnixNano(), 10)
+	if err := s.store.Set(ctx, revokedKey(userID), []byte(now), 0); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	if err := s.store.Delete(ctx, sessionIndexKey(userID)); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// CleanExpired removes expired sessions. Cache entries expire on their own,
+// so there is nothing to do.
+func (s *CacheSessionStore) CleanExpired(ctx context.Context) error {
+	return nil
+}
@@ -0,0 +1,156 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/cache"
+)
+
+// mockCache implements cache.Store for testing
+type mockCache struct {
+	values map[string][]byte
+}
+
+func (m *mockCache) Get(ctx context.Context, key string) ([]byte, error) {
+	if v, ok := m.values[key]; ok {
+		return v, nil
+	}
+	return nil, cache.ErrMiss
+}
+
+func (m *mockCache) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	m.values[key] = value
+	return nil
+}
+
+func (m *mockCache) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if _, ok := m.values[key]; ok {
+		return false, nil
+	}
+	m.values[key] = value
+	return true, nil
+}
+
+func (m *mockCache) Delete(ctx context.Context, keys ...string) error {
+	for _, key := range keys {
+		delete(m.values, key)
+	}
+	return nil
+}
+
+func (m *mockCache) TTL(ctx context.Context, key string) (time.Duration, error) {
+	if _, ok := m.values[key]; ok {
+		return 0, nil
+	}
+	return 0, cache.ErrMiss
+}
+
+func TestCacheSessionStore(t *testing.T) {
+	ctx := context.Background()
+	store := NewCacheSessionStore(&mockCache{values: make(map[string][]byte)})
+
+	newSession := func(token, userID string) *Session {
+		return &Session{
+			ID:        token,
+			UserID:    userID,
+			Token:     token,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	for _, s := range []*Session{newSession("a1", "alice"), newSession("a2", "alice"), newSession("b1", "bob")} {
+		if err := store.Create(ctx, s); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	got, err := store.GetByToken(ctx, "a1")
+	if err != nil || got.UserID != "alice" {
+		t.Fatalf("GetByToken() = %v, %v", got, err)
+	}
+
+	if err := store.Delete(ctx, "a1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if _, err := store.GetByToken(ctx, "a1"); err == nil {
+		t.Error("expected deleted session to be missing")
+	}
+
+	if err := store.DeleteByUserID(ctx, "alice"); err != nil {
+		t.Fatalf("DeleteByUserID() error = %v", err)
+	}
+	if _, err := store.GetByToken(ctx, "a2"); err == nil {
+		t.Error("expected revoked session to be missing")
+	}
+	if _, err := store.GetByToken(ctx, "b1"); err != nil {
+		t.Errorf("expected other user's session to remain, got %v", err)
+	}
+
+	// Sessions created after a revocation are valid
+	time.Sleep(time.Millisecond)
+	if err := store.Create(ctx, newSession("a3", "alice")); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if _, err := store.GetByToken(ctx, "a3"); err != nil {
+		t.Errorf("expected new session after revocation, got %v", err)
+	}
+}
+
+func TestCacheSessionStoreListAndDelete(t *testing.T) {
+	ctx := context.Background()
+	store := NewCacheSessionStore(&mockCache{values: make(map[string][]byte)})
+
+	for _, token := range []string{"a1", "a2", "a3"} {
+		session := &Session{
+			ID:        "id-" + token,
+			UserID:    "alice",
+			Token:     token,
+			CreatedAt: time.Now(),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+		if err := store.Create(ctx, session); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+	if err := store.Delete(ctx, "a1"); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+
+	sessions, err := store.ListByUserID(ctx, "alice")
+	if err != nil {
+		t.Fatalf("ListByUserID() error = %v", err)
+	}
+	if len(sessions) != 2 || sessions[0].Token != "a3" || sessions[1].Token != "a2" {
+		t.Fatalf("ListByUserID() = %v, want a3, a2", sessions)
+	}
+
+	tests := []struct {
+		name    string
+		userID  string
+		id      string
+		wantErr bool
+	}{
+		{"own session", "alice", "id-a2", false},
+		{"already deleted", "alice", "id-a2", true},
+		{"other user's session", "bob", "id-a3", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := store.DeleteByID(ctx, tt.userID, tt.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("DeleteByID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+
+	if err := store.Touch(ctx, "a3", time.Unix(100, 0)); err != nil {
+		t.Fatalf("Touch() error = %v", err)
+	}
+	got, err := store.GetByToken(ctx, "a3")
+	if err != nil || !got.LastSeenAt.Equal(time.Unix(100, 0)) {
+		t.Errorf("GetByToken() after Touch() = %v, %v", got, err)
+	}
+}
@@ -0,0 +1,74 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"regexp"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// claimNameRegex matches names usable as custom claims and columns.
+var claimNameRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ValidClaimName reports whether name can be used as a custom claim.
+func ValidClaimName(name string) bool {
+	return claimNameRegex.MatchString(name)
+}
+
+// ClaimsEnricher returns custom claims for a user, such as a tenant or
+// department ID. JWT access tokens carry them under "claims"; sessions and
+// signed requests compute them per request. Nil or empty results add
+// nothing.
+type ClaimsEnricher func(ctx context.Context, user *User) map[string]any
+
+// ColumnClaims returns an enricher reading columns of a user table as
+// claims named after them.
+func ColumnClaims(db *sqlx.DB, table string, columns []string) (ClaimsEnricher, error) {
+	if !claimNameRegex.MatchString(table) {
+		return nil, fmt.Errorf("invalid user table '%s'", table)
+	}
+	for _, column := range columns {
+		if !claimNameRegex.MatchString(column) {
+			return nil, fmt.Errorf("invalid claim column '%s'", column)
+		}
+	}
+
+	query := db.Rebind(`SELECT ` + strings.Join(columns, ", ") + ` FROM ` + table + ` WHERE id = ?`)
+	return func(ctx context.Context, user *User) map[string]any {
+		row := make(map[string]any, len(columns))
+		if err := db.QueryRowxContext(ctx, query, user.ID).MapScan(row); err != nil {
+			return nil
+		}
+		for key, value := range row {
+			if b, ok := value.([]byte); ok {
+				row[key] = string(b)
+			}
+		}
+		return row
+	}, nil
+}
+
+// MergeClaims returns an enricher combining the claims of enrichers, later
+// ones overriding earlier ones. Nil enrichers are skipped.
+func MergeClaims(enrichers ...ClaimsEnricher) ClaimsEnricher {
+	return func(ctx context.Context, user *User) map[string]any {
+		var claims map[string]any
+		for _, enrich := range enrichers {
+			if enrich == nil {
+				continue
+			}
+			extra := enrich(ctx, user)
+			if len(extra) == 0 {
+				continue
+			}
+			if claims == nil {
+				claims = make(map[string]any, len(extra))
+			}
+			maps.Copy(claims, extra)
+		}
+		return claims
+	}
+}
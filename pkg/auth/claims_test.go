@@ -0,0 +1,75 @@
+package auth
+
+import (
+	"context"
+	"reflect"
+	"testing"
+)
+
+func TestMergeClaims(t *testing.T) {
+	user := &User{ID: "u1"}
+	static := func(claims map[string]any) ClaimsEnricher {
+		return func(ctx context.Context, user *User) map[string]any { return claims }
+	}
+
+	tests := []struct {
+		name      string
+		enrichers []ClaimsEnricher
+		want      map[string]any
+	}{
+		{"none", nil, nil},
+		{"nil and empty", []ClaimsEnricher{nil, static(nil), static(map[string]any{})}, nil},
+		{"single", []ClaimsEnricher{static(map[string]any{"tenant": "t1"})}, map[string]any{"tenant": "t1"}},
+		{
+			"later overrides",
+			[]ClaimsEnricher{static(map[string]any{"tenant": "t1", "dept": "d1"}), static(map[string]any{"dept": "d2"})},
+			map[string]any{"tenant": "t1", "dept": "d2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MergeClaims(tt.enrichers...)(context.Background(), user)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("MergeClaims() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestJWTProviderCustomClaims(t *testing.T) {
+	provider := NewJWTProvider(JWTConfig{Secret: "test-secret-key-for-testing"}, newMockUserStore())
+	provider.SetClaimsEnricher(func(ctx context.Context, user *User) map[string]any {
+		return map[string]any{"department_id": "d-" + user.ID}
+	})
+
+	tokens, err := provider.GenerateTokens(context.Background(), &User{ID: "u1", Username: "alice"})
+	if err != nil {
+		t.Fatalf("GenerateTokens() error = %v", err)
+	}
+	claims, err := provider.ValidateToken(context.Background(), tokens.AccessToken)
+	if err != nil {
+		t.Fatalf("ValidateToken() error = %v", err)
+	}
+	if got := claims.Extra["department_id"]; got != "d-u1" {
+		t.Errorf("Extra[department_id] = %v, want d-u1", got)
+	}
+}
+
+func TestValidClaimName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{"department_id", true},
+		{"_tenant", true},
+		{"1tenant", false},
+		{"tenant-id", false},
+		{"a.b", false},
+		{"", false},
+	}
+	for _, tt := range tests {
+		if got := ValidClaimName(tt.name); got != tt.want {
+			t.Errorf("ValidClaimName(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
 	"github.com/thienel/tugo/pkg/response"
 	"go.uber.org/zap"
 )
@@ -15,6 +16,8 @@ type Handler struct {
 	userStore     UserStore
 	totpManager   *TOTPManager
 	sessionConfig *SessionConfig
+	sessions      SessionManager
+	account       AccountConfig
 	logger        *zap.SugaredLogger
 }
 
@@ -24,18 +27,34 @@ type HandlerConfig struct {
 	UserStore     UserStore
 	TOTPManager   *TOTPManager
 	SessionConfig *SessionConfig
-	Logger        *zap.SugaredLogger
+	// SessionStore serves GET and DELETE /auth/sessions when it is a
+	// SessionManager.
+	SessionStore SessionStore
+	// Account configures PATCH /auth/me.
+	Account AccountConfig
+	Logger  *zap.SugaredLogger
 }
 
 // NewHandler creates a new auth handler.
 func NewHandler(config HandlerConfig) *Handler {
-	return &Handler{
+	h := &Handler{
 		provider:      config.Provider,
 		userStore:     config.UserStore,
 		totpManager:   config.TOTPManager,
 		sessionConfig: config.SessionConfig,
+		account:       config.Account,
 		logger:        config.Logger,
 	}
+	if h.account.EmailChangeTemplate == "" {
+		h.account.EmailChangeTemplate = DefaultEmailChangeTemplate
+	}
+	if h.account.EmailChangeTTL <= 0 {
+		h.account.EmailChangeTTL = DefaultEmailChangeTTL
+	}
+	if sessions, ok := config.SessionStore.(SessionManager); ok {
+		h.sessions = sessions
+	}
+	return h
 }
 
 // LoginRequest represents a login request.
@@ -54,7 +73,7 @@ type RefreshRequest struct {
 func (h *Handler) Login(c *gin.Context) {
 	var req LoginRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
@@ -73,7 +92,7 @@ func (h *Handler) Login(c *gin.Context) {
 	// Check if TOTP is enabled
 	if user.TOTPEnabled {
 		if req.TOTPCode == "" {
-			c.JSON(http.StatusUnauthorized, response.Error(
+			response.JSON(c, http.StatusUnauthorized, response.Error(
 				"TOTP_REQUIRED",
 				"TOTP code is required",
 			))
@@ -90,7 +109,11 @@ func (h *Handler) Login(c *gin.Context) {
 	}
 
 	// Generate tokens
-	tokens, err := h.provider.GenerateTokens(c.Request.Context(), user)
+	ctx := WithClientInfo(c.Request.Context(), ClientInfo{
+		UserAgent: c.Request.UserAgent(),
+		IPAddress: c.ClientIP(),
+	})
+	tokens, err := h.provider.GenerateTokens(ctx, user)
 	if err != nil {
 		h.handleError(c, err)
 		return
@@ -109,20 +132,10 @@ func (h *Handler) Login(c *gin.Context) {
 
 // Logout handles POST /auth/logout requests.
 func (h *Handler) Logout(c *gin.Context) {
-	// Get token from header or cookie
-	token := ""
-	authHeader := c.GetHeader("Authorization")
-	if authHeader != "" {
-		token = ExtractTokenFromHeader(authHeader)
-	}
-	if token == "" && h.sessionConfig != nil {
-		token, _ = c.Cookie(h.sessionConfig.CookieName)
-	}
-
-	if token != "" {
+	if token := h.requestToken(c); token != "" {
 		// Revoke token
 		if err := h.provider.RevokeToken(c.Request.Context(), token); err != nil {
-			h.logger.Warnw("Failed to revoke token", "error", err)
+			requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to revoke token", "error", err)
 		}
 	}
 
@@ -150,7 +163,7 @@ func (h *Handler) Refresh(c *gin.Context) {
 	}
 
 	if refreshToken == "" {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Refresh token is required"),
 		))
 		return
@@ -175,7 +188,7 @@ func (h *Handler) Refresh(c *gin.Context) {
 func (h *Handler) Me(c *gin.Context) {
 	user := GetUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 		return
 	}
 
@@ -190,7 +203,7 @@ type TOTPSetupRequest struct {
 // TOTPSetup handles POST /auth/totp/setup requests.
 func (h *Handler) TOTPSetup(c *gin.Context) {
 	if h.totpManager == nil {
-		c.JSON(http.StatusNotImplemented, response.Error(
+		response.JSON(c, http.StatusNotImplemented, response.Error(
 			"NOT_IMPLEMENTED",
 			"TOTP is not enabled",
 		))
@@ -199,14 +212,14 @@ func (h *Handler) TOTPSetup(c *gin.Context) {
 
 	user := GetUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 		return
 	}
 
 	// Verify password first
 	var req TOTPSetupRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
@@ -220,7 +233,7 @@ func (h *Handler) TOTPSetup(c *gin.Context) {
 	}
 
 	if !CheckPassword(req.Password, passwordHash) {
-		c.JSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrInvalidCredentials))
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrInvalidCredentials))
 		return
 	}
 
@@ -242,7 +255,7 @@ type TOTPEnableRequest struct {
 // TOTPEnable handles POST /auth/totp/enable requests.
 func (h *Handler) TOTPEnable(c *gin.Context) {
 	if h.totpManager == nil {
-		c.JSON(http.StatusNotImplemented, response.Error(
+		response.JSON(c, http.StatusNotImplemented, response.Error(
 			"NOT_IMPLEMENTED",
 			"TOTP is not enabled",
 		))
@@ -251,13 +264,13 @@ func (h *Handler) TOTPEnable(c *gin.Context) {
 
 	user := GetUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 		return
 	}
 
 	var req TOTPEnableRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
@@ -275,7 +288,7 @@ func (h *Handler) TOTPEnable(c *gin.Context) {
 // TOTPDisable handles POST /auth/totp/disable requests.
 func (h *Handler) TOTPDisable(c *gin.Context) {
 	if h.totpManager == nil {
-		c.JSON(http.StatusNotImplemented, response.Error(
+		response.JSON(c, http.StatusNotImplemented, response.Error(
 			"NOT_IMPLEMENTED",
 			"TOTP is not enabled",
 		))
@@ -284,13 +297,13 @@ func (h *Handler) TOTPDisable(c *gin.Context) {
 
 	user := GetUser(c)
 	if user == nil {
-		c.JSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 		return
 	}
 
 	var req TOTPEnableRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
 			apperror.ErrBadRequest.WithMessage("Invalid request body"),
 		))
 		return
@@ -310,6 +323,11 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup, authMiddleware gin.Handler
 	// Public routes (no auth required)
 	rg.POST("/login", h.Login)
 	rg.POST("/refresh", h.Refresh)
+	if h.account.EmailChanges != nil {
+		if _, ok := h.userStore.(UserUpdater); ok {
+			rg.POST("/email/confirm", h.ConfirmEmail)
+		}
+	}
 
 	// Protected routes (auth required)
 	protected := rg.Group("")
@@ -318,20 +336,39 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup, authMiddleware gin.Handler
 	}
 	protected.POST("/logout", h.Logout)
 	protected.GET("/me", h.Me)
+	protected.PATCH("/me", h.UpdateMe)
 	protected.POST("/totp/setup", h.TOTPSetup)
 	protected.POST("/totp/enable", h.TOTPEnable)
 	protected.POST("/totp/disable", h.TOTPDisable)
+	if h.sessions != nil {
+		protected.GET("/sessions", h.ListSessions)
+		protected.DELETE("/sessions/:id", h.DeleteSession)
+	}
+}
+
+// requestToken returns the request's token from the Authorization header
+// or the session cookie.
+func (h *Handler) requestToken(c *gin.Context) string {
+	if token := ExtractTokenFromHeader(c.GetHeader("Authorization")); token != "" {
+		return token
+	}
+	if h.sessionConfig != nil {
+		token, _ := c.Cookie(h.sessionConfig.CookieName)
+		return token
+	}
+	return ""
 }
 
 // handleError converts errors to HTTP responses.
 func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
 	if appErr, ok := apperror.AsAppError(err); ok {
-		c.JSON(appErr.HTTPStatus, response.FromAppError(appErr))
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
 		return
 	}
 
-	h.logger.Errorw("Unexpected auth error", "error", err)
-	c.JSON(http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected auth error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
 }
 
 // setSessionCookie sets the session cookie.
@@ -41,12 +41,16 @@ type JWTClaims struct {
 	Role     string `json:"role"`
 	RoleID   string `json:"role_id,omitempty"`
 	Type     string `json:"type"` // "access" or "refresh"
+
+	// Claims holds custom claims on access tokens.
+	Claims map[string]any `json:"claims,omitempty"`
 }
 
 // JWTProvider implements JWT-based authentication.
 type JWTProvider struct {
 	config    JWTConfig
 	userStore UserStore
+	enrich    ClaimsEnricher
 }
 
 // NewJWTProvider creates a new JWT provider.
@@ -67,6 +71,12 @@ func NewJWTProvider(config JWTConfig, userStore UserStore) *JWTProvider {
 	}
 }
 
+// SetClaimsEnricher sets the source of custom claims added to access
+// tokens. Claims are refreshed with the tokens.
+func (p *JWTProvider) SetClaimsEnricher(enrich ClaimsEnricher) {
+	p.enrich = enrich
+}
+
 // Name returns the provider name.
 func (p *JWTProvider) Name() string {
 	return "jwt"
@@ -123,6 +133,9 @@ func (p *JWTProvider) GenerateTokens(ctx context.Context, user *User) (*TokenPai
 		RoleID:   user.RoleID,
 		Type:     "access",
 	}
+	if p.enrich != nil {
+		accessClaims.Claims = p.enrich(ctx, user)
+	}
 
 	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
 	accessTokenString, err := accessToken.SignedString([]byte(p.config.Secret))
@@ -190,6 +203,7 @@ func (p *JWTProvider) ValidateToken(ctx context.Context, tokenString string) (*C
 		Username: claims.Username,
 		Role:     claims.Role,
 		RoleID:   claims.RoleID,
+		Extra:    claims.Claims,
 	}, nil
 }
 
@@ -20,6 +20,14 @@ type MiddlewareConfig struct {
 	// SessionConfig is used for cookie-based auth.
 	SessionConfig *SessionConfig
 
+	// Signatures verifies HMAC-signed requests, checked before tokens.
+	// Nil disables request signing.
+	Signatures *SignatureVerifier
+
+	// ClaimsEnricher adds custom claims to requests whose token carries
+	// none, such as sessions and signed requests.
+	ClaimsEnricher ClaimsEnricher
+
 	// SkipPaths are paths that don't require authentication.
 	SkipPaths []string
 
@@ -39,10 +47,26 @@ func Middleware(config MiddlewareConfig) gin.HandlerFunc {
 
 		var claims *Claims
 		var err error
+		var signed bool
+
+		// Signed requests act as their key's user; a bad signature fails
+		// even when authentication is optional
+		if config.Signatures != nil && IsSigned(c.Request) {
+			userID, signErr := config.Signatures.Verify(c.Request)
+			if signErr != nil {
+				appErr, ok := apperror.AsAppError(signErr)
+				if !ok {
+					appErr = apperror.ErrInternalServer
+				}
+				response.Abort(c, appErr.HTTPStatus, response.FromAppError(appErr))
+				return
+			}
+			claims, signed = &Claims{UserID: userID}, true
+		}
 
 		// Try to extract token from Authorization header
 		authHeader := c.GetHeader("Authorization")
-		if authHeader != "" {
+		if claims == nil && authHeader != "" {
 			token := ExtractTokenFromHeader(authHeader)
 			if token != "" {
 				claims, err = config.Provider.ValidateToken(c.Request.Context(), token)
@@ -67,18 +91,18 @@ func Middleware(config MiddlewareConfig) gin.HandlerFunc {
 			// Required auth - return error
 			if err != nil {
 				if appErr, ok := apperror.AsAppError(err); ok {
-					c.AbortWithStatusJSON(appErr.HTTPStatus, response.FromAppError(appErr))
+					response.Abort(c, appErr.HTTPStatus, response.FromAppError(appErr))
 					return
 				}
 			}
-			c.AbortWithStatusJSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+			response.Abort(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 			return
 		}
 
 		// Load user from store
 		user, err := config.UserStore.GetByID(c.Request.Context(), claims.UserID)
 		if err != nil {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, response.FromAppError(
+			response.Abort(c, http.StatusUnauthorized, response.FromAppError(
 				apperror.ErrUnauthorized.WithMessage("User not found"),
 			))
 			return
@@ -86,12 +110,19 @@ func Middleware(config MiddlewareConfig) gin.HandlerFunc {
 
 		// Check if user is active
 		if user.Status != "" && user.Status != "active" {
-			c.AbortWithStatusJSON(http.StatusForbidden, response.FromAppError(
+			response.Abort(c, http.StatusForbidden, response.FromAppError(
 				apperror.ErrForbidden.WithMessage("Account is not active"),
 			))
 			return
 		}
 
+		if signed {
+			claims.Username, claims.Role, claims.RoleID = user.Username, user.Role, user.RoleID
+		}
+		if claims.Extra == nil && config.ClaimsEnricher != nil {
+			claims.Extra = config.ClaimsEnricher(c.Request.Context(), user)
+		}
+
 		// Set user and claims in context
 		ctx := SetUserInContext(c.Request.Context(), user)
 		ctx = SetClaimsInContext(ctx, claims)
@@ -145,18 +176,18 @@ func RequireRole(roles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		user, exists := c.Get("user")
 		if !exists {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+			response.Abort(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 			return
 		}
 
 		u, ok := user.(*User)
 		if !ok {
-			c.AbortWithStatusJSON(http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+			response.Abort(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
 			return
 		}
 
 		if !roleSet[strings.ToLower(u.Role)] {
-			c.AbortWithStatusJSON(http.StatusForbidden, response.FromAppError(
+			response.Abort(c, http.StatusForbidden, response.FromAppError(
 				apperror.ErrForbidden.WithMessage("Insufficient permissions"),
 			))
 			return
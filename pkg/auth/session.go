@@ -6,6 +6,7 @@ import (
 	"encoding/base64"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/thienel/tugo/pkg/apperror"
 )
 
@@ -117,12 +118,17 @@ func (p *SessionProvider) GenerateTokens(ctx context.Context, user *User) (*Toke
 	}
 
 	// Create session
+	now := time.Now()
+	client := clientInfoFrom(ctx)
 	session := &Session{
-		ID:        generateID(),
-		UserID:    user.ID,
-		Token:     token,
-		ExpiresAt: time.Now().Add(time.Duration(p.config.MaxAge) * time.Second),
-		CreatedAt: time.Now(),
+		ID:         generateID(),
+		UserID:     user.ID,
+		Token:      token,
+		ExpiresAt:  now.Add(time.Duration(p.config.MaxAge) * time.Second),
+		CreatedAt:  now,
+		UserAgent:  client.UserAgent,
+		IPAddress:  client.IPAddress,
+		LastSeenAt: now,
 	}
 
 	if err := p.sessionStore.Create(ctx, session); err != nil {
@@ -150,6 +156,11 @@ func (p *SessionProvider) ValidateToken(ctx context.Context, token string) (*Cla
 		return nil, apperror.ErrTokenExpired.WithMessage("Session expired")
 	}
 
+	// Record the session's use, at most once per interval
+	if manager, ok := p.sessionStore.(SessionManager); ok && time.Since(session.LastSeenAt) > DefaultSessionTouchInterval {
+		_ = manager.Touch(ctx, token, time.Now())
+	}
+
 	// Get user
 	user, err := p.userStore.GetByID(ctx, session.UserID)
 	if err != nil {
@@ -198,8 +209,7 @@ func generateSecureToken(length int) (string, error) {
 	return base64.URLEncoding.EncodeToString(bytes), nil
 }
 
-// generateID generates a unique ID.
+// generateID generates a unique ID, a UUID as tugo_sessions requires.
 func generateID() string {
-	token, _ := generateSecureToken(16)
-	return token
+	return uuid.NewString()
 }
@@ -0,0 +1,242 @@
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/cache"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// DefaultSessionTouchInterval is how often a session's last use is
+// recorded.
+const DefaultSessionTouchInterval = time.Minute
+
+// SessionManager is a SessionStore that lists a user's sessions and
+// records their use, serving GET and DELETE /auth/sessions. The DB and
+// cache session stores implement it.
+type SessionManager interface {
+	SessionStore
+
+	// ListByUserID returns a user's unexpired sessions, newest first.
+	ListByUserID(ctx context.Context, userID string) ([]*Session, error)
+
+	// DeleteByID deletes a session of a user by its ID.
+	DeleteByID(ctx context.Context, userID, id string) error
+
+	// Touch records the last use of a session.
+	Touch(ctx context.Context, token string, at time.Time) error
+}
+
+// ClientInfo describes the client a session is created for.
+type ClientInfo struct {
+	UserAgent string
+	IPAddress string
+}
+
+// clientInfoKey is the context key of the ClientInfo.
+const clientInfoKey contextKey = "tugo_client_info"
+
+// WithClientInfo returns a context recording the client that sessions
+// created with it belong to.
+func WithClientInfo(ctx context.Context, info ClientInfo) context.Context {
+	return context.WithValue(ctx, clientInfoKey, info)
+}
+
+// clientInfoFrom returns the ClientInfo of a context, if any.
+func clientInfoFrom(ctx context.Context) ClientInfo {
+	info, _ := ctx.Value(clientInfoKey).(ClientInfo)
+	return info
+}
+
+// SessionInfo is a session as listed to users, without its token.
+type SessionInfo struct {
+	ID         string    `json:"id"`
+	UserAgent  string    `json:"user_agent,omitempty"`
+	IPAddress  string    `json:"ip_address,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	LastSeenAt time.Time `json:"last_seen_at"`
+	// Current marks the session of the request.
+	Current bool `json:"current"`
+}
+
+// SessionInfos lists sessions without their tokens, marking the one whose
+// token is current.
+func SessionInfos(sessions []*Session, current string) []SessionInfo {
+	infos := make([]SessionInfo, 0, len(sessions))
+	for _, s := range sessions {
+		infos = append(infos, SessionInfo{
+			ID:         s.ID,
+			UserAgent:  s.UserAgent,
+			IPAddress:  s.IPAddress,
+			CreatedAt:  s.CreatedAt,
+			ExpiresAt:  s.ExpiresAt,
+			LastSeenAt: s.LastSeenAt,
+			Current:    current != "" && s.Token == current,
+		})
+	}
+	return infos
+}
+
+// ListSessions handles GET /auth/sessions requests, listing the current
+// user's active sessions.
+func (h *Handler) ListSessions(c *gin.Context) {
+	user := GetUser(c)
+	if user == nil {
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		return
+	}
+
+	sessions, err := h.sessions.ListByUserID(c.Request.Context(), user.ID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(SessionInfos(sessions, h.requestToken(c))))
+}
+
+// DeleteSession handles DELETE /auth/sessions/:id requests, signing the
+// current user out of one of their sessions.
+func (h *Handler) DeleteSession(c *gin.Context) {
+	user := GetUser(c)
+	if user == nil {
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		return
+	}
+
+	if err := h.sessions.DeleteByID(c.Request.Context(), user.ID, c.Param("id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(nil))
+}
+
+// sessionIndexKey returns the cache key listing a user's session tokens.
+func sessionIndexKey(userID string) string {
+	return "user_sessions:" + userID
+}
+
+// ListByUserID returns a user's unexpired sessions, newest first, pruning
+// ended ones from the user's index.
+func (s *CacheSessionStore) ListByUserID(ctx context.Context, userID string) ([]*Session, error) {
+	tokens, err := s.index(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	sessions := make([]*Session, 0, len(tokens))
+	live := make([]string, 0, len(tokens))
+	for _, token := range tokens {
+		session, err := s.GetByToken(ctx, token)
+		if err != nil {
+			if appErr, ok := apperror.AsAppError(err); ok && appErr.HTTPStatus == http.StatusNotFound {
+				continue
+			}
+			return nil, err
+		}
+		sessions = append(sessions, session)
+		live = append(live, token)
+	}
+	if len(live) < len(tokens) {
+		if err := s.setIndex(ctx, userID, live, sessions); err != nil {
+			return nil, err
+		}
+	}
+
+	// Tokens are appended on create, so the newest come last
+	for i, j := 0, len(sessions)-1; i < j; i, j = i+1, j-1 {
+		sessions[i], sessions[j] = sessions[j], sessions[i]
+	}
+	return sessions, nil
+}
+
+// DeleteByID deletes a session of a user by its ID.
+func (s *CacheSessionStore) DeleteByID(ctx context.Context, userID, id string) error {
+	sessions, err := s.ListByUserID(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		if session.ID == id {
+			return s.Delete(ctx, session.Token)
+		}
+	}
+	return apperror.ErrNotFound.WithMessage("Session not found")
+}
+
+// Touch records the last use of a session.
+func (s *CacheSessionStore) Touch(ctx context.Context, token string, at time.Time) error {
+	session, err := s.GetByToken(ctx, token)
+	if err != nil {
+		return err
+	}
+	session.LastSeenAt = at
+	return s.put(ctx, session)
+}
+
+// index returns the tokens of a user's sessions, oldest first.
+func (s *CacheSessionStore) index(ctx context.Context, userID string) ([]string, error) {
+	data, err := s.store.Get(ctx, sessionIndexKey(userID))
+	if errors.Is(err, cache.ErrMiss) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	var tokens []string
+	if err := json.Unmarshal(data, &tokens); err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return tokens, nil
+}
+
+// setIndex stores the tokens of a user's sessions until the last of them
+// expires.
+func (s *CacheSessionStore) setIndex(ctx context.Context, userID string, tokens []string, sessions []*Session) error {
+	if len(tokens) == 0 {
+		return s.store.Delete(ctx, sessionIndexKey(userID))
+	}
+
+	var ttl time.Duration
+	for _, session := range sessions {
+		ttl = max(ttl, time.Until(session.ExpiresAt))
+	}
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	if err := s.store.Set(ctx, sessionIndexKey(userID), data, ttl); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// addToIndex adds a new session to its user's index.
+func (s *CacheSessionStore) addToIndex(ctx context.Context, session *Session) error {
+	tokens, err := s.index(ctx, session.UserID)
+	if err != nil {
+		return err
+	}
+	ttl := time.Until(session.ExpiresAt)
+	if remaining, err := s.store.TTL(ctx, sessionIndexKey(session.UserID)); err == nil && remaining > ttl {
+		ttl = remaining
+	}
+
+	data, err := json.Marshal(append(tokens, session.Token))
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	if err := s.store.Set(ctx, sessionIndexKey(session.UserID), data, ttl); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
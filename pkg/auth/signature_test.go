@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSignatureVerifier(t *testing.T) {
+	keys := StaticSigningKeys{"billing": {Secret: "s3cret", UserID: "user-1"}}
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+	old := strconv.FormatInt(time.Now().Add(-10*time.Minute).Unix(), 10)
+
+	tests := []struct {
+		name      string
+		key       string
+		timestamp string
+		nonce     string
+		secret    string
+		signPath  string
+		body      string
+		wantUser  string
+	}{
+		{"valid", "billing", now, "n1", "s3cret", "/api/v1/orders?limit=5", `{"total":5}`, "user-1"},
+		{"replayed nonce", "billing", now, "n1", "s3cret", "/api/v1/orders?limit=5", `{"total":5}`, ""},
+		{"wrong secret", "billing", now, "n2", "other", "/api/v1/orders?limit=5", `{"total":5}`, ""},
+		{"unknown key", "payroll", now, "n3", "s3cret", "/api/v1/orders?limit=5", `{"total":5}`, ""},
+		{"expired timestamp", "billing", old, "n4", "s3cret", "/api/v1/orders?limit=5", `{"total":5}`, ""},
+		{"tampered query", "billing", now, "n5", "s3cret", "/api/v1/orders?limit=500", `{"total":5}`, ""},
+		{"missing nonce", "billing", now, "", "s3cret", "/api/v1/orders?limit=5", `{"total":5}`, ""},
+	}
+
+	verifier := NewSignatureVerifier(SignatureConfig{Keys: keys})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/orders?limit=5", strings.NewReader(`{"total":5}`))
+			req.Header.Set(SignatureKeyHeader, tt.key)
+			req.Header.Set(SignatureTimestampHeader, tt.timestamp)
+			req.Header.Set(SignatureNonceHeader, tt.nonce)
+			req.Header.Set(SignatureHeader, Sign(tt.secret, tt.timestamp, tt.nonce, http.MethodPost, tt.signPath, []byte(tt.body)))
+
+			userID, err := verifier.Verify(req)
+			if userID != tt.wantUser || (err == nil) != (tt.wantUser != "") {
+				t.Fatalf("Verify() = %q, %v, want %q", userID, err, tt.wantUser)
+			}
+			if err == nil {
+				if body, _ := io.ReadAll(req.Body); string(body) != tt.body {
+					t.Errorf("body after Verify = %q, want %q", body, tt.body)
+				}
+			}
+		})
+	}
+}
+
+func TestSignatureVerifierConcurrentReplay(t *testing.T) {
+	verifier := NewSignatureVerifier(SignatureConfig{Keys: StaticSigningKeys{"billing": {Secret: "s3cret", UserID: "user-1"}}})
+	now := strconv.FormatInt(time.Now().Unix(), 10)
+
+	var wg sync.WaitGroup
+	var verified atomic.Int32
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			req := httptest.NewRequest(http.MethodPost, "/api/v1/orders", strings.NewReader(`{"total":5}`))
+			req.Header.Set(SignatureKeyHeader, "billing")
+			req.Header.Set(SignatureTimestampHeader, now)
+			req.Header.Set(SignatureNonceHeader, "n1")
+			req.Header.Set(SignatureHeader, Sign("s3cret", now, "n1", http.MethodPost, "/api/v1/orders", []byte(`{"total":5}`)))
+			if _, err := verifier.Verify(req); err == nil {
+				verified.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if n := verified.Load(); n != 1 {
+		t.Errorf("%d requests with the same nonce verified, want 1", n)
+	}
+}
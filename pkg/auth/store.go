@@ -3,7 +3,9 @@ package auth
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -39,6 +41,7 @@ type userRow struct {
 	TOTPSecret   sql.NullString `db:"totp_secret"`
 	TOTPEnabled  bool           `db:"totp_enabled"`
 	Status       string         `db:"status"`
+	Metadata     sql.NullString `db:"metadata"`
 	CreatedAt    time.Time      `db:"created_at"`
 	UpdatedAt    time.Time      `db:"updated_at"`
 }
@@ -62,6 +65,9 @@ func (r *userRow) toUser() *User {
 	if r.RoleName.Valid {
 		user.Role = r.RoleName.String
 	}
+	if r.Metadata.Valid && r.Metadata.String != "" {
+		_ = json.Unmarshal([]byte(r.Metadata.String), &user.Metadata)
+	}
 	return user
 }
 
@@ -70,14 +76,14 @@ func (s *DBUserStore) GetByID(ctx context.Context, id string) (*User, error) {
 	query := `
 		SELECT u.id, u.username, u.email, u.password_hash, u.role_id,
 			   r.name as role_name, u.totp_secret, u.totp_enabled,
-			   u.status, u.created_at, u.updated_at
+			   u.status, u.metadata, u.created_at, u.updated_at
 		FROM ` + s.tableName + ` u
 		LEFT JOIN tugo_roles r ON u.role_id = r.id
-		WHERE u.id = $1
+		WHERE u.id = ?
 	`
 
 	var row userRow
-	if err := s.db.GetContext(ctx, &row, query, id); err != nil {
+	if err := s.db.GetContext(ctx, &row, s.db.Rebind(query), id); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperror.ErrNotFound.WithMessage("User not found")
 		}
@@ -92,14 +98,14 @@ func (s *DBUserStore) GetByUsername(ctx context.Context, username string) (*User
 	query := `
 		SELECT u.id, u.username, u.email, u.password_hash, u.role_id,
 			   r.name as role_name, u.totp_secret, u.totp_enabled,
-			   u.status, u.created_at, u.updated_at
+			   u.status, u.metadata, u.created_at, u.updated_at
 		FROM ` + s.tableName + ` u
 		LEFT JOIN tugo_roles r ON u.role_id = r.id
-		WHERE u.username = $1
+		WHERE u.username = ?
 	`
 
 	var row userRow
-	if err := s.db.GetContext(ctx, &row, query, username); err != nil {
+	if err := s.db.GetContext(ctx, &row, s.db.Rebind(query), username); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperror.ErrNotFound.WithMessage("User not found")
 		}
@@ -114,14 +120,14 @@ func (s *DBUserStore) GetByEmail(ctx context.Context, email string) (*User, erro
 	query := `
 		SELECT u.id, u.username, u.email, u.password_hash, u.role_id,
 			   r.name as role_name, u.totp_secret, u.totp_enabled,
-			   u.status, u.created_at, u.updated_at
+			   u.status, u.metadata, u.created_at, u.updated_at
 		FROM ` + s.tableName + ` u
 		LEFT JOIN tugo_roles r ON u.role_id = r.id
-		WHERE u.email = $1
+		WHERE u.email = ?
 	`
 
 	var row userRow
-	if err := s.db.GetContext(ctx, &row, query, email); err != nil {
+	if err := s.db.GetContext(ctx, &row, s.db.Rebind(query), email); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperror.ErrNotFound.WithMessage("User not found")
 		}
@@ -133,10 +139,10 @@ func (s *DBUserStore) GetByEmail(ctx context.Context, email string) (*User, erro
 
 // GetPasswordHash retrieves the password hash for a user.
 func (s *DBUserStore) GetPasswordHash(ctx context.Context, userID string) (string, error) {
-	query := `SELECT password_hash FROM ` + s.tableName + ` WHERE id = $1`
+	query := `SELECT password_hash FROM ` + s.tableName + ` WHERE id = ?`
 
 	var hash string
-	if err := s.db.GetContext(ctx, &hash, query, userID); err != nil {
+	if err := s.db.GetContext(ctx, &hash, s.db.Rebind(query), userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", apperror.ErrNotFound.WithMessage("User not found")
 		}
@@ -148,10 +154,10 @@ func (s *DBUserStore) GetPasswordHash(ctx context.Context, userID string) (strin
 
 // GetTOTPSecret retrieves the TOTP secret for a user.
 func (s *DBUserStore) GetTOTPSecret(ctx context.Context, userID string) (string, error) {
-	query := `SELECT totp_secret FROM ` + s.tableName + ` WHERE id = $1`
+	query := `SELECT totp_secret FROM ` + s.tableName + ` WHERE id = ?`
 
 	var secret sql.NullString
-	if err := s.db.GetContext(ctx, &secret, query, userID); err != nil {
+	if err := s.db.GetContext(ctx, &secret, s.db.Rebind(query), userID); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return "", apperror.ErrNotFound.WithMessage("User not found")
 		}
@@ -175,7 +181,7 @@ func (s *DBUserStore) Create(ctx context.Context, user *User, passwordHash strin
 
 	query := `
 		INSERT INTO ` + s.tableName + ` (id, username, email, password_hash, role_id, status, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
 	var roleID any
@@ -193,7 +199,7 @@ func (s *DBUserStore) Create(ctx context.Context, user *User, passwordHash strin
 		status = "active"
 	}
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
 		user.ID, user.Username, email, passwordHash, roleID, status, user.CreatedAt, user.UpdatedAt)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
@@ -204,9 +210,9 @@ func (s *DBUserStore) Create(ctx context.Context, user *User, passwordHash strin
 
 // UpdatePassword updates a user's password.
 func (s *DBUserStore) UpdatePassword(ctx context.Context, userID string, passwordHash string) error {
-	query := `UPDATE ` + s.tableName + ` SET password_hash = $1, updated_at = $2 WHERE id = $3`
+	query := `UPDATE ` + s.tableName + ` SET password_hash = ?, updated_at = ? WHERE id = ?`
 
-	result, err := s.db.ExecContext(ctx, query, passwordHash, time.Now(), userID)
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query), passwordHash, time.Now(), userID)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
@@ -221,14 +227,14 @@ func (s *DBUserStore) UpdatePassword(ctx context.Context, userID string, passwor
 
 // SetTOTPSecret sets the TOTP secret for a user.
 func (s *DBUserStore) SetTOTPSecret(ctx context.Context, userID string, secret string) error {
-	query := `UPDATE ` + s.tableName + ` SET totp_secret = $1, updated_at = $2 WHERE id = $3`
+	query := `UPDATE ` + s.tableName + ` SET totp_secret = ?, updated_at = ? WHERE id = ?`
 
 	var secretValue any
 	if secret != "" {
 		secretValue = secret
 	}
 
-	result, err := s.db.ExecContext(ctx, query, secretValue, time.Now(), userID)
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query), secretValue, time.Now(), userID)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
@@ -243,9 +249,48 @@ func (s *DBUserStore) SetTOTPSecret(ctx context.Context, userID string, secret s
 
 // EnableTOTP enables or disables TOTP for a user.
 func (s *DBUserStore) EnableTOTP(ctx context.Context, userID string, enabled bool) error {
-	query := `UPDATE ` + s.tableName + ` SET totp_enabled = $1, updated_at = $2 WHERE id = $3`
+	query := `UPDATE ` + s.tableName + ` SET totp_enabled = ?, updated_at = ? WHERE id = ?`
+
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query), enabled, time.Now(), userID)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+
+	rows, _ := result.RowsAffected()
+	if rows == 0 {
+		return apperror.ErrNotFound.WithMessage("User not found")
+	}
 
-	result, err := s.db.ExecContext(ctx, query, enabled, time.Now(), userID)
+	return nil
+}
+
+// UpdateUser updates a user's username, email and metadata.
+func (s *DBUserStore) UpdateUser(ctx context.Context, userID string, update UserUpdate) error {
+	sets := []string{"updated_at = ?"}
+	args := []any{time.Now()}
+	if update.Username != nil {
+		sets = append(sets, "username = ?")
+		args = append(args, *update.Username)
+	}
+	if update.Email != nil {
+		sets = append(sets, "email = ?")
+		var email any
+		if *update.Email != "" {
+			email = *update.Email
+		}
+		args = append(args, email)
+	}
+	if update.Metadata != nil {
+		data, err := json.Marshal(update.Metadata)
+		if err != nil {
+			return apperror.ErrBadRequest.WithMessage("Invalid metadata")
+		}
+		sets = append(sets, "metadata = ?")
+		args = append(args, string(data))
+	}
+
+	query := `UPDATE ` + s.tableName + ` SET ` + strings.Join(sets, ", ") + ` WHERE id = ?`
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query), append(args, userID)...)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
@@ -258,6 +303,74 @@ func (s *DBUserStore) EnableTOTP(ctx context.Context, userID string, enabled boo
 	return nil
 }
 
+// EmailChangeStore stores email changes awaiting confirmation in
+// tugo_email_changes. Only a hash of each confirmation token is kept.
+type EmailChangeStore struct {
+	db *sqlx.DB
+}
+
+// NewEmailChangeStore creates an email change store.
+func NewEmailChangeStore(db *sqlx.DB) *EmailChangeStore {
+	return &EmailChangeStore{db: db}
+}
+
+// Create stores a pending email change, replacing earlier ones of the user.
+func (s *EmailChangeStore) Create(ctx context.Context, change *EmailChange, token string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, tx.Rebind(`DELETE FROM tugo_email_changes WHERE user_id = ?`), change.UserID); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	query := `
+		INSERT INTO tugo_email_changes (token_hash, user_id, email, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query),
+		hashToken(token), change.UserID, change.Email, change.ExpiresAt, time.Now()); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// Consume removes and returns the pending email change of a token.
+func (s *EmailChangeStore) Consume(ctx context.Context, token string) (*EmailChange, error) {
+	query := `SELECT user_id, email, expires_at FROM tugo_email_changes WHERE token_hash = ?`
+
+	var change EmailChange
+	if err := s.db.GetContext(ctx, &change, s.db.Rebind(query), hashToken(token)); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrBadRequest.WithMessage("Invalid or expired confirmation token")
+		}
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(`DELETE FROM tugo_email_changes WHERE token_hash = ?`), hashToken(token))
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	// A concurrent confirmation consumed it first
+	if rows, _ := result.RowsAffected(); rows == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("Invalid or expired confirmation token")
+	}
+	if time.Now().After(change.ExpiresAt) {
+		return nil, apperror.ErrBadRequest.WithMessage("Invalid or expired confirmation token")
+	}
+
+	return &change, nil
+}
+
+// sessionColumns are the columns of tugo_sessions read into a Session.
+const sessionColumns = `id, user_id, token, expires_at, created_at, COALESCE(user_agent, '') AS user_agent,
+	COALESCE(ip_address, '') AS ip_address, updated_at`
+
 // DBSessionStore implements SessionStore using sqlx.
 type DBSessionStore struct {
 	db        *sqlx.DB
@@ -278,13 +391,17 @@ func NewDBSessionStore(db *sqlx.DB, tableName string) *DBSessionStore {
 // Create creates a new session.
 func (s *DBSessionStore) Create(ctx context.Context, session *Session) error {
 	query := `
-		INSERT INTO ` + s.tableName + ` (id, user_id, token, expires_at, created_at, user_agent, ip_address)
-		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		INSERT INTO ` + s.tableName + ` (id, user_id, token, expires_at, created_at, updated_at, user_agent, ip_address)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.ExecContext(ctx, query,
+	lastSeen := session.LastSeenAt
+	if lastSeen.IsZero() {
+		lastSeen = session.CreatedAt
+	}
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
 		session.ID, session.UserID, session.Token, session.ExpiresAt,
-		session.CreatedAt, session.UserAgent, session.IPAddress)
+		session.CreatedAt, lastSeen, session.UserAgent, session.IPAddress)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
@@ -294,10 +411,10 @@ func (s *DBSessionStore) Create(ctx context.Context, session *Session) error {
 
 // GetByToken retrieves a session by token.
 func (s *DBSessionStore) GetByToken(ctx context.Context, token string) (*Session, error) {
-	query := `SELECT id, user_id, token, expires_at, created_at, user_agent, ip_address FROM ` + s.tableName + ` WHERE token = $1`
+	query := `SELECT ` + sessionColumns + ` FROM ` + s.tableName + ` WHERE token = ?`
 
 	var session Session
-	if err := s.db.GetContext(ctx, &session, query, token); err != nil {
+	if err := s.db.GetContext(ctx, &session, s.db.Rebind(query), token); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, apperror.ErrNotFound.WithMessage("Session not found")
 		}
@@ -309,21 +426,59 @@ func (s *DBSessionStore) GetByToken(ctx context.Context, token string) (*Session
 
 // Delete deletes a session.
 func (s *DBSessionStore) Delete(ctx context.Context, token string) error {
-	query := `DELETE FROM ` + s.tableName + ` WHERE token = $1`
+	query := `DELETE FROM ` + s.tableName + ` WHERE token = ?`
+
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query), token)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+
+	return nil
+}
+
+// ListByUserID returns a user's unexpired sessions, newest first.
+func (s *DBSessionStore) ListByUserID(ctx context.Context, userID string) ([]*Session, error) {
+	query := `SELECT ` + sessionColumns + ` FROM ` + s.tableName + ` WHERE user_id = ? AND expires_at > ? ORDER BY created_at DESC`
+
+	var sessions []*Session
+	if err := s.db.SelectContext(ctx, &sessions, s.db.Rebind(query), userID, time.Now()); err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	return sessions, nil
+}
 
-	_, err := s.db.ExecContext(ctx, query, token)
+// DeleteByID deletes a session of a user by its ID.
+func (s *DBSessionStore) DeleteByID(ctx context.Context, userID, id string) error {
+	query := `DELETE FROM ` + s.tableName + ` WHERE id = ? AND user_id = ?`
+
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query), id, userID)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return apperror.ErrNotFound.WithMessage("Session not found")
+	}
+
+	return nil
+}
+
+// Touch records the last use of a session.
+func (s *DBSessionStore) Touch(ctx context.Context, token string, at time.Time) error {
+	query := `UPDATE ` + s.tableName + ` SET updated_at = ? WHERE token = ?`
+
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), at, token); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
 
 	return nil
 }
 
 // DeleteByUserID deletes all sessions for a user.
 func (s *DBSessionStore) DeleteByUserID(ctx context.Context, userID string) error {
-	query := `DELETE FROM ` + s.tableName + ` WHERE user_id = $1`
+	query := `DELETE FROM ` + s.tableName + ` WHERE user_id = ?`
 
-	_, err := s.db.ExecContext(ctx, query, userID)
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query), userID)
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
@@ -333,9 +488,9 @@ func (s *DBSessionStore) DeleteByUserID(ctx context.Context, userID string) erro
 
 // CleanExpired removes expired sessions.
 func (s *DBSessionStore) CleanExpired(ctx context.Context) error {
-	query := `DELETE FROM ` + s.tableName + ` WHERE expires_at < $1`
+	query := `DELETE FROM ` + s.tableName + ` WHERE expires_at < ?`
 
-	_, err := s.db.ExecContext(ctx, query, time.Now())
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query), time.Now())
 	if err != nil {
 		return apperror.ErrInternalServer.WithError(err)
 	}
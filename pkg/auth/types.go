@@ -60,6 +60,9 @@ type Claims struct {
 	Username string `json:"username"`
 	Role     string `json:"role"`
 	RoleID   string `json:"role_id,omitempty"`
+
+	// Extra holds custom claims from a ClaimsEnricher.
+	Extra map[string]any `json:"extra,omitempty"`
 }
 
 // Session represents a session stored in database or cookie.
@@ -71,6 +74,10 @@ type Session struct {
 	CreatedAt time.Time `json:"created_at" db:"created_at"`
 	UserAgent string    `json:"user_agent,omitempty" db:"user_agent"`
 	IPAddress string    `json:"ip_address,omitempty" db:"ip_address"`
+
+	// LastSeenAt is when the session was last used, recorded at most once
+	// per DefaultSessionTouchInterval.
+	LastSeenAt time.Time `json:"last_seen_at" db:"updated_at"`
 }
 
 // contextKey is the type for context keys.
@@ -0,0 +1,32 @@
+// Package cache defines a key-value cache shared by TuGo subsystems, so one
+// backend can be configured for sessions, policies and responses.
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// ErrMiss is returned when a key is not in the cache.
+var ErrMiss = errors.New("cache: key not found")
+
+// Store is a key-value cache with per-key expiry.
+type Store interface {
+	// Get returns the value of a key, or ErrMiss.
+	Get(ctx context.Context, key string) ([]byte, error)
+
+	// Set stores a value. A ttl of zero or less keeps it until deleted.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// SetNX stores a value unless the key exists, reporting whether it
+	// did. The check and the write are atomic.
+	SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error)
+
+	// Delete removes keys. Missing keys are ignored.
+	Delete(ctx context.Context, keys ...string) error
+
+	// TTL returns the remaining lifetime of a key, zero for keys without
+	// expiry, or ErrMiss.
+	TTL(ctx context.Context, key string) (time.Duration, error)
+}
@@ -0,0 +1,140 @@
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// DefaultMemoryEntries caps a memory store created without a size.
+const DefaultMemoryEntries = 10000
+
+// MemoryStore implements Store in process memory, evicting the least
+// recently used entry when full. It is not shared between instances.
+type MemoryStore struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Front is most recently used
+}
+
+// memoryEntry is a cached value.
+type memoryEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time // Zero for no expiry
+}
+
+// NewMemoryStore creates an in-memory LRU store holding up to maxEntries keys.
+func NewMemoryStore(maxEntries int) *MemoryStore {
+	if maxEntries <= 0 {
+		maxEntries = DefaultMemoryEntries
+	}
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the value of a key.
+func (s *MemoryStore) Get(ctx context.Context, key string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.lookup(key)
+	if entry == nil {
+		return nil, ErrMiss
+	}
+	return entry.value, nil
+}
+
+// Set stores a value.
+func (s *MemoryStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.set(key, value, ttl)
+	return nil
+}
+
+// SetNX stores a value unless the key exists.
+func (s *MemoryStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.lookup(key) != nil {
+		return false, nil
+	}
+	s.set(key, value, ttl)
+	return true, nil
+}
+
+// set stores a value, evicting the least recently used entries when full.
+func (s *MemoryStore) set(key string, value []byte, ttl time.Duration) {
+	entry := &memoryEntry{key: key, value: value}
+	if ttl > 0 {
+		entry.expiresAt = time.Now().Add(ttl)
+	}
+
+	if el, ok := s.entries[key]; ok {
+		el.Value = entry
+		s.order.MoveToFront(el)
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(entry)
+	for s.order.Len() > s.maxEntries {
+		s.remove(s.order.Back())
+	}
+}
+
+// Delete removes keys.
+func (s *MemoryStore) Delete(ctx context.Context, keys ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, key := range keys {
+		if el, ok := s.entries[key]; ok {
+			s.remove(el)
+		}
+	}
+	return nil
+}
+
+// TTL returns the remaining lifetime of a key.
+func (s *MemoryStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	entry := s.lookup(key)
+	if entry == nil {
+		return 0, ErrMiss
+	}
+	if entry.expiresAt.IsZero() {
+		return 0, nil
+	}
+	return time.Until(entry.expiresAt), nil
+}
+
+// lookup returns the live entry of a key and marks it used, dropping it if expired.
+func (s *MemoryStore) lookup(key string) *memoryEntry {
+	el, ok := s.entries[key]
+	if !ok {
+		return nil
+	}
+	entry := el.Value.(*memoryEntry)
+	if !entry.expiresAt.IsZero() && !time.Now().Before(entry.expiresAt) {
+		s.remove(el)
+		return nil
+	}
+	s.order.MoveToFront(el)
+	return entry
+}
+
+// remove drops an entry.
+func (s *MemoryStore) remove(el *list.Element) {
+	s.order.Remove(el)
+	delete(s.entries, el.Value.(*memoryEntry).key)
+}
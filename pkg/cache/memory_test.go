@@ -0,0 +1,77 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMemoryStore(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("get set delete", func(t *testing.T) {
+		s := NewMemoryStore(10)
+		if _, err := s.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+			t.Fatalf("expected ErrMiss, got %v", err)
+		}
+		s.Set(ctx, "a", []byte("1"), 0)
+		if v, err := s.Get(ctx, "a"); err != nil || string(v) != "1" {
+			t.Fatalf("Get() = %q, %v", v, err)
+		}
+		s.Delete(ctx, "a", "missing")
+		if _, err := s.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+			t.Fatalf("expected deleted key to miss, got %v", err)
+		}
+	})
+
+	t.Run("set if absent", func(t *testing.T) {
+		s := NewMemoryStore(10)
+		if ok, err := s.SetNX(ctx, "a", []byte("1"), 0); err != nil || !ok {
+			t.Fatalf("SetNX() on a missing key = %v, %v", ok, err)
+		}
+		if ok, err := s.SetNX(ctx, "a", []byte("2"), 0); err != nil || ok {
+			t.Fatalf("SetNX() on an existing key = %v, %v", ok, err)
+		}
+		if v, _ := s.Get(ctx, "a"); string(v) != "1" {
+			t.Fatalf("Get() = %q, want the first value", v)
+		}
+		s.Set(ctx, "b", []byte("1"), 10*time.Millisecond)
+		time.Sleep(15 * time.Millisecond)
+		if ok, err := s.SetNX(ctx, "b", []byte("2"), 0); err != nil || !ok {
+			t.Fatalf("SetNX() on an expired key = %v, %v", ok, err)
+		}
+	})
+
+	t.Run("expiry", func(t *testing.T) {
+		s := NewMemoryStore(10)
+		s.Set(ctx, "a", []byte("1"), 10*time.Millisecond)
+		if ttl, err := s.TTL(ctx, "a"); err != nil || ttl <= 0 || ttl > 10*time.Millisecond {
+			t.Fatalf("TTL() = %v, %v", ttl, err)
+		}
+		time.Sleep(15 * time.Millisecond)
+		if _, err := s.Get(ctx, "a"); !errors.Is(err, ErrMiss) {
+			t.Fatalf("expected expired key to miss, got %v", err)
+		}
+		if _, err := s.TTL(ctx, "a"); !errors.Is(err, ErrMiss) {
+			t.Fatalf("expected TTL of expired key to miss, got %v", err)
+		}
+	})
+
+	t.Run("evicts least recently used", func(t *testing.T) {
+		s := NewMemoryStore(2)
+		s.Set(ctx, "a", []byte("1"), 0)
+		s.Set(ctx, "b", []byte("2"), 0)
+		s.Get(ctx, "a")
+		s.Set(ctx, "c", []byte("3"), 0)
+
+		if _, err := s.Get(ctx, "b"); !errors.Is(err, ErrMiss) {
+			t.Errorf("expected b to be evicted, got %v", err)
+		}
+		for _, key := range []string{"a", "c"} {
+			if _, err := s.Get(ctx, key); err != nil {
+				t.Errorf("expected %s to remain, got %v", key, err)
+			}
+		}
+	})
+}
@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// RedisStore implements Store on Redis.
+type RedisStore struct {
+	client redis.UniversalClient
+	prefix string
+}
+
+// NewRedisStore creates a Redis-backed store. Keys are namespaced with prefix.
+func NewRedisStore(client redis.UniversalClient, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix}
+}
+
+// Get returns the value of a key.
+func (s *RedisStore) Get(ctx context.Context, key string) ([]byte, error) {
+	value, err := s.client.Get(ctx, s.prefix+key).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, ErrMiss
+	}
+	return value, err
+}
+
+// Set stores a value.
+func (s *RedisStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return s.client.Set(ctx, s.prefix+key, value, ttl).Err()
+}
+
+// SetNX stores a value unless the key exists.
+func (s *RedisStore) SetNX(ctx context.Context, key string, value []byte, ttl time.Duration) (bool, error) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	return s.client.SetNX(ctx, s.prefix+key, value, ttl).Result()
+}
+
+// Delete removes keys.
+func (s *RedisStore) Delete(ctx context.Context, keys ...string) error {
+	if len(keys) == 0 {
+		return nil
+	}
+	prefixed := make([]string, len(keys))
+	for i, key := range keys {
+		prefixed[i] = s.prefix + key
+	}
+	return s.client.Del(ctx, prefixed...).Err()
+}
+
+// TTL returns the remaining lifetime of a key.
+func (s *RedisStore) TTL(ctx context.Context, key string) (time.Duration, error) {
+	ttl, err := s.client.PTTL(ctx, s.prefix+key).Result()
+	if err != nil {
+		return 0, err
+	}
+	// go-redis reports -2 for missing keys and -1 for keys without expiry
+	switch ttl {
+	case -2:
+		return 0, ErrMiss
+	case -1:
+		return 0, nil
+	}
+	return ttl, nil
+}
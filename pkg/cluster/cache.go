@@ -0,0 +1,89 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/thienel/tugo/pkg/cache"
+)
+
+// CacheBus carries messages through a key-value cache shared by the
+// instances, such as Redis. Each topic keeps its latest message, which
+// listeners poll for, so messages of a topic sent within one poll
+// interval may reach listeners as only the last of them.
+type CacheBus struct {
+	store    cache.Store
+	interval time.Duration
+}
+
+// NewCacheBus creates a bus on store, polling it every interval.
+func NewCacheBus(store cache.Store, interval time.Duration) *CacheBus {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	return &CacheBus{store: store, interval: interval}
+}
+
+// Publish stores the message as the latest of its topic.
+func (b *CacheBus) Publish(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	return b.store.Set(ctx, cacheKey(msg.Topic), payload, 0)
+}
+
+// Listen delivers the messages of topics stored after it started, until
+// ctx is done.
+func (b *CacheBus) Listen(ctx context.Context, topics []string, deliver func(Message)) error {
+	seen := make(map[string]string, len(topics))
+	for _, topic := range topics {
+		msg, err := b.latest(ctx, topic)
+		if err != nil {
+			return err
+		}
+		seen[topic] = msg.ID
+	}
+
+	ticker := time.NewTicker(b.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			for _, topic := range topics {
+				msg, err := b.latest(ctx, topic)
+				if err != nil {
+					return err
+				}
+				if msg.ID != "" && msg.ID != seen[topic] {
+					seen[topic] = msg.ID
+					deliver(msg)
+				}
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// latest returns the latest message of a topic, or an empty message.
+func (b *CacheBus) latest(ctx context.Context, topic string) (Message, error) {
+	var msg Message
+	payload, err := b.store.Get(ctx, cacheKey(topic))
+	if errors.Is(err, cache.ErrMiss) {
+		return msg, nil
+	}
+	if err != nil {
+		return msg, err
+	}
+	// A corrupt entry is skipped until the next message replaces it.
+	_ = json.Unmarshal(payload, &msg)
+	return msg, nil
+}
+
+// cacheKey returns the cache key of a topic's latest message.
+func cacheKey(topic string) string {
+	return "cluster:" + topic
+}
@@ -0,0 +1,232 @@
+// Package cluster coordinates TuGo instances sharing a database: a bus
+// tells the other instances about schema refreshes, policy cache
+// invalidations, webhook and job state and collection locks, and leases
+// in tugo_leases let one instance at a time run each periodic job.
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Buses.
+const (
+	BusNotify = "notify"
+	BusCache  = "cache"
+)
+
+// Defaults for Config.
+const (
+	DefaultChannel      = "tugo_cluster"
+	DefaultPollInterval = time.Second
+)
+
+// Topics of the messages sent by TuGo.
+const (
+	// TopicSchema asks instances to rediscover the schema.
+	TopicSchema = "schema"
+
+	// TopicPolicies asks instances to drop their cached policies.
+	TopicPolicies = "policies"
+
+	// TopicWebhooks carries the disabled state of a webhook.
+	TopicWebhooks = "webhooks"
+
+	// TopicConfig asks instances to reload their configuration.
+	TopicConfig = "config"
+
+	// TopicJobs carries the disabled state of a job.
+	TopicJobs = "jobs"
+
+	// TopicLocks carries the write lock of a collection.
+	TopicLocks = "locks"
+)
+
+// Config configures cluster coordination.
+type Config struct {
+	// Enabled coordinates this instance with the others sharing its
+	// database.
+	Enabled bool
+
+	// Bus is how instances signal each other: "notify" uses PostgreSQL
+	// LISTEN/NOTIFY, "cache" polls the shared Config.Cache.
+	// Default: "notify" on PostgreSQL, "cache" otherwise
+	Bus string
+
+	// Channel is the PostgreSQL channel of the notify bus.
+	// Default: DefaultChannel
+	Channel string
+
+	// PollInterval is how often the cache bus looks for messages.
+	// Default: DefaultPollInterval
+	PollInterval time.Duration
+}
+
+// Message is a signal from one instance to the others.
+type Message struct {
+	ID     string          `json:"id"`
+	Topic  string          `json:"topic"`
+	Origin string          `json:"origin"`
+	Data   json.RawMessage `json:"data,omitempty"`
+	SentAt time.Time       `json:"sent_at"`
+}
+
+// Decode unmarshals the message data into v.
+func (m Message) Decode(v any) error {
+	if len(m.Data) == 0 {
+		return fmt.Errorf("message %s has no data", m.ID)
+	}
+	return json.Unmarshal(m.Data, v)
+}
+
+// Handler handles the messages of a topic sent by other instances.
+type Handler func(ctx context.Context, msg Message)
+
+// Bus carries messages between instances.
+type Bus interface {
+	// Publish sends a message to every instance listening.
+	Publish(ctx context.Context, msg Message) error
+
+	// Listen delivers the messages of topics until ctx is done.
+	Listen(ctx context.Context, topics []string, deliver func(Message)) error
+}
+
+// Node is this instance's member of the cluster.
+type Node struct {
+	id     string
+	bus    Bus
+	leases *LeaseStore
+	logger *zap.SugaredLogger
+
+	mu       sync.Mutex
+	handlers map[string][]Handler
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewNode creates a node sending messages on bus and holding leases in
+// leases. Without a lease store, every lease is granted.
+func NewNode(bus Bus, leases *LeaseStore, logger *zap.SugaredLogger) *Node {
+	return &Node{
+		id:       uuid.NewString(),
+		bus:      bus,
+		leases:   leases,
+		logger:   logger,
+		handlers: make(map[string][]Handler),
+	}
+}
+
+// ID returns the node's ID, unique to this process.
+func (n *Node) ID() string {
+	return n.id
+}
+
+// Handle registers a handler for the messages of a topic. Handlers added
+// after Start are called from the next Start.
+func (n *Node) Handle(topic string, fn Handler) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.handlers[topic] = append(n.handlers[topic], fn)
+}
+
+// Publish sends a message with data to the other instances.
+func (n *Node) Publish(ctx context.Context, topic string, data any) error {
+	msg := Message{ID: uuid.NewString(), Topic: topic, Origin: n.id, SentAt: time.Now().UTC()}
+	if data != nil {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("failed to encode message: %w", err)
+		}
+		msg.Data = raw
+	}
+	if err := n.bus.Publish(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish %s message: %w", topic, err)
+	}
+	return nil
+}
+
+// Start listens for the messages of the handled topics in the background.
+// It does nothing when the node is already started.
+func (n *Node) Start(ctx context.Context) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.cancel != nil {
+		return
+	}
+
+	topics := make([]string, 0, len(n.handlers))
+	handlers := make(map[string][]Handler, len(n.handlers))
+	for topic, fns := range n.handlers {
+		topics = append(topics, topic)
+		handlers[topic] = append([]Handler(nil), fns...)
+	}
+
+	ctx, n.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	n.done = make(chan struct{})
+	go func() {
+		defer close(n.done)
+		for ctx.Err() == nil {
+			err := n.bus.Listen(ctx, topics, func(msg Message) {
+				if msg.Origin == n.id {
+					return
+				}
+				for _, fn := range handlers[msg.Topic] {
+					fn(ctx, msg)
+				}
+			})
+			if ctx.Err() != nil {
+				return
+			}
+			n.logger.Warnw("Cluster bus stopped, reconnecting", "error", err)
+			select {
+			case <-time.After(DefaultPollInterval):
+			case <-ctx.Done():
+			}
+		}
+	}()
+}
+
+// Stop stops listening and releases the node's leases.
+func (n *Node) Stop() {
+	n.mu.Lock()
+	cancel, done := n.cancel, n.done
+	n.cancel = nil
+	n.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+
+	cancel()
+	<-done
+	if n.leases == nil {
+		return
+	}
+	ctx, release := context.WithTimeout(context.Background(), 5*time.Second)
+	defer release()
+	if err := n.leases.ReleaseAll(ctx, n.id); err != nil {
+		n.logger.Warnw("Failed to release leases", "error", err)
+	}
+}
+
+// Acquire takes or renews the named lease for ttl, reporting whether this
+// node holds it. It implements jobs.Lease.
+func (n *Node) Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error) {
+	if n.leases == nil {
+		return true, nil
+	}
+	return n.leases.Acquire(ctx, name, n.id, time.Now().Add(ttl))
+}
+
+// Leases lists the leases held by any instance.
+func (n *Node) Leases(ctx context.Context) ([]Lease, error) {
+	if n.leases == nil {
+		return []Lease{}, nil
+	}
+	return n.leases.List(ctx)
+}
@@ -0,0 +1,60 @@
+package cluster
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/cache"
+	"go.uber.org/zap"
+)
+
+func TestCacheBus(t *testing.T) {
+	store := cache.NewMemoryStore(0)
+	logger := zap.NewNop().Sugar()
+	a := NewNode(NewCacheBus(store, 5*time.Millisecond), nil, logger)
+	b := NewNode(NewCacheBus(store, 5*time.Millisecond), nil, logger)
+
+	// a message sent before b listens is not delivered to it
+	if err := a.Publish(context.Background(), TopicSchema, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	received := make(chan Message, 10)
+	for _, node := range []*Node{a, b} {
+		node.Handle(TopicWebhooks, func(ctx context.Context, msg Message) {
+			received <- msg
+		})
+		node.Handle(TopicSchema, func(ctx context.Context, msg Message) {
+			received <- msg
+		})
+		node.Start(context.Background())
+		defer node.Stop()
+	}
+	time.Sleep(20 * time.Millisecond)
+
+	if err := a.Publish(context.Background(), TopicWebhooks, map[string]any{"id": "crm"}); err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-received:
+		if msg.Origin != a.ID() || msg.Topic != TopicWebhooks {
+			t.Errorf("got %+v, want a webhooks message from a", msg)
+		}
+		var data struct {
+			ID string `json:"id"`
+		}
+		if err := msg.Decode(&data); err != nil || data.ID != "crm" {
+			t.Errorf("Decode() = %+v, %v", data, err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("message was not delivered")
+	}
+
+	select {
+	case msg := <-received:
+		t.Errorf("unexpected message %+v", msg)
+	case <-time.After(30 * time.Millisecond):
+	}
+}
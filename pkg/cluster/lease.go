@@ -0,0 +1,82 @@
+package cluster
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Lease is a named lock held by one instance until it expires.
+type Lease struct {
+	Name       string    `db:"name" json:"name"`
+	Owner      string    `db:"owner" json:"owner"`
+	LeaseUntil int64     `db:"lease_until" json:"-"`
+	Until      time.Time `db:"-" json:"until"`
+}
+
+// LeaseStore keeps leases in tugo_leases.
+type LeaseStore struct {
+	db *sqlx.DB
+}
+
+// NewLeaseStore creates a new lease store.
+func NewLeaseStore(db *sqlx.DB) *LeaseStore {
+	return &LeaseStore{db: db}
+}
+
+// Acquire makes owner the holder of the named lease until the given time,
+// unless another owner holds it unexpired. It reports whether owner holds
+// the lease.
+func (s *LeaseStore) Acquire(ctx context.Context, name, owner string, until time.Time) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		UPDATE tugo_leases SET owner = ?, lease_until = ?
+		WHERE name = ? AND (owner = ? OR lease_until < ?)
+	`), owner, until.UnixMilli(), name, owner, time.Now().UnixMilli())
+	if err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n > 0 {
+		return true, nil
+	}
+
+	var exists int
+	if err := s.db.GetContext(ctx, &exists, s.db.Rebind("SELECT COUNT(*) FROM tugo_leases WHERE name = ?"), name); err != nil {
+		return false, fmt.Errorf("failed to acquire lease: %w", err)
+	}
+	if exists > 0 {
+		return false, nil
+	}
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		INSERT INTO tugo_leases (name, owner, lease_until) VALUES (?, ?, ?)
+	`), name, owner, until.UnixMilli()); err != nil {
+		// Another instance created it first.
+		return false, nil
+	}
+	return true, nil
+}
+
+// ReleaseAll gives up the leases of owner, so other instances may take
+// them over at once.
+func (s *LeaseStore) ReleaseAll(ctx context.Context, owner string) error {
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM tugo_leases WHERE owner = ?"), owner); err != nil {
+		return fmt.Errorf("failed to release leases: %w", err)
+	}
+	return nil
+}
+
+// List returns the unexpired leases, ordered by name.
+func (s *LeaseStore) List(ctx context.Context) ([]Lease, error) {
+	leases := []Lease{}
+	err := s.db.SelectContext(ctx, &leases, s.db.Rebind(`
+		SELECT name, owner, lease_until FROM tugo_leases WHERE lease_until >= ? ORDER BY name
+	`), time.Now().UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leases: %w", err)
+	}
+	for i := range leases {
+		leases[i].Until = time.UnixMilli(leases[i].LeaseUntil).UTC()
+	}
+	return leases, nil
+}
@@ -0,0 +1,116 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"go.uber.org/zap"
+)
+
+// NotifyBus carries messages on a PostgreSQL NOTIFY channel. Instances
+// not listening when a message is sent never receive it.
+type NotifyBus struct {
+	db      *sqlx.DB
+	pool    *pgxpool.Pool
+	dsn     string
+	channel string
+	logger  *zap.SugaredLogger
+}
+
+// NewNotifyBus creates a bus notifying through db. It listens on a
+// connection from pool, or when pool is nil on one opened from dsn with
+// lib/pq.
+func NewNotifyBus(db *sqlx.DB, pool *pgxpool.Pool, dsn, channel string, logger *zap.SugaredLogger) (*NotifyBus, error) {
+	if pool == nil && dsn == "" {
+		return nil, fmt.Errorf("the notify bus needs a pgx pool or a database URL to listen on")
+	}
+	if channel == "" {
+		channel = DefaultChannel
+	}
+	return &NotifyBus{db: db, pool: pool, dsn: dsn, channel: channel, logger: logger}, nil
+}
+
+// Publish notifies the channel with the message.
+func (b *NotifyBus) Publish(ctx context.Context, msg Message) error {
+	payload, err := json.Marshal(msg)
+	if err != nil {
+		return err
+	}
+	_, err = b.db.ExecContext(ctx, b.db.Rebind("SELECT pg_notify(?, ?)"), b.channel, string(payload))
+	return err
+}
+
+// Listen delivers the messages of topics notified on the channel until ctx
+// is done or the connection fails.
+func (b *NotifyBus) Listen(ctx context.Context, topics []string, deliver func(Message)) error {
+	if b.pool != nil {
+		return b.listenPgx(ctx, topics, deliver)
+	}
+	return b.listenPq(ctx, topics, deliver)
+}
+
+// listenPgx listens on a connection held from the pgx pool.
+func (b *NotifyBus) listenPgx(ctx context.Context, topics []string, deliver func(Message)) error {
+	conn, err := b.pool.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Release()
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{b.channel}.Sanitize()); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.Conn().WaitForNotification(ctx)
+		if err != nil {
+			// The connection may still be listening; do not hand it back.
+			conn.Hijack().Close(context.Background())
+			return err
+		}
+		b.deliver(notification.Payload, topics, deliver)
+	}
+}
+
+// listenPq listens with a lib/pq listener, which reconnects by itself.
+func (b *NotifyBus) listenPq(ctx context.Context, topics []string, deliver func(Message)) error {
+	listener := pq.NewListener(b.dsn, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		if err != nil {
+			b.logger.Warnw("Cluster listener error", "error", err)
+		}
+	})
+	defer listener.Close()
+	if err := listener.Listen(b.channel); err != nil {
+		return err
+	}
+
+	for {
+		select {
+		case notification := <-listener.Notify:
+			// nil follows a reconnect, after which messages may be missed
+			if notification != nil {
+				b.deliver(notification.Extra, topics, deliver)
+			}
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// deliver decodes a payload and delivers it when its topic is wanted.
+func (b *NotifyBus) deliver(payload string, topics []string, deliver func(Message)) {
+	var msg Message
+	if err := json.Unmarshal([]byte(payload), &msg); err != nil {
+		b.logger.Warnw("Ignoring invalid cluster message", "error", err)
+		return
+	}
+	if slices.Contains(topics, msg.Topic) {
+		deliver(msg)
+	}
+}
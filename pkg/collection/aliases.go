@@ -0,0 +1,279 @@
+package collection
+
+import (
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// sortFieldRegex splits a sort term into its direction and function, its
+// field, and its modifiers.
+var sortFieldRegex = regexp.MustCompile(`^(-?(?:(?:lower|upper)\()?)([a-zA-Z_][a-zA-Z0-9_]*)(.*)$`)
+
+// columnNames maps a collection's aliases to their columns.
+func columnNames(collection *schema.Collection) map[string]string {
+	columns := make(map[string]string, len(collection.Aliases))
+	for column, alias := range collection.Aliases {
+		columns[alias] = column
+	}
+	return columns
+}
+
+// aliasItem returns a copy of item with aliased columns under their alias.
+// Expanded relations are renamed with the aliases of their collection.
+func (h *Handler) aliasItem(collection *schema.Collection, item map[string]any) map[string]any {
+	if item == nil {
+		return nil
+	}
+	aliased := aliasMap(collection.Aliases, item)
+	for key, value := range item {
+		related, ok := value.(map[string]any)
+		if !ok {
+			continue
+		}
+		if _, relatedCollection, ok := h.service.expandRelation(collection, key); ok && len(relatedCollection.Aliases) > 0 {
+			if alias, ok := collection.Aliases[key]; ok {
+				key = alias
+			}
+			aliased[key] = aliasMap(relatedCollection.Aliases, related)
+		}
+	}
+	return aliased
+}
+
+// aliasMap returns a copy of item with the columns of aliases under their
+// alias.
+func aliasMap(aliases map[string]string, item map[string]any) map[string]any {
+	aliased := make(map[string]any, len(item))
+	for key, value := range item {
+		if alias, ok := aliases[key]; ok {
+			key = alias
+		}
+		aliased[key] = value
+	}
+	return aliased
+}
+
+// aliasItems returns copies of items with aliased columns under their
+// alias.
+func (h *Handler) aliasItems(collection *schema.Collection, items []map[string]any) []map[string]any {
+	aliased := make([]map[string]any, len(items))
+	for i, item := range items {
+		aliased[i] = h.aliasItem(collection, item)
+	}
+	return aliased
+}
+
+// aliased returns the collection of a request when it has aliases.
+func (h *Handler) aliased(c *gin.Context) (*schema.Collection, bool) {
+	if h.service == nil || c.Param("collection") == "" {
+		return nil, false
+	}
+	collection, err := h.service.schemaManager.GetCollection(c.Param("collection"))
+	if err != nil || len(collection.Aliases) == 0 {
+		return nil, false
+	}
+	return collection, true
+}
+
+// aliasResponse renames the columns of the items a response carries.
+func (h *Handler) aliasResponse(c *gin.Context, resp response.Response) response.Response {
+	if resp.Data == nil {
+		return resp
+	}
+	collection, ok := h.aliased(c)
+	if !ok {
+		return resp
+	}
+
+	switch data := resp.Data.(type) {
+	case map[string]any:
+		resp.Data = h.aliasItem(collection, data)
+	case []map[string]any:
+		resp.Data = h.aliasItems(collection, data)
+	case response.ListData:
+		if items, ok := data.Items.([]map[string]any); ok {
+			data.Items = h.aliasItems(collection, items)
+			resp.Data = data
+		}
+	case *BatchResponse:
+		resp.Data = &BatchResponse{Items: h.aliasItems(collection, data.Items), Missing: data.Missing}
+	case []PossibleDuplicate:
+		duplicates := make([]PossibleDuplicate, len(data))
+		for i, d := range data {
+			d.Item = h.aliasItem(collection, d.Item)
+			d.Matched = aliasFields(collection, d.Matched)
+			duplicates[i] = d
+		}
+		resp.Data = duplicates
+	case *MergeResponse:
+		merged := *data
+		merged.Item = h.aliasItem(collection, data.Item)
+		resp.Data = &merged
+	case *Capabilities:
+		caps := *data
+		caps.FieldOperators = make(map[string][]string, len(data.FieldOperators))
+		for field, ops := range data.FieldOperators {
+			if alias, ok := collection.Aliases[field]; ok {
+				field = alias
+			}
+			caps.FieldOperators[field] = ops
+		}
+		resp.Data = &caps
+	}
+	return resp
+}
+
+// aliasFields returns fields with aliased columns replaced by their alias.
+func aliasFields(collection *schema.Collection, fields []string) []string {
+	aliased := make([]string, len(fields))
+	for i, field := range fields {
+		if alias, ok := collection.Aliases[field]; ok {
+			field = alias
+		}
+		aliased[i] = field
+	}
+	return aliased
+}
+
+// aliasError renames the fields of a validation error or a constraint
+// violation.
+func aliasError(collection *schema.Collection, appErr *apperror.AppError) *apperror.AppError {
+	if violation, ok := appErr.Details.(ConstraintViolation); ok {
+		violation.Fields = aliasFields(collection, violation.Fields)
+		return violation.appError().WithError(appErr.Err)
+	}
+	fieldErrs, ok := appErr.Details.([]validation.FieldError)
+	if !ok {
+		return appErr
+	}
+	errs := &validation.ValidationErrors{Errors: make([]validation.FieldError, len(fieldErrs))}
+	for i, fieldErr := range fieldErrs {
+		if alias, ok := collection.Aliases[fieldErr.Field]; ok {
+			fieldErr.Field = alias
+		}
+		errs.Errors[i] = fieldErr
+	}
+	renamed := appErr.WithDetails(errs.Errors)
+	if appErr.Code == apperror.ErrValidation.Code {
+		renamed = renamed.WithMessage(errs.Error())
+	}
+	return renamed
+}
+
+// columnItem returns a copy of data with aliases replaced by their column.
+// Columns may still be written under their own name; the alias wins when
+// both are given.
+func columnItem(collection *schema.Collection, data map[string]any) map[string]any {
+	if data == nil || len(collection.Aliases) == 0 {
+		return data
+	}
+	columns := columnNames(collection)
+	item := make(map[string]any, len(data))
+	for key, value := range data {
+		if _, ok := columns[key]; !ok {
+			item[key] = value
+		}
+	}
+	for key, value := range data {
+		if column, ok := columns[key]; ok {
+			item[column] = value
+		}
+	}
+	return item
+}
+
+// columnParams rewrites the aliases in the filter[...], sort and fields
+// parameters of a request to their columns.
+func columnParams(collection *schema.Collection, params map[string][]string) map[string][]string {
+	columns := columnNames(collection)
+	rewritten := make(map[string][]string, len(params))
+	for key, values := range params {
+		switch {
+		case key == "sort":
+			values = mapStrings(values, func(v string) string { return columnSort(columns, v) })
+		case key == "fields":
+			values = mapStrings(values, func(v string) string { return columnList(columns, v) })
+		default:
+			if field, ok := query.FilterKeyField(key); ok {
+				if column, ok := columns[field]; ok {
+					key = "filter[" + column + strings.TrimPrefix(key, "filter["+field)
+				}
+			}
+		}
+		rewritten[key] = values
+	}
+	return rewritten
+}
+
+// mapStrings applies fn to each value.
+func mapStrings(values []string, fn func(string) string) []string {
+	mapped := make([]string, len(values))
+	for i, v := range values {
+		mapped[i] = fn(v)
+	}
+	return mapped
+}
+
+// columnList rewrites the aliases of a comma-separated field list, whose
+// fields may be excluded with a - prefix.
+func columnList(columns map[string]string, list string) string {
+	fields := strings.Split(list, ",")
+	for i, field := range fields {
+		field = strings.TrimSpace(field)
+		exclude := strings.HasPrefix(field, "-")
+		if column, ok := columns[strings.TrimPrefix(field, "-")]; ok {
+			if exclude {
+				column = "-" + column
+			}
+			fields[i] = column
+		}
+	}
+	return strings.Join(fields, ",")
+}
+
+// columnSort rewrites the aliases of a sort parameter, keeping directions,
+// functions and modifiers. Related fields are left as they are.
+func columnSort(columns map[string]string, sort string) string {
+	terms := strings.Split(sort, ",")
+	for i, term := range terms {
+		m := sortFieldRegex.FindStringSubmatch(strings.TrimSpace(term))
+		if m == nil || strings.HasPrefix(m[3], ".") {
+			continue
+		}
+		if column, ok := columns[m[2]]; ok {
+			terms[i] = m[1] + column + m[3]
+		}
+	}
+	return strings.Join(terms, ",")
+}
+
+// aliases rewrites the aliases in a collection request's query parameters
+// and field path parameter to their columns.
+func (h *Handler) aliases(c *gin.Context) {
+	collection, ok := h.aliased(c)
+	if !ok {
+		c.Next()
+		return
+	}
+
+	if c.Request.URL.RawQuery != "" {
+		c.Request.URL.RawQuery = url.Values(columnParams(collection, c.Request.URL.Query())).Encode()
+	}
+	columns := columnNames(collection)
+	for i, param := range c.Params {
+		if param.Key == "field" {
+			if column, ok := columns[param.Value]; ok {
+				c.Params[i].Value = column
+			}
+		}
+	}
+	c.Next()
+}
@@ -0,0 +1,117 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+var aliasedUsers = &schema.Collection{
+	Name:    "users",
+	Fields:  []schema.Field{{Name: "id"}, {Name: "usr_nm"}, {Name: "crt_dt"}},
+	Aliases: map[string]string{"usr_nm": "username", "crt_dt": "created_at"},
+}
+
+func TestColumnParams(t *testing.T) {
+	tests := []struct {
+		name   string
+		params map[string][]string
+		want   map[string][]string
+	}{
+		{
+			name:   "filters",
+			params: map[string][]string{"filter[username]": {"ann"}, "filter[created_at:gte]": {"2024-01-01"}, "filter[id]": {"1"}},
+			want:   map[string][]string{"filter[usr_nm]": {"ann"}, "filter[crt_dt:gte]": {"2024-01-01"}, "filter[id]": {"1"}},
+		},
+		{
+			name:   "column names kept",
+			params: map[string][]string{"filter[usr_nm]": {"ann"}, "sort": {"usr_nm"}},
+			want:   map[string][]string{"filter[usr_nm]": {"ann"}, "sort": {"usr_nm"}},
+		},
+		{
+			name:   "sort terms",
+			params: map[string][]string{"sort": {"-created_at,lower(username):asc:nullslast,id,author.username,random:seed1"}},
+			want:   map[string][]string{"sort": {"-crt_dt,lower(usr_nm):asc:nullslast,id,author.username,random:seed1"}},
+		},
+		{
+			name:   "fields",
+			params: map[string][]string{"fields": {"id,username"}, "page": {"2"}},
+			want:   map[string][]string{"fields": {"id,usr_nm"}, "page": {"2"}},
+		},
+		{
+			name:   "excluded fields",
+			params: map[string][]string{"fields": {"-created_at,-id"}},
+			want:   map[string][]string{"fields": {"-crt_dt,-id"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := columnParams(aliasedUsers, tt.params)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columnParams() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestColumnItem(t *testing.T) {
+	tests := []struct {
+		name string
+		data map[string]any
+		want map[string]any
+	}{
+		{"alias", map[string]any{"username": "ann", "id": 1}, map[string]any{"usr_nm": "ann", "id": 1}},
+		{"column", map[string]any{"usr_nm": "ann"}, map[string]any{"usr_nm": "ann"}},
+		{"alias wins", map[string]any{"usr_nm": "old", "username": "ann"}, map[string]any{"usr_nm": "ann"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := columnItem(aliasedUsers, tt.data)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("columnItem() = %v, want %v", got, tt.want)
+			}
+			if back := aliasMap(aliasedUsers.Aliases, got); back["username"] != "ann" {
+				t.Errorf("aliasMap() = %v, want username", back)
+			}
+		})
+	}
+}
+
+func TestAliasError(t *testing.T) {
+	errs := &validation.ValidationErrors{}
+	errs.Add("usr_nm", "is required", "required")
+	errs.Add("id", "must be a number", "type")
+	appErr := apperror.ErrValidation.WithMessage(errs.Error()).WithDetails(errs.Errors)
+
+	got := aliasError(aliasedUsers, appErr)
+	if got.Message != "username: is required; id: must be a number" {
+		t.Errorf("aliasError() message = %q", got.Message)
+	}
+	if fields := got.Details.([]validation.FieldError); fields[0].Field != "username" || fields[1].Field != "id" {
+		t.Errorf("aliasError() details = %v", fields)
+	}
+	if appErr.Details.([]validation.FieldError)[0].Field != "usr_nm" {
+		t.Error("aliasError() changed the original error")
+	}
+}
+
+func TestAliasErrorConstraintViolation(t *testing.T) {
+	violation := ConstraintViolation{Constraint: "users_usr_nm_id_key", Type: ConstraintUnique, Fields: []string{"usr_nm", "id"}}
+
+	got := aliasError(aliasedUsers, violation.appError())
+	if got.Message != "Record with this combination of username, id already exists" {
+		t.Errorf("aliasError() message = %q", got.Message)
+	}
+	details := got.Details.(ConstraintViolation)
+	if details.Constraint != "users_usr_nm_id_key" || details.Fields[0] != "username" {
+		t.Errorf("aliasError() details = %v", details)
+	}
+	if violation.Fields[0] != "usr_nm" {
+		t.Error("aliasError() changed the original error")
+	}
+}
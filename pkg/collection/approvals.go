@@ -0,0 +1,456 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Pending change statuses.
+const (
+	ChangeStatusPending  = "pending"
+	ChangeStatusApproved = "approved"
+	ChangeStatusRejected = "rejected"
+)
+
+// tugo_audit_log actions of reviewed changes.
+const (
+	AuditApproveAction = "approval.approve"
+	AuditRejectAction  = "approval.reject"
+)
+
+// PendingChange is a create or update of a collection awaiting review.
+type PendingChange struct {
+	ID          string         `db:"id" json:"id"`
+	Collection  string         `db:"collection" json:"collection"`
+	Action      string         `db:"action" json:"action"`
+	ItemID      *string        `db:"item_id" json:"item_id,omitempty"`
+	Data        map[string]any `db:"-" json:"data"`
+	Status      string         `db:"status" json:"status"`
+	SubmittedBy *string        `db:"submitted_by" json:"submitted_by,omitempty"`
+	ReviewedBy  *string        `db:"reviewed_by" json:"reviewed_by,omitempty"`
+	ReviewNote  *string        `db:"review_note" json:"review_note,omitempty"`
+	CreatedAt   time.Time      `db:"created_at" json:"created_at"`
+	ReviewedAt  *time.Time     `db:"reviewed_at" json:"reviewed_at,omitempty"`
+
+	RawData []byte `db:"data" json:"-"`
+}
+
+// ChangeFilter selects pending changes to list.
+type ChangeFilter struct {
+	// Status defaults to pending.
+	Status     string
+	Collection string
+	Limit      int
+}
+
+// ApprovalStore persists pending changes in tugo_pending_changes.
+type ApprovalStore struct {
+	db *sqlx.DB
+}
+
+// NewApprovalStore creates a new approval store.
+func NewApprovalStore(db *sqlx.DB) *ApprovalStore {
+	return &ApprovalStore{db: db}
+}
+
+// SetApprovalStore enables the approval workflow of collections configured
+// with one.
+func (s *Service) SetApprovalStore(store *ApprovalStore) {
+	s.approvals = store
+}
+
+// Create stores a new pending change.
+func (s *ApprovalStore) Create(ctx context.Context, change *PendingChange) error {
+	payload, err := json.Marshal(change.Data)
+	if err != nil {
+		return fmt.Errorf("failed to encode pending change: %w", err)
+	}
+	change.ID = uuid.New().String()
+	change.Status = ChangeStatusPending
+	change.CreatedAt = time.Now()
+
+	query := `
+		INSERT INTO tugo_pending_changes (id, collection, action, item_id, data, status, submitted_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = s.db.ExecContext(ctx, s.db.Rebind(query), change.ID, change.Collection, change.Action,
+		change.ItemID, string(payload), change.Status, change.SubmittedBy, change.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store pending change: %w", err)
+	}
+	return nil
+}
+
+// Get returns a pending change by ID.
+func (s *ApprovalStore) Get(ctx context.Context, id string) (*PendingChange, error) {
+	query := `
+		SELECT id, collection, action, item_id, data, status, submitted_by, reviewed_by, review_note, created_at, reviewed_at
+		FROM tugo_pending_changes
+		WHERE id = ?
+	`
+	var change PendingChange
+	if err := s.db.GetContext(ctx, &change, s.db.Rebind(query), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Change '%s' not found", id)
+		}
+		return nil, fmt.Errorf("failed to get pending change: %w", err)
+	}
+	if err := change.decode(); err != nil {
+		return nil, err
+	}
+	return &change, nil
+}
+
+// List returns the changes matching filter, oldest first.
+func (s *ApprovalStore) List(ctx context.Context, filter ChangeFilter) ([]PendingChange, error) {
+	if filter.Status == "" {
+		filter.Status = ChangeStatusPending
+	}
+	query := `
+		SELECT id, collection, action, item_id, data, status, submitted_by, reviewed_by, review_note, created_at, reviewed_at
+		FROM tugo_pending_changes
+		WHERE status = ?
+	`
+	args := []any{filter.Status}
+	if filter.Collection != "" {
+		query += " AND collection = ?"
+		args = append(args, filter.Collection)
+	}
+	query += " ORDER BY created_at, id"
+	if filter.Limit > 0 {
+		query += fmt.Sprintf(" LIMIT %d", filter.Limit)
+	}
+
+	changes := make([]PendingChange, 0)
+	if err := s.db.SelectContext(ctx, &changes, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to list pending changes: %w", err)
+	}
+	for i := range changes {
+		if err := changes[i].decode(); err != nil {
+			return nil, err
+		}
+	}
+	return changes, nil
+}
+
+// decode unmarshals the stored data.
+func (c *PendingChange) decode() error {
+	if len(c.RawData) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.RawData, &c.Data); err != nil {
+		return fmt.Errorf("failed to decode pending change '%s': %w", c.ID, err)
+	}
+	return nil
+}
+
+// needsApproval reports whether an action on collection by the request's
+// user must be reviewed. Requests without a user are always reviewed.
+func (s *Service) needsApproval(ctx context.Context, collection *schema.Collection, action string) bool {
+	if s.approvals == nil || collection.Approval == nil {
+		return false
+	}
+	var role string
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		role = user.Role
+	}
+	return collection.Approval.Reviews(action, role)
+}
+
+// submitChange stores a write as a pending change, returning
+// apperror.ErrPendingApproval with the change as details.
+func (s *Service) submitChange(ctx context.Context, collection *schema.Collection, action string, id any, data map[string]any) error {
+	change := &PendingChange{
+		Collection: collection.Name,
+		Action:     action,
+		Data:       filterFields(data, collection.Fields),
+	}
+	if id != nil {
+		itemID := fmt.Sprint(id)
+		change.ItemID = &itemID
+	}
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil && user.ID != "" {
+		change.SubmittedBy = &user.ID
+	}
+
+	if err := s.approvals.Create(ctx, change); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return apperror.ErrPendingApproval.WithDetails(change)
+}
+
+// ListChanges returns the changes matching filter, oldest first.
+func (s *Service) ListChanges(ctx context.Context, filter ChangeFilter) ([]PendingChange, error) {
+	if s.approvals == nil {
+		return nil, apperror.ErrNotFound.WithMessage("Approvals are not enabled")
+	}
+	changes, err := s.approvals.List(ctx, filter)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return changes, nil
+}
+
+// GetChange returns a change by ID.
+func (s *Service) GetChange(ctx context.Context, id string) (*PendingChange, error) {
+	if s.approvals == nil {
+		return nil, apperror.ErrNotFound.WithMessage("Approvals are not enabled")
+	}
+	change, err := s.approvals.Get(ctx, id)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return nil, err
+		}
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return change, nil
+}
+
+// ApproveChange applies a pending change as its submitter and marks it
+// approved by the request's user, in one transaction that also records it
+// in tugo_audit_log. It returns the written item.
+func (s *Service) ApproveChange(ctx context.Context, id, note string) (*PendingChange, map[string]any, error) {
+	change, collection, err := s.pendingChange(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, nil, err
+	}
+
+	// Server-filled fields name the submitter, not the reviewer
+	submitter := &auth.User{}
+	if change.SubmittedBy != nil {
+		submitter.ID = *change.SubmittedBy
+	}
+	writeCtx := auth.SetUserInContext(ctx, submitter)
+
+	var data map[string]any
+	var itemID any
+	var record RevisionFunc
+	switch change.Action {
+	case NotifyActionCreate:
+		data, err = s.prepareCreate(writeCtx, collection, change.Data)
+	case NotifyActionUpdate:
+		itemID = *change.ItemID
+		data, err = s.prepareUpdate(writeCtx, collection, itemID, change.Data)
+		record = s.revisionFunc(ctx, collection, RevisionActionUpdate)
+	default:
+		err = apperror.ErrBadRequest.WithMessagef("Unknown change action '%s'", change.Action)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	s.review(ctx, change, ChangeStatusApproved, note)
+	itemID, err = s.repo.ApplyChange(ctx, collection, change, itemID, data, record)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	item, err := s.repo.GetByID(ctx, collection, itemID)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.notify(ctx, collection, change.Action, item)
+	s.notifyReview(ctx, change, itemID)
+	return change, item, nil
+}
+
+// RejectChange marks a pending change rejected by the request's user and
+// records it in tugo_audit_log.
+func (s *Service) RejectChange(ctx context.Context, id, note string) (*PendingChange, error) {
+	change, collection, err := s.pendingChange(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	s.review(ctx, change, ChangeStatusRejected, note)
+	if err := s.repo.RejectChange(ctx, collection, change); err != nil {
+		return nil, err
+	}
+	s.notifyReview(ctx, change, nil)
+	return change, nil
+}
+
+// pendingChange loads a change awaiting review and its collection.
+func (s *Service) pendingChange(ctx context.Context, id string) (*PendingChange, *schema.Collection, error) {
+	change, err := s.GetChange(ctx, id)
+	if err != nil {
+		return nil, nil, err
+	}
+	if change.Status != ChangeStatusPending {
+		return nil, nil, apperror.ErrConflict.WithMessagef("Change '%s' was already %s", id, change.Status)
+	}
+	collection, err := s.schemaManager.GetCollection(change.Collection)
+	if err != nil {
+		return nil, nil, err
+	}
+	return change, collection, nil
+}
+
+// review fills in the review of change by the request's user.
+func (s *Service) review(ctx context.Context, change *PendingChange, status, note string) {
+	now := time.Now()
+	change.Status = status
+	change.ReviewedAt = &now
+	change.ReviewedBy = nil
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil && user.ID != "" {
+		change.ReviewedBy = &user.ID
+	}
+	change.ReviewNote = nil
+	if note != "" {
+		change.ReviewNote = &note
+	}
+}
+
+// ApplyChange writes the prepared data of an approved change, marks the
+// change reviewed and audits it in one transaction, returning the item's ID.
+// The version an update replaces is passed to record when it is non-nil.
+// A change reviewed concurrently fails with a conflict.
+func (r *Repository) ApplyChange(ctx context.Context, collection *schema.Collection, change *PendingChange, id any, data map[string]any, record RevisionFunc) (any, error) {
+	if err := prepareValues(collection, data); err != nil {
+		return nil, err
+	}
+
+	err := r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		var err error
+		if id == nil {
+			id, err = r.insertTx(ctx, tx, collection, data)
+		} else {
+			err = r.applyUpdateTx(ctx, tx, collection, id, data, record)
+		}
+		if err != nil {
+			return err
+		}
+		itemID := fmt.Sprint(id)
+		change.ItemID = &itemID
+		return r.reviewTx(ctx, tx, collection, change, AuditApproveAction)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return id, nil
+}
+
+// RejectChange marks a change rejected and audits it in one transaction.
+func (r *Repository) RejectChange(ctx context.Context, collection *schema.Collection, change *PendingChange) error {
+	return r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		return r.reviewTx(ctx, tx, collection, change, AuditRejectAction)
+	})
+}
+
+// insertTx inserts an item in tx, returning its primary key.
+func (r *Repository) insertTx(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, data map[string]any) (any, error) {
+	querySQL, args := query.BuildInsertDialect(r.dialect, collection.TableName, data)
+	if r.dialect.SupportsReturning() {
+		row := make(map[string]any)
+		if err := tx.QueryRowxContext(ctx, querySQL, args...).MapScan(row); err != nil {
+			return nil, duplicateError(ctx, err, "Record already exists")
+		}
+		id := row[collection.PrimaryKey]
+		if b, ok := id.([]byte); ok {
+			id = string(b)
+		}
+		return id, nil
+	}
+
+	res, err := tx.ExecContext(ctx, querySQL, args...)
+	if err != nil {
+		return nil, duplicateError(ctx, err, "Record already exists")
+	}
+	if id, ok := data[collection.PrimaryKey]; ok {
+		return id, nil
+	}
+	lastID, err := res.LastInsertId()
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return lastID, nil
+}
+
+// updateTx updates an existing item in tx.
+func (r *Repository) updateTx(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, id any, data map[string]any) error {
+	if len(data) == 0 {
+		return nil
+	}
+	querySQL, args := query.BuildUpdateDialect(r.dialect, collection.TableName, collection.PrimaryKey, id, data)
+	if r.dialect.SupportsReturning() {
+		row := make(map[string]any)
+		if err := tx.QueryRowxContext(ctx, querySQL, args...).MapScan(row); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", id)
+			}
+			return duplicateError(ctx, err, "Record with this value already exists")
+		}
+		return nil
+	}
+
+	if _, err := tx.ExecContext(ctx, querySQL, args...); err != nil {
+		return duplicateError(ctx, err, "Record with this value already exists")
+	}
+	return nil
+}
+
+// applyUpdateTx updates an item in tx, passing the version it replaces to
+// record when it is non-nil.
+func (r *Repository) applyUpdateTx(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, id any, data map[string]any, record RevisionFunc) error {
+	if record == nil {
+		return r.updateTx(ctx, tx, collection, id, data)
+	}
+	previous, err := r.getTx(ctx, tx, collection, id, true)
+	if err != nil {
+		return err
+	}
+	if err := r.updateTx(ctx, tx, collection, id, data); err != nil {
+		return err
+	}
+	return record(ctx, tx, id, previous)
+}
+
+// reviewTx stores the review of a pending change and audits it.
+func (r *Repository) reviewTx(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, change *PendingChange, action string) error {
+	res, err := tx.ExecContext(ctx, tx.Rebind(`
+		UPDATE tugo_pending_changes
+		SET status = ?, item_id = ?, reviewed_by = ?, review_note = ?, reviewed_at = ?
+		WHERE id = ? AND status = ?
+	`), change.Status, change.ItemID, change.ReviewedBy, change.ReviewNote, change.ReviewedAt, change.ID, ChangeStatusPending)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrConflict.WithMessagef("Change '%s' was already reviewed", change.ID)
+	}
+
+	audit, err := json.Marshal(map[string]any{
+		"change_id":    change.ID,
+		"action":       change.Action,
+		"data":         change.Data,
+		"submitted_by": change.SubmittedBy,
+		"reviewed_by":  change.ReviewedBy,
+		"note":         change.ReviewNote,
+	})
+	if err != nil {
+		return err
+	}
+	var itemID any
+	if change.ItemID != nil {
+		itemID = *change.ItemID
+	}
+	auditSQL := tx.Rebind(`INSERT INTO tugo_audit_log (id, action, collection, item_id, changes, created_at) VALUES (?, ?, ?, ?, ?, ?)`)
+	if _, err := tx.ExecContext(ctx, auditSQL, uuid.NewString(), action, collection.Name, itemID, string(audit), *change.ReviewedAt); err != nil {
+		return dbError(ctx, err)
+	}
+	return nil
+}
@@ -0,0 +1,44 @@
+package collection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestNeedsApproval(t *testing.T) {
+	reviewed := &schema.Approval{Actions: []string{"create"}, BypassRoles: []string{"editor"}}
+
+	tests := []struct {
+		name     string
+		approval *schema.Approval
+		role     string
+		anon     bool
+		action   string
+		want     bool
+	}{
+		{"no workflow", nil, "user", false, NotifyActionCreate, false},
+		{"reviewed role", reviewed, "user", false, NotifyActionCreate, true},
+		{"unreviewed action", reviewed, "user", false, NotifyActionUpdate, false},
+		{"bypass role", reviewed, "editor", false, NotifyActionCreate, false},
+		{"admin", reviewed, "admin", false, NotifyActionCreate, false},
+		{"anonymous", reviewed, "", true, NotifyActionCreate, true},
+		{"all actions by default", &schema.Approval{}, "user", false, NotifyActionUpdate, true},
+	}
+
+	s := &Service{approvals: &ApprovalStore{}}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			if !tt.anon {
+				ctx = auth.SetUserInContext(ctx, &auth.User{ID: "u1", Role: tt.role})
+			}
+			collection := &schema.Collection{Name: "docs", Approval: tt.approval}
+			if got := s.needsApproval(ctx, collection, tt.action); got != tt.want {
+				t.Errorf("needsApproval() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,44 @@
+package collection
+
+import (
+	"context"
+	"time"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// fillAutoFields sets the collection's auto-filled columns in write data
+// from now and the request's user, replacing values sent by the client. On
+// update the created columns are dropped instead. Without an authenticated
+// user the user columns are left to their defaults.
+func fillAutoFields(ctx context.Context, collection *schema.Collection, data map[string]any, create bool, now time.Time) {
+	fields := collection.AutoFields
+	if fields.IsZero() {
+		return
+	}
+
+	var userID any
+	if user, ok := auth.GetUserFromContext(ctx); ok && user.ID != "" {
+		userID = user.ID
+	}
+	set := func(name string, value any) {
+		switch {
+		case name == "":
+		case value == nil:
+			delete(data, name)
+		default:
+			data[name] = value
+		}
+	}
+
+	if create {
+		set(fields.CreatedAt, now)
+		set(fields.CreatedBy, userID)
+	} else {
+		set(fields.CreatedAt, nil)
+		set(fields.CreatedBy, nil)
+	}
+	set(fields.UpdatedAt, now)
+	set(fields.UpdatedBy, userID)
+}
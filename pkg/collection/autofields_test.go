@@ -0,0 +1,77 @@
+package collection
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestFillAutoFields(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	collection := &schema.Collection{AutoFields: schema.DefaultAutoFields}
+	userCtx := auth.SetUserInContext(context.Background(), &auth.User{ID: "42"})
+
+	tests := []struct {
+		name       string
+		collection *schema.Collection
+		ctx        context.Context
+		create     bool
+		data       map[string]any
+		want       map[string]any
+	}{
+		{
+			name:       "create with user",
+			collection: collection,
+			ctx:        userCtx,
+			create:     true,
+			data:       map[string]any{"title": "a", "created_by": "7"},
+			want: map[string]any{
+				"title": "a", "created_at": now, "updated_at": now, "created_by": "42", "updated_by": "42",
+			},
+		},
+		{
+			name:       "create without user",
+			collection: collection,
+			ctx:        context.Background(),
+			create:     true,
+			data:       map[string]any{"title": "a", "updated_by": "7"},
+			want:       map[string]any{"title": "a", "created_at": now, "updated_at": now},
+		},
+		{
+			name:       "update keeps created columns",
+			collection: collection,
+			ctx:        userCtx,
+			data:       map[string]any{"title": "b", "created_at": "2000-01-01", "created_by": "7"},
+			want:       map[string]any{"title": "b", "updated_at": now, "updated_by": "42"},
+		},
+		{
+			name:       "partial columns",
+			collection: &schema.Collection{AutoFields: schema.AutoFields{UpdatedAt: "modified"}},
+			ctx:        userCtx,
+			create:     true,
+			data:       map[string]any{"created_at": "2000-01-01"},
+			want:       map[string]any{"created_at": "2000-01-01", "modified": now},
+		},
+		{
+			name:       "disabled",
+			collection: &schema.Collection{},
+			ctx:        userCtx,
+			create:     true,
+			data:       map[string]any{"created_by": "7"},
+			want:       map[string]any{"created_by": "7"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fillAutoFields(tt.ctx, tt.collection, tt.data, tt.create, now)
+			if !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("fillAutoFields() = %v, want %v", tt.data, tt.want)
+			}
+		})
+	}
+}
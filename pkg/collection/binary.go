@@ -0,0 +1,159 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// rawChunkBytes is the size of the slices read by the raw field endpoint.
+const rawChunkBytes = 1 << 20
+
+// binaryFields returns the names of the collection's binary fields, or nil
+// when it has none.
+func binaryFields(collection *schema.Collection) map[string]bool {
+	var fields map[string]bool
+	for _, f := range collection.Fields {
+		if f.DataType == "binary" {
+			if fields == nil {
+				fields = make(map[string]bool)
+			}
+			fields[f.Name] = true
+		}
+	}
+	return fields
+}
+
+// normalizeBinary encodes a scanned binary value as base64.
+func normalizeBinary(v any) any {
+	if b, ok := v.([]byte); ok {
+		return base64.StdEncoding.EncodeToString(b)
+	}
+	return v
+}
+
+// decodeBinary replaces the base64 strings given for binary fields with
+// their bytes.
+func decodeBinary(collection *schema.Collection, data map[string]any) error {
+	for name := range binaryFields(collection) {
+		s, ok := data[name].(string)
+		if !ok {
+			continue
+		}
+		b, err := base64.StdEncoding.DecodeString(s)
+		if err != nil {
+			return apperror.ErrValidation.WithDetails([]validation.FieldError{
+				{Field: name, Message: "must be base64 encoded", Code: "invalid_base64"},
+			})
+		}
+		data[name] = b
+	}
+	return nil
+}
+
+// ReadBinary reads a binary field of an item in slices of chunkSize bytes,
+// calling fn with the field's total size and each slice. The slices are
+// read with separate statements, so a large value is never held in memory
+// at once.
+func (r *Repository) ReadBinary(ctx context.Context, collection *schema.Collection, id any, field string, chunkSize int, fn func(size int64, chunk []byte) error) error {
+	querySQL := query.BuildSliceDialect(r.dialect, collection.TableName, collection.PrimaryKey, field)
+
+	var size int64
+	for offset := int64(0); offset == 0 || offset < size; {
+		var length sql.NullInt64
+		var chunk []byte
+		err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+			row := q.QueryRowxContext(ctx, querySQL, offset+1, chunkSize, id)
+			if err := row.Scan(&length, &chunk); err != nil {
+				if errors.Is(err, sql.ErrNoRows) {
+					return apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", id)
+				}
+				if isInvalidUUIDError(err) {
+					return apperror.ErrBadRequest.WithMessagef("Invalid ID format: '%v'", id)
+				}
+				return dbError(ctx, err)
+			}
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+
+		if !length.Valid {
+			return apperror.ErrNotFound.WithMessagef("Field '%s' of item '%v' has no value", field, id)
+		}
+		if offset == 0 {
+			size = length.Int64
+		}
+		if len(chunk) == 0 {
+			// The value was replaced by a shorter one between reads
+			break
+		}
+		if err := fn(size, chunk); err != nil {
+			return err
+		}
+		offset += int64(len(chunk))
+	}
+	if size == 0 {
+		return fn(0, nil)
+	}
+	return nil
+}
+
+// Raw streams the value of a binary field of an item to fn.
+func (s *Service) Raw(ctx context.Context, collectionName string, id any, field string, fn func(size int64, chunk []byte) error) error {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return err
+	}
+
+	if collection.PrimaryKey == "" {
+		return apperror.ErrBadRequest.WithMessagef("Collection '%s' has no primary key", collectionName)
+	}
+	if !binaryFields(collection)[field] {
+		return apperror.ErrBadRequest.WithMessagef("Field '%s' is not a binary field", field)
+	}
+
+	if hidesUnpublished(ctx, collection) {
+		if _, err := s.getVisible(ctx, collection, id); err != nil {
+			return err
+		}
+	}
+	return s.repo.ReadBinary(ctx, collection, id, field, rawChunkBytes, fn)
+}
+
+// Raw handles GET /:collection/:id/raw/:field requests.
+// The field's bytes are streamed as application/octet-stream.
+func (h *Handler) Raw(c *gin.Context) {
+	started := false
+	err := h.service.Raw(c.Request.Context(), c.Param("collection"), c.Param("id"), c.Param("field"), func(size int64, chunk []byte) error {
+		if !started {
+			started = true
+			c.Header("Content-Type", "application/octet-stream")
+			c.Header("Content-Length", strconv.FormatInt(size, 10))
+			c.Header("X-Content-Type-Options", "nosniff")
+			c.Status(http.StatusOK)
+		}
+		_, err := c.Writer.Write(chunk)
+		return err
+	})
+
+	switch {
+	case err != nil && !started:
+		h.handleError(c, err)
+	case err != nil:
+		// The status is already sent; the short body signals the failure
+		requestlog.Logger(c.Request.Context(), h.logger).Errorw("Raw field read failed", "collection", c.Param("collection"), "field", c.Param("field"), "error", err)
+	}
+}
@@ -0,0 +1,83 @@
+package collection
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestRowScanner_Binary(t *testing.T) {
+	collection := &schema.Collection{Fields: []schema.Field{
+		{Name: "id", DataType: "int"},
+		{Name: "body", DataType: "binary"},
+	}}
+	body := `"TGluZSAib25lIgo8Yj50d288L2I+IMOp"`
+
+	rows := fakeQuery(t, 2)
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+	scanner.setFields(collection, valueEncoder{})
+
+	if !rows.Next() {
+		t.Fatal("result set is empty")
+	}
+	item, err := scanner.scanMap()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := json.Marshal(item["body"]); string(got) != body {
+		t.Errorf("scanMap() body = %s, want %s", got, body)
+	}
+
+	if !rows.Next() {
+		t.Fatal("result set ended early")
+	}
+	buf, err := scanner.appendJSON(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Contains(buf, []byte(`"body":`+body+",")) {
+		t.Errorf("appendJSON() = %s, want body %s", buf, body)
+	}
+}
+
+func TestDecodeBinary(t *testing.T) {
+	collection := &schema.Collection{Fields: []schema.Field{
+		{Name: "name", DataType: "string"},
+		{Name: "data", DataType: "binary"},
+	}}
+
+	tests := []struct {
+		name    string
+		data    map[string]any
+		want    any
+		wantErr bool
+	}{
+		{"base64", map[string]any{"name": "aGk=", "data": "aGk="}, []byte("hi"), false},
+		{"null", map[string]any{"name": "x", "data": nil}, nil, false},
+		{"invalid", map[string]any{"data": "not base64!"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := decodeBinary(collection, tt.data)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBinary() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !reflect.DeepEqual(tt.data["data"], tt.want) {
+				t.Errorf("decodeBinary() data = %#v, want %#v", tt.data["data"], tt.want)
+			}
+			if _, ok := tt.data["name"].([]byte); ok {
+				t.Errorf("decodeBinary() decoded a non-binary field")
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package collection
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// DefaultMaxBodyBytes is the request body limit of collections without one.
+const DefaultMaxBodyBytes int64 = 10 << 20
+
+// limitBody caps the request body at the collection's limit. Requests
+// declaring a larger Content-Length are rejected before the body is read.
+func (h *Handler) limitBody(c *gin.Context) {
+	col, err := h.service.schemaManager.GetCollection(c.Param("collection"))
+	if err != nil {
+		h.handleError(c, err)
+		c.Abort()
+		return
+	}
+
+	limit := col.MaxBodyBytes
+	if limit == 0 {
+		limit = DefaultMaxBodyBytes
+	}
+	if limit > 0 && c.Request.Body != nil {
+		if c.Request.ContentLength > limit {
+			h.handleError(c, tooLarge(limit))
+			c.Abort()
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, limit)
+	}
+	c.Next()
+}
+
+// bodyError maps a failure to read or decode a request body to an AppError.
+func bodyError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return tooLarge(maxErr.Limit)
+	}
+	if _, ok := apperror.AsAppError(err); ok {
+		return err
+	}
+	return apperror.ErrBadRequest.WithMessage("Invalid JSON body")
+}
+
+// tooLarge reports a request body over limit bytes.
+func tooLarge(limit int64) error {
+	return apperror.ErrPayloadTooLarge.WithMessagef("Request body exceeds %d bytes", limit)
+}
+
+// decodeBody decodes a request body holding either one JSON object or an
+// array of them, without buffering the raw body. Numbers are decoded as
+// json.Number, for decimal fields to keep their exact digits. At most maxItems+1 array
+// items are decoded, enough for the caller to reject oversized batches.
+func decodeBody(body io.Reader, maxItems int) (map[string]any, []map[string]any, bool, error) {
+	reader := bufio.NewReader(body)
+	isArray, err := startsWithArray(reader)
+	if err != nil {
+		return nil, nil, false, err
+	}
+	decoder := json.NewDecoder(reader)
+	decoder.UseNumber()
+
+	if !isArray {
+		var data map[string]any
+		if err := decoder.Decode(&data); err != nil {
+			return nil, nil, false, err
+		}
+		if data == nil {
+			return nil, nil, false, errors.New("body is not an object")
+		}
+		return data, nil, false, nil
+	}
+
+	if _, err := decoder.Token(); err != nil {
+		return nil, nil, true, err
+	}
+	items := make([]map[string]any, 0)
+	for decoder.More() && len(items) <= maxItems {
+		var item map[string]any
+		if err := decoder.Decode(&item); err != nil {
+			return nil, nil, true, err
+		}
+		items = append(items, item)
+	}
+	if len(items) <= maxItems {
+		if _, err := decoder.Token(); err != nil {
+			return nil, nil, true, err
+		}
+	}
+	return nil, items, true, nil
+}
+
+// startsWithArray reports whether the first non-space byte opens an array.
+func startsWithArray(reader *bufio.Reader) (bool, error) {
+	for {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return false, err
+		}
+		switch b {
+		case ' ', '\t', '\r', '\n':
+			continue
+		}
+		return b == '[', reader.UnreadByte()
+	}
+}
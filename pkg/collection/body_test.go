@@ -0,0 +1,62 @@
+package collection
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+func TestDecodeBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		batch   bool
+		items   int
+		wantErr bool
+	}{
+		{"object", ` {"title": "a"}`, false, 0, false},
+		{"array", "\n[{\"title\": \"a\"}, {\"title\": \"b\"}]", true, 2, false},
+		{"empty array", `[]`, true, 0, false},
+		{"over limit", `[{}, {}, {}, {}, {}]`, true, 4, false},
+		{"empty", ``, false, 0, true},
+		{"null", `null`, false, 0, true},
+		{"truncated array", `[{"title": "a"},`, true, 0, true},
+		{"scalar items", `[1, 2]`, true, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data, items, batch, err := decodeBody(strings.NewReader(tt.body), 3)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeBody() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if batch != tt.batch || len(items) != tt.items {
+				t.Errorf("decodeBody() batch = %v, items = %d, want %v, %d", batch, len(items), tt.batch, tt.items)
+			}
+			if !batch && data["title"] != "a" {
+				t.Errorf("decodeBody() data = %v", data)
+			}
+		})
+	}
+}
+
+func TestBodyError(t *testing.T) {
+	body := http.MaxBytesReader(httptest.NewRecorder(), io.NopCloser(strings.NewReader(`{"title": "too long"}`)), 8)
+	_, _, _, err := decodeBody(body, 1)
+
+	var appErr *apperror.AppError
+	if !errors.As(bodyError(err), &appErr) || appErr.HTTPStatus != http.StatusRequestEntityTooLarge {
+		t.Errorf("bodyError() = %v, want 413", bodyError(err))
+	}
+	if !errors.As(bodyError(errors.New("bad")), &appErr) || appErr.HTTPStatus != http.StatusBadRequest {
+		t.Errorf("bodyError() = %v, want 400", bodyError(errors.New("bad")))
+	}
+}
@@ -0,0 +1,134 @@
+package collection
+
+import (
+	"context"
+	"time"
+
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Bulk insert defaults.
+const (
+	// DefaultCopyThreshold is the row count above which PostgreSQL batch creates use COPY.
+	DefaultCopyThreshold = 500
+
+	// DefaultMaxBatchItems caps the items of one batch create.
+	DefaultMaxBatchItems = 10000
+)
+
+// BulkConfig configures batch creates and writes by filter.
+type BulkConfig struct {
+	// CopyThreshold switches PostgreSQL batch creates of more than this many
+	// rows from multi-row INSERTs to COPY. A negative value disables COPY.
+	// Default: 500
+	CopyThreshold int
+
+	// MaxItems caps the items of one batch create.
+	// Default: 10000
+	MaxItems int
+
+	// MaxAffected caps the items one update by filter affects
+	// unless the request confirms their number.
+	// Default: 1000
+	MaxAffected int
+}
+
+// SetBulkConfig sets how batch creates are inserted and how many items
+// writes by filter affect.
+func (s *Service) SetBulkConfig(config BulkConfig) {
+	if config.CopyThreshold == 0 {
+		config.CopyThreshold = DefaultCopyThreshold
+	}
+	if config.MaxItems <= 0 {
+		config.MaxItems = DefaultMaxBatchItems
+	}
+	if config.MaxAffected <= 0 {
+		config.MaxAffected = DefaultMaxAffected
+	}
+	s.bulk = config
+}
+
+// CreateMany validates and inserts items in one transaction, returning the
+// number created. Either every item is created or none is.
+func (s *Service) CreateMany(ctx context.Context, collectionName string, items []map[string]any) (int64, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return 0, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return 0, err
+	}
+
+	if s.needsApproval(ctx, collection, NotifyActionCreate) {
+		return 0, apperror.ErrForbidden.WithMessagef("Creates in '%s' need approval; create items one at a time", collectionName)
+	}
+
+	maxItems := s.maxBatchItems()
+	if len(items) > maxItems {
+		return 0, apperror.ErrBadRequest.WithMessagef("At most %d items can be created at once", maxItems)
+	}
+	if len(items) == 0 {
+		return 0, nil
+	}
+
+	// Append new items to the end of manually ordered lists, in request order
+	var pos int64
+	ordered := hasSortOrder(collection)
+	if ordered {
+		if pos, err = s.nextPosition(ctx, collection); err != nil {
+			return 0, err
+		}
+	}
+
+	now := time.Now().UTC()
+	slugs := make(map[string]bool)
+	combinations := make(map[string]bool)
+	filtered := make([]map[string]any, len(items))
+	for i, data := range items {
+		filtered[i] = filterFields(data, collection.Fields)
+		if validationErr := normalizeNumbers(collection, filtered[i]); validationErr != nil {
+			return 0, apperror.ErrValidation.WithMessagef("Item %d: %s", i, validationErr.Error()).WithDetails(validationErr.Errors)
+		}
+		fillAutoFields(ctx, collection, filtered[i], true, now)
+		if err := checkLifecycleCreate(ctx, collection, filtered[i], now); err != nil {
+			return 0, err
+		}
+		if err := s.fillID(collection, filtered[i]); err != nil {
+			return 0, err
+		}
+		if err := s.fillSlugs(ctx, collection, filtered[i], slugs); err != nil {
+			return 0, err
+		}
+		if ordered && filtered[i][SortOrderField] == nil {
+			filtered[i][SortOrderField] = pos
+			pos += SortOrderStep
+		}
+
+		if s.validator != nil {
+			if validationErr := s.validator.Validate(ctx, collectionName, filtered[i]); validationErr != nil {
+				return 0, apperror.ErrValidation.WithMessagef("Item %d: %s", i, validationErr.Error()).WithDetails(validationErr.Errors)
+			}
+		}
+		if err := s.checkUniqueConstraints(ctx, collection, nil, filtered[i], combinations); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.fillEmbeddings(ctx, collection, filtered); err != nil {
+		return 0, err
+	}
+
+	threshold := s.bulk.CopyThreshold
+	if threshold == 0 {
+		threshold = DefaultCopyThreshold
+	}
+	return s.repo.CreateMany(ctx, collection, filtered, threshold)
+}
+
+// maxBatchItems returns the most items one batch create may hold.
+func (s *Service) maxBatchItems() int {
+	if s.bulk.MaxItems <= 0 {
+		return DefaultMaxBatchItems
+	}
+	return s.bulk.MaxItems
+}
@@ -0,0 +1,390 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// MaxComments caps the comments returned for one item.
+const MaxComments = 1000
+
+// MaxCommentLength caps the characters of a comment body.
+const MaxCommentLength = 10000
+
+// mentionPattern matches @username mentions in comment bodies, skipping
+// email addresses.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w[\w.-]*)`)
+
+// Comment is a comment on a collection item. Replies name the comment
+// they answer as their parent.
+type Comment struct {
+	ID         string    `db:"id" json:"id"`
+	Collection string    `db:"collection" json:"collection"`
+	ItemID     string    `db:"item_id" json:"item_id"`
+	ParentID   *string   `db:"parent_id" json:"parent_id,omitempty"`
+	UserID     *string   `db:"user_id" json:"user_id"`
+	Body       string    `db:"body" json:"body"`
+	Mentions   []string  `db:"-" json:"mentions"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+
+	// Replies holds the answers to the comment, oldest first.
+	Replies []*Comment `db:"-" json:"replies,omitempty"`
+
+	RawMentions []byte `db:"mentions" json:"-"`
+}
+
+// CommentStore persists comments in tugo_comments.
+type CommentStore struct {
+	db *sqlx.DB
+}
+
+// NewCommentStore creates a new comment store.
+func NewCommentStore(db *sqlx.DB) *CommentStore {
+	return &CommentStore{db: db}
+}
+
+// SetCommentStore enables comments on collections configured with them.
+func (s *Service) SetCommentStore(store *CommentStore) {
+	s.comments = store
+}
+
+// Create stores a new comment.
+func (s *CommentStore) Create(ctx context.Context, comment *Comment) error {
+	mentions, err := json.Marshal(comment.Mentions)
+	if err != nil {
+		return fmt.Errorf("failed to encode comment mentions: %w", err)
+	}
+	comment.ID = uuid.New().String()
+	comment.CreatedAt = time.Now()
+	comment.UpdatedAt = comment.CreatedAt
+
+	query := `
+		INSERT INTO tugo_comments (id, collection, item_id, parent_id, user_id, body, mentions, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = s.db.ExecContext(ctx, s.db.Rebind(query), comment.ID, comment.Collection, comment.ItemID,
+		comment.ParentID, comment.UserID, comment.Body, string(mentions), comment.CreatedAt, comment.UpdatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store comment: %w", err)
+	}
+	return nil
+}
+
+// Get returns a comment by ID.
+func (s *CommentStore) Get(ctx context.Context, id string) (*Comment, error) {
+	query := `
+		SELECT id, collection, item_id, parent_id, user_id, body, mentions, created_at, updated_at
+		FROM tugo_comments
+		WHERE id = ?
+	`
+	var comment Comment
+	if err := s.db.GetContext(ctx, &comment, s.db.Rebind(query), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Comment '%s' not found", id)
+		}
+		return nil, fmt.Errorf("failed to get comment: %w", err)
+	}
+	if err := comment.decode(); err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+// List returns up to limit comments on an item, oldest first.
+func (s *CommentStore) List(ctx context.Context, collection, itemID string, limit int) ([]*Comment, error) {
+	query := fmt.Sprintf(`
+		SELECT id, collection, item_id, parent_id, user_id, body, mentions, created_at, updated_at
+		FROM tugo_comments
+		WHERE collection = ? AND item_id = ?
+		ORDER BY created_at, id
+		LIMIT %d
+	`, limit)
+	comments := make([]*Comment, 0)
+	if err := s.db.SelectContext(ctx, &comments, s.db.Rebind(query), collection, itemID); err != nil {
+		return nil, fmt.Errorf("failed to list comments: %w", err)
+	}
+	for _, comment := range comments {
+		if err := comment.decode(); err != nil {
+			return nil, err
+		}
+	}
+	return comments, nil
+}
+
+// DeleteThread removes a comment and every reply below it.
+func (s *CommentStore) DeleteThread(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := `
+		WITH RECURSIVE thread (id) AS (
+			SELECT id FROM tugo_comments WHERE id = ?
+			UNION ALL
+			SELECT c.id FROM tugo_comments c JOIN thread t ON c.parent_id = t.id
+		)
+		SELECT id FROM thread
+	`
+	var ids []string
+	if err := tx.SelectContext(ctx, &ids, tx.Rebind(query), id); err != nil {
+		return fmt.Errorf("failed to find replies: %w", err)
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	query, args, err := sqlx.In(`DELETE FROM tugo_comments WHERE id IN (?)`, ids)
+	if err != nil {
+		return fmt.Errorf("failed to delete comments: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, tx.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to delete comments: %w", err)
+	}
+	return tx.Commit()
+}
+
+// DeleteItem removes the comments on an item.
+func (s *CommentStore) DeleteItem(ctx context.Context, collection, itemID string) error {
+	query := `DELETE FROM tugo_comments WHERE collection = ? AND item_id = ?`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), collection, itemID); err != nil {
+		return fmt.Errorf("failed to delete comments: %w", err)
+	}
+	return nil
+}
+
+// decode unmarshals the stored mentions.
+func (c *Comment) decode() error {
+	c.Mentions = []string{}
+	if len(c.RawMentions) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(c.RawMentions, &c.Mentions); err != nil {
+		return fmt.Errorf("failed to decode comment '%s': %w", c.ID, err)
+	}
+	return nil
+}
+
+// ListComments returns the comments on an item as threads, oldest first.
+func (s *Service) ListComments(ctx context.Context, params ItemParams) ([]*Comment, error) {
+	collection, err := s.commentTarget(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+
+	comments, err := s.comments.List(ctx, collection.Name, params.ItemID, MaxComments)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return threadComments(comments), nil
+}
+
+// AddComment posts a comment on an item as the request's user, replying
+// to parentID when it is set.
+func (s *Service) AddComment(ctx context.Context, params ItemParams, body string, parentID *string) (*Comment, error) {
+	user, ok := auth.GetUserFromContext(ctx)
+	if !ok || user == nil {
+		return nil, apperror.ErrUnauthorized.WithMessage("Authentication required to comment")
+	}
+
+	body = strings.TrimSpace(body)
+	if body == "" {
+		return nil, apperror.ErrBadRequest.WithMessage("Comment body is required")
+	}
+	if utf8.RuneCountInString(body) > MaxCommentLength {
+		return nil, apperror.ErrBadRequest.WithMessagef("Comments are limited to %d characters", MaxCommentLength)
+	}
+
+	collection, err := s.commentTarget(ctx, params)
+	if err != nil {
+		return nil, err
+	}
+	var parent *Comment
+	if parentID != nil {
+		if _, err := uuid.Parse(*parentID); err != nil {
+			return nil, apperror.ErrBadRequest.WithMessagef("Invalid parent_id '%s'", *parentID)
+		}
+		parent, err = s.comments.Get(ctx, *parentID)
+		if err != nil && !apperror.IsAppError(err) {
+			return nil, apperror.ErrInternalServer.WithError(err)
+		}
+		if err != nil || parent.Collection != collection.Name || parent.ItemID != params.ItemID {
+			return nil, apperror.ErrBadRequest.WithMessagef("Comment '%s' is not on this item", *parentID)
+		}
+	}
+
+	comment := &Comment{
+		Collection: collection.Name,
+		ItemID:     params.ItemID,
+		ParentID:   parentID,
+		UserID:     &user.ID,
+		Body:       body,
+		Mentions:   parseMentions(body),
+	}
+	if err := s.comments.Create(ctx, comment); err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	s.notifyComment(ctx, comment, parent)
+	return comment, nil
+}
+
+// DeleteComment removes a comment with its replies. Only its author and
+// admins may delete it.
+func (s *Service) DeleteComment(ctx context.Context, params ItemParams, id string) error {
+	collection, err := s.commentTarget(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	if _, err := uuid.Parse(id); err != nil {
+		return apperror.ErrNotFound.WithMessagef("Comment '%s' not found", id)
+	}
+	target, err := s.comments.Get(ctx, id)
+	if err != nil && !apperror.IsAppError(err) {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	if err != nil || target.Collection != collection.Name || target.ItemID != params.ItemID {
+		return apperror.ErrNotFound.WithMessagef("Comment '%s' not found", id)
+	}
+
+	user, ok := auth.GetUserFromContext(ctx)
+	if !ok || user == nil {
+		return apperror.ErrUnauthorized.WithMessage("Authentication required to delete comments")
+	}
+	if user.Role != "admin" && (target.UserID == nil || *target.UserID != user.ID) {
+		return apperror.ErrForbidden.WithMessage("Only the author can delete a comment")
+	}
+
+	if err := s.comments.DeleteThread(ctx, id); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// commentTarget returns the collection of an item taking comments,
+// checking the item exists and is readable.
+func (s *Service) commentTarget(ctx context.Context, params ItemParams) (*schema.Collection, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if s.comments == nil || !collection.Comments {
+		return nil, apperror.ErrNotFound.WithMessagef("Comments are not enabled for collection '%s'", params.CollectionName)
+	}
+
+	if err := s.checkReadable(ctx, collection, params); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// deleteComments removes the comments on a deleted item.
+func (s *Service) deleteComments(ctx context.Context, collection *schema.Collection, id any) {
+	if s.comments == nil || !collection.Comments {
+		return
+	}
+	if err := s.comments.DeleteItem(ctx, collection.Name, fmt.Sprint(id)); err != nil {
+		requestlog.Logger(ctx, s.logger).Warnw("Failed to delete comments", "collection", collection.Name, "id", id, "error", err)
+	}
+}
+
+// threadComments nests replies under their parents, keeping the order of
+// comments. Replies whose parent is missing are listed at the top level.
+func threadComments(comments []*Comment) []*Comment {
+	byID := make(map[string]*Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+
+	threads := make([]*Comment, 0)
+	for _, comment := range comments {
+		if comment.ParentID != nil {
+			if parent, ok := byID[*comment.ParentID]; ok {
+				parent.Replies = append(parent.Replies, comment)
+				continue
+			}
+		}
+		threads = append(threads, comment)
+	}
+	return threads
+}
+
+// parseMentions returns the usernames mentioned in a comment body, once
+// each, in order of appearance.
+func parseMentions(body string) []string {
+	mentions := make([]string, 0)
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(body, -1) {
+		name := strings.TrimRight(match[1], ".-")
+		if name != "" && !seen[name] {
+			seen[name] = true
+			mentions = append(mentions, name)
+		}
+	}
+	return mentions
+}
+
+// CommentRequest is the body of POST /:collection/:id/comments.
+type CommentRequest struct {
+	Body     string  `json:"body" binding:"required"`
+	ParentID *string `json:"parent_id"`
+}
+
+// ListComments handles GET /:collection/:id/comments requests.
+func (h *Handler) ListComments(c *gin.Context) {
+	comments, err := h.service.ListComments(c.Request.Context(), itemParams(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(comments))
+}
+
+// AddComment handles POST /:collection/:id/comments requests.
+// Bodies take the form {"body": "Looks good @alice", "parent_id": "..."}.
+func (h *Handler) AddComment(c *gin.Context) {
+	var req CommentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	comment, err := h.service.AddComment(c.Request.Context(), itemParams(c), req.Body, req.ParentID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusCreated, response.Success(comment))
+}
+
+// DeleteComment handles DELETE /:collection/:id/comments/:comment_id
+// requests.
+func (h *Handler) DeleteComment(c *gin.Context) {
+	if err := h.service.DeleteComment(c.Request.Context(), itemParams(c), c.Param("comment_id")); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(nil))
+}
@@ -0,0 +1,57 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseMentions(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want []string
+	}{
+		{"none", "Looks good", []string{}},
+		{"one", "@alice please check", []string{"alice"}},
+		{"several in order", "cc @bob and @alice", []string{"bob", "alice"}},
+		{"repeated", "@bob @bob", []string{"bob"}},
+		{"trailing punctuation", "Thanks @carol.", []string{"carol"}},
+		{"dotted name", "ask @jane.doe today", []string{"jane.doe"}},
+		{"email address", "mail bob@example.com", []string{}},
+		{"after parenthesis", "(@dave)", []string{"dave"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseMentions(tt.body); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseMentions() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestThreadComments(t *testing.T) {
+	ref := func(id string) *string { return &id }
+	comments := []*Comment{
+		{ID: "a"},
+		{ID: "b"},
+		{ID: "c", ParentID: ref("a")},
+		{ID: "d", ParentID: ref("c")},
+		{ID: "e", ParentID: ref("gone")},
+	}
+
+	threads := threadComments(comments)
+	var top []string
+	for _, c := range threads {
+		top = append(top, c.ID)
+	}
+	if want := []string{"a", "b", "e"}; !reflect.DeepEqual(top, want) {
+		t.Fatalf("top level = %v, want %v", top, want)
+	}
+	if len(threads[0].Replies) != 1 || threads[0].Replies[0].ID != "c" {
+		t.Fatalf("replies of a = %v", threads[0].Replies)
+	}
+	if len(threads[0].Replies[0].Replies) != 1 || threads[0].Replies[0].Replies[0].ID != "d" {
+		t.Fatalf("replies of c = %v", threads[0].Replies[0].Replies)
+	}
+}
@@ -0,0 +1,152 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Constraint types of a ConstraintViolation.
+const (
+	ConstraintUnique     = "unique"
+	ConstraintExclusion  = "exclusion"
+	ConstraintForeignKey = "foreign_key"
+	ConstraintCheck      = "check"
+	ConstraintNotNull    = "not_null"
+)
+
+// ConstraintViolation details a write that breaks a constraint of the
+// table: which constraint, of which type, over which fields.
+type ConstraintViolation struct {
+	Constraint string   `json:"constraint,omitempty"`
+	Type       string   `json:"type"`
+	Fields     []string `json:"fields,omitempty"`
+
+	// Table is the other table of a foreign key: the referenced table when
+	// a write points at a missing record, the referencing table when
+	// Referenced is set.
+	Table      string `json:"table,omitempty"`
+	Referenced bool   `json:"referenced,omitempty"`
+}
+
+// Error describes the violation without the values involved.
+func (v ConstraintViolation) Error() string {
+	fields := strings.Join(v.Fields, ", ")
+	switch {
+	case v.Type == ConstraintUnique && len(v.Fields) > 1:
+		return fmt.Sprintf("Record with this combination of %s already exists", fields)
+	case v.Type == ConstraintUnique && len(v.Fields) == 1:
+		return fmt.Sprintf("Record with this %s already exists", fields)
+	case v.Type == ConstraintUnique:
+		return "Record with this value already exists"
+	case v.Type == ConstraintExclusion:
+		return fmt.Sprintf("Record conflicts with an existing record under constraint '%s'", v.Constraint)
+	case v.Type == ConstraintForeignKey && v.Referenced:
+		return fmt.Sprintf("Record is still referenced from %s", v.Table)
+	case v.Type == ConstraintForeignKey && fields != "":
+		return fmt.Sprintf("%s references a record that does not exist in %s", fields, v.Table)
+	case v.Type == ConstraintForeignKey:
+		return "Record references a record that does not exist"
+	case v.Type == ConstraintCheck:
+		return fmt.Sprintf("Record violates check constraint '%s'", v.Constraint)
+	case v.Type == ConstraintNotNull && fields != "":
+		return fmt.Sprintf("%s is required", fields)
+	default:
+		return "Record violates a constraint"
+	}
+}
+
+// appError returns the AppError reporting the violation: a conflict with
+// other records fails with apperror.ErrConflict, invalid data with
+// apperror.ErrBadRequest.
+func (v ConstraintViolation) appError() *apperror.AppError {
+	base := apperror.ErrBadRequest
+	if v.Type == ConstraintUnique || v.Type == ConstraintExclusion || v.Referenced {
+		base = apperror.ErrConflict
+	}
+	return base.WithMessage(v.Error()).WithDetails(v)
+}
+
+// constraintTypes maps the SQLSTATE codes of integrity constraint
+// violations to constraint types.
+var constraintTypes = map[string]string{
+	"23505": ConstraintUnique,
+	"23P01": ConstraintExclusion,
+	"23503": ConstraintForeignKey,
+	"23514": ConstraintCheck,
+	"23502": ConstraintNotNull,
+}
+
+// keyDetailRegex matches the detail of unique and foreign key violations,
+// such as `Key (team_id, user_id)=(1, 2) already exists.` or
+// `Key (customer_id)=(9) is not present in table "customers".`
+var keyDetailRegex = regexp.MustCompile(`^Key \((.+?)\)=\(.*\) (?:already exists|is not present in table "([^"]+)"|is (still) referenced from table "([^"]+)")\.?$`)
+
+// identifierRegex matches the plain column names of a key detail.
+var identifierRegex = regexp.MustCompile(`^"?([a-zA-Z_][a-zA-Z0-9_]*)"?$`)
+
+// constraintViolation parses a PostgreSQL integrity constraint violation
+// reported through pq or pgx. It reports false for other errors.
+func constraintViolation(err error) (ConstraintViolation, bool) {
+	var code, constraint, column, detail string
+	var pqErr *pq.Error
+	var pgErr *pgconn.PgError
+	switch {
+	case errors.As(err, &pqErr):
+		code, constraint, column, detail = string(pqErr.Code), pqErr.Constraint, pqErr.Column, pqErr.Detail
+	case errors.As(err, &pgErr):
+		code, constraint, column, detail = pgErr.Code, pgErr.ConstraintName, pgErr.ColumnName, pgErr.Detail
+	default:
+		return ConstraintViolation{}, false
+	}
+
+	kind, ok := constraintTypes[code]
+	if !ok {
+		return ConstraintViolation{}, false
+	}
+	v := ConstraintViolation{Constraint: constraint, Type: kind}
+	if column != "" {
+		v.Fields = []string{column}
+	}
+	if m := keyDetailRegex.FindStringSubmatch(detail); m != nil {
+		v.Fields = keyColumns(m[1])
+		switch {
+		case m[2] != "":
+			v.Table = m[2]
+		case m[3] != "":
+			v.Table, v.Referenced = m[4], true
+		}
+	}
+	return v, true
+}
+
+// keyColumns splits the column list of a key detail, or returns nil when it
+// holds an expression rather than plain columns.
+func keyColumns(list string) []string {
+	parts := strings.Split(list, ", ")
+	columns := make([]string, len(parts))
+	for i, part := range parts {
+		m := identifierRegex.FindStringSubmatch(part)
+		if m == nil {
+			return nil
+		}
+		columns[i] = m[1]
+	}
+	return columns
+}
+
+// duplicateError maps a failed write to an AppError. Duplicate keys the
+// database reports without details fail with apperror.ErrConflict and
+// message; other errors are mapped by dbError.
+func duplicateError(ctx context.Context, err error, message string) *apperror.AppError {
+	if _, ok := constraintViolation(err); !ok && isDuplicateKeyError(err) {
+		return apperror.ErrConflict.WithMessage(message)
+	}
+	return dbError(ctx, err)
+}
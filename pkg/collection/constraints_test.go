@@ -0,0 +1,139 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+func TestConstraintViolation(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		want    ConstraintViolation
+		wantOK  bool
+		status  int
+		message string
+	}{
+		{
+			name: "unique over two columns",
+			err: &pq.Error{Code: "23505", Constraint: "members_team_id_user_id_key",
+				Detail: "Key (team_id, user_id)=(1, 2) already exists."},
+			want:    ConstraintViolation{Constraint: "members_team_id_user_id_key", Type: ConstraintUnique, Fields: []string{"team_id", "user_id"}},
+			wantOK:  true,
+			status:  http.StatusConflict,
+			message: "Record with this combination of team_id, user_id already exists",
+		},
+		{
+			name: "unique through pgx",
+			err: fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505", ConstraintName: "users_email_key",
+				Detail: `Key ("email")=(a@example.com) already exists.`}),
+			want:    ConstraintViolation{Constraint: "users_email_key", Type: ConstraintUnique, Fields: []string{"email"}},
+			wantOK:  true,
+			status:  http.StatusConflict,
+			message: "Record with this email already exists",
+		},
+		{
+			name: "unique expression index",
+			err: &pq.Error{Code: "23505", Constraint: "users_lower_email_idx",
+				Detail: "Key (lower(email::text))=(a@example.com) already exists."},
+			want:    ConstraintViolation{Constraint: "users_lower_email_idx", Type: ConstraintUnique},
+			wantOK:  true,
+			status:  http.StatusConflict,
+			message: "Record with this value already exists",
+		},
+		{
+			name: "missing referenced record",
+			err: &pq.Error{Code: "23503", Constraint: "orders_customer_id_fkey",
+				Detail: `Key (customer_id)=(99) is not present in table "customers".`},
+			want:    ConstraintViolation{Constraint: "orders_customer_id_fkey", Type: ConstraintForeignKey, Fields: []string{"customer_id"}, Table: "customers"},
+			wantOK:  true,
+			status:  http.StatusBadRequest,
+			message: "customer_id references a record that does not exist in customers",
+		},
+		{
+			name: "still referenced",
+			err: &pgconn.PgError{Code: "23503", ConstraintName: "orders_customer_id_fkey",
+				Detail: `Key (id)=(1) is still referenced from table "orders".`},
+			want:    ConstraintViolation{Constraint: "orders_customer_id_fkey", Type: ConstraintForeignKey, Fields: []string{"id"}, Table: "orders", Referenced: true},
+			wantOK:  true,
+			status:  http.StatusConflict,
+			message: "Record is still referenced from orders",
+		},
+		{
+			name:    "check",
+			err:     &pq.Error{Code: "23514", Constraint: "products_price_check", Detail: "Failing row contains (1, -5)."},
+			want:    ConstraintViolation{Constraint: "products_price_check", Type: ConstraintCheck},
+			wantOK:  true,
+			status:  http.StatusBadRequest,
+			message: "Record violates check constraint 'products_price_check'",
+		},
+		{
+			name:    "not null",
+			err:     &pgconn.PgError{Code: "23502", ColumnName: "name", Detail: "Failing row contains (1, null)."},
+			want:    ConstraintViolation{Type: ConstraintNotNull, Fields: []string{"name"}},
+			wantOK:  true,
+			status:  http.StatusBadRequest,
+			message: "name is required",
+		},
+		{
+			name:    "exclusion",
+			err:     &pq.Error{Code: "23P01", Constraint: "bookings_room_during_excl"},
+			want:    ConstraintViolation{Constraint: "bookings_room_during_excl", Type: ConstraintExclusion},
+			wantOK:  true,
+			status:  http.StatusConflict,
+			message: "Record conflicts with an existing record under constraint 'bookings_room_during_excl'",
+		},
+		{name: "other postgres error", err: &pq.Error{Code: "42P01"}},
+		{name: "mysql duplicate", err: errors.New("Error 1062: Duplicate entry 'a' for key 'email'")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := constraintViolation(tt.err)
+			if ok != tt.wantOK {
+				t.Fatalf("constraintViolation() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("constraintViolation() = %+v, want %+v", got, tt.want)
+			}
+			appErr := dbError(context.Background(), tt.err)
+			if appErr.HTTPStatus != tt.status || appErr.Message != tt.message {
+				t.Errorf("dbError() = %d %q, want %d %q", appErr.HTTPStatus, appErr.Message, tt.status, tt.message)
+			}
+		})
+	}
+}
+
+func TestDuplicateError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    string
+		message string
+	}{
+		{"postgres details", &pq.Error{Code: "23505", Detail: "Key (sku)=(A1) already exists."}, apperror.ErrConflict.Code, "Record with this sku already exists"},
+		{"mysql duplicate", errors.New("Error 1062: Duplicate entry 'A1' for key 'sku'"), apperror.ErrConflict.Code, "Record already exists"},
+		{"sqlite duplicate", errors.New("UNIQUE constraint failed: products.sku"), apperror.ErrConflict.Code, "Record already exists"},
+		{"other error", errors.New("syntax error"), apperror.ErrInternalServer.Code, apperror.ErrInternalServer.Message},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := duplicateError(context.Background(), tt.err, "Record already exists")
+			if got.Code != tt.code || got.Message != tt.message {
+				t.Errorf("duplicateError() = %s %q, want %s %q", got.Code, got.Message, tt.code, tt.message)
+			}
+		})
+	}
+}
@@ -0,0 +1,115 @@
+package collection
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// copyRows loads rows into a table with PostgreSQL COPY on the connection
+// holding tx. Values are sent as text for the server to parse, like the
+// parameters of an INSERT. It reports false when the driver has no COPY
+// support, leaving the rows for the caller to insert.
+func (r *Repository) copyRows(ctx context.Context, conn *sqlx.Conn, tx *sqlx.Tx, table string, columns []string, rows [][]any) (bool, error) {
+	switch r.db.DriverName() {
+	case "postgres":
+		stmt, err := tx.PrepareContext(ctx, pq.CopyIn(table, columns...))
+		if err != nil {
+			return true, err
+		}
+		defer stmt.Close()
+
+		values := make([]any, len(columns))
+		for _, row := range rows {
+			for i, v := range row {
+				values[i] = copyArg(v)
+			}
+			if _, err := stmt.ExecContext(ctx, values...); err != nil {
+				return true, err
+			}
+		}
+		_, err = stmt.ExecContext(ctx)
+		return true, err
+
+	case "pgx":
+		quoted := make([]string, len(columns))
+		for i, col := range columns {
+			quoted[i] = r.dialect.QuoteIdent(col)
+		}
+		statement := fmt.Sprintf("COPY %s (%s) FROM STDIN WITH (FORMAT csv)", r.dialect.QuoteIdent(table), strings.Join(quoted, ", "))
+		data := copyCSV(rows)
+
+		supported := true
+		err := conn.Raw(func(driverConn any) error {
+			c, ok := driverConn.(*stdlib.Conn)
+			if !ok {
+				supported = false
+				return nil
+			}
+			_, err := c.Conn().PgConn().CopyFrom(ctx, bytes.NewReader(data), statement)
+			return err
+		})
+		return supported, err
+	}
+	return false, nil
+}
+
+// copyCSV encodes rows as CSV for COPY. Every value is quoted, so empty
+// strings stay distinct from NULL, which is written as an empty field.
+func copyCSV(rows [][]any) []byte {
+	var buf bytes.Buffer
+	for _, row := range rows {
+		for i, v := range row {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			text, ok := copyArg(v).(string)
+			if !ok {
+				continue
+			}
+			buf.WriteByte('"')
+			buf.WriteString(strings.ReplaceAll(text, `"`, `""`))
+			buf.WriteByte('"')
+		}
+		buf.WriteByte('\n')
+	}
+	return buf.Bytes()
+}
+
+// copyArg converts a decoded JSON value to its text representation, or nil for NULL.
+// Binary values use the bytea hex format.
+func copyArg(v any) any {
+	switch val := v.(type) {
+	case nil:
+		return nil
+	case string:
+		return val
+	case []byte:
+		return `\x` + hex.EncodeToString(val)
+	case json.Number:
+		return val.String()
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64)
+	case bool:
+		return strconv.FormatBool(val)
+	case time.Time:
+		return val.Format(time.RFC3339Nano)
+	case map[string]any, []any:
+		encoded, err := json.Marshal(val)
+		if err != nil {
+			return fmt.Sprint(val)
+		}
+		return string(encoded)
+	default:
+		return fmt.Sprint(val)
+	}
+}
@@ -0,0 +1,40 @@
+package collection
+
+import "testing"
+
+func TestCopyCSV(t *testing.T) {
+	tests := []struct {
+		name string
+		rows [][]any
+		want string
+	}{
+		{
+			name: "scalars",
+			rows: [][]any{{"a", 1.5, true}, {"b", float64(2), false}},
+			want: "\"a\",\"1.5\",\"true\"\n\"b\",\"2\",\"false\"\n",
+		},
+		{
+			name: "null and empty string",
+			rows: [][]any{{nil, ""}},
+			want: ",\"\"\n",
+		},
+		{
+			name: "quotes and newlines",
+			rows: [][]any{{"say \"hi\"\nbye"}},
+			want: "\"say \"\"hi\"\"\nbye\"\n",
+		},
+		{
+			name: "json values",
+			rows: [][]any{{map[string]any{"a": 1.0}, []any{"x"}}},
+			want: "\"{\"\"a\"\":1}\",\"[\"\"x\"\"]\"\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := string(copyCSV(tt.rows)); got != tt.want {
+				t.Errorf("copyCSV() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+package collection
+
+import (
+	"context"
+	"time"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// explainTimeout bounds the EXPLAIN run for a slow query log entry.
+const explainTimeout = 5 * time.Second
+
+// SetSlowQueryThreshold enables logging of list queries slower than threshold,
+// with their EXPLAIN plans. Zero disables it.
+func (s *Service) SetSlowQueryThreshold(threshold time.Duration) {
+	s.slowQueryThreshold = threshold
+}
+
+// checkCost rejects requests exceeding the collection's expensive query limits.
+func checkCost(collection *schema.Collection, opts query.Options) error {
+	err := query.NewOptionsValidator(nil).WithCostLimits(query.CostLimits{
+		MaxOffset:    collection.MaxOffset,
+		MaxExpand:    collection.MaxExpand,
+		SearchFields: collection.SearchFields,
+	}).ValidateCost(opts)
+	if err != nil {
+		return apperror.ErrBadRequest.WithMessage(err.Error())
+	}
+	return nil
+}
+
+// checkExpand rejects expanding more relations than the collection allows.
+func checkExpand(collection *schema.Collection, expand []string) error {
+	return checkCost(collection, query.Options{Expand: expand})
+}
+
+// observeList logs a list query that ran longer than the slow query threshold.
+// The plan is fetched in the background so the response is not delayed.
+func (s *Service) observeList(ctx context.Context, collection *schema.Collection, opts ListOptions, params map[string][]string, elapsed time.Duration) {
+	if s.slowQueryThreshold <= 0 || elapsed < s.slowQueryThreshold {
+		return
+	}
+
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		ctx, cancel := context.WithTimeout(ctx, explainTimeout)
+		defer cancel()
+
+		plans, err := s.repo.Explain(ctx, collection, opts)
+		if err != nil {
+			requestlog.Logger(ctx, s.logger).Warnw("Slow query", "collection", collection.Name, "duration_ms", elapsed.Milliseconds(),
+				"params", params, "explain_error", err)
+			return
+		}
+		requestlog.Logger(ctx, s.logger).Warnw("Slow query", "collection", collection.Name, "duration_ms", elapsed.Milliseconds(),
+			"params", params, "plans", plans)
+	}()
+}
@@ -0,0 +1,55 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// deadlineKey is the context key of a request's deadline budget.
+type deadlineKey struct{}
+
+// deadline bounds a request by its collection's RequestTimeout. Queries
+// still running when it passes are canceled, and the request fails with
+// 504 REQUEST_TIMEOUT.
+func (h *Handler) deadline(c *gin.Context) {
+	collection, err := h.service.schemaManager.GetCollection(c.Param("collection"))
+	if err != nil || collection.RequestTimeout <= 0 {
+		c.Next()
+		return
+	}
+
+	timeout := collection.RequestTimeout
+	ctx, cancel := context.WithTimeout(c.Request.Context(), timeout)
+	defer cancel()
+	c.Request = c.Request.WithContext(context.WithValue(ctx, deadlineKey{}, timeout))
+	c.Next()
+
+	if !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return
+	}
+	requestlog.Logger(ctx, h.logger).Warnw("Request timed out", "collection", collection.Name, "timeout", timeout)
+	if !c.Writer.Written() {
+		h.write(c, http.StatusGatewayTimeout, response.FromAppError(timeoutError(ctx)))
+	}
+}
+
+// timeoutError returns the error of a request whose deadline passed.
+func timeoutError(ctx context.Context) *apperror.AppError {
+	timeout, _ := ctx.Value(deadlineKey{}).(time.Duration)
+	return apperror.ErrRequestTimeout.WithDetails(map[string]any{
+		"timeout_ms": timeout.Milliseconds(),
+	})
+}
+
+// requestTimedOut reports whether the request's deadline budget passed.
+func requestTimedOut(ctx context.Context) bool {
+	_, ok := ctx.Value(deadlineKey{}).(time.Duration)
+	return ok && errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
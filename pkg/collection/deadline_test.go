@@ -0,0 +1,66 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"go.uber.org/zap"
+)
+
+func TestHandleErrorRequestTimeout(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{logger: zap.NewNop().Sugar()}
+
+	tests := []struct {
+		name       string
+		budget     time.Duration
+		err        error
+		wantStatus int
+		wantCode   string
+	}{
+		{"statement timeout", 0, apperror.ErrTimeout, http.StatusGatewayTimeout, apperror.CodeTimeout},
+		{"budget exceeded", time.Nanosecond, apperror.ErrTimeout, http.StatusGatewayTimeout, apperror.CodeRequestTimeout},
+		{"budget exceeded while canceled", time.Nanosecond, apperror.ErrRequestCanceled, http.StatusGatewayTimeout, apperror.CodeRequestTimeout},
+		{"within budget", time.Hour, apperror.ErrNotFound, http.StatusNotFound, apperror.CodeNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.GET("/", func(c *gin.Context) {
+				if tt.budget > 0 {
+					ctx, cancel := context.WithTimeout(c.Request.Context(), tt.budget)
+					defer cancel()
+					if tt.budget < time.Second {
+						<-ctx.Done()
+					}
+					c.Request = c.Request.WithContext(context.WithValue(ctx, deadlineKey{}, tt.budget))
+				}
+				h.handleError(c, tt.err)
+			})
+
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			var body struct {
+				Error struct {
+					Code string `json:"code"`
+				} `json:"error"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("invalid body %s: %v", rec.Body, err)
+			}
+			if body.Error.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Error.Code, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package collection
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/permission"
+)
+
+// DebugParam is the query parameter asking for debug information with a
+// list response. Only admins may set it.
+const DebugParam = "_debug"
+
+// DebugInfo explains how a list request was answered.
+type DebugInfo struct {
+	// Queries holds the count and select statements with their parameters
+	// and EXPLAIN plans.
+	Queries      []QueryPlan `json:"queries"`
+	ExplainError string      `json:"explain_error,omitempty"`
+
+	Timing     DebugTiming      `json:"timing"`
+	Permission *DebugPermission `json:"permission,omitempty"`
+}
+
+// DebugTiming breaks down the time spent on a list request in milliseconds.
+type DebugTiming struct {
+	ParseMS   float64 `json:"parse_ms"`
+	QueryMS   float64 `json:"query_ms"`
+	ExpandMS  float64 `json:"expand_ms"`
+	ExplainMS float64 `json:"explain_ms"`
+	TotalMS   float64 `json:"total_ms"`
+}
+
+// DebugPermission is the permission check applied to a request.
+type DebugPermission struct {
+	Role   string                      `json:"role"`
+	Filter map[string]any              `json:"filter"`
+	Fields permission.FieldPermissions `json:"fields"`
+}
+
+// milliseconds converts a duration to fractional milliseconds.
+func milliseconds(d time.Duration) float64 {
+	return float64(d.Microseconds()) / 1000
+}
+
+// debugRequested reports whether a request asks for debug information,
+// writing an error response when it may not have it.
+func (h *Handler) debugRequested(c *gin.Context) (debug, ok bool) {
+	raw := c.Query(DebugParam)
+	if raw == "" {
+		return false, true
+	}
+	debug, err := strconv.ParseBool(raw)
+	if err != nil {
+		h.handleError(c, apperror.ErrBadRequest.WithMessagef("Invalid %s value '%s'", DebugParam, raw))
+		return false, false
+	}
+	if debug {
+		if user := auth.GetUser(c); user == nil || user.Role != "admin" {
+			h.handleError(c, apperror.ErrForbidden.WithMessage("Debug mode requires the admin role"))
+			return false, false
+		}
+	}
+	return debug, true
+}
+
+// debugPermission returns the permission check of a request, if one ran.
+func debugPermission(c *gin.Context) *DebugPermission {
+	result := permission.GetCheckResult(c)
+	if result == nil {
+		return nil
+	}
+	debug := &DebugPermission{Filter: result.Filter, Fields: result.FieldPerms}
+	if user := auth.GetUser(c); user != nil {
+		debug.Role = user.Role
+	}
+	return debug
+}
@@ -0,0 +1,157 @@
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// SetDecimalNumbers writes decimal fields as JSON numbers with their exact
+// digits instead of strings.
+func (r *Repository) SetDecimalNumbers(enabled bool) {
+	r.decimalNumbers = enabled
+}
+
+// normalizeDecimal writes a scanned decimal value as its exact digits, as a
+// string or a json.Number.
+func normalizeDecimal(v any, asNumber bool) any {
+	var s string
+	switch val := v.(type) {
+	case []byte:
+		s = string(val)
+	case string:
+		s = val
+	case float64:
+		s = strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		s = strconv.FormatInt(val, 10)
+	default:
+		return normalizeValue(v)
+	}
+
+	// NaN and infinities have no JSON number form
+	if asNumber && json.Valid([]byte(s)) {
+		return json.Number(s)
+	}
+	return s
+}
+
+// normalizeNumbers prepares the numbers of decoded write data. Money fields
+// are converted to their stored form first. Decimal fields take numbers or
+// numeric strings, kept exact as json.Number and checked against the
+// column's precision and scale; other numbers decoded as json.Number become
+// float64.
+func normalizeNumbers(collection *schema.Collection, data map[string]any) *validation.ValidationErrors {
+	errs := &validation.ValidationErrors{}
+	normalizeMoney(collection, data, errs)
+	for _, f := range collection.Fields {
+		v, ok := data[f.Name]
+		if !ok || v == nil {
+			continue
+		}
+
+		if f.DataType != "decimal" {
+			if n, ok := v.(json.Number); ok {
+				data[f.Name], _ = n.Float64()
+			}
+			continue
+		}
+
+		value, err := decimalValue(f, v)
+		if err != nil {
+			errs.Add(f.Name, err.Error(), "invalid_decimal")
+			continue
+		}
+		data[f.Name] = value
+	}
+
+	if errs.HasErrors() {
+		return errs
+	}
+	return nil
+}
+
+// decimalValue parses a decimal field's value and checks it fits the
+// field's precision and scale.
+func decimalValue(f schema.Field, v any) (json.Number, error) {
+	var s string
+	switch val := v.(type) {
+	case json.Number:
+		s = val.String()
+	case string:
+		s = strings.TrimSpace(val)
+	case float64:
+		s = strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		s = strconv.FormatInt(val, 10)
+	case int:
+		s = strconv.Itoa(val)
+	default:
+		return "", fmt.Errorf("must be a decimal number")
+	}
+
+	r, ok := new(big.Rat).SetString(s)
+	if !ok || (!json.Valid([]byte(s)) && !isDecimalString(s)) {
+		return "", fmt.Errorf("must be a decimal number")
+	}
+
+	// Exponents are written out so the database receives plain digits
+	if strings.ContainsAny(s, "eE") {
+		s = r.FloatString(decimalPlaces(r))
+	}
+
+	if f.Scale != nil && decimalPlaces(r) > *f.Scale {
+		return "", fmt.Errorf("must have at most %d decimal places", *f.Scale)
+	}
+	if f.Precision != nil {
+		scale := 0
+		if f.Scale != nil {
+			scale = *f.Scale
+		}
+		intPart := new(big.Int).Quo(r.Num(), r.Denom())
+		digits := len(intPart.Abs(intPart).String())
+		if intPart.Sign() == 0 {
+			digits = 0
+		}
+		if digits > *f.Precision-scale {
+			return "", fmt.Errorf("must have at most %d digits before the decimal point", *f.Precision-scale)
+		}
+	}
+	return json.Number(s), nil
+}
+
+// decimalPlaces returns the number of decimal places needed to write r exactly.
+func decimalPlaces(r *big.Rat) int {
+	x := new(big.Rat).Set(r)
+	ten := big.NewRat(10, 1)
+	places := 0
+	for !x.IsInt() && places < 1000 {
+		x.Mul(x, ten)
+		places++
+	}
+	return places
+}
+
+// isDecimalString reports whether s is a plain decimal such as "+1.50" or
+// ".5", which are not JSON numbers but are accepted as strings.
+func isDecimalString(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	digits := 0
+	dot := false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
@@ -0,0 +1,76 @@
+package collection
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestNormalizeNumbers(t *testing.T) {
+	precision, scale := 6, 2
+	collection := &schema.Collection{Fields: []schema.Field{
+		{Name: "price", DataType: "decimal", Precision: &precision, Scale: &scale},
+		{Name: "amount", DataType: "decimal"},
+		{Name: "qty", DataType: "int"},
+	}}
+
+	tests := []struct {
+		name    string
+		field   string
+		value   any
+		want    any
+		wantErr bool
+	}{
+		{"exact number", "price", json.Number("1234.56"), json.Number("1234.56"), false},
+		{"string", "price", " 0.10 ", json.Number("0.10"), false},
+		{"negative", "price", "-9999.99", json.Number("-9999.99"), false},
+		{"float", "price", 12.5, json.Number("12.5"), false},
+		{"exponent", "amount", json.Number("1.5e3"), json.Number("1500"), false},
+		{"leading dot", "amount", ".5", json.Number(".5"), false},
+		{"unbounded", "amount", json.Number("12345678901234567890.123456789"), json.Number("12345678901234567890.123456789"), false},
+		{"too many places", "price", "1.234", nil, true},
+		{"too many digits", "price", json.Number("12345.6"), nil, true},
+		{"not a number", "price", "abc", nil, true},
+		{"fraction", "amount", "1/3", nil, true},
+		{"boolean", "amount", true, nil, true},
+		{"other field", "qty", json.Number("3"), 3.0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := map[string]any{tt.field: tt.value}
+			errs := normalizeNumbers(collection, data)
+			if (errs != nil) != tt.wantErr {
+				t.Fatalf("normalizeNumbers() error = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if !tt.wantErr && data[tt.field] != tt.want {
+				t.Errorf("normalizeNumbers() %s = %#v, want %#v", tt.field, data[tt.field], tt.want)
+			}
+		})
+	}
+}
+
+func TestNormalizeDecimal(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    any
+		asNumber bool
+		want     any
+	}{
+		{"bytes", []byte("10.50"), false, "10.50"},
+		{"bytes as number", []byte("10.50"), true, json.Number("10.50")},
+		{"float", 0.1, false, "0.1"},
+		{"integer", int64(7), true, json.Number("7")},
+		{"NaN stays a string", "NaN", true, "NaN"},
+		{"null", nil, true, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := normalizeDecimal(tt.value, tt.asNumber); got != tt.want {
+				t.Errorf("normalizeDecimal() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,355 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// DefaultDuplicateLimit is the number of possible duplicates returned when
+// a request sets no limit.
+const DefaultDuplicateLimit = 10
+
+// MaxDuplicateLimit caps the possible duplicates returned by one request.
+const MaxDuplicateLimit = 100
+
+// maxDuplicateCandidates bounds the rows compared with a candidate item.
+const maxDuplicateCandidates = 500
+
+// maxSimilarPatterns bounds the LIKE patterns used to preselect rows for a
+// similar match.
+const maxSimilarPatterns = 32
+
+// matchColumnPrefix names the columns reporting which rules a row matched.
+const matchColumnPrefix = "tugo_match_"
+
+// FindDuplicatesParams holds parameters for finding duplicates.
+type FindDuplicatesParams struct {
+	CollectionName string
+
+	// Candidate is the item about to be created. When it holds a primary
+	// key, that item is not reported as its own duplicate.
+	Candidate map[string]any
+
+	// Limit caps the duplicates returned; zero uses DefaultDuplicateLimit.
+	Limit int
+
+	// Scope is a row-level permission filter; hidden rows are not reported.
+	Scope map[string]any
+}
+
+// PossibleDuplicate is a stored item resembling a candidate item.
+type PossibleDuplicate struct {
+	Item map[string]any `json:"item"`
+
+	// Score is the mean of the rule scores: 1 for each exact or
+	// case-insensitive match and the similarity for similar matches.
+	Score float64 `json:"score"`
+
+	// Matched lists the fields that matched.
+	Matched []string `json:"matched"`
+}
+
+// duplicateRule is a match rule with the candidate's value for its field.
+type duplicateRule struct {
+	schema.DuplicateMatch
+	value    any
+	patterns []string
+}
+
+// FindDuplicates returns the stored items resembling a candidate item under
+// the collection's duplicate match rules, best first. Rules on fields the
+// candidate leaves empty are skipped.
+func (s *Service) FindDuplicates(ctx context.Context, params FindDuplicatesParams) ([]PossibleDuplicate, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if len(collection.DuplicateMatch) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no duplicate match rules", params.CollectionName)
+	}
+
+	limit := params.Limit
+	if limit == 0 {
+		limit = DefaultDuplicateLimit
+	}
+	if limit < 1 || limit > MaxDuplicateLimit {
+		return nil, apperror.ErrBadRequest.WithMessagef("Limit must be between 1 and %d", MaxDuplicateLimit)
+	}
+
+	rules, err := duplicateRules(collection, params.Candidate)
+	if err != nil {
+		return nil, err
+	}
+	if len(rules) == 0 {
+		return []PossibleDuplicate{}, nil
+	}
+
+	var exclude any
+	if id, ok := params.Candidate[collection.PrimaryKey]; ok && id != nil {
+		exclude = candidateString(id)
+	}
+	rows, err := s.repo.FindDuplicates(ctx, collection, rules, params.Scope, exclude)
+	if err != nil {
+		return nil, err
+	}
+
+	duplicates := make([]PossibleDuplicate, 0, len(rows))
+	for _, row := range rows {
+		if duplicate, ok := scoreDuplicate(row, rules); ok {
+			duplicates = append(duplicates, duplicate)
+		}
+	}
+	sort.SliceStable(duplicates, func(i, j int) bool { return duplicates[i].Score > duplicates[j].Score })
+	if len(duplicates) > limit {
+		duplicates = duplicates[:limit]
+	}
+	return duplicates, nil
+}
+
+// duplicateRules pairs the collection's match rules with the candidate's
+// values, checking exact values against their field types.
+func duplicateRules(collection *schema.Collection, candidate map[string]any) ([]duplicateRule, error) {
+	rules := make([]duplicateRule, 0, len(collection.DuplicateMatch))
+	for _, match := range collection.DuplicateMatch {
+		v, ok := candidate[match.Field]
+		if !ok || v == nil {
+			continue
+		}
+		switch v.(type) {
+		case map[string]any, []any:
+			return nil, apperror.ErrBadRequest.WithMessagef("Field '%s' must be a single value", match.Field)
+		}
+
+		value := candidateString(v)
+		if strings.TrimSpace(value) == "" {
+			continue
+		}
+		rule := duplicateRule{DuplicateMatch: match, value: value}
+		switch match.Mode {
+		case schema.MatchExact:
+			filters, err := coerceFilters(collection, []query.Filter{{Field: match.Field, Operator: query.OpEqual, Value: value}})
+			if err != nil {
+				return nil, apperror.ErrBadRequest.WithMessagef("Invalid value for field '%s'", match.Field)
+			}
+			rule.value = filters[0].Value
+		case schema.MatchSimilar:
+			if rule.patterns = similarPatterns(value); len(rule.patterns) == 0 {
+				continue
+			}
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// candidateString returns the string form of a candidate value.
+func candidateString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case json.Number:
+		return val.String()
+	default:
+		return formatID(v)
+	}
+}
+
+// scoreDuplicate scores a row against the rules, reporting false when it
+// matches none of them.
+func scoreDuplicate(row map[string]any, rules []duplicateRule) (PossibleDuplicate, bool) {
+	duplicate := PossibleDuplicate{Matched: []string{}}
+	total := 0.0
+	for i, rule := range rules {
+		column := fmt.Sprintf("%s%d", matchColumnPrefix, i)
+		flag, _ := positionValue(normalizeValue(row[column]))
+		delete(row, column)
+		if flag != 1 {
+			continue
+		}
+
+		score := 1.0
+		if rule.Mode == schema.MatchSimilar {
+			stored, _ := normalizeValue(row[rule.Field]).(string)
+			if score = trigramSimilarity(rule.value.(string), stored); score < rule.Threshold {
+				continue
+			}
+		}
+		total += score
+		duplicate.Matched = append(duplicate.Matched, rule.Field)
+	}
+	if len(duplicate.Matched) == 0 {
+		return duplicate, false
+	}
+
+	duplicate.Item = row
+	duplicate.Score = math.Round(total/float64(len(rules))*1000) / 1000
+	return duplicate, true
+}
+
+// trigrams returns the trigrams of a string as pg_trgm builds them: each
+// lower-cased word of letters and digits is padded with two spaces in front
+// and one behind and cut into every run of three characters.
+func trigrams(s string) map[string]bool {
+	result := make(map[string]bool)
+	for _, word := range trigramWords(s) {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			result[string(padded[i:i+3])] = true
+		}
+	}
+	return result
+}
+
+// trigramWords splits a string into its lower-cased words of letters and digits.
+func trigramWords(s string) []string {
+	return strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// trigramSimilarity returns the share of trigrams two strings have in
+// common, from 0 to 1, as pg_trgm's similarity does.
+func trigramSimilarity(a, b string) float64 {
+	ta, tb := trigrams(a), trigrams(b)
+	if len(ta) == 0 || len(tb) == 0 {
+		return 0
+	}
+	shared := 0
+	for t := range ta {
+		if tb[t] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(ta)+len(tb)-shared)
+}
+
+// similarPatterns returns LIKE patterns preselecting rows that may be
+// similar to a value: the inner trigrams of its words, or words too short
+// to have any.
+func similarPatterns(value string) []string {
+	seen := make(map[string]bool)
+	patterns := make([]string, 0)
+	for _, word := range trigramWords(value) {
+		runes := []rune(word)
+		parts := []string{word}
+		if len(runes) > 3 {
+			parts = parts[:0]
+			for i := 0; i+3 <= len(runes); i++ {
+				parts = append(parts, string(runes[i:i+3]))
+			}
+		}
+		for _, part := range parts {
+			if !seen[part] && len(patterns) < maxSimilarPatterns {
+				seen[part] = true
+				patterns = append(patterns, "%"+part+"%")
+			}
+		}
+	}
+	return patterns
+}
+
+// FindDuplicates returns the rows matching any of the rules, with a
+// tugo_match_<n> column set to 1 for each rule n a row matches. Similar
+// rules match rows sharing part of the value, to be scored by the caller.
+// Rows outside the scope and the row whose primary key is exclude are left
+// out.
+func (r *Repository) FindDuplicates(ctx context.Context, collection *schema.Collection, rules []duplicateRule, scope map[string]any, exclude any) ([]map[string]any, error) {
+	quote := r.dialect.QuoteIdent
+	n := 0
+	bind := func() string {
+		n++
+		return r.dialect.Placeholder(n)
+	}
+
+	// Placeholders are numbered in the order they appear in the statement
+	condition := func(rule duplicateRule, args *[]any) string {
+		column := quote(rule.Field)
+		switch rule.Mode {
+		case schema.MatchCaseInsensitive:
+			*args = append(*args, rule.value)
+			return fmt.Sprintf("LOWER(%s) = LOWER(%s)", column, bind())
+		case schema.MatchSimilar:
+			likes := make([]string, len(rule.patterns))
+			for i, pattern := range rule.patterns {
+				*args = append(*args, pattern)
+				likes[i] = fmt.Sprintf("%s %s %s", column, r.dialect.LikeOperator(), bind())
+			}
+			return "(" + strings.Join(likes, " OR ") + ")"
+		default:
+			*args = append(*args, rule.value)
+			return fmt.Sprintf("%s = %s", column, bind())
+		}
+	}
+
+	args := make([]any, 0)
+	flags := make([]string, len(rules))
+	for i, rule := range rules {
+		flags[i] = fmt.Sprintf("CASE WHEN %s THEN 1 ELSE 0 END AS %s", condition(rule, &args), quote(fmt.Sprintf("%s%d", matchColumnPrefix, i)))
+	}
+
+	where := make([]string, 0, 3)
+	scopeSQL, scopeArgs := permission.NewFilterBuilder(n).WithDialect(r.dialect).Build(scope)
+	if scopeSQL != "" {
+		where = append(where, "("+scopeSQL+")")
+		args = append(args, scopeArgs...)
+		n += len(scopeArgs)
+	}
+	conditions := make([]string, len(rules))
+	for i, rule := range rules {
+		conditions[i] = condition(rule, &args)
+	}
+	where = append(where, "("+strings.Join(conditions, " OR ")+")")
+	if exclude != nil {
+		where = append(where, fmt.Sprintf("%s <> %s", quote(collection.PrimaryKey), bind()))
+		args = append(args, exclude)
+	}
+
+	querySQL := fmt.Sprintf("SELECT *, %s FROM %s WHERE %s LIMIT %d",
+		strings.Join(flags, ", "), quote(collection.TableName), strings.Join(where, " AND "), maxDuplicateCandidates)
+
+	var items []map[string]any
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		items = make([]map[string]any, 0)
+		rows, err := q.QueryxContext(ctx, querySQL, args...)
+		if err != nil {
+			if isInvalidUUIDError(err) {
+				return apperror.ErrBadRequest.WithMessage("Invalid ID format")
+			}
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		scanner.setFields(collection, r.encoder(ctx))
+		for rows.Next() {
+			item, err := scanner.scanMap()
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			items = append(items, item)
+		}
+
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,44 @@
+package collection
+
+import (
+	"math"
+	"reflect"
+	"testing"
+)
+
+func TestTrigramSimilarity(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"word", "two words", 0.363636},
+		{"Jon Smith", "jon smith", 1},
+		{"abc", "xyz", 0},
+		{"", "abc", 0},
+		{"--", "--", 0},
+	}
+
+	for _, tt := range tests {
+		got := trigramSimilarity(tt.a, tt.b)
+		if math.Abs(got-tt.want) > 1e-6 {
+			t.Errorf("trigramSimilarity(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestSimilarPatterns(t *testing.T) {
+	tests := []struct {
+		value string
+		want  []string
+	}{
+		{"Jon Smith", []string{"%jon%", "%smi%", "%mit%", "%ith%"}},
+		{"a_b", []string{"%a%", "%b%"}},
+		{"%%", []string{}},
+	}
+
+	for _, tt := range tests {
+		if got := similarPatterns(tt.value); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("similarPatterns(%q) = %v, want %v", tt.value, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+package collection
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// applyDefaults merges a collection's default filter and sort into list
+// parameters. A filter on a field replaces the defaults of that field, and
+// any sort replaces the default sort.
+func applyDefaults(collection *schema.Collection, params ListParams) ListParams {
+	if len(collection.DefaultFilter) == 0 && collection.DefaultSort == "" {
+		return params
+	}
+
+	filtered := make(map[string]bool)
+	merged := make(map[string][]string, len(params.QueryParams)+len(collection.DefaultFilter)+1)
+	for key, values := range params.QueryParams {
+		if field, ok := query.FilterKeyField(key); ok {
+			filtered[field] = true
+		}
+		merged[key] = values
+	}
+	for key, value := range collection.DefaultFilter {
+		field, _, _ := strings.Cut(key, ":")
+		if !filtered[field] {
+			merged["filter["+key+"]"] = []string{value}
+		}
+	}
+	if sorts := merged["sort"]; collection.DefaultSort != "" && (len(sorts) == 0 || sorts[0] == "") {
+		merged["sort"] = []string{collection.DefaultSort}
+	}
+	params.QueryParams = merged
+	return params
+}
+
+// CheckDefaults checks that the default filter and sort of every
+// collection of manager fit its schema.
+func CheckDefaults(manager *schema.Manager) error {
+	for _, collection := range manager.GetCollections() {
+		q := ViewQuery{Filter: collection.DefaultFilter, Sort: collection.DefaultSort}
+		if err := validateView(manager, collection, q); err != nil {
+			return fmt.Errorf("collection %s: %w", collection.Name, err)
+		}
+	}
+	return nil
+}
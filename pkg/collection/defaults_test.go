@@ -0,0 +1,64 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestApplyDefaults(t *testing.T) {
+	posts := &schema.Collection{
+		Name:          "posts",
+		DefaultFilter: map[string]string{"status:ne": "archived", "deleted": "false"},
+		DefaultSort:   "-created_at",
+	}
+
+	tests := []struct {
+		name       string
+		collection *schema.Collection
+		params     map[string][]string
+		want       map[string][]string
+	}{
+		{
+			name:       "no defaults",
+			collection: &schema.Collection{Name: "posts"},
+			params:     map[string][]string{"page": {"2"}},
+			want:       map[string][]string{"page": {"2"}},
+		},
+		{
+			name:       "defaults applied",
+			collection: posts,
+			params:     map[string][]string{"page": {"2"}},
+			want: map[string][]string{
+				"page": {"2"}, "sort": {"-created_at"},
+				"filter[status:ne]": {"archived"}, "filter[deleted]": {"false"},
+			},
+		},
+		{
+			name:       "field filtered with another operator",
+			collection: posts,
+			params:     map[string][]string{"filter[status]": {"archived"}},
+			want: map[string][]string{
+				"filter[status]": {"archived"}, "filter[deleted]": {"false"}, "sort": {"-created_at"},
+			},
+		},
+		{
+			name:       "sort given",
+			collection: posts,
+			params:     map[string][]string{"sort": {"title"}, "filter[deleted]": {"true"}},
+			want: map[string][]string{
+				"sort": {"title"}, "filter[deleted]": {"true"}, "filter[status:ne]": {"archived"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := applyDefaults(tt.collection, ListParams{QueryParams: tt.params})
+			if !reflect.DeepEqual(got.QueryParams, tt.want) {
+				t.Errorf("applyDefaults() = %v, want %v", got.QueryParams, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,377 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Unique column handling for duplicates.
+const (
+	DuplicateUniqueSuffix = "suffix"
+	DuplicateUniqueNull   = "null"
+)
+
+// DefaultDuplicateSuffix is appended to unique string columns of a duplicate.
+const DefaultDuplicateSuffix = " (copy)"
+
+// maxDuplicateChildren bounds the records deep-copied per child collection.
+const maxDuplicateChildren = 1000
+
+// autoTimestampFields are timestamp columns reset on duplicates even without a column default.
+var autoTimestampFields = map[string]bool{
+	"created_at":   true,
+	"updated_at":   true,
+	"date_created": true,
+	"date_updated": true,
+}
+
+// DuplicateOptions controls how a record is copied.
+type DuplicateOptions struct {
+	// Unique is how unique columns are handled: "suffix" (default) or "null".
+	// Non-string unique columns are always nulled.
+	Unique string `json:"unique"`
+
+	// Suffix is appended to unique string columns; defaults to " (copy)".
+	Suffix string `json:"suffix"`
+
+	// Presets are set on the copy, overriding copied values.
+	Presets map[string]any `json:"presets"`
+
+	// Children lists one-to-many collections whose records are copied along.
+	// Use "collection.field" when a child references the record more than once.
+	Children []string `json:"children"`
+}
+
+// duplicateChild is a child collection and the rows to copy into it.
+type duplicateChild struct {
+	collection *schema.Collection
+	field      string
+	column     string
+	items      []map[string]any
+}
+
+// Duplicate copies an item into a new record, optionally deep-copying its children.
+// The primary key is dropped, unique columns are suffixed or nulled and
+// timestamps are reset so column defaults apply. The record and its children
+// are written in one transaction. Copying children needs read and create
+// permission on their collections, and is rejected when creates in any of
+// the collections need approval.
+func (s *Service) Duplicate(ctx context.Context, collectionName string, id any, opts DuplicateOptions) (map[string]any, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	switch opts.Unique {
+	case "":
+		opts.Unique = DuplicateUniqueSuffix
+	case DuplicateUniqueSuffix, DuplicateUniqueNull:
+	default:
+		return nil, apperror.ErrBadRequest.WithMessagef("Invalid unique mode '%s'; use 'suffix' or 'null'", opts.Unique)
+	}
+	if opts.Suffix == "" {
+		opts.Suffix = DefaultDuplicateSuffix
+	}
+
+	source, err := s.repo.GetByID(ctx, collection, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// Load children before writing anything so oversized copies fail early
+	children, err := s.duplicateChildren(ctx, collection, source, opts.Children)
+	if err != nil {
+		return nil, err
+	}
+
+	data := duplicateData(collection, source, opts)
+	for k, v := range opts.Presets {
+		data[k] = v
+	}
+	if len(children) == 0 {
+		return s.Create(ctx, collectionName, data)
+	}
+
+	if s.needsApproval(ctx, collection, NotifyActionCreate) {
+		return nil, apperror.ErrBadRequest.WithMessagef("Creates in '%s' need approval; duplicate it without children", collection.Name)
+	}
+	prepared, err := s.prepareCreate(ctx, collection, data)
+	if err != nil {
+		return nil, err
+	}
+	for i := range children {
+		if err := s.prepareChildren(ctx, &children[i], source, prepared, opts); err != nil {
+			return nil, err
+		}
+	}
+
+	newID, childIDs, err := s.repo.CreateWithChildren(ctx, collection, prepared, children)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.repo.GetByID(ctx, collection, newID)
+	if err != nil {
+		return nil, err
+	}
+	s.notify(ctx, collection, NotifyActionCreate, item)
+	for i, child := range children {
+		s.notifyCreated(ctx, child.collection, childIDs[i])
+	}
+	return item, nil
+}
+
+// prepareChildren turns the loaded rows of child into the data of their
+// copies. Until the copy of the parent is written, the foreign key holds
+// its key when already known, or else the source's key.
+func (s *Service) prepareChildren(ctx context.Context, child *duplicateChild, source, parent map[string]any, opts DuplicateOptions) error {
+	if err := s.checkUnlocked(child.collection); err != nil {
+		return err
+	}
+	if s.needsApproval(ctx, child.collection, NotifyActionCreate) {
+		return apperror.ErrBadRequest.WithMessagef("Creates in '%s' need approval; duplicate without its records", child.collection.Name)
+	}
+
+	key := parent[child.column]
+	if key == nil {
+		key = source[child.column]
+	}
+	for i, row := range child.items {
+		data := duplicateData(child.collection, row, opts)
+		data[child.field] = key
+		prepared, err := s.prepareNew(ctx, child.collection, data)
+		if err != nil {
+			return err
+		}
+		child.items[i] = prepared
+	}
+	return nil
+}
+
+// notifyCreated sends the create notifications of the items of collection
+// written by a duplicate.
+func (s *Service) notifyCreated(ctx context.Context, collection *schema.Collection, ids []any) {
+	if !s.watches(collection, NotifyActionCreate) {
+		return
+	}
+	keys := make([]string, len(ids))
+	for i, id := range ids {
+		keys[i] = fmt.Sprint(id)
+	}
+	items, err := s.repo.GetByIDs(ctx, collection, keys)
+	if err != nil {
+		requestlog.Logger(ctx, s.logger).Warnw("Failed to load duplicated records", "collection", collection.Name, "error", err)
+		return
+	}
+	for _, key := range keys {
+		if item, ok := items[key]; ok {
+			s.notify(ctx, collection, NotifyActionCreate, item)
+		}
+	}
+}
+
+// duplicateChildren resolves the requested child collections and loads the
+// rows the request's user may read.
+func (s *Service) duplicateChildren(ctx context.Context, collection *schema.Collection, source map[string]any, names []string) ([]duplicateChild, error) {
+	children := make([]duplicateChild, 0, len(names))
+	for _, name := range names {
+		childName, field, _ := strings.Cut(name, ".")
+
+		child, err := s.schemaManager.GetCollection(childName)
+		if err != nil {
+			return nil, apperror.ErrBadRequest.WithMessagef("Unknown child collection '%s'", childName)
+		}
+		if _, err := s.allowed(ctx, child, permission.ActionCreate); err != nil {
+			return nil, err
+		}
+		read, err := s.allowed(ctx, child, permission.ActionRead)
+		if err != nil {
+			return nil, err
+		}
+
+		fields := make([]string, 0, 1)
+		for _, rel := range s.schemaManager.GetRelationships(child.Name) {
+			if rel.RelatedCollection == collection.Name && (field == "" || rel.FieldName == field) {
+				fields = append(fields, rel.FieldName)
+			}
+		}
+		switch len(fields) {
+		case 0:
+			return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no relation to '%s'", childName, collection.Name)
+		case 1:
+		default:
+			return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' references '%s' more than once; use '%s.<field>'", childName, collection.Name, childName)
+		}
+
+		column := collection.PrimaryKey
+		for _, f := range child.Fields {
+			if f.Name == fields[0] && f.ForeignKey != nil && f.ForeignKey.Column != "" {
+				column = f.ForeignKey.Column
+			}
+		}
+
+		result, err := s.repo.List(ctx, child, ListOptions{
+			Filters:    []query.Filter{{Field: fields[0], Operator: query.OpEqual, Value: source[column]}},
+			Sorts:      []query.Sort{{Field: child.PrimaryKey, Direction: query.SortAsc}},
+			Pagination: query.Pagination{Page: 1, Limit: maxDuplicateChildren},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if result.Total > maxDuplicateChildren {
+			return nil, apperror.ErrBadRequest.WithMessagef("Cannot duplicate more than %d '%s' records", maxDuplicateChildren, childName)
+		}
+		items := result.Items
+		if read != nil && len(read.Filter) > 0 {
+			if items, err = s.itemsInScope(ctx, child, items, read.Filter); err != nil {
+				return nil, err
+			}
+		}
+
+		children = append(children, duplicateChild{
+			collection: child,
+			field:      fields[0],
+			column:     column,
+			items:      items,
+		})
+	}
+	return children, nil
+}
+
+// itemsInScope returns the items matching a row-level permission filter.
+func (s *Service) itemsInScope(ctx context.Context, collection *schema.Collection, items []map[string]any, filter map[string]any) ([]map[string]any, error) {
+	if len(items) == 0 {
+		return items, nil
+	}
+	ids := make([]string, len(items))
+	for i, item := range items {
+		ids[i] = fmt.Sprint(item[collection.PrimaryKey])
+	}
+	scope, scopeArgs := permission.NewFilterBuilder(0).WithDialect(s.repo.dialect).Build(filter)
+	inScope, err := s.repo.IDsInScope(ctx, collection, ids, scope, scopeArgs)
+	if err != nil {
+		return nil, err
+	}
+	kept := items[:0]
+	for i, item := range items {
+		if inScope[ids[i]] {
+			kept = append(kept, item)
+		}
+	}
+	return kept, nil
+}
+
+// CreateWithChildren inserts an item and the items of its children in one
+// transaction, pointing each child's foreign key at the new item. It returns
+// the new item's ID and the IDs of each child's items.
+func (r *Repository) CreateWithChildren(ctx context.Context, collection *schema.Collection, data map[string]any, children []duplicateChild) (any, [][]any, error) {
+	if err := prepareValues(collection, data); err != nil {
+		return nil, nil, err
+	}
+	for _, child := range children {
+		for _, item := range child.items {
+			if err := prepareValues(child.collection, item); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	var id any
+	var childIDs [][]any
+	err := r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		var err error
+		if id, err = r.insertTx(ctx, tx, collection, data); err != nil {
+			return err
+		}
+		childIDs = make([][]any, len(children))
+		for i, child := range children {
+			key, err := r.columnTx(ctx, tx, collection, id, data, child.column)
+			if err != nil {
+				return err
+			}
+			for _, item := range child.items {
+				item[child.field] = key
+				childID, err := r.insertTx(ctx, tx, child.collection, item)
+				if err != nil {
+					return err
+				}
+				childIDs[i] = append(childIDs[i], childID)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return id, childIDs, nil
+}
+
+// columnTx returns the value of column in the item inserted with data,
+// reading it back when the database filled it in.
+func (r *Repository) columnTx(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, id any, data map[string]any, column string) (any, error) {
+	if column == collection.PrimaryKey {
+		return id, nil
+	}
+	if value, ok := data[column]; ok && value != nil {
+		return value, nil
+	}
+	quote := r.dialect.QuoteIdent
+	querySQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s = %s",
+		quote(column), quote(collection.TableName), quote(collection.PrimaryKey), r.dialect.Placeholder(1))
+	var value any
+	if err := tx.QueryRowxContext(ctx, querySQL, id).Scan(&value); err != nil {
+		return nil, dbError(ctx, err)
+	}
+	return value, nil
+}
+
+// duplicateData prepares a copy of item for insertion into collection.
+func duplicateData(collection *schema.Collection, item map[string]any, opts DuplicateOptions) map[string]any {
+	data := filterFields(item, collection.Fields)
+
+	for _, f := range collection.Fields {
+		switch {
+		case f.Name == collection.PrimaryKey || f.IsPrimaryKey:
+			delete(data, f.Name)
+			if f.DataType == "uuid" && f.DefaultValue == nil && collection.IDGeneration == nil {
+				data[f.Name] = uuid.NewString()
+			}
+		case (f.DataType == "timestamp" || f.DataType == "date") && (f.DefaultValue != nil || autoTimestampFields[f.Name]):
+			delete(data, f.Name)
+		case f.IsUnique:
+			data[f.Name] = duplicateUnique(f, data[f.Name], opts)
+		}
+	}
+	return data
+}
+
+// duplicateUnique returns the value a unique column takes on a duplicate.
+func duplicateUnique(f schema.Field, value any, opts DuplicateOptions) any {
+	s, ok := value.(string)
+	if !ok || opts.Unique == DuplicateUniqueNull {
+		return nil
+	}
+
+	// Keep the suffix when the column is too short for the full value
+	runes, suffix := []rune(s), []rune(opts.Suffix)
+	if f.MaxLength != nil && len(runes)+len(suffix) > *f.MaxLength {
+		keep := *f.MaxLength - len(suffix)
+		if keep < 0 {
+			return nil
+		}
+		s = string(runes[:keep])
+	}
+	return s + opts.Suffix
+}
@@ -0,0 +1,56 @@
+package collection
+
+import (
+	"context"
+	"time"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// valueEncoder writes the scanned values of binary, timestamp and decimal
+// fields for one request.
+type valueEncoder struct {
+	encodeTime     func(time.Time) any
+	decimalNumbers bool
+}
+
+// encoder returns the value encoder for a request.
+func (r *Repository) encoder(ctx context.Context) valueEncoder {
+	return valueEncoder{
+		encodeTime:     r.encodeTime(ctx),
+		decimalNumbers: r.decimalNumbers,
+	}
+}
+
+// encode normalizes a scanned value of a field with the given data type.
+func (e valueEncoder) encode(dataType string, v any) any {
+	switch dataType {
+	case "binary":
+		return normalizeBinary(v)
+	case "timestamp":
+		if t, ok := v.(time.Time); ok && e.encodeTime != nil {
+			return e.encodeTime(t)
+		}
+	case "decimal":
+		return normalizeDecimal(v, e.decimalNumbers)
+	case "vector":
+		return normalizeVector(v)
+	}
+	return normalizeValue(v)
+}
+
+// encodedTypes returns the data types of the collection's fields whose
+// values need encoding, by field name, or nil when it has none.
+func encodedTypes(collection *schema.Collection) map[string]string {
+	var types map[string]string
+	for _, f := range collection.Fields {
+		switch f.DataType {
+		case "binary", "timestamp", "decimal", "vector":
+			if types == nil {
+				types = make(map[string]string)
+			}
+			types[f.Name] = f.DataType
+		}
+	}
+	return types
+}
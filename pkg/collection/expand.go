@@ -0,0 +1,92 @@
+package collection
+
+import (
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Expansion strategies for list requests.
+const (
+	// ExpandStrategyQuery fetches each expanded relation with a separate query.
+	ExpandStrategyQuery = "query"
+
+	// ExpandStrategyJoin embeds expanded relations in the list query.
+	ExpandStrategyJoin = "join"
+
+	// ExpandStrategyAuto embeds relations for pages up to JoinMaxRows rows
+	// and queries them separately for larger pages.
+	ExpandStrategyAuto = "auto"
+)
+
+// DefaultExpandJoinMaxRows is the largest page the auto strategy embeds relations for.
+const DefaultExpandJoinMaxRows = 100
+
+// ExpandConfig selects how list requests expand relations.
+type ExpandConfig struct {
+	// Strategy is "query", "join" or "auto".
+	// Default: "query"
+	Strategy string
+
+	// JoinMaxRows is the largest page size the auto strategy joins for.
+	// Default: 100
+	JoinMaxRows int
+}
+
+// SetExpandConfig sets the expansion strategy for list requests.
+func (s *Service) SetExpandConfig(config ExpandConfig) {
+	if config.JoinMaxRows <= 0 {
+		config.JoinMaxRows = DefaultExpandJoinMaxRows
+	}
+	s.expand = config
+}
+
+// joinExpand reports whether a list page should embed its expanded relations.
+func (s *Service) joinExpand(pagination query.Pagination) bool {
+	switch s.expand.Strategy {
+	case ExpandStrategyJoin:
+		return true
+	case ExpandStrategyAuto:
+		return pagination.Limit <= s.expand.JoinMaxRows
+	default:
+		return false
+	}
+}
+
+// expansions returns the relations to embed in a list query.
+// Unknown relations are ignored, as with separate expansion queries.
+func (s *Service) expansions(collection *schema.Collection, expand []string) []query.Expansion {
+	result := make([]query.Expansion, 0, len(expand))
+	for _, expandField := range expand {
+		rel, related, ok := s.expandRelation(collection, expandField)
+		if !ok {
+			continue
+		}
+		result = append(result, query.Expansion{
+			Name:          expandField,
+			Table:         related.TableName,
+			LocalColumn:   rel.FieldName,
+			ForeignColumn: related.PrimaryKey,
+			Fields:        getFieldNames(related.Fields),
+		})
+	}
+	return result
+}
+
+// expandRelation resolves an expand field to its relation and related collection.
+// The field may name the foreign key with or without its _id suffix.
+func (s *Service) expandRelation(collection *schema.Collection, expandField string) (*schema.Relationship, *schema.Collection, bool) {
+	rel, ok := s.schemaManager.GetRelationship(collection.Name, expandField+"_id")
+	if !ok {
+		// Try without _id suffix
+		rel, ok = s.schemaManager.GetRelationship(collection.Name, expandField)
+		if !ok {
+			return nil, nil, false
+		}
+	}
+
+	related, err := s.schemaManager.GetCollection(rel.RelatedCollection)
+	if err != nil || related.PrimaryKey == "" {
+		return nil, nil, false
+	}
+	return rel, related, true
+}
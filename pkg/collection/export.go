@@ -0,0 +1,42 @@
+package collection
+
+import (
+	"context"
+
+	"github.com/thienel/tugo/pkg/query"
+)
+
+// DefaultMaxExportRows caps the rows of an export when none is configured.
+const DefaultMaxExportRows = 10000
+
+// SetMaxExportRows sets the most rows an export returns.
+func (s *Service) SetMaxExportRows(n int) {
+	s.maxExportRows = n
+}
+
+// Export streams the items matching a list request's filters and sort as
+// JSON objects, calling fn with each encoded item. The request's page is
+// ignored; at most the configured number of rows are returned. The slice
+// passed to fn is reused for the next item.
+func (s *Service) Export(ctx context.Context, params ListParams, fn func(item []byte) error) error {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return err
+	}
+
+	_, opts, err := s.listOptions(ctx, collection, params)
+	if err != nil {
+		return err
+	}
+	if err := checkCost(collection, query.Options{Filters: opts.Filters}); err != nil {
+		return err
+	}
+
+	limit := s.maxExportRows
+	if limit <= 0 {
+		limit = DefaultMaxExportRows
+	}
+	opts.Pagination = query.Pagination{Page: 1, Limit: limit}
+
+	return s.repo.Export(ctx, collection, opts, fn)
+}
@@ -0,0 +1,431 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Favorite page sizes.
+const (
+	DefaultFavoritesLimit = 50
+	MaxFavoritesLimit     = 200
+)
+
+// Favorite is an item a user starred. Item holds the current record when
+// listed.
+type Favorite struct {
+	ID         string         `db:"id" json:"id"`
+	UserID     string         `db:"user_id" json:"user_id"`
+	Collection string         `db:"collection" json:"collection"`
+	ItemID     string         `db:"item_id" json:"item_id"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+	Item       map[string]any `db:"-" json:"item,omitempty"`
+}
+
+// FavoriteFilter selects a user's favorites to list.
+type FavoriteFilter struct {
+	// Collection limits favorites to one collection.
+	Collection string
+
+	// Before lists favorites starred before this time, for paging.
+	Before *time.Time
+
+	Limit int
+}
+
+// FavoritePage is a page of a user's favorites, newest first. Pass the
+// creation time of its last favorite as ?before= for the next page.
+type FavoritePage struct {
+	Favorites []*Favorite `json:"favorites"`
+	HasMore   bool        `json:"has_more"`
+}
+
+// FavoriteStore persists favorites in tugo_favorites.
+type FavoriteStore struct {
+	db *sqlx.DB
+}
+
+// NewFavoriteStore creates a new favorite store.
+func NewFavoriteStore(db *sqlx.DB) *FavoriteStore {
+	return &FavoriteStore{db: db}
+}
+
+// SetFavoriteStore enables favorites on collections configured with them.
+func (s *Service) SetFavoriteStore(store *FavoriteStore) {
+	s.favorites = store
+}
+
+// Get returns a user's favorite of an item.
+func (s *FavoriteStore) Get(ctx context.Context, userID, collection, itemID string) (*Favorite, error) {
+	query := `
+		SELECT id, user_id, collection, item_id, created_at
+		FROM tugo_favorites
+		WHERE user_id = ? AND collection = ? AND item_id = ?
+	`
+	var favorite Favorite
+	if err := s.db.GetContext(ctx, &favorite, s.db.Rebind(query), userID, collection, itemID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Item '%s' is not a favorite", itemID)
+		}
+		return nil, fmt.Errorf("failed to get favorite: %w", err)
+	}
+	return &favorite, nil
+}
+
+// Add stores a favorite, filling in its ID and creation time. It returns
+// false when the user already starred the item.
+func (s *FavoriteStore) Add(ctx context.Context, favorite *Favorite) (bool, error) {
+	favorite.ID = uuid.New().String()
+	favorite.CreatedAt = time.Now().UTC()
+
+	query := `
+		INSERT INTO tugo_favorites (id, user_id, collection, item_id, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
+		favorite.ID, favorite.UserID, favorite.Collection, favorite.ItemID, favorite.CreatedAt)
+	if isDuplicateKeyError(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to store favorite: %w", err)
+	}
+	return true, nil
+}
+
+// Remove deletes a user's favorite of an item.
+func (s *FavoriteStore) Remove(ctx context.Context, userID, collection, itemID string) error {
+	query := `DELETE FROM tugo_favorites WHERE user_id = ? AND collection = ? AND item_id = ?`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), userID, collection, itemID); err != nil {
+		return fmt.Errorf("failed to delete favorite: %w", err)
+	}
+	return nil
+}
+
+// List returns a user's favorites matching filter, newest first.
+func (s *FavoriteStore) List(ctx context.Context, userID string, filter FavoriteFilter) ([]*Favorite, error) {
+	query := `
+		SELECT id, user_id, collection, item_id, created_at
+		FROM tugo_favorites
+		WHERE user_id = ?
+	`
+	args := []any{userID}
+	if filter.Collection != "" {
+		query += " AND collection = ?"
+		args = append(args, filter.Collection)
+	}
+	if filter.Before != nil {
+		query += " AND created_at < ?"
+		args = append(args, *filter.Before)
+	}
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", filter.Limit)
+
+	favorites := make([]*Favorite, 0)
+	if err := s.db.SelectContext(ctx, &favorites, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to list favorites: %w", err)
+	}
+	return favorites, nil
+}
+
+// DeleteItem removes every user's favorite of an item.
+func (s *FavoriteStore) DeleteItem(ctx context.Context, collection, itemID string) error {
+	query := `DELETE FROM tugo_favorites WHERE collection = ? AND item_id = ?`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), collection, itemID); err != nil {
+		return fmt.Errorf("failed to delete favorites: %w", err)
+	}
+	return nil
+}
+
+// AddFavorite stars an item for the request's user. It returns the
+// favorite and whether it is new.
+func (s *Service) AddFavorite(ctx context.Context, params ItemParams) (*Favorite, bool, error) {
+	user, err := favoriteUser(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	collection, err := s.favoriteTarget(ctx, params)
+	if err != nil {
+		return nil, false, err
+	}
+
+	favorite := &Favorite{UserID: user.ID, Collection: collection.Name, ItemID: params.ItemID}
+	added, err := s.favorites.Add(ctx, favorite)
+	if err != nil {
+		return nil, false, apperror.ErrInternalServer.WithError(err)
+	}
+	if !added {
+		if favorite, err = s.favorites.Get(ctx, user.ID, collection.Name, params.ItemID); err != nil {
+			return nil, false, err
+		}
+	}
+	return favorite, added, nil
+}
+
+// RemoveFavorite unstars an item for the request's user. Removing an item
+// that is not a favorite succeeds.
+func (s *Service) RemoveFavorite(ctx context.Context, params ItemParams) error {
+	user, err := favoriteUser(ctx)
+	if err != nil {
+		return err
+	}
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return err
+	}
+	if s.favorites == nil || !collection.Favorites {
+		return apperror.ErrNotFound.WithMessagef("Favorites are not enabled for collection '%s'", params.CollectionName)
+	}
+
+	if err := s.favorites.Remove(ctx, user.ID, collection.Name, params.ItemID); err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	return nil
+}
+
+// ListFavorites returns the request's user's favorites with their items.
+// Favorites of items that were deleted, or that the user may no longer
+// read, are left out.
+func (s *Service) ListFavorites(ctx context.Context, filter FavoriteFilter) (*FavoritePage, error) {
+	user, err := favoriteUser(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if s.favorites == nil {
+		return &FavoritePage{Favorites: []*Favorite{}}, nil
+	}
+	if filter.Collection != "" {
+		collection, err := s.schemaManager.GetCollection(filter.Collection)
+		if err != nil {
+			return nil, err
+		}
+		filter.Collection = collection.Name
+	}
+
+	limit := filter.Limit
+	filter.Limit++
+	favorites, err := s.favorites.List(ctx, user.ID, filter)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	page := &FavoritePage{Favorites: favorites}
+	if len(favorites) > limit {
+		page.Favorites, page.HasMore = favorites[:limit], true
+	}
+
+	// Load the items of each collection at once
+	byCollection := make(map[string][]string)
+	for _, favorite := range page.Favorites {
+		byCollection[favorite.Collection] = append(byCollection[favorite.Collection], favorite.ItemID)
+	}
+	items := make(map[string]map[string]map[string]any, len(byCollection))
+	for name, ids := range byCollection {
+		if items[name], err = s.readableItems(ctx, user, name, ids); err != nil {
+			return nil, err
+		}
+	}
+
+	listed := make([]*Favorite, 0, len(page.Favorites))
+	for _, favorite := range page.Favorites {
+		if item, ok := items[favorite.Collection][favorite.ItemID]; ok {
+			favorite.Item = item
+			listed = append(listed, favorite)
+		}
+	}
+	page.Favorites = listed
+	return page, nil
+}
+
+// readableItems returns the items of a collection among ids that user may
+// read, by ID. Unknown collections and collections without favorites have
+// none.
+func (s *Service) readableItems(ctx context.Context, user *auth.User, name string, ids []string) (map[string]map[string]any, error) {
+	collection, err := s.schemaManager.GetCollection(name)
+	if err != nil || !collection.Favorites || collection.PrimaryKey == "" {
+		return nil, nil
+	}
+
+	var scope map[string]any
+	if s.permissions != nil {
+		result, err := s.permissions.Check(ctx, user, collection.Name, permission.ActionRead)
+		if err != nil {
+			return nil, apperror.ErrInternalServer.WithError(err)
+		}
+		if !result.Allowed {
+			return nil, nil
+		}
+		scope = result.Filter
+	}
+
+	items, err := s.repo.GetByIDs(ctx, collection, ids)
+	if err != nil {
+		return nil, err
+	}
+	if len(scope) > 0 && len(items) > 0 {
+		where, whereArgs := permission.NewFilterBuilder(0).WithDialect(s.repo.dialect).Build(scope)
+		inScope, err := s.repo.IDsInScope(ctx, collection, ids, where, whereArgs)
+		if err != nil {
+			return nil, err
+		}
+		for id := range items {
+			if !inScope[id] {
+				delete(items, id)
+			}
+		}
+	}
+	if err := s.translate(ctx, collection, mapValues(items)); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// favoriteTarget returns the collection of an item that can be starred,
+// checking the item exists and is readable.
+func (s *Service) favoriteTarget(ctx context.Context, params ItemParams) (*schema.Collection, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if s.favorites == nil || !collection.Favorites {
+		return nil, apperror.ErrNotFound.WithMessagef("Favorites are not enabled for collection '%s'", params.CollectionName)
+	}
+
+	if err := s.checkReadable(ctx, collection, params); err != nil {
+		return nil, err
+	}
+	return collection, nil
+}
+
+// deleteFavorites removes the favorites of a deleted item.
+func (s *Service) deleteFavorites(ctx context.Context, collection *schema.Collection, id any) {
+	if s.favorites == nil || !collection.Favorites {
+		return
+	}
+	if err := s.favorites.DeleteItem(ctx, collection.Name, fmt.Sprint(id)); err != nil {
+		requestlog.Logger(ctx, s.logger).Warnw("Failed to delete favorites", "collection", collection.Name, "id", id, "error", err)
+	}
+}
+
+// favoriteUser returns the request's user, who owns favorites.
+func favoriteUser(ctx context.Context) (*auth.User, error) {
+	user, ok := auth.GetUserFromContext(ctx)
+	if !ok || user == nil || user.ID == "" {
+		return nil, apperror.ErrUnauthorized.WithMessage("Authentication required for favorites")
+	}
+	return user, nil
+}
+
+// mapValues returns the values of items.
+func mapValues(items map[string]map[string]any) []map[string]any {
+	values := make([]map[string]any, 0, len(items))
+	for _, item := range items {
+		values = append(values, item)
+	}
+	return values
+}
+
+// IDsInScope returns which of ids name items matching a row-level scope.
+func (r *Repository) IDsInScope(ctx context.Context, collection *schema.Collection, ids []string, scope string, scopeArgs []any) (map[string]bool, error) {
+	args := append([]any{}, scopeArgs...)
+	placeholders := make([]string, len(ids))
+	for i, id := range ids {
+		args = append(args, id)
+		placeholders[i] = r.dialect.Placeholder(len(args))
+	}
+	quote := r.dialect.QuoteIdent
+	querySQL := fmt.Sprintf("SELECT %s FROM %s WHERE (%s) AND %s IN (%s)",
+		quote(collection.PrimaryKey), quote(collection.TableName), scope, quote(collection.PrimaryKey), strings.Join(placeholders, ", "))
+
+	inScope := make(map[string]bool, len(ids))
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		var found []string
+		if err := sqlx.SelectContext(ctx, q, &found, querySQL, args...); err != nil {
+			if isInvalidUUIDError(err) {
+				return apperror.ErrBadRequest.WithMessage("Invalid ID format")
+			}
+			return dbError(ctx, err)
+		}
+		for _, id := range found {
+			inScope[id] = true
+		}
+		return nil
+	})
+	return inScope, err
+}
+
+// AddFavorite handles POST /:collection/:id/favorite requests, responding
+// 201 when the item was starred and 200 when it already was.
+func (h *Handler) AddFavorite(c *gin.Context) {
+	favorite, added, err := h.service.AddFavorite(c.Request.Context(), itemParams(c))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	status := http.StatusOK
+	if added {
+		status = http.StatusCreated
+	}
+	h.write(c, status, response.Success(favorite))
+}
+
+// RemoveFavorite handles DELETE /:collection/:id/favorite requests.
+func (h *Handler) RemoveFavorite(c *gin.Context) {
+	if err := h.service.RemoveFavorite(c.Request.Context(), itemParams(c)); err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(nil))
+}
+
+// ListFavorites handles GET /me/favorites requests. ?collection= limits
+// the favorites to one collection.
+func (h *Handler) ListFavorites(c *gin.Context) {
+	filter := FavoriteFilter{Collection: c.Query("collection"), Limit: DefaultFavoritesLimit}
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid limit"))
+			return
+		}
+		filter.Limit = min(n, MaxFavoritesLimit)
+	}
+	if value := c.Query("before"); value != "" {
+		before, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid before time"))
+			return
+		}
+		filter.Before = &before
+	}
+
+	page, err := h.service.ListFavorites(c.Request.Context(), filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(page))
+}
+
+// RegisterFavoriteRoutes registers the favorites of the request's user on
+// a Gin router group, such as /me/favorites.
+func (h *Handler) RegisterFavoriteRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.timezone, h.locale, h.ListFavorites)
+}
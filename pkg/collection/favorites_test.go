@@ -0,0 +1,27 @@
+package collection
+
+import (
+	"context"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+)
+
+func TestListFavoritesUser(t *testing.T) {
+	s := &Service{}
+
+	_, err := s.ListFavorites(context.Background(), FavoriteFilter{Limit: DefaultFavoritesLimit})
+	if appErr, ok := apperror.AsAppError(err); !ok || appErr.Code != apperror.ErrUnauthorized.Code {
+		t.Fatalf("ListFavorites() without user error = %v, want unauthorized", err)
+	}
+
+	ctx := auth.SetUserInContext(context.Background(), &auth.User{ID: "u1", Role: "user"})
+	page, err := s.ListFavorites(ctx, FavoriteFilter{Limit: DefaultFavoritesLimit})
+	if err != nil {
+		t.Fatalf("ListFavorites() error = %v", err)
+	}
+	if page.Favorites == nil || len(page.Favorites) != 0 || page.HasMore {
+		t.Errorf("ListFavorites() without store = %+v, want empty page", page)
+	}
+}
@@ -0,0 +1,352 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// ConfirmCountParam confirms the number of items a write by filter
+// affects, letting it affect more than the MaxAffected items.
+const ConfirmCountParam = "confirm_count"
+
+// DryRunParam makes a write by filter count the items it would affect
+// without writing them.
+const DryRunParam = "dry_run"
+
+// DefaultMaxAffected caps the items a write by filter affects without a
+// confirmed count.
+const DefaultMaxAffected = 1000
+
+// AuditUpdateManyAction is the tugo_audit_log action of an update by filter.
+const AuditUpdateManyAction = "collection.update_many"
+
+// AuditDeleteManyAction is the tugo_audit_log action of a delete by filter.
+const AuditDeleteManyAction = "collection.delete_many"
+
+// FilterParams selects the items of a write by filter.
+type FilterParams struct {
+	CollectionName string
+
+	// QueryParams holds the filter[...] parameters matching the items. At
+	// least one filter is required.
+	QueryParams map[string][]string
+
+	// ConfirmCount is the number of matching items the caller expects.
+	// Writes matching more than MaxAffected items need it, and fail when
+	// the items matching differ in number. Deletes always need it.
+	ConfirmCount int64
+
+	// DryRun counts the matching items without writing them.
+	DryRun bool
+}
+
+// AffectedResponse holds the result of a write by filter.
+type AffectedResponse struct {
+	Matched  int64 `json:"matched"`
+	Affected int64 `json:"affected"`
+	DryRun   bool  `json:"dry_run,omitempty"`
+}
+
+// UpdateMany sets the fields of data on every item matching the filters
+// of params, in one statement. Per-item work such as revisions,
+// notifications and derived fields is skipped. The update is recorded in
+// tugo_audit_log.
+func (s *Service) UpdateMany(ctx context.Context, params FilterParams, data map[string]any) (*AffectedResponse, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+	if s.needsApproval(ctx, collection, NotifyActionUpdate) {
+		return nil, apperror.ErrForbidden.WithMessagef("Updates in '%s' need approval; update items one at a time", collection.Name)
+	}
+
+	filters, err := s.writeFilters(ctx, collection, params.QueryParams)
+	if err != nil {
+		return nil, err
+	}
+	filteredData, err := s.prepareUpdateMany(ctx, collection, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.DryRun {
+		return s.countMany(ctx, collection, filters)
+	}
+
+	audit := writeAudit(ctx, params)
+	audit["fields"] = sortedKeys(filteredData)
+	var matched int64
+	affected, err := s.repo.UpdateWhere(ctx, collection, filters, filteredData, audit, func(n int64) error {
+		matched = n
+		return s.checkAffected(n, params.ConfirmCount, false)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AffectedResponse{Matched: matched, Affected: affected}, nil
+}
+
+// DeleteMany deletes every item matching the filters of params, in one
+// statement. The number of matching items must always be confirmed, so a
+// caller first counts them with a dry run. Per-item work such as removing
+// translations, comments and favorites is skipped. The delete is recorded
+// in tugo_audit_log.
+func (s *Service) DeleteMany(ctx context.Context, params FilterParams) (*AffectedResponse, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+	if s.needsApproval(ctx, collection, NotifyActionDelete) {
+		return nil, apperror.ErrForbidden.WithMessagef("Deletes in '%s' need approval; delete items one at a time", collection.Name)
+	}
+
+	filters, err := s.writeFilters(ctx, collection, params.QueryParams)
+	if err != nil {
+		return nil, err
+	}
+	if params.DryRun {
+		return s.countMany(ctx, collection, filters)
+	}
+
+	var matched int64
+	affected, err := s.repo.DeleteWhere(ctx, collection, filters, writeAudit(ctx, params), func(n int64) error {
+		matched = n
+		return s.checkAffected(n, params.ConfirmCount, true)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &AffectedResponse{Matched: matched, Affected: affected}, nil
+}
+
+// countMany counts the items a write by filter would affect.
+func (s *Service) countMany(ctx context.Context, collection *schema.Collection, filters []query.Filter) (*AffectedResponse, error) {
+	matched, err := s.repo.CountWhere(ctx, collection, filters)
+	if err != nil {
+		return nil, err
+	}
+	return &AffectedResponse{Matched: matched, DryRun: true}, nil
+}
+
+// writeAudit returns the audit entry of a write by filter.
+func writeAudit(ctx context.Context, params FilterParams) map[string]any {
+	audit := map[string]any{"filter": filterAudit(params.QueryParams)}
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		audit["user_id"] = user.ID
+	}
+	return audit
+}
+
+// writeFilters parses the filters of a write by filter, requiring at
+// least one. Malformed filter keys are rejected rather than ignored, so a
+// typo cannot widen the write.
+func (s *Service) writeFilters(ctx context.Context, collection *schema.Collection, params map[string][]string) ([]query.Filter, error) {
+	for key := range params {
+		if strings.HasPrefix(key, "filter") && !query.IsFilterKey(key) {
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Malformed filter parameter '%s'; use filter[field] or filter[field:op]", key)
+		}
+	}
+
+	filters, err := query.NewFilterParser(getFieldNames(collection.Fields)).Parse(params)
+	if err != nil {
+		return nil, err
+	}
+	if len(filters) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("A filter is required to write by filter")
+	}
+	if filters, err = textFilters(collection, filters); err != nil {
+		return nil, err
+	}
+	filters = resolveRelativeDates(collection, filters, time.Now().In(s.repo.location(ctx)))
+	if filters, err = coerceFilters(collection, filters); err != nil {
+		return nil, err
+	}
+	if filters, err = moneyFilters(collection, filters); err != nil {
+		return nil, err
+	}
+	return localizeDateFilters(collection, filters, s.repo.location(ctx)), nil
+}
+
+// prepareUpdateMany filters, fills in and validates the changes of an
+// update by filter. Fields that must be checked against each item, such
+// as immutable and lifecycle fields, cannot be set.
+func (s *Service) prepareUpdateMany(ctx context.Context, collection *schema.Collection, data map[string]any) (map[string]any, error) {
+	filteredData := filterFields(data, collection.Fields)
+	delete(filteredData, collection.PrimaryKey)
+	if len(filteredData) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("No fields to update")
+	}
+	if validationErr := normalizeNumbers(collection, filteredData); validationErr != nil {
+		return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
+	}
+
+	errs := &validation.ValidationErrors{}
+	for _, name := range collection.ImmutableFields {
+		if _, ok := filteredData[name]; ok {
+			errs.Add(name, "cannot be changed after creation", "immutable")
+		}
+	}
+	if lifecycle := collection.Lifecycle; lifecycle != nil {
+		for _, name := range []string{lifecycle.Field, lifecycle.PublishAtField} {
+			if _, ok := filteredData[name]; ok && name != "" {
+				errs.Add(name, "changes through POST /:collection/:id/transition", "lifecycle")
+			}
+		}
+	}
+	if errs.HasErrors() {
+		return nil, apperror.ErrValidation.WithMessage(errs.Error()).WithDetails(errs.Errors)
+	}
+
+	fillAutoFields(ctx, collection, filteredData, false, time.Now().UTC())
+	if s.validator != nil {
+		if validationErr := s.validator.ValidatePartial(ctx, collection.Name, filteredData); validationErr != nil {
+			return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
+		}
+	}
+	return filteredData, nil
+}
+
+// checkAffected fails with apperror.ErrConfirmationRequired unless the
+// matched items are confirmed or, when confirmation is not required,
+// within MaxAffected.
+func (s *Service) checkAffected(matched, confirmed int64, required bool) error {
+	maxAffected := int64(s.bulk.MaxAffected)
+	if maxAffected <= 0 {
+		maxAffected = DefaultMaxAffected
+	}
+	if matched == confirmed || (!required && confirmed == 0 && matched <= maxAffected) {
+		return nil
+	}
+	details := map[string]any{"matched": matched}
+	if !required {
+		details["max_affected"] = maxAffected
+	}
+	return apperror.ErrConfirmationRequired.
+		WithMessagef("%d items match; repeat with %s=%d to write them all", matched, ConfirmCountParam, matched).
+		WithDetails(details)
+}
+
+// filterAudit returns the filter parameters of a write for its audit entry.
+func filterAudit(params map[string][]string) map[string]any {
+	filter := make(map[string]any)
+	for key, values := range params {
+		if query.IsFilterKey(key) {
+			filter[key] = values
+		}
+	}
+	return filter
+}
+
+// sortedKeys returns the keys of data in order.
+func sortedKeys(data map[string]any) []string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// CountWhere counts the rows matching filters.
+func (r *Repository) CountWhere(ctx context.Context, collection *schema.Collection, filters []query.Filter) (int64, error) {
+	countSQL, countArgs := query.NewBuilder(collection.TableName).WithDialect(r.dialect).Where(filters).BuildCount()
+	var matched int64
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if err := sqlx.GetContext(ctx, q, &matched, countSQL, countArgs...); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return matched, nil
+}
+
+// UpdateWhere counts the rows matching filters, passes the count to check
+// and, unless it fails, sets data on them, in one transaction with an
+// audit entry. It returns the number of rows updated.
+func (r *Repository) UpdateWhere(ctx context.Context, collection *schema.Collection, filters []query.Filter, data, audit map[string]any, check func(matched int64) error) (int64, error) {
+	if err := prepareValues(collection, data); err != nil {
+		return 0, err
+	}
+	updateSQL, updateArgs := query.BuildUpdateWhereDialect(r.dialect, collection.TableName, data, filters)
+	return r.writeWhere(ctx, collection, filters, AuditUpdateManyAction, audit, check, updateSQL, updateArgs)
+}
+
+// DeleteWhere counts the rows matching filters, passes the count to check
+// and, unless it fails, deletes them, in one transaction with an audit
+// entry. It returns the number of rows deleted.
+func (r *Repository) DeleteWhere(ctx context.Context, collection *schema.Collection, filters []query.Filter, audit map[string]any, check func(matched int64) error) (int64, error) {
+	deleteSQL, deleteArgs := query.BuildDeleteWhereDialect(r.dialect, collection.TableName, filters)
+	return r.writeWhere(ctx, collection, filters, AuditDeleteManyAction, audit, check, deleteSQL, deleteArgs)
+}
+
+// writeWhere runs a write by filter once check accepts the count of rows
+// matching filters, and records it in tugo_audit_log under action. Rows
+// matching after the count, such as ones inserted concurrently, change how
+// many are written; check must accept that number too or the write is
+// rolled back. MySQL reports only the rows an UPDATE changes, so there the
+// count locks the matching rows instead.
+func (r *Repository) writeWhere(ctx context.Context, collection *schema.Collection, filters []query.Filter, action string, audit map[string]any, check func(matched int64) error, writeSQL string, writeArgs []any) (int64, error) {
+	countSQL, countArgs := query.NewBuilder(collection.TableName).WithDialect(r.dialect).Where(filters).BuildCount()
+	lock := r.dialect.Name() == dialect.MySQL
+	if lock {
+		countSQL += " FOR UPDATE"
+	}
+
+	var affected int64
+	err := r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		var matched int64
+		if err := tx.GetContext(ctx, &matched, countSQL, countArgs...); err != nil {
+			return dbError(ctx, err)
+		}
+		if err := check(matched); err != nil {
+			return err
+		}
+
+		res, err := tx.ExecContext(ctx, writeSQL, writeArgs...)
+		if err != nil {
+			return duplicateError(ctx, err, "Record with this value already exists")
+		}
+		affected, _ = res.RowsAffected()
+		if !lock && affected != matched {
+			if err := check(affected); err != nil {
+				return err
+			}
+		}
+
+		audit["affected"] = affected
+		changes, err := json.Marshal(audit)
+		if err != nil {
+			return err
+		}
+		auditSQL := tx.Rebind(`INSERT INTO tugo_audit_log (id, action, collection, changes, created_at) VALUES (?, ?, ?, ?, ?)`)
+		if _, err := tx.ExecContext(ctx, auditSQL, uuid.NewString(), action, collection.Name, string(changes), time.Now().UTC()); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return affected, nil
+}
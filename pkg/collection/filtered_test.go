@@ -0,0 +1,193 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestCheckAffected(t *testing.T) {
+	s := &Service{bulk: BulkConfig{MaxAffected: 100}}
+
+	tests := []struct {
+		name      string
+		matched   int64
+		confirmed int64
+		required  bool
+		wantErr   bool
+	}{
+		{"none", 0, 0, false, false},
+		{"within limit", 100, 0, false, false},
+		{"over limit", 101, 0, false, true},
+		{"over limit confirmed", 5000, 5000, false, false},
+		{"confirmed count changed", 5001, 5000, false, true},
+		{"within limit wrongly confirmed", 10, 12, false, true},
+		{"required unconfirmed", 1, 0, true, true},
+		{"required confirmed", 10, 10, true, false},
+		{"required none matched", 0, 0, true, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := s.checkAffected(tt.matched, tt.confirmed, tt.required)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkAffected() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			var appErr *apperror.AppError
+			if err != nil && (!errors.As(err, &appErr) || appErr.Code != apperror.ErrConfirmationRequired.Code) {
+				t.Errorf("checkAffected() error = %v, want CONFIRMATION_REQUIRED", err)
+			}
+		})
+	}
+}
+
+func TestWriteFilters(t *testing.T) {
+	s := &Service{repo: &Repository{}}
+	collection := &schema.Collection{Name: "posts", Fields: []schema.Field{{Name: "status"}, {Name: "title"}}}
+
+	tests := []struct {
+		name    string
+		params  map[string][]string
+		want    int
+		wantErr bool
+	}{
+		{"filter", map[string][]string{"filter[status]": {"draft"}}, 1, false},
+		{"filter with operator", map[string][]string{"filter[title:like]": {"%x%"}, "confirm_count": {"3"}}, 1, false},
+		{"no filter", map[string][]string{"confirm_count": {"3"}}, 0, true},
+		{"malformed filter", map[string][]string{"filter[status]": {"draft"}, "filter_title": {"x"}}, 0, true},
+		{"unknown field", map[string][]string{"filter[missing]": {"x"}}, 0, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filters, err := s.writeFilters(context.Background(), collection, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("writeFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if len(filters) != tt.want {
+				t.Errorf("writeFilters() = %d filters, want %d", len(filters), tt.want)
+			}
+		})
+	}
+}
+
+// writeConn counts matched rows for COUNT queries and reports affected rows
+// for writes, recording whether transactions commit. It is opened through
+// writeConnector.
+type writeConn struct {
+	matched    int64
+	affected   int64
+	committed  bool
+	rolledBack bool
+}
+
+type writeStmt struct {
+	conn  *writeConn
+	query string
+}
+
+type writeTx struct{ conn *writeConn }
+
+type writeRows struct {
+	count int64
+	done  bool
+}
+
+type writeConnector struct{ conn *writeConn }
+
+func (c writeConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c writeConnector) Driver() driver.Driver                        { return revisionDriver{} }
+
+func (c *writeConn) Prepare(query string) (driver.Stmt, error) {
+	return &writeStmt{conn: c, query: query}, nil
+}
+func (c *writeConn) Close() error              { return nil }
+func (c *writeConn) Begin() (driver.Tx, error) { return writeTx{c}, nil }
+
+func (tx writeTx) Commit() error   { tx.conn.committed = true; return nil }
+func (tx writeTx) Rollback() error { tx.conn.rolledBack = true; return nil }
+
+func (s *writeStmt) Close() error  { return nil }
+func (s *writeStmt) NumInput() int { return -1 }
+func (s *writeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &writeRows{count: s.conn.matched}, nil
+}
+func (s *writeStmt) Exec([]driver.Value) (driver.Result, error) {
+	if strings.HasPrefix(s.query, "INSERT") {
+		return driver.RowsAffected(1), nil
+	}
+	return driver.RowsAffected(s.conn.affected), nil
+}
+
+func (r *writeRows) Columns() []string { return []string{"count"} }
+func (r *writeRows) Close() error      { return nil }
+func (r *writeRows) Next(dest []driver.Value) error {
+	if r.done {
+		return io.EOF
+	}
+	r.done = true
+	dest[0] = r.count
+	return nil
+}
+
+func TestWriteWhereRecheck(t *testing.T) {
+	s := &Service{bulk: BulkConfig{MaxAffected: 100}}
+	collection := &schema.Collection{Name: "posts", TableName: "api_posts", Fields: []schema.Field{{Name: "status"}}}
+	filters := []query.Filter{{Field: "status", Operator: query.OpEqual, Value: "draft"}}
+
+	tests := []struct {
+		name         string
+		update       bool
+		matched      int64
+		affected     int64
+		confirmed    int64
+		wantErr      bool
+		wantAffected int64
+	}{
+		{"delete as counted", false, 3, 3, 3, false, 3},
+		{"delete of rows added after a confirmed count", false, 3, 4, 3, true, 0},
+		{"delete of rows added after an unconfirmed count", false, 3, 4, 0, false, 4},
+		{"update of rows added after a confirmed count", true, 3, 4, 3, true, 0},
+		{"update of rows added past MaxAffected", true, 100, 101, 0, true, 0},
+		{"update of rows removed after a confirmed count", true, 200, 199, 200, true, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &writeConn{matched: tt.matched, affected: tt.affected}
+			db := sqlx.NewDb(sql.OpenDB(writeConnector{conn}), "postgres")
+			defer db.Close()
+			repo := NewRepository(db)
+			check := func(n int64) error { return s.checkAffected(n, tt.confirmed, false) }
+
+			var affected int64
+			var err error
+			if tt.update {
+				affected, err = repo.UpdateWhere(context.Background(), collection, filters, map[string]any{"status": "published"}, map[string]any{}, check)
+			} else {
+				affected, err = repo.DeleteWhere(context.Background(), collection, filters, map[string]any{}, check)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("write error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if affected != tt.wantAffected {
+				t.Errorf("affected = %d, want %d", affected, tt.wantAffected)
+			}
+			if conn.committed == tt.wantErr {
+				t.Errorf("committed = %v, want %v", conn.committed, !tt.wantErr)
+			}
+			if tt.wantErr && !conn.rolledBack {
+				t.Error("write was not rolled back")
+			}
+		})
+	}
+}
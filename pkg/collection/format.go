@@ -0,0 +1,115 @@
+package collection
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/format"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// SetFormats enables XML and MessagePack responses and request bodies.
+func (h *Handler) SetFormats(formats format.Config) {
+	h.formats = formats
+}
+
+// write sends a response in the format negotiated from the Accept header,
+// with aliased columns under their alias.
+func (h *Handler) write(c *gin.Context, status int, resp response.Response) {
+	response.Negotiate(c, status, h.aliasResponse(c, resp), h.formats)
+}
+
+// invalidBody reports an XML or MessagePack body that is not a record or an
+// array of records.
+var invalidBody = apperror.ErrBadRequest.WithMessage("Invalid request body")
+
+// decodeRecords reads a record body, or an array of records, in JSON or an
+// enabled format, with aliases replaced by their column.
+func (h *Handler) decodeRecords(c *gin.Context, maxItems int) (map[string]any, []map[string]any, bool, error) {
+	data, items, batch, err := h.readRecords(c, maxItems)
+	if err != nil {
+		return data, items, batch, err
+	}
+	collection, ok := h.aliased(c)
+	if !ok {
+		return data, items, batch, nil
+	}
+	for i, item := range items {
+		items[i] = columnItem(collection, item)
+	}
+	return columnItem(collection, data), items, batch, nil
+}
+
+// readRecords reads a record body, or an array of records, in JSON or an
+// enabled format. XML values are converted to the type of their field.
+func (h *Handler) readRecords(c *gin.Context, maxItems int) (map[string]any, []map[string]any, bool, error) {
+	contentType := c.ContentType()
+	if !h.formats.Accepts(contentType) {
+		return decodeBody(c.Request.Body, maxItems)
+	}
+
+	v, err := format.Decode(contentType, c.Request.Body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			return nil, nil, false, err
+		}
+		return nil, nil, false, invalidBody
+	}
+
+	var col *schema.Collection
+	if contentType == format.MIMEXML || contentType == format.MIMEXML2 {
+		if col, err = h.service.schemaManager.GetCollection(c.Param("collection")); err != nil {
+			return nil, nil, false, err
+		}
+	}
+
+	switch val := v.(type) {
+	case map[string]any:
+		return coerceFields(col, val), nil, false, nil
+	case []any:
+		items := make([]map[string]any, 0, len(val))
+		for _, item := range val {
+			record, ok := item.(map[string]any)
+			if !ok {
+				return nil, nil, true, invalidBody
+			}
+			items = append(items, coerceFields(col, record))
+		}
+		return nil, items, true, nil
+	}
+	return nil, nil, false, invalidBody
+}
+
+// coerceFields converts string values of int, float and boolean fields.
+// Values that do not parse are left for validation to reject.
+func coerceFields(col *schema.Collection, record map[string]any) map[string]any {
+	if col == nil {
+		return record
+	}
+	for _, field := range col.Fields {
+		s, ok := record[field.Name].(string)
+		if !ok {
+			continue
+		}
+		switch field.DataType {
+		case "int":
+			if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+				record[field.Name] = n
+			}
+		case "float":
+			if f, err := strconv.ParseFloat(s, 64); err == nil {
+				record[field.Name] = f
+			}
+		case "boolean":
+			if b, err := strconv.ParseBool(s); err == nil {
+				record[field.Name] = b
+			}
+		}
+	}
+	return record
+}
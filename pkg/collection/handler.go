@@ -1,11 +1,20 @@
 package collection
 
 import (
+	"errors"
+	"fmt"
+	"io"
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/format"
+	"github.com/thienel/tugo/pkg/permission"
 	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/requestlog"
 	"github.com/thienel/tugo/pkg/response"
 	"go.uber.org/zap"
 )
@@ -14,6 +23,10 @@ import (
 type Handler struct {
 	service *Service
 	logger  *zap.SugaredLogger
+	formats format.Config
+
+	// overrides replace generated endpoints of single collections
+	overrides overrides
 }
 
 // NewHandler creates a new collection handler.
@@ -37,10 +50,16 @@ func (h *Handler) List(c *gin.Context) {
 	// Parse expand parameter
 	expand := query.ParseExpand(queryParams)
 
+	debug, ok := h.debugRequested(c)
+	if !ok {
+		return
+	}
+
 	result, err := h.service.List(c.Request.Context(), ListParams{
 		CollectionName: collectionName,
 		QueryParams:    queryParams,
 		Expand:         expand,
+		Debug:          debug,
 	})
 
 	if err != nil {
@@ -48,7 +67,54 @@ func (h *Handler) List(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response.SuccessList(result.Items, result.Pagination))
+	setPaginationHeaders(c, result.Pagination)
+	resp := response.SuccessList(result.Items, result.Pagination)
+	if result.Debug != nil {
+		result.Debug.Permission = debugPermission(c)
+		resp.Meta = map[string]any{"debug": result.Debug}
+	}
+	h.write(c, http.StatusOK, resp)
+}
+
+// Export handles GET /:collection/export requests.
+// Matching items are streamed as a JSON array; filters, sort and fields
+// apply as for List, without pagination.
+func (h *Handler) Export(c *gin.Context) {
+	queryParams := make(map[string][]string)
+	for k, v := range c.Request.URL.Query() {
+		queryParams[k] = v
+	}
+
+	started := false
+	err := h.service.Export(c.Request.Context(), ListParams{
+		CollectionName: c.Param("collection"),
+		QueryParams:    queryParams,
+	}, func(item []byte) error {
+		sep := []byte{','}
+		if !started {
+			started = true
+			c.Header("Content-Type", "application/json; charset=utf-8")
+			c.Status(http.StatusOK)
+			sep = []byte{'['}
+		}
+		if _, err := c.Writer.Write(sep); err != nil {
+			return err
+		}
+		_, err := c.Writer.Write(item)
+		return err
+	})
+
+	switch {
+	case err != nil && !started:
+		h.handleError(c, err)
+	case err != nil:
+		// The status is already sent; the truncated array signals the failure
+		requestlog.Logger(c.Request.Context(), h.logger).Errorw("Export failed", "collection", c.Param("collection"), "error", err)
+	case !started:
+		c.Data(http.StatusOK, "application/json; charset=utf-8", []byte("[]"))
+	default:
+		c.Writer.Write([]byte{']'})
+	}
 }
 
 // Get handles GET /:collection/:id requests.
@@ -69,18 +135,187 @@ func (h *Handler) Get(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response.Success(item))
+	h.write(c, http.StatusOK, response.Success(item))
+}
+
+// GetByField handles GET /:collection/by/:field/:value requests.
+func (h *Handler) GetByField(c *gin.Context) {
+	expand := query.ParseExpand(c.Request.URL.Query())
+
+	item, err := h.service.GetByField(c.Request.Context(), c.Param("collection"), c.Param("field"), c.Param("value"), expand)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(item))
+}
+
+// BatchGet handles GET /:collection/batch?ids=1,2,3 and POST /:collection/batch requests.
+// POST bodies take the form {"ids": [1, 2, 3]}.
+func (h *Handler) BatchGet(c *gin.Context) {
+	collectionName := c.Param("collection")
+
+	queryParams := make(map[string][]string)
+	for k, v := range c.Request.URL.Query() {
+		queryParams[k] = v
+	}
+	expand := query.ParseExpand(queryParams)
+
+	var ids []string
+	if c.Request.Method == http.MethodPost {
+		var body struct {
+			IDs []any `json:"ids"`
+		}
+		if err := c.ShouldBindJSON(&body); err != nil {
+			h.handleError(c, bodyError(err))
+			return
+		}
+		for _, id := range body.IDs {
+			ids = append(ids, formatID(id))
+		}
+	} else {
+		for _, id := range strings.Split(c.Query("ids"), ",") {
+			if id = strings.TrimSpace(id); id != "" {
+				ids = append(ids, id)
+			}
+		}
+	}
+
+	result, err := h.service.BatchGet(c.Request.Context(), collectionName, ids, expand)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(result))
+}
+
+// Reorder handles POST /:collection/reorder requests.
+// Bodies take the form {"ids": [3, 1, 2]}, listing items in their new order.
+func (h *Handler) Reorder(c *gin.Context) {
+	var body struct {
+		IDs []any `json:"ids"`
+	}
+	if err := c.ShouldBindJSON(&body); err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	ids := make([]string, len(body.IDs))
+	for i, id := range body.IDs {
+		ids[i] = formatID(id)
+	}
+
+	result, err := h.service.Reorder(c.Request.Context(), c.Param("collection"), ids)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(result))
+}
+
+// FindDuplicates handles POST /:collection/find-duplicates requests.
+// The body is the item about to be created; ?limit caps the results.
+func (h *Handler) FindDuplicates(c *gin.Context) {
+	data, _, batch, err := h.decodeRecords(c, 0)
+	if err == nil && batch {
+		err = errors.New("body is not an object")
+	}
+	if err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	params := FindDuplicatesParams{
+		CollectionName: c.Param("collection"),
+		Candidate:      data,
+	}
+	if limit := c.Query("limit"); limit != "" {
+		n, err := strconv.Atoi(limit)
+		if err != nil || n < 1 {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid limit"))
+			return
+		}
+		params.Limit = n
+	}
+	if result := permission.GetCheckResult(c); result != nil {
+		params.Scope = result.Filter
+	}
+
+	duplicates, err := h.service.FindDuplicates(c.Request.Context(), params)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(duplicates))
+}
+
+// Merge handles POST /:collection/merge requests.
+// Bodies take the form {"winner": 1, "losers": [2, 3]}, with optional
+// strategy, fields and delete settings.
+func (h *Handler) Merge(c *gin.Context) {
+	var opts MergeOptions
+	if err := c.ShouldBindJSON(&opts); err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+	if result := permission.GetCheckResult(c); result != nil {
+		opts.Scope = result.Filter
+	}
+
+	result, err := h.service.Merge(c.Request.Context(), c.Param("collection"), opts)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(result))
+}
+
+// itemParams returns the item named by a request, scoped by the caller's
+// row-level read permissions.
+func itemParams(c *gin.Context) ItemParams {
+	params := ItemParams{
+		CollectionName: c.Param("collection"),
+		ItemID:         c.Param("id"),
+	}
+	if result := permission.GetCheckResult(c); result != nil {
+		params.Scope = result.Filter
+	}
+	return params
+}
+
+// formatID converts a JSON ID value to its string form.
+func formatID(v any) string {
+	if f, ok := v.(float64); ok && f == float64(int64(f)) {
+		return strconv.FormatInt(int64(f), 10)
+	}
+	return fmt.Sprint(v)
 }
 
 // Create handles POST /:collection requests.
+// A JSON array body creates every item in one transaction and responds with
+// the number created.
 func (h *Handler) Create(c *gin.Context) {
 	collectionName := c.Param("collection")
 
-	var data map[string]any
-	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
-			apperror.ErrBadRequest.WithMessage("Invalid JSON body"),
-		))
+	data, items, batch, err := h.decodeRecords(c, h.service.maxBatchItems())
+	if err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	if batch {
+		created, err := h.service.CreateMany(c.Request.Context(), collectionName, items)
+		if err != nil {
+			h.handleError(c, err)
+			return
+		}
+
+		h.write(c, http.StatusCreated, response.Success(gin.H{"created": created}))
 		return
 	}
 
@@ -90,7 +325,7 @@ func (h *Handler) Create(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusCreated, response.Success(item))
+	h.write(c, http.StatusCreated, response.Success(item))
 }
 
 // Update handles PATCH /:collection/:id requests.
@@ -98,11 +333,12 @@ func (h *Handler) Update(c *gin.Context) {
 	collectionName := c.Param("collection")
 	id := c.Param("id")
 
-	var data map[string]any
-	if err := c.ShouldBindJSON(&data); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
-			apperror.ErrBadRequest.WithMessage("Invalid JSON body"),
-		))
+	data, _, batch, err := h.decodeRecords(c, 0)
+	if err == nil && batch {
+		err = errors.New("body is not an object")
+	}
+	if err != nil {
+		h.handleError(c, bodyError(err))
 		return
 	}
 
@@ -112,7 +348,80 @@ func (h *Handler) Update(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response.Success(item))
+	h.write(c, http.StatusOK, response.Success(item))
+}
+
+// UpdateMany handles PATCH /:collection requests, setting the fields of
+// the body on every item matching the filter[...] parameters. It requires
+// the admin role.
+func (h *Handler) UpdateMany(c *gin.Context) {
+	params, ok := h.filterParams(c)
+	if !ok {
+		return
+	}
+
+	data, _, batch, err := h.decodeRecords(c, 0)
+	if err == nil && batch {
+		err = errors.New("body is not an object")
+	}
+	if err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	result, err := h.service.UpdateMany(c.Request.Context(), params, data)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(result))
+}
+
+// filterParams returns the items a write by filter selects, writing an
+// error response when the caller is not an admin or the confirmed count or
+// dry run flag is invalid.
+func (h *Handler) filterParams(c *gin.Context) (FilterParams, bool) {
+	if user := auth.GetUser(c); user == nil || user.Role != "admin" {
+		h.handleError(c, apperror.ErrForbidden.WithMessage("Writes by filter require the admin role"))
+		return FilterParams{}, false
+	}
+
+	params := FilterParams{CollectionName: c.Param("collection"), QueryParams: c.Request.URL.Query()}
+	if raw := c.Query(ConfirmCountParam); raw != "" {
+		n, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || n < 1 {
+			h.handleError(c, apperror.ErrBadRequest.WithMessagef("Invalid %s value '%s'", ConfirmCountParam, raw))
+			return FilterParams{}, false
+		}
+		params.ConfirmCount = n
+	}
+	if raw := c.Query(DryRunParam); raw != "" {
+		dryRun, err := strconv.ParseBool(raw)
+		if err != nil {
+			h.handleError(c, apperror.ErrBadRequest.WithMessagef("%s must be true or false", DryRunParam))
+			return FilterParams{}, false
+		}
+		params.DryRun = dryRun
+	}
+	return params, true
+}
+
+// DeleteMany handles DELETE /:collection requests, deleting every item
+// matching the filter[...] parameters. It requires the admin role.
+func (h *Handler) DeleteMany(c *gin.Context) {
+	params, ok := h.filterParams(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.service.DeleteMany(c.Request.Context(), params)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(result))
 }
 
 // Delete handles DELETE /:collection/:id requests.
@@ -126,25 +435,203 @@ func (h *Handler) Delete(c *gin.Context) {
 		return
 	}
 
-	c.JSON(http.StatusOK, response.Success(nil))
+	h.write(c, http.StatusOK, response.Success(nil))
+}
+
+// Duplicate handles POST /:collection/:id/duplicate requests.
+// The body is optional and holds DuplicateOptions.
+func (h *Handler) Duplicate(c *gin.Context) {
+	collectionName := c.Param("collection")
+	id := c.Param("id")
+
+	var opts DuplicateOptions
+	if err := c.ShouldBindJSON(&opts); err != nil && !errors.Is(err, io.EOF) {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	item, err := h.service.Duplicate(c.Request.Context(), collectionName, id, opts)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusCreated, response.Success(item))
+}
+
+// Tree handles GET /:collection/tree?depth=n requests.
+func (h *Handler) Tree(c *gin.Context) {
+	h.tree(c, nil)
+}
+
+// Children handles GET /:collection/:id/children?depth=n requests.
+func (h *Handler) Children(c *gin.Context) {
+	h.tree(c, c.Param("id"))
+}
+
+// tree writes the tree below rootID, filtered by the caller's row-level permissions.
+func (h *Handler) tree(c *gin.Context, rootID any) {
+	params := TreeParams{
+		CollectionName: c.Param("collection"),
+		RootID:         rootID,
+	}
+
+	if depth := c.Query("depth"); depth != "" {
+		n, err := strconv.Atoi(depth)
+		if err != nil || n < 1 {
+			h.write(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("Invalid depth"),
+			))
+			return
+		}
+		params.Depth = n
+	}
+
+	if result := permission.GetCheckResult(c); result != nil {
+		params.Scope = result.Filter
+	}
+
+	items, err := h.service.Tree(c.Request.Context(), params)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(items))
+}
+
+// ListRevisions handles GET /:collection/:id/revisions requests.
+func (h *Handler) ListRevisions(c *gin.Context) {
+	revisions, err := h.service.ListRevisions(c.Request.Context(), c.Param("collection"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(revisions))
+}
+
+// GetRevision handles GET /:collection/:id/revisions/:rev requests.
+func (h *Handler) GetRevision(c *gin.Context) {
+	rev, ok := h.revisionParam(c)
+	if !ok {
+		return
+	}
+
+	revision, err := h.service.GetRevision(c.Request.Context(), c.Param("collection"), c.Param("id"), rev)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(revision))
+}
+
+// RestoreRevision handles POST /:collection/:id/revisions/:rev/restore requests.
+func (h *Handler) RestoreRevision(c *gin.Context) {
+	rev, ok := h.revisionParam(c)
+	if !ok {
+		return
+	}
+
+	item, err := h.service.RestoreRevision(c.Request.Context(), c.Param("collection"), c.Param("id"), rev)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(item))
+}
+
+// revisionParam parses the :rev route parameter, writing a 400 on failure.
+func (h *Handler) revisionParam(c *gin.Context) (int, bool) {
+	rev, err := strconv.Atoi(c.Param("rev"))
+	if err != nil || rev < 1 {
+		h.write(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Invalid revision number"),
+		))
+		return 0, false
+	}
+	return rev, true
 }
 
 // handleError converts errors to HTTP responses.
 func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if requestTimedOut(c.Request.Context()) {
+		err = timeoutError(c.Request.Context()).WithError(err)
+	}
 	if appErr, ok := apperror.AsAppError(err); ok {
-		c.JSON(appErr.HTTPStatus, response.FromAppError(appErr))
+		if lock, ok := appErr.Details.(Lock); ok {
+			c.Header("Retry-After", lock.RetryAfter())
+		}
+		if collection, ok := h.aliased(c); ok {
+			appErr = aliasError(collection, appErr)
+		}
+		h.write(c, appErr.HTTPStatus, response.FromAppError(appErr))
 		return
 	}
 
-	h.logger.Errorw("Unexpected error", "error", err)
-	c.JSON(http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	h.write(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
 }
 
 // RegisterRoutes registers collection routes on a Gin router group.
+// Requests may name the time zone of their timestamps with X-Timezone or tz,
+// and the locale of translated fields with locale or Accept-Language.
+// Collections with a RequestTimeout fail with 504 once it passes.
 func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
-	rg.GET("/:collection", h.List)
-	rg.POST("/:collection", h.Create)
-	rg.GET("/:collection/:id", h.Get)
-	rg.PATCH("/:collection/:id", h.Update)
-	rg.DELETE("/:collection/:id", h.Delete)
+	h.RegisterRoutesWithMiddleware(rg, Middleware{})
+}
+
+// RegisterRoutesWithMiddleware registers collection routes running the
+// middleware of single collections.
+func (h *Handler) RegisterRoutesWithMiddleware(rg *gin.RouterGroup, middleware Middleware) {
+	for name, byEndpoint := range middleware.Endpoints {
+		for endpoint := range byEndpoint {
+			if !endpoints[endpoint] {
+				h.logger.Warnw("Ignoring middleware of unknown collection endpoint", "collection", name, "endpoint", endpoint)
+			}
+		}
+	}
+	route := func(endpoint string, handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+		last := len(handlers) - 1
+		handlers[last] = h.endpoint(endpoint, handlers[last])
+		return middleware.route(endpoint, handlers...)
+	}
+
+	rg = rg.Group("", h.timezone, h.locale, h.publicReader, h.deadline, h.aliases)
+	rg.GET("/:collection", route(EndpointList, h.List)...)
+	rg.HEAD("/:collection", route(EndpointHeadList, h.HeadList)...)
+	rg.OPTIONS("/:collection", route(EndpointOptions, h.Options)...)
+	rg.POST("/:collection", route(EndpointCreate, h.limitBody, h.Create)...)
+	rg.PATCH("/:collection", route(EndpointUpdateMany, h.limitBody, h.UpdateMany)...)
+	rg.DELETE("/:collection", route(EndpointDeleteMany, h.DeleteMany)...)
+	rg.GET("/:collection/batch", route(EndpointBatchGet, h.BatchGet)...)
+	rg.GET("/:collection/tree", route(EndpointTree, h.Tree)...)
+	rg.GET("/:collection/export", route(EndpointExport, h.Export)...)
+	rg.GET("/:collection/by/:field/:value", route(EndpointGetByField, h.GetByField)...)
+	rg.POST("/:collection/batch", route(EndpointBatchGet, h.limitBody, h.BatchGet)...)
+	rg.POST("/:collection/reorder", route(EndpointReorder, h.limitBody, h.Reorder)...)
+	rg.POST("/:collection/find-duplicates", route(EndpointFindDuplicates, h.limitBody, h.FindDuplicates)...)
+	rg.POST("/:collection/merge", route(EndpointMerge, h.limitBody, h.Merge)...)
+	rg.POST("/:collection/query-token", route(EndpointQueryToken, h.QueryToken)...)
+	rg.GET("/:collection/:id", route(EndpointGet, h.Get)...)
+	rg.HEAD("/:collection/:id", route(EndpointHeadItem, h.HeadItem)...)
+	rg.OPTIONS("/:collection/:id", route(EndpointOptions, h.Options)...)
+	rg.PATCH("/:collection/:id", route(EndpointUpdate, h.limitBody, h.Update)...)
+	rg.DELETE("/:collection/:id", route(EndpointDelete, h.Delete)...)
+	rg.POST("/:collection/:id/duplicate", route(EndpointDuplicate, h.limitBody, h.Duplicate)...)
+	rg.POST("/:collection/:id/transition", route(EndpointTransition, h.limitBody, h.Transition)...)
+	rg.GET("/:collection/:id/children", route(EndpointChildren, h.Children)...)
+	rg.GET("/:collection/:id/raw/:field", route(EndpointRaw, h.Raw)...)
+	rg.GET("/:collection/:id/revisions", route(EndpointRevisions, h.ListRevisions)...)
+	rg.GET("/:collection/:id/revisions/:rev", route(EndpointRevision, h.GetRevision)...)
+	rg.POST("/:collection/:id/revisions/:rev/restore", route(EndpointRestore, h.RestoreRevision)...)
+	rg.GET("/:collection/:id/translations", route(EndpointTranslations, h.ListTranslations)...)
+	rg.GET("/:collection/:id/comments", route(EndpointComments, h.ListComments)...)
+	rg.POST("/:collection/:id/comments", route(EndpointAddComment, h.limitBody, h.AddComment)...)
+	rg.DELETE("/:collection/:id/comments/:comment_id", route(EndpointDeleteComment, h.DeleteComment)...)
+	rg.POST("/:collection/:id/favorite", route(EndpointFavorite, h.AddFavorite)...)
+	rg.DELETE("/:collection/:id/favorite", route(EndpointUnfavorite, h.RemoveFavorite)...)
 }
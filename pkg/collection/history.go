@@ -0,0 +1,216 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// ListRevisions returns an item's revisions, newest first.
+// Each revision carries the changes made by the version that replaced it.
+func (s *Service) ListRevisions(ctx context.Context, collectionName string, id string) ([]Revision, error) {
+	collection, err := s.historyCollection(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := s.revisions.List(ctx, collection.Name, id)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	// The newest revision was replaced by the live record (absent once deleted)
+	next := s.liveVersion(ctx, collection, id)
+	for i := range revisions {
+		revisions[i].Changes = diffRecords(revisions[i].Data, next)
+		next = revisions[i].Data
+	}
+
+	return revisions, nil
+}
+
+// GetRevision returns a single revision with its changes against the live record.
+func (s *Service) GetRevision(ctx context.Context, collectionName string, id string, revision int) (*Revision, error) {
+	collection, err := s.historyCollection(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	rev, err := s.revisions.Get(ctx, collection.Name, id, revision)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return nil, err
+		}
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	rev.Changes = diffRecords(rev.Data, s.liveVersion(ctx, collection, id))
+	return rev, nil
+}
+
+// RestoreRevision writes a revision's data back to the record.
+// Deleted records are re-created; the replaced version is itself recorded.
+func (s *Service) RestoreRevision(ctx context.Context, collectionName string, id string, revision int) (map[string]any, error) {
+	collection, err := s.historyCollection(ctx, collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	rev, err := s.revisions.Get(ctx, collection.Name, id, revision)
+	if err != nil {
+		if apperror.IsAppError(err) {
+			return nil, err
+		}
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+
+	data := filterFields(rev.Data, collection.Fields)
+
+	// A deleted record is created anew, checked like any other create
+	if s.liveVersion(ctx, collection, id) == nil {
+		return s.Create(ctx, collectionName, data)
+	}
+
+	// The lifecycle state changes through transitions only
+	delete(data, collection.PrimaryKey)
+	if collection.Lifecycle != nil {
+		delete(data, collection.Lifecycle.Field)
+		delete(data, collection.Lifecycle.PublishAtField)
+	}
+	return s.update(ctx, collectionName, id, data, RevisionActionRestore)
+}
+
+// historyCollection returns the collection if history is available for it.
+func (s *Service) historyCollection(ctx context.Context, collectionName string) (*schema.Collection, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if s.revisions == nil || !collection.History || hidesUnpublished(ctx, collection) {
+		return nil, apperror.ErrNotFound.WithMessagef("History is not enabled for collection '%s'", collectionName)
+	}
+	return collection, nil
+}
+
+// liveVersion returns the current record, or nil if it does not exist.
+func (s *Service) liveVersion(ctx context.Context, collection *schema.Collection, id string) map[string]any {
+	item, err := s.repo.GetByID(ctx, collection, id)
+	if err != nil {
+		return nil
+	}
+	return item
+}
+
+// RevisionFunc records the previous version of an item in tx, the
+// transaction writing the version that replaces it.
+type RevisionFunc func(ctx context.Context, tx *sqlx.Tx, id any, previous map[string]any) error
+
+// revisionFunc returns the RevisionFunc recording action on the request's
+// user's behalf, or nil when the collection keeps no history.
+func (s *Service) revisionFunc(ctx context.Context, collection *schema.Collection, action string) RevisionFunc {
+	if s.revisions == nil || !collection.History {
+		return nil
+	}
+
+	var userID *string
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		userID = &user.ID
+	}
+
+	return func(ctx context.Context, tx *sqlx.Tx, id any, previous map[string]any) error {
+		if err := s.revisions.Record(ctx, tx, collection.Name, fmt.Sprint(id), action, previous, userID); err != nil {
+			return apperror.ErrInternalServer.WithError(err)
+		}
+		return nil
+	}
+}
+
+// UpdateRecorded updates an item like Update, passing the version it
+// replaces to record in the same transaction. The item's row is locked
+// first, so concurrent writes record the versions they replace in turn.
+// A nil record makes it Update.
+func (r *Repository) UpdateRecorded(ctx context.Context, collection *schema.Collection, id any, data map[string]any, record RevisionFunc) (map[string]any, error) {
+	if record == nil {
+		return r.Update(ctx, collection, id, data)
+	}
+	if err := prepareValues(collection, data); err != nil {
+		return nil, err
+	}
+
+	var item map[string]any
+	err := r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		previous, err := r.getTx(ctx, tx, collection, id, true)
+		if err != nil {
+			return err
+		}
+		if err := r.updateTx(ctx, tx, collection, id, data); err != nil {
+			return err
+		}
+		if item, err = r.getTx(ctx, tx, collection, id, false); err != nil {
+			return err
+		}
+		return record(ctx, tx, id, previous)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// DeleteRecorded deletes an item like Delete and returns it, passing it to
+// record in the same transaction when record is non-nil.
+func (r *Repository) DeleteRecorded(ctx context.Context, collection *schema.Collection, id any, record RevisionFunc) (map[string]any, error) {
+	querySQL := query.BuildDeleteDialect(r.dialect, collection.TableName, collection.PrimaryKey)
+
+	var item map[string]any
+	err := r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		var err error
+		if item, err = r.getTx(ctx, tx, collection, id, true); err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, querySQL, id); err != nil {
+			return dbError(ctx, err)
+		}
+		if record == nil {
+			return nil
+		}
+		return record(ctx, tx, id, item)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// getTx retrieves an item in tx. With lock set, its row stays locked until
+// tx ends on dialects that lock rows; SQLite locks the whole database.
+func (r *Repository) getTx(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, id any, lock bool) (map[string]any, error) {
+	querySQL, _ := query.NewBuilder(collection.TableName).WithDialect(r.dialect).BuildSelectByID(collection.PrimaryKey)
+	if lock && r.dialect.Name() != dialect.SQLite {
+		querySQL += " FOR UPDATE"
+	}
+
+	item := make(map[string]any)
+	if err := tx.QueryRowxContext(ctx, querySQL, id).MapScan(item); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", id)
+		}
+		if isInvalidUUIDError(err) {
+			return nil, apperror.ErrBadRequest.WithMessagef("Invalid ID format: '%v'", id)
+		}
+		return nil, dbError(ctx, err)
+	}
+	normalizeMapValues(collection, item, r.encoder(ctx))
+	return item, nil
+}
@@ -0,0 +1,27 @@
+package collection
+
+import (
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/idgen"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// SetIDGenerator sets the generator of primary keys for collections with
+// an ID strategy.
+func (s *Service) SetIDGenerator(ids *idgen.Generator) {
+	s.ids = ids
+}
+
+// fillID generates the primary key of an item being created when the
+// collection has an ID strategy and the client sent no key.
+func (s *Service) fillID(collection *schema.Collection, data map[string]any) error {
+	if collection.IDGeneration == nil || data[collection.PrimaryKey] != nil {
+		return nil
+	}
+	id, err := s.ids.Generate(collection.IDGeneration.Strategy)
+	if err != nil {
+		return apperror.ErrInternalServer.WithError(err)
+	}
+	data[collection.PrimaryKey] = id
+	return nil
+}
@@ -0,0 +1,50 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// checkImmutable rejects update data that changes one of the collection's
+// immutable fields. Sending the stored value again is allowed, so full
+// records such as revision restores pass unchanged.
+func (s *Service) checkImmutable(ctx context.Context, collection *schema.Collection, id any, data map[string]any) error {
+	var present []string
+	for _, name := range collection.ImmutableFields {
+		if _, ok := data[name]; ok {
+			present = append(present, name)
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+
+	current, err := s.repo.GetByID(ctx, collection, id)
+	if err != nil {
+		return err
+	}
+
+	errs := &validation.ValidationErrors{}
+	for _, name := range present {
+		if !sameValue(current[name], data[name]) {
+			errs.Add(name, "cannot be changed after creation", "immutable")
+		}
+	}
+	if errs.HasErrors() {
+		return apperror.ErrValidation.WithMessage(errs.Error()).WithDetails(errs.Errors)
+	}
+	return nil
+}
+
+// sameValue compares a stored value with one from a request body, which
+// may differ in Go type for the same number or string.
+func sameValue(stored, given any) bool {
+	if stored == nil || given == nil {
+		return stored == nil && given == nil
+	}
+	return fmt.Sprint(stored) == fmt.Sprint(given)
+}
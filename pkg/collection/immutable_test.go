@@ -0,0 +1,32 @@
+package collection
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestSameValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		stored any
+		given  any
+		want   bool
+	}{
+		{"equal strings", "acme", "acme", true},
+		{"different strings", "acme", "other", false},
+		{"int and float", int64(7), float64(7), true},
+		{"int and json number", int64(7), json.Number("7"), true},
+		{"different numbers", int64(7), float64(8), false},
+		{"both nil", nil, nil, true},
+		{"nil stored", nil, "acme", false},
+		{"nil given", "acme", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sameValue(tt.stored, tt.given); got != tt.want {
+				t.Errorf("sameValue(%v, %v) = %v, want %v", tt.stored, tt.given, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,101 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/inbox"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// SetInbox sends in-app notifications for mentions and replies in
+// comments, lifecycle transitions and reviews of pending changes.
+func (s *Service) SetInbox(notifications *inbox.Inbox) {
+	s.inbox = notifications
+}
+
+// actorName returns the username of the request's user, for notification
+// titles.
+func actorName(ctx context.Context) string {
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil && user.Username != "" {
+		return user.Username
+	}
+	return "Someone"
+}
+
+// itemNotification returns a notification about an item.
+func itemNotification(kind, title, body, collection, itemID string) inbox.Notification {
+	return inbox.Notification{
+		Type:       kind,
+		Title:      title,
+		Body:       body,
+		Collection: &collection,
+		ItemID:     &itemID,
+	}
+}
+
+// notifyComment tells the users mentioned in a comment, and the author of
+// the comment it replies to.
+func (s *Service) notifyComment(ctx context.Context, comment *Comment, parent *Comment) {
+	if s.inbox == nil {
+		return
+	}
+	actor := actorName(ctx)
+	data := map[string]any{"comment_id": comment.ID}
+
+	if len(comment.Mentions) > 0 {
+		n := itemNotification(inbox.TypeMention, actor+" mentioned you", comment.Body, comment.Collection, comment.ItemID)
+		n.Data = data
+		s.inbox.NotifyUsernames(ctx, comment.Mentions, n)
+	}
+	if parent != nil && parent.UserID != nil {
+		n := itemNotification(inbox.TypeReply, actor+" replied to your comment", comment.Body, comment.Collection, comment.ItemID)
+		n.Data = data
+		s.inbox.Notify(ctx, []string{*parent.UserID}, n)
+	}
+}
+
+// notifyTransition tells the creator of an item that it moved to another
+// state.
+func (s *Service) notifyTransition(ctx context.Context, collection *schema.Collection, id any, item map[string]any, from, to string, scheduled bool) {
+	if s.inbox == nil || collection.AutoFields.CreatedBy == "" {
+		return
+	}
+	creator := normalizeValue(item[collection.AutoFields.CreatedBy])
+	if creator == nil {
+		return
+	}
+
+	title := fmt.Sprintf("%s moved your %s item from '%s' to '%s'", actorName(ctx), collection.Name, from, to)
+	if scheduled {
+		title = fmt.Sprintf("%s scheduled your %s item for '%s'", actorName(ctx), collection.Name, to)
+	}
+	n := itemNotification(inbox.TypeTransition, title, "", collection.Name, formatID(id))
+	n.Data = map[string]any{"from": from, "to": to}
+	s.inbox.Notify(ctx, []string{fmt.Sprint(creator)}, n)
+}
+
+// notifyReview tells the submitter of a pending change that it was
+// reviewed. itemID is the ID of the written item, nil when rejected.
+func (s *Service) notifyReview(ctx context.Context, change *PendingChange, itemID any) {
+	if s.inbox == nil || change.SubmittedBy == nil {
+		return
+	}
+
+	n := inbox.Notification{
+		Type:       inbox.TypeApproval,
+		Title:      fmt.Sprintf("%s %s your change to %s", actorName(ctx), change.Status, change.Collection),
+		Collection: &change.Collection,
+		Data:       map[string]any{"change_id": change.ID, "status": change.Status},
+	}
+	if change.ReviewNote != nil {
+		n.Body = *change.ReviewNote
+	}
+	n.ItemID = change.ItemID
+	if itemID != nil {
+		id := formatID(itemID)
+		n.ItemID = &id
+	}
+	s.inbox.Notify(ctx, []string{*change.SubmittedBy}, n)
+}
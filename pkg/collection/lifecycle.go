@@ -0,0 +1,327 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// DefaultPublishInterval is the time between checks for scheduled items
+// due to be published.
+const DefaultPublishInterval = time.Minute
+
+// publicReaderKey is the context key marking anonymous readers of public
+// collections.
+type publicReaderKey struct{}
+
+// WithPublicReader returns a context of an anonymous request to a public
+// collection. Such readers see published items of lifecycle collections
+// only.
+func WithPublicReader(ctx context.Context) context.Context {
+	return context.WithValue(ctx, publicReaderKey{}, true)
+}
+
+// isPublicReader reports whether ctx belongs to an anonymous reader of a
+// public collection.
+func isPublicReader(ctx context.Context) bool {
+	public, _ := ctx.Value(publicReaderKey{}).(bool)
+	return public
+}
+
+// hidesUnpublished reports whether the reader of ctx sees only the
+// published items of collection.
+func hidesUnpublished(ctx context.Context, collection *schema.Collection) bool {
+	return collection.Lifecycle != nil && isPublicReader(ctx)
+}
+
+// publishedFilter returns the list filter matching published items.
+func publishedFilter(collection *schema.Collection) query.Filter {
+	return query.Filter{Field: collection.Lifecycle.Field, Operator: query.OpEqual, Value: collection.Lifecycle.Published}
+}
+
+// publishedScope adds the published state to a row-level permission
+// filter.
+func publishedScope(collection *schema.Collection, scope map[string]any) map[string]any {
+	return permission.ApplyPermissionFilter(scope, map[string]any{
+		collection.Lifecycle.Field: map[string]any{"_eq": collection.Lifecycle.Published},
+	})
+}
+
+// stateOf returns the lifecycle state of an item.
+func stateOf(collection *schema.Collection, item map[string]any) string {
+	switch v := normalizeValue(item[collection.Lifecycle.Field]).(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// visible reports whether the reader of ctx may see item.
+func visible(ctx context.Context, collection *schema.Collection, item map[string]any) bool {
+	return !hidesUnpublished(ctx, collection) || stateOf(collection, item) == collection.Lifecycle.Published
+}
+
+// userRole returns the role of the request's user, or "" without one.
+func userRole(ctx context.Context) string {
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		return user.Role
+	}
+	return ""
+}
+
+// checkLifecycleCreate fills in the initial state of a new item. Another
+// state is allowed when the user's role may move items there from the
+// initial state. The publish time is set by transitions only.
+func checkLifecycleCreate(ctx context.Context, collection *schema.Collection, data map[string]any, now time.Time) error {
+	lifecycle := collection.Lifecycle
+	if lifecycle == nil {
+		return nil
+	}
+
+	errs := &validation.ValidationErrors{}
+	if lifecycle.PublishAtField != "" && data[lifecycle.PublishAtField] != nil {
+		errs.Add(lifecycle.PublishAtField, "is set by transitions", "lifecycle")
+	}
+
+	state := stateOf(collection, data)
+	switch {
+	case state == "":
+		data[lifecycle.Field] = lifecycle.Initial()
+		state = lifecycle.Initial()
+	case !lifecycle.HasState(state):
+		errs.Add(lifecycle.Field, fmt.Sprintf("must be one of %v", lifecycle.States), "lifecycle")
+	case state != lifecycle.Initial():
+		if t := lifecycle.Transition(lifecycle.Initial(), state); t == nil || !t.Permits(userRole(ctx)) {
+			errs.Add(lifecycle.Field, fmt.Sprintf("new items start as '%s'", lifecycle.Initial()), "lifecycle")
+		}
+	}
+	if errs.HasErrors() {
+		return apperror.ErrValidation.WithMessage(errs.Error()).WithDetails(errs.Errors)
+	}
+
+	if state == lifecycle.Published && lifecycle.PublishAtField != "" {
+		data[lifecycle.PublishAtField] = now
+	}
+	return nil
+}
+
+// checkLifecycleUpdate rejects update data changing the lifecycle fields,
+// which change only through transitions. Sending the stored value again is
+// allowed.
+func (s *Service) checkLifecycleUpdate(ctx context.Context, collection *schema.Collection, id any, data map[string]any) error {
+	lifecycle := collection.Lifecycle
+	if lifecycle == nil {
+		return nil
+	}
+
+	var present []string
+	for _, name := range []string{lifecycle.Field, lifecycle.PublishAtField} {
+		if _, ok := data[name]; ok && name != "" {
+			present = append(present, name)
+		}
+	}
+	if len(present) == 0 {
+		return nil
+	}
+
+	current, err := s.repo.GetByID(ctx, collection, id)
+	if err != nil {
+		return err
+	}
+
+	errs := &validation.ValidationErrors{}
+	for _, name := range present {
+		if !sameValue(current[name], data[name]) {
+			errs.Add(name, "changes through POST /:collection/:id/transition", "lifecycle")
+		}
+	}
+	if errs.HasErrors() {
+		return apperror.ErrValidation.WithMessage(errs.Error()).WithDetails(errs.Errors)
+	}
+	return nil
+}
+
+// TransitionParams holds the parameters of a lifecycle transition.
+type TransitionParams struct {
+	CollectionName string
+	ID             any
+
+	// To is the new state.
+	To string
+
+	// At schedules publishing for a later time. The item keeps its state
+	// until then.
+	At *time.Time
+}
+
+// Transition moves an item to another lifecycle state, if the collection
+// allows the transition for the user's role.
+func (s *Service) Transition(ctx context.Context, params TransitionParams) (map[string]any, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	lifecycle := collection.Lifecycle
+	if lifecycle == nil {
+		return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no status lifecycle", params.CollectionName)
+	}
+	if !lifecycle.HasState(params.To) {
+		return nil, apperror.ErrBadRequest.WithMessagef("Unknown state '%s'", params.To)
+	}
+	if params.At != nil && (params.To != lifecycle.Published || lifecycle.PublishAtField == "") {
+		return nil, apperror.ErrBadRequest.WithMessage("Only publishing can be scheduled, in collections with a publish_at field")
+	}
+
+	item, err := s.repo.GetByID(ctx, collection, params.ID)
+	if err != nil {
+		return nil, err
+	}
+	from := stateOf(collection, item)
+	if from == params.To {
+		return nil, apperror.ErrConflict.WithMessagef("Item is already '%s'", from)
+	}
+	t := lifecycle.Transition(from, params.To)
+	if t == nil {
+		return nil, apperror.ErrConflict.WithMessagef("Items cannot move from '%s' to '%s'", from, params.To)
+	}
+	if !t.Permits(userRole(ctx)) {
+		return nil, apperror.ErrForbidden.WithMessagef("Your role cannot move items from '%s' to '%s'", from, params.To)
+	}
+
+	now := time.Now().UTC()
+	data := make(map[string]any)
+	scheduled := params.At != nil && params.At.After(now)
+	if scheduled {
+		data[lifecycle.PublishAtField] = params.At.UTC()
+	} else {
+		data[lifecycle.Field] = params.To
+		if lifecycle.PublishAtField != "" {
+			if params.To == lifecycle.Published {
+				data[lifecycle.PublishAtField] = now
+			} else {
+				data[lifecycle.PublishAtField] = nil
+			}
+		}
+	}
+	fillAutoFields(ctx, collection, data, false, now)
+
+	if item, err = s.repo.UpdateRecorded(ctx, collection, params.ID, data, s.revisionFunc(ctx, collection, RevisionActionUpdate)); err != nil {
+		return nil, err
+	}
+	if err := s.translate(ctx, collection, []map[string]any{item}); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, collection, NotifyActionUpdate, item)
+	s.notifyTransition(ctx, collection, params.ID, item, from, params.To, scheduled)
+	return item, nil
+}
+
+// PublishScheduled publishes the items of lifecycle collections whose
+// publish time has passed, returning the number published.
+func (s *Service) PublishScheduled(ctx context.Context) (int64, error) {
+	now := time.Now().UTC()
+	var total int64
+	for _, collection := range s.schemaManager.GetCollections() {
+		if collection.Lifecycle == nil || collection.Lifecycle.PublishAtField == "" {
+			continue
+		}
+		// Items of locked collections are published once unlocked
+		if s.checkUnlocked(collection) != nil {
+			continue
+		}
+		n, err := s.repo.PublishDue(ctx, collection, now)
+		if err != nil {
+			return total, fmt.Errorf("failed to publish scheduled items of %s: %w", collection.Name, err)
+		}
+		total += n
+	}
+	return total, nil
+}
+
+// PublishDue sets the published state on items whose publish time is at
+// or before now.
+func (r *Repository) PublishDue(ctx context.Context, collection *schema.Collection, now time.Time) (int64, error) {
+	lifecycle := collection.Lifecycle
+	quote := r.dialect.QuoteIdent
+
+	set := quote(lifecycle.Field) + " = ?"
+	args := []any{lifecycle.Published}
+	if column := collection.AutoFields.UpdatedAt; column != "" {
+		set += ", " + quote(column) + " = ?"
+		args = append(args, now)
+	}
+	querySQL := r.db.Rebind(fmt.Sprintf("UPDATE %s SET %s WHERE %s <= ? AND (%s IS NULL OR %s <> ?)",
+		quote(collection.TableName), set, quote(lifecycle.PublishAtField), quote(lifecycle.Field), quote(lifecycle.Field)))
+	args = append(args, now, lifecycle.Published)
+
+	var published int64
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		res, err := q.ExecContext(ctx, querySQL, args...)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		published, _ = res.RowsAffected()
+		return nil
+	})
+	return published, err
+}
+
+// TransitionRequest is the body of POST /:collection/:id/transition.
+type TransitionRequest struct {
+	To string     `json:"to" binding:"required"`
+	At *time.Time `json:"at"`
+}
+
+// publicReader marks anonymous requests let through by a collection's
+// public actions.
+func (h *Handler) publicReader(c *gin.Context) {
+	if c.GetBool(string(permission.PublicAccessKey)) {
+		if user, ok := auth.GetUserFromContext(c.Request.Context()); !ok || user == nil {
+			c.Request = c.Request.WithContext(WithPublicReader(c.Request.Context()))
+		}
+	}
+	c.Next()
+}
+
+// Transition handles POST /:collection/:id/transition requests.
+// Bodies take the form {"to": "published"}, with an optional RFC 3339
+// "at" time scheduling publication.
+func (h *Handler) Transition(c *gin.Context) {
+	var req TransitionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		h.handleError(c, bodyError(err))
+		return
+	}
+
+	item, err := h.service.Transition(c.Request.Context(), TransitionParams{
+		CollectionName: c.Param("collection"),
+		ID:             c.Param("id"),
+		To:             req.To,
+		At:             req.At,
+	})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(item))
+}
@@ -0,0 +1,88 @@
+package collection
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestCheckLifecycleCreate(t *testing.T) {
+	lifecycle := &schema.Lifecycle{
+		Field:     "status",
+		States:    []string{"draft", "review", "published"},
+		Published: "published",
+		Transitions: []schema.Transition{
+			{From: "draft", To: "review"},
+			{From: "draft", To: "published", Roles: []string{"editor"}},
+		},
+		PublishAtField: "publish_at",
+	}
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		role      string
+		data      map[string]any
+		wantState string
+		published bool
+		wantErr   bool
+	}{
+		{"initial state filled in", "user", map[string]any{"title": "a"}, "draft", false, false},
+		{"initial state given", "user", map[string]any{"status": "draft"}, "draft", false, false},
+		{"open transition", "user", map[string]any{"status": "review"}, "review", false, false},
+		{"role transition", "editor", map[string]any{"status": "published"}, "published", true, false},
+		{"admin", "admin", map[string]any{"status": "published"}, "published", true, false},
+		{"forbidden role", "user", map[string]any{"status": "published"}, "", false, true},
+		{"unknown state", "admin", map[string]any{"status": "archived"}, "", false, true},
+		{"publish time", "admin", map[string]any{"publish_at": now}, "", false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := auth.SetUserInContext(context.Background(), &auth.User{ID: "u1", Role: tt.role})
+			collection := &schema.Collection{Name: "posts", Lifecycle: lifecycle}
+			err := checkLifecycleCreate(ctx, collection, tt.data, now)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkLifecycleCreate() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got := tt.data["status"]; got != tt.wantState {
+				t.Errorf("status = %v, want %v", got, tt.wantState)
+			}
+			if _, ok := tt.data["publish_at"]; ok != tt.published {
+				t.Errorf("publish_at set = %v, want %v", ok, tt.published)
+			}
+		})
+	}
+}
+
+func TestVisible(t *testing.T) {
+	collection := &schema.Collection{Name: "posts", Lifecycle: &schema.Lifecycle{Field: "status", Published: "published"}}
+	public := WithPublicReader(context.Background())
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		item map[string]any
+		want bool
+	}{
+		{"public published", public, map[string]any{"status": "published"}, true},
+		{"public published bytes", public, map[string]any{"status": []byte("published")}, true},
+		{"public draft", public, map[string]any{"status": "draft"}, false},
+		{"public missing state", public, map[string]any{}, false},
+		{"authenticated draft", context.Background(), map[string]any{"status": "draft"}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visible(tt.ctx, collection, tt.item); got != tt.want {
+				t.Errorf("visible() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package collection
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// DefaultLockDuration is how long a collection stays locked when no
+// duration is given.
+const DefaultLockDuration = 15 * time.Minute
+
+// Lock freezes writes to a collection, such as while a migration or
+// backfill runs, until it is released or Until passes.
+type Lock struct {
+	Collection string    `json:"collection"`
+	Reason     string    `json:"reason,omitempty"`
+	Until      time.Time `json:"until"`
+}
+
+// LockFunc is called when a collection is locked or unlocked. An unlocked
+// collection has a zero Until.
+type LockFunc func(lock Lock)
+
+// Locks holds the write locks of collections.
+type Locks struct {
+	mu       sync.Mutex
+	locks    map[string]Lock
+	onChange LockFunc
+}
+
+// NewLocks creates an empty lock table.
+func NewLocks() *Locks {
+	return &Locks{locks: make(map[string]Lock)}
+}
+
+// OnChange sets a function called when a collection is locked or
+// unlocked, such as one telling other instances.
+func (l *Locks) OnChange(fn LockFunc) {
+	l.onChange = fn
+}
+
+// Lock freezes writes to a collection for d, or DefaultLockDuration when d
+// is not positive. Locking a locked collection replaces its lock.
+func (l *Locks) Lock(collection, reason string, d time.Duration) Lock {
+	if d <= 0 {
+		d = DefaultLockDuration
+	}
+	lock := Lock{Collection: collection, Reason: reason, Until: time.Now().Add(d).UTC()}
+	l.Set(lock)
+	if l.onChange != nil {
+		l.onChange(lock)
+	}
+	return lock
+}
+
+// Unlock releases a collection's lock, reporting whether it was locked.
+func (l *Locks) Unlock(collection string) bool {
+	_, locked := l.Get(collection)
+	l.Set(Lock{Collection: collection})
+	if locked && l.onChange != nil {
+		l.onChange(Lock{Collection: collection})
+	}
+	return locked
+}
+
+// Set applies a lock, such as one taken on another instance, without
+// calling the OnChange function. A lock with a zero Until unlocks.
+func (l *Locks) Set(lock Lock) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if lock.Until.IsZero() {
+		delete(l.locks, lock.Collection)
+		return
+	}
+	l.locks[lock.Collection] = lock
+}
+
+// Get returns a collection's lock, if it is locked.
+func (l *Locks) Get(collection string) (Lock, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	lock, ok := l.locks[collection]
+	if ok && !time.Now().Before(lock.Until) {
+		delete(l.locks, collection)
+		return Lock{}, false
+	}
+	return lock, ok
+}
+
+// List returns the locks in force, ordered by collection.
+func (l *Locks) List() []Lock {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	now := time.Now()
+	locks := make([]Lock, 0, len(l.locks))
+	for name, lock := range l.locks {
+		if !now.Before(lock.Until) {
+			delete(l.locks, name)
+			continue
+		}
+		locks = append(locks, lock)
+	}
+	slices.SortFunc(locks, func(a, b Lock) int { return strings.Compare(a.Collection, b.Collection) })
+	return locks
+}
+
+// Hold locks a collection for at most d while fn runs, and unlocks it when
+// fn returns. A collection already locked stays locked as it was.
+func (l *Locks) Hold(ctx context.Context, collection, reason string, d time.Duration, fn func(ctx context.Context) error) error {
+	if _, locked := l.Get(collection); locked {
+		return fn(ctx)
+	}
+	l.Lock(collection, reason, d)
+	defer l.Unlock(collection)
+	return fn(ctx)
+}
+
+// RetryAfter returns the whole seconds until the lock expires, for the
+// Retry-After header.
+func (lock Lock) RetryAfter() string {
+	return strconv.FormatInt(int64(time.Until(lock.Until).Seconds())+1, 10)
+}
+
+// SetLocks sets the lock table whose locks freeze writes.
+func (s *Service) SetLocks(locks *Locks) {
+	s.locks = locks
+}
+
+// checkUnlocked fails with apperror.ErrLocked when the collection is
+// locked.
+func (s *Service) checkUnlocked(collection *schema.Collection) error {
+	if s.locks == nil {
+		return nil
+	}
+	lock, locked := s.locks.Get(collection.Name)
+	if !locked {
+		return nil
+	}
+	return apperror.ErrLocked.WithMessagef("Collection '%s' is locked for writes", collection.Name).WithDetails(lock)
+}
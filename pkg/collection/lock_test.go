@@ -0,0 +1,111 @@
+package collection
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+func TestLocks(t *testing.T) {
+	var changes []Lock
+	locks := NewLocks()
+	locks.OnChange(func(lock Lock) { changes = append(changes, lock) })
+
+	locks.Lock("orders", "backfill", time.Hour)
+	locks.Set(Lock{Collection: "expired", Until: time.Now().Add(-time.Second)})
+	locks.Set(Lock{Collection: "remote", Until: time.Now().Add(time.Hour)})
+
+	tests := []struct {
+		collection string
+		wantLocked bool
+	}{
+		{"orders", true},
+		{"remote", true},
+		{"expired", false},
+		{"users", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			if _, locked := locks.Get(tt.collection); locked != tt.wantLocked {
+				t.Errorf("Get() locked = %v, want %v", locked, tt.wantLocked)
+			}
+		})
+	}
+
+	if got := locks.List(); len(got) != 2 || got[0].Collection != "orders" || got[1].Collection != "remote" {
+		t.Errorf("List() = %+v, want orders and remote", got)
+	}
+	if !locks.Unlock("orders") || locks.Unlock("orders") {
+		t.Error("Unlock() should report only the first release")
+	}
+	if len(changes) != 2 || changes[0].Reason != "backfill" || !changes[1].Until.IsZero() {
+		t.Errorf("changes = %+v, want the lock and the release", changes)
+	}
+}
+
+func TestLocksHold(t *testing.T) {
+	locks := NewLocks()
+
+	err := locks.Hold(context.Background(), "orders", "migration", time.Hour, func(context.Context) error {
+		if _, locked := locks.Get("orders"); !locked {
+			t.Error("collection not locked while held")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, locked := locks.Get("orders"); locked {
+		t.Error("collection still locked after Hold returned")
+	}
+
+	// A lock taken before stays after Hold returns
+	locks.Lock("orders", "backfill", time.Hour)
+	_ = locks.Hold(context.Background(), "orders", "migration", time.Hour, func(context.Context) error { return nil })
+	if lock, locked := locks.Get("orders"); !locked || lock.Reason != "backfill" {
+		t.Errorf("Get() = %+v, %v, want the earlier lock", lock, locked)
+	}
+}
+
+func TestHandleErrorLocked(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{logger: zap.NewNop().Sugar()}
+	s := &Service{locks: NewLocks()}
+	s.locks.Lock("orders", "backfill", 90*time.Second)
+
+	router := gin.New()
+	router.POST("/:collection", func(c *gin.Context) {
+		if err := s.checkUnlocked(&schema.Collection{Name: c.Param("collection")}); err != nil {
+			h.handleError(c, err)
+			return
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	tests := []struct {
+		collection     string
+		wantStatus     int
+		wantRetryAfter string
+	}{
+		{"orders", http.StatusLocked, "90"},
+		{"users", http.StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/"+tt.collection, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", rec.Code, tt.wantStatus)
+			}
+			if got := rec.Header().Get("Retry-After"); got != tt.wantRetryAfter {
+				t.Errorf("Retry-After = %q, want %q", got, tt.wantRetryAfter)
+			}
+		})
+	}
+}
@@ -0,0 +1,483 @@
+package collection
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Merge strategies, deciding which values the winner of a merge keeps.
+const (
+	// MergeFill fills the winner's empty fields from the losers, in order.
+	MergeFill = "fill"
+
+	// MergeKeep keeps the winner's fields as they are.
+	MergeKeep = "keep"
+
+	// MergeOverwrite replaces the winner's fields with the first loser
+	// value that is not null.
+	MergeOverwrite = "overwrite"
+)
+
+// How the losers of a merge are removed.
+const (
+	MergeDeleteSoft = "soft"
+	MergeDeleteHard = "hard"
+)
+
+// SoftDeleteField is the timestamp column set on losers of a soft-deleting merge.
+const SoftDeleteField = "deleted_at"
+
+// AuditMergeAction is the tugo_audit_log action of a merge.
+const AuditMergeAction = "collection.merge"
+
+// maxMergeLosers bounds the items merged into a winner at once.
+const maxMergeLosers = 100
+
+// MergeOptions selects the items of a merge and how they are combined.
+type MergeOptions struct {
+	// Winner is the ID of the item that is kept.
+	Winner any `json:"winner"`
+
+	// Losers are the IDs of the items merged into the winner and removed.
+	Losers []any `json:"losers"`
+
+	// Strategy is "fill" (default), "keep" or "overwrite".
+	Strategy string `json:"strategy"`
+
+	// Fields takes the values of the given fields from the item with the
+	// given ID, whatever the strategy.
+	Fields map[string]any `json:"fields"`
+
+	// Delete is "soft" to set deleted_at on the losers or "hard" to delete
+	// them. Defaults to "soft" when the collection has a deleted_at field.
+	Delete string `json:"delete"`
+
+	// Scope is a row-level permission filter the winner and the losers must
+	// match.
+	Scope map[string]any `json:"-"`
+}
+
+// MergeResponse holds the result of a merge.
+type MergeResponse struct {
+	Item map[string]any `json:"item"`
+
+	// Merged is the number of losers removed.
+	Merged int `json:"merged"`
+
+	// Relinked counts the child records moved to the winner, by
+	// collection and field, as in "orders.customer_id".
+	Relinked map[string]int64 `json:"relinked"`
+
+	// Delete is how the losers were removed.
+	Delete string `json:"delete"`
+}
+
+// mergeChild is a foreign key to the merged collection and the values it is
+// re-pointed from and to.
+type mergeChild struct {
+	collection *schema.Collection
+	field      string
+	from       []any
+	to         any
+}
+
+// mergePlan holds the statements of a merge.
+type mergePlan struct {
+	winner   any
+	losers   []any
+	data     map[string]any
+	children []mergeChild
+	soft     bool
+	now      time.Time
+	audit    map[string]any
+
+	// record stores the merged records' revisions, nil without history
+	record func(ctx context.Context, tx *sqlx.Tx) error
+}
+
+// Merge merges the losers into the winner in one transaction: child records
+// referencing a loser are re-pointed to the winner, the winner takes field
+// values from the losers by strategy, the losers are soft-deleted or deleted
+// and the merge is recorded in tugo_audit_log.
+func (s *Service) Merge(ctx context.Context, collectionName string, opts MergeOptions) (*MergeResponse, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	switch opts.Strategy {
+	case "":
+		opts.Strategy = MergeFill
+	case MergeFill, MergeKeep, MergeOverwrite:
+	default:
+		return nil, apperror.ErrBadRequest.WithMessagef("Invalid strategy '%s'; use 'fill', 'keep' or 'overwrite'", opts.Strategy)
+	}
+	soft := fieldType(collection, SoftDeleteField) != ""
+	switch opts.Delete {
+	case "":
+	case MergeDeleteSoft:
+		if !soft {
+			return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no %s field to soft-delete with", collectionName, SoftDeleteField)
+		}
+	case MergeDeleteHard:
+		soft = false
+	default:
+		return nil, apperror.ErrBadRequest.WithMessagef("Invalid delete mode '%s'; use 'soft' or 'hard'", opts.Delete)
+	}
+
+	if opts.Winner == nil {
+		return nil, apperror.ErrBadRequest.WithMessage("A winner ID is required")
+	}
+	if len(opts.Losers) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("At least one loser ID is required")
+	}
+	if len(opts.Losers) > maxMergeLosers {
+		return nil, apperror.ErrBadRequest.WithMessagef("At most %d items may be merged at once", maxMergeLosers)
+	}
+	ids := []string{formatID(opts.Winner)}
+	for _, id := range opts.Losers {
+		ids = append(ids, formatID(id))
+	}
+	if len(uniqueStrings(ids)) != len(ids) {
+		return nil, apperror.ErrBadRequest.WithMessage("IDs must not repeat, and the winner may not be a loser")
+	}
+
+	found, err := s.repo.GetByIDs(ctx, collection, ids)
+	if err != nil {
+		return nil, err
+	}
+	records := make([]map[string]any, len(ids))
+	missing := make([]string, 0)
+	for i, id := range ids {
+		if records[i] = found[id]; records[i] == nil {
+			missing = append(missing, id)
+		}
+	}
+	if len(missing) == 0 && len(opts.Scope) > 0 {
+		scope, scopeArgs := permission.NewFilterBuilder(0).WithDialect(s.repo.dialect).Build(opts.Scope)
+		inScope, err := s.repo.IDsInScope(ctx, collection, ids, scope, scopeArgs)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			if !inScope[id] {
+				missing = append(missing, id)
+			}
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apperror.ErrNotFound.WithMessagef("Items not found: %v", missing)
+	}
+	winner, losers := records[0], records[1:]
+
+	data, err := mergeFields(collection, ids, records, opts)
+	if err != nil {
+		return nil, err
+	}
+	if validationErr := normalizeNumbers(collection, data); validationErr != nil {
+		return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
+	}
+	if err := s.checkImmutable(ctx, collection, ids[0], data); err != nil {
+		return nil, err
+	}
+	if s.validator != nil && len(data) > 0 {
+		if validationErr := s.validator.ValidatePartial(ctx, collectionName, data); validationErr != nil {
+			return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
+		}
+	}
+	now := time.Now().UTC()
+	if len(data) > 0 {
+		fillAutoFields(ctx, collection, data, false, now)
+	}
+
+	plan := mergePlan{
+		winner: winner[collection.PrimaryKey],
+		data:   data,
+		soft:   soft,
+		now:    now,
+	}
+	for _, loser := range losers {
+		plan.losers = append(plan.losers, loser[collection.PrimaryKey])
+	}
+	if plan.children, err = s.mergeChildren(ctx, collection, winner, losers); err != nil {
+		return nil, err
+	}
+	if update := s.revisionFunc(ctx, collection, RevisionActionUpdate); update != nil {
+		remove := s.revisionFunc(ctx, collection, RevisionActionDelete)
+		plan.record = func(ctx context.Context, tx *sqlx.Tx) error {
+			if err := update(ctx, tx, ids[0], winner); err != nil {
+				return err
+			}
+			for i, loser := range losers {
+				if err := remove(ctx, tx, ids[i+1], loser); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+
+	result := &MergeResponse{Merged: len(losers), Delete: MergeDeleteHard}
+	if soft {
+		result.Delete = MergeDeleteSoft
+	}
+	merged := make([]string, 0, len(data))
+	for field := range data {
+		merged = append(merged, field)
+	}
+	sort.Strings(merged)
+	plan.audit = map[string]any{
+		"winner":   plan.winner,
+		"losers":   plan.losers,
+		"strategy": opts.Strategy,
+		"fields":   merged,
+		"delete":   result.Delete,
+	}
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		plan.audit["user_id"] = user.ID
+	}
+
+	if result.Relinked, err = s.repo.Merge(ctx, collection, plan); err != nil {
+		return nil, err
+	}
+
+	if result.Item, err = s.repo.GetByID(ctx, collection, ids[0]); err != nil {
+		return nil, err
+	}
+	if err := s.translate(ctx, collection, []map[string]any{result.Item}); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, collection, NotifyActionUpdate, result.Item)
+	for i, loser := range losers {
+		if !soft {
+			s.deleteTranslations(ctx, collection, ids[i+1])
+		}
+		s.notify(ctx, collection, NotifyActionDelete, loser)
+	}
+	return result, nil
+}
+
+// mergeFields returns the fields the winner, records[0], takes from the
+// losers. The primary key, unique fields and deleted_at are only taken when
+// named in opts.Fields.
+func mergeFields(collection *schema.Collection, ids []string, records []map[string]any, opts MergeOptions) (map[string]any, error) {
+	winner := records[0]
+	picks := make(map[string]map[string]any, len(opts.Fields))
+	for field, id := range opts.Fields {
+		if fieldType(collection, field) == "" || field == collection.PrimaryKey {
+			return nil, apperror.ErrBadRequest.WithMessagef("Cannot merge field '%s'", field)
+		}
+		source := -1
+		for i, candidate := range ids {
+			if candidate == formatID(id) {
+				source = i
+			}
+		}
+		if source == -1 {
+			return nil, apperror.ErrBadRequest.WithMessagef("Field '%s' must come from the winner or a loser", field)
+		}
+		picks[field] = records[source]
+	}
+
+	data := make(map[string]any)
+	for _, f := range collection.Fields {
+		current := winner[f.Name]
+		value := current
+		if source, ok := picks[f.Name]; ok {
+			value = source[f.Name]
+		} else if f.Name == collection.PrimaryKey || f.IsPrimaryKey || f.IsUnique || f.Name == SoftDeleteField {
+			continue
+		} else if opts.Strategy == MergeOverwrite || (opts.Strategy == MergeFill && current == nil) {
+			for _, loser := range records[1:] {
+				if loser[f.Name] != nil {
+					value = loser[f.Name]
+					break
+				}
+			}
+		}
+		if !sameValue(current, value) {
+			data[f.Name] = value
+		}
+	}
+	return data, nil
+}
+
+// mergeChildren returns the foreign keys referencing the collection, with
+// the losers' values to re-point to the winner's. Re-pointing records fails
+// with apperror.ErrForbidden unless the request's user may update them.
+func (s *Service) mergeChildren(ctx context.Context, collection *schema.Collection, winner map[string]any, losers []map[string]any) ([]mergeChild, error) {
+	children := make([]mergeChild, 0)
+	for _, child := range s.schemaManager.GetCollections() {
+		for _, rel := range s.schemaManager.GetRelationships(child.Name) {
+			if rel.RelatedCollection != collection.Name {
+				continue
+			}
+			column := collection.PrimaryKey
+			for _, f := range child.Fields {
+				if f.Name == rel.FieldName && f.ForeignKey != nil && f.ForeignKey.Column != "" {
+					column = f.ForeignKey.Column
+				}
+			}
+
+			mc := mergeChild{collection: child, field: rel.FieldName, to: winner[column]}
+			for _, loser := range losers {
+				if v := loser[column]; v != nil {
+					mc.from = append(mc.from, v)
+				}
+			}
+			if len(mc.from) == 0 || mc.to == nil {
+				continue
+			}
+			if err := s.checkRepointable(ctx, mc); err != nil {
+				return nil, err
+			}
+			children = append(children, mc)
+		}
+	}
+	return children, nil
+}
+
+// checkRepointable fails with apperror.ErrForbidden when a merge would
+// re-point child records the request's user may not update.
+func (s *Service) checkRepointable(ctx context.Context, child mergeChild) error {
+	result, err := s.check(ctx, child.collection, permission.ActionUpdate)
+	if err != nil {
+		return err
+	}
+	var scope string
+	var scopeArgs []any
+	switch {
+	case result == nil:
+		return nil
+	case !result.Allowed:
+	case len(result.Filter) > 0:
+		scope, scopeArgs = permission.NewFilterBuilder(0).WithDialect(s.repo.dialect).Build(result.Filter)
+	default:
+		return nil
+	}
+
+	n, err := s.repo.CountReferencing(ctx, child.collection, child.field, child.from, scope, scopeArgs)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		return apperror.ErrForbidden.WithMessagef("Merging would move %d '%s' records you may not update", n, child.collection.Name)
+	}
+	return nil
+}
+
+// CountReferencing counts the records whose field holds one of values and
+// that do not match a scope condition with placeholders numbered from 1.
+// An empty scope counts every such record.
+func (r *Repository) CountReferencing(ctx context.Context, collection *schema.Collection, field string, values []any, scope string, scopeArgs []any) (int64, error) {
+	args := append([]any{}, scopeArgs...)
+	placeholders := make([]string, len(values))
+	for i, value := range values {
+		args = append(args, value)
+		placeholders[i] = r.dialect.Placeholder(len(args))
+	}
+	quote := r.dialect.QuoteIdent
+	where := fmt.Sprintf("%s IN (%s)", quote(field), strings.Join(placeholders, ", "))
+	if scope != "" {
+		where = "NOT COALESCE((" + scope + "), FALSE) AND " + where
+	}
+	querySQL := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", quote(collection.TableName), where)
+
+	var count int64
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if err := sqlx.GetContext(ctx, q, &count, querySQL, args...); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	return count, err
+}
+
+// Merge runs the statements of a merge in one transaction and returns the
+// number of child records re-pointed per foreign key.
+func (r *Repository) Merge(ctx context.Context, collection *schema.Collection, plan mergePlan) (map[string]int64, error) {
+	if err := prepareValues(collection, plan.data); err != nil {
+		return nil, err
+	}
+	audit, err := json.Marshal(plan.audit)
+	if err != nil {
+		return nil, err
+	}
+
+	quote := r.dialect.QuoteIdent
+	var relinked map[string]int64
+	err = r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		relinked = make(map[string]int64, len(plan.children))
+		for _, child := range plan.children {
+			querySQL, args, err := sqlx.In(fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s IN (?)",
+				quote(child.collection.TableName), quote(child.field), quote(child.field)), child.to, child.from)
+			if err != nil {
+				return err
+			}
+			res, err := tx.ExecContext(ctx, tx.Rebind(querySQL), args...)
+			if err != nil {
+				if isDuplicateKeyError(err) {
+					return apperror.ErrConflict.WithMessagef("Moving '%s' records to the winner would duplicate a unique value", child.collection.Name)
+				}
+				return dbError(ctx, err)
+			}
+			n, _ := res.RowsAffected()
+			relinked[child.collection.Name+"."+child.field] = n
+		}
+
+		// Losers go first so unique values they give up are free for the winner
+		removeSQL := fmt.Sprintf("DELETE FROM %s WHERE %s IN (?)", quote(collection.TableName), quote(collection.PrimaryKey))
+		removeArgs := []any{plan.losers}
+		if plan.soft {
+			removeSQL = fmt.Sprintf("UPDATE %s SET %s = ? WHERE %s IN (?)",
+				quote(collection.TableName), quote(SoftDeleteField), quote(collection.PrimaryKey))
+			removeArgs = []any{plan.now, plan.losers}
+		}
+		removeSQL, removeArgs, err := sqlx.In(removeSQL, removeArgs...)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, tx.Rebind(removeSQL), removeArgs...); err != nil {
+			return dbError(ctx, err)
+		}
+
+		if len(plan.data) > 0 {
+			querySQL, args := query.BuildUpdateDialect(r.dialect, collection.TableName, collection.PrimaryKey, plan.winner, plan.data)
+			if _, err := tx.ExecContext(ctx, querySQL, args...); err != nil {
+				return duplicateError(ctx, err, "Record with this value already exists")
+			}
+		}
+
+		if plan.record != nil {
+			if err := plan.record(ctx, tx); err != nil {
+				return err
+			}
+		}
+
+		auditSQL := tx.Rebind(`INSERT INTO tugo_audit_log (id, action, collection, item_id, changes, created_at) VALUES (?, ?, ?, ?, ?, ?)`)
+		if _, err := tx.ExecContext(ctx, auditSQL, uuid.NewString(), AuditMergeAction, collection.Name, fmt.Sprint(plan.winner), string(audit), plan.now); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return relinked, nil
+}
@@ -0,0 +1,54 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestMergeFields(t *testing.T) {
+	collection := &schema.Collection{
+		PrimaryKey: "id",
+		Fields: []schema.Field{
+			{Name: "id", DataType: "int", IsPrimaryKey: true},
+			{Name: "name", DataType: "string"},
+			{Name: "email", DataType: "string", IsUnique: true},
+			{Name: "phone", DataType: "string"},
+			{Name: "deleted_at", DataType: "timestamp"},
+		},
+	}
+	ids := []string{"1", "2", "3"}
+	records := []map[string]any{
+		{"id": int64(1), "name": "Jon", "email": "jon@x.com", "phone": nil, "deleted_at": nil},
+		{"id": int64(2), "name": "Jonathan", "email": "jon2@x.com", "phone": nil, "deleted_at": nil},
+		{"id": int64(3), "name": nil, "email": nil, "phone": "555", "deleted_at": "2024-01-01T00:00:00Z"},
+	}
+
+	tests := []struct {
+		name    string
+		opts    MergeOptions
+		want    map[string]any
+		wantErr bool
+	}{
+		{"fill", MergeOptions{Strategy: MergeFill}, map[string]any{"phone": "555"}, false},
+		{"keep", MergeOptions{Strategy: MergeKeep}, map[string]any{}, false},
+		{"overwrite", MergeOptions{Strategy: MergeOverwrite}, map[string]any{"name": "Jonathan", "phone": "555"}, false},
+		{"picked unique field", MergeOptions{Strategy: MergeKeep, Fields: map[string]any{"email": float64(2)}}, map[string]any{"email": "jon2@x.com"}, false},
+		{"pick from another item", MergeOptions{Strategy: MergeKeep, Fields: map[string]any{"name": 9}}, nil, true},
+		{"pick unknown field", MergeOptions{Strategy: MergeKeep, Fields: map[string]any{"nope": 2}}, nil, true},
+		{"pick primary key", MergeOptions{Strategy: MergeKeep, Fields: map[string]any{"id": 2}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := mergeFields(collection, ids, records, tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("mergeFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package collection
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Middleware holds middleware run on the endpoints of single collections,
+// such as a rate limiter for an expensive collection.
+type Middleware struct {
+	// Collections maps collection names to middleware run on all their
+	// endpoints.
+	Collections map[string][]gin.HandlerFunc
+
+	// Endpoints maps collection names and endpoint names, such as
+	// EndpointList, to middleware run after the collection's.
+	Endpoints map[string]map[string][]gin.HandlerFunc
+}
+
+// forEndpoint returns the middleware of each collection on an endpoint.
+func (m Middleware) forEndpoint(endpoint string) map[string][]gin.HandlerFunc {
+	chains := make(map[string][]gin.HandlerFunc)
+	for name, handlers := range m.Collections {
+		chains[name] = append(chains[name], handlers...)
+	}
+	for name, byEndpoint := range m.Endpoints {
+		chains[name] = append(chains[name], byEndpoint[endpoint]...)
+	}
+	return chains
+}
+
+// route returns the handlers of an endpoint's route. Routes are shared by
+// all collections, so the route starts with one slot per middleware
+// position, each running the requested collection's middleware at that
+// position, if any. Middleware calling c.Next runs the rest of the route
+// as usual.
+func (m Middleware) route(endpoint string, handlers ...gin.HandlerFunc) []gin.HandlerFunc {
+	chains := m.forEndpoint(endpoint)
+	slots := 0
+	for _, chain := range chains {
+		slots = max(slots, len(chain))
+	}
+
+	route := make([]gin.HandlerFunc, 0, slots+len(handlers))
+	for i := range slots {
+		route = append(route, func(c *gin.Context) {
+			if chain := chains[c.Param("collection")]; i < len(chain) {
+				chain[i](c)
+			}
+		})
+	}
+	return append(route, handlers...)
+}
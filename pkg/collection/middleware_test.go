@@ -0,0 +1,62 @@
+package collection
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMiddlewareRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var calls []string
+	record := func(name string) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			calls = append(calls, name)
+			c.Next()
+			calls = append(calls, "/"+name)
+		}
+	}
+	m := Middleware{
+		Collections: map[string][]gin.HandlerFunc{
+			"reports": {record("limit"), record("cache")},
+			"orders":  {func(c *gin.Context) { c.AbortWithStatus(http.StatusTooManyRequests) }},
+		},
+		Endpoints: map[string]map[string][]gin.HandlerFunc{
+			"reports":  {EndpointList: {record("list")}},
+			"products": {EndpointGet: {record("get")}},
+		},
+	}
+
+	router := gin.New()
+	router.GET("/:collection", m.route(EndpointList, func(c *gin.Context) {
+		calls = append(calls, "handler")
+		c.Status(http.StatusOK)
+	})...)
+
+	tests := []struct {
+		collection string
+		wantStatus int
+		wantCalls  string
+	}{
+		{"reports", http.StatusOK, "limit cache list handler /list /cache /limit"},
+		{"orders", http.StatusTooManyRequests, ""},
+		{"products", http.StatusOK, "handler"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			calls = nil
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/"+tt.collection, nil))
+			if rec.Code != tt.wantStatus {
+				t.Errorf("GET /%s status = %d, want %d", tt.collection, rec.Code, tt.wantStatus)
+			}
+			if got := strings.Join(calls, " "); got != tt.wantCalls {
+				t.Errorf("GET /%s calls = %q, want %q", tt.collection, got, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -0,0 +1,230 @@
+package collection
+
+import (
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"strconv"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/money"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// encodeMoney replaces the money fields of a scanned item with objects
+// holding the amount in major units as a string and the currency code.
+// Amounts finer than the currency's minor unit are written as stored.
+func encodeMoney(collection *schema.Collection, item map[string]any) {
+	for field, cfg := range collection.Money {
+		v, ok := item[field]
+		if !ok || v == nil {
+			continue
+		}
+		if _, done := v.(map[string]any); done {
+			continue
+		}
+
+		currency := cfg.Currency
+		if cfg.CurrencyField != "" {
+			currency, _ = item[cfg.CurrencyField].(string)
+		}
+		amount := fmt.Sprint(v)
+		if digits, known := money.Digits(currency); known {
+			if minor, err := minorUnits(collection, field, amount, digits); err == nil {
+				amount = money.FormatMajor(minor, digits)
+			}
+		}
+
+		encoded := map[string]any{"amount": amount, "currency": nil}
+		if currency != "" {
+			encoded["currency"] = currency
+		}
+		item[field] = encoded
+	}
+}
+
+// minorUnits returns a stored amount of a money field in minor units:
+// integer fields hold them already, decimal fields hold major units.
+func minorUnits(collection *schema.Collection, field, amount string, digits int) (*big.Int, error) {
+	if fieldType(collection, field) == "int" {
+		return money.ParseMinor(amount)
+	}
+	return money.ParseMajor(amount, digits)
+}
+
+// normalizeMoney converts the money fields of write data, given as
+// {"amount": "12.50", "currency": "USD"} or as a bare amount in major units,
+// to the stored form: major units for decimal fields and minor units for
+// integer fields. A currency given for a money field with a currency field
+// is written to that field. Invalid fields are reported and removed.
+func normalizeMoney(collection *schema.Collection, data map[string]any, errs *validation.ValidationErrors) {
+	for field, cfg := range collection.Money {
+		if cfg.CurrencyField != "" {
+			if code, ok := data[cfg.CurrencyField].(string); ok {
+				if _, known := money.Digits(code); !known {
+					errs.Add(cfg.CurrencyField, fmt.Sprintf("'%s' is not an ISO 4217 currency code", code), "invalid_currency")
+					delete(data, cfg.CurrencyField)
+				}
+			}
+		}
+
+		v, ok := data[field]
+		if !ok || v == nil {
+			continue
+		}
+		value, err := moneyValue(collection, field, cfg, v, data)
+		if err != nil {
+			errs.Add(field, err.Error(), "invalid_money")
+			delete(data, field)
+			continue
+		}
+		data[field] = value
+	}
+}
+
+// moneyValue converts one money field's write value to its stored form.
+func moneyValue(collection *schema.Collection, field string, cfg schema.Money, v any, data map[string]any) (any, error) {
+	var currency string
+	if obj, ok := v.(map[string]any); ok {
+		v = obj["amount"]
+		if obj["currency"] != nil {
+			code, ok := obj["currency"].(string)
+			if !ok {
+				return nil, fmt.Errorf("currency must be a string")
+			}
+			currency = code
+		}
+	}
+
+	var amount string
+	switch val := v.(type) {
+	case json.Number:
+		amount = val.String()
+	case string:
+		amount = val
+	case float64:
+		amount = strconv.FormatFloat(val, 'f', -1, 64)
+	case int64:
+		amount = strconv.FormatInt(val, 10)
+	case int:
+		amount = strconv.Itoa(val)
+	default:
+		return nil, fmt.Errorf("must be an amount or an object with amount and currency")
+	}
+
+	switch {
+	case cfg.Currency != "":
+		if currency != "" && currency != cfg.Currency {
+			return nil, fmt.Errorf("must be in %s", cfg.Currency)
+		}
+		currency = cfg.Currency
+	case currency != "":
+		if _, known := money.Digits(currency); !known {
+			return nil, fmt.Errorf("'%s' is not an ISO 4217 currency code", currency)
+		}
+		if existing, ok := data[cfg.CurrencyField].(string); ok && existing != currency {
+			return nil, fmt.Errorf("currency '%s' does not match %s '%s'", currency, cfg.CurrencyField, existing)
+		}
+		data[cfg.CurrencyField] = currency
+	default:
+		currency, _ = data[cfg.CurrencyField].(string)
+	}
+
+	digits, known := money.Digits(currency)
+	if !known {
+		// Without a currency, decimal amounts are only checked as decimals
+		if fieldType(collection, field) == "int" {
+			return nil, fmt.Errorf("needs a currency to be converted to minor units")
+		}
+		return amount, nil
+	}
+
+	minor, err := money.ParseMajor(amount, digits)
+	if err != nil {
+		return nil, err
+	}
+	if fieldType(collection, field) == "int" {
+		if !minor.IsInt64() {
+			return nil, fmt.Errorf("'%s' is out of range", amount)
+		}
+		return minor.Int64(), nil
+	}
+	return money.FormatMajor(minor, digits), nil
+}
+
+// moneyFilters converts filter values on money fields from minor units to
+// the stored form. Money fields with a currency field need an equality
+// filter on it to know the minor unit.
+func moneyFilters(collection *schema.Collection, filters []query.Filter) ([]query.Filter, error) {
+	if len(collection.Money) == 0 {
+		return filters, nil
+	}
+
+	for i, f := range filters {
+		cfg, ok := collection.Money[f.Field]
+		if !ok || f.Operator == query.OpIsNull || f.Operator == query.OpIsNotNull || f.Operator == query.OpLike {
+			continue
+		}
+
+		currency := cfg.Currency
+		if cfg.CurrencyField != "" {
+			for _, other := range filters {
+				if other.Field == cfg.CurrencyField && other.Operator == query.OpEqual {
+					currency, _ = other.Value.(string)
+				}
+			}
+		}
+		digits, known := money.Digits(currency)
+		if !known {
+			return nil, apperror.ErrInvalidFilter.WithMessagef(
+				"Filters on money field '%s' need filter[%s] to convert minor units", f.Field, cfg.CurrencyField)
+		}
+
+		if values, ok := f.Value.([]any); ok {
+			converted := make([]any, len(values))
+			for j, v := range values {
+				value, err := moneyFilterValue(collection, f.Field, v, digits)
+				if err != nil {
+					return nil, err
+				}
+				converted[j] = value
+			}
+			filters[i].Value = converted
+			continue
+		}
+		value, err := moneyFilterValue(collection, f.Field, f.Value, digits)
+		if err != nil {
+			return nil, err
+		}
+		filters[i].Value = value
+	}
+	return filters, nil
+}
+
+// moneyFilterValue converts one filter value in minor units.
+func moneyFilterValue(collection *schema.Collection, field string, v any, digits int) (any, error) {
+	minor, err := money.ParseMinor(fmt.Sprint(v))
+	if err != nil {
+		return nil, apperror.ErrInvalidFilter.WithMessagef(
+			"Filters on money field '%s' take whole minor units, such as 1250 for 12.50: %s", field, err.Error())
+	}
+	if fieldType(collection, field) == "int" {
+		if !minor.IsInt64() {
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Filter value on money field '%s' is out of range", field)
+		}
+		return minor.Int64(), nil
+	}
+	return money.FormatMajor(minor, digits), nil
+}
+
+// fieldType returns the data type of a collection's field.
+func fieldType(collection *schema.Collection, name string) string {
+	for _, f := range collection.Fields {
+		if f.Name == name {
+			return f.DataType
+		}
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+func moneyCollection() *schema.Collection {
+	return &schema.Collection{
+		Fields: []schema.Field{{Name: "total", DataType: "decimal"}, {Name: "fee", DataType: "int"}, {Name: "currency", DataType: "string"}},
+		Money: map[string]schema.Money{
+			"total": {CurrencyField: "currency"},
+			"fee":   {Currency: "USD"},
+		},
+	}
+}
+
+func TestEncodeMoney(t *testing.T) {
+	item := map[string]any{"total": "12.5000", "fee": int64(1250), "currency": "KWD"}
+	encodeMoney(moneyCollection(), item)
+
+	want := map[string]any{
+		"total":    map[string]any{"amount": "12.500", "currency": "KWD"},
+		"fee":      map[string]any{"amount": "12.50", "currency": "USD"},
+		"currency": "KWD",
+	}
+	if !reflect.DeepEqual(item, want) {
+		t.Errorf("encodeMoney() = %v, want %v", item, want)
+	}
+}
+
+func TestNormalizeMoney(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    map[string]any
+		want    map[string]any
+		wantErr bool
+	}{
+		{"object sets currency field", map[string]any{"total": map[string]any{"amount": "12.5", "currency": "EUR"}}, map[string]any{"total": "12.50", "currency": "EUR"}, false},
+		{"bare amount with currency field", map[string]any{"total": "3", "currency": "JPY"}, map[string]any{"total": "3", "currency": "JPY"}, false},
+		{"integer field in minor units", map[string]any{"fee": "12.34"}, map[string]any{"fee": int64(1234)}, false},
+		{"too many decimal places", map[string]any{"fee": "12.345"}, nil, true},
+		{"other currency than fixed", map[string]any{"fee": map[string]any{"amount": "1", "currency": "EUR"}}, nil, true},
+		{"unknown currency code", map[string]any{"currency": "ABC"}, nil, true},
+		{"mismatched currencies", map[string]any{"total": map[string]any{"amount": "1", "currency": "EUR"}, "currency": "USD"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs := &validation.ValidationErrors{}
+			normalizeMoney(moneyCollection(), tt.data, errs)
+			if errs.HasErrors() != tt.wantErr {
+				t.Fatalf("normalizeMoney() errors = %v, wantErr %v", errs, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(tt.data, tt.want) {
+				t.Errorf("normalizeMoney() = %v, want %v", tt.data, tt.want)
+			}
+		})
+	}
+}
+
+func TestMoneyFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filters []query.Filter
+		want    any
+		wantErr bool
+	}{
+		{"fixed currency integer field", []query.Filter{{Field: "fee", Operator: query.OpGreaterEqual, Value: int64(1250)}}, int64(1250), false},
+		{"currency from filter", []query.Filter{{Field: "total", Operator: query.OpLessThan, Value: "1250"}, {Field: "currency", Operator: query.OpEqual, Value: "USD"}}, "12.50", false},
+		{"in list", []query.Filter{{Field: "total", Operator: query.OpIn, Value: []any{"5", "10"}}, {Field: "currency", Operator: query.OpEqual, Value: "JPY"}}, []any{"5", "10"}, false},
+		{"no currency filter", []query.Filter{{Field: "total", Operator: query.OpEqual, Value: "1250"}}, nil, true},
+		{"major units", []query.Filter{{Field: "total", Operator: query.OpEqual, Value: "12.50"}, {Field: "currency", Operator: query.OpEqual, Value: "USD"}}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := moneyFilters(moneyCollection(), tt.filters)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("moneyFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got[0].Value, tt.want) {
+				t.Errorf("moneyFilters() value = %#v, want %#v", got[0].Value, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,65 @@
+package collection
+
+import (
+	"context"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Record actions passed to a RecordNotifier.
+const (
+	NotifyActionCreate = "create"
+	NotifyActionUpdate = "update"
+	NotifyActionDelete = "delete"
+)
+
+// RecordNotifier is told about records written through the service, such
+// as notify.Notifier for email notifications.
+type RecordNotifier interface {
+	// Watches reports whether the notifier acts on action in collection,
+	// so deleted records are only read when needed.
+	Watches(collection *schema.Collection, action string) bool
+
+	// NotifyRecord handles a written record without blocking the request.
+	NotifyRecord(ctx context.Context, collection *schema.Collection, action string, record map[string]any)
+}
+
+// SetNotifier sets the notifier told about created, updated and deleted
+// records.
+func (s *Service) SetNotifier(notifier RecordNotifier) {
+	s.notifier = notifier
+}
+
+// watches reports whether the notifier acts on action in collection.
+func (s *Service) watches(collection *schema.Collection, action string) bool {
+	return s.notifier != nil && s.notifier.Watches(collection, action)
+}
+
+// notify passes a written record to the notifier when it watches action.
+func (s *Service) notify(ctx context.Context, collection *schema.Collection, action string, record map[string]any) {
+	if record != nil && s.watches(collection, action) {
+		s.notifier.NotifyRecord(ctx, collection, action, record)
+	}
+}
+
+// Notifiers passes records to several notifiers.
+type Notifiers []RecordNotifier
+
+// Watches reports whether any notifier acts on action in collection.
+func (n Notifiers) Watches(collection *schema.Collection, action string) bool {
+	for _, notifier := range n {
+		if notifier.Watches(collection, action) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyRecord passes a record to each notifier watching action.
+func (n Notifiers) NotifyRecord(ctx context.Context, collection *schema.Collection, action string, record map[string]any) {
+	for _, notifier := range n {
+		if notifier.Watches(collection, action) {
+			notifier.NotifyRecord(ctx, collection, action, record)
+		}
+	}
+}
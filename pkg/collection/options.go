@@ -0,0 +1,158 @@
+package collection
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// Methods allowed on collection and item routes.
+var (
+	collectionMethods = []string{http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+	itemMethods       = []string{http.MethodGet, http.MethodHead, http.MethodPatch, http.MethodDelete, http.MethodOptions}
+)
+
+// Capabilities describes what a collection's endpoints accept, so generic
+// clients and gateways can adapt to it.
+type Capabilities struct {
+	Collection string   `json:"collection"`
+	Methods    []string `json:"methods"`
+
+	// Operators lists the filter operators of the collection's fields, and
+	// FieldOperators the operators each field accepts.
+	Operators      []string            `json:"filter_operators"`
+	FieldOperators map[string][]string `json:"field_operators"`
+
+	// DefaultLimit and MaxLimit are the page sizes of lists.
+	DefaultLimit int `json:"default_limit"`
+	MaxLimit     int `json:"max_limit"`
+
+	// MaxOffset and MaxExpand are zero when unlimited.
+	MaxOffset int `json:"max_offset,omitempty"`
+	MaxExpand int `json:"max_expand,omitempty"`
+}
+
+// Capabilities returns what a collection's endpoints accept, without the
+// methods of a route.
+func (s *Service) Capabilities(collectionName string) (*Capabilities, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	limits := query.PaginationLimits{
+		DefaultLimit: collection.DefaultLimit,
+		MaxLimit:     collection.MaxLimit,
+	}.Normalize()
+	caps := &Capabilities{
+		Collection:     collection.Name,
+		FieldOperators: make(map[string][]string, len(collection.Fields)),
+		DefaultLimit:   limits.DefaultLimit,
+		MaxLimit:       limits.MaxLimit,
+		MaxOffset:      collection.MaxOffset,
+		MaxExpand:      collection.MaxExpand,
+	}
+
+	all := query.FilterOperators()
+	used := make(map[string]bool, len(all))
+	for _, f := range collection.Fields {
+		ops := make([]string, 0, len(all))
+		for _, op := range all {
+			switch {
+			case f.DataType == "vector":
+				if op != string(query.OpCosineLessThan) && op != string(query.OpIsNull) && op != string(query.OpIsNotNull) {
+					continue
+				}
+			case op == string(query.OpCosineLessThan):
+				continue
+			case op == string(query.OpCaseInsensitiveEqual) && f.DataType != "string":
+				continue
+			case op == string(query.OpSimilar) && (f.DataType != "string" || !slices.Contains(collection.TextSearch.TrigramFields, f.Name)):
+				continue
+			case op == string(query.OpLike) && len(collection.SearchFields) > 0 && !slices.Contains(collection.SearchFields, f.Name):
+				continue
+			}
+			ops = append(ops, op)
+			used[op] = true
+		}
+		caps.FieldOperators[f.Name] = ops
+	}
+	for _, op := range all {
+		if used[op] {
+			caps.Operators = append(caps.Operators, op)
+		}
+	}
+	return caps, nil
+}
+
+// Options handles OPTIONS /:collection and /:collection/:id requests. The
+// Allow header lists the route's methods and the body the collection's
+// capabilities.
+func (h *Handler) Options(c *gin.Context) {
+	caps, err := h.service.Capabilities(c.Param("collection"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	caps.Methods = collectionMethods
+	if c.Param("id") != "" {
+		caps.Methods = itemMethods
+	}
+	c.Header("Allow", strings.Join(caps.Methods, ", "))
+	h.write(c, http.StatusOK, response.Success(caps))
+}
+
+// HeadList handles HEAD /:collection requests: the list's pagination
+// headers without the items.
+func (h *Handler) HeadList(c *gin.Context) {
+	result, err := h.service.List(c.Request.Context(), ListParams{
+		CollectionName: c.Param("collection"),
+		QueryParams:    c.Request.URL.Query(),
+	})
+	if err != nil {
+		h.headError(c, err)
+		return
+	}
+
+	setPaginationHeaders(c, result.Pagination)
+	c.Status(http.StatusOK)
+}
+
+// HeadItem handles HEAD /:collection/:id requests, answering whether the
+// item exists without its body.
+func (h *Handler) HeadItem(c *gin.Context) {
+	if _, err := h.service.Get(c.Request.Context(), c.Param("collection"), c.Param("id"), nil); err != nil {
+		h.headError(c, err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// headError sends the status of an error without a body.
+func (h *Handler) headError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	status := http.StatusInternalServerError
+	if appErr, ok := apperror.AsAppError(err); ok {
+		status = appErr.HTTPStatus
+	}
+	c.Status(status)
+}
+
+// setPaginationHeaders sends a list's pagination as X-Total-Count,
+// X-Total-Pages, X-Page and X-Limit headers.
+func setPaginationHeaders(c *gin.Context, p *response.Pagination) {
+	if p == nil {
+		return
+	}
+	c.Header("X-Total-Count", strconv.Itoa(p.Total))
+	c.Header("X-Total-Pages", strconv.Itoa(p.TotalPages))
+	c.Header("X-Page", strconv.Itoa(p.Page))
+	c.Header("X-Limit", strconv.Itoa(p.Limit))
+}
@@ -0,0 +1,111 @@
+package collection
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Endpoints of the generated collection routes, named to override them.
+const (
+	EndpointList           = "list"
+	EndpointHeadList       = "head_list"
+	EndpointOptions        = "options"
+	EndpointCreate         = "create"
+	EndpointBatchGet       = "batch_get"
+	EndpointTree           = "tree"
+	EndpointExport         = "export"
+	EndpointGetByField     = "get_by_field"
+	EndpointReorder        = "reorder"
+	EndpointFindDuplicates = "find_duplicates"
+	EndpointMerge          = "merge"
+	EndpointQueryToken     = "query_token"
+	EndpointUpdateMany     = "update_many"
+	EndpointDeleteMany     = "delete_many"
+	EndpointGet            = "get"
+	EndpointHeadItem       = "head_item"
+	EndpointUpdate         = "update"
+	EndpointDelete         = "delete"
+	EndpointDuplicate      = "duplicate"
+	EndpointChildren       = "children"
+	EndpointRaw            = "raw"
+	EndpointRevisions      = "revisions"
+	EndpointRevision       = "revision"
+	EndpointRestore        = "restore"
+	EndpointTranslations   = "translations"
+	EndpointTransition     = "transition"
+	EndpointComments       = "comments"
+	EndpointAddComment     = "add_comment"
+	EndpointDeleteComment  = "delete_comment"
+	EndpointFavorite       = "favorite"
+	EndpointUnfavorite     = "unfavorite"
+)
+
+// endpoints lists the names of the generated endpoints.
+var endpoints = map[string]bool{
+	EndpointList: true, EndpointHeadList: true, EndpointOptions: true, EndpointCreate: true,
+	EndpointBatchGet: true, EndpointTree: true, EndpointExport: true, EndpointGetByField: true,
+	EndpointReorder: true, EndpointFindDuplicates: true, EndpointMerge: true, EndpointGet: true,
+	EndpointHeadItem: true, EndpointUpdate: true, EndpointDelete: true, EndpointDuplicate: true,
+	EndpointChildren: true, EndpointRaw: true, EndpointRevisions: true, EndpointRevision: true,
+	EndpointRestore: true, EndpointTranslations: true, EndpointTransition: true,
+	EndpointComments: true, EndpointAddComment: true, EndpointDeleteComment: true,
+	EndpointFavorite: true, EndpointUnfavorite: true, EndpointUpdateMany: true,
+	EndpointDeleteMany: true, EndpointQueryToken: true,
+}
+
+// defaultHandlerKey is the context key of the generated handler an
+// override replaces.
+const defaultHandlerKey = "tugo_default_handler"
+
+// overrides holds the handlers replacing generated endpoints, keyed by
+// collection and endpoint.
+type overrides struct {
+	mu       sync.RWMutex
+	handlers map[[2]string]gin.HandlerFunc
+}
+
+// Override replaces a collection's generated endpoint with handler, such
+// as EndpointCreate for POST /orders. The handler runs after the
+// collection middleware and may call CallDefault to wrap the generated
+// handler instead of replacing it.
+func (h *Handler) Override(collection, endpoint string, handler gin.HandlerFunc) error {
+	if !endpoints[endpoint] {
+		return fmt.Errorf("unknown collection endpoint '%s'", endpoint)
+	}
+
+	h.overrides.mu.Lock()
+	defer h.overrides.mu.Unlock()
+	if h.overrides.handlers == nil {
+		h.overrides.handlers = make(map[[2]string]gin.HandlerFunc)
+	}
+	h.overrides.handlers[[2]string{collection, endpoint}] = handler
+	return nil
+}
+
+// endpoint returns the handler of a generated endpoint, running the
+// collection's override instead when one is set.
+func (h *Handler) endpoint(name string, handler gin.HandlerFunc) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		h.overrides.mu.RLock()
+		override := h.overrides.handlers[[2]string{c.Param("collection"), name}]
+		h.overrides.mu.RUnlock()
+
+		if override == nil {
+			handler(c)
+			return
+		}
+		c.Set(defaultHandlerKey, handler)
+		override(c)
+	}
+}
+
+// CallDefault runs the generated handler of the endpoint an override
+// replaces, so the override can wrap it. It does nothing outside an
+// override.
+func CallDefault(c *gin.Context) {
+	if v, ok := c.Get(defaultHandlerKey); ok {
+		v.(gin.HandlerFunc)(c)
+	}
+}
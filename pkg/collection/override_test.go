@@ -0,0 +1,53 @@
+package collection
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestOverride(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	h := &Handler{}
+	if err := h.Override("orders", "publish", func(c *gin.Context) {}); err == nil {
+		t.Error("Override() accepted an unknown endpoint")
+	}
+	h.Override("orders", EndpointCreate, func(c *gin.Context) {
+		c.Header("X-Wrapped", "true")
+		CallDefault(c)
+	})
+	h.Override("invoices", EndpointCreate, func(c *gin.Context) {
+		c.String(http.StatusAccepted, "custom")
+	})
+
+	router := gin.New()
+	router.POST("/:collection", h.endpoint(EndpointCreate, func(c *gin.Context) {
+		c.String(http.StatusCreated, "generated")
+	}))
+
+	tests := []struct {
+		collection  string
+		wantStatus  int
+		wantBody    string
+		wantWrapped bool
+	}{
+		{"products", http.StatusCreated, "generated", false},
+		{"orders", http.StatusCreated, "generated", true},
+		{"invoices", http.StatusAccepted, "custom", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.collection, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			router.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/"+tt.collection, nil))
+			if rec.Code != tt.wantStatus || rec.Body.String() != tt.wantBody {
+				t.Errorf("POST /%s = %d %q, want %d %q", tt.collection, rec.Code, rec.Body.String(), tt.wantStatus, tt.wantBody)
+			}
+			if wrapped := rec.Header().Get("X-Wrapped") == "true"; wrapped != tt.wantWrapped {
+				t.Errorf("POST /%s wrapped = %v, want %v", tt.collection, wrapped, tt.wantWrapped)
+			}
+		})
+	}
+}
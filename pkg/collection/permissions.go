@@ -0,0 +1,53 @@
+package collection
+
+import (
+	"context"
+	"slices"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// SetPermissions checks the collections a request touches besides the one
+// of its route, as permission.Middleware does on the route: favorited items
+// listed outside the collection routes, children copied by a duplicate,
+// records re-pointed by a merge and related collections sorted on. Nil
+// skips these checks.
+func (s *Service) SetPermissions(checker *permission.Checker) {
+	s.permissions = checker
+}
+
+// allowed checks that the request's user may perform action on collection,
+// failing with apperror.ErrForbidden otherwise. It returns the check result,
+// or nil when nothing is checked.
+func (s *Service) allowed(ctx context.Context, collection *schema.Collection, action permission.Action) (*permission.CheckResult, error) {
+	result, err := s.check(ctx, collection, action)
+	if err != nil {
+		return nil, err
+	}
+	if result != nil && !result.Allowed {
+		return nil, apperror.ErrForbidden.WithMessagef("No %s permission on collection '%s'", action, collection.Name)
+	}
+	return result, nil
+}
+
+// check checks action on collection for the request's user. Anonymous
+// requests are allowed the collection's public actions. It returns nil when
+// no checker is set or the action is public.
+func (s *Service) check(ctx context.Context, collection *schema.Collection, action permission.Action) (*permission.CheckResult, error) {
+	if s.permissions == nil {
+		return nil, nil
+	}
+	user, ok := auth.GetUserFromContext(ctx)
+	if (!ok || user == nil) && slices.Contains(collection.PublicActions, string(action)) {
+		return nil, nil
+	}
+
+	result, err := s.permissions.Check(ctx, user, collection.Name, action)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return result, nil
+}
@@ -0,0 +1,53 @@
+package collection
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// selectFields parses a fields parameter into the selected fields and the
+// projections computed from them, such as price*1.2 as with_tax.
+// Projections may name fields by their alias, and their names may not
+// shadow a field.
+func selectFields(collection *schema.Collection, value string) ([]string, []query.Projection, error) {
+	fieldNames := getFieldNames(collection.Fields)
+	columns := columnNames(collection)
+	resolve := func(name string) (string, bool) {
+		if column, ok := columns[name]; ok {
+			return column, true
+		}
+		return name, slices.Contains(fieldNames, name)
+	}
+
+	var plain []string
+	var projections []query.Projection
+	taken := make(map[string]bool)
+	for _, entry := range query.SplitFields(value) {
+		if query.IsPlainField(entry) || strings.TrimSpace(entry) == "" {
+			plain = append(plain, entry)
+			continue
+		}
+		if len(projections) == query.MaxProjections {
+			return nil, nil, apperror.ErrBadRequest.WithMessagef("At most %d computed fields may be selected", query.MaxProjections)
+		}
+		p, err := query.ParseProjection(entry, resolve)
+		if err != nil {
+			return nil, nil, err
+		}
+		if _, ok := resolve(p.Alias); ok || taken[p.Alias] {
+			return nil, nil, apperror.ErrBadRequest.WithMessagef("Computed field '%s' is already a field name", p.Alias)
+		}
+		taken[p.Alias] = true
+		projections = append(projections, p)
+	}
+
+	fields, err := parseFields(strings.Join(plain, ","), fieldNames)
+	if err != nil {
+		return nil, nil, err
+	}
+	return fields, projections, nil
+}
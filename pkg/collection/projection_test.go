@@ -0,0 +1,61 @@
+package collection
+
+import (
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestSelectFields(t *testing.T) {
+	collection := &schema.Collection{
+		Fields: []schema.Field{
+			{Name: "id"}, {Name: "title"}, {Name: "price"},
+		},
+		Aliases: map[string]string{"title": "headline"},
+	}
+
+	tests := []struct {
+		name            string
+		value           string
+		wantFields      []string
+		wantProjections []string
+		wantErr         bool
+	}{
+		{"fields and projections", "id,price*2 as double,upper(title)", []string{"id"}, []string{"double", "upper(title)"}, false},
+		{"projections only", "round(price, 1) as p", []string{}, []string{"p"}, false},
+		{"excluded fields", "-title,price*2 as double", []string{"id", "price"}, []string{"double"}, false},
+		{"alias in expression", "lower(headline) as h", []string{}, []string{"h"}, false},
+		{"name shadows field", "price*2 as price", nil, nil, true},
+		{"name shadows alias", "price*2 as headline", nil, nil, true},
+		{"duplicate name", "price*2 as p,price*3 as p", nil, nil, true},
+		{"unknown field", "cost*2 as c", nil, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fields, projections, err := selectFields(collection, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("selectFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if len(fields) != len(tt.wantFields) {
+				t.Fatalf("fields = %v, want %v", fields, tt.wantFields)
+			}
+			for i := range fields {
+				if fields[i] != tt.wantFields[i] {
+					t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+				}
+			}
+			if len(projections) != len(tt.wantProjections) {
+				t.Fatalf("projections = %v, want %v", projections, tt.wantProjections)
+			}
+			for i, p := range projections {
+				if p.Alias != tt.wantProjections[i] {
+					t.Errorf("projection %d = %q, want %q", i, p.Alias, tt.wantProjections[i])
+				}
+			}
+		})
+	}
+}
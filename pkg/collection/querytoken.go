@@ -0,0 +1,205 @@
+package collection
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// QueryParam carries a query token on list, HEAD and export requests:
+// ?q=eyJ...
+const QueryParam = "q"
+
+// tokenParams are the list parameters a query token carries, besides
+// filter[field] and filter[field:op]. Time zone, locale and debug stay
+// with each request.
+var tokenParams = []string{"expand", "fields", "limit", "order_by_similarity", "page", "search", "sort", "view"}
+
+// pagingParams are the token parameters a request may override, so a
+// shared query can be paged through.
+var pagingParams = []string{"limit", "page"}
+
+// QueryTokens issues and expands query tokens, which hold the list
+// parameters of a collection signed so clients cannot alter them. Any
+// instance sharing the secret accepts a token.
+type QueryTokens struct {
+	key []byte
+	ttl time.Duration
+}
+
+// NewQueryTokens creates query tokens valid for ttl, or without expiry
+// when ttl is zero. An empty secret makes a random key, so tokens only work
+// on the instance that issued them and until it restarts.
+func NewQueryTokens(secret string, ttl time.Duration) *QueryTokens {
+	key := []byte(secret)
+	if len(key) == 0 {
+		key = make([]byte, 32)
+		_, _ = rand.Read(key)
+	}
+	return &QueryTokens{key: key, ttl: ttl}
+}
+
+// SetQueryTokens enables query tokens.
+func (s *Service) SetQueryTokens(tokens *QueryTokens) {
+	s.queryTokens = tokens
+}
+
+// QueryToken is an issued query token.
+type QueryToken struct {
+	Token     string     `json:"token"`
+	ExpiresAt *time.Time `json:"expires_at,omitempty"`
+}
+
+// queryTokenPayload is the signed content of a query token.
+type queryTokenPayload struct {
+	Collection string              `json:"c"`
+	Params     map[string][]string `json:"p"`
+	Expires    int64               `json:"exp,omitempty"`
+}
+
+// issue returns a token holding the params of collection.
+func (t *QueryTokens) issue(collection string, params map[string][]string, now time.Time) QueryToken {
+	payload := queryTokenPayload{Collection: collection, Params: params}
+	var token QueryToken
+	if t.ttl > 0 {
+		expires := now.Add(t.ttl).Truncate(time.Second)
+		payload.Expires = expires.Unix()
+		token.ExpiresAt = &expires
+	}
+	encoded, _ := json.Marshal(payload)
+	body := base64.RawURLEncoding.EncodeToString(encoded)
+	token.Token = body + "." + t.sign(body)
+	return token
+}
+
+// expand returns the params of a token issued for collection, checking
+// its signature and expiry.
+func (t *QueryTokens) expand(token, collection string, now time.Time) (map[string][]string, error) {
+	body, sig, ok := strings.Cut(token, ".")
+	if !ok || !hmac.Equal([]byte(sig), []byte(t.sign(body))) {
+		return nil, apperror.ErrBadRequest.WithMessage("Invalid query token")
+	}
+	decoded, err := base64.RawURLEncoding.DecodeString(body)
+	if err != nil {
+		return nil, apperror.ErrBadRequest.WithMessage("Invalid query token")
+	}
+	var payload queryTokenPayload
+	if err := json.Unmarshal(decoded, &payload); err != nil {
+		return nil, apperror.ErrBadRequest.WithMessage("Invalid query token")
+	}
+	if payload.Collection != collection {
+		return nil, apperror.ErrBadRequest.WithMessagef("Query token is for collection '%s'", payload.Collection)
+	}
+	if payload.Expires != 0 && now.Unix() > payload.Expires {
+		return nil, apperror.ErrBadRequest.WithMessage("Query token has expired")
+	}
+	return payload.Params, nil
+}
+
+// sign returns the signature of a token body.
+func (t *QueryTokens) sign(body string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(body))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// tokenQuery returns the list parameters of params a query token carries.
+func tokenQuery(params map[string][]string) map[string][]string {
+	carried := make(map[string][]string, len(params))
+	for key, values := range params {
+		if len(values) > 0 && carries(key) {
+			carried[key] = values
+		}
+	}
+	return carried
+}
+
+// carries reports whether a query token carries the list parameter key.
+func carries(key string) bool {
+	return query.IsFilterKey(key) || slices.Contains(tokenParams, key)
+}
+
+// IssueQueryToken validates list parameters of a collection and returns a
+// token holding them.
+func (s *Service) IssueQueryToken(ctx context.Context, collectionName string, params map[string][]string) (*QueryToken, error) {
+	if s.queryTokens == nil {
+		return nil, apperror.ErrNotFound.WithMessage("Query tokens are not enabled")
+	}
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := checkParams(collection, params); err != nil {
+		return nil, err
+	}
+
+	carried := tokenQuery(params)
+	if _, _, err := s.listOptions(ctx, collection, ListParams{CollectionName: collection.Name, QueryParams: carried}); err != nil {
+		return nil, err
+	}
+
+	token := s.queryTokens.issue(collection.Name, carried, time.Now())
+	return &token, nil
+}
+
+// applyQueryToken merges the parameters of a query token into list
+// parameters. The request may page through the token's query; other
+// parameters a token carries fail with apperror.ErrBadRequest, so the
+// query cannot be altered.
+func (s *Service) applyQueryToken(collectionName string, params ListParams, token string) (ListParams, error) {
+	if s.queryTokens == nil {
+		return params, apperror.ErrBadRequest.WithMessage("Query tokens are not enabled")
+	}
+	carried, err := s.queryTokens.expand(token, collectionName, time.Now())
+	if err != nil {
+		return params, err
+	}
+
+	merged := make(map[string][]string, len(carried)+len(params.QueryParams))
+	for key, values := range carried {
+		merged[key] = values
+	}
+	var altered []string
+	for key, values := range params.QueryParams {
+		switch {
+		case key == QueryParam:
+		case carries(key) && !slices.Contains(pagingParams, key):
+			altered = append(altered, key)
+		default:
+			merged[key] = values
+		}
+	}
+	if len(altered) > 0 {
+		slices.Sort(altered)
+		return params, apperror.ErrBadRequest.WithMessagef("Parameters cannot be combined with a query token: %s", strings.Join(altered, ", "))
+	}
+	params.QueryParams = merged
+	if len(params.Expand) == 0 {
+		params.Expand = query.ParseExpand(merged)
+	}
+	return params, nil
+}
+
+// QueryToken handles POST /:collection/query-token requests, returning a
+// token holding the list parameters of the request's query string.
+func (h *Handler) QueryToken(c *gin.Context) {
+	token, err := h.service.IssueQueryToken(c.Request.Context(), c.Param("collection"), c.Request.URL.Query())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	h.write(c, http.StatusOK, response.Success(token))
+}
@@ -0,0 +1,106 @@
+package collection
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestQueryTokens(t *testing.T) {
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	params := map[string][]string{"filter[status]": {"active"}, "filter[tags:in]": {"a", "b"}, "sort": {"-created_at"}}
+	tokens := NewQueryTokens("secret", time.Hour)
+	issued := tokens.issue("products", params, now)
+	body, sig, _ := strings.Cut(issued.Token, ".")
+
+	tests := []struct {
+		name       string
+		tokens     *QueryTokens
+		token      string
+		collection string
+		at         time.Time
+		wantErr    bool
+	}{
+		{"valid", tokens, issued.Token, "products", now, false},
+		{"shared secret", NewQueryTokens("secret", time.Hour), issued.Token, "products", now, false},
+		{"other secret", NewQueryTokens("other", time.Hour), issued.Token, "products", now, true},
+		{"other collection", tokens, issued.Token, "orders", now, true},
+		{"expired", tokens, issued.Token, "products", now.Add(2 * time.Hour), true},
+		{"altered body", tokens, body + "x." + sig, "products", now, true},
+		{"no signature", tokens, body, "products", now, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.tokens.expand(tt.token, tt.collection, tt.at)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("expand() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got, params) {
+				t.Errorf("expand() = %v, want %v", got, params)
+			}
+		})
+	}
+}
+
+func TestTokenQuery(t *testing.T) {
+	got := tokenQuery(map[string][]string{
+		"filter[status]": {"active", "pending"},
+		"sort":           {"name"},
+		"tz":             {"Asia/Ho_Chi_Minh"},
+		"debug":          {"true"},
+		"q":              {"eyJ"},
+		"fields":         {},
+	})
+	want := map[string][]string{"filter[status]": {"active", "pending"}, "sort": {"name"}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("tokenQuery() = %v, want %v", got, want)
+	}
+}
+
+func TestApplyQueryToken(t *testing.T) {
+	tokens := NewQueryTokens("secret", 0)
+	s := &Service{queryTokens: tokens}
+	token := tokens.issue("products", map[string][]string{"filter[tags:in]": {"a", "b"}, "sort": {"name"}, "page": {"1"}}, time.Now()).Token
+
+	tests := []struct {
+		name    string
+		request map[string][]string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name:    "token only",
+			request: map[string][]string{"q": {token}},
+			want:    map[string][]string{"filter[tags:in]": {"a", "b"}, "sort": {"name"}, "page": {"1"}},
+		},
+		{
+			name:    "paging and request settings",
+			request: map[string][]string{"q": {token}, "page": {"3"}, "limit": {"50"}, "tz": {"UTC"}},
+			want:    map[string][]string{"filter[tags:in]": {"a", "b"}, "sort": {"name"}, "page": {"3"}, "limit": {"50"}, "tz": {"UTC"}},
+		},
+		{
+			name:    "overridden sort",
+			request: map[string][]string{"q": {token}, "sort": {"-price"}},
+			wantErr: true,
+		},
+		{
+			name:    "added filter",
+			request: map[string][]string{"q": {token}, "filter[status]": {"draft"}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := s.applyQueryToken("products", ListParams{QueryParams: tt.request}, token)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("applyQueryToken() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got.QueryParams, tt.want) {
+				t.Errorf("applyQueryToken() = %v, want %v", got.QueryParams, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,111 @@
+package collection
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// relativeDateRegex matches relative date filter values: a base such as
+// now, $TODAY or startOfMonth followed by offsets such as -7d or +1M.
+var relativeDateRegex = regexp.MustCompile(`^\$?([a-zA-Z]+)((?:[+-][0-9]{1,6}[smhdwMy])*)$`)
+
+// relativeOffsetRegex matches one offset of a relative date.
+var relativeOffsetRegex = regexp.MustCompile(`([+-][0-9]+)([smhdwMy])`)
+
+// relativeDate resolves a relative date value against now, in the time
+// zone of now. It reports whether the result is the start of a day, which
+// holds unless the base is now or an offset is in hours, minutes or
+// seconds. Weeks start on Monday. Spaces are read as +, which query
+// strings decode + to.
+func relativeDate(value string, now time.Time) (t time.Time, day bool, ok bool) {
+	m := relativeDateRegex.FindStringSubmatch(strings.ReplaceAll(strings.TrimSpace(value), " ", "+"))
+	if m == nil {
+		return time.Time{}, false, false
+	}
+
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch strings.ToLower(m[1]) {
+	case "now":
+		t = now
+	case "today", "startofday":
+		t, day = midnight, true
+	case "startofweek":
+		t, day = midnight.AddDate(0, 0, -(int(now.Weekday())+6)%7), true
+	case "startofmonth":
+		t, day = midnight.AddDate(0, 0, 1-now.Day()), true
+	case "startofyear":
+		t, day = time.Date(now.Year(), time.January, 1, 0, 0, 0, 0, now.Location()), true
+	default:
+		return time.Time{}, false, false
+	}
+
+	for _, offset := range relativeOffsetRegex.FindAllStringSubmatch(m[2], -1) {
+		n, _ := strconv.Atoi(offset[1])
+		switch offset[2] {
+		case "s":
+			t, day = t.Add(time.Duration(n)*time.Second), false
+		case "m":
+			t, day = t.Add(time.Duration(n)*time.Minute), false
+		case "h":
+			t, day = t.Add(time.Duration(n)*time.Hour), false
+		case "d":
+			t = t.AddDate(0, 0, n)
+		case "w":
+			t = t.AddDate(0, 0, 7*n)
+		case "M":
+			t = t.AddDate(0, n, 0)
+		case "y":
+			t = t.AddDate(n, 0, 0)
+		}
+	}
+	return t, day, true
+}
+
+// resolveRelativeDates replaces relative date values of filters on
+// timestamp and date fields, such as now-7d, with dates resolved against
+// now. Date fields and whole days on timestamp fields become YYYY-MM-DD
+// dates, which localizeDateFilters reads in the request time zone; other
+// times become UTC timestamps. Other values are left to coerceFilters.
+func resolveRelativeDates(collection *schema.Collection, filters []query.Filter, now time.Time) []query.Filter {
+	types := make(map[string]string, len(collection.Fields))
+	for _, f := range collection.Fields {
+		types[f.Name] = f.DataType
+	}
+
+	resolve := func(dataType, value string) string {
+		t, day, ok := relativeDate(value, now)
+		switch {
+		case !ok:
+			return value
+		case day || dataType == "date":
+			return t.Format(time.DateOnly)
+		default:
+			return filterTime(t)
+		}
+	}
+
+	for i, f := range filters {
+		dataType := types[f.Field]
+		raw, ok := f.Value.(string)
+		if !ok || (dataType != "timestamp" && dataType != "date") {
+			continue
+		}
+		switch f.Operator {
+		case query.OpLike, query.OpIsNull, query.OpIsNotNull:
+		case query.OpIn:
+			parts := strings.Split(raw, ",")
+			for j, part := range parts {
+				parts[j] = resolve(dataType, strings.TrimSpace(part))
+			}
+			filters[i].Value = strings.Join(parts, ",")
+		default:
+			filters[i].Value = resolve(dataType, raw)
+		}
+	}
+	return filters
+}
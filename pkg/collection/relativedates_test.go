@@ -0,0 +1,48 @@
+package collection
+
+import (
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestResolveRelativeDates(t *testing.T) {
+	collection := &schema.Collection{Fields: []schema.Field{
+		{Name: "created_at", DataType: "timestamp"},
+		{Name: "due_on", DataType: "date"},
+		{Name: "title", DataType: "string"},
+	}}
+	// Thursday, 15 October 2026, 01:30 in UTC+7.
+	now := time.Date(2026, 10, 15, 1, 30, 0, 0, time.FixedZone("ICT", 7*3600))
+
+	tests := []struct {
+		name   string
+		filter query.Filter
+		want   any
+	}{
+		{"now", query.Filter{Field: "created_at", Operator: query.OpLessThan, Value: "$NOW"}, "2026-10-14 18:30:00+00:00"},
+		{"now offset", query.Filter{Field: "created_at", Operator: query.OpGreaterEqual, Value: "now-7d"}, "2026-10-07 18:30:00+00:00"},
+		{"today", query.Filter{Field: "created_at", Operator: query.OpEqual, Value: "$TODAY"}, "2026-10-15"},
+		{"hours break the day", query.Filter{Field: "created_at", Operator: query.OpGreaterEqual, Value: "today+9h"}, "2026-10-15 02:00:00+00:00"},
+		{"start of week", query.Filter{Field: "created_at", Operator: query.OpGreaterEqual, Value: "startOfWeek"}, "2026-10-12"},
+		{"start of month", query.Filter{Field: "created_at", Operator: query.OpGreaterEqual, Value: "startOfMonth-1M"}, "2026-09-01"},
+		{"start of year", query.Filter{Field: "due_on", Operator: query.OpGreaterEqual, Value: "startOfYear+1y"}, "2027-01-01"},
+		{"date field drops time", query.Filter{Field: "due_on", Operator: query.OpLessEqual, Value: "now+36h"}, "2026-10-16"},
+		{"decoded plus", query.Filter{Field: "due_on", Operator: query.OpEqual, Value: "today 2d"}, "2026-10-17"},
+		{"in list", query.Filter{Field: "due_on", Operator: query.OpIn, Value: "today, today+1d"}, "2026-10-15,2026-10-16"},
+		{"absolute date", query.Filter{Field: "due_on", Operator: query.OpEqual, Value: "2026-01-02"}, "2026-01-02"},
+		{"unknown base", query.Filter{Field: "due_on", Operator: query.OpEqual, Value: "later"}, "later"},
+		{"text field", query.Filter{Field: "title", Operator: query.OpEqual, Value: "now"}, "now"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := resolveRelativeDates(collection, []query.Filter{tt.filter}, now)
+			if got[0].Value != tt.want {
+				t.Errorf("resolveRelativeDates() = %v, want %v", got[0].Value, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,336 @@
+package collection
+
+import (
+	"context"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// SortOrderField is the integer column holding manual list positions.
+const SortOrderField = "sort_order"
+
+// SortOrderStep is the gap left between positions so single moves rarely
+// require renumbering.
+const SortOrderStep = 1000
+
+// maxReorderItems bounds the IDs accepted by a single reorder.
+const maxReorderItems = 1000
+
+// ItemPosition is an item's position in a manually ordered collection.
+type ItemPosition struct {
+	ID       any   `json:"id"`
+	Position int64 `json:"sort_order"`
+}
+
+// ReorderResponse holds the result of a reorder.
+type ReorderResponse struct {
+	Items   []ItemPosition `json:"items"`
+	Updated int            `json:"updated"`
+}
+
+// Reorder places the given items in order by rewriting their sort_order.
+// Items already in order keep their positions; the others are moved into the
+// gaps between them, clear of unlisted items, and the whole list is
+// renumbered only when a gap runs out.
+func (s *Service) Reorder(ctx context.Context, collectionName string, ids []string) (*ReorderResponse, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	if !hasSortOrder(collection) {
+		return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no %s field", collectionName, SortOrderField)
+	}
+	if len(ids) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("At least one ID is required")
+	}
+	if len(ids) > maxReorderItems {
+		return nil, apperror.ErrBadRequest.WithMessagef("At most %d items may be reordered at once", maxReorderItems)
+	}
+	if len(uniqueStrings(ids)) != len(ids) {
+		return nil, apperror.ErrBadRequest.WithMessage("IDs must not repeat")
+	}
+
+	found, err := s.repo.GetByIDs(ctx, collection, ids)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]*int64, len(ids))
+	missing := make([]string, 0)
+	for i, id := range ids {
+		item, ok := found[id]
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		if pos, ok := positionValue(item[SortOrderField]); ok {
+			current[i] = &pos
+		}
+	}
+	if len(missing) > 0 {
+		return nil, apperror.ErrNotFound.WithMessagef("Items not found: %s", strings.Join(missing, ", "))
+	}
+
+	listed := make(map[string]bool, len(ids))
+	for _, item := range found {
+		listed[formatID(item[collection.PrimaryKey])] = true
+	}
+	others, err := s.neighbourPositions(ctx, collection, current, listed)
+	if err != nil {
+		return nil, err
+	}
+
+	// When a gap runs out, the whole list is renumbered
+	changed := make([]ItemPosition, 0)
+	positions, ok := planPositions(current, others, SortOrderStep)
+	if !ok {
+		all, err := s.unlistedPositions(ctx, collection, math.MinInt64, math.MaxInt64, listed)
+		if err != nil {
+			return nil, err
+		}
+		values := make([]int64, len(all))
+		for i, item := range all {
+			values[i] = item.Position
+		}
+		var renumbered []int64
+		positions, renumbered = renumberPositions(current, values, SortOrderStep)
+		for i, item := range all {
+			if item.Position != renumbered[i] {
+				changed = append(changed, ItemPosition{ID: item.ID, Position: renumbered[i]})
+			}
+		}
+	}
+
+	result := &ReorderResponse{Items: make([]ItemPosition, len(ids))}
+	for i, id := range ids {
+		result.Items[i] = ItemPosition{ID: found[id][collection.PrimaryKey], Position: positions[i]}
+		if current[i] == nil || *current[i] != positions[i] {
+			changed = append(changed, result.Items[i])
+		}
+	}
+
+	if err := s.repo.SetPositions(ctx, collection, SortOrderField, changed); err != nil {
+		return nil, err
+	}
+
+	result.Updated = len(changed)
+	return result, nil
+}
+
+// neighbourPositions returns the sorted positions of unlisted items that
+// planPositions may reach from the known positions of the listed ones, or
+// the last position of the list when none is known.
+func (s *Service) neighbourPositions(ctx context.Context, collection *schema.Collection, current []*int64, listed map[string]bool) ([]int64, error) {
+	lo, hi, known := int64(math.MaxInt64), int64(math.MinInt64), false
+	for _, pos := range current {
+		if pos != nil {
+			lo, hi, known = min(lo, *pos), max(hi, *pos), true
+		}
+	}
+	if !known {
+		last, err := s.repo.MaxInt(ctx, collection, SortOrderField)
+		return []int64{last}, err
+	}
+
+	reach := int64(len(current)+1) * SortOrderStep
+	items, err := s.unlistedPositions(ctx, collection, lo-reach, hi+reach, listed)
+	if err != nil {
+		return nil, err
+	}
+	others := make([]int64, len(items))
+	for i, item := range items {
+		others[i] = item.Position
+	}
+	return others, nil
+}
+
+// unlistedPositions returns the positions between from and to of the items
+// not in listed, sorted.
+func (s *Service) unlistedPositions(ctx context.Context, collection *schema.Collection, from, to int64, listed map[string]bool) ([]ItemPosition, error) {
+	items, err := s.repo.Positions(ctx, collection, SortOrderField, from, to)
+	if err != nil {
+		return nil, err
+	}
+	unlisted := items[:0]
+	for _, item := range items {
+		if !listed[formatID(item.ID)] {
+			unlisted = append(unlisted, item)
+		}
+	}
+	return unlisted, nil
+}
+
+// nextPosition returns the sort_order that appends an item to the end of the list.
+func (s *Service) nextPosition(ctx context.Context, collection *schema.Collection) (int64, error) {
+	last, err := s.repo.MaxInt(ctx, collection, SortOrderField)
+	if err != nil {
+		return 0, err
+	}
+	return last + SortOrderStep, nil
+}
+
+// hasSortOrder reports whether a collection is manually ordered.
+func hasSortOrder(collection *schema.Collection) bool {
+	for _, f := range collection.Fields {
+		if f.Name == SortOrderField && f.DataType == "int" {
+			return true
+		}
+	}
+	return false
+}
+
+// planPositions assigns increasing positions to items listed in their new
+// order, given the sorted positions of the unlisted items around them. The
+// longest run of items whose current positions are already increasing is
+// kept. Each run of the rest moves into the gap after the kept item before
+// it, short of the next unlisted item, or else into the gap before the kept
+// item after it. It reports false when a gap is too small.
+func planPositions(current []*int64, others []int64, step int64) ([]int64, bool) {
+	n := len(current)
+	keep := increasingSubsequence(current)
+
+	positions := make([]int64, n)
+	for i := 0; i < n; {
+		if keep[i] {
+			positions[i] = *current[i]
+			i++
+			continue
+		}
+
+		// Find the run of items to move and the positions bounding it
+		end := i
+		for end < n && !keep[end] {
+			end++
+		}
+		count := int64(end - i)
+
+		var lower, upper int64
+		switch {
+		case i > 0:
+			lower = positions[i-1]
+			upper = lower + (count+1)*step
+			if end < n {
+				upper = *current[end]
+			}
+			if j := sort.Search(len(others), func(j int) bool { return others[j] > lower }); j < len(others) {
+				upper = min(upper, others[j])
+			}
+		case end < n:
+			upper = *current[end]
+			lower = upper - (count+1)*step
+			if j := sort.Search(len(others), func(j int) bool { return others[j] >= upper }); j > 0 {
+				lower = max(lower, others[j-1])
+			}
+		default:
+			if len(others) > 0 {
+				lower = others[len(others)-1]
+			}
+			upper = lower + (count+1)*step
+		}
+
+		gap := (upper - lower) / (count + 1)
+		if gap < 1 {
+			return nil, false
+		}
+		for j := i; j < end; j++ {
+			positions[j] = lower + gap*int64(j-i+1)
+		}
+		i = end
+	}
+	return positions, true
+}
+
+// renumberPositions numbers the listed and unlisted items step apart in the
+// order planPositions gives them. Positions are first scaled by more than
+// the number of listed items, so every gap fits. It returns the positions
+// of the listed items and of the unlisted ones, in the order of others.
+func renumberPositions(current []*int64, others []int64, step int64) ([]int64, []int64) {
+	scale := int64(len(current) + 1)
+	scaled := make([]*int64, len(current))
+	for i, pos := range current {
+		if pos != nil {
+			v := *pos * scale
+			scaled[i] = &v
+		}
+	}
+	scaledOthers := make([]int64, len(others))
+	for i, pos := range others {
+		scaledOthers[i] = pos * scale
+	}
+	planned, _ := planPositions(scaled, scaledOthers, step)
+
+	// Merge both lists by planned position; listed items go first on a tie
+	listed := make([]int64, len(current))
+	unlisted := make([]int64, len(others))
+	next := step
+	for i, j := 0, 0; i < len(planned) || j < len(others); next += step {
+		if j == len(others) || i < len(planned) && planned[i] <= scaledOthers[j] {
+			listed[i] = next
+			i++
+		} else {
+			unlisted[j] = next
+			j++
+		}
+	}
+	return listed, unlisted
+}
+
+// increasingSubsequence marks a longest strictly increasing subsequence of the
+// known positions.
+func increasingSubsequence(values []*int64) []bool {
+	n := len(values)
+	length := make([]int, n)
+	prev := make([]int, n)
+	best := -1
+
+	for i := 0; i < n; i++ {
+		prev[i] = -1
+		if values[i] == nil {
+			continue
+		}
+		length[i] = 1
+		for j := 0; j < i; j++ {
+			if values[j] != nil && *values[j] < *values[i] && length[j]+1 > length[i] {
+				length[i] = length[j] + 1
+				prev[i] = j
+			}
+		}
+		if best == -1 || length[i] > length[best] {
+			best = i
+		}
+	}
+
+	keep := make([]bool, n)
+	for i := best; i != -1; i = prev[i] {
+		keep[i] = true
+	}
+	return keep
+}
+
+// positionValue converts a scanned sort_order value to an integer.
+func positionValue(v any) (int64, bool) {
+	switch val := v.(type) {
+	case int64:
+		return val, true
+	case int32:
+		return int64(val), true
+	case int:
+		return int64(val), true
+	case float64:
+		return int64(val), true
+	case string:
+		pos, err := strconv.ParseInt(val, 10, 64)
+		return pos, err == nil
+	default:
+		return 0, false
+	}
+}
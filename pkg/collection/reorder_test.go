@@ -0,0 +1,181 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+// positions returns pointers to values, with -1 standing for an item
+// without a position.
+func positions(values ...int64) []*int64 {
+	result := make([]*int64, len(values))
+	for i, v := range values {
+		if v != -1 {
+			result[i] = &v
+		}
+	}
+	return result
+}
+
+func TestIncreasingSubsequence(t *testing.T) {
+	tests := []struct {
+		name   string
+		values []*int64
+		want   []bool
+	}{
+		{"in order", positions(1000, 2000, 3000), []bool{true, true, true}},
+		{"last moved first", positions(3000, 1000, 2000), []bool{false, true, true}},
+		{"first moved last", positions(2000, 3000, 1000), []bool{true, true, false}},
+		{"swapped pair", positions(2000, 1000), []bool{true, false}},
+		{"new item", positions(1000, -1, 2000), []bool{true, false, true}},
+		{"no positions", positions(-1, -1), []bool{false, false}},
+		{"repeated positions", positions(1000, 1000), []bool{true, false}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := increasingSubsequence(tt.values); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("increasingSubsequence() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPlanPositions(t *testing.T) {
+	tests := []struct {
+		name    string
+		current []*int64
+		others  []int64
+		want    []int64
+		wantOK  bool
+	}{
+		{
+			name:    "in order",
+			current: positions(1000, 2000, 3000),
+			want:    []int64{1000, 2000, 3000},
+			wantOK:  true,
+		},
+		{
+			name:    "last moved first",
+			current: positions(3000, 1000, 2000),
+			want:    []int64{0, 1000, 2000},
+			wantOK:  true,
+		},
+		{
+			name:    "first moved last",
+			current: positions(2000, 3000, 1000),
+			want:    []int64{2000, 3000, 4000},
+			wantOK:  true,
+		},
+		{
+			name:    "swap with an unlisted item after",
+			current: positions(2000, 1000),
+			others:  []int64{3000},
+			want:    []int64{2000, 2500},
+			wantOK:  true,
+		},
+		{
+			name:    "first item after an unlisted item",
+			current: positions(-1, 2000),
+			others:  []int64{1500},
+			want:    []int64{1750, 2000},
+			wantOK:  true,
+		},
+		{
+			name:    "new items appended after the last unlisted item",
+			current: positions(-1, -1),
+			others:  []int64{4000},
+			want:    []int64{5000, 6000},
+			wantOK:  true,
+		},
+		{
+			name:    "new item between kept items",
+			current: positions(1000, -1, 2000),
+			others:  []int64{1200},
+			want:    []int64{1000, 1100, 2000},
+			wantOK:  true,
+		},
+		{
+			name:    "gap taken by an unlisted item",
+			current: positions(2, 1),
+			others:  []int64{3},
+			wantOK:  false,
+		},
+		{
+			name:    "gap too small",
+			current: positions(1, -1, 2),
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := planPositions(tt.current, tt.others, SortOrderStep)
+			if ok != tt.wantOK {
+				t.Fatalf("planPositions() ok = %v, want %v (%v)", ok, tt.wantOK, got)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("planPositions() = %v, want %v", got, tt.want)
+			}
+			if ok {
+				assertNoCollisions(t, got, tt.others)
+			}
+		})
+	}
+}
+
+func TestRenumberPositions(t *testing.T) {
+	tests := []struct {
+		name         string
+		current      []*int64
+		others       []int64
+		wantListed   []int64
+		wantUnlisted []int64
+	}{
+		{
+			name:         "gap taken by an unlisted item",
+			current:      positions(2, 1),
+			others:       []int64{3},
+			wantListed:   []int64{1000, 2000},
+			wantUnlisted: []int64{3000},
+		},
+		{
+			name:         "gap too small",
+			current:      positions(1, 3, 2),
+			others:       []int64{0, 4},
+			wantListed:   []int64{2000, 3000, 4000},
+			wantUnlisted: []int64{1000, 5000},
+		},
+		{
+			name:         "new item between close positions",
+			current:      positions(1, -1, 2),
+			wantListed:   []int64{1000, 2000, 3000},
+			wantUnlisted: []int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			listed, unlisted := renumberPositions(tt.current, tt.others, SortOrderStep)
+			if !reflect.DeepEqual(listed, tt.wantListed) || !reflect.DeepEqual(unlisted, tt.wantUnlisted) {
+				t.Errorf("renumberPositions() = %v, %v, want %v, %v", listed, unlisted, tt.wantListed, tt.wantUnlisted)
+			}
+		})
+	}
+}
+
+// assertNoCollisions fails when planned positions repeat or take an
+// unlisted item's position.
+func assertNoCollisions(t *testing.T, planned, others []int64) {
+	t.Helper()
+	taken := make(map[int64]bool, len(others))
+	for _, pos := range others {
+		taken[pos] = true
+	}
+	for i, pos := range planned {
+		if taken[pos] || i > 0 && pos <= planned[i-1] {
+			t.Errorf("position %d of %v collides", pos, planned)
+		}
+		taken[pos] = true
+	}
+}
@@ -3,22 +3,45 @@ package collection
 import (
 	"context"
 	"database/sql"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
 	"github.com/thienel/tugo/pkg/query"
 	"github.com/thienel/tugo/pkg/schema"
 )
 
 // Repository handles data access for dynamic collections.
 type Repository struct {
-	db *sqlx.DB
+	db      *sqlx.DB
+	dialect dialect.Dialect
+	session SessionHook
+	retry   RetryConfig
+	breaker *breaker
+
+	timestamps     TimestampConfig
+	decimalNumbers bool
 }
 
+// SessionHook prepares a transaction before any statement runs, such as
+// setting the database role and session variables for row-level security.
+type SessionHook func(ctx context.Context, tx *sqlx.Tx) error
+
 // NewRepository creates a new repository.
+// The SQL dialect is derived from the connection's driver name.
 func NewRepository(db *sqlx.DB) *Repository {
-	return &Repository{db: db}
+	return &Repository{db: db, dialect: dialect.ForDriver(db.DriverName())}
+}
+
+// SetSessionHook sets a hook run at the start of every transaction.
+// With a hook set, every query runs in a transaction.
+func (r *Repository) SetSessionHook(hook SessionHook) {
+	r.session = hook
 }
 
 // ListResult contains the results of a list query.
@@ -29,84 +52,471 @@ type ListResult struct {
 
 // List retrieves items with filtering, sorting, and pagination.
 func (r *Repository) List(ctx context.Context, collection *schema.Collection, opts ListOptions) (*ListResult, error) {
-	builder := query.NewBuilder(collection.TableName).
+	builder := r.listBuilder(collection, opts)
+
+	var result *ListResult
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		// Build and execute count query
+		countSQL, countArgs := builder.BuildCount()
+		var total int
+		if err := sqlx.GetContext(ctx, q, &total, countSQL, countArgs...); err != nil {
+			return dbError(ctx, err)
+		}
+
+		// Build and execute select query
+		selectSQL, selectArgs := builder.BuildSelect()
+		rows, err := q.QueryxContext(ctx, selectSQL, selectArgs...)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		items := make([]map[string]any, 0)
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		scanner.setFields(collection, r.encoder(ctx))
+		for rows.Next() {
+			item, err := scanner.scanMap()
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			if err := embedExpansions(item, opts.Expansions); err != nil {
+				return dbError(ctx, err)
+			}
+			items = append(items, item)
+		}
+
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+
+		result = &ListResult{
+			Items: items,
+			Total: total,
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// Export runs a list query and calls fn with each row encoded as JSON,
+// without building a map per row. Aliased columns are written under their
+// alias. The slice passed to fn is reused.
+func (r *Repository) Export(ctx context.Context, collection *schema.Collection, opts ListOptions, fn func(row []byte) error) error {
+	builder := r.listBuilder(collection, opts)
+
+	// Rows already passed to fn cannot be taken back, so exports are not retried
+	return r.run(ctx, false, func() error {
+		return r.timeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+			selectSQL, selectArgs := builder.BuildSelect()
+			rows, err := q.QueryxContext(ctx, selectSQL, selectArgs...)
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			defer rows.Close()
+
+			scanner, err := newRowScanner(rows)
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			scanner.setFields(collection, r.encoder(ctx))
+			scanner.setAliases(collection.Aliases)
+			buf := make([]byte, 0, 1024)
+			for rows.Next() {
+				if buf, err = scanner.appendJSON(buf[:0]); err != nil {
+					return dbError(ctx, err)
+				}
+				if err := fn(buf); err != nil {
+					return err
+				}
+			}
+
+			if err := rows.Err(); err != nil {
+				return dbError(ctx, err)
+			}
+			return nil
+		})
+	})
+}
+
+// QueryPlan is the EXPLAIN output of a statement.
+type QueryPlan struct {
+	SQL  string   `json:"sql"`
+	Args []any    `json:"args"`
+	Plan []string `json:"plan"`
+}
+
+// Explain returns the plans of the count and select statements of a list query.
+func (r *Repository) Explain(ctx context.Context, collection *schema.Collection, opts ListOptions) ([]QueryPlan, error) {
+	builder := r.listBuilder(collection, opts)
+	countSQL, countArgs := builder.BuildCount()
+	selectSQL, selectArgs := builder.BuildSelect()
+	plans := []QueryPlan{
+		{SQL: countSQL, Args: countArgs},
+		{SQL: selectSQL, Args: selectArgs},
+	}
+
+	prefix := "EXPLAIN "
+	if r.dialect.Name() == dialect.SQLite {
+		prefix = "EXPLAIN QUERY PLAN "
+	}
+
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		for i := range plans {
+			rows, err := q.QueryxContext(ctx, prefix+plans[i].SQL, plans[i].Args...)
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			plans[i].Plan, err = planLines(rows)
+			rows.Close()
+			if err != nil {
+				return dbError(ctx, err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return plans, nil
+}
+
+// listBuilder builds the query for a list request.
+func (r *Repository) listBuilder(collection *schema.Collection, opts ListOptions) *query.Builder {
+	return query.NewBuilder(collection.TableName).
+		WithDialect(r.dialect).
 		Where(opts.Filters).
 		OrderBy(opts.Sorts).
+		WithJoins(opts.Joins).
+		Select(opts.Fields...).
+		WithProjections(opts.Projections).
+		WithExpansions(opts.Expansions).
 		Paginate(opts.Pagination)
+}
 
-	// Build and execute count query
-	countSQL, countArgs := builder.BuildCount()
-	var total int
-	if err := r.db.GetContext(ctx, &total, countSQL, countArgs...); err != nil {
-		return nil, apperror.ErrInternalServer.WithError(err)
+// embedExpansions replaces the expansion columns of a row with the decoded
+// related objects. Rows without a related record are left unexpanded.
+func embedExpansions(item map[string]any, expansions []query.Expansion) error {
+	for _, e := range expansions {
+		raw, ok := item[e.Column()]
+		if !ok {
+			continue
+		}
+		delete(item, e.Column())
+
+		text, ok := raw.(string)
+		if !ok || text == "" {
+			continue
+		}
+		decoder := json.NewDecoder(strings.NewReader(text))
+		decoder.UseNumber()
+		var related map[string]any
+		if err := decoder.Decode(&related); err != nil {
+			return fmt.Errorf("failed to decode expanded relation '%s': %w", e.Name, err)
+		}
+		item[e.Name] = related
 	}
+	return nil
+}
 
-	// Build and execute select query
-	selectSQL, selectArgs := builder.BuildSelect()
-	rows, err := r.db.QueryxContext(ctx, selectSQL, selectArgs...)
+// planLines formats EXPLAIN rows as text. Single-column plans, as returned by
+// PostgreSQL, are used as is; other rows list their non-null columns.
+func planLines(rows *sqlx.Rows) ([]string, error) {
+	cols, err := rows.Columns()
 	if err != nil {
-		return nil, apperror.ErrInternalServer.WithError(err)
+		return nil, err
 	}
-	defer rows.Close()
 
-	items := make([]map[string]any, 0)
+	lines := make([]string, 0)
 	for rows.Next() {
-		item := make(map[string]any)
-		if err := rows.MapScan(item); err != nil {
-			return nil, apperror.ErrInternalServer.WithError(err)
+		values, err := rows.SliceScan()
+		if err != nil {
+			return nil, err
+		}
+		parts := make([]string, 0, len(values))
+		for i, v := range values {
+			if b, ok := v.([]byte); ok {
+				v = string(b)
+			}
+			switch {
+			case v == nil:
+			case len(cols) == 1:
+				parts = append(parts, fmt.Sprint(v))
+			default:
+				parts = append(parts, fmt.Sprintf("%s=%v", cols[i], v))
+			}
 		}
-		normalizeMapValues(item)
-		items = append(items, item)
+		lines = append(lines, strings.Join(parts, " "))
 	}
+	return lines, rows.Err()
+}
 
-	if err := rows.Err(); err != nil {
-		return nil, apperror.ErrInternalServer.WithError(err)
+// GetByID retrieves a single item by ID.
+func (r *Repository) GetByID(ctx context.Context, collection *schema.Collection, id any) (map[string]any, error) {
+	return r.getBy(ctx, collection, collection.PrimaryKey, "ID", id)
+}
+
+// GetByField retrieves the item whose unique field equals value.
+func (r *Repository) GetByField(ctx context.Context, collection *schema.Collection, field string, value any) (map[string]any, error) {
+	return r.getBy(ctx, collection, field, field, value)
+}
+
+// getBy retrieves the item whose column equals value, naming the column
+// label in errors.
+func (r *Repository) getBy(ctx context.Context, collection *schema.Collection, column, label string, value any) (map[string]any, error) {
+	item, err := r.scanBy(ctx, collection, column, label, value)
+	if err != nil {
+		return nil, err
 	}
+	normalizeMapValues(collection, item, r.encoder(ctx))
+	return item, nil
+}
 
-	return &ListResult{
-		Items: items,
-		Total: total,
-	}, nil
+// getStored retrieves an item with its values as stored, for use as query
+// arguments: only text scanned as bytes is converted.
+func (r *Repository) getStored(ctx context.Context, collection *schema.Collection, id any) (map[string]any, error) {
+	item, err := r.scanBy(ctx, collection, collection.PrimaryKey, "ID", id)
+	if err != nil {
+		return nil, err
+	}
+	types := encodedTypes(collection)
+	for k, v := range item {
+		if types[k] != "binary" {
+			item[k] = normalizeValue(v)
+		}
+	}
+	return item, nil
 }
 
-// GetByID retrieves a single item by ID.
-func (r *Repository) GetByID(ctx context.Context, collection *schema.Collection, id any) (map[string]any, error) {
-	builder := query.NewBuilder(collection.TableName)
-	querySQL, _ := builder.BuildSelectByID(collection.PrimaryKey)
+// scanBy scans the row whose column equals value, naming the column label
+// in errors.
+func (r *Repository) scanBy(ctx context.Context, collection *schema.Collection, column, label string, value any) (map[string]any, error) {
+	builder := query.NewBuilder(collection.TableName).WithDialect(r.dialect)
+	querySQL, _ := builder.BuildSelectByID(column)
 
-	row := r.db.QueryRowxContext(ctx, querySQL, id)
 	item := make(map[string]any)
-	if err := row.MapScan(item); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return nil, apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", id)
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		row := q.QueryRowxContext(ctx, querySQL, value)
+		if err := row.MapScan(item); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return apperror.ErrNotFound.WithMessagef("Item with %s '%v' not found", label, value)
+			}
+			if isInvalidUUIDError(err) {
+				return apperror.ErrBadRequest.WithMessagef("Invalid %s format: '%v'", label, value)
+			}
+			return dbError(ctx, err)
 		}
-		if isInvalidUUIDError(err) {
-			return nil, apperror.ErrBadRequest.WithMessagef("Invalid ID format: '%v'", id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+// GetByIDs retrieves items whose primary key is in ids with a single IN query.
+// The result is keyed by the string form of each primary key.
+func (r *Repository) GetByIDs(ctx context.Context, collection *schema.Collection, ids []string) (map[string]map[string]any, error) {
+	result := make(map[string]map[string]any, len(ids))
+	if len(ids) == 0 {
+		return result, nil
+	}
+
+	builder := query.NewBuilder(collection.TableName).
+		WithDialect(r.dialect).
+		Where([]query.Filter{
+			{Field: collection.PrimaryKey, Operator: query.OpIn, Value: joinStrings(ids, ",")},
+		}).
+		Paginate(query.Pagination{Page: 1, Limit: len(ids)})
+
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		selectSQL, selectArgs := builder.BuildSelect()
+		rows, err := q.QueryxContext(ctx, selectSQL, selectArgs...)
+		if err != nil {
+			if isInvalidUUIDError(err) {
+				return apperror.ErrBadRequest.WithMessage("Invalid ID format in batch")
+			}
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		scanner.setFields(collection, r.encoder(ctx))
+		for rows.Next() {
+			item, err := scanner.scanMap()
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			result[fmt.Sprint(item[collection.PrimaryKey])] = item
+		}
+
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
 		}
-		return nil, apperror.ErrInternalServer.WithError(err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	normalizeMapValues(item)
-	return item, nil
+	return result, nil
 }
 
 // Create inserts a new item.
 func (r *Repository) Create(ctx context.Context, collection *schema.Collection, data map[string]any) (map[string]any, error) {
-	querySQL, args := query.BuildInsert(collection.TableName, data)
+	if err := prepareValues(collection, data); err != nil {
+		return nil, err
+	}
+	querySQL, args := query.BuildInsertDialect(r.dialect, collection.TableName, data)
+
+	if !r.dialect.SupportsReturning() {
+		return r.createWithoutReturning(ctx, collection, querySQL, args, data)
+	}
 
-	row := r.db.QueryRowxContext(ctx, querySQL, args...)
 	result := make(map[string]any)
-	if err := row.MapScan(result); err != nil {
-		if isDuplicateKeyError(err) {
-			return nil, apperror.ErrConflict.WithMessage("Record already exists")
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		row := q.QueryRowxContext(ctx, querySQL, args...)
+		if err := row.MapScan(result); err != nil {
+			return duplicateError(ctx, err, "Record already exists")
 		}
-		return nil, apperror.ErrInternalServer.WithError(err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	normalizeMapValues(result)
+	normalizeMapValues(collection, result, r.encoder(ctx))
 	return result, nil
 }
 
+// maxInsertParams bounds the parameters of one multi-row INSERT, within
+// the limits of PostgreSQL, MySQL and SQLite.
+const maxInsertParams = 32766
+
+// CreateMany inserts items in one transaction and returns the number inserted.
+// Items are grouped by their set of fields, so omitted fields take their
+// column defaults. On PostgreSQL, groups of more than copyThreshold rows are
+// loaded with COPY; other groups use multi-row INSERTs. A copyThreshold of
+// zero or less disables COPY, as does a session hook, since COPY FROM is not
+// supported on tables with row-level security.
+func (r *Repository) CreateMany(ctx context.Context, collection *schema.Collection, items []map[string]any, copyThreshold int) (int64, error) {
+	type group struct {
+		columns []string
+		rows    [][]any
+	}
+	groups := make([]*group, 0, 1)
+	byColumns := make(map[string]*group)
+	for _, item := range items {
+		if err := prepareValues(collection, item); err != nil {
+			return 0, err
+		}
+		columns := make([]string, 0, len(item))
+		for col := range item {
+			columns = append(columns, col)
+		}
+		sort.Strings(columns)
+
+		key := strings.Join(columns, ",")
+		g, ok := byColumns[key]
+		if !ok {
+			g = &group{columns: columns}
+			byColumns[key] = g
+			groups = append(groups, g)
+		}
+		row := make([]any, len(columns))
+		for i, col := range columns {
+			row[i] = item[col]
+		}
+		g.rows = append(g.rows, row)
+	}
+
+	useCopy := copyThreshold > 0 && r.session == nil && r.dialect.Name() == dialect.Postgres
+
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		return 0, dbError(ctx, err)
+	}
+	defer conn.Close()
+
+	var created int64
+	err = r.withTxOn(ctx, conn, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		created = 0
+		for _, g := range groups {
+			if len(g.columns) == 0 {
+				return apperror.ErrBadRequest.WithMessage("Items must have at least one known field")
+			}
+
+			if useCopy && len(g.rows) > copyThreshold {
+				copied, err := r.copyRows(ctx, conn, tx, collection.TableName, g.columns, g.rows)
+				if err != nil {
+					return bulkError(ctx, err)
+				}
+				if copied {
+					created += int64(len(g.rows))
+					continue
+				}
+			}
+
+			size := max(1, maxInsertParams/len(g.columns))
+			for start := 0; start < len(g.rows); start += size {
+				chunk := g.rows[start:min(start+size, len(g.rows))]
+				querySQL, args := query.BuildInsertManyDialect(r.dialect, collection.TableName, g.columns, chunk)
+				if _, err := tx.ExecContext(ctx, querySQL, args...); err != nil {
+					return bulkError(ctx, err)
+				}
+				created += int64(len(chunk))
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return created, nil
+}
+
+// bulkError maps a failed bulk insert to an AppError.
+func bulkError(ctx context.Context, err error) error {
+	return duplicateError(ctx, err, "Record already exists")
+}
+
+// createWithoutReturning inserts a row and reads it back for dialects without RETURNING.
+func (r *Repository) createWithoutReturning(ctx context.Context, collection *schema.Collection, querySQL string, args []any, data map[string]any) (map[string]any, error) {
+	var res sql.Result
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		var err error
+		res, err = q.ExecContext(ctx, querySQL, args...)
+		if err != nil {
+			return duplicateError(ctx, err, "Record already exists")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefer the client-supplied primary key, fall back to the generated one
+	id, ok := data[collection.PrimaryKey]
+	if !ok {
+		lastID, err := res.LastInsertId()
+		if err != nil {
+			return nil, apperror.ErrInternalServer.WithError(err)
+		}
+		id = lastID
+	}
+
+	return r.GetByID(ctx, collection, id)
+}
+
 // Update updates an existing item.
 func (r *Repository) Update(ctx context.Context, collection *schema.Collection, id any, data map[string]any) (map[string]any, error) {
 	// Check if item exists
@@ -115,18 +525,38 @@ func (r *Repository) Update(ctx context.Context, collection *schema.Collection,
 		return nil, err
 	}
 
-	querySQL, args := query.BuildUpdate(collection.TableName, collection.PrimaryKey, id, data)
+	if err := prepareValues(collection, data); err != nil {
+		return nil, err
+	}
+
+	querySQL, args := query.BuildUpdateDialect(r.dialect, collection.TableName, collection.PrimaryKey, id, data)
+
+	if !r.dialect.SupportsReturning() {
+		err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+			if _, err := q.ExecContext(ctx, querySQL, args...); err != nil {
+				return duplicateError(ctx, err, "Record with this value already exists")
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		return r.GetByID(ctx, collection, id)
+	}
 
-	row := r.db.QueryRowxContext(ctx, querySQL, args...)
 	result := make(map[string]any)
-	if err := row.MapScan(result); err != nil {
-		if isDuplicateKeyError(err) {
-			return nil, apperror.ErrConflict.WithMessage("Record with this value already exists")
+	err = r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		row := q.QueryRowxContext(ctx, querySQL, args...)
+		if err := row.MapScan(result); err != nil {
+			return duplicateError(ctx, err, "Record with this value already exists")
 		}
-		return nil, apperror.ErrInternalServer.WithError(err)
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
-	normalizeMapValues(result)
+	normalizeMapValues(collection, result, r.encoder(ctx))
 	return result, nil
 }
 
@@ -138,13 +568,152 @@ func (r *Repository) Delete(ctx context.Context, collection *schema.Collection,
 		return err
 	}
 
-	querySQL := query.BuildDelete(collection.TableName, collection.PrimaryKey)
-	_, err = r.db.ExecContext(ctx, querySQL, id)
+	querySQL := query.BuildDeleteDialect(r.dialect, collection.TableName, collection.PrimaryKey)
+	return r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if _, err := q.ExecContext(ctx, querySQL, id); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+}
+
+// MaxInt returns the largest value of an integer field, or zero when the table is empty.
+func (r *Repository) MaxInt(ctx context.Context, collection *schema.Collection, field string) (int64, error) {
+	querySQL := query.BuildMaxDialect(r.dialect, collection.TableName, field)
+
+	var value int64
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if err := sqlx.GetContext(ctx, q, &value, querySQL); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
 	if err != nil {
-		return apperror.ErrInternalServer.WithError(err)
+		return 0, err
 	}
+	return value, nil
+}
 
-	return nil
+// SetPositions writes position values to an integer field in a single transaction.
+func (r *Repository) SetPositions(ctx context.Context, collection *schema.Collection, field string, positions []ItemPosition) error {
+	if len(positions) == 0 {
+		return nil
+	}
+
+	querySQL := query.BuildSetColumnDialect(r.dialect, collection.TableName, collection.PrimaryKey, field)
+
+	return r.withTx(ctx, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		for _, p := range positions {
+			if _, err := tx.ExecContext(ctx, querySQL, p.Position, p.ID); err != nil {
+				return dbError(ctx, err)
+			}
+		}
+		return nil
+	})
+}
+
+// Positions returns the items whose integer field lies between from and to,
+// ordered by it.
+func (r *Repository) Positions(ctx context.Context, collection *schema.Collection, field string, from, to int64) ([]ItemPosition, error) {
+	quote := r.dialect.QuoteIdent
+	querySQL := fmt.Sprintf("SELECT %s, %s FROM %s WHERE %s BETWEEN %s AND %s ORDER BY %s",
+		quote(collection.PrimaryKey), quote(field), quote(collection.TableName),
+		quote(field), r.dialect.Placeholder(1), r.dialect.Placeholder(2), quote(field))
+
+	var positions []ItemPosition
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		positions = make([]ItemPosition, 0)
+		rows, err := q.QueryxContext(ctx, querySQL, from, to)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var p ItemPosition
+			if err := rows.Scan(&p.ID, &p.Position); err != nil {
+				return dbError(ctx, err)
+			}
+			if b, ok := p.ID.([]byte); ok {
+				p.ID = string(b)
+			}
+			positions = append(positions, p)
+		}
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return positions, nil
+}
+
+// Tree retrieves the rows of a recursive tree query, shallowest first.
+func (r *Repository) Tree(ctx context.Context, collection *schema.Collection, q query.TreeQuery) ([]map[string]any, error) {
+	querySQL, args := query.BuildTreeSelect(r.dialect, q)
+	if querySQL == "" {
+		return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' cannot be queried as a tree", collection.Name)
+	}
+
+	var items []map[string]any
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		items = make([]map[string]any, 0)
+		rows, err := q.QueryxContext(ctx, querySQL, args...)
+		if err != nil {
+			if isInvalidUUIDError(err) {
+				return apperror.ErrBadRequest.WithMessage("Invalid ID format")
+			}
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		scanner.setFields(collection, r.encoder(ctx))
+		for rows.Next() {
+			item, err := scanner.scanMap()
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			items = append(items, item)
+		}
+
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return items, nil
+}
+
+// ExistsInScope reports whether an item exists and matches a scope condition
+// whose placeholders are numbered from 1.
+func (r *Repository) ExistsInScope(ctx context.Context, collection *schema.Collection, id any, scope string, scopeArgs []any) (bool, error) {
+	querySQL := query.BuildCountInScopeDialect(r.dialect, collection.TableName, collection.PrimaryKey, scope, len(scopeArgs))
+	args := append(append([]any{}, scopeArgs...), id)
+
+	var count int
+	err := r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if err := sqlx.GetContext(ctx, q, &count, querySQL, args...); err != nil {
+			if isInvalidUUIDError(err) {
+				return apperror.ErrBadRequest.WithMessage("Invalid ID format")
+			}
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
 }
 
 // GetRelated retrieves related items for expansion.
@@ -155,44 +724,177 @@ func (r *Repository) GetRelated(ctx context.Context, relatedCollection *schema.C
 
 	// Build IN query for related items
 	builder := query.NewBuilder(relatedCollection.TableName).
+		WithDialect(r.dialect).
 		Where([]query.Filter{
 			{Field: relatedCollection.PrimaryKey, Operator: query.OpIn, Value: interfacesToString(ids)},
 		})
 
-	selectSQL, selectArgs := builder.BuildSelect()
-	rows, err := r.db.QueryxContext(ctx, selectSQL, selectArgs...)
-	if err != nil {
-		return nil, apperror.ErrInternalServer.WithError(err)
-	}
-	defer rows.Close()
-
 	result := make(map[any]map[string]any)
-	for rows.Next() {
-		item := make(map[string]any)
-		if err := rows.MapScan(item); err != nil {
-			return nil, apperror.ErrInternalServer.WithError(err)
+	err := r.withTimeout(ctx, relatedCollection, func(ctx context.Context, q sqlx.ExtContext) error {
+		selectSQL, selectArgs := builder.BuildSelect()
+		rows, err := q.QueryxContext(ctx, selectSQL, selectArgs...)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			return dbError(ctx, err)
 		}
-		normalizeMapValues(item)
-		if id, ok := item[relatedCollection.PrimaryKey]; ok {
-			result[normalizeValue(id)] = item
+		scanner.setFields(relatedCollection, r.encoder(ctx))
+		for rows.Next() {
+			item, err := scanner.scanMap()
+			if err != nil {
+				return dbError(ctx, err)
+			}
+			if id, ok := item[relatedCollection.PrimaryKey]; ok {
+				result[normalizeValue(id)] = item
+			}
 		}
+
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return result, nil
 }
 
+// withTimeout runs fn with the collection's statement timeout applied.
+// PostgreSQL scopes the timeout to a transaction via SET LOCAL; other
+// dialects fall back to a context deadline. A session hook also forces a
+// transaction. Transient errors are retried, so fn must be safe to repeat.
+func (r *Repository) withTimeout(ctx context.Context, collection *schema.Collection, fn func(ctx context.Context, q sqlx.ExtContext) error) error {
+	return r.run(ctx, true, func() error {
+		return r.timeout(ctx, collection, fn)
+	})
+}
+
+// timeout runs fn once with the collection's statement timeout applied.
+func (r *Repository) timeout(ctx context.Context, collection *schema.Collection, fn func(ctx context.Context, q sqlx.ExtContext) error) error {
+	timeout := collection.StatementTimeout
+	if r.session == nil && timeout <= 0 {
+		return fn(ctx, r.db)
+	}
+
+	if r.session == nil && r.dialect.Name() != dialect.Postgres {
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return fn(ctx, r.db)
+	}
+
+	return r.inTx(ctx, r.db, collection, func(ctx context.Context, tx *sqlx.Tx) error {
+		return fn(ctx, tx)
+	})
+}
+
+// withTx runs fn in a transaction with the collection's statement timeout applied.
+// Transactions failing with transient errors are retried from the start.
+func (r *Repository) withTx(ctx context.Context, collection *schema.Collection, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	return r.withTxOn(ctx, r.db, collection, fn)
+}
+
+// txBeginner starts transactions; *sqlx.DB and *sqlx.Conn implement it.
+type txBeginner interface {
+	BeginTxx(ctx context.Context, opts *sql.TxOptions) (*sqlx.Tx, error)
+}
+
+// withTxOn runs fn in a transaction started on db, like withTx.
+func (r *Repository) withTxOn(ctx context.Context, db txBeginner, collection *schema.Collection, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	return r.run(ctx, true, func() error {
+		return r.inTx(ctx, db, collection, fn)
+	})
+}
+
+// inTx runs fn once in a transaction started on db.
+func (r *Repository) inTx(ctx context.Context, db txBeginner, collection *schema.Collection, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	timeout := collection.StatementTimeout
+	if timeout > 0 && r.dialect.Name() != dialect.Postgres {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	tx, err := db.BeginTxx(ctx, nil)
+	if err != nil {
+		return dbError(ctx, err)
+	}
+	defer tx.Rollback()
+
+	if r.session != nil {
+		if err := r.session(ctx, tx); err != nil {
+			return dbError(ctx, err)
+		}
+	}
+	if timeout > 0 && r.dialect.Name() == dialect.Postgres {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+			return dbError(ctx, err)
+		}
+	}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return dbError(ctx, err)
+	}
+	return nil
+}
+
 // ListOptions holds options for list queries.
 type ListOptions struct {
 	Filters    []query.Filter
 	Sorts      []query.Sort
 	Pagination query.Pagination
+
+	// Joins lists relations available to related-field sorts.
+	Joins []query.Join
+
+	// Fields limits the selected columns; empty selects all.
+	Fields []string
+
+	// Projections adds computed fields; with no Fields, only they are
+	// selected.
+	Projections []query.Projection
+
+	// Expansions embeds to-one relations in the list query itself.
+	Expansions []query.Expansion
 }
 
-// normalizeMapValues converts []byte to string and handles other type normalizations.
-func normalizeMapValues(m map[string]any) {
+// normalizeMapValues converts []byte to string, writes binary, timestamp
+// and decimal fields with enc and handles other type normalizations.
+func normalizeMapValues(collection *schema.Collection, m map[string]any, enc valueEncoder) {
+	types := encodedTypes(collection)
 	for k, v := range m {
+		if dataType := types[k]; dataType != "" {
+			m[k] = enc.encode(dataType, v)
+			continue
+		}
 		m[k] = normalizeValue(v)
 	}
+	encodeMoney(collection, m)
+}
+
+// prepareValues converts write data to query arguments: base64 strings of
+// binary fields to bytes, vectors to pgvector's text form and exact
+// decimals to strings.
+func prepareValues(collection *schema.Collection, data map[string]any) error {
+	if err := decodeBinary(collection, data); err != nil {
+		return err
+	}
+	if err := encodeVectors(collection, data); err != nil {
+		return err
+	}
+	for k, v := range data {
+		if n, ok := v.(json.Number); ok {
+			data[k] = n.String()
+		}
+	}
+	return nil
 }
 
 // normalizeValue normalizes a single value.
@@ -243,9 +945,55 @@ func isDuplicateKeyError(err error) bool {
 	if err == nil {
 		return false
 	}
-	// PostgreSQL error code for unique_violation is 23505
+	// PostgreSQL error code for unique_violation is 23505, MySQL uses 1062
+	errStr := err.Error()
+	return contains(errStr, "23505") || contains(errStr, "duplicate key") ||
+		contains(errStr, "Error 1062") || contains(errStr, "Duplicate entry") ||
+		contains(errStr, "UNIQUE constraint failed")
+}
+
+// dbError maps a database error to an AppError.
+// Constraint violations reported by PostgreSQL become a ConstraintViolation,
+// client disconnects 499, timeouts 504, row-level security violations 403
+// and unreachable databases 503.
+func dbError(ctx context.Context, err error) *apperror.AppError {
+	if appErr, ok := apperror.AsAppError(err); ok {
+		return appErr
+	}
+	if violation, ok := constraintViolation(err); ok {
+		return violation.appError().WithError(err)
+	}
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return apperror.ErrRequestCanceled.WithError(err)
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) || isQueryCanceledError(err):
+		return apperror.ErrTimeout.WithError(err)
+	case isRowSecurityError(err):
+		return apperror.ErrForbidden.WithError(err)
+	case isConnectionError(err):
+		return apperror.ErrServiceUnavailable.WithError(err)
+	default:
+		return apperror.ErrInternalServer.WithError(err)
+	}
+}
+
+// isQueryCanceledError checks if the database aborted a statement for exceeding its timeout.
+func isQueryCanceledError(err error) bool {
+	if err == nil {
+		return false
+	}
+	// PostgreSQL query_canceled is 57014, MySQL max_execution_time is 3024
 	errStr := err.Error()
-	return contains(errStr, "23505") || contains(errStr, "duplicate key")
+	return contains(errStr, "57014") || contains(errStr, "statement timeout") ||
+		contains(errStr, "Error 3024")
+}
+
+// isRowSecurityError checks if a write was rejected by a row-level security policy.
+func isRowSecurityError(err error) bool {
+	if err == nil {
+		return false
+	}
+	return contains(err.Error(), "row-level security policy")
 }
 
 // isInvalidUUIDError checks if an error is an invalid UUID format error.
@@ -0,0 +1,247 @@
+package collection
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"math/rand/v2"
+	"net"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/lib/pq"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Retry and circuit breaker defaults.
+const (
+	DefaultRetryAttempts   = 3
+	DefaultRetryBaseDelay  = 25 * time.Millisecond
+	DefaultRetryMaxDelay   = time.Second
+	DefaultBreakerFailures = 5
+	DefaultBreakerCooldown = 10 * time.Second
+)
+
+// RetryConfig configures retries of transient database errors.
+type RetryConfig struct {
+	// Attempts is the most times an operation runs. Serialization failures,
+	// deadlocks, busy databases and failures to connect are retried; errors
+	// after a statement may have reached the database are not.
+	// 1 disables retries. Default: 3
+	Attempts int
+
+	// BaseDelay is the backoff before the first retry, doubling with each
+	// further retry. Each wait is drawn at random up to the backoff.
+	// Default: 25ms
+	BaseDelay time.Duration
+
+	// MaxDelay caps the backoff.
+	// Default: 1s
+	MaxDelay time.Duration
+}
+
+// BreakerConfig configures the circuit breaker that fails fast while the
+// database is unreachable.
+type BreakerConfig struct {
+	// Failures is the number of consecutive connection failures that open the
+	// breaker. A negative value disables it.
+	// Default: 5
+	Failures int
+
+	// Cooldown is how long an open breaker rejects requests before letting
+	// one through to probe the database.
+	// Default: 10s
+	Cooldown time.Duration
+}
+
+// SetRetryConfig sets how transient database errors are retried.
+func (r *Repository) SetRetryConfig(config RetryConfig) {
+	if config.Attempts <= 0 {
+		config.Attempts = DefaultRetryAttempts
+	}
+	if config.BaseDelay <= 0 {
+		config.BaseDelay = DefaultRetryBaseDelay
+	}
+	if config.MaxDelay <= 0 {
+		config.MaxDelay = DefaultRetryMaxDelay
+	}
+	r.retry = config
+}
+
+// SetBreakerConfig sets the circuit breaker for database outages.
+func (r *Repository) SetBreakerConfig(config BreakerConfig) {
+	if config.Failures < 0 {
+		r.breaker = nil
+		return
+	}
+	if config.Failures == 0 {
+		config.Failures = DefaultBreakerFailures
+	}
+	if config.Cooldown <= 0 {
+		config.Cooldown = DefaultBreakerCooldown
+	}
+	r.breaker = &breaker{config: config}
+}
+
+// run calls fn through the circuit breaker, retrying transient errors with
+// jittered exponential backoff when retry is set. fn must be safe to repeat.
+func (r *Repository) run(ctx context.Context, retry bool, fn func() error) error {
+	attempts := r.retry.Attempts
+	if !retry || attempts < 1 {
+		attempts = 1
+	}
+
+	for attempt := 1; ; attempt++ {
+		if !r.breaker.allow() {
+			return apperror.ErrServiceUnavailable
+		}
+		err := fn()
+		r.breaker.record(isConnectionError(err))
+		if err == nil || attempt >= attempts || !isRetryableError(err) {
+			return err
+		}
+
+		timer := time.NewTimer(r.backoff(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return dbError(ctx, ctx.Err())
+		case <-timer.C:
+		}
+	}
+}
+
+// backoff returns a random wait up to the exponential backoff of an attempt.
+func (r *Repository) backoff(attempt int) time.Duration {
+	limit := r.retry.BaseDelay << (attempt - 1)
+	if limit <= 0 || limit > r.retry.MaxDelay {
+		limit = r.retry.MaxDelay
+	}
+	if limit <= 0 {
+		return 0
+	}
+	return rand.N(limit) + 1
+}
+
+// breaker is a circuit breaker counting consecutive connection failures.
+// A nil breaker allows everything.
+type breaker struct {
+	config BreakerConfig
+
+	mu       sync.Mutex
+	failures int
+	openedAt time.Time
+	probing  bool
+}
+
+// allow reports whether a database call may proceed. Once the cooldown of
+// an open breaker has passed, a single call is let through as a probe.
+func (b *breaker) allow() bool {
+	if b == nil {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.config.Failures {
+		return true
+	}
+	if b.probing || time.Since(b.openedAt) < b.config.Cooldown {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record notes the outcome of a call. Any answer from the database closes
+// the breaker; a connection failure counts towards opening it.
+func (b *breaker) record(failed bool) {
+	if b == nil {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	if !failed {
+		b.failures = 0
+		return
+	}
+	b.failures++
+	if b.failures >= b.config.Failures {
+		b.openedAt = time.Now()
+	}
+}
+
+// sqlState returns the SQLSTATE code of a PostgreSQL error.
+func sqlState(err error) string {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code)
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code
+	}
+	return ""
+}
+
+// isRetryableError checks if an error is transient and the failed operation
+// had no effect: serialization failures, deadlocks, a busy database, or a
+// connection that could not be established.
+func isRetryableError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch sqlState(err) {
+	case "40001", "40P01", "57P03", "08001", "08004":
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, syscall.ECONNREFUSED) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) && opErr.Op == "dial" {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	// MySQL deadlocks and lock wait timeouts, SQLite busy errors
+	errStr := err.Error()
+	return contains(errStr, "Error 1213") || contains(errStr, "Error 1205") ||
+		contains(errStr, "database is locked") || contains(errStr, "SQLITE_BUSY") ||
+		contains(errStr, "connection refused")
+}
+
+// isConnectionError checks if an error means the database could not be reached.
+func isConnectionError(err error) bool {
+	if err == nil {
+		return false
+	}
+	if code := sqlState(err); len(code) == 5 && (code[:2] == "08" || code == "57P01" || code == "57P02" || code == "57P03") {
+		return true
+	}
+	if errors.Is(err, driver.ErrBadConn) || errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.EPIPE) {
+		return true
+	}
+	var opErr *net.OpError
+	if errors.As(err, &opErr) {
+		return true
+	}
+	var connectErr *pgconn.ConnectError
+	if errors.As(err, &connectErr) {
+		return true
+	}
+
+	errStr := err.Error()
+	return contains(errStr, "connection refused") || contains(errStr, "broken pipe") ||
+		contains(errStr, "connection reset") || contains(errStr, "bad connection") ||
+		contains(errStr, "invalid connection")
+}
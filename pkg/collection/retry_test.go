@@ -0,0 +1,80 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"net"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+func TestRepository_RunRetries(t *testing.T) {
+	dialErr := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+
+	tests := []struct {
+		name  string
+		err   error
+		retry bool
+		calls int
+	}{
+		{"serialization failure", &pq.Error{Code: "40001"}, true, 3},
+		{"deadlock", &pq.Error{Code: "40P01"}, true, 3},
+		{"refused connection", dialErr, true, 3},
+		{"sqlite busy", errors.New("database is locked (5) (SQLITE_BUSY)"), true, 3},
+		{"constraint violation", &pq.Error{Code: "23505"}, true, 1},
+		{"reset connection", &net.OpError{Op: "read", Net: "tcp", Err: syscall.ECONNRESET}, true, 1},
+		{"retry disabled", &pq.Error{Code: "40001"}, false, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repository{}
+			r.SetRetryConfig(RetryConfig{Attempts: 3, BaseDelay: time.Microsecond})
+
+			calls := 0
+			err := r.run(context.Background(), tt.retry, func() error {
+				calls++
+				return tt.err
+			})
+			if !errors.Is(err, tt.err) {
+				t.Errorf("expected error %v, got %v", tt.err, err)
+			}
+			if calls != tt.calls {
+				t.Errorf("expected %d calls, got %d", tt.calls, calls)
+			}
+		})
+	}
+}
+
+func TestRepository_RunBreaker(t *testing.T) {
+	r := &Repository{}
+	r.SetRetryConfig(RetryConfig{Attempts: 1})
+	r.SetBreakerConfig(BreakerConfig{Failures: 2, Cooldown: 20 * time.Millisecond})
+
+	down := &net.OpError{Op: "dial", Net: "tcp", Err: syscall.ECONNREFUSED}
+	calls := 0
+	call := func(err error) error {
+		return r.run(context.Background(), true, func() error {
+			calls++
+			return err
+		})
+	}
+
+	call(down)
+	call(down)
+	if err := call(nil); err != apperror.ErrServiceUnavailable || calls != 2 {
+		t.Fatalf("expected open breaker to fail fast, got %v after %d calls", err, calls)
+	}
+
+	time.Sleep(30 * time.Millisecond)
+	if err := call(nil); err != nil || calls != 3 {
+		t.Fatalf("expected probe after cooldown to succeed, got %v after %d calls", err, calls)
+	}
+	if err := call(nil); err != nil || calls != 4 {
+		t.Fatalf("expected closed breaker, got %v after %d calls", err, calls)
+	}
+}
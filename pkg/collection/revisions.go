@@ -0,0 +1,183 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Revision actions.
+const (
+	RevisionActionUpdate  = "update"
+	RevisionActionDelete  = "delete"
+	RevisionActionRestore = "restore"
+)
+
+// Revision is a full previous version of a record.
+type Revision struct {
+	ID         int64          `db:"id" json:"id"`
+	Collection string         `db:"collection" json:"collection"`
+	ItemID     string         `db:"item_id" json:"item_id"`
+	Revision   int            `db:"revision" json:"revision"`
+	Action     string         `db:"action" json:"action"`
+	Data       map[string]any `db:"-" json:"data"`
+	UserID     *string        `db:"user_id" json:"user_id,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+
+	// Changes lists fields that differ between this revision and the version that replaced it.
+	Changes map[string]FieldChange `db:"-" json:"changes,omitempty"`
+
+	RawData []byte `db:"data" json:"-"`
+}
+
+// FieldChange describes a field's value before and after a change.
+type FieldChange struct {
+	From any `json:"from"`
+	To   any `json:"to"`
+}
+
+// RevisionStore persists record revisions in tugo_revisions.
+type RevisionStore struct {
+	db *sqlx.DB
+}
+
+// NewRevisionStore creates a new revision store.
+func NewRevisionStore(db *sqlx.DB) *RevisionStore {
+	return &RevisionStore{db: db}
+}
+
+// revisionAttempts bounds how often Record retries a revision number taken
+// by a concurrent transaction.
+const revisionAttempts = 3
+
+// recordRevisionSQL inserts a revision numbered after the item's latest one.
+// The derived table lets MySQL read the table it inserts into.
+const recordRevisionSQL = `
+	INSERT INTO tugo_revisions (collection, item_id, revision, action, data, user_id)
+	VALUES (?, ?, (
+		SELECT next_revision FROM (
+			SELECT COALESCE(MAX(revision), 0) + 1 AS next_revision
+			FROM tugo_revisions WHERE collection = ? AND item_id = ?
+		) AS latest
+	), ?, ?, ?)
+`
+
+// Record stores data as the next revision of an item in tx, the transaction
+// writing the version that replaces it. The number is allocated by the
+// INSERT itself; one taken by a concurrent transaction is retried from a
+// savepoint, so tx stays usable.
+func (s *RevisionStore) Record(ctx context.Context, tx *sqlx.Tx, collection, itemID, action string, data map[string]any, userID *string) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode revision: %w", err)
+	}
+
+	query := tx.Rebind(recordRevisionSQL)
+	for attempt := 1; ; attempt++ {
+		if _, err := tx.ExecContext(ctx, "SAVEPOINT tugo_revision"); err != nil {
+			return fmt.Errorf("failed to record revision: %w", err)
+		}
+		_, err := tx.ExecContext(ctx, query, collection, itemID, collection, itemID, action, string(payload), userID)
+		if err == nil {
+			break
+		}
+		if !isDuplicateKeyError(err) || attempt == revisionAttempts {
+			return fmt.Errorf("failed to record revision: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "ROLLBACK TO SAVEPOINT tugo_revision"); err != nil {
+			return fmt.Errorf("failed to record revision: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "RELEASE SAVEPOINT tugo_revision"); err != nil {
+		return fmt.Errorf("failed to record revision: %w", err)
+	}
+	return nil
+}
+
+// List returns all revisions of an item, newest first.
+func (s *RevisionStore) List(ctx context.Context, collection, itemID string) ([]Revision, error) {
+	query := `
+		SELECT id, collection, item_id, revision, action, data, user_id, created_at
+		FROM tugo_revisions
+		WHERE collection = ? AND item_id = ?
+		ORDER BY revision DESC
+	`
+	var revisions []Revision
+	if err := s.db.SelectContext(ctx, &revisions, s.db.Rebind(query), collection, itemID); err != nil {
+		return nil, fmt.Errorf("failed to list revisions: %w", err)
+	}
+
+	for i := range revisions {
+		if err := revisions[i].decode(); err != nil {
+			return nil, err
+		}
+	}
+	return revisions, nil
+}
+
+// Get returns a single revision of an item.
+func (s *RevisionStore) Get(ctx context.Context, collection, itemID string, revision int) (*Revision, error) {
+	query := `
+		SELECT id, collection, item_id, revision, action, data, user_id, created_at
+		FROM tugo_revisions
+		WHERE collection = ? AND item_id = ? AND revision = ?
+	`
+	var rev Revision
+	if err := s.db.GetContext(ctx, &rev, s.db.Rebind(query), collection, itemID, revision); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Revision %d of item '%s' not found", revision, itemID)
+		}
+		return nil, fmt.Errorf("failed to get revision: %w", err)
+	}
+
+	if err := rev.decode(); err != nil {
+		return nil, err
+	}
+	return &rev, nil
+}
+
+// decode unmarshals the stored JSON payload.
+func (r *Revision) decode() error {
+	r.Data = make(map[string]any)
+	if len(r.RawData) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(r.RawData, &r.Data); err != nil {
+		return fmt.Errorf("failed to decode revision %d: %w", r.Revision, err)
+	}
+	return nil
+}
+
+// diffRecords returns the fields whose values differ between from and to.
+// Values are compared in their JSON form so stored and live records match.
+func diffRecords(from, to map[string]any) map[string]FieldChange {
+	changes := make(map[string]FieldChange)
+	for k, v := range from {
+		if !jsonEqual(v, to[k]) {
+			changes[k] = FieldChange{From: v, To: to[k]}
+		}
+	}
+	for k, v := range to {
+		if _, ok := from[k]; !ok && v != nil {
+			changes[k] = FieldChange{From: nil, To: v}
+		}
+	}
+	return changes
+}
+
+// jsonEqual compares two values by their JSON representation.
+func jsonEqual(a, b any) bool {
+	var av, bv any
+	aj, _ := json.Marshal(a)
+	bj, _ := json.Marshal(b)
+	_ = json.Unmarshal(aj, &av)
+	_ = json.Unmarshal(bj, &bv)
+	return reflect.DeepEqual(av, bv)
+}
@@ -0,0 +1,141 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+)
+
+// revisionConn records the statements run on it, failing its INSERTs with
+// insertErrs in order. It is opened through revisionConnector.
+type revisionConn struct {
+	mu         sync.Mutex
+	insertErrs []error
+	statements []string
+	args       [][]driver.Value
+}
+
+type revisionStmt struct {
+	conn  *revisionConn
+	query string
+}
+
+type revisionTx struct{}
+
+type revisionConnector struct{ conn *revisionConn }
+
+type revisionDriver struct{}
+
+func (c revisionConnector) Connect(context.Context) (driver.Conn, error) { return c.conn, nil }
+func (c revisionConnector) Driver() driver.Driver                        { return revisionDriver{} }
+
+func (revisionDriver) Open(string) (driver.Conn, error) {
+	return nil, errors.New("open through revisionConnector")
+}
+
+func (c *revisionConn) Prepare(query string) (driver.Stmt, error) {
+	return &revisionStmt{conn: c, query: query}, nil
+}
+func (c *revisionConn) Close() error              { return nil }
+func (c *revisionConn) Begin() (driver.Tx, error) { return revisionTx{}, nil }
+
+func (revisionTx) Commit() error   { return nil }
+func (revisionTx) Rollback() error { return nil }
+
+func (s *revisionStmt) Close() error  { return nil }
+func (s *revisionStmt) NumInput() int { return -1 }
+func (s *revisionStmt) Query([]driver.Value) (driver.Rows, error) {
+	return nil, errors.New("unexpected query")
+}
+
+func (s *revisionStmt) Exec(args []driver.Value) (driver.Result, error) {
+	c := s.conn
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	statement := strings.Fields(s.query)[0]
+	if statement == "ROLLBACK" || statement == "RELEASE" {
+		statement += " " + strings.Fields(s.query)[1]
+	}
+	c.statements = append(c.statements, statement)
+	if statement != "INSERT" {
+		return driver.RowsAffected(0), nil
+	}
+	c.args = append(c.args, args)
+	if len(c.insertErrs) > 0 {
+		err := c.insertErrs[0]
+		c.insertErrs = c.insertErrs[1:]
+		if err != nil {
+			return nil, err
+		}
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func TestRevisionStoreRecord(t *testing.T) {
+	taken := &pq.Error{Code: "23505", Message: `duplicate key value violates unique constraint "tugo_revisions_collection_item_id_revision_key"`}
+
+	tests := []struct {
+		name       string
+		insertErrs []error
+		wantErr    bool
+		statements []string
+	}{
+		{
+			name:       "first attempt",
+			statements: []string{"SAVEPOINT", "INSERT", "RELEASE SAVEPOINT"},
+		},
+		{
+			name:       "number taken by a concurrent write",
+			insertErrs: []error{taken},
+			statements: []string{"SAVEPOINT", "INSERT", "ROLLBACK TO", "SAVEPOINT", "INSERT", "RELEASE SAVEPOINT"},
+		},
+		{
+			name:       "number taken on every attempt",
+			insertErrs: []error{taken, taken, taken},
+			wantErr:    true,
+			statements: []string{"SAVEPOINT", "INSERT", "ROLLBACK TO", "SAVEPOINT", "INSERT", "ROLLBACK TO", "SAVEPOINT", "INSERT"},
+		},
+		{
+			name:       "other error",
+			insertErrs: []error{errors.New("connection reset")},
+			wantErr:    true,
+			statements: []string{"SAVEPOINT", "INSERT"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn := &revisionConn{insertErrs: tt.insertErrs}
+			db := sqlx.NewDb(sql.OpenDB(revisionConnector{conn}), "postgres")
+			defer db.Close()
+
+			tx, err := db.Beginx()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer tx.Rollback()
+
+			userID := "u1"
+			err = NewRevisionStore(db).Record(context.Background(), tx, "posts", "7", RevisionActionUpdate, map[string]any{"title": "a"}, &userID)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Record() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(conn.statements, tt.statements) {
+				t.Errorf("statements = %v, want %v", conn.statements, tt.statements)
+			}
+			want := []driver.Value{"posts", "7", "posts", "7", RevisionActionUpdate, `{"title":"a"}`, "u1"}
+			if !reflect.DeepEqual(conn.args[0], want) {
+				t.Errorf("INSERT args = %v, want %v", conn.args[0], want)
+			}
+		})
+	}
+}
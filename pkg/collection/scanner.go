@@ -0,0 +1,258 @@
+package collection
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// rowScanner scans result rows while reusing its buffers across rows.
+// Unlike sqlx's MapScan it resolves the columns once, and it can encode
+// rows straight to JSON without building a map.
+type rowScanner struct {
+	rows    *sqlx.Rows
+	columns []string
+	values  []any
+	ptrs    []any
+
+	// order lists the column indexes in JSON key order: sorted by name, as
+	// encoding/json sorts map keys, keeping the last of duplicate names.
+	order []int
+	keys  [][]byte
+
+	// types holds the data types of columns whose values need encoding.
+	types []string
+	enc   valueEncoder
+
+	// collection is set when rows hold money fields, written as objects.
+	collection *schema.Collection
+
+	// aliases renames columns in JSON objects.
+	aliases map[string]string
+}
+
+// newRowScanner creates a scanner for rows.
+func newRowScanner(rows *sqlx.Rows) (*rowScanner, error) {
+	columns, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	s := &rowScanner{
+		rows:    rows,
+		columns: columns,
+		values:  make([]any, len(columns)),
+		ptrs:    make([]any, len(columns)),
+	}
+	for i := range s.values {
+		s.ptrs[i] = &s.values[i]
+	}
+
+	last := make(map[string]int, len(columns))
+	for i, col := range columns {
+		last[col] = i
+	}
+	for _, i := range last {
+		s.order = append(s.order, i)
+	}
+	sort.Slice(s.order, func(a, b int) bool { return columns[s.order[a]] < columns[s.order[b]] })
+
+	s.keys = make([][]byte, len(s.order))
+	for n, i := range s.order {
+		key, _ := json.Marshal(columns[i])
+		s.keys[n] = append(key, ':')
+	}
+	return s, nil
+}
+
+// setFields marks the columns holding the collection's binary, timestamp
+// and decimal fields, written with enc.
+func (s *rowScanner) setFields(collection *schema.Collection, enc valueEncoder) {
+	if len(collection.Money) > 0 {
+		s.collection = collection
+	}
+	types := encodedTypes(collection)
+	if len(types) == 0 {
+		return
+	}
+	s.types = make([]string, len(s.columns))
+	for i, col := range s.columns {
+		s.types[i] = types[col]
+	}
+	s.enc = enc
+}
+
+// setAliases writes the columns of aliases under their alias in JSON
+// objects.
+func (s *rowScanner) setAliases(aliases map[string]string) {
+	if len(aliases) == 0 {
+		return
+	}
+	s.aliases = aliases
+	names := make([]string, len(s.columns))
+	for i, col := range s.columns {
+		names[i] = col
+		if alias, ok := aliases[col]; ok {
+			names[i] = alias
+		}
+	}
+	sort.Slice(s.order, func(a, b int) bool { return names[s.order[a]] < names[s.order[b]] })
+	for n, i := range s.order {
+		key, _ := json.Marshal(names[i])
+		s.keys[n] = append(key, ':')
+	}
+}
+
+// dataType returns the data type of column i if its values need encoding.
+func (s *rowScanner) dataType(i int) string {
+	if s.types == nil {
+		return ""
+	}
+	return s.types[i]
+}
+
+// value returns the normalized value of column i.
+func (s *rowScanner) value(i int) any {
+	if dataType := s.dataType(i); dataType != "" {
+		return s.enc.encode(dataType, s.values[i])
+	}
+	return normalizeValue(s.values[i])
+}
+
+// scan reads the current row into the reused value buffer.
+func (s *rowScanner) scan() error {
+	for i := range s.values {
+		s.values[i] = nil
+	}
+	return s.rows.Scan(s.ptrs...)
+}
+
+// scanMap scans the current row into a new map with normalized values.
+func (s *rowScanner) scanMap() (map[string]any, error) {
+	if err := s.scan(); err != nil {
+		return nil, err
+	}
+	item := make(map[string]any, len(s.columns))
+	for i, col := range s.columns {
+		item[col] = s.value(i)
+	}
+	if s.collection != nil {
+		encodeMoney(s.collection, item)
+	}
+	return item, nil
+}
+
+// appendJSON scans the current row and appends it to buf as a JSON object.
+// The output matches encoding/json applied to the row's normalized map.
+func (s *rowScanner) appendJSON(buf []byte) ([]byte, error) {
+	// Money fields depend on other columns, so these rows go through a map
+	if s.collection != nil {
+		item, err := s.scanMap()
+		if err != nil {
+			return buf, err
+		}
+		if s.aliases != nil {
+			item = aliasMap(s.aliases, item)
+		}
+		encoded, err := json.Marshal(item)
+		return append(buf, encoded...), err
+	}
+
+	if err := s.scan(); err != nil {
+		return buf, err
+	}
+
+	buf = append(buf, '{')
+	for n, i := range s.order {
+		if n > 0 {
+			buf = append(buf, ',')
+		}
+		buf = append(buf, s.keys[n]...)
+
+		v := s.values[i]
+		switch dataType := s.dataType(i); {
+		case dataType == "binary":
+			if b, ok := v.([]byte); ok {
+				buf = append(buf, '"')
+				buf = base64.StdEncoding.AppendEncode(buf, b)
+				buf = append(buf, '"')
+				continue
+			}
+		case dataType != "":
+			v = s.enc.encode(dataType, v)
+		}
+
+		var err error
+		if buf, err = appendJSONValue(buf, v); err != nil {
+			return buf, err
+		}
+	}
+	return append(buf, '}'), nil
+}
+
+// appendJSONValue appends the JSON encoding of a scanned value, with []byte
+// encoded as a string. Common types are encoded in place; anything else
+// falls back to encoding/json.
+func appendJSONValue(buf []byte, v any) ([]byte, error) {
+	switch val := v.(type) {
+	case []byte:
+		if isPlainJSONString(string(val)) {
+			buf = append(buf, '"')
+			buf = append(buf, val...)
+			return append(buf, '"'), nil
+		}
+		v = string(val)
+	case nil:
+		return append(buf, "null"...), nil
+	case bool:
+		return strconv.AppendBool(buf, val), nil
+	case int64:
+		return strconv.AppendInt(buf, val, 10), nil
+	case int32:
+		return strconv.AppendInt(buf, int64(val), 10), nil
+	case int:
+		return strconv.AppendInt(buf, int64(val), 10), nil
+	case float64:
+		// encoding/json switches to exponent notation outside this range
+		if abs := math.Abs(val); abs == 0 || (abs >= 1e-6 && abs < 1e21) {
+			return strconv.AppendFloat(buf, val, 'f', -1, 64), nil
+		}
+	case string:
+		if isPlainJSONString(val) {
+			buf = append(buf, '"')
+			buf = append(buf, val...)
+			return append(buf, '"'), nil
+		}
+	case time.Time:
+		if y := val.Year(); y >= 0 && y <= 9999 {
+			buf = append(buf, '"')
+			buf = val.AppendFormat(buf, time.RFC3339Nano)
+			return append(buf, '"'), nil
+		}
+	}
+
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return buf, err
+	}
+	return append(buf, encoded...), nil
+}
+
+// isPlainJSONString reports whether s can be quoted without escaping,
+// including the HTML escaping encoding/json applies by default.
+func isPlainJSONString(s string) bool {
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c < 0x20 || c == '"' || c == '\\' || c == '<' || c == '>' || c == '&' || c >= utf8.RuneSelf {
+			return false
+		}
+	}
+	return true
+}
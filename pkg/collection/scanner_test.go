@@ -0,0 +1,215 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// exportRows is the row count of the export benchmarks.
+const exportRows = 10000
+
+// fakeDriver serves a fixed result set of mixed column types. The DSN is the
+// number of rows to return.
+type fakeDriver struct{}
+
+type fakeConn struct{ rows int }
+
+type fakeStmt struct{ rows int }
+
+type fakeRows struct {
+	n, rows int
+}
+
+func init() {
+	sql.Register("tugo_scanner_fake", fakeDriver{})
+}
+
+func (fakeDriver) Open(dsn string) (driver.Conn, error) {
+	n, err := strconv.Atoi(dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &fakeConn{rows: n}, nil
+}
+
+func (c *fakeConn) Prepare(string) (driver.Stmt, error) { return &fakeStmt{rows: c.rows}, nil }
+func (c *fakeConn) Close() error                        { return nil }
+func (c *fakeConn) Begin() (driver.Tx, error)           { return nil, driver.ErrSkip }
+
+func (s *fakeStmt) Close() error                               { return nil }
+func (s *fakeStmt) NumInput() int                              { return -1 }
+func (s *fakeStmt) Exec([]driver.Value) (driver.Result, error) { return nil, driver.ErrSkip }
+func (s *fakeStmt) Query([]driver.Value) (driver.Rows, error) {
+	return &fakeRows{rows: s.rows}, nil
+}
+
+func (r *fakeRows) Columns() []string {
+	return []string{"id", "title", "price", "active", "body", "created_at", "deleted_at"}
+}
+
+func (r *fakeRows) Close() error { return nil }
+
+func (r *fakeRows) Next(dest []driver.Value) error {
+	if r.n == r.rows {
+		return io.EOF
+	}
+	r.n++
+	dest[0] = int64(r.n)
+	dest[1] = "Item " + strconv.Itoa(r.n)
+	dest[2] = float64(r.n) / 4
+	dest[3] = r.n%2 == 0
+	dest[4] = []byte(`Line "one"` + "\n<b>two</b> é")
+	dest[5] = time.Date(2024, 1, 2, 3, 4, 5, r.n, time.UTC)
+	dest[6] = nil
+	return nil
+}
+
+// fakeQuery opens a result set of n rows.
+func fakeQuery(tb testing.TB, n int) *sqlx.Rows {
+	tb.Helper()
+	db, err := sqlx.Open("tugo_scanner_fake", strconv.Itoa(n))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { db.Close() })
+
+	rows, err := db.QueryxContext(context.Background(), "SELECT")
+	if err != nil {
+		tb.Fatal(err)
+	}
+	tb.Cleanup(func() { rows.Close() })
+	return rows
+}
+
+func TestRowScanner_AppendJSON(t *testing.T) {
+	rows := fakeQuery(t, 50)
+	scanner, err := newRowScanner(rows)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := fakeQuery(t, 50)
+	var buf []byte
+	for rows.Next() {
+		if !expected.Next() {
+			t.Fatal("expected result set ended early")
+		}
+		if buf, err = scanner.appendJSON(buf[:0]); err != nil {
+			t.Fatal(err)
+		}
+
+		item := make(map[string]any)
+		if err := expected.MapScan(item); err != nil {
+			t.Fatal(err)
+		}
+		for k, v := range item {
+			item[k] = normalizeValue(v)
+		}
+		want, err := json.Marshal(item)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if string(buf) != string(want) {
+			t.Fatalf("appendJSON() = %s, want %s", buf, want)
+		}
+	}
+}
+
+func TestAppendJSONValue(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+	}{
+		{"nil", nil},
+		{"bool", true},
+		{"int", int64(-42)},
+		{"float", 3.25},
+		{"small float", 1e-7},
+		{"large float", 1e21},
+		{"negative zero", -0.0},
+		{"plain string", "hello"},
+		{"escaped string", "a\"b\\c\t<&>"},
+		{"unicode string", "héllo  "},
+		{"bytes", []byte("raw")},
+		{"time", time.Date(2024, 5, 6, 7, 8, 9, 123000, time.FixedZone("", 3600))},
+		{"map", map[string]any{"a": 1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := appendJSONValue(nil, tt.value)
+			if err != nil {
+				t.Fatal(err)
+			}
+			want, _ := json.Marshal(normalizeValue(tt.value))
+			if string(got) != string(want) {
+				t.Errorf("appendJSONValue() = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func BenchmarkExport_MapScan(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rows := fakeQuery(b, exportRows)
+		for rows.Next() {
+			item := make(map[string]any)
+			if err := rows.MapScan(item); err != nil {
+				b.Fatal(err)
+			}
+			for k, v := range item {
+				item[k] = normalizeValue(v)
+			}
+			if _, err := json.Marshal(item); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkExport_ScanMap(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rows := fakeQuery(b, exportRows)
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for rows.Next() {
+			item, err := scanner.scanMap()
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := json.Marshal(item); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkExport_AppendJSON(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		rows := fakeQuery(b, exportRows)
+		scanner, err := newRowScanner(rows)
+		if err != nil {
+			b.Fatal(err)
+		}
+		buf := make([]byte, 0, 1024)
+		for rows.Next() {
+			if buf, err = scanner.appendJSON(buf[:0]); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Searcher serves ?search= queries from an external search engine.
+type Searcher interface {
+	// Searches reports whether the searcher serves collection's queries.
+	Searches(collection *schema.Collection) bool
+
+	// Search returns the IDs of collection's items matching term, best
+	// first.
+	Search(ctx context.Context, collection *schema.Collection, term string) ([]string, error)
+}
+
+// SetSearcher sets the searcher serving ?search= queries of the
+// collections it searches.
+func (s *Service) SetSearcher(searcher Searcher) {
+	s.searcher = searcher
+}
+
+// searchRanking is the order of the items matching a search.
+type searchRanking struct {
+	ranks map[string]int
+
+	// ordered is set when items are ordered by rank rather than a sort.
+	ordered bool
+}
+
+// externalSearch narrows a list to the items matching its ?search= term in
+// the search engine. Without a sort param the whole match is read and
+// ordered by rank, so the page is cut from the ranked items. It returns
+// nil when the collection is not searched externally.
+func (s *Service) externalSearch(ctx context.Context, collection *schema.Collection, params ListParams, opts *ListOptions) (*searchRanking, error) {
+	values := params.QueryParams["search"]
+	if s.searcher == nil || len(values) == 0 || values[0] == "" || !s.searcher.Searches(collection) {
+		return nil, nil
+	}
+
+	ids, err := s.searcher.Search(ctx, collection, values[0])
+	if err != nil {
+		return nil, apperror.ErrServiceUnavailable.WithMessage("Search is unavailable").WithError(err)
+	}
+
+	ranking := &searchRanking{ranks: make(map[string]int, len(ids))}
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		ranking.ranks[id] = i
+		args[i] = id
+	}
+	if len(ids) == 0 {
+		return ranking, nil
+	}
+	opts.Filters = append(opts.Filters, query.Filter{Field: collection.PrimaryKey, Operator: query.OpIn, Value: args})
+
+	if sorts := params.QueryParams["sort"]; len(sorts) == 0 || sorts[0] == "" {
+		ranking.ordered = true
+		opts.Pagination = query.Pagination{Page: 1, Limit: len(ids)}
+	}
+	return ranking, nil
+}
+
+// page orders the items of a ranked search by rank and cuts the requested
+// page from them.
+func (r *searchRanking) page(collection *schema.Collection, items []map[string]any, pagination query.Pagination) []map[string]any {
+	rank := func(item map[string]any) int {
+		if i, ok := r.ranks[fmt.Sprint(item[collection.PrimaryKey])]; ok {
+			return i
+		}
+		return len(r.ranks)
+	}
+	slices.SortStableFunc(items, func(a, b map[string]any) int {
+		return rank(a) - rank(b)
+	})
+
+	start := min(pagination.Offset, len(items))
+	end := min(start+pagination.Limit, len(items))
+	return items[start:end]
+}
@@ -0,0 +1,76 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// fakeSearcher returns fixed IDs for every search.
+type fakeSearcher struct {
+	ids []string
+	err error
+}
+
+func (f fakeSearcher) Searches(*schema.Collection) bool { return true }
+
+func (f fakeSearcher) Search(context.Context, *schema.Collection, string) ([]string, error) {
+	return f.ids, f.err
+}
+
+func TestExternalSearch(t *testing.T) {
+	collection := &schema.Collection{Name: "posts", PrimaryKey: "id"}
+	tests := []struct {
+		name        string
+		searcher    fakeSearcher
+		query       map[string][]string
+		wantFilter  bool
+		wantOrdered bool
+		wantErr     bool
+	}{
+		{"no term", fakeSearcher{ids: []string{"1"}}, map[string][]string{}, false, false, false},
+		{"ranked", fakeSearcher{ids: []string{"3", "1"}}, map[string][]string{"search": {"go"}}, true, true, false},
+		{"sorted", fakeSearcher{ids: []string{"3", "1"}}, map[string][]string{"search": {"go"}, "sort": {"title"}}, true, false, false},
+		{"no matches", fakeSearcher{}, map[string][]string{"search": {"go"}}, false, false, false},
+		{"engine down", fakeSearcher{err: errors.New("down")}, map[string][]string{"search": {"go"}}, false, false, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &Service{}
+			s.SetSearcher(tt.searcher)
+			opts := ListOptions{Pagination: query.Pagination{Page: 2, Limit: 1, Offset: 1}}
+			ranking, err := s.externalSearch(context.Background(), collection, ListParams{QueryParams: tt.query}, &opts)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("externalSearch() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got := len(opts.Filters) == 1; got != tt.wantFilter {
+				t.Errorf("filters = %v, want ID filter %v", opts.Filters, tt.wantFilter)
+			}
+			if ranking != nil && ranking.ordered != tt.wantOrdered {
+				t.Errorf("ordered = %v, want %v", ranking.ordered, tt.wantOrdered)
+			}
+			if tt.wantOrdered && opts.Pagination.Limit != len(tt.searcher.ids) {
+				t.Errorf("pagination = %+v, want all matches", opts.Pagination)
+			}
+		})
+	}
+}
+
+func TestSearchRanking_Page(t *testing.T) {
+	collection := &schema.Collection{PrimaryKey: "id"}
+	ranking := &searchRanking{ranks: map[string]int{"3": 0, "1": 1, "7": 2}}
+	items := []map[string]any{{"id": int64(1)}, {"id": int64(3)}, {"id": int64(7)}}
+
+	got := ranking.page(collection, items, query.Pagination{Page: 1, Limit: 2, Offset: 0})
+	if want := []map[string]any{{"id": int64(3)}, {"id": int64(1)}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("page() = %v, want %v", got, want)
+	}
+	if got := ranking.page(collection, items, query.Pagination{Page: 3, Limit: 2, Offset: 4}); len(got) != 0 {
+		t.Errorf("page() past the end = %v, want none", got)
+	}
+}
@@ -2,12 +2,20 @@ package collection
 
 import (
 	"context"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/idgen"
+	"github.com/thienel/tugo/pkg/inbox"
+	"github.com/thienel/tugo/pkg/permission"
 	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/requestlog"
 	"github.com/thienel/tugo/pkg/response"
 	"github.com/thienel/tugo/pkg/schema"
 	"github.com/thienel/tugo/pkg/validation"
+	"github.com/thienel/tugo/pkg/vector"
 	"go.uber.org/zap"
 )
 
@@ -16,7 +24,46 @@ type Service struct {
 	repo          *Repository
 	schemaManager *schema.Manager
 	validator     *validation.ValidatorRegistry
+	revisions     *RevisionStore
+	views         *ViewStore
+	approvals     *ApprovalStore
+	comments      *CommentStore
+	favorites     *FavoriteStore
+	inbox         *inbox.Inbox
 	logger        *zap.SugaredLogger
+
+	// slowQueryThreshold enables slow list query logging when positive
+	slowQueryThreshold time.Duration
+
+	// expand selects how list requests expand relations
+	expand ExpandConfig
+
+	// maxExportRows caps exports; zero uses DefaultMaxExportRows
+	maxExportRows int
+
+	// bulk configures batch creates
+	bulk BulkConfig
+
+	// notifier is told about written records when set
+	notifier RecordNotifier
+
+	// embedder fills in embedded vector fields when set
+	embedder vector.Embedder
+
+	// searcher serves ?search= from a search engine when set
+	searcher Searcher
+
+	// permissions checks reads of listed favorites when set
+	permissions *permission.Checker
+
+	// ids generates primary keys of collections with an ID strategy
+	ids *idgen.Generator
+
+	// queryTokens expands ?q= query tokens when set
+	queryTokens *QueryTokens
+
+	// locks freezes writes to locked collections when set
+	locks *Locks
 }
 
 // NewService creates a new collection service.
@@ -25,6 +72,7 @@ func NewService(repo *Repository, schemaManager *schema.Manager, logger *zap.Sug
 		repo:          repo,
 		schemaManager: schemaManager,
 		logger:        logger,
+		ids:           &idgen.Generator{},
 	}
 }
 
@@ -33,11 +81,24 @@ func (s *Service) SetValidator(v *validation.ValidatorRegistry) {
 	s.validator = v
 }
 
+// SetRevisionStore sets the store used by collections with history enabled.
+func (s *Service) SetRevisionStore(store *RevisionStore) {
+	s.revisions = store
+}
+
+// SetViewStore enables saved views.
+func (s *Service) SetViewStore(store *ViewStore) {
+	s.views = store
+}
+
 // ListParams holds parameters for listing items.
 type ListParams struct {
 	CollectionName string
 	QueryParams    map[string][]string
 	Expand         []string
+
+	// Debug returns the statements, plans and timings of the request.
+	Debug bool
 }
 
 // List retrieves a list of items with filtering, sorting, and pagination.
@@ -47,53 +108,76 @@ func (s *Service) List(ctx context.Context, params ListParams) (*ListResponse, e
 		return nil, err
 	}
 
-	// Get allowed field names for validation
-	fieldNames := getFieldNames(collection.Fields)
-
-	// Parse filters
-	filterParser := query.NewFilterParser(fieldNames)
-	filters, err := filterParser.Parse(params.QueryParams)
+	start := time.Now()
+	params, listOpts, err := s.listOptions(ctx, collection, params)
 	if err != nil {
 		return nil, err
 	}
-
-	// Parse sorts
-	sortParser := query.NewSortParser(fieldNames)
-	sortParam := ""
-	if sortStrs, ok := params.QueryParams["sort"]; ok && len(sortStrs) > 0 {
-		sortParam = sortStrs[0]
-	}
-	sorts, err := sortParser.Parse(sortParam)
+	pagination := listOpts.Pagination
+	ranking, err := s.externalSearch(ctx, collection, params, &listOpts)
 	if err != nil {
 		return nil, err
 	}
-
-	// Default sort by primary key if not specified
-	if len(sorts) == 0 && collection.PrimaryKey != "" {
-		sorts = query.DefaultSort(collection.PrimaryKey)
+	if ranking != nil && len(ranking.ranks) == 0 {
+		return &ListResponse{
+			Items:      []map[string]any{},
+			Pagination: response.NewPagination(pagination.Page, pagination.Limit, 0),
+		}, nil
 	}
+	parsed := time.Now()
 
-	// Parse pagination
-	pagination := query.ParsePagination(params.QueryParams)
+	// Reject expensive patterns before running anything
+	if err := checkCost(collection, query.Options{
+		Filters:    listOpts.Filters,
+		Pagination: pagination,
+		Expand:     params.Expand,
+	}); err != nil {
+		return nil, err
+	}
 
 	// Execute query
-	result, err := s.repo.List(ctx, collection, ListOptions{
-		Filters:    filters,
-		Sorts:      sorts,
-		Pagination: pagination,
-	})
+	joinExpand := len(params.Expand) > 0 && s.joinExpand(pagination)
+	if joinExpand {
+		listOpts.Expansions = s.expansions(collection, params.Expand)
+	}
+	queryStart := time.Now()
+	result, err := s.repo.List(ctx, collection, listOpts)
 	if err != nil {
 		return nil, err
 	}
+	if ranking != nil && ranking.ordered {
+		result.Items = ranking.page(collection, result.Items, pagination)
+	}
+	if err := s.translate(ctx, collection, result.Items); err != nil {
+		return nil, err
+	}
+	queried := time.Now()
+	s.observeList(ctx, collection, listOpts, params.QueryParams, queried.Sub(queryStart))
 
 	// Handle expand
-	if len(params.Expand) > 0 {
+	if len(params.Expand) > 0 && !joinExpand {
 		if err := s.expandItems(ctx, collection, result.Items, params.Expand); err != nil {
-			s.logger.Warnw("Failed to expand relationships", "error", err)
+			requestlog.Logger(ctx, s.logger).Warnw("Failed to expand relationships", "error", err)
 		}
 	}
+	expanded := time.Now()
+
+	var debug *DebugInfo
+	if params.Debug {
+		debug = &DebugInfo{Timing: DebugTiming{
+			ParseMS:  milliseconds(parsed.Sub(start)),
+			QueryMS:  milliseconds(queried.Sub(queryStart)),
+			ExpandMS: milliseconds(expanded.Sub(queried)),
+		}}
+		if debug.Queries, err = s.repo.Explain(ctx, collection, listOpts); err != nil {
+			debug.ExplainError = err.Error()
+		}
+		debug.Timing.ExplainMS = milliseconds(time.Since(expanded))
+		debug.Timing.TotalMS = milliseconds(time.Since(start))
+	}
 
 	return &ListResponse{
+		Debug: debug,
 		Items: result.Items,
 		Pagination: response.NewPagination(
 			pagination.Page,
@@ -103,6 +187,126 @@ func (s *Service) List(ctx context.Context, params ListParams) (*ListResponse, e
 	}, nil
 }
 
+// listOptions parses the filters, sorts, fields and page of a list request,
+// applying the saved view it names.
+func (s *Service) listOptions(ctx context.Context, collection *schema.Collection, params ListParams) (ListParams, ListOptions, error) {
+	if err := checkParams(collection, params.QueryParams); err != nil {
+		return params, ListOptions{}, err
+	}
+
+	var err error
+
+	// Apply a query token, then the saved view it or the request names
+	if tokens, ok := params.QueryParams[QueryParam]; ok && len(tokens) > 0 && tokens[0] != "" {
+		if params, err = s.applyQueryToken(collection.Name, params, tokens[0]); err != nil {
+			return params, ListOptions{}, err
+		}
+	}
+
+	// Apply a saved view under the request's own parameters
+	if views, ok := params.QueryParams["view"]; ok && len(views) > 0 && views[0] != "" {
+		if params, err = s.applyView(ctx, collection, params, views[0]); err != nil {
+			return params, ListOptions{}, err
+		}
+	}
+
+	// Apply the collection's defaults under the request and view
+	params = applyDefaults(collection, params)
+
+	// Get allowed field names for validation
+	fieldNames := getFieldNames(collection.Fields)
+
+	// Parse selected fields and projections
+	fields := []string(nil)
+	var projections []query.Projection
+	if fieldStrs, ok := params.QueryParams["fields"]; ok && len(fieldStrs) > 0 {
+		if fields, projections, err = selectFields(collection, fieldStrs[0]); err != nil {
+			return params, ListOptions{}, err
+		}
+	}
+
+	// Parse filters
+	filterParser := query.NewFilterParser(fieldNames)
+	filters, err := filterParser.Parse(params.QueryParams)
+	if err != nil {
+		return params, ListOptions{}, err
+	}
+	if filters, err = vectorFilters(collection, filters); err != nil {
+		return params, ListOptions{}, err
+	}
+	if filters, err = textFilters(collection, filters); err != nil {
+		return params, ListOptions{}, err
+	}
+	filters = resolveRelativeDates(collection, filters, time.Now().In(s.repo.location(ctx)))
+	if filters, err = coerceFilters(collection, filters); err != nil {
+		return params, ListOptions{}, err
+	}
+	if filters, err = moneyFilters(collection, filters); err != nil {
+		return params, ListOptions{}, err
+	}
+	filters = localizeDateFilters(collection, filters, s.repo.location(ctx))
+	if hidesUnpublished(ctx, collection) {
+		filters = append(filters, publishedFilter(collection))
+	}
+
+	// Parse sorts, allowing related fields of to-one relations
+	joins, err := s.sortJoins(ctx, collection)
+	if err != nil {
+		return params, ListOptions{}, err
+	}
+	sortParser := query.NewSortParser(fieldNames).WithJoins(joins)
+	sortParam := ""
+	if sortStrs, ok := params.QueryParams["sort"]; ok && len(sortStrs) > 0 {
+		sortParam = sortStrs[0]
+	}
+	sorts, err := sortParser.Parse(sortParam)
+	if err != nil {
+		return params, ListOptions{}, err
+	}
+
+	// Default sort by manual position, else by primary key, if not specified
+	if len(sorts) == 0 && hasSortOrder(collection) {
+		sorts = []query.Sort{{Field: SortOrderField, Direction: query.SortAsc}}
+		if collection.PrimaryKey != "" {
+			sorts = append(sorts, query.Sort{Field: collection.PrimaryKey, Direction: query.SortAsc})
+		}
+	}
+	if len(sorts) == 0 && collection.PrimaryKey != "" {
+		sorts = query.DefaultSort(collection.PrimaryKey)
+	}
+
+	// Order by similarity to a vector first, the other sorts breaking ties
+	if values, ok := params.QueryParams[SimilarityParam]; ok && len(values) > 0 && values[0] != "" {
+		similarity, err := similaritySort(collection, values[0])
+		if err != nil {
+			return params, ListOptions{}, err
+		}
+		sorts = append([]query.Sort{similarity}, sorts...)
+	}
+
+	// Seeded shuffles hash the primary key for a stable order across pages
+	for i := range sorts {
+		if sorts[i].Random {
+			sorts[i].Field = collection.PrimaryKey
+		}
+	}
+
+	// Parse pagination
+	pagination := query.ParsePaginationWithLimits(params.QueryParams, query.PaginationLimits{
+		DefaultLimit: collection.DefaultLimit,
+		MaxLimit:     collection.MaxLimit,
+	})
+
+	return params, ListOptions{
+		Filters:     filters,
+		Sorts:       sorts,
+		Pagination:  pagination,
+		Joins:       joins,
+		Fields:      fields,
+		Projections: projections,
+	}, nil
+}
+
 // Get retrieves a single item by ID.
 func (s *Service) Get(ctx context.Context, collectionName string, id any, expand []string) (map[string]any, error) {
 	collection, err := s.schemaManager.GetCollection(collectionName)
@@ -110,60 +314,337 @@ func (s *Service) Get(ctx context.Context, collectionName string, id any, expand
 		return nil, err
 	}
 
+	if err := checkExpand(collection, expand); err != nil {
+		return nil, err
+	}
+
+	item, err := s.getVisible(ctx, collection, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.prepareItem(ctx, collection, item, expand)
+}
+
+// getVisible retrieves an item by ID, hiding unpublished items from
+// public readers.
+func (s *Service) getVisible(ctx context.Context, collection *schema.Collection, id any) (map[string]any, error) {
 	item, err := s.repo.GetByID(ctx, collection, id)
 	if err != nil {
 		return nil, err
 	}
+	if !visible(ctx, collection, item) {
+		return nil, apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", id)
+	}
+	return item, nil
+}
+
+// ItemParams names an item that comments, favorites and other per-item
+// features attach to.
+type ItemParams struct {
+	CollectionName string
+	ItemID         string
+
+	// Scope is a row-level read permission filter the item must match.
+	Scope map[string]any
+}
+
+// checkReadable checks the item of params exists and the reader of ctx may
+// see it.
+func (s *Service) checkReadable(ctx context.Context, collection *schema.Collection, params ItemParams) error {
+	if _, err := s.getVisible(ctx, collection, params.ItemID); err != nil {
+		return err
+	}
+	if len(params.Scope) > 0 {
+		scope, scopeArgs := permission.NewFilterBuilder(0).WithDialect(s.repo.dialect).Build(params.Scope)
+		ok, err := s.repo.ExistsInScope(ctx, collection, params.ItemID, scope, scopeArgs)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			return apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", params.ItemID)
+		}
+	}
+	return nil
+}
+
+// GetByField retrieves the item whose unique field equals value, such as a
+// slug, email or SKU.
+func (s *Service) GetByField(ctx context.Context, collectionName, field, value string, expand []string) (map[string]any, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	unique := false
+	for _, f := range collection.Fields {
+		if f.Name == field {
+			unique = f.IsUnique || f.IsPrimaryKey || f.Name == collection.PrimaryKey
+			break
+		}
+	}
+	if !unique {
+		return nil, apperror.ErrBadRequest.WithMessagef("Field '%s' is not a unique field", field)
+	}
+	if err := checkExpand(collection, expand); err != nil {
+		return nil, err
+	}
+
+	item, err := s.repo.GetByField(ctx, collection, field, value)
+	if err != nil {
+		return nil, err
+	}
+	if !visible(ctx, collection, item) {
+		return nil, apperror.ErrNotFound.WithMessagef("Item with %s '%v' not found", field, value)
+	}
+	return s.prepareItem(ctx, collection, item, expand)
+}
+
+// prepareItem translates a single item and expands its relations.
+func (s *Service) prepareItem(ctx context.Context, collection *schema.Collection, item map[string]any, expand []string) (map[string]any, error) {
+	if err := s.translate(ctx, collection, []map[string]any{item}); err != nil {
+		return nil, err
+	}
 
 	// Handle expand
 	if len(expand) > 0 {
 		items := []map[string]any{item}
 		if err := s.expandItems(ctx, collection, items, expand); err != nil {
-			s.logger.Warnw("Failed to expand relationships", "error", err)
+			requestlog.Logger(ctx, s.logger).Warnw("Failed to expand relationships", "error", err)
 		}
 	}
 
 	return item, nil
 }
 
+// BatchGet retrieves items by ID in the requested order.
+// Items not found are returned as nil and listed in Missing.
+func (s *Service) BatchGet(ctx context.Context, collectionName string, ids []string, expand []string) (*BatchResponse, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	if collection.PrimaryKey == "" {
+		return nil, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no primary key", collectionName)
+	}
+	if len(ids) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("At least one ID is required")
+	}
+
+	maxIDs := query.PaginationLimits{MaxLimit: collection.MaxLimit}.Normalize().MaxLimit
+	if len(ids) > maxIDs {
+		return nil, apperror.ErrBadRequest.WithMessagef("At most %d IDs may be requested at once", maxIDs)
+	}
+
+	if err := checkExpand(collection, expand); err != nil {
+		return nil, err
+	}
+
+	found, err := s.repo.GetByIDs(ctx, collection, uniqueStrings(ids))
+	if err != nil {
+		return nil, err
+	}
+	items := make([]map[string]any, 0, len(found))
+	for id, item := range found {
+		if !visible(ctx, collection, item) {
+			delete(found, id)
+			continue
+		}
+		items = append(items, item)
+	}
+	if err := s.translate(ctx, collection, items); err != nil {
+		return nil, err
+	}
+
+	// Handle expand
+	if len(expand) > 0 && len(found) > 0 {
+		if err := s.expandItems(ctx, collection, items, expand); err != nil {
+			requestlog.Logger(ctx, s.logger).Warnw("Failed to expand relationships", "error", err)
+		}
+	}
+
+	result := &BatchResponse{
+		Items:   make([]map[string]any, len(ids)),
+		Missing: make([]string, 0),
+	}
+	for i, id := range ids {
+		if item, ok := found[id]; ok {
+			result.Items[i] = item
+		} else {
+			result.Missing = append(result.Missing, id)
+		}
+	}
+
+	return result, nil
+}
+
 // Create creates a new item.
+// Creates needing approval are stored as pending changes and fail with
+// apperror.ErrPendingApproval.
 func (s *Service) Create(ctx context.Context, collectionName string, data map[string]any) (map[string]any, error) {
 	collection, err := s.schemaManager.GetCollection(collectionName)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	filteredData, err := s.prepareCreate(ctx, collection, data)
+	if err != nil {
+		return nil, err
+	}
+	if s.needsApproval(ctx, collection, NotifyActionCreate) {
+		return nil, s.submitChange(ctx, collection, NotifyActionCreate, nil, data)
+	}
+
+	item, err := s.repo.Create(ctx, collection, filteredData)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, collection, NotifyActionCreate, item)
+	return item, nil
+}
+
+// prepareCreate filters, fills in and validates the data of a new item.
+func (s *Service) prepareCreate(ctx context.Context, collection *schema.Collection, data map[string]any) (map[string]any, error) {
+	filteredData, err := s.prepareNew(ctx, collection, data)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkUniqueConstraints(ctx, collection, nil, filteredData, nil); err != nil {
+		return nil, err
+	}
+	return filteredData, nil
+}
 
+// prepareNew is prepareCreate without checking multi-column unique
+// constraints, for items whose foreign key is only known once their parent
+// is written.
+func (s *Service) prepareNew(ctx context.Context, collection *schema.Collection, data map[string]any) (map[string]any, error) {
 	// Filter out unknown fields
 	filteredData := filterFields(data, collection.Fields)
+	if validationErr := normalizeNumbers(collection, filteredData); validationErr != nil {
+		return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
+	}
+
+	// Fill in timestamps and the user on the server
+	now := time.Now().UTC()
+	fillAutoFields(ctx, collection, filteredData, true, now)
+	if err := checkLifecycleCreate(ctx, collection, filteredData, now); err != nil {
+		return nil, err
+	}
+	if err := s.fillID(collection, filteredData); err != nil {
+		return nil, err
+	}
+	if err := s.fillSlugs(ctx, collection, filteredData, nil); err != nil {
+		return nil, err
+	}
+	if err := s.fillEmbeddings(ctx, collection, []map[string]any{filteredData}); err != nil {
+		return nil, err
+	}
+
+	// Append new items to the end of manually ordered lists
+	if hasSortOrder(collection) && filteredData[SortOrderField] == nil {
+		pos, err := s.nextPosition(ctx, collection)
+		if err != nil {
+			return nil, err
+		}
+		filteredData[SortOrderField] = pos
+	}
 
 	// Validate data
 	if s.validator != nil {
-		if validationErr := s.validator.Validate(ctx, collectionName, filteredData); validationErr != nil {
+		if validationErr := s.validator.Validate(ctx, collection.Name, filteredData); validationErr != nil {
 			return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
 		}
 	}
 
-	return s.repo.Create(ctx, collection, filteredData)
+	return filteredData, nil
 }
 
 // Update updates an existing item.
 func (s *Service) Update(ctx context.Context, collectionName string, id any, data map[string]any) (map[string]any, error) {
+	return s.update(ctx, collectionName, id, data, RevisionActionUpdate)
+}
+
+// update updates an item, recording the previous version under action when history is enabled.
+// Updates needing approval are stored as pending changes and fail with
+// apperror.ErrPendingApproval.
+func (s *Service) update(ctx context.Context, collectionName string, id any, data map[string]any, action string) (map[string]any, error) {
 	collection, err := s.schemaManager.GetCollection(collectionName)
 	if err != nil {
 		return nil, err
 	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return nil, err
+	}
+
+	filteredData, err := s.prepareUpdate(ctx, collection, id, data)
+	if err != nil {
+		return nil, err
+	}
+	if s.needsApproval(ctx, collection, NotifyActionUpdate) {
+		if _, err := s.repo.GetByID(ctx, collection, id); err != nil {
+			return nil, err
+		}
+		return nil, s.submitChange(ctx, collection, NotifyActionUpdate, id, data)
+	}
 
+	// Translated fields of a localized update go to the translations table
+	translated := splitTranslations(ctx, collection, filteredData)
+	var item map[string]any
+	if len(filteredData) > 0 || translated == nil {
+		item, err = s.repo.UpdateRecorded(ctx, collection, id, filteredData, s.revisionFunc(ctx, collection, action))
+	} else {
+		item, err = s.repo.GetByID(ctx, collection, id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if translated != nil {
+		if err := s.repo.SetTranslation(ctx, collection, id, localeFrom(ctx), translated); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.translate(ctx, collection, []map[string]any{item}); err != nil {
+		return nil, err
+	}
+
+	s.notify(ctx, collection, NotifyActionUpdate, item)
+	return item, nil
+}
+
+// prepareUpdate filters, fills in and validates the changes to an item.
+func (s *Service) prepareUpdate(ctx context.Context, collection *schema.Collection, id any, data map[string]any) (map[string]any, error) {
 	// Filter out unknown fields
 	filteredData := filterFields(data, collection.Fields)
+	if validationErr := normalizeNumbers(collection, filteredData); validationErr != nil {
+		return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
+	}
+	if err := s.checkImmutable(ctx, collection, id, filteredData); err != nil {
+		return nil, err
+	}
+	if err := s.checkLifecycleUpdate(ctx, collection, id, filteredData); err != nil {
+		return nil, err
+	}
+	fillAutoFields(ctx, collection, filteredData, false, time.Now().UTC())
+	if err := s.fillEmbeddings(ctx, collection, []map[string]any{filteredData}); err != nil {
+		return nil, err
+	}
 
 	// Validate data (for updates, we only validate provided fields - skip required check)
 	if s.validator != nil {
-		if validationErr := s.validator.ValidatePartial(ctx, collectionName, filteredData); validationErr != nil {
+		if validationErr := s.validator.ValidatePartial(ctx, collection.Name, filteredData); validationErr != nil {
 			return nil, apperror.ErrValidation.WithMessage(validationErr.Error()).WithDetails(validationErr.Errors)
 		}
 	}
+	if err := s.checkUniqueConstraints(ctx, collection, id, filteredData, nil); err != nil {
+		return nil, err
+	}
 
-	return s.repo.Update(ctx, collection, id, filteredData)
+	return filteredData, nil
 }
 
 // Delete removes an item by ID.
@@ -172,24 +653,34 @@ func (s *Service) Delete(ctx context.Context, collectionName string, id any) err
 	if err != nil {
 		return err
 	}
+	if err := s.checkUnlocked(collection); err != nil {
+		return err
+	}
+
+	// The deleted record is read back to record or announce it
+	var deleted map[string]any
+	record := s.revisionFunc(ctx, collection, RevisionActionDelete)
+	if record != nil || s.watches(collection, NotifyActionDelete) {
+		deleted, err = s.repo.DeleteRecorded(ctx, collection, id, record)
+	} else {
+		err = s.repo.Delete(ctx, collection, id)
+	}
+	if err != nil {
+		return err
+	}
+	s.deleteTranslations(ctx, collection, id)
+	s.deleteComments(ctx, collection, id)
+	s.deleteFavorites(ctx, collection, id)
 
-	return s.repo.Delete(ctx, collection, id)
+	s.notify(ctx, collection, NotifyActionDelete, deleted)
+	return nil
 }
 
 // expandItems expands relationships in items.
 func (s *Service) expandItems(ctx context.Context, collection *schema.Collection, items []map[string]any, expand []string) error {
 	for _, expandField := range expand {
-		rel, ok := s.schemaManager.GetRelationship(collection.Name, expandField+"_id")
+		rel, relatedCollection, ok := s.expandRelation(collection, expandField)
 		if !ok {
-			// Try without _id suffix
-			rel, ok = s.schemaManager.GetRelationship(collection.Name, expandField)
-			if !ok {
-				continue
-			}
-		}
-
-		relatedCollection, err := s.schemaManager.GetCollection(rel.RelatedCollection)
-		if err != nil {
 			continue
 		}
 
@@ -228,10 +719,102 @@ func (s *Service) expandItems(ctx context.Context, collection *schema.Collection
 	return nil
 }
 
+// sortJoins returns the to-one relations whose fields the request's user
+// may sort on: those of collections they may read without a row filter,
+// limited to the fields their field permissions allow, so the order of a
+// list never reveals values they cannot see.
+func (s *Service) sortJoins(ctx context.Context, collection *schema.Collection) ([]query.Join, error) {
+	joins := make([]query.Join, 0)
+	for _, join := range relationJoins(s.schemaManager, collection) {
+		related, err := s.schemaManager.GetCollection(join.Collection)
+		if err != nil {
+			continue
+		}
+		result, err := s.check(ctx, related, permission.ActionRead)
+		if err != nil {
+			return nil, err
+		}
+		if result != nil {
+			if !result.Allowed || len(result.Filter) > 0 {
+				continue
+			}
+			join.Fields = slices.DeleteFunc(join.Fields, func(field string) bool {
+				return !result.FieldPerms.Permits(field)
+			})
+		}
+		joins = append(joins, join.Join)
+	}
+	return joins, nil
+}
+
+// relationJoin is a to-one relation with the collection it joins.
+type relationJoin struct {
+	query.Join
+	Collection string
+}
+
+// relationJoins returns the to-one relations whose fields can be sorted on.
+// A relation is named like expand: the foreign key without its _id suffix.
+func relationJoins(manager *schema.Manager, collection *schema.Collection) []relationJoin {
+	joins := make([]relationJoin, 0)
+	for _, rel := range manager.GetRelationships(collection.Name) {
+		if rel.RelationshipType != "many_to_one" {
+			continue
+		}
+
+		related, err := manager.GetCollection(rel.RelatedCollection)
+		if err != nil {
+			continue
+		}
+
+		foreignColumn := related.PrimaryKey
+		for _, f := range collection.Fields {
+			if f.Name == rel.FieldName && f.ForeignKey != nil {
+				foreignColumn = f.ForeignKey.Column
+			}
+		}
+		if foreignColumn == "" {
+			continue
+		}
+
+		joins = append(joins, relationJoin{
+			Join: query.Join{
+				Name:          strings.TrimSuffix(rel.FieldName, "_id"),
+				Table:         related.TableName,
+				LocalColumn:   rel.FieldName,
+				ForeignColumn: foreignColumn,
+				Fields:        getFieldNames(related.Fields),
+			},
+			Collection: related.Name,
+		})
+	}
+	return joins
+}
+
 // ListResponse holds the response for list operations.
 type ListResponse struct {
 	Items      []map[string]any
 	Pagination *response.Pagination
+	Debug      *DebugInfo
+}
+
+// BatchResponse holds the response for batch get operations.
+type BatchResponse struct {
+	Items   []map[string]any `json:"items"`
+	Missing []string         `json:"missing"`
+}
+
+// uniqueStrings returns values without duplicates, preserving order.
+func uniqueStrings(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !seen[v] {
+			seen[v] = true
+			result = append(result, v)
+		}
+	}
+	return result
 }
 
 // getFieldNames extracts field names from a slice of fields.
@@ -243,6 +826,63 @@ func getFieldNames(fields []schema.Field) []string {
 	return names
 }
 
+// coerceFilters checks filter values against the types of their fields and
+// converts them, so a mistyped value is a 400 instead of a database error.
+func coerceFilters(collection *schema.Collection, filters []query.Filter) ([]query.Filter, error) {
+	types := make(map[string]string, len(collection.Fields))
+	for _, f := range collection.Fields {
+		types[f.Name] = f.DataType
+	}
+	validator := query.NewFilterValidator(getFieldNames(collection.Fields)).WithFieldTypes(types)
+	coerced, err := validator.CoerceFilters(filters)
+	if err != nil {
+		return nil, apperror.ErrInvalidFilter.WithMessage(err.Error())
+	}
+	return coerced, nil
+}
+
+// parseFields parses a comma-separated field list, rejecting unknown fields.
+// Fields prefixed with '-' are excluded instead, selecting every other field.
+func parseFields(value string, allowed []string) ([]string, error) {
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, f := range allowed {
+		allowedSet[f] = true
+	}
+
+	fields := make([]string, 0)
+	excluded := make(map[string]bool)
+	for _, f := range strings.Split(value, ",") {
+		if f = strings.TrimSpace(f); f == "" {
+			continue
+		}
+		name, exclude := strings.CutPrefix(f, "-")
+		if !allowedSet[name] {
+			return nil, apperror.ErrBadRequest.WithMessagef("Unknown field '%s'", name)
+		}
+		if exclude {
+			excluded[name] = true
+		} else {
+			fields = append(fields, name)
+		}
+	}
+
+	if len(excluded) == 0 {
+		return fields, nil
+	}
+	if len(fields) > 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("Fields cannot be both selected and excluded")
+	}
+	for _, f := range allowed {
+		if !excluded[f] {
+			fields = append(fields, f)
+		}
+	}
+	if len(fields) == 0 {
+		return nil, apperror.ErrBadRequest.WithMessage("At least one field must be selected")
+	}
+	return fields, nil
+}
+
 // filterFields removes fields that don't exist in the schema.
 func filterFields(data map[string]any, fields []schema.Field) map[string]any {
 	fieldSet := make(map[string]bool)
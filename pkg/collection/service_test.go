@@ -0,0 +1,36 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseFields(t *testing.T) {
+	allowed := []string{"id", "title", "body", "avatar"}
+
+	tests := []struct {
+		name    string
+		value   string
+		want    []string
+		wantErr bool
+	}{
+		{"selected", "title, id", []string{"title", "id"}, false},
+		{"excluded", "-avatar,-body", []string{"id", "title"}, false},
+		{"unknown", "title,secret", nil, true},
+		{"unknown excluded", "-secret", nil, true},
+		{"mixed", "title,-avatar", nil, true},
+		{"all excluded", "-id,-title,-body,-avatar", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseFields(tt.value, allowed)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseFields() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,66 @@
+package collection
+
+import (
+	"context"
+
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// fillSlugs generates the collection's slugs missing from create data from
+// their source fields, suffixed until unique. Slugs already taken by
+// earlier items of the same batch are recorded in taken when it is non-nil.
+func (s *Service) fillSlugs(ctx context.Context, collection *schema.Collection, data map[string]any, taken map[string]bool) error {
+	if len(collection.Slugs) == 0 {
+		return nil
+	}
+
+	checker := &batchChecker{
+		UniqueChecker: validation.NewDBUniqueChecker(s.repo.db, collection.PrimaryKey).WithDialect(s.repo.dialect),
+		taken:         taken,
+	}
+	for field, source := range collection.Slugs {
+		if current, ok := data[field].(string); ok && current != "" {
+			checker.add(field, current)
+			continue
+		}
+		value, _ := data[source].(string)
+		base := validation.Slugify(value)
+		if base == "" {
+			continue
+		}
+		slug, err := validation.UniqueSlug(ctx, checker, collection.TableName, field, base)
+		if err != nil {
+			return err
+		}
+		data[field] = slug
+		checker.add(field, slug)
+	}
+	return nil
+}
+
+// batchChecker treats values taken by earlier items of a batch as used.
+type batchChecker struct {
+	validation.UniqueChecker
+	taken map[string]bool
+}
+
+// IsUnique checks the batch before the database.
+func (c *batchChecker) IsUnique(ctx context.Context, table, column string, value any, excludeID any) (bool, error) {
+	if s, ok := value.(string); ok && c.taken[slugKey(column, s)] {
+		return false, nil
+	}
+	return c.UniqueChecker.IsUnique(ctx, table, column, value, excludeID)
+}
+
+// add records a value as taken by the batch.
+func (c *batchChecker) add(column, value string) {
+	if c.taken != nil {
+		c.taken[slugKey(column, value)] = true
+	}
+}
+
+// slugKey identifies a value of a column in a batch's taken set.
+func slugKey(column, value string) string {
+	return column + "\x00" + value
+}
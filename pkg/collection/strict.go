@@ -0,0 +1,49 @@
+package collection
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// listParams are the query parameters of list and export requests, besides
+// filter[field] and filter[field:op].
+var listParams = []string{DebugParam, "expand", "fields", "limit", "locale", "order_by_similarity", "page", QueryParam, "search", "sort", "tz", "view"}
+
+// checkParams rejects unknown query parameters and malformed filter keys when
+// the collection is strict about them, listing the valid options.
+func checkParams(collection *schema.Collection, params map[string][]string) error {
+	if !collection.StrictParams {
+		return nil
+	}
+
+	keys := make([]string, 0, len(params))
+	for key := range params {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		switch {
+		case query.IsFilterKey(key):
+		case strings.HasPrefix(key, "filter"):
+			return apperror.ErrInvalidFilter.WithMessagef("Malformed filter parameter '%s'; use filter[field] or filter[field:op]", key).
+				WithDetails(map[string]any{
+					"parameter": key,
+					"fields":    getFieldNames(collection.Fields),
+					"operators": query.FilterOperators(),
+				})
+		case !slices.Contains(listParams, key):
+			return apperror.ErrBadRequest.WithMessagef("Unknown query parameter '%s'", key).
+				WithDetails(map[string]any{
+					"parameter": key,
+					"valid":     append(append([]string(nil), listParams...), "filter[field]", "filter[field:op]"),
+				})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,33 @@
+package collection
+
+import (
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestCheckParams(t *testing.T) {
+	strict := &schema.Collection{Name: "posts", StrictParams: true, Fields: []schema.Field{{Name: "title"}}}
+
+	tests := []struct {
+		name       string
+		collection *schema.Collection
+		params     map[string][]string
+		wantErr    bool
+	}{
+		{"known parameters", strict, map[string][]string{"page": {"2"}, "sort": {"-title"}, "filter[title:like]": {"go"}}, false},
+		{"unknown parameter", strict, map[string][]string{"pgae": {"2"}}, true},
+		{"misspelled filter", strict, map[string][]string{"fitler[title]": {"go"}}, true},
+		{"malformed filter", strict, map[string][]string{"filter[title:EQ]": {"go"}}, true},
+		{"lenient collection", &schema.Collection{Name: "posts"}, map[string][]string{"pgae": {"2"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkParams(tt.collection, tt.params)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("checkParams() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
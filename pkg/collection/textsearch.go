@@ -0,0 +1,69 @@
+package collection
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// textFilters checks _ieq and _similar filters against the text search
+// support of their fields. _ieq on citext fields becomes a plain equality,
+// and _similar values of the form "text" or "threshold:text" are parsed.
+func textFilters(collection *schema.Collection, filters []query.Filter) ([]query.Filter, error) {
+	fields := make(map[string]schema.Field, len(collection.Fields))
+	for _, f := range collection.Fields {
+		fields[f.Name] = f
+	}
+
+	for i, f := range filters {
+		if f.Operator != query.OpCaseInsensitiveEqual && f.Operator != query.OpSimilar {
+			continue
+		}
+		field := fields[f.Field]
+		if field.DataType != "string" {
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Filter '%s' needs a text field", f.Operator).
+				WithDetails(map[string]any{"field": f.Field})
+		}
+		raw, _ := f.Value.(string)
+
+		if f.Operator == query.OpCaseInsensitiveEqual {
+			if field.PostgresType == "citext" {
+				filters[i].Operator = query.OpEqual
+			}
+			continue
+		}
+
+		if !collection.TextSearch.Trigram {
+			return nil, apperror.ErrInvalidFilter.WithMessagef(
+				"Filter '_similar' needs PostgreSQL with the %s extension; run CREATE EXTENSION %s", schema.TrigramExtension, schema.TrigramExtension)
+		}
+		if !slices.Contains(collection.TextSearch.TrigramFields, f.Field) {
+			return nil, apperror.ErrInvalidFilter.WithMessagef(
+				"Field '%s' has no trigram index; run CREATE INDEX ON %s USING gin (%s gin_trgm_ops)", f.Field, collection.TableName, f.Field).
+				WithDetails(map[string]any{"field": f.Field})
+		}
+		similar, ok := parseSimilarity(raw)
+		if !ok {
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Invalid value for filter '%s'; use text or threshold:text", f.Field)
+		}
+		filters[i].Value = similar
+	}
+	return filters, nil
+}
+
+// parseSimilarity parses a _similar value, "text" or "threshold:text". A
+// prefix that is not a number between 0 and 1 is part of the text, which
+// must not be blank.
+func parseSimilarity(raw string) (query.Similarity, bool) {
+	similar := query.Similarity{Text: raw, Threshold: query.DefaultSimilarityThreshold}
+	if prefix, text, ok := strings.Cut(raw, ":"); ok {
+		if threshold, err := strconv.ParseFloat(strings.TrimSpace(prefix), 64); err == nil && threshold >= 0 && threshold <= 1 {
+			similar = query.Similarity{Text: text, Threshold: threshold}
+		}
+	}
+	return similar, strings.TrimSpace(similar.Text) != ""
+}
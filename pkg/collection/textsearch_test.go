@@ -0,0 +1,98 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestTextFilters(t *testing.T) {
+	collection := &schema.Collection{
+		TableName: "api_users",
+		Fields: []schema.Field{
+			{Name: "name", DataType: "string", PostgresType: "varchar"},
+			{Name: "email", DataType: "string", PostgresType: "citext"},
+			{Name: "bio", DataType: "string", PostgresType: "text"},
+			{Name: "age", DataType: "int", PostgresType: "int4"},
+		},
+		TextSearch: schema.TextSearch{Trigram: true, TrigramFields: []string{"name"}},
+	}
+
+	tests := []struct {
+		name       string
+		collection *schema.Collection
+		filter     query.Filter
+		want       query.Filter
+		wantErr    bool
+	}{
+		{
+			name:   "ieq lowers text",
+			filter: query.Filter{Field: "name", Operator: query.OpCaseInsensitiveEqual, Value: "Ann"},
+			want:   query.Filter{Field: "name", Operator: query.OpCaseInsensitiveEqual, Value: "Ann"},
+		},
+		{
+			name:   "ieq on citext is equality",
+			filter: query.Filter{Field: "email", Operator: query.OpCaseInsensitiveEqual, Value: "Ann@Example.com"},
+			want:   query.Filter{Field: "email", Operator: query.OpEqual, Value: "Ann@Example.com"},
+		},
+		{
+			name:    "ieq on number",
+			filter:  query.Filter{Field: "age", Operator: query.OpCaseInsensitiveEqual, Value: "3"},
+			wantErr: true,
+		},
+		{
+			name:   "similar with default threshold",
+			filter: query.Filter{Field: "name", Operator: query.OpSimilar, Value: "jon"},
+			want:   query.Filter{Field: "name", Operator: query.OpSimilar, Value: query.Similarity{Text: "jon", Threshold: 0.3}},
+		},
+		{
+			name:   "similar with threshold",
+			filter: query.Filter{Field: "name", Operator: query.OpSimilar, Value: "0.6:jon"},
+			want:   query.Filter{Field: "name", Operator: query.OpSimilar, Value: query.Similarity{Text: "jon", Threshold: 0.6}},
+		},
+		{
+			name:   "similar text with colon",
+			filter: query.Filter{Field: "name", Operator: query.OpSimilar, Value: "re:jon"},
+			want:   query.Filter{Field: "name", Operator: query.OpSimilar, Value: query.Similarity{Text: "re:jon", Threshold: 0.3}},
+		},
+		{
+			name:    "similar blank text",
+			filter:  query.Filter{Field: "name", Operator: query.OpSimilar, Value: "0.5: "},
+			wantErr: true,
+		},
+		{
+			name:    "similar without trigram index",
+			filter:  query.Filter{Field: "bio", Operator: query.OpSimilar, Value: "jon"},
+			wantErr: true,
+		},
+		{
+			name:       "similar without extension",
+			collection: &schema.Collection{Fields: collection.Fields},
+			filter:     query.Filter{Field: "name", Operator: query.OpSimilar, Value: "jon"},
+			wantErr:    true,
+		},
+		{
+			name:   "other operators untouched",
+			filter: query.Filter{Field: "age", Operator: query.OpEqual, Value: "3"},
+			want:   query.Filter{Field: "age", Operator: query.OpEqual, Value: "3"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := tt.collection
+			if c == nil {
+				c = collection
+			}
+			got, err := textFilters(c, []query.Filter{tt.filter})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("textFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got[0], tt.want) {
+				t.Errorf("textFilters() = %+v, want %+v", got[0], tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// Timestamp output formats.
+const (
+	// TimestampISO8601 writes RFC 3339 strings with the zone offset.
+	TimestampISO8601 = "iso8601"
+
+	// TimestampEpochMillis writes milliseconds since the Unix epoch.
+	TimestampEpochMillis = "epoch_millis"
+
+	// TimestampNaive writes local wall time without an offset.
+	TimestampNaive = "naive"
+)
+
+// TimezoneHeader names the time zone of a request; the tz query parameter
+// does the same.
+const TimezoneHeader = "X-Timezone"
+
+// naiveLayout formats timestamps for TimestampNaive.
+const naiveLayout = "2006-01-02T15:04:05.999999999"
+
+// TimestampConfig configures how timestamp fields are written in responses.
+type TimestampConfig struct {
+	// Format is TimestampISO8601, TimestampEpochMillis or TimestampNaive.
+	// Default: TimestampISO8601
+	Format string
+
+	// Location is the time zone timestamps are converted to, and date-only
+	// filters are read in, when a request names none. Timestamps stored
+	// without a zone are taken to be UTC.
+	// Default: UTC
+	Location *time.Location
+}
+
+// locationKey is the context key of a request's time zone.
+type locationKey struct{}
+
+// WithLocation returns a context carrying the time zone of a request.
+func WithLocation(ctx context.Context, loc *time.Location) context.Context {
+	return context.WithValue(ctx, locationKey{}, loc)
+}
+
+// SetTimestampConfig sets how timestamp fields are written.
+func (r *Repository) SetTimestampConfig(config TimestampConfig) error {
+	switch config.Format {
+	case "":
+		config.Format = TimestampISO8601
+	case TimestampISO8601, TimestampEpochMillis, TimestampNaive:
+	default:
+		return fmt.Errorf("unknown timestamp format %q", config.Format)
+	}
+	if config.Location == nil {
+		config.Location = time.UTC
+	}
+	r.timestamps = config
+	return nil
+}
+
+// location returns the time zone of the request, or the configured one.
+func (r *Repository) location(ctx context.Context) *time.Location {
+	if loc, ok := ctx.Value(locationKey{}).(*time.Location); ok {
+		return loc
+	}
+	if r.timestamps.Location != nil {
+		return r.timestamps.Location
+	}
+	return time.UTC
+}
+
+// encodeTime returns the function writing timestamp values for a request.
+func (r *Repository) encodeTime(ctx context.Context) func(time.Time) any {
+	loc := r.location(ctx)
+	switch r.timestamps.Format {
+	case TimestampEpochMillis:
+		return func(t time.Time) any { return t.UnixMilli() }
+	case TimestampNaive:
+		return func(t time.Time) any { return t.In(loc).Format(naiveLayout) }
+	default:
+		return func(t time.Time) any { return t.In(loc) }
+	}
+}
+
+// timestampFields returns the names of the collection's timestamp fields,
+// or nil when it has none.
+func timestampFields(collection *schema.Collection) map[string]bool {
+	var fields map[string]bool
+	for _, f := range collection.Fields {
+		if f.DataType == "timestamp" {
+			if fields == nil {
+				fields = make(map[string]bool)
+			}
+			fields[f.Name] = true
+		}
+	}
+	return fields
+}
+
+// localizeDateFilters reads date-only filter values on timestamp fields as
+// whole days in loc: equality becomes a range over the day, and the bounds
+// of the other comparisons move to the start of the day or the next one.
+func localizeDateFilters(collection *schema.Collection, filters []query.Filter, loc *time.Location) []query.Filter {
+	timestamps := timestampFields(collection)
+	if len(timestamps) == 0 {
+		return filters
+	}
+
+	result := make([]query.Filter, 0, len(filters))
+	for _, f := range filters {
+		value, ok := f.Value.(string)
+		if !ok || !timestamps[f.Field] {
+			result = append(result, f)
+			continue
+		}
+		day, err := time.ParseInLocation(time.DateOnly, value, loc)
+		if err != nil {
+			result = append(result, f)
+			continue
+		}
+
+		start, next := filterTime(day), filterTime(day.AddDate(0, 0, 1))
+		switch f.Operator {
+		case query.OpEqual:
+			result = append(result,
+				query.Filter{Field: f.Field, Operator: query.OpGreaterEqual, Value: start},
+				query.Filter{Field: f.Field, Operator: query.OpLessThan, Value: next})
+		case query.OpGreaterEqual, query.OpLessThan:
+			result = append(result, query.Filter{Field: f.Field, Operator: f.Operator, Value: start})
+		case query.OpGreaterThan:
+			result = append(result, query.Filter{Field: f.Field, Operator: query.OpGreaterEqual, Value: next})
+		case query.OpLessEqual:
+			result = append(result, query.Filter{Field: f.Field, Operator: query.OpLessThan, Value: next})
+		default:
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+// filterTime formats a filter bound in UTC, in a form PostgreSQL, MySQL and
+// SQLite all compare against their timestamp columns.
+func filterTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02 15:04:05-07:00")
+}
+
+// timezone resolves the time zone named by the X-Timezone header or the tz
+// query parameter and stores it in the request context.
+func (h *Handler) timezone(c *gin.Context) {
+	name := c.GetHeader(TimezoneHeader)
+	if name == "" {
+		name = c.Query("tz")
+	}
+	if name == "" {
+		c.Next()
+		return
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil || name == "Local" {
+		h.handleError(c, apperror.ErrBadRequest.WithMessagef("Unknown time zone '%s'", name))
+		c.Abort()
+		return
+	}
+	c.Request = c.Request.WithContext(WithLocation(c.Request.Context(), loc))
+	c.Next()
+}
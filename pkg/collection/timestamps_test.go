@@ -0,0 +1,104 @@
+package collection
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestLocalizeDateFilters(t *testing.T) {
+	collection := &schema.Collection{Fields: []schema.Field{
+		{Name: "created_at", DataType: "timestamp"},
+		{Name: "birthday", DataType: "date"},
+	}}
+	loc := time.FixedZone("UTC+7", 7*60*60)
+
+	tests := []struct {
+		name   string
+		filter query.Filter
+		want   []query.Filter
+	}{
+		{
+			name:   "equal covers the day",
+			filter: query.Filter{Field: "created_at", Operator: query.OpEqual, Value: "2024-03-10"},
+			want: []query.Filter{
+				{Field: "created_at", Operator: query.OpGreaterEqual, Value: "2024-03-09 17:00:00+00:00"},
+				{Field: "created_at", Operator: query.OpLessThan, Value: "2024-03-10 17:00:00+00:00"},
+			},
+		},
+		{
+			name:   "lte includes the day",
+			filter: query.Filter{Field: "created_at", Operator: query.OpLessEqual, Value: "2024-03-10"},
+			want:   []query.Filter{{Field: "created_at", Operator: query.OpLessThan, Value: "2024-03-10 17:00:00+00:00"}},
+		},
+		{
+			name:   "gt excludes the day",
+			filter: query.Filter{Field: "created_at", Operator: query.OpGreaterThan, Value: "2024-03-10"},
+			want:   []query.Filter{{Field: "created_at", Operator: query.OpGreaterEqual, Value: "2024-03-10 17:00:00+00:00"}},
+		},
+		{
+			name:   "gte starts the day",
+			filter: query.Filter{Field: "created_at", Operator: query.OpGreaterEqual, Value: "2024-03-10"},
+			want:   []query.Filter{{Field: "created_at", Operator: query.OpGreaterEqual, Value: "2024-03-09 17:00:00+00:00"}},
+		},
+		{
+			name:   "full timestamp unchanged",
+			filter: query.Filter{Field: "created_at", Operator: query.OpGreaterEqual, Value: "2024-03-10T08:00:00Z"},
+			want:   []query.Filter{{Field: "created_at", Operator: query.OpGreaterEqual, Value: "2024-03-10T08:00:00Z"}},
+		},
+		{
+			name:   "date field unchanged",
+			filter: query.Filter{Field: "birthday", Operator: query.OpEqual, Value: "2024-03-10"},
+			want:   []query.Filter{{Field: "birthday", Operator: query.OpEqual, Value: "2024-03-10"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := localizeDateFilters(collection, []query.Filter{tt.filter}, loc)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("localizeDateFilters() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepository_EncodeTime(t *testing.T) {
+	ts := time.Date(2024, 3, 10, 1, 30, 0, 0, time.UTC)
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip("time zone database unavailable")
+	}
+
+	tests := []struct {
+		name   string
+		config TimestampConfig
+		ctx    context.Context
+		want   any
+	}{
+		{"default UTC", TimestampConfig{}, context.Background(), ts},
+		{"request zone", TimestampConfig{}, WithLocation(context.Background(), loc), ts.In(loc)},
+		{"epoch millis", TimestampConfig{Format: TimestampEpochMillis}, context.Background(), ts.UnixMilli()},
+		{"naive", TimestampConfig{Format: TimestampNaive, Location: loc}, context.Background(), "2024-03-09T20:30:00"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := &Repository{}
+			if err := r.SetTimestampConfig(tt.config); err != nil {
+				t.Fatal(err)
+			}
+			if got := r.encodeTime(tt.ctx)(ts); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("encodeTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := (&Repository{}).SetTimestampConfig(TimestampConfig{Format: "rfc822"}); err == nil {
+		t.Error("SetTimestampConfig() accepted an unknown format")
+	}
+}
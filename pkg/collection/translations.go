@@ -0,0 +1,333 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// localeRegex matches language tags such as de, de-AT and zh-Hant-TW.
+var localeRegex = regexp.MustCompile(`^[a-zA-Z]{2,3}(-[a-zA-Z0-9]{2,8})*$`)
+
+// localeKey is the context key of a request's locale.
+type localeKey struct{}
+
+// WithLocale returns a context carrying the locale of a request.
+func WithLocale(ctx context.Context, locale string) context.Context {
+	return context.WithValue(ctx, localeKey{}, locale)
+}
+
+// localeFrom returns the locale of a request, or "" when it names none.
+func localeFrom(ctx context.Context) string {
+	locale, _ := ctx.Value(localeKey{}).(string)
+	return locale
+}
+
+// normalizeLocale returns a language tag with the language in lower case
+// and a region in upper case, as in de-AT, or "" when it is not valid.
+func normalizeLocale(tag string) string {
+	if !localeRegex.MatchString(tag) {
+		return ""
+	}
+	parts := strings.Split(tag, "-")
+	for i, part := range parts {
+		if i > 0 && len(part) == 2 {
+			parts[i] = strings.ToUpper(part)
+		} else {
+			parts[i] = strings.ToLower(part)
+		}
+	}
+	return strings.Join(parts, "-")
+}
+
+// localeFallbacks returns the locales whose translations apply to a request
+// for locale, most specific first: de-AT falls back to de.
+func localeFallbacks(locale string) []string {
+	locales := []string{locale}
+	for i := strings.LastIndex(locale, "-"); i > 0; i = strings.LastIndex(locale, "-") {
+		locale = locale[:i]
+		locales = append(locales, locale)
+	}
+	return locales
+}
+
+// acceptedLocale returns the preferred valid language of an Accept-Language
+// header, or "" when it names none.
+func acceptedLocale(header string) string {
+	best, bestQ := "", 0.0
+	for _, entry := range strings.Split(header, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(entry), ";")
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if locale := normalizeLocale(strings.TrimSpace(tag)); locale != "" && q > bestQ {
+			best, bestQ = locale, q
+		}
+	}
+	return best
+}
+
+// locale resolves the locale named by the locale query parameter or the
+// Accept-Language header and stores it in the request context. Responses of
+// translated collections vary by Accept-Language.
+func (h *Handler) locale(c *gin.Context) {
+	if collection, err := h.service.schemaManager.GetCollection(c.Param("collection")); err == nil && collection.Translations != nil {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+	}
+
+	locale := ""
+	if tag, ok := c.GetQuery("locale"); ok {
+		if locale = normalizeLocale(tag); locale == "" {
+			h.handleError(c, apperror.ErrBadRequest.WithMessagef("Invalid locale '%s'", tag))
+			c.Abort()
+			return
+		}
+	} else if header := c.GetHeader("Accept-Language"); header != "" {
+		locale = acceptedLocale(header)
+	}
+	if locale != "" {
+		c.Request = c.Request.WithContext(WithLocale(c.Request.Context(), locale))
+	}
+	c.Next()
+}
+
+// ListTranslations handles GET /:collection/:id/translations requests.
+func (h *Handler) ListTranslations(c *gin.Context) {
+	translations, err := h.service.ListTranslations(c.Request.Context(), c.Param("collection"), c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	h.write(c, http.StatusOK, response.Success(translations))
+}
+
+// ListTranslations returns the stored translations of an item by locale.
+func (s *Service) ListTranslations(ctx context.Context, collectionName string, id any) (map[string]map[string]any, error) {
+	collection, err := s.schemaManager.GetCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	if collection.Translations == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Translations are not enabled for collection '%s'", collectionName)
+	}
+
+	if _, err := s.getVisible(ctx, collection, id); err != nil {
+		return nil, err
+	}
+	found, err := s.repo.Translations(ctx, collection, []any{id}, nil)
+	if err != nil {
+		return nil, err
+	}
+	if translations, ok := found[fmt.Sprint(id)]; ok {
+		return translations, nil
+	}
+	return map[string]map[string]any{}, nil
+}
+
+// translate replaces the translated fields of items with their values in
+// the request's locale, falling back to its base language and then to the
+// stored values. Fields not selected are left out.
+func (s *Service) translate(ctx context.Context, collection *schema.Collection, items []map[string]any) error {
+	locale := localeFrom(ctx)
+	if locale == "" || collection.Translations == nil || len(items) == 0 {
+		return nil
+	}
+
+	ids := make([]any, 0, len(items))
+	for _, item := range items {
+		if id, ok := item[collection.PrimaryKey]; ok && id != nil {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	locales := localeFallbacks(locale)
+	found, err := s.repo.Translations(ctx, collection, ids, locales)
+	if err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		translations, ok := found[fmt.Sprint(item[collection.PrimaryKey])]
+		if !ok {
+			continue
+		}
+		for _, field := range collection.Translations.Fields {
+			if _, selected := item[field]; !selected {
+				continue
+			}
+			for _, l := range locales {
+				if value := translations[l][field]; value != nil {
+					item[field] = value
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// splitTranslations moves the translated fields out of update data when the
+// request names a locale, returning them, or nil when it does not.
+func splitTranslations(ctx context.Context, collection *schema.Collection, data map[string]any) map[string]any {
+	if localeFrom(ctx) == "" || collection.Translations == nil {
+		return nil
+	}
+	translated := make(map[string]any)
+	for _, field := range collection.Translations.Fields {
+		if value, ok := data[field]; ok {
+			translated[field] = value
+			delete(data, field)
+		}
+	}
+	if len(translated) == 0 {
+		return nil
+	}
+	return translated
+}
+
+// deleteTranslations removes the translations of a deleted item.
+func (s *Service) deleteTranslations(ctx context.Context, collection *schema.Collection, id any) {
+	if collection.Translations == nil {
+		return
+	}
+	if err := s.repo.DeleteTranslations(ctx, collection, id); err != nil {
+		requestlog.Logger(ctx, s.logger).Warnw("Failed to delete translations", "collection", collection.Name, "id", id, "error", err)
+	}
+}
+
+// Translations returns the translations of items, in locales or in all of
+// them when locales is empty, keyed by item ID and then by locale.
+func (r *Repository) Translations(ctx context.Context, collection *schema.Collection, ids []any, locales []string) (map[string]map[string]map[string]any, error) {
+	t := collection.Translations
+	quote := r.dialect.QuoteIdent
+	columns := []string{quote(schema.TranslationItemColumn), quote(schema.TranslationLocaleColumn)}
+	for _, field := range t.Fields {
+		columns = append(columns, quote(field))
+	}
+
+	querySQL := fmt.Sprintf("SELECT %s FROM %s WHERE %s IN (?)",
+		strings.Join(columns, ", "), quote(t.Table), quote(schema.TranslationItemColumn))
+	args := []any{ids}
+	if len(locales) > 0 {
+		querySQL += fmt.Sprintf(" AND %s IN (?)", quote(schema.TranslationLocaleColumn))
+		args = append(args, locales)
+	}
+	querySQL, args, err := sqlx.In(querySQL, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build translations query: %w", err)
+	}
+	querySQL = r.db.Rebind(querySQL)
+
+	result := make(map[string]map[string]map[string]any)
+	err = r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		rows, err := q.QueryxContext(ctx, querySQL, args...)
+		if err != nil {
+			return dbError(ctx, err)
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			row := make(map[string]any)
+			if err := rows.MapScan(row); err != nil {
+				return dbError(ctx, err)
+			}
+			normalizeMapValues(collection, row, r.encoder(ctx))
+			id := fmt.Sprint(row[schema.TranslationItemColumn])
+			locale := fmt.Sprint(row[schema.TranslationLocaleColumn])
+			delete(row, schema.TranslationItemColumn)
+			delete(row, schema.TranslationLocaleColumn)
+			if result[id] == nil {
+				result[id] = make(map[string]map[string]any)
+			}
+			result[id][locale] = row
+		}
+		if err := rows.Err(); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// SetTranslation stores translated values of an item in a locale, keeping
+// the other fields of an existing translation.
+func (r *Repository) SetTranslation(ctx context.Context, collection *schema.Collection, id any, locale string, data map[string]any) error {
+	if err := prepareValues(collection, data); err != nil {
+		return err
+	}
+
+	fields := make([]string, 0, len(data))
+	for field := range data {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+
+	quote := r.dialect.QuoteIdent
+	columns := []string{quote(schema.TranslationItemColumn), quote(schema.TranslationLocaleColumn)}
+	args := []any{id, locale}
+	updates := make([]string, len(fields))
+	for i, field := range fields {
+		columns = append(columns, quote(field))
+		args = append(args, data[field])
+		if r.dialect.Name() == dialect.MySQL {
+			updates[i] = fmt.Sprintf("%s = VALUES(%s)", quote(field), quote(field))
+		} else {
+			updates[i] = fmt.Sprintf("%s = EXCLUDED.%s", quote(field), quote(field))
+		}
+	}
+
+	querySQL := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		quote(collection.Translations.Table), strings.Join(columns, ", "),
+		strings.TrimSuffix(strings.Repeat("?, ", len(columns)), ", "))
+	if r.dialect.Name() == dialect.MySQL {
+		querySQL += " ON DUPLICATE KEY UPDATE " + strings.Join(updates, ", ")
+	} else {
+		querySQL += fmt.Sprintf(" ON CONFLICT (%s, %s) DO UPDATE SET %s",
+			quote(schema.TranslationItemColumn), quote(schema.TranslationLocaleColumn), strings.Join(updates, ", "))
+	}
+	querySQL = r.db.Rebind(querySQL)
+
+	return r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if _, err := q.ExecContext(ctx, querySQL, args...); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+}
+
+// DeleteTranslations removes the translations of an item.
+func (r *Repository) DeleteTranslations(ctx context.Context, collection *schema.Collection, id any) error {
+	quote := r.dialect.QuoteIdent
+	querySQL := r.db.Rebind(fmt.Sprintf("DELETE FROM %s WHERE %s = ?",
+		quote(collection.Translations.Table), quote(schema.TranslationItemColumn)))
+	return r.withTimeout(ctx, collection, func(ctx context.Context, q sqlx.ExtContext) error {
+		if _, err := q.ExecContext(ctx, querySQL, id); err != nil {
+			return dbError(ctx, err)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,77 @@
+package collection
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestNormalizeLocale(t *testing.T) {
+	tests := []struct {
+		tag  string
+		want string
+	}{
+		{"de", "de"},
+		{"DE-at", "de-AT"},
+		{"zh-hant-tw", "zh-hant-TW"},
+		{"en_US", ""},
+		{"*", ""},
+		{"", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.tag, func(t *testing.T) {
+			if got := normalizeLocale(tt.tag); got != tt.want {
+				t.Errorf("normalizeLocale(%q) = %q, want %q", tt.tag, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAcceptedLocale(t *testing.T) {
+	tests := []struct {
+		header string
+		want   string
+	}{
+		{"de", "de"},
+		{"fr;q=0.5, de-CH;q=0.9, en;q=0.1", "de-CH"},
+		{"en-US,en;q=0.9", "en-US"},
+		{"*, de;q=0.5", "de"},
+		{"de;q=0", ""},
+		{"de;q=abc", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.header, func(t *testing.T) {
+			if got := acceptedLocale(tt.header); got != tt.want {
+				t.Errorf("acceptedLocale(%q) = %q, want %q", tt.header, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLocaleFallbacks(t *testing.T) {
+	want := []string{"zh-hant-TW", "zh-hant", "zh"}
+	if got := localeFallbacks("zh-hant-TW"); !reflect.DeepEqual(got, want) {
+		t.Errorf("localeFallbacks() = %v, want %v", got, want)
+	}
+}
+
+func TestSplitTranslations(t *testing.T) {
+	collection := &schema.Collection{Translations: &schema.Translations{Table: "api_posts_translations", Fields: []string{"title"}}}
+	data := map[string]any{"title": "Hallo", "views": 3}
+
+	if got := splitTranslations(context.Background(), collection, data); got != nil {
+		t.Errorf("splitTranslations() without locale = %v, want nil", got)
+	}
+
+	got := splitTranslations(WithLocale(context.Background(), "de"), collection, data)
+	if !reflect.DeepEqual(got, map[string]any{"title": "Hallo"}) {
+		t.Errorf("splitTranslations() = %v", got)
+	}
+	if !reflect.DeepEqual(data, map[string]any{"views": 3}) {
+		t.Errorf("data after splitTranslations() = %v", data)
+	}
+}
@@ -0,0 +1,159 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// TreeChildrenKey is the key holding nested children in tree responses.
+const TreeChildrenKey = "children"
+
+// MaxTreeDepth is the deepest level tree queries descend to.
+const MaxTreeDepth = 32
+
+// maxTreeNodes bounds the rows returned by a single tree query.
+const maxTreeNodes = 5000
+
+// TreeParams holds parameters for tree queries.
+type TreeParams struct {
+	CollectionName string
+
+	// RootID selects an item's descendants; nil returns the whole tree.
+	RootID any
+
+	// Depth is the number of levels returned; zero returns direct children
+	// of an item, or the whole tree up to MaxTreeDepth.
+	Depth int
+
+	// Scope is a row-level permission filter; hidden rows hide their descendants.
+	Scope map[string]any
+}
+
+// Tree returns items of a self-referencing collection nested under TreeChildrenKey.
+func (s *Service) Tree(ctx context.Context, params TreeParams) ([]map[string]any, error) {
+	collection, err := s.schemaManager.GetCollection(params.CollectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	parent, column, err := s.parentField(collection)
+	if err != nil {
+		return nil, err
+	}
+
+	depth := params.Depth
+	if depth == 0 {
+		depth = MaxTreeDepth
+		if params.RootID != nil {
+			depth = 1
+		}
+	}
+	if depth < 1 || depth > MaxTreeDepth {
+		return nil, apperror.ErrBadRequest.WithMessagef("Depth must be between 1 and %d", MaxTreeDepth)
+	}
+
+	if hidesUnpublished(ctx, collection) {
+		params.Scope = publishedScope(collection, params.Scope)
+	}
+	scope, scopeArgs := permission.NewFilterBuilder(0).WithDialect(s.repo.dialect).Build(params.Scope)
+
+	// The root itself must be visible for its children to be
+	if params.RootID != nil {
+		ok, err := s.repo.ExistsInScope(ctx, collection, params.RootID, scope, scopeArgs)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, apperror.ErrNotFound.WithMessagef("Item with ID '%v' not found", params.RootID)
+		}
+	}
+
+	sorts := []query.Sort{{Field: column, Direction: query.SortAsc}}
+	if hasSortOrder(collection) {
+		sorts = append([]query.Sort{{Field: SortOrderField, Direction: query.SortAsc}}, sorts...)
+	}
+
+	rows, err := s.repo.Tree(ctx, collection, query.TreeQuery{
+		Table:        collection.TableName,
+		PrimaryKey:   column,
+		ParentColumn: parent,
+		RootID:       params.RootID,
+		Scope:        scope,
+		ScopeArgs:    scopeArgs,
+		MaxDepth:     depth,
+		Sorts:        sorts,
+		Limit:        maxTreeNodes + 1,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(rows) > maxTreeNodes {
+		return nil, apperror.ErrBadRequest.WithMessagef("Tree has more than %d items; request a smaller depth", maxTreeNodes)
+	}
+
+	return nestTree(rows, column, parent, params.RootID), nil
+}
+
+// parentField returns the self-referencing foreign key of a collection and the
+// column it references, preferring one named parent_id.
+func (s *Service) parentField(collection *schema.Collection) (string, string, error) {
+	field := ""
+	for _, rel := range s.schemaManager.GetRelationships(collection.Name) {
+		if rel.RelatedCollection != collection.Name {
+			continue
+		}
+		if field == "" || rel.FieldName == "parent_id" {
+			field = rel.FieldName
+		}
+	}
+	if field == "" {
+		return "", "", apperror.ErrBadRequest.WithMessagef("Collection '%s' has no self-referencing field", collection.Name)
+	}
+
+	column := collection.PrimaryKey
+	for _, f := range collection.Fields {
+		if f.Name == field && f.ForeignKey != nil && f.ForeignKey.Column != "" {
+			column = f.ForeignKey.Column
+		}
+	}
+	return field, column, nil
+}
+
+// nestTree nests rows, ordered shallowest first, under their parents.
+// Rows reached twice through a cycle are kept only at their first position.
+func nestTree(rows []map[string]any, column, parent string, rootID any) []map[string]any {
+	roots := make([]map[string]any, 0)
+	nodes := make(map[string]map[string]any, len(rows))
+	seen := make(map[string]bool, len(rows)+1)
+	if rootID != nil {
+		seen[fmt.Sprint(rootID)] = true
+	}
+
+	for _, row := range rows {
+		key := fmt.Sprint(row[column])
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		depth, _ := positionValue(row[query.TreeDepthColumn])
+		delete(row, query.TreeDepthColumn)
+		row[TreeChildrenKey] = make([]map[string]any, 0)
+
+		if depth <= 1 {
+			roots = append(roots, row)
+			nodes[key] = row
+			continue
+		}
+		if p, ok := nodes[fmt.Sprint(row[parent])]; ok {
+			p[TreeChildrenKey] = append(p[TreeChildrenKey].([]map[string]any), row)
+			nodes[key] = row
+		}
+	}
+	return roots
+}
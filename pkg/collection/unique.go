@@ -0,0 +1,103 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+)
+
+// checkUniqueConstraints fails with apperror.ErrConflict when data repeats
+// the combination of an existing item for one of the collection's
+// multi-column unique constraints. Updates pass the item's id, whose stored
+// values fill in the constrained columns data leaves out. Values are
+// compared in their stored form, as prepareValues writes them. Combinations
+// taken by earlier items of the same batch are recorded in taken when it
+// is non-nil.
+func (s *Service) checkUniqueConstraints(ctx context.Context, collection *schema.Collection, id any, data map[string]any, taken map[string]bool) error {
+	if len(collection.UniqueConstraints) == 0 {
+		return nil
+	}
+
+	var checker *validation.DBUniqueChecker
+	var current map[string]any
+	for _, constraint := range collection.UniqueConstraints {
+		if id != nil && !touchesConstraint(constraint, data) {
+			continue
+		}
+		if id != nil && current == nil {
+			var err error
+			if current, err = s.repo.getStored(ctx, collection, id); err != nil {
+				return err
+			}
+		}
+		values, ok := constraintValues(constraint, data, current)
+		if !ok {
+			continue
+		}
+		if err := prepareValues(collection, values); err != nil {
+			return err
+		}
+
+		violation := ConstraintViolation{Constraint: constraint.Name, Type: ConstraintUnique, Fields: constraint.Fields}
+		key := combinationKey(constraint, values)
+		if taken[key] {
+			return violation.appError()
+		}
+		if checker == nil {
+			checker = validation.NewDBUniqueChecker(s.repo.db, collection.PrimaryKey).WithDialect(s.repo.dialect)
+		}
+		unique, err := checker.IsUniqueTogether(ctx, collection.TableName, values, id)
+		if err != nil {
+			return apperror.ErrInternalServer.WithError(err)
+		}
+		if !unique {
+			return violation.appError()
+		}
+		if taken != nil {
+			taken[key] = true
+		}
+	}
+	return nil
+}
+
+// touchesConstraint reports whether data writes a column of the constraint.
+func touchesConstraint(constraint schema.UniqueConstraint, data map[string]any) bool {
+	for _, field := range constraint.Fields {
+		if _, ok := data[field]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// constraintValues returns the values of the constraint's columns, taken
+// from data or else from current. It reports false when a value is null,
+// as nulls never conflict under a unique constraint.
+func constraintValues(constraint schema.UniqueConstraint, data, current map[string]any) (map[string]any, bool) {
+	values := make(map[string]any, len(constraint.Fields))
+	for _, field := range constraint.Fields {
+		value, ok := data[field]
+		if !ok {
+			value = current[field]
+		}
+		if value == nil {
+			return nil, false
+		}
+		values[field] = value
+	}
+	return values, true
+}
+
+// combinationKey identifies the values of a constraint in a batch's taken
+// set.
+func combinationKey(constraint schema.UniqueConstraint, values map[string]any) string {
+	parts := []string{constraint.Name}
+	for _, field := range constraint.Fields {
+		parts = append(parts, fmt.Sprint(values[field]))
+	}
+	return strings.Join(parts, "\x00")
+}
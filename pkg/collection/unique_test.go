@@ -0,0 +1,93 @@
+package collection
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestConstraintValues(t *testing.T) {
+	constraint := schema.UniqueConstraint{Name: "members_team_user_key", Fields: []string{"team_id", "user_id"}}
+
+	tests := []struct {
+		name    string
+		data    map[string]any
+		current map[string]any
+		want    map[string]any
+		wantOK  bool
+	}{
+		{
+			name:   "both in data",
+			data:   map[string]any{"team_id": 1, "user_id": 2, "role": "owner"},
+			want:   map[string]any{"team_id": 1, "user_id": 2},
+			wantOK: true,
+		},
+		{
+			name:    "filled in from current",
+			data:    map[string]any{"user_id": 3},
+			current: map[string]any{"team_id": 1, "user_id": 2},
+			want:    map[string]any{"team_id": 1, "user_id": 3},
+			wantOK:  true,
+		},
+		{
+			name:   "missing on create",
+			data:   map[string]any{"team_id": 1},
+			wantOK: false,
+		},
+		{
+			name:    "null in data",
+			data:    map[string]any{"team_id": nil},
+			current: map[string]any{"team_id": 1, "user_id": 2},
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := constraintValues(constraint, tt.data, tt.current)
+			if ok != tt.wantOK {
+				t.Fatalf("constraintValues() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if ok && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("constraintValues() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTouchesConstraint(t *testing.T) {
+	constraint := schema.UniqueConstraint{Name: "members_team_user_key", Fields: []string{"team_id", "user_id"}}
+
+	tests := []struct {
+		name string
+		data map[string]any
+		want bool
+	}{
+		{"constrained field", map[string]any{"user_id": 3}, true},
+		{"constrained field set to null", map[string]any{"team_id": nil}, true},
+		{"other fields", map[string]any{"role": "owner"}, false},
+		{"empty", map[string]any{}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := touchesConstraint(constraint, tt.data); got != tt.want {
+				t.Errorf("touchesConstraint(%v) = %v, want %v", tt.data, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCombinationKey(t *testing.T) {
+	constraint := schema.UniqueConstraint{Name: "members_team_user_key", Fields: []string{"team_id", "user_id"}}
+
+	a := combinationKey(constraint, map[string]any{"team_id": 1, "user_id": 23})
+	b := combinationKey(constraint, map[string]any{"team_id": 12, "user_id": 3})
+	if a == b {
+		t.Errorf("combinationKey() = %q for different combinations", a)
+	}
+	if c := combinationKey(constraint, map[string]any{"team_id": float64(1), "user_id": "23"}); c != a {
+		t.Errorf("combinationKey() = %q, want %q for the same combination", c, a)
+	}
+}
@@ -0,0 +1,160 @@
+package collection
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/validation"
+	"github.com/thienel/tugo/pkg/vector"
+)
+
+// SimilarityParam orders list results by cosine distance to a vector:
+// ?order_by_similarity=field:[x,y,...].
+const SimilarityParam = "order_by_similarity"
+
+// SetEmbedder sets the embedder filling in the collections' embedded
+// vector fields from their source text fields.
+func (s *Service) SetEmbedder(embedder vector.Embedder) {
+	s.embedder = embedder
+}
+
+// fillEmbeddings computes the embedded vector fields of write data from
+// their source fields, with one call to the embedder for all items. Fields
+// given by the client are kept, and an emptied source clears its vector.
+func (s *Service) fillEmbeddings(ctx context.Context, collection *schema.Collection, items []map[string]any) error {
+	if s.embedder == nil || len(collection.Embeddings) == 0 {
+		return nil
+	}
+
+	type target struct {
+		item  map[string]any
+		field string
+	}
+	var texts []string
+	var targets []target
+	for _, item := range items {
+		for field, source := range collection.Embeddings {
+			if _, ok := item[field]; ok {
+				continue
+			}
+			value, ok := item[source]
+			if !ok {
+				continue
+			}
+			text, _ := value.(string)
+			if strings.TrimSpace(text) == "" {
+				item[field] = nil
+				continue
+			}
+			texts = append(texts, text)
+			targets = append(targets, target{item: item, field: field})
+		}
+	}
+	if len(texts) == 0 {
+		return nil
+	}
+
+	vectors, err := s.embedder.Embed(ctx, texts)
+	if err == nil && len(vectors) != len(texts) {
+		err = fmt.Errorf("embedder returned %d vectors for %d texts", len(vectors), len(texts))
+	}
+	if err != nil {
+		return apperror.ErrServiceUnavailable.WithMessage("Failed to compute embeddings").WithError(err)
+	}
+	for i, t := range targets {
+		t.item[t.field] = vectors[i]
+	}
+	return nil
+}
+
+// encodeVectors converts the values of vector fields, lists of numbers or
+// strings such as "[0.1,0.2]", to pgvector's text form.
+func encodeVectors(collection *schema.Collection, data map[string]any) error {
+	for _, f := range collection.Fields {
+		value, ok := data[f.Name]
+		if f.DataType != "vector" || !ok || value == nil {
+			continue
+		}
+		v, err := vector.FromValue(value)
+		if err != nil {
+			return apperror.ErrValidation.WithDetails([]validation.FieldError{
+				{Field: f.Name, Message: "must be a vector: " + err.Error(), Code: "invalid_vector"},
+			})
+		}
+		data[f.Name] = v.String()
+	}
+	return nil
+}
+
+// normalizeVector writes a scanned pgvector value as a list of numbers.
+func normalizeVector(v any) any {
+	switch v.(type) {
+	case []byte, string:
+		if parsed, err := vector.FromValue(v); err == nil {
+			return parsed
+		}
+	}
+	return normalizeValue(v)
+}
+
+// vectorFilters checks the filters on vector fields, which take null,
+// notnull and _cosine_lt, and parses _cosine_lt values of the form
+// max:[x,y,...], such as 0.25:[0.1,0.2,0.3].
+func vectorFilters(collection *schema.Collection, filters []query.Filter) ([]query.Filter, error) {
+	types := make(map[string]string, len(collection.Fields))
+	for _, f := range collection.Fields {
+		types[f.Name] = f.DataType
+	}
+
+	for i, f := range filters {
+		isVector := types[f.Field] == "vector"
+		switch {
+		case f.Operator == query.OpCosineLessThan && !isVector:
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Filter '%s' needs a vector field", f.Operator).
+				WithDetails(map[string]any{"field": f.Field})
+		case f.Operator == query.OpCosineLessThan:
+			raw, _ := f.Value.(string)
+			maxStr, vectorStr, ok := strings.Cut(raw, ":")
+			maxDistance, err := strconv.ParseFloat(strings.TrimSpace(maxStr), 64)
+			if !ok || err != nil {
+				return nil, apperror.ErrInvalidFilter.WithMessagef("Invalid value for filter '%s'; use max:[x,y,...]", f.Field)
+			}
+			v, err := vector.Parse(vectorStr)
+			if err != nil {
+				return nil, apperror.ErrInvalidFilter.WithMessagef("Invalid value for filter '%s': %s", f.Field, err)
+			}
+			filters[i].Value = query.VectorDistance{Vector: v.String(), Max: maxDistance}
+		case isVector && f.Operator != query.OpIsNull && f.Operator != query.OpIsNotNull:
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Vector field '%s' can only be filtered with null, notnull or _cosine_lt", f.Field)
+		}
+	}
+	return filters, nil
+}
+
+// similaritySort parses an order_by_similarity value of the form
+// field:[x,y,...] into a sort by cosine distance, nearest first.
+func similaritySort(collection *schema.Collection, value string) (query.Sort, error) {
+	field, vectorStr, ok := strings.Cut(value, ":")
+	if !ok {
+		return query.Sort{}, apperror.ErrInvalidSort.WithMessagef("Invalid %s; use field:[x,y,...]", SimilarityParam)
+	}
+	var isVector bool
+	for _, f := range collection.Fields {
+		if f.Name == field {
+			isVector = f.DataType == "vector"
+		}
+	}
+	if !isVector {
+		return query.Sort{}, apperror.ErrInvalidSort.WithMessagef("Field '%s' is not a vector field", field)
+	}
+	v, err := vector.Parse(vectorStr)
+	if err != nil {
+		return query.Sort{}, apperror.ErrInvalidSort.WithMessagef("Invalid %s: %s", SimilarityParam, err)
+	}
+	return query.Sort{Field: field, Direction: query.SortAsc, Vector: v.String()}, nil
+}
@@ -0,0 +1,133 @@
+package collection
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/vector"
+)
+
+var vectorCollection = &schema.Collection{
+	Fields: []schema.Field{
+		{Name: "body", DataType: "string"},
+		{Name: "embedding", DataType: "vector"},
+	},
+	Embeddings: map[string]string{"embedding": "body"},
+}
+
+func TestVectorFilters(t *testing.T) {
+	tests := []struct {
+		name    string
+		filter  query.Filter
+		want    any
+		wantErr bool
+	}{
+		{"cosine", query.Filter{Field: "embedding", Operator: query.OpCosineLessThan, Value: "0.25:[1, 2]"}, query.VectorDistance{Vector: "[1,2]", Max: 0.25}, false},
+		{"not null", query.Filter{Field: "embedding", Operator: query.OpIsNotNull, Value: "true"}, "true", false},
+		{"text field", query.Filter{Field: "body", Operator: query.OpEqual, Value: "a"}, "a", false},
+		{"missing max", query.Filter{Field: "embedding", Operator: query.OpCosineLessThan, Value: "[1,2]"}, nil, true},
+		{"bad vector", query.Filter{Field: "embedding", Operator: query.OpCosineLessThan, Value: "0.5:[x]"}, nil, true},
+		{"cosine on text", query.Filter{Field: "body", Operator: query.OpCosineLessThan, Value: "0.5:[1]"}, nil, true},
+		{"equal on vector", query.Filter{Field: "embedding", Operator: query.OpEqual, Value: "[1]"}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := vectorFilters(vectorCollection, []query.Filter{tt.filter})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("vectorFilters() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(got[0].Value, tt.want) {
+				t.Errorf("vectorFilters() value = %v, want %v", got[0].Value, tt.want)
+			}
+		})
+	}
+}
+
+func TestSimilaritySort(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   string
+		want    query.Sort
+		wantErr bool
+	}{
+		{"vector field", "embedding:[0.5,1]", query.Sort{Field: "embedding", Direction: query.SortAsc, Vector: "[0.5,1]"}, false},
+		{"no vector", "embedding", query.Sort{}, true},
+		{"text field", "body:[1]", query.Sort{}, true},
+		{"bad vector", "embedding:[]", query.Sort{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := similaritySort(vectorCollection, tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("similaritySort() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("similaritySort() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncodeVectors(t *testing.T) {
+	data := map[string]any{"body": "a", "embedding": []any{0.5, 1.0}}
+	if err := encodeVectors(vectorCollection, data); err != nil {
+		t.Fatal(err)
+	}
+	if data["embedding"] != "[0.5,1]" {
+		t.Errorf("embedding = %v, want [0.5,1]", data["embedding"])
+	}
+
+	if err := encodeVectors(vectorCollection, map[string]any{"embedding": "oops"}); err == nil {
+		t.Error("encodeVectors() with an invalid vector = nil, want error")
+	}
+}
+
+func TestFillEmbeddings(t *testing.T) {
+	var calls [][]string
+	s := &Service{}
+	s.SetEmbedder(vector.EmbedderFunc(func(_ context.Context, texts []string) ([]vector.Vector, error) {
+		calls = append(calls, texts)
+		out := make([]vector.Vector, len(texts))
+		for i, text := range texts {
+			out[i] = vector.Vector{float32(len(text))}
+		}
+		return out, nil
+	}))
+
+	items := []map[string]any{
+		{"body": "hello"},
+		{"body": "hi", "embedding": "[9]"},
+		{"body": ""},
+		{"title": "no body"},
+		{"body": "hey"},
+	}
+	if err := s.fillEmbeddings(context.Background(), vectorCollection, items); err != nil {
+		t.Fatal(err)
+	}
+
+	if want := [][]string{{"hello", "hey"}}; !reflect.DeepEqual(calls, want) {
+		t.Errorf("embedder calls = %v, want %v", calls, want)
+	}
+	wants := []any{vector.Vector{5}, "[9]", nil, nil, vector.Vector{3}}
+	for i, want := range wants {
+		if got := items[i]["embedding"]; !reflect.DeepEqual(got, want) {
+			t.Errorf("item %d embedding = %v, want %v", i, got, want)
+		}
+	}
+	if _, ok := items[3]["embedding"]; ok {
+		t.Error("item without its source got an embedding")
+	}
+
+	s.SetEmbedder(vector.EmbedderFunc(func(context.Context, []string) ([]vector.Vector, error) {
+		return nil, errors.New("unavailable")
+	}))
+	if err := s.fillEmbeddings(context.Background(), vectorCollection, []map[string]any{{"body": "x"}}); err == nil {
+		t.Error("fillEmbeddings() with a failing embedder = nil, want error")
+	}
+}
@@ -0,0 +1,377 @@
+package collection
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/query"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// viewNameRegex restricts view names to URL-safe identifiers.
+var viewNameRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// View is a named query preset for a collection.
+type View struct {
+	ID          int64     `db:"id" json:"id"`
+	Collection  string    `db:"collection" json:"collection"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description,omitempty"`
+	Query       ViewQuery `db:"-" json:"query"`
+	Roles       []string  `db:"-" json:"roles,omitempty"` // Empty allows every role
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+
+	RawDefinition []byte `db:"definition" json:"-"`
+	RawRoles      []byte `db:"roles" json:"-"`
+}
+
+// ViewQuery holds the list parameters a view applies.
+type ViewQuery struct {
+	// Filter maps "field" or "field:op" to a value, as in filter[field:op]=value.
+	Filter map[string]string `json:"filter,omitempty"`
+	Sort   string            `json:"sort,omitempty"`
+	Fields []string          `json:"fields,omitempty"`
+	Expand []string          `json:"expand,omitempty"`
+}
+
+// Params returns the view as list query parameters.
+func (q ViewQuery) Params() map[string][]string {
+	params := make(map[string][]string)
+	for key, value := range q.Filter {
+		params["filter["+key+"]"] = []string{value}
+	}
+	if q.Sort != "" {
+		params["sort"] = []string{q.Sort}
+	}
+	if len(q.Fields) > 0 {
+		params["fields"] = []string{strings.Join(q.Fields, ",")}
+	}
+	return params
+}
+
+// allows reports whether a role may use the view.
+func (v *View) allows(role string) bool {
+	if len(v.Roles) == 0 || role == "admin" {
+		return true
+	}
+	for _, r := range v.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ViewStore persists saved views in tugo_views.
+type ViewStore struct {
+	db *sqlx.DB
+}
+
+// NewViewStore creates a new view store.
+func NewViewStore(db *sqlx.DB) *ViewStore {
+	return &ViewStore{db: db}
+}
+
+// List returns the views of a collection ordered by name.
+func (s *ViewStore) List(ctx context.Context, collection string) ([]View, error) {
+	query := `
+		SELECT id, collection, name, description, definition, roles, created_at, updated_at
+		FROM tugo_views
+		WHERE collection = ?
+		ORDER BY name
+	`
+	views := make([]View, 0)
+	if err := s.db.SelectContext(ctx, &views, s.db.Rebind(query), collection); err != nil {
+		return nil, fmt.Errorf("failed to list views: %w", err)
+	}
+
+	for i := range views {
+		if err := views[i].decode(); err != nil {
+			return nil, err
+		}
+	}
+	return views, nil
+}
+
+// Get returns a view by collection and name.
+func (s *ViewStore) Get(ctx context.Context, collection, name string) (*View, error) {
+	query := `
+		SELECT id, collection, name, description, definition, roles, created_at, updated_at
+		FROM tugo_views
+		WHERE collection = ? AND name = ?
+	`
+	var view View
+	if err := s.db.GetContext(ctx, &view, s.db.Rebind(query), collection, name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("View '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to get view: %w", err)
+	}
+
+	if err := view.decode(); err != nil {
+		return nil, err
+	}
+	return &view, nil
+}
+
+// Create stores a new view.
+func (s *ViewStore) Create(ctx context.Context, view *View) error {
+	definition, roles, err := view.encode()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO tugo_views (collection, name, description, definition, roles)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), view.Collection, view.Name, view.Description, definition, roles); err != nil {
+		if isDuplicateKeyError(err) {
+			return apperror.ErrConflict.WithMessagef("View '%s' already exists", view.Name)
+		}
+		return fmt.Errorf("failed to create view: %w", err)
+	}
+	return nil
+}
+
+// Update replaces a view's description, query and roles.
+func (s *ViewStore) Update(ctx context.Context, view *View) error {
+	definition, roles, err := view.encode()
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE tugo_views
+		SET description = ?, definition = ?, roles = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE collection = ? AND name = ?
+	`
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), view.Description, definition, roles, view.Collection, view.Name)
+	if err != nil {
+		return fmt.Errorf("failed to update view: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("View '%s' not found", view.Name)
+	}
+	return nil
+}
+
+// Delete removes a view.
+func (s *ViewStore) Delete(ctx context.Context, collection, name string) error {
+	query := "DELETE FROM tugo_views WHERE collection = ? AND name = ?"
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), collection, name)
+	if err != nil {
+		return fmt.Errorf("failed to delete view: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("View '%s' not found", name)
+	}
+	return nil
+}
+
+// encode marshals the view's query and roles for storage.
+func (v *View) encode() (string, string, error) {
+	definition, err := json.Marshal(v.Query)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode view: %w", err)
+	}
+	roles := v.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	rolesJSON, err := json.Marshal(roles)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode view roles: %w", err)
+	}
+	return string(definition), string(rolesJSON), nil
+}
+
+// decode unmarshals the stored query and roles.
+func (v *View) decode() error {
+	if len(v.RawDefinition) > 0 {
+		if err := json.Unmarshal(v.RawDefinition, &v.Query); err != nil {
+			return fmt.Errorf("failed to decode view '%s': %w", v.Name, err)
+		}
+	}
+	if len(v.RawRoles) > 0 {
+		if err := json.Unmarshal(v.RawRoles, &v.Roles); err != nil {
+			return fmt.Errorf("failed to decode view '%s' roles: %w", v.Name, err)
+		}
+	}
+	return nil
+}
+
+// ListViews returns the saved views of a collection.
+func (s *Service) ListViews(ctx context.Context, collectionName string) ([]View, error) {
+	collection, err := s.viewCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+
+	views, err := s.views.List(ctx, collection.Name)
+	if err != nil {
+		return nil, apperror.ErrInternalServer.WithError(err)
+	}
+	return views, nil
+}
+
+// GetView returns a saved view.
+func (s *Service) GetView(ctx context.Context, collectionName, name string) (*View, error) {
+	collection, err := s.viewCollection(collectionName)
+	if err != nil {
+		return nil, err
+	}
+	return s.loadView(ctx, collection, name)
+}
+
+// CreateView validates and stores a new view.
+func (s *Service) CreateView(ctx context.Context, view *View) (*View, error) {
+	collection, err := s.viewCollection(view.Collection)
+	if err != nil {
+		return nil, err
+	}
+	if !viewNameRegex.MatchString(view.Name) {
+		return nil, apperror.ErrBadRequest.WithMessage("View names may only contain letters, digits, '_' and '-' (max 64)")
+	}
+	if err := validateView(s.schemaManager, collection, view.Query); err != nil {
+		return nil, err
+	}
+
+	view.Collection = collection.Name
+	if err := s.views.Create(ctx, view); err != nil {
+		return nil, storeError(err)
+	}
+	return s.loadView(ctx, collection, view.Name)
+}
+
+// UpdateView validates and replaces an existing view.
+func (s *Service) UpdateView(ctx context.Context, view *View) (*View, error) {
+	collection, err := s.viewCollection(view.Collection)
+	if err != nil {
+		return nil, err
+	}
+	if err := validateView(s.schemaManager, collection, view.Query); err != nil {
+		return nil, err
+	}
+
+	view.Collection = collection.Name
+	if err := s.views.Update(ctx, view); err != nil {
+		return nil, storeError(err)
+	}
+	return s.loadView(ctx, collection, view.Name)
+}
+
+// DeleteView removes a view.
+func (s *Service) DeleteView(ctx context.Context, collectionName, name string) error {
+	collection, err := s.viewCollection(collectionName)
+	if err != nil {
+		return err
+	}
+	return storeError(s.views.Delete(ctx, collection.Name, name))
+}
+
+// viewCollection returns the collection if saved views are available.
+func (s *Service) viewCollection(collectionName string) (*schema.Collection, error) {
+	if s.views == nil {
+		return nil, apperror.ErrNotFound.WithMessage("Saved views are not enabled")
+	}
+	return s.schemaManager.GetCollection(collectionName)
+}
+
+// loadView fetches a view, mapping store failures to AppErrors.
+func (s *Service) loadView(ctx context.Context, collection *schema.Collection, name string) (*View, error) {
+	view, err := s.views.Get(ctx, collection.Name, name)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return view, nil
+}
+
+// applyView merges a view into list parameters for the calling user.
+// Parameters given in the request take precedence over the view's.
+func (s *Service) applyView(ctx context.Context, collection *schema.Collection, params ListParams, name string) (ListParams, error) {
+	if s.views == nil {
+		return params, apperror.ErrBadRequest.WithMessage("Saved views are not enabled")
+	}
+
+	view, err := s.loadView(ctx, collection, name)
+	if err != nil {
+		return params, err
+	}
+
+	role := ""
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		role = user.Role
+	}
+	if !view.allows(role) {
+		return params, apperror.ErrForbidden.WithMessagef("View '%s' is not available to your role", name)
+	}
+
+	merged := view.Query.Params()
+	for key, values := range params.QueryParams {
+		merged[key] = values
+	}
+	params.QueryParams = merged
+	if len(params.Expand) == 0 {
+		params.Expand = view.Query.Expand
+	}
+	return params, nil
+}
+
+// validateView checks that a view's query is valid for the collection.
+func validateView(manager *schema.Manager, collection *schema.Collection, q ViewQuery) error {
+	fieldNames := getFieldNames(collection.Fields)
+	params := q.Params()
+
+	filters, err := query.NewFilterParser(fieldNames).Parse(params)
+	if err != nil {
+		return err
+	}
+	if len(filters) != len(q.Filter) {
+		return apperror.ErrInvalidFilter.WithMessage("Filter keys must be 'field' or 'field:op'")
+	}
+	if filters, err = textFilters(collection, filters); err != nil {
+		return err
+	}
+	filters = resolveRelativeDates(collection, filters, time.Now())
+	if _, err := coerceFilters(collection, filters); err != nil {
+		return err
+	}
+	joins := make([]query.Join, 0)
+	for _, join := range relationJoins(manager, collection) {
+		joins = append(joins, join.Join)
+	}
+	if _, err := query.NewSortParser(fieldNames).WithJoins(joins).Parse(q.Sort); err != nil {
+		return err
+	}
+	if _, _, err := selectFields(collection, strings.Join(q.Fields, ",")); err != nil {
+		return err
+	}
+	for _, e := range q.Expand {
+		if _, ok := manager.GetRelationship(collection.Name, e+"_id"); ok {
+			continue
+		}
+		if _, ok := manager.GetRelationship(collection.Name, e); !ok {
+			return apperror.ErrBadRequest.WithMessagef("Cannot expand '%s'", e)
+		}
+	}
+	return nil
+}
+
+// storeError passes AppErrors through and wraps other store failures.
+func storeError(err error) error {
+	if err == nil || apperror.IsAppError(err) {
+		return err
+	}
+	return apperror.ErrInternalServer.WithError(err)
+}
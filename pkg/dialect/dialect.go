@@ -0,0 +1,93 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Dialect names.
+const (
+	Postgres = "postgres"
+	MySQL    = "mysql"
+	SQLite   = "sqlite"
+)
+
+// Dialect describes the SQL syntax differences between database backends.
+type Dialect interface {
+	// Name returns the dialect name.
+	Name() string
+
+	// DriverName returns the default database/sql driver name.
+	DriverName() string
+
+	// Placeholder returns the bind parameter for the n-th argument (1-based).
+	Placeholder(n int) string
+
+	// QuoteIdent quotes a table or column identifier.
+	QuoteIdent(name string) string
+
+	// LikeOperator returns the case-insensitive LIKE operator.
+	LikeOperator() string
+
+	// SupportsReturning reports whether INSERT/UPDATE ... RETURNING is available.
+	SupportsReturning() bool
+
+	// SupportsNullsOrdering reports whether ORDER BY ... NULLS FIRST/LAST is available.
+	SupportsNullsOrdering() bool
+
+	// RandomOrder returns an ORDER BY expression that shuffles rows.
+	// With an empty seed the order is random per query; otherwise it is a
+	// stable shuffle derived from the row key and the seed. qualifier is a
+	// quoted table name or alias (possibly empty), key the quoted primary key
+	// column, and seed must already be validated as a safe literal.
+	RandomOrder(qualifier, key, seed string) string
+}
+
+// qualify prefixes a column with a table qualifier when one is given.
+func qualify(qualifier, column string) string {
+	if qualifier == "" {
+		return column
+	}
+	return qualifier + "." + column
+}
+
+// registry maps dialect and driver names to dialects.
+var registry = map[string]Dialect{
+	"postgres": PostgresDialect{},
+	"pgx":      PostgresDialect{},
+	"mysql":    MySQLDialect{},
+	"sqlite":   SQLiteDialect{},
+	"sqlite3":  SQLiteDialect{},
+}
+
+// Register registers a dialect under the given name or driver name.
+func Register(name string, d Dialect) {
+	registry[strings.ToLower(name)] = d
+}
+
+// Get returns the dialect registered under name.
+// An empty name returns the PostgreSQL dialect.
+func Get(name string) (Dialect, error) {
+	if name == "" {
+		return PostgresDialect{}, nil
+	}
+	d, ok := registry[strings.ToLower(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported database dialect: %s", name)
+	}
+	return d, nil
+}
+
+// ForDriver returns the dialect for a database/sql driver name,
+// falling back to PostgreSQL for unknown drivers.
+func ForDriver(driverName string) Dialect {
+	if d, err := Get(driverName); err == nil {
+		return d
+	}
+	return PostgresDialect{}
+}
+
+// Default returns the default (PostgreSQL) dialect.
+func Default() Dialect {
+	return PostgresDialect{}
+}
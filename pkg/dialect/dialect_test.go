@@ -0,0 +1,48 @@
+package dialect
+
+import (
+	"strings"
+	"testing"
+)
+
+func FuzzQuoteIdent(f *testing.F) {
+	for _, seed := range []string{
+		"name",
+		"",
+		`id"; DROP TABLE users; --`,
+		"id`; DROP TABLE users; --",
+		`""`,
+		"order",
+		"ünïcode",
+	} {
+		f.Add(seed)
+	}
+
+	dialects := []struct {
+		dialect Dialect
+		quote   string
+	}{
+		{PostgresDialect{}, `"`},
+		{MySQLDialect{}, "`"},
+		{SQLiteDialect{}, `"`},
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		for _, tt := range dialects {
+			d, quote := tt.dialect, tt.quote
+			quoted := d.QuoteIdent(name)
+			if len(quoted) < 2 || !strings.HasPrefix(quoted, quote) || !strings.HasSuffix(quoted, quote) {
+				t.Fatalf("%s: %q is not enclosed in %s", d.Name(), quoted, quote)
+			}
+
+			// Every quote inside must be doubled, so none ends the identifier early
+			inner := quoted[1 : len(quoted)-1]
+			if strings.Count(strings.ReplaceAll(inner, quote+quote, ""), quote) != 0 {
+				t.Fatalf("%s: %q has an unescaped quote", d.Name(), quoted)
+			}
+			if got := strings.ReplaceAll(inner, quote+quote, quote); got != name {
+				t.Fatalf("%s: %q unquotes to %q, want %q", d.Name(), quoted, got, name)
+			}
+		}
+	})
+}
@@ -0,0 +1,40 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MySQLDialect implements Dialect for MySQL and MariaDB.
+type MySQLDialect struct{}
+
+// Name returns the dialect name.
+func (MySQLDialect) Name() string { return MySQL }
+
+// DriverName returns the default driver name.
+func (MySQLDialect) DriverName() string { return "mysql" }
+
+// Placeholder returns a ? bind parameter.
+func (MySQLDialect) Placeholder(n int) string { return "?" }
+
+// QuoteIdent quotes an identifier with backticks.
+func (MySQLDialect) QuoteIdent(name string) string {
+	return "`" + strings.ReplaceAll(name, "`", "``") + "`"
+}
+
+// LikeOperator returns LIKE, which is case-insensitive under the default collations.
+func (MySQLDialect) LikeOperator() string { return "LIKE" }
+
+// SupportsReturning returns false.
+func (MySQLDialect) SupportsReturning() bool { return false }
+
+// SupportsNullsOrdering returns false; NULL placement is emulated with IS NULL ordering.
+func (MySQLDialect) SupportsNullsOrdering() bool { return false }
+
+// RandomOrder returns RAND() or an MD5 hash of the key and seed.
+func (MySQLDialect) RandomOrder(qualifier, key, seed string) string {
+	if seed == "" || key == "" {
+		return "RAND()"
+	}
+	return fmt.Sprintf("MD5(CONCAT(%s, '%s'))", qualify(qualifier, key), seed)
+}
@@ -0,0 +1,41 @@
+package dialect
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// PostgresDialect implements Dialect for PostgreSQL.
+type PostgresDialect struct{}
+
+// Name returns the dialect name.
+func (PostgresDialect) Name() string { return Postgres }
+
+// DriverName returns the default driver name.
+func (PostgresDialect) DriverName() string { return "postgres" }
+
+// Placeholder returns a $n bind parameter.
+func (PostgresDialect) Placeholder(n int) string { return "$" + strconv.Itoa(n) }
+
+// QuoteIdent quotes an identifier with double quotes.
+func (PostgresDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// LikeOperator returns ILIKE.
+func (PostgresDialect) LikeOperator() string { return "ILIKE" }
+
+// SupportsReturning returns true.
+func (PostgresDialect) SupportsReturning() bool { return true }
+
+// SupportsNullsOrdering returns true.
+func (PostgresDialect) SupportsNullsOrdering() bool { return true }
+
+// RandomOrder returns random() or an md5 hash of the key and seed.
+func (PostgresDialect) RandomOrder(qualifier, key, seed string) string {
+	if seed == "" || key == "" {
+		return "random()"
+	}
+	return fmt.Sprintf("md5(%s::text || '%s')", qualify(qualifier, key), seed)
+}
@@ -0,0 +1,45 @@
+package dialect
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strings"
+)
+
+// SQLiteDialect implements Dialect for SQLite.
+type SQLiteDialect struct{}
+
+// Name returns the dialect name.
+func (SQLiteDialect) Name() string { return SQLite }
+
+// DriverName returns the default driver name.
+func (SQLiteDialect) DriverName() string { return "sqlite3" }
+
+// Placeholder returns a ? bind parameter.
+func (SQLiteDialect) Placeholder(n int) string { return "?" }
+
+// QuoteIdent quotes an identifier with double quotes.
+func (SQLiteDialect) QuoteIdent(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// LikeOperator returns LIKE, which is case-insensitive for ASCII in SQLite.
+func (SQLiteDialect) LikeOperator() string { return "LIKE" }
+
+// SupportsReturning returns true (SQLite 3.35+).
+func (SQLiteDialect) SupportsReturning() bool { return true }
+
+// SupportsNullsOrdering returns true (SQLite 3.30+).
+func (SQLiteDialect) SupportsNullsOrdering() bool { return true }
+
+// RandomOrder returns random() or a multiplicative hash of the rowid.
+// SQLite has no built-in hash function, so seeded shuffles use the rowid
+// rather than the key.
+func (SQLiteDialect) RandomOrder(qualifier, key, seed string) string {
+	if seed == "" {
+		return "random()"
+	}
+	h := fnv.New32a()
+	h.Write([]byte(seed))
+	return fmt.Sprintf("((%s * 2654435761 + %d) %% 4294967296)", qualify(qualifier, "rowid"), h.Sum32())
+}
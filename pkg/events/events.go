@@ -0,0 +1,157 @@
+// Package events records every collection write as an ordered event in
+// tugo_events and publishes it to message brokers, forming a change feed
+// for downstream projections and data pipelines.
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Config configures the change feed.
+type Config struct {
+	// Enabled appends an event to tugo_events for every create, update
+	// and delete, served by GET /events.
+	Enabled bool
+
+	// Collections limits the feed to these collections, by API or table
+	// name. Empty means all collections.
+	Collections []string
+
+	// Publishers receive every event, such as NATSPublisher or
+	// KafkaPublisher. When Enabled, a Relay delivers them from tugo_events
+	// at least once, retrying while a broker is down; otherwise they are
+	// called once per write and failures are only logged.
+	Publishers []Publisher
+
+	// PollInterval is how often the relay looks for events stored by other
+	// instances. Default: DefaultPollInterval
+	PollInterval time.Duration
+}
+
+// Event is a write to a collection record. IDs increase in the order
+// events are stored and serve as cursors; they are zero when events are
+// only published.
+type Event struct {
+	ID         int64           `db:"id" json:"id"`
+	Collection string          `db:"collection" json:"collection"`
+	Action     string          `db:"action" json:"action"`
+	ItemID     string          `db:"item_id" json:"item_id"`
+	Data       json.RawMessage `db:"-" json:"data"`
+	UserID     *string         `db:"user_id" json:"user_id,omitempty"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+
+	RawData []byte `db:"data" json:"-"`
+}
+
+// Publisher sends events to a message broker such as NATS or Kafka.
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+// PublisherFunc adapts a function to a Publisher.
+type PublisherFunc func(ctx context.Context, event Event) error
+
+// Publish calls f.
+func (f PublisherFunc) Publish(ctx context.Context, event Event) error {
+	return f(ctx, event)
+}
+
+// Log appends collection writes to the change feed. It implements
+// collection.RecordNotifier.
+type Log struct {
+	collections []string
+	store       *Store
+	publishers  []Publisher
+	relay       *Relay
+	logger      *zap.SugaredLogger
+}
+
+// NewLog creates a change feed writing to store, or only publishing when
+// store is nil.
+func NewLog(config Config, store *Store, logger *zap.SugaredLogger) *Log {
+	l := &Log{
+		collections: config.Collections,
+		store:       store,
+		publishers:  config.Publishers,
+		logger:      logger,
+	}
+	if store != nil && len(config.Publishers) > 0 {
+		l.relay = NewRelay(store, config.Publishers, config.PollInterval, logger)
+	}
+	return l
+}
+
+// Start starts relaying stored events to the publishers.
+func (l *Log) Start(ctx context.Context) error {
+	if l.relay == nil {
+		return nil
+	}
+	return l.relay.Start(ctx)
+}
+
+// Stop stops relaying events.
+func (l *Log) Stop() {
+	if l.relay != nil {
+		l.relay.Stop()
+	}
+}
+
+// Store returns the event store, or nil when events are only published.
+func (l *Log) Store() *Store {
+	return l.store
+}
+
+// Watches reports whether writes to collection are part of the feed.
+func (l *Log) Watches(collection *schema.Collection, action string) bool {
+	return len(l.collections) == 0 ||
+		slices.Contains(l.collections, collection.Name) ||
+		slices.Contains(l.collections, collection.TableName)
+}
+
+// NotifyRecord appends a written record to the feed and publishes it,
+// through the relay when events are stored. Events are stored before the
+// request returns so their order follows the order of writes; failures are
+// logged, since the write has succeeded.
+func (l *Log) NotifyRecord(ctx context.Context, collection *schema.Collection, action string, record map[string]any) {
+	ctx = context.WithoutCancel(ctx)
+
+	data, err := json.Marshal(record)
+	if err != nil {
+		l.logger.Errorw("Failed to encode event", "collection", collection.Name, "action", action, "error", err)
+		return
+	}
+	event := Event{
+		Collection: collection.Name,
+		Action:     action,
+		ItemID:     fmt.Sprint(record[collection.PrimaryKey]),
+		Data:       data,
+		CreatedAt:  time.Now().UTC(),
+	}
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil && user.ID != "" {
+		event.UserID = &user.ID
+	}
+
+	if l.store != nil {
+		if err := l.store.Append(ctx, &event); err != nil {
+			l.logger.Errorw("Failed to store event", "collection", collection.Name, "action", action, "item_id", event.ItemID, "error", err)
+			return
+		}
+		if l.relay != nil {
+			l.relay.Wake()
+			return
+		}
+	}
+	for _, publisher := range l.publishers {
+		if err := publisher.Publish(ctx, event); err != nil {
+			l.logger.Errorw("Failed to publish event", "collection", collection.Name, "action", action, "id", event.ID, "error", err)
+		}
+	}
+}
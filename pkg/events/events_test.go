@@ -0,0 +1,86 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+func TestLogWatches(t *testing.T) {
+	posts := &schema.Collection{Name: "posts", TableName: "api_posts"}
+
+	tests := []struct {
+		name        string
+		collections []string
+		want        bool
+	}{
+		{"all collections", nil, true},
+		{"by API name", []string{"posts"}, true},
+		{"by table name", []string{"api_posts"}, true},
+		{"other collection", []string{"tags"}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log := NewLog(Config{Collections: tt.collections}, nil, zap.NewNop().Sugar())
+			if got := log.Watches(posts, "create"); got != tt.want {
+				t.Errorf("Watches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLogPublishes(t *testing.T) {
+	var published []Event
+	failing := PublisherFunc(func(ctx context.Context, event Event) error {
+		return errors.New("broker down")
+	})
+	recording := PublisherFunc(func(ctx context.Context, event Event) error {
+		published = append(published, event)
+		return nil
+	})
+	log := NewLog(Config{Publishers: []Publisher{failing, recording}}, nil, zap.NewNop().Sugar())
+
+	posts := &schema.Collection{Name: "posts", PrimaryKey: "id"}
+	log.NotifyRecord(context.Background(), posts, "update", map[string]any{"id": 7, "title": "Hello"})
+
+	if len(published) != 1 {
+		t.Fatalf("published %d events, want 1", len(published))
+	}
+	got := published[0]
+	if got.Collection != "posts" || got.Action != "update" || got.ItemID != "7" || string(got.Data) != `{"id":7,"title":"Hello"}` {
+		t.Errorf("published event = %+v", got)
+	}
+}
+
+type namedPublisher struct {
+	PublisherFunc
+	name string
+}
+
+func (p namedPublisher) Name() string { return p.name }
+
+func TestRelayCursorNames(t *testing.T) {
+	noop := PublisherFunc(func(ctx context.Context, event Event) error { return nil })
+	relay := NewRelay(nil, []Publisher{
+		noop,
+		namedPublisher{noop, "kafka:orders"},
+		namedPublisher{noop, ""},
+	}, 0, zap.NewNop().Sugar())
+
+	want := []string{"publisher-0", "kafka:orders", "publisher-2"}
+	if len(relay.publishers) != len(want) {
+		t.Fatalf("got %d publishers, want %d", len(relay.publishers), len(want))
+	}
+	for i, p := range relay.publishers {
+		if p.name != want[i] {
+			t.Errorf("publisher %d name = %q, want %q", i, p.name, want[i])
+		}
+	}
+	if relay.interval != DefaultPollInterval {
+		t.Errorf("interval = %v, want %v", relay.interval, DefaultPollInterval)
+	}
+}
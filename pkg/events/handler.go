@@ -0,0 +1,106 @@
+package events
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// Event page sizes.
+const (
+	DefaultLimit = 100
+	MaxLimit     = 1000
+)
+
+// Page is a page of the change feed. Cursor is the ID of its last event,
+// or the requested cursor when it is empty; pass it as ?since= for the
+// next page.
+type Page struct {
+	Events  []Event `json:"events"`
+	Cursor  int64   `json:"cursor"`
+	HasMore bool    `json:"has_more"`
+}
+
+// Handler serves the change feed.
+type Handler struct {
+	store  *Store
+	logger *zap.SugaredLogger
+}
+
+// NewHandler creates a new change feed handler.
+func NewHandler(store *Store, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// List handles GET /events?since=cursor requests, returning the events
+// after the cursor, oldest first. ?collection= limits them to a
+// comma-separated list of collections.
+func (h *Handler) List(c *gin.Context) {
+	var since int64
+	if value := c.Query("since"); value != "" {
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || n < 0 {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid cursor"))
+			return
+		}
+		since = n
+	}
+
+	limit := DefaultLimit
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid limit"))
+			return
+		}
+		limit = min(n, MaxLimit)
+	}
+
+	var collections []string
+	for _, name := range strings.Split(c.Query("collection"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			collections = append(collections, name)
+		}
+	}
+
+	events, err := h.store.List(c.Request.Context(), since, collections, limit+1)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	page := Page{Events: events, Cursor: since}
+	if len(events) > limit {
+		page.Events, page.HasMore = events[:limit], true
+	}
+	if n := len(page.Events); n > 0 {
+		page.Cursor = page.Events[n-1].ID
+	}
+	c.JSON(http.StatusOK, response.Success(page))
+}
+
+// RegisterRoutes registers change feed routes on a Gin router group.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.List)
+}
+
+// handleError converts errors to HTTP responses.
+func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if appErr, ok := apperror.AsAppError(err); ok {
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+}
@@ -0,0 +1,91 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/segmentio/kafka-go"
+)
+
+// KafkaConfig holds configuration for a Kafka publisher.
+type KafkaConfig struct {
+	// Brokers are the addresses of the Kafka brokers, such as
+	// "localhost:9092".
+	Brokers []string
+
+	// Topic receives all events. Events are keyed by collection and item
+	// ID, so the events of a record keep their order within a partition.
+	Topic string
+
+	// Transport configures TLS and SASL authentication.
+	// Default: kafka.DefaultTransport
+	Transport *kafka.Transport
+
+	// Name names the relay cursor. Default: "kafka:<Topic>"
+	Name string
+}
+
+// KafkaPublisher publishes events to a Kafka topic, waiting for all
+// in-sync replicas to acknowledge each one.
+type KafkaPublisher struct {
+	name   string
+	writer *kafka.Writer
+}
+
+// NewKafkaPublisher creates a new Kafka publisher.
+func NewKafkaPublisher(cfg KafkaConfig) (*KafkaPublisher, error) {
+	if len(cfg.Brokers) == 0 {
+		return nil, fmt.Errorf("Kafka brokers are required")
+	}
+	if cfg.Topic == "" {
+		return nil, fmt.Errorf("Kafka topic is required")
+	}
+	if cfg.Name == "" {
+		cfg.Name = "kafka:" + cfg.Topic
+	}
+
+	writer := &kafka.Writer{
+		Addr:         kafka.TCP(cfg.Brokers...),
+		Topic:        cfg.Topic,
+		Balancer:     &kafka.Hash{},
+		RequiredAcks: kafka.RequireAll,
+		BatchSize:    1,
+	}
+	if cfg.Transport != nil {
+		writer.Transport = cfg.Transport
+	}
+	return &KafkaPublisher{name: cfg.Name, writer: writer}, nil
+}
+
+// Name returns the relay cursor name.
+func (p *KafkaPublisher) Name() string {
+	return p.name
+}
+
+// Publish writes an event to the topic.
+func (p *KafkaPublisher) Publish(ctx context.Context, event Event) error {
+	value, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	msg := kafka.Message{
+		Key:   []byte(event.Collection + ":" + event.ItemID),
+		Value: value,
+		Headers: []kafka.Header{
+			{Key: "tugo-event-id", Value: []byte(strconv.FormatInt(event.ID, 10))},
+			{Key: "tugo-collection", Value: []byte(event.Collection)},
+			{Key: "tugo-action", Value: []byte(event.Action)},
+		},
+	}
+	if err := p.writer.WriteMessages(ctx, msg); err != nil {
+		return fmt.Errorf("failed to publish event to Kafka: %w", err)
+	}
+	return nil
+}
+
+// Close flushes and closes the writer.
+func (p *KafkaPublisher) Close() error {
+	return p.writer.Close()
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// DefaultNATSSubject is the subject prefix of published events.
+const DefaultNATSSubject = "tugo.events"
+
+// NATSConfig holds configuration for a NATS JetStream publisher.
+type NATSConfig struct {
+	// URL is the NATS server URL. Default: nats.DefaultURL
+	URL string
+
+	// Subject is the prefix of event subjects; events are published to
+	// <Subject>.<collection>.<action>, which a JetStream stream must
+	// capture. Default: DefaultNATSSubject
+	Subject string
+
+	// Options configure the connection, such as nats.UserInfo or
+	// nats.Secure.
+	Options []nats.Option
+
+	// Name names the relay cursor. Default: "nats:<Subject>"
+	Name string
+}
+
+// NATSPublisher publishes events to NATS JetStream, waiting for the stream
+// to acknowledge each one. Stored events carry their ID as Nats-Msg-Id, so
+// JetStream drops events the relay publishes twice within the stream's
+// duplicate window.
+type NATSPublisher struct {
+	name    string
+	subject string
+	conn    *nats.Conn
+	js      jetstream.JetStream
+}
+
+// NewNATSPublisher connects a new NATS publisher. The connection is
+// retried in the background when the server is unreachable.
+func NewNATSPublisher(cfg NATSConfig) (*NATSPublisher, error) {
+	if cfg.URL == "" {
+		cfg.URL = nats.DefaultURL
+	}
+	if cfg.Subject == "" {
+		cfg.Subject = DefaultNATSSubject
+	}
+	if cfg.Name == "" {
+		cfg.Name = "nats:" + cfg.Subject
+	}
+
+	opts := append([]nats.Option{nats.Name("tugo"), nats.RetryOnFailedConnect(true), nats.MaxReconnects(-1)}, cfg.Options...)
+	conn, err := nats.Connect(cfg.URL, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to NATS: %w", err)
+	}
+	js, err := jetstream.New(conn)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("failed to create JetStream context: %w", err)
+	}
+	return &NATSPublisher{name: cfg.Name, subject: cfg.Subject, conn: conn, js: js}, nil
+}
+
+// Name returns the relay cursor name.
+func (p *NATSPublisher) Name() string {
+	return p.name
+}
+
+// Publish publishes an event to <subject>.<collection>.<action>.
+func (p *NATSPublisher) Publish(ctx context.Context, event Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to encode event: %w", err)
+	}
+	msg := nats.NewMsg(p.subject + "." + event.Collection + "." + event.Action)
+	msg.Data = data
+
+	var opts []jetstream.PublishOpt
+	if event.ID > 0 {
+		opts = append(opts, jetstream.WithMsgID("tugo-"+strconv.FormatInt(event.ID, 10)))
+	}
+	if _, err := p.js.PublishMsg(ctx, msg, opts...); err != nil {
+		return fmt.Errorf("failed to publish event to NATS: %w", err)
+	}
+	return nil
+}
+
+// Close drains and closes the connection.
+func (p *NATSPublisher) Close() error {
+	return p.conn.Drain()
+}
@@ -0,0 +1,235 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"go.uber.org/zap"
+)
+
+// Relay timings.
+const (
+	// DefaultPollInterval is how often the relay looks for events it was
+	// not woken for, such as those stored by other instances.
+	DefaultPollInterval = time.Second
+
+	// maxRetryInterval caps the backoff after a failed publish.
+	maxRetryInterval = time.Minute
+
+	// leaseDuration is how long a relay owns a publisher's cursor before
+	// another instance may take it over.
+	leaseDuration = 30 * time.Second
+
+	// gapWait is how long the relay waits for a missing event ID to be
+	// committed before treating it as a gap in the sequence.
+	gapWait = 5 * time.Second
+
+	relayBatch = 100
+)
+
+// Named is implemented by publishers that name their relay cursor. Other
+// publishers are named by their position in Config.Publishers.
+type Named interface {
+	Name() string
+}
+
+// Relay delivers stored events to publishers from tugo_events, acting as a
+// transactional outbox: each publisher has a cursor in tugo_event_cursors
+// that only advances once the broker accepts an event, so events stored
+// while a broker is down are published when it is back. Delivery is
+// at least once; consumers should deduplicate by event ID.
+type Relay struct {
+	store    *Store
+	owner    string
+	interval time.Duration
+	logger   *zap.SugaredLogger
+
+	publishers []relayPublisher
+
+	mu     sync.Mutex
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+// relayPublisher is a publisher with its cursor name and wake-up channel.
+type relayPublisher struct {
+	Publisher
+	name string
+	wake chan struct{}
+}
+
+// NewRelay creates a relay from store to publishers, checking for events
+// every interval when it is not woken.
+func NewRelay(store *Store, publishers []Publisher, interval time.Duration, logger *zap.SugaredLogger) *Relay {
+	if interval <= 0 {
+		interval = DefaultPollInterval
+	}
+	r := &Relay{
+		store:    store,
+		owner:    uuid.NewString(),
+		interval: interval,
+		logger:   logger,
+	}
+	for i, p := range publishers {
+		name := fmt.Sprintf("publisher-%d", i)
+		if named, ok := p.(Named); ok && named.Name() != "" {
+			name = named.Name()
+		}
+		r.publishers = append(r.publishers, relayPublisher{Publisher: p, name: name, wake: make(chan struct{}, 1)})
+	}
+	return r
+}
+
+// Start begins relaying in the background. Publishers without a cursor
+// start at the current end of the feed, so a new publisher does not replay
+// it. It does nothing when the relay is already started.
+func (r *Relay) Start(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return nil
+	}
+
+	head, err := r.store.Head(ctx)
+	if err != nil {
+		return err
+	}
+	ctx, r.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	for _, p := range r.publishers {
+		r.wg.Add(1)
+		go r.run(ctx, p, head)
+	}
+	return nil
+}
+
+// Stop stops relaying and waits for in-flight publishes to return.
+func (r *Relay) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		r.wg.Wait()
+	}
+}
+
+// Wake tells the relay that an event was stored.
+func (r *Relay) Wake() {
+	for _, p := range r.publishers {
+		select {
+		case p.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// run relays events to one publisher until ctx is done. Only the instance
+// holding the cursor lease publishes; the others retry the claim.
+func (r *Relay) run(ctx context.Context, p relayPublisher, head int64) {
+	defer r.wg.Done()
+
+	var (
+		owned  bool
+		cursor int64
+		leased time.Time
+		retry  time.Duration
+	)
+	for {
+		wait := r.interval
+		wake := p.wake
+
+		if !owned || time.Until(leased) < leaseDuration/2 {
+			var err error
+			owned, cursor, err = r.claim(ctx, p.name, head, cursor, owned)
+			switch {
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				r.logger.Errorw("Failed to claim event cursor", "publisher", p.name, "error", err)
+			case owned:
+				leased = time.Now().Add(leaseDuration)
+			}
+		}
+
+		if owned {
+			n, err := r.deliver(ctx, p, &cursor)
+			switch {
+			case errors.Is(err, errLostCursor):
+				owned = false
+			case err != nil:
+				if ctx.Err() != nil {
+					return
+				}
+				retry = min(max(2*retry, r.interval), maxRetryInterval)
+				wait, wake = retry, nil
+				r.logger.Warnw("Failed to publish event, retrying", "publisher", p.name, "id", cursor+1, "retry_in", retry, "error", err)
+			case n == relayBatch:
+				retry, wait = 0, 0
+			default:
+				retry = 0
+			}
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-wake:
+			timer.Stop()
+		case <-timer.C:
+		}
+	}
+}
+
+// claim takes or renews the cursor lease, creating the cursor at head when
+// it does not exist.
+func (r *Relay) claim(ctx context.Context, name string, head, cursor int64, owned bool) (bool, int64, error) {
+	position, ok, err := r.store.Claim(ctx, name, r.owner, head, time.Now().Add(leaseDuration))
+	if err != nil || !ok {
+		return false, cursor, err
+	}
+	if owned && position < cursor {
+		// Keep the local position when renewing; it may be ahead of a
+		// failed Advance.
+		return true, cursor, nil
+	}
+	return true, position, nil
+}
+
+// errLostCursor reports that another instance took over a cursor.
+var errLostCursor = errors.New("event cursor taken over by another instance")
+
+// deliver publishes the next batch of events after cursor, advancing it
+// after each one. It stops early at a missing ID that may still be
+// committed, so a slow transaction does not have its event skipped.
+func (r *Relay) deliver(ctx context.Context, p relayPublisher, cursor *int64) (int, error) {
+	events, err := r.store.List(ctx, *cursor, nil, relayBatch)
+	if err != nil {
+		return 0, err
+	}
+	for i, event := range events {
+		if event.ID != *cursor+1 && time.Since(event.CreatedAt) < gapWait {
+			return i, nil
+		}
+		if err := p.Publish(ctx, event); err != nil {
+			return i, err
+		}
+		*cursor = event.ID
+		ok, err := r.store.Advance(ctx, p.name, r.owner, event.ID)
+		if err != nil {
+			return i + 1, err
+		}
+		if !ok {
+			return i + 1, errLostCursor
+		}
+	}
+	return len(events), nil
+}
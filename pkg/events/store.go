@@ -0,0 +1,144 @@
+package events
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// Store persists events in tugo_events.
+type Store struct {
+	db        *sqlx.DB
+	returning bool
+}
+
+// NewStore creates a new event store.
+func NewStore(db *sqlx.DB) *Store {
+	s := &Store{db: db, returning: true}
+	if d, err := dialect.Get(db.DriverName()); err == nil {
+		s.returning = d.SupportsReturning()
+	}
+	return s
+}
+
+// Append stores an event, setting its ID.
+func (s *Store) Append(ctx context.Context, e *Event) error {
+	query := `
+		INSERT INTO tugo_events (collection, action, item_id, data, user_id, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	args := []any{e.Collection, e.Action, e.ItemID, string(e.Data), e.UserID, e.CreatedAt}
+
+	if s.returning {
+		if err := s.db.GetContext(ctx, &e.ID, s.db.Rebind(query+" RETURNING id"), args...); err != nil {
+			return fmt.Errorf("failed to append event: %w", err)
+		}
+		return nil
+	}
+
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	if e.ID, err = res.LastInsertId(); err != nil {
+		return fmt.Errorf("failed to append event: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit events after the cursor since, oldest first,
+// limited to collections unless it is empty.
+func (s *Store) List(ctx context.Context, since int64, collections []string, limit int) ([]Event, error) {
+	query := `
+		SELECT id, collection, action, item_id, data, user_id, created_at
+		FROM tugo_events
+		WHERE id > ?
+	`
+	args := []any{since}
+	if len(collections) > 0 {
+		query += " AND collection IN (?" + strings.Repeat(", ?", len(collections)-1) + ")"
+		for _, c := range collections {
+			args = append(args, c)
+		}
+	}
+	query += " ORDER BY id LIMIT ?"
+	args = append(args, limit)
+
+	events := make([]Event, 0)
+	if err := s.db.SelectContext(ctx, &events, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to list events: %w", err)
+	}
+	for i := range events {
+		events[i].Data = events[i].RawData
+	}
+	return events, nil
+}
+
+// Head returns the ID of the last stored event, or zero.
+func (s *Store) Head(ctx context.Context) (int64, error) {
+	var head int64
+	if err := s.db.GetContext(ctx, &head, "SELECT COALESCE(MAX(id), 0) FROM tugo_events"); err != nil {
+		return 0, fmt.Errorf("failed to read event head: %w", err)
+	}
+	return head, nil
+}
+
+// Claim makes owner the relay of the named cursor until the lease ends,
+// unless another owner holds an unexpired lease. The cursor is created at
+// start when it does not exist. It returns the cursor position and whether
+// the claim succeeded.
+func (s *Store) Claim(ctx context.Context, name, owner string, start int64, until time.Time) (int64, bool, error) {
+	now := time.Now().UnixMilli()
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		UPDATE tugo_event_cursors SET owner = ?, lease_until = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE name = ? AND (owner = ? OR owner IS NULL OR lease_until IS NULL OR lease_until < ?)
+	`), owner, until.UnixMilli(), name, owner, now)
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to claim event cursor: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		var exists int
+		err := s.db.GetContext(ctx, &exists, s.db.Rebind("SELECT COUNT(*) FROM tugo_event_cursors WHERE name = ?"), name)
+		if err != nil {
+			return 0, false, fmt.Errorf("failed to claim event cursor: %w", err)
+		}
+		if exists > 0 {
+			return 0, false, nil
+		}
+		if _, err := s.db.ExecContext(ctx, s.db.Rebind(`
+			INSERT INTO tugo_event_cursors (name, last_id, owner, lease_until, updated_at)
+			VALUES (?, ?, ?, ?, CURRENT_TIMESTAMP)
+		`), name, start, owner, until.UnixMilli()); err != nil {
+			// Another instance created it first.
+			return 0, false, nil
+		}
+		return start, true, nil
+	}
+
+	var position int64
+	if err := s.db.GetContext(ctx, &position, s.db.Rebind("SELECT last_id FROM tugo_event_cursors WHERE name = ?"), name); err != nil {
+		return 0, false, fmt.Errorf("failed to read event cursor: %w", err)
+	}
+	return position, true, nil
+}
+
+// Advance moves the named cursor to position while owner holds it. It
+// returns false when another owner has taken over.
+func (s *Store) Advance(ctx context.Context, name, owner string, position int64) (bool, error) {
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(`
+		UPDATE tugo_event_cursors SET last_id = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE name = ? AND owner = ?
+	`), position, name, owner)
+	if err != nil {
+		return false, fmt.Errorf("failed to advance event cursor: %w", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to advance event cursor: %w", err)
+	}
+	return n > 0, nil
+}
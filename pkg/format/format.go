@@ -0,0 +1,312 @@
+// Package format encodes API values as XML and MessagePack and decodes
+// request bodies in those formats, alongside the default JSON.
+package format
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/ugorji/go/codec"
+)
+
+// Supported media types.
+const (
+	MIMEJSON     = "application/json"
+	MIMEXML      = "application/xml"
+	MIMEXML2     = "text/xml"
+	MIMEMsgPack  = "application/msgpack"
+	MIMEMsgPack2 = "application/x-msgpack"
+)
+
+// Config selects the formats offered besides JSON.
+type Config struct {
+	// XML serializes responses as XML for Accept: application/xml and
+	// accepts XML request bodies.
+	XML bool
+
+	// MsgPack serializes responses as MessagePack for
+	// Accept: application/msgpack and accepts MessagePack request bodies.
+	MsgPack bool
+}
+
+// Enabled reports whether any format besides JSON is offered.
+func (c Config) Enabled() bool {
+	return c.XML || c.MsgPack
+}
+
+// Offered returns the media types to negotiate, JSON first.
+func (c Config) Offered() []string {
+	offered := []string{MIMEJSON}
+	if c.XML {
+		offered = append(offered, MIMEXML, MIMEXML2)
+	}
+	if c.MsgPack {
+		offered = append(offered, MIMEMsgPack, MIMEMsgPack2)
+	}
+	return offered
+}
+
+// Accepts reports whether a request content type is an enabled format
+// other than JSON.
+func (c Config) Accepts(contentType string) bool {
+	switch contentType {
+	case MIMEXML, MIMEXML2:
+		return c.XML
+	case MIMEMsgPack, MIMEMsgPack2:
+		return c.MsgPack
+	}
+	return false
+}
+
+// Decode reads a request body of the given content type into maps,
+// slices and scalars, as encoding/json would decode it into an any.
+func Decode(contentType string, r io.Reader) (any, error) {
+	switch contentType {
+	case MIMEXML, MIMEXML2:
+		return DecodeXML(r)
+	case MIMEMsgPack, MIMEMsgPack2:
+		return DecodeMsgPack(r)
+	}
+	return nil, fmt.Errorf("unsupported content type %q", contentType)
+}
+
+// generic converts v to the maps, slices and scalars of its JSON form, so
+// every format follows the JSON field names and value encodings.
+func generic(v any) (any, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(b))
+	decoder.UseNumber()
+	var out any
+	if err := decoder.Decode(&out); err != nil {
+		return nil, err
+	}
+	return numbers(out), nil
+}
+
+// numbers replaces json.Number values with int64 or float64.
+func numbers(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		for k, item := range val {
+			val[k] = numbers(item)
+		}
+	case []any:
+		for i, item := range val {
+			val[i] = numbers(item)
+		}
+	case json.Number:
+		if n, err := val.Int64(); err == nil {
+			return n
+		}
+		f, _ := val.Float64()
+		return f
+	}
+	return v
+}
+
+// msgpackHandle decodes maps with string keys and strings as UTF-8.
+var msgpackHandle = func() *codec.MsgpackHandle {
+	h := &codec.MsgpackHandle{}
+	h.MapType = reflect.TypeOf(map[string]any(nil))
+	h.RawToString = true
+	h.WriteExt = true
+	return h
+}()
+
+// EncodeMsgPack writes v as MessagePack.
+func EncodeMsgPack(w io.Writer, v any) error {
+	g, err := generic(v)
+	if err != nil {
+		return err
+	}
+	return codec.NewEncoder(w, msgpackHandle).Encode(g)
+}
+
+// DecodeMsgPack reads one MessagePack value.
+func DecodeMsgPack(r io.Reader) (any, error) {
+	var v any
+	if err := codec.NewDecoder(r, msgpackHandle).Decode(&v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// EncodeXML writes v as an XML document with the given root element.
+// Objects become child elements in key order, arrays repeat <item>
+// elements, and null is marked with nil="true". Keys that are not valid
+// element names are written as <field name="key">.
+func EncodeXML(w io.Writer, root string, v any) error {
+	g, err := generic(v)
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, xml.Header); err != nil {
+		return err
+	}
+	encoder := xml.NewEncoder(w)
+	if err := encodeXMLValue(encoder, xml.StartElement{Name: xml.Name{Local: root}}, g); err != nil {
+		return err
+	}
+	return encoder.Flush()
+}
+
+// encodeXMLValue writes one element holding v.
+func encodeXMLValue(e *xml.Encoder, start xml.StartElement, v any) error {
+	if v == nil {
+		start.Attr = append(start.Attr, xml.Attr{Name: xml.Name{Local: "nil"}, Value: "true"})
+	}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+
+	switch val := v.(type) {
+	case nil:
+	case map[string]any:
+		keys := make([]string, 0, len(val))
+		for k := range val {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			if err := encodeXMLValue(e, elementFor(k), val[k]); err != nil {
+				return err
+			}
+		}
+	case []any:
+		for _, item := range val {
+			if err := encodeXMLValue(e, xml.StartElement{Name: xml.Name{Local: "item"}}, item); err != nil {
+				return err
+			}
+		}
+	default:
+		if err := e.EncodeToken(xml.CharData(fmt.Sprint(val))); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// elementFor returns the element for an object key.
+func elementFor(key string) xml.StartElement {
+	if validName(key) {
+		return xml.StartElement{Name: xml.Name{Local: key}}
+	}
+	return xml.StartElement{
+		Name: xml.Name{Local: "field"},
+		Attr: []xml.Attr{{Name: xml.Name{Local: "name"}, Value: key}},
+	}
+}
+
+// validName reports whether key can be used as an element name as is.
+func validName(key string) bool {
+	if key == "" || strings.HasPrefix(strings.ToLower(key), "xml") {
+		return false
+	}
+	for i, r := range key {
+		switch {
+		case unicode.IsLetter(r) || r == '_':
+		case i > 0 && (unicode.IsDigit(r) || r == '-' || r == '.'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// DecodeXML reads an XML document written in the EncodeXML layout. The root
+// element's name is ignored. Elements with children become objects, or
+// arrays if every child is an <item>; others hold their text as a string.
+func DecodeXML(r io.Reader) (any, error) {
+	decoder := xml.NewDecoder(r)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil, errors.New("missing root element")
+			}
+			return nil, err
+		}
+		if start, ok := token.(xml.StartElement); ok {
+			return decodeXMLElement(decoder, start)
+		}
+	}
+}
+
+// decodeXMLElement reads the content of an element whose start was consumed.
+func decodeXMLElement(d *xml.Decoder, start xml.StartElement) (any, error) {
+	isNil := false
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "nil" && attr.Value == "true" {
+			isNil = true
+		}
+	}
+
+	var text strings.Builder
+	var keys []string
+	var values []any
+	for {
+		token, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			value, err := decodeXMLElement(d, t)
+			if err != nil {
+				return nil, err
+			}
+			key := t.Name.Local
+			if key == "field" {
+				for _, attr := range t.Attr {
+					if attr.Name.Local == "name" {
+						key = attr.Value
+					}
+				}
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			return xmlValue(isNil, text.String(), keys, values), nil
+		}
+	}
+}
+
+// xmlValue builds the value of a decoded element.
+func xmlValue(isNil bool, text string, keys []string, values []any) any {
+	if isNil {
+		return nil
+	}
+	if len(keys) == 0 {
+		return text
+	}
+
+	isArray := true
+	for _, k := range keys {
+		if k != "item" {
+			isArray = false
+			break
+		}
+	}
+	if isArray {
+		return values
+	}
+
+	obj := make(map[string]any, len(keys))
+	for i, k := range keys {
+		obj[k] = values[i]
+	}
+	return obj
+}
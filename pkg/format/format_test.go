@@ -0,0 +1,155 @@
+package format
+
+import (
+	"bytes"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeXML(t *testing.T) {
+	tests := []struct {
+		name  string
+		value any
+		want  string
+	}{
+		{
+			name:  "object fields in key order",
+			value: map[string]any{"title": "a < b", "id": 1},
+			want:  "<response><id>1</id><title>a &lt; b</title></response>",
+		},
+		{
+			name:  "array items",
+			value: []any{1, "x"},
+			want:  "<response><item>1</item><item>x</item></response>",
+		},
+		{
+			name:  "null",
+			value: map[string]any{"deleted_at": nil},
+			want:  `<response><deleted_at nil="true"></deleted_at></response>`,
+		},
+		{
+			name:  "invalid element names",
+			value: map[string]any{"2fa": true, "xmlns": "x"},
+			want:  `<response><field name="2fa">true</field><field name="xmlns">x</field></response>`,
+		},
+		{
+			name: "struct uses json names",
+			value: struct {
+				Success bool `json:"success"`
+				Data    any  `json:"data,omitempty"`
+			}{Success: true},
+			want: "<response><success>true</success></response>",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := EncodeXML(&buf, "response", tt.value); err != nil {
+				t.Fatalf("EncodeXML() error = %v", err)
+			}
+			got := strings.TrimPrefix(buf.String(), "<?xml version=\"1.0\" encoding=\"UTF-8\"?>\n")
+			if got != tt.want {
+				t.Errorf("EncodeXML() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeXML(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    string
+		want    any
+		wantErr bool
+	}{
+		{
+			name: "record",
+			body: `<record><title>Hello</title><views>3</views><note nil="true"/></record>`,
+			want: map[string]any{"title": "Hello", "views": "3", "note": nil},
+		},
+		{
+			name: "items",
+			body: `<items><item><title>a</title></item><item><title>b</title></item></items>`,
+			want: []any{map[string]any{"title": "a"}, map[string]any{"title": "b"}},
+		},
+		{
+			name: "field element",
+			body: `<record><field name="2fa">true</field></record>`,
+			want: map[string]any{"2fa": "true"},
+		},
+		{
+			name:    "malformed",
+			body:    `<record><title>a</record>`,
+			wantErr: true,
+		},
+		{
+			name:    "empty",
+			body:    ``,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeXML(strings.NewReader(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("DecodeXML() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DecodeXML() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMsgPackRoundTrip(t *testing.T) {
+	value := map[string]any{
+		"id":     int64(7),
+		"price":  9.5,
+		"title":  "Hello",
+		"tags":   []any{"a", "b"},
+		"active": true,
+		"note":   nil,
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeMsgPack(&buf, value); err != nil {
+		t.Fatalf("EncodeMsgPack() error = %v", err)
+	}
+	got, err := DecodeMsgPack(&buf)
+	if err != nil {
+		t.Fatalf("DecodeMsgPack() error = %v", err)
+	}
+
+	m, ok := got.(map[string]any)
+	if !ok {
+		t.Fatalf("DecodeMsgPack() = %T, want map[string]any", got)
+	}
+	if m["id"] != int64(7) || m["price"] != 9.5 || m["title"] != "Hello" || m["active"] != true || m["note"] != nil {
+		t.Errorf("DecodeMsgPack() = %#v", m)
+	}
+	if !reflect.DeepEqual(m["tags"], []any{"a", "b"}) {
+		t.Errorf("DecodeMsgPack() tags = %#v", m["tags"])
+	}
+}
+
+func TestConfig_Accepts(t *testing.T) {
+	config := Config{XML: true}
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{MIMEXML, true},
+		{MIMEXML2, true},
+		{MIMEMsgPack, false},
+		{MIMEJSON, false},
+	}
+
+	for _, tt := range tests {
+		if got := config.Accepts(tt.contentType); got != tt.want {
+			t.Errorf("Accepts(%q) = %v, want %v", tt.contentType, got, tt.want)
+		}
+	}
+}
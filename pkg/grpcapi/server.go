@@ -0,0 +1,359 @@
+// Package grpcapi serves the collections over gRPC for internal consumers
+// that avoid HTTP/JSON. It exposes a generic tugo.v1.Collections service,
+// described in tugo.proto, whose Query and Mutate methods take and return
+// google.protobuf.Struct payloads and run through the same collection
+// service, authentication and permission checks as the REST API.
+package grpcapi
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"sync"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/permission"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// ServiceName is the full name of the gRPC service.
+const ServiceName = "tugo.v1.Collections"
+
+// Mutate actions.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// Config configures the gRPC server.
+type Config struct {
+	// Service runs the collection queries and writes.
+	Service *collection.Service
+
+	// Provider and UserStore authenticate the bearer token in the
+	// "authorization" metadata. A nil Provider serves unauthenticated calls.
+	Provider  auth.Provider
+	UserStore auth.UserStore
+
+	// Permissions checks each call against the collection policies, as
+	// permission.Middleware does for REST requests. Nil skips the checks.
+	Permissions *permission.Checker
+
+	// Options configure the server, such as TLS credentials or interceptors.
+	Options []grpc.ServerOption
+
+	Logger *zap.SugaredLogger
+}
+
+// Server serves the Collections service.
+type Server struct {
+	config Config
+	server *grpc.Server
+
+	mu       sync.Mutex
+	listener net.Listener
+	done     chan struct{}
+}
+
+// NewServer creates a new gRPC server.
+func NewServer(config Config) *Server {
+	s := &Server{
+		config: config,
+		server: grpc.NewServer(config.Options...),
+	}
+	s.server.RegisterService(&serviceDesc, s)
+	return s
+}
+
+// GRPCServer returns the underlying server, to register more services on it.
+func (s *Server) GRPCServer() *grpc.Server {
+	return s.server
+}
+
+// Start listens on addr and serves in the background.
+func (s *Server) Start(addr string) error {
+	listener, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("failed to listen for gRPC: %w", err)
+	}
+
+	s.mu.Lock()
+	s.listener = listener
+	s.done = make(chan struct{})
+	s.mu.Unlock()
+
+	go func() {
+		defer close(s.done)
+		if err := s.server.Serve(listener); err != nil {
+			s.config.Logger.Errorw("gRPC server stopped", "error", err)
+		}
+	}()
+	s.config.Logger.Infow("gRPC server listening", "address", listener.Addr().String())
+	return nil
+}
+
+// Addr returns the address the server listens on, or nil before Start.
+func (s *Server) Addr() net.Addr {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+// Stop finishes pending calls and stops the server.
+func (s *Server) Stop() {
+	s.server.GracefulStop()
+	s.mu.Lock()
+	done := s.done
+	s.mu.Unlock()
+	if done != nil {
+		<-done
+	}
+}
+
+// Query reads records. The request holds the collection and either an
+// id, a list of ids, or a query of REST list parameters such as
+// {"filter[status]": "open", "sort": "-created_at"}, with optional expand.
+func (s *Server) Query(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	fields := req.GetFields()
+	name := fields["collection"].GetStringValue()
+	ctx, err := s.authorize(ctx, name, permission.ActionRead)
+	if err != nil {
+		return nil, err
+	}
+	expand := stringList(fields["expand"])
+
+	switch {
+	case fields["id"] != nil:
+		item, err := s.config.Service.Get(ctx, name, scalar(fields["id"]), expand)
+		if err != nil {
+			return nil, s.status(err)
+		}
+		return encode(map[string]any{"item": item})
+	case fields["ids"] != nil:
+		batch, err := s.config.Service.BatchGet(ctx, name, stringList(fields["ids"]), expand)
+		if err != nil {
+			return nil, s.status(err)
+		}
+		return encode(batch)
+	}
+
+	params := map[string][]string{}
+	for key, value := range fields["query"].GetStructValue().GetFields() {
+		params[key] = stringList(value)
+	}
+	list, err := s.config.Service.List(ctx, collection.ListParams{CollectionName: name, QueryParams: params, Expand: expand})
+	if err != nil {
+		return nil, s.status(err)
+	}
+	return encode(map[string]any{"items": list.Items, "pagination": list.Pagination})
+}
+
+// Mutate writes records. The request holds the collection, an action of
+// "create", "update" or "delete", the record id for updates and deletes,
+// and the record as data, or a list of records as items for a batch create.
+func (s *Server) Mutate(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error) {
+	fields := req.GetFields()
+	name := fields["collection"].GetStringValue()
+	action := fields["action"].GetStringValue()
+
+	var required permission.Action
+	switch action {
+	case ActionCreate:
+		required = permission.ActionCreate
+	case ActionUpdate:
+		required = permission.ActionUpdate
+	case ActionDelete:
+		required = permission.ActionDelete
+	default:
+		return nil, status.Errorf(codes.InvalidArgument, "invalid action %q", action)
+	}
+	ctx, err := s.authorize(ctx, name, required)
+	if err != nil {
+		return nil, err
+	}
+	if action != ActionCreate && fields["id"] == nil {
+		return nil, status.Errorf(codes.InvalidArgument, "%s requires an id", action)
+	}
+	id := scalar(fields["id"])
+	data := fields["data"].GetStructValue().AsMap()
+
+	switch action {
+	case ActionCreate:
+		if items := fields["items"].GetListValue(); items != nil {
+			records := make([]map[string]any, 0, len(items.GetValues()))
+			for _, item := range items.GetValues() {
+				records = append(records, item.GetStructValue().AsMap())
+			}
+			created, err := s.config.Service.CreateMany(ctx, name, records)
+			if err != nil {
+				return nil, s.status(err)
+			}
+			return encode(map[string]any{"created": created})
+		}
+		item, err := s.config.Service.Create(ctx, name, data)
+		if err != nil {
+			return nil, s.status(err)
+		}
+		return encode(map[string]any{"item": item})
+	case ActionUpdate:
+		item, err := s.config.Service.Update(ctx, name, id, data)
+		if err != nil {
+			return nil, s.status(err)
+		}
+		return encode(map[string]any{"item": item})
+	default:
+		if err := s.config.Service.Delete(ctx, name, id); err != nil {
+			return nil, s.status(err)
+		}
+		return encode(map[string]any{"deleted": true})
+	}
+}
+
+// authorize authenticates the caller and checks its permission for action
+// on the collection, returning a context holding the user.
+func (s *Server) authorize(ctx context.Context, name string, action permission.Action) (context.Context, error) {
+	if name == "" {
+		return ctx, status.Error(codes.InvalidArgument, "collection is required")
+	}
+
+	var user *auth.User
+	if s.config.Provider != nil {
+		var token string
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get("authorization"); len(values) > 0 {
+				token = auth.ExtractTokenFromHeader(values[0])
+			}
+		}
+		if token == "" {
+			return ctx, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		claims, err := s.config.Provider.ValidateToken(ctx, token)
+		if err != nil {
+			return ctx, s.status(err)
+		}
+		user, err = s.config.UserStore.GetByID(ctx, claims.UserID)
+		if err != nil {
+			return ctx, status.Error(codes.Unauthenticated, "user not found")
+		}
+		if user.Status != "" && user.Status != "active" {
+			return ctx, status.Error(codes.PermissionDenied, "account is not active")
+		}
+		ctx = auth.SetClaimsInContext(auth.SetUserInContext(ctx, user), claims)
+	}
+
+	if s.config.Permissions != nil {
+		if user == nil {
+			return ctx, status.Error(codes.Unauthenticated, "authentication required")
+		}
+		result, err := s.config.Permissions.Check(ctx, user, name, action)
+		if err != nil {
+			s.config.Logger.Errorw("Permission check failed", "collection", name, "error", err)
+			return ctx, status.Error(codes.Internal, "permission check failed")
+		}
+		if !result.Allowed {
+			return ctx, status.Error(codes.PermissionDenied, result.Reason)
+		}
+	}
+	return ctx, nil
+}
+
+// status converts an error to a gRPC status, logging unexpected ones.
+func (s *Server) status(err error) error {
+	st := statusFromError(err)
+	if st.Code() == codes.Internal {
+		s.config.Logger.Errorw("Unexpected error", "error", err)
+	}
+	return st.Err()
+}
+
+// encode converts a value to a Struct through its JSON form, so records
+// hold the same values as REST responses.
+func encode(v any) (*structpb.Struct, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+	}
+	out := &structpb.Struct{}
+	if err := protojson.Unmarshal(data, out); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to encode response: %v", err)
+	}
+	return out, nil
+}
+
+// scalar returns a string or number value as a string, as REST path
+// parameters are.
+func scalar(v *structpb.Value) string {
+	switch kind := v.GetKind().(type) {
+	case *structpb.Value_StringValue:
+		return kind.StringValue
+	case *structpb.Value_NumberValue:
+		return strconv.FormatFloat(kind.NumberValue, 'f', -1, 64)
+	case *structpb.Value_BoolValue:
+		return strconv.FormatBool(kind.BoolValue)
+	}
+	return ""
+}
+
+// stringList returns a scalar or a list of scalars as strings.
+func stringList(v *structpb.Value) []string {
+	if v == nil {
+		return nil
+	}
+	list := v.GetListValue()
+	if list == nil {
+		return []string{scalar(v)}
+	}
+	values := make([]string, 0, len(list.GetValues()))
+	for _, item := range list.GetValues() {
+		values = append(values, scalar(item))
+	}
+	return values
+}
+
+// collectionsServer is the Collections service implementation.
+type collectionsServer interface {
+	Query(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+	Mutate(ctx context.Context, req *structpb.Struct) (*structpb.Struct, error)
+}
+
+// serviceDesc describes the Collections service of tugo.proto.
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: ServiceName,
+	HandlerType: (*collectionsServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{MethodName: "Query", Handler: unaryHandler("Query", collectionsServer.Query)},
+		{MethodName: "Mutate", Handler: unaryHandler("Mutate", collectionsServer.Mutate)},
+	},
+	Metadata: "tugo.proto",
+}
+
+// unaryHandler adapts a Struct method to a gRPC method handler.
+func unaryHandler(method string, call func(collectionsServer, context.Context, *structpb.Struct) (*structpb.Struct, error)) grpc.MethodHandler {
+	fullMethod := "/" + ServiceName + "/" + method
+	return func(srv any, ctx context.Context, dec func(any) error, interceptor grpc.UnaryServerInterceptor) (any, error) {
+		req := &structpb.Struct{}
+		if err := dec(req); err != nil {
+			return nil, err
+		}
+		if interceptor == nil {
+			return call(srv.(collectionsServer), ctx, req)
+		}
+		info := &grpc.UnaryServerInfo{Server: srv, FullMethod: fullMethod}
+		return interceptor(ctx, req, info, func(ctx context.Context, req any) (any, error) {
+			return call(srv.(collectionsServer), ctx, req.(*structpb.Struct))
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package grpcapi
+
+import (
+	"net/http"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// codeByHTTPStatus maps the HTTP status of application errors to gRPC codes.
+var codeByHTTPStatus = map[int]codes.Code{
+	http.StatusAccepted:                codes.FailedPrecondition, // pending approval
+	http.StatusBadRequest:              codes.InvalidArgument,
+	http.StatusUnauthorized:            codes.Unauthenticated,
+	http.StatusForbidden:               codes.PermissionDenied,
+	http.StatusNotFound:                codes.NotFound,
+	http.StatusConflict:                codes.AlreadyExists,
+	http.StatusPreconditionFailed:      codes.FailedPrecondition,
+	http.StatusPreconditionRequired:    codes.FailedPrecondition,
+	http.StatusRequestEntityTooLarge:   codes.InvalidArgument,
+	http.StatusUnprocessableEntity:     codes.InvalidArgument,
+	http.StatusLocked:                  codes.Unavailable,
+	http.StatusTooManyRequests:         codes.ResourceExhausted,
+	http.StatusServiceUnavailable:      codes.Unavailable,
+	http.StatusGatewayTimeout:          codes.DeadlineExceeded,
+	apperror.StatusClientClosedRequest: codes.Canceled,
+}
+
+// statusFromError converts an error to a gRPC status. Application errors
+// keep their message; other errors become Internal without details.
+func statusFromError(err error) *status.Status {
+	if st, ok := status.FromError(err); ok {
+		return st
+	}
+	appErr, ok := apperror.AsAppError(err)
+	if !ok {
+		return status.New(codes.Internal, apperror.ErrInternalServer.Message)
+	}
+	code, ok := codeByHTTPStatus[appErr.HTTPStatus]
+	if !ok {
+		code = codes.Internal
+	}
+	return status.New(code, appErr.Message)
+}
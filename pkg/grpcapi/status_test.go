@@ -0,0 +1,61 @@
+package grpcapi
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+func TestStatusFromError(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		code    codes.Code
+		message string
+	}{
+		{"not found", apperror.ErrCollectionNotFound.WithMessage("Collection 'x' not found"), codes.NotFound, "Collection 'x' not found"},
+		{"validation", apperror.ErrValidation.WithMessage("title: field is required"), codes.InvalidArgument, "title: field is required"},
+		{"conflict", apperror.ErrConflict, codes.AlreadyExists, apperror.ErrConflict.Message},
+		{"canceled", apperror.ErrRequestCanceled, codes.Canceled, apperror.ErrRequestCanceled.Message},
+		{"status kept", status.Error(codes.Unauthenticated, "authentication required"), codes.Unauthenticated, "authentication required"},
+		{"unexpected", errors.New("connection reset"), codes.Internal, apperror.ErrInternalServer.Message},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			st := statusFromError(tt.err)
+			if st.Code() != tt.code || st.Message() != tt.message {
+				t.Errorf("statusFromError() = %v %q, want %v %q", st.Code(), st.Message(), tt.code, tt.message)
+			}
+		})
+	}
+}
+
+func TestStringList(t *testing.T) {
+	tests := []struct {
+		name  string
+		value *structpb.Value
+		want  []string
+	}{
+		{"missing", nil, nil},
+		{"string", structpb.NewStringValue("-created_at"), []string{"-created_at"}},
+		{"integer", structpb.NewNumberValue(20), []string{"20"}},
+		{"large integer", structpb.NewNumberValue(1234567890123), []string{"1234567890123"}},
+		{"list", structpb.NewListValue(&structpb.ListValue{Values: []*structpb.Value{
+			structpb.NewNumberValue(1), structpb.NewStringValue("b"), structpb.NewBoolValue(true),
+		}}), []string{"1", "b", "true"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stringList(tt.value); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("stringList() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,217 @@
+// Package idgen generates primary keys on the server: UUIDv7, ULID, KSUID
+// and snowflake IDs, all ordered by creation time.
+package idgen
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ID generation strategies.
+const (
+	// UUIDv7 is a time-ordered UUID with millisecond precision.
+	UUIDv7 = "uuidv7"
+
+	// ULID is a 26 character Crockford base32 ID with millisecond precision.
+	ULID = "ulid"
+
+	// KSUID is a 27 character base62 ID with second precision.
+	KSUID = "ksuid"
+
+	// Snowflake is a 64-bit integer made of a millisecond timestamp, a node
+	// ID and a sequence number.
+	Snowflake = "snowflake"
+)
+
+// DefaultEpoch is the start of snowflake timestamps.
+var DefaultEpoch = time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// MaxNode is the largest snowflake node ID.
+const MaxNode = 1<<10 - 1
+
+// ksuidEpoch is the start of KSUID timestamps, in Unix seconds.
+const ksuidEpoch = 1400000000
+
+// Properties describes the IDs of a strategy so clients know how they
+// order.
+type Properties struct {
+	Strategy string `json:"strategy"`
+
+	// Type is "uuid", "string" or "integer".
+	Type string `json:"type"`
+
+	// Length is the length of string IDs.
+	Length int `json:"length,omitempty"`
+
+	// Precision is the unit of time IDs are ordered by: IDs created within
+	// the same unit on different instances may sort in any order.
+	Precision string `json:"precision"`
+
+	// Monotonic is set when IDs from one instance always increase, even
+	// within the same unit of time.
+	Monotonic bool `json:"monotonic"`
+}
+
+var properties = map[string]Properties{
+	UUIDv7:    {Strategy: UUIDv7, Type: "uuid", Length: 36, Precision: "millisecond", Monotonic: true},
+	ULID:      {Strategy: ULID, Type: "string", Length: 26, Precision: "millisecond", Monotonic: true},
+	KSUID:     {Strategy: KSUID, Type: "string", Length: 27, Precision: "second"},
+	Snowflake: {Strategy: Snowflake, Type: "integer", Precision: "millisecond", Monotonic: true},
+}
+
+// Describe returns the properties of strategy's IDs, or false when the
+// strategy is unknown.
+func Describe(strategy string) (Properties, bool) {
+	p, ok := properties[strategy]
+	return p, ok
+}
+
+// Config holds configuration for ID generation.
+type Config struct {
+	// Node identifies this instance in snowflake IDs, from 0 to MaxNode.
+	// Instances writing to the same table need distinct nodes.
+	// Default: 0
+	Node int64
+
+	// Epoch is the start of snowflake timestamps, which last 69 years.
+	// Default: DefaultEpoch
+	Epoch time.Time
+}
+
+// Generator generates IDs. The zero Generator is ready to use with the
+// default config.
+type Generator struct {
+	node  int64
+	epoch time.Time
+
+	mu sync.Mutex
+
+	// lastULID is the time and randomness of the last ULID
+	lastULIDMs int64
+	lastULID   [10]byte
+
+	// lastSnowflake is the time and sequence of the last snowflake ID
+	lastSnowflakeMs int64
+	sequence        int64
+}
+
+// NewGenerator creates a new generator.
+func NewGenerator(cfg Config) (*Generator, error) {
+	if cfg.Node < 0 || cfg.Node > MaxNode {
+		return nil, fmt.Errorf("snowflake node must be between 0 and %d", MaxNode)
+	}
+	return &Generator{node: cfg.Node, epoch: cfg.Epoch}, nil
+}
+
+// Generate returns a new ID of strategy: a string, or an int64 for
+// snowflake IDs.
+func (g *Generator) Generate(strategy string) (any, error) {
+	switch strategy {
+	case UUIDv7:
+		id, err := uuid.NewV7()
+		if err != nil {
+			return nil, err
+		}
+		return id.String(), nil
+	case ULID:
+		return g.ulid(time.Now())
+	case KSUID:
+		return ksuid(time.Now())
+	case Snowflake:
+		return g.snowflake(time.Now()), nil
+	}
+	return nil, fmt.Errorf("unknown ID strategy '%s'", strategy)
+}
+
+// crockford is the Crockford base32 alphabet used by ULIDs.
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// ulid returns a ULID for now. Within the same millisecond the random part
+// of the last ULID is incremented, so IDs keep increasing.
+func (g *Generator) ulid(now time.Time) (string, error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	ms := now.UnixMilli()
+	if ms <= g.lastULIDMs {
+		ms = g.lastULIDMs
+		i := len(g.lastULID) - 1
+		for ; i >= 0; i-- {
+			g.lastULID[i]++
+			if g.lastULID[i] != 0 {
+				break
+			}
+		}
+		if i < 0 {
+			return "", fmt.Errorf("ULID overflow within one millisecond")
+		}
+	} else {
+		if _, err := rand.Read(g.lastULID[:]); err != nil {
+			return "", err
+		}
+		g.lastULIDMs = ms
+	}
+
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(ms)<<16)
+	copy(b[6:], g.lastULID[:])
+	return encode(b[:], crockford, 26), nil
+}
+
+// base62 is the alphabet used by KSUIDs.
+const base62 = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// ksuid returns a KSUID for now.
+func ksuid(now time.Time) (string, error) {
+	var b [20]byte
+	binary.BigEndian.PutUint32(b[:4], uint32(now.Unix()-ksuidEpoch))
+	if _, err := rand.Read(b[4:]); err != nil {
+		return "", err
+	}
+	return encode(b[:], base62, 27), nil
+}
+
+// encode writes b as a big-endian number in alphabet, padded to length.
+func encode(b []byte, alphabet string, length int) string {
+	n := new(big.Int).SetBytes(b)
+	base := big.NewInt(int64(len(alphabet)))
+	digit := new(big.Int)
+
+	out := make([]byte, length)
+	for i := length - 1; i >= 0; i-- {
+		n.DivMod(n, base, digit)
+		out[i] = alphabet[digit.Int64()]
+	}
+	return string(out)
+}
+
+// snowflake returns a snowflake ID for now. The sequence counts IDs within
+// a millisecond and moves on to the next one after 4096. A clock that moves
+// backwards keeps the last timestamp, so IDs never decrease.
+func (g *Generator) snowflake(now time.Time) int64 {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	epoch := g.epoch
+	if epoch.IsZero() {
+		epoch = DefaultEpoch
+	}
+	ms := now.Sub(epoch).Milliseconds()
+	if ms <= g.lastSnowflakeMs {
+		ms = g.lastSnowflakeMs
+		g.sequence = (g.sequence + 1) & 0xfff
+		if g.sequence == 0 {
+			ms++
+		}
+	} else {
+		g.sequence = 0
+	}
+	g.lastSnowflakeMs = ms
+	return ms<<22 | g.node<<12 | g.sequence
+}
@@ -0,0 +1,88 @@
+package idgen
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	g := &Generator{}
+	tests := []struct {
+		strategy string
+		length   int
+		alphabet string
+	}{
+		{UUIDv7, 36, "0123456789abcdef-"},
+		{ULID, 26, crockford},
+		{KSUID, 27, base62},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.strategy, func(t *testing.T) {
+			var last string
+			for range 100 {
+				v, err := g.Generate(tt.strategy)
+				if err != nil {
+					t.Fatal(err)
+				}
+				id := v.(string)
+				if len(id) != tt.length || strings.Trim(id, tt.alphabet) != "" {
+					t.Fatalf("Generate() = %q, want %d characters of %q", id, tt.length, tt.alphabet)
+				}
+				if id == last {
+					t.Fatalf("Generate() repeated %q", id)
+				}
+				if p, _ := Describe(tt.strategy); p.Monotonic && id < last {
+					t.Fatalf("Generate() = %q after %q", id, last)
+				}
+				last = id
+			}
+		})
+	}
+
+	if _, err := g.Generate("serial"); err == nil {
+		t.Error("Generate(serial) succeeded")
+	}
+}
+
+func TestULIDSameMillisecond(t *testing.T) {
+	g := &Generator{}
+	now := time.UnixMilli(1700000000000)
+	first, _ := g.ulid(now)
+	second, _ := g.ulid(now)
+	if second <= first || first[:10] != second[:10] {
+		t.Errorf("ulid() = %s then %s, want same time and larger", first, second)
+	}
+	if earlier, _ := g.ulid(now.Add(-time.Second)); earlier <= second {
+		t.Errorf("ulid() after clock moved back = %s, want larger than %s", earlier, second)
+	}
+}
+
+func TestSnowflake(t *testing.T) {
+	g, err := NewGenerator(Config{Node: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := DefaultEpoch.Add(time.Second)
+
+	first := g.snowflake(now)
+	if want := int64(1000)<<22 | 5<<12; first != want {
+		t.Errorf("snowflake() = %d, want %d", first, want)
+	}
+	if second := g.snowflake(now); second != first+1 {
+		t.Errorf("snowflake() in the same millisecond = %d, want %d", second, first+1)
+	}
+	if back := g.snowflake(now.Add(-time.Minute)); back <= first+1 {
+		t.Errorf("snowflake() after clock moved back = %d, want larger than %d", back, first+1)
+	}
+
+	g.sequence = 0xfff
+	if next := g.snowflake(now); next>>22 != 1001 || next&0xfff != 0 {
+		t.Errorf("snowflake() after a full sequence = %d, want the next millisecond", next)
+	}
+
+	if _, err := NewGenerator(Config{Node: MaxNode + 1}); err == nil {
+		t.Error("NewGenerator() accepted a node out of range")
+	}
+}
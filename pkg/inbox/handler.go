@@ -0,0 +1,184 @@
+package inbox
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// Notification page sizes.
+const (
+	DefaultLimit = 50
+	MaxLimit     = 500
+)
+
+// Page is a page of a user's notifications, with the number still unread.
+// Pass the creation time of its last notification as ?before= for the
+// next page.
+type Page struct {
+	Notifications []Notification `json:"notifications"`
+	Unread        int            `json:"unread"`
+	HasMore       bool           `json:"has_more"`
+}
+
+// ReadRequest is the body of POST /me/notifications/read.
+type ReadRequest struct {
+	IDs []string `json:"ids"`
+}
+
+// Handler serves the notifications of the request's user.
+type Handler struct {
+	store  *Store
+	logger *zap.SugaredLogger
+}
+
+// NewHandler creates a new notification handler.
+func NewHandler(store *Store, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// List handles GET /me/notifications requests, newest first. ?unread=true
+// lists only unread notifications.
+func (h *Handler) List(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	filter := Filter{Limit: DefaultLimit, Unread: c.Query("unread") == "true"}
+	if value := c.Query("limit"); value != "" {
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid limit"))
+			return
+		}
+		filter.Limit = min(n, MaxLimit)
+	}
+	if value := c.Query("before"); value != "" {
+		before, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid before time"))
+			return
+		}
+		filter.Before = &before
+	}
+
+	ctx := c.Request.Context()
+	limit := filter.Limit
+	filter.Limit++
+	notifications, err := h.store.List(ctx, userID, filter)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	unread, err := h.store.CountUnread(ctx, userID)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	page := Page{Notifications: notifications, Unread: unread}
+	if len(notifications) > limit {
+		page.Notifications, page.HasMore = notifications[:limit], true
+	}
+	c.JSON(http.StatusOK, response.Success(page))
+}
+
+// MarkRead handles POST /me/notifications/read requests. Bodies take the
+// form {"ids": [...]}; without IDs every notification is marked read.
+func (h *Handler) MarkRead(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	var req ReadRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid JSON body"))
+			return
+		}
+	}
+
+	marked, err := h.store.MarkRead(c.Request.Context(), userID, req.IDs)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(gin.H{"marked": marked}))
+}
+
+// MarkOneRead handles POST /me/notifications/:id/read requests.
+func (h *Handler) MarkOneRead(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	marked, err := h.store.MarkRead(c.Request.Context(), userID, []string{c.Param("id")})
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(gin.H{"marked": marked}))
+}
+
+// Delete handles DELETE /me/notifications/:id requests.
+func (h *Handler) Delete(c *gin.Context) {
+	userID, ok := h.userID(c)
+	if !ok {
+		return
+	}
+
+	deleted, err := h.store.Delete(c.Request.Context(), userID, c.Param("id"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	if !deleted {
+		h.handleError(c, apperror.ErrNotFound.WithMessagef("Notification '%s' not found", c.Param("id")))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(nil))
+}
+
+// RegisterRoutes registers notification routes on a Gin router group.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.List)
+	rg.POST("/read", h.MarkRead)
+	rg.POST("/:id/read", h.MarkOneRead)
+	rg.DELETE("/:id", h.Delete)
+}
+
+// userID returns the ID of the request's user, responding with an error
+// without one.
+func (h *Handler) userID(c *gin.Context) (string, bool) {
+	user, ok := auth.GetUserFromContext(c.Request.Context())
+	if !ok || user == nil || user.ID == "" {
+		h.handleError(c, apperror.ErrUnauthorized)
+		return "", false
+	}
+	return user.ID, true
+}
+
+// handleError converts errors to HTTP responses.
+func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if appErr, ok := apperror.AsAppError(err); ok {
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+}
@@ -0,0 +1,157 @@
+// Package inbox keeps in-app notifications for users in tugo_notifications,
+// such as mentions in comments, lifecycle transitions, reviews of pending
+// changes and failed webhook deliveries, and serves them under
+// /me/notifications.
+package inbox
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/thienel/tugo/pkg/auth"
+	"go.uber.org/zap"
+)
+
+// Notification types sent by TuGo. Hosts may use their own.
+const (
+	TypeMention        = "mention"
+	TypeReply          = "reply"
+	TypeTransition     = "transition"
+	TypeApproval       = "approval"
+	TypeWebhookFailure = "webhook_failure"
+)
+
+// Config configures the notification inbox.
+type Config struct {
+	// Enabled stores notifications and serves them under /me/notifications.
+	Enabled bool
+
+	// Pusher receives every stored notification, such as a bridge to the
+	// host's WebSocket or SSE connections.
+	Pusher Pusher
+
+	// WebhookRecipients are the usernames notified of failed webhook
+	// deliveries.
+	WebhookRecipients []string
+}
+
+// Notification is a message to a user. ReadAt is nil until the user marks
+// it read.
+type Notification struct {
+	ID         string         `db:"id" json:"id"`
+	UserID     string         `db:"user_id" json:"user_id"`
+	Type       string         `db:"type" json:"type"`
+	Title      string         `db:"title" json:"title"`
+	Body       string         `db:"body" json:"body,omitempty"`
+	Collection *string        `db:"collection" json:"collection,omitempty"`
+	ItemID     *string        `db:"item_id" json:"item_id,omitempty"`
+	ActorID    *string        `db:"actor_id" json:"actor_id,omitempty"`
+	Data       map[string]any `db:"-" json:"data,omitempty"`
+	ReadAt     *time.Time     `db:"read_at" json:"read_at,omitempty"`
+	CreatedAt  time.Time      `db:"created_at" json:"created_at"`
+
+	RawData []byte `db:"data" json:"-"`
+}
+
+// Pusher delivers stored notifications to connected clients.
+type Pusher interface {
+	Push(ctx context.Context, n Notification) error
+}
+
+// PusherFunc adapts a function to a Pusher.
+type PusherFunc func(ctx context.Context, n Notification) error
+
+// Push calls f.
+func (f PusherFunc) Push(ctx context.Context, n Notification) error {
+	return f(ctx, n)
+}
+
+// UserLookup finds users by username, such as an auth.UserStore.
+type UserLookup interface {
+	GetByUsername(ctx context.Context, username string) (*auth.User, error)
+}
+
+// Inbox stores notifications and pushes them to their users.
+type Inbox struct {
+	store  *Store
+	pusher Pusher
+	users  UserLookup
+	logger *zap.SugaredLogger
+}
+
+// NewInbox creates an inbox storing notifications in store.
+func NewInbox(config Config, store *Store, logger *zap.SugaredLogger) *Inbox {
+	return &Inbox{
+		store:  store,
+		pusher: config.Pusher,
+		logger: logger,
+	}
+}
+
+// SetUserLookup sets how usernames are resolved. Without it, notifications
+// addressed by username are dropped.
+func (i *Inbox) SetUserLookup(users UserLookup) {
+	i.users = users
+}
+
+// Store returns the notification store.
+func (i *Inbox) Store() *Store {
+	return i.store
+}
+
+// Notify sends n to each of userIDs, skipping the request's user, who
+// caused it. The actor is taken from the context when unset. Failures are logged,
+// not returned, so they never fail the write that caused them.
+func (i *Inbox) Notify(ctx context.Context, userIDs []string, n Notification) {
+	actor := ""
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		actor = user.ID
+	}
+	if n.ActorID == nil && actor != "" {
+		n.ActorID = &actor
+	}
+
+	for _, userID := range recipients(userIDs, actor) {
+		n.UserID = userID
+		if err := i.store.Create(ctx, &n); err != nil {
+			i.logger.Errorw("Failed to store notification", "user", userID, "type", n.Type, "error", err)
+			continue
+		}
+		if i.pusher != nil {
+			if err := i.pusher.Push(ctx, n); err != nil {
+				i.logger.Warnw("Failed to push notification", "user", userID, "notification", n.ID, "error", err)
+			}
+		}
+	}
+}
+
+// NotifyUsernames sends n to the users with usernames. Unknown usernames
+// are skipped.
+func (i *Inbox) NotifyUsernames(ctx context.Context, usernames []string, n Notification) {
+	if i.users == nil {
+		return
+	}
+	var userIDs []string
+	for _, username := range usernames {
+		user, err := i.users.GetByUsername(ctx, username)
+		if err != nil || user == nil {
+			continue
+		}
+		userIDs = append(userIDs, user.ID)
+	}
+	i.Notify(ctx, userIDs, n)
+}
+
+// recipients returns the distinct non-empty user IDs other than actor, in
+// order.
+func recipients(userIDs []string, actor string) []string {
+	result := make([]string, 0, len(userIDs))
+	for _, id := range userIDs {
+		if id == "" || id == actor || slices.Contains(result, id) {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
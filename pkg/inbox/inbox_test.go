@@ -0,0 +1,54 @@
+package inbox
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+func TestRecipients(t *testing.T) {
+	tests := []struct {
+		name    string
+		userIDs []string
+		actor   string
+		want    []string
+	}{
+		{"none", nil, "", []string{}},
+		{"in order", []string{"b", "a"}, "", []string{"b", "a"}},
+		{"actor skipped", []string{"a", "b"}, "a", []string{"b"}},
+		{"only actor", []string{"a"}, "a", []string{}},
+		{"duplicates", []string{"a", "b", "a"}, "", []string{"a", "b"}},
+		{"empty IDs", []string{"", "a"}, "", []string{"a"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := recipients(tt.userIDs, tt.actor); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recipients() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandlerRequiresUser(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	NewHandler(nil, zap.NewNop().Sugar()).RegisterRoutes(router.Group("/me/notifications"))
+
+	for _, route := range []struct{ method, path string }{
+		{http.MethodGet, "/me/notifications"},
+		{http.MethodPost, "/me/notifications/read"},
+		{http.MethodPost, "/me/notifications/1/read"},
+		{http.MethodDelete, "/me/notifications/1"},
+	} {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(route.method, route.path, nil))
+		if w.Code != http.StatusUnauthorized {
+			t.Errorf("%s %s = %d, want %d", route.method, route.path, w.Code, http.StatusUnauthorized)
+		}
+	}
+}
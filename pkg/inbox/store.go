@@ -0,0 +1,141 @@
+package inbox
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+)
+
+// Filter selects a user's notifications to list.
+type Filter struct {
+	// Unread lists only notifications not yet read.
+	Unread bool
+
+	// Before lists notifications created before this time, for paging.
+	Before *time.Time
+
+	Limit int
+}
+
+// Store persists notifications in tugo_notifications.
+type Store struct {
+	db *sqlx.DB
+}
+
+// NewStore creates a new notification store.
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// Create stores a new notification, filling in its ID and creation time.
+func (s *Store) Create(ctx context.Context, n *Notification) error {
+	var data any
+	if n.Data != nil {
+		raw, err := json.Marshal(n.Data)
+		if err != nil {
+			return fmt.Errorf("failed to encode notification data: %w", err)
+		}
+		data = string(raw)
+	}
+	n.ID = uuid.New().String()
+	n.CreatedAt = time.Now().UTC()
+
+	query := `
+		INSERT INTO tugo_notifications (id, user_id, type, title, body, collection, item_id, actor_id, data, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query), n.ID, n.UserID, n.Type, n.Title, n.Body,
+		n.Collection, n.ItemID, n.ActorID, data, n.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store notification: %w", err)
+	}
+	return nil
+}
+
+// List returns a user's notifications matching filter, newest first.
+func (s *Store) List(ctx context.Context, userID string, filter Filter) ([]Notification, error) {
+	query := `
+		SELECT id, user_id, type, title, body, collection, item_id, actor_id, data, read_at, created_at
+		FROM tugo_notifications
+		WHERE user_id = ?
+	`
+	args := []any{userID}
+	if filter.Unread {
+		query += " AND read_at IS NULL"
+	}
+	if filter.Before != nil {
+		query += " AND created_at < ?"
+		args = append(args, *filter.Before)
+	}
+	query += fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT %d", filter.Limit)
+
+	notifications := make([]Notification, 0)
+	if err := s.db.SelectContext(ctx, &notifications, s.db.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("failed to list notifications: %w", err)
+	}
+	for i := range notifications {
+		if err := notifications[i].decode(); err != nil {
+			return nil, err
+		}
+	}
+	return notifications, nil
+}
+
+// CountUnread returns the number of a user's unread notifications.
+func (s *Store) CountUnread(ctx context.Context, userID string) (int, error) {
+	query := `SELECT COUNT(*) FROM tugo_notifications WHERE user_id = ? AND read_at IS NULL`
+	var count int
+	if err := s.db.GetContext(ctx, &count, s.db.Rebind(query), userID); err != nil {
+		return 0, fmt.Errorf("failed to count notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead marks a user's notifications read, all unread ones when ids is
+// empty. It returns the number marked.
+func (s *Store) MarkRead(ctx context.Context, userID string, ids []string) (int64, error) {
+	query := `UPDATE tugo_notifications SET read_at = ? WHERE user_id = ? AND read_at IS NULL`
+	args := []any{time.Now().UTC(), userID}
+	if len(ids) > 0 {
+		query += " AND id IN (?)"
+		args = append(args, ids)
+		var err error
+		if query, args, err = sqlx.In(query, args...); err != nil {
+			return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+		}
+	}
+
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+	marked, _ := res.RowsAffected()
+	return marked, nil
+}
+
+// Delete removes a user's notification.
+func (s *Store) Delete(ctx context.Context, userID, id string) (bool, error) {
+	query := `DELETE FROM tugo_notifications WHERE user_id = ? AND id = ?`
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), userID, id)
+	if err != nil {
+		return false, fmt.Errorf("failed to delete notification: %w", err)
+	}
+	deleted, _ := res.RowsAffected()
+	return deleted > 0, nil
+}
+
+// decode fills in Data from the stored JSON.
+func (n *Notification) decode() error {
+	n.Data = nil
+	if len(n.RawData) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(n.RawData, &n.Data); err != nil {
+		return fmt.Errorf("failed to decode notification data: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,172 @@
+package ingest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Ingest results.
+const (
+	ActionCreated = "created"
+	ActionUpdated = "updated"
+	ActionIgnored = "ignored"
+)
+
+// Result reports what an ingested payload did.
+type Result struct {
+	Action string `json:"action"`
+	ID     any    `json:"id,omitempty"`
+}
+
+// Handler serves ingest routes.
+type Handler struct {
+	routes        map[string]*Route
+	service       *collection.Service
+	schemaManager *schema.Manager
+	logger        *zap.SugaredLogger
+}
+
+// NewHandler creates a handler for routes writing through service.
+func NewHandler(routes []Route, service *collection.Service, schemaManager *schema.Manager, logger *zap.SugaredLogger) (*Handler, error) {
+	h := &Handler{
+		routes:        make(map[string]*Route, len(routes)),
+		service:       service,
+		schemaManager: schemaManager,
+		logger:        logger,
+	}
+	for i := range routes {
+		route := routes[i]
+		if err := route.validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := h.routes[route.Name]; ok {
+			return nil, fmt.Errorf("duplicate ingest route: %s", route.Name)
+		}
+		h.routes[route.Name] = &route
+	}
+	return h, nil
+}
+
+// Ingest handles POST /ingest/:name requests.
+func (h *Handler) Ingest(c *gin.Context) {
+	route, ok := h.routes[c.Param("name")]
+	if !ok {
+		h.handleError(c, apperror.ErrNotFound.WithMessagef("Ingest route '%s' not found", c.Param("name")))
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, DefaultMaxBodyBytes))
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			err = apperror.ErrPayloadTooLarge.WithMessagef("Request body exceeds %d bytes", maxErr.Limit)
+		}
+		h.handleError(c, err)
+		return
+	}
+	if route.Verifier != nil {
+		if err := route.Verifier.Verify(c.Request.Header, body); err != nil {
+			h.handleError(c, apperror.ErrUnauthorized.WithMessage("Invalid signature"))
+			return
+		}
+	}
+
+	var payload map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	if err := decoder.Decode(&payload); err != nil || payload == nil {
+		h.handleError(c, apperror.ErrBadRequest.WithMessage("Invalid JSON payload"))
+		return
+	}
+
+	result, err := h.ingest(c, route, payload)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+	status := http.StatusOK
+	if result.Action == ActionCreated {
+		status = http.StatusCreated
+	}
+	c.JSON(status, response.Success(result))
+}
+
+// ingest writes the record of a payload, updating the record holding its
+// key value if there is one.
+func (h *Handler) ingest(c *gin.Context, route *Route, payload map[string]any) (*Result, error) {
+	ctx := c.Request.Context()
+	if !route.matches(payload, c.Request.Header) {
+		return &Result{Action: ActionIgnored}, nil
+	}
+	record, err := route.record(ctx, payload, c.Request.Header)
+	if err != nil {
+		return nil, apperror.ErrBadRequest.WithMessage("Failed to transform payload").WithError(err)
+	}
+	if record == nil {
+		return &Result{Action: ActionIgnored}, nil
+	}
+
+	col, err := h.schemaManager.GetCollection(route.Collection)
+	if err != nil {
+		return nil, err
+	}
+
+	if key, ok := record[route.Key]; ok && route.Key != "" && key != nil {
+		existing, err := h.service.List(ctx, collection.ListParams{
+			CollectionName: route.Collection,
+			QueryParams: map[string][]string{
+				"filter[" + route.Key + "]": {fmt.Sprint(key)},
+				"limit":                     {"1"},
+			},
+		})
+		if err != nil {
+			return nil, err
+		}
+		if len(existing.Items) > 0 {
+			// The key is unchanged; leaving it out spares its unique check
+			id := existing.Items[0][col.PrimaryKey]
+			update := maps.Clone(record)
+			delete(update, route.Key)
+			if _, err := h.service.Update(ctx, route.Collection, id, update); err != nil {
+				return nil, err
+			}
+			return &Result{Action: ActionUpdated, ID: id}, nil
+		}
+	}
+
+	item, err := h.service.Create(ctx, route.Collection, record)
+	if err != nil {
+		return nil, err
+	}
+	return &Result{Action: ActionCreated, ID: item[col.PrimaryKey]}, nil
+}
+
+// RegisterRoutes registers ingest routes on a Gin router group.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("/:name", h.Ingest)
+}
+
+// handleError converts errors to HTTP responses.
+func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if appErr, ok := apperror.AsAppError(err); ok {
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+}
@@ -0,0 +1,151 @@
+// Package ingest accepts third-party webhook payloads, such as Stripe or
+// GitHub events, verifies their signatures and maps them into records of a
+// collection.
+package ingest
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// DefaultMaxBodyBytes caps the size of an ingested payload.
+const DefaultMaxBodyBytes = 1 << 20
+
+// Route maps the payloads posted to POST /ingest/<Name> into a collection.
+type Route struct {
+	// Name is the last path segment of the ingest URL.
+	Name string
+
+	// Collection receives the records, by API name.
+	Collection string
+
+	// Verifier checks the payload signature, such as Stripe, GitHub or
+	// HMAC. Nil accepts unsigned payloads.
+	Verifier Verifier
+
+	// Match limits the route to payloads whose values at these paths are
+	// one of the listed values, such as {"type": {"customer.created"}}.
+	// Other payloads are acknowledged and ignored.
+	Match map[string][]string
+
+	// Mapping is the template of the record: each field takes its value
+	// from the payload. "{{data.object.email}}" keeps the value at the
+	// path with its JSON type, other strings are rendered with each
+	// {{path}} replaced, and strings without one are constants. Paths
+	// separate keys and array indexes with dots, "$" is the whole payload
+	// and "$headers.Name" a request header. Fields whose path is missing
+	// are left out. Empty means the top-level payload fields.
+	Mapping map[string]string
+
+	// Transform builds the record instead of Mapping. Returning a nil
+	// record ignores the payload.
+	Transform func(ctx context.Context, payload map[string]any, header http.Header) (map[string]any, error)
+
+	// Key is the field identifying existing records: a payload whose
+	// record holds a key value already stored updates that record. Empty
+	// means every payload creates a record.
+	Key string
+}
+
+// validate checks that the route is complete.
+func (r *Route) validate() error {
+	if r.Name == "" || r.Collection == "" {
+		return fmt.Errorf("ingest route needs a name and a collection")
+	}
+	if len(r.Mapping) > 0 && r.Transform != nil {
+		return fmt.Errorf("ingest route %s has both a mapping and a transform", r.Name)
+	}
+	if r.Key != "" && len(r.Mapping) > 0 {
+		if _, ok := r.Mapping[r.Key]; !ok {
+			return fmt.Errorf("ingest route %s does not map its key %s", r.Name, r.Key)
+		}
+	}
+	return nil
+}
+
+// matches reports whether the payload is one the route accepts.
+func (r *Route) matches(payload map[string]any, header http.Header) bool {
+	for path, values := range r.Match {
+		value, ok := lookup(payload, header, path)
+		if !ok || !slices.Contains(values, fmt.Sprint(value)) {
+			return false
+		}
+	}
+	return true
+}
+
+// record builds the record of a payload, or nil to ignore it.
+func (r *Route) record(ctx context.Context, payload map[string]any, header http.Header) (map[string]any, error) {
+	if r.Transform != nil {
+		return r.Transform(ctx, payload, header)
+	}
+	if len(r.Mapping) == 0 {
+		return payload, nil
+	}
+	return render(r.Mapping, payload, header), nil
+}
+
+// placeholder matches a {{path}} in a mapping template.
+var placeholder = regexp.MustCompile(`\{\{\s*([^{}]+?)\s*\}\}`)
+
+// render fills a mapping template from a payload.
+func render(mapping map[string]string, payload map[string]any, header http.Header) map[string]any {
+	record := make(map[string]any, len(mapping))
+	for field, tmpl := range mapping {
+		if m := placeholder.FindStringSubmatch(tmpl); m != nil && m[0] == tmpl {
+			if value, ok := lookup(payload, header, m[1]); ok {
+				record[field] = value
+			}
+			continue
+		}
+		record[field] = placeholder.ReplaceAllStringFunc(tmpl, func(s string) string {
+			value, ok := lookup(payload, header, placeholder.FindStringSubmatch(s)[1])
+			if !ok || value == nil {
+				return ""
+			}
+			return fmt.Sprint(value)
+		})
+	}
+	return record
+}
+
+// lookup returns the value at a dotted path of the payload, "$" for the
+// payload itself or "$headers.Name" for a request header.
+func lookup(payload map[string]any, header http.Header, path string) (any, bool) {
+	if path == "$" {
+		return payload, true
+	}
+	if name, ok := strings.CutPrefix(path, "$headers."); ok {
+		values := header.Values(name)
+		if len(values) == 0 {
+			return nil, false
+		}
+		return values[0], true
+	}
+
+	var value any = payload
+	for _, key := range strings.Split(path, ".") {
+		switch v := value.(type) {
+		case map[string]any:
+			next, ok := v[key]
+			if !ok {
+				return nil, false
+			}
+			value = next
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(v) {
+				return nil, false
+			}
+			value = v[i]
+		default:
+			return nil, false
+		}
+	}
+	return value, true
+}
@@ -0,0 +1,92 @@
+package ingest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestRender(t *testing.T) {
+	payload := map[string]any{
+		"type": "customer.created",
+		"data": map[string]any{
+			"object": map[string]any{
+				"id":    "cus_1",
+				"first": "Ann",
+				"last":  "Lee",
+				"items": []any{map[string]any{"sku": "A1"}},
+				"count": 3.0,
+			},
+		},
+	}
+	header := http.Header{"X-Github-Event": {"push"}}
+
+	tests := []struct {
+		name string
+		tmpl string
+		want any
+		ok   bool
+	}{
+		{"typed path", "{{data.object.count}}", 3.0, true},
+		{"array index", "{{ data.object.items.0.sku }}", "A1", true},
+		{"interpolated", "{{data.object.first}} {{data.object.last}}", "Ann Lee", true},
+		{"missing in string", "id-{{data.object.missing}}", "id-", true},
+		{"constant", "stripe", "stripe", true},
+		{"header", "{{$headers.X-GitHub-Event}}", "push", true},
+		{"missing path", "{{data.object.missing}}", nil, false},
+		{"index out of range", "{{data.object.items.5.sku}}", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := render(map[string]string{"field": tt.tmpl}, payload, header)
+			got, ok := record["field"]
+			if ok != tt.ok || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("render(%q) = %v, %v, want %v, %v", tt.tmpl, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func sign(secret, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func TestVerifiers(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := fmt.Sprint(time.Now().Unix())
+	old := fmt.Sprint(time.Now().Add(-time.Hour).Unix())
+
+	tests := []struct {
+		name     string
+		verifier Verifier
+		header   http.Header
+		valid    bool
+	}{
+		{"github", GitHub("s3cret"), http.Header{"X-Hub-Signature-256": {"sha256=" + sign("s3cret", string(body))}}, true},
+		{"github wrong secret", GitHub("s3cret"), http.Header{"X-Hub-Signature-256": {"sha256=" + sign("other", string(body))}}, false},
+		{"github missing", GitHub("s3cret"), http.Header{}, false},
+		{"hmac without prefix", HMAC("X-Signature", "s3cret"), http.Header{"X-Signature": {sign("s3cret", string(body))}}, true},
+		{"stripe", Stripe("whsec", 0), http.Header{"Stripe-Signature": {"t=" + now + ",v1=" + sign("whsec", now+"."+string(body))}}, true},
+		{"stripe second signature", Stripe("whsec", 0), http.Header{"Stripe-Signature": {"t=" + now + ",v1=00,v1=" + sign("whsec", now+"."+string(body))}}, true},
+		{"stripe expired", Stripe("whsec", 0), http.Header{"Stripe-Signature": {"t=" + old + ",v1=" + sign("whsec", old+"."+string(body))}}, false},
+		{"token", Token("X-Gitlab-Token", "tok"), http.Header{"X-Gitlab-Token": {"tok"}}, true},
+		{"token wrong", Token("X-Gitlab-Token", "tok"), http.Header{"X-Gitlab-Token": {"nope"}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.verifier.Verify(tt.header, body)
+			if (err == nil) != tt.valid {
+				t.Errorf("Verify() error = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
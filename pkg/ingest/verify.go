@@ -0,0 +1,104 @@
+package ingest
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// DefaultStripeTolerance is how old a Stripe signature timestamp may be.
+const DefaultStripeTolerance = 5 * time.Minute
+
+// ErrInvalidSignature is returned for payloads failing verification.
+var ErrInvalidSignature = errors.New("invalid signature")
+
+// Verifier checks that a payload comes from its sender.
+type Verifier interface {
+	Verify(header http.Header, body []byte) error
+}
+
+// VerifierFunc adapts a function to a Verifier.
+type VerifierFunc func(header http.Header, body []byte) error
+
+// Verify calls f.
+func (f VerifierFunc) Verify(header http.Header, body []byte) error {
+	return f(header, body)
+}
+
+// HMAC verifies a header holding the hex HMAC-SHA256 of the body, with or
+// without a "sha256=" prefix, as sent by TuGo webhooks in X-Tugo-Signature.
+func HMAC(headerName, secret string) Verifier {
+	return VerifierFunc(func(header http.Header, body []byte) error {
+		signature := strings.TrimPrefix(header.Get(headerName), "sha256=")
+		if !validMAC(secret, body, signature) {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+}
+
+// GitHub verifies the X-Hub-Signature-256 header of GitHub webhooks.
+func GitHub(secret string) Verifier {
+	return HMAC("X-Hub-Signature-256", secret)
+}
+
+// Stripe verifies the Stripe-Signature header of Stripe webhooks, rejecting
+// signatures older than tolerance, or DefaultStripeTolerance when zero.
+func Stripe(secret string, tolerance time.Duration) Verifier {
+	if tolerance <= 0 {
+		tolerance = DefaultStripeTolerance
+	}
+	return VerifierFunc(func(header http.Header, body []byte) error {
+		var timestamp string
+		var signatures []string
+		for _, part := range strings.Split(header.Get("Stripe-Signature"), ",") {
+			key, value, _ := strings.Cut(strings.TrimSpace(part), "=")
+			switch key {
+			case "t":
+				timestamp = value
+			case "v1":
+				signatures = append(signatures, value)
+			}
+		}
+
+		seconds, err := strconv.ParseInt(timestamp, 10, 64)
+		if err != nil || time.Since(time.Unix(seconds, 0)).Abs() > tolerance {
+			return ErrInvalidSignature
+		}
+		signed := append([]byte(timestamp+"."), body...)
+		for _, signature := range signatures {
+			if validMAC(secret, signed, signature) {
+				return nil
+			}
+		}
+		return ErrInvalidSignature
+	})
+}
+
+// Token verifies a header holding a shared secret, such as the
+// X-Gitlab-Token header of GitLab webhooks.
+func Token(headerName, token string) Verifier {
+	return VerifierFunc(func(header http.Header, body []byte) error {
+		if subtle.ConstantTimeCompare([]byte(header.Get(headerName)), []byte(token)) != 1 {
+			return ErrInvalidSignature
+		}
+		return nil
+	})
+}
+
+// validMAC reports whether signature is the hex HMAC-SHA256 of payload.
+func validMAC(secret string, payload []byte, signature string) bool {
+	got, err := hex.DecodeString(signature)
+	if err != nil {
+		return false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return hmac.Equal(got, mac.Sum(nil))
+}
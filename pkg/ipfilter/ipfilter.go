@@ -0,0 +1,227 @@
+// Package ipfilter restricts routes to client IP ranges and countries.
+package ipfilter
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// AuditAction is the tugo_audit_log action of a denied request.
+const AuditAction = "ip.denied"
+
+// Rule restricts the client addresses allowed on a route. Deny lists are
+// checked first; a non-empty allow list then admits only its entries.
+type Rule struct {
+	// Allow lists the IPs and CIDR ranges allowed, such as office or VPN
+	// ranges. Empty allows all addresses not denied.
+	Allow []string
+
+	// Deny lists the IPs and CIDR ranges denied.
+	Deny []string
+
+	// AllowCountries lists the ISO 3166-1 alpha-2 codes of the countries
+	// allowed, such as "DE". Addresses whose country cannot be resolved
+	// are denied. Requires a GeoResolver.
+	AllowCountries []string
+
+	// DenyCountries lists the countries denied. Addresses whose country
+	// cannot be resolved are allowed. Requires a GeoResolver.
+	DenyCountries []string
+}
+
+// IsZero reports whether the rule restricts nothing.
+func (r Rule) IsZero() bool {
+	return len(r.Allow) == 0 && len(r.Deny) == 0 && len(r.AllowCountries) == 0 && len(r.DenyCountries) == 0
+}
+
+// GeoResolver resolves the country of an address, such as a client of a
+// MaxMind GeoIP2 database.
+type GeoResolver interface {
+	// Country returns the ISO 3166-1 alpha-2 code of the address's
+	// country, or "" when it is unknown.
+	Country(ctx context.Context, addr netip.Addr) (string, error)
+}
+
+// Filter checks client addresses against a rule.
+type Filter struct {
+	allow          []netip.Prefix
+	deny           []netip.Prefix
+	allowCountries map[string]bool
+	denyCountries  map[string]bool
+	geo            GeoResolver
+	db             *sqlx.DB
+	logger         *zap.SugaredLogger
+}
+
+// NewFilter creates a filter for rule. Denied requests are recorded in
+// tugo_audit_log unless db is nil.
+func NewFilter(rule Rule, geo GeoResolver, db *sqlx.DB, logger *zap.SugaredLogger) (*Filter, error) {
+	f := &Filter{
+		allowCountries: countries(rule.AllowCountries),
+		denyCountries:  countries(rule.DenyCountries),
+		geo:            geo,
+		db:             db,
+		logger:         logger,
+	}
+	var err error
+	if f.allow, err = prefixes(rule.Allow); err != nil {
+		return nil, err
+	}
+	if f.deny, err = prefixes(rule.Deny); err != nil {
+		return nil, err
+	}
+	if geo == nil && (len(f.allowCountries) > 0 || len(f.denyCountries) > 0) {
+		return nil, errors.New("country rules require a GeoResolver")
+	}
+	return f, nil
+}
+
+// prefixes parses IPs and CIDR ranges.
+func prefixes(values []string) ([]netip.Prefix, error) {
+	result := make([]netip.Prefix, 0, len(values))
+	for _, value := range values {
+		if strings.Contains(value, "/") {
+			prefix, err := netip.ParsePrefix(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid CIDR range '%s': %w", value, err)
+			}
+			result = append(result, prefix.Masked())
+			continue
+		}
+		addr, err := netip.ParseAddr(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid IP address '%s': %w", value, err)
+		}
+		result = append(result, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+	}
+	return result, nil
+}
+
+// countries returns a set of upper-cased country codes.
+func countries(codes []string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[strings.ToUpper(code)] = true
+	}
+	return set
+}
+
+// contains reports whether any prefix contains addr.
+func contains(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Check reports whether a client IP is allowed, and why not. country is
+// the resolved country, if any.
+func (f *Filter) Check(ctx context.Context, ip string) (allowed bool, reason, country string) {
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return false, "invalid client address", ""
+	}
+	addr = addr.Unmap()
+
+	if contains(f.deny, addr) {
+		return false, "address denied", ""
+	}
+	if len(f.allow) > 0 && !contains(f.allow, addr) {
+		return false, "address not allowed", ""
+	}
+	if len(f.allowCountries) == 0 && len(f.denyCountries) == 0 {
+		return true, "", ""
+	}
+
+	country, err = f.geo.Country(ctx, addr)
+	if err != nil {
+		f.logger.Warnw("Failed to resolve client country", "ip", ip, "error", err)
+		country = ""
+	}
+	country = strings.ToUpper(country)
+	if f.denyCountries[country] {
+		return false, "country denied", country
+	}
+	if len(f.allowCountries) > 0 && !f.allowCountries[country] {
+		return false, "country not allowed", country
+	}
+	return true, "", country
+}
+
+// Middleware rejects requests from addresses the rule does not allow with
+// 403, recording them in tugo_audit_log. The client IP is gin's
+// c.ClientIP, so configure trusted proxies behind a load balancer.
+func (f *Filter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		allowed, reason, country := f.Check(c.Request.Context(), c.ClientIP())
+		if allowed {
+			c.Next()
+			return
+		}
+
+		f.audit(c, reason, country)
+		response.JSON(c, http.StatusForbidden, response.FromAppError(
+			apperror.ErrForbidden.WithMessage("Access from this address is not allowed"),
+		))
+		c.Abort()
+	}
+}
+
+// audit records a denied request in tugo_audit_log.
+func (f *Filter) audit(c *gin.Context, reason, country string) {
+	f.logger.Infow("Denied request by IP", "ip", c.ClientIP(), "path", c.Request.URL.Path, "reason", reason)
+	if f.db == nil {
+		return
+	}
+
+	changes := map[string]any{
+		"reason": reason,
+		"method": c.Request.Method,
+		"path":   c.Request.URL.Path,
+	}
+	if country != "" {
+		changes["country"] = country
+	}
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return
+	}
+	var collection *string
+	if name := c.Param("collection"); name != "" {
+		collection = &name
+	}
+
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(c.Request.Context()), 5*time.Second)
+	defer cancel()
+	query := `
+		INSERT INTO tugo_audit_log (id, action, collection, changes, ip_address, user_agent, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+	if _, err := f.db.ExecContext(ctx, f.db.Rebind(query), uuid.NewString(), AuditAction, collection,
+		string(data), c.ClientIP(), truncate(c.Request.UserAgent(), 500), time.Now().UTC()); err != nil {
+		f.logger.Errorw("Failed to audit denied request", "error", err)
+	}
+}
+
+// truncate shortens s to at most n bytes.
+func truncate(s string, n int) string {
+	if len(s) > n {
+		return s[:n]
+	}
+	return s
+}
@@ -0,0 +1,80 @@
+package ipfilter
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// geoMap resolves countries from a map of addresses.
+type geoMap map[string]string
+
+func (g geoMap) Country(_ context.Context, addr netip.Addr) (string, error) {
+	country, ok := g[addr.String()]
+	if !ok {
+		return "", errors.New("unknown address")
+	}
+	return country, nil
+}
+
+func TestFilterCheck(t *testing.T) {
+	geo := geoMap{"198.51.100.7": "de", "203.0.113.9": "RU"}
+
+	tests := []struct {
+		name   string
+		rule   Rule
+		ip     string
+		want   bool
+		reason string
+	}{
+		{"no rule", Rule{}, "192.0.2.1", true, ""},
+		{"allowed range", Rule{Allow: []string{"10.0.0.0/8"}}, "10.1.2.3", true, ""},
+		{"outside allowed range", Rule{Allow: []string{"10.0.0.0/8"}}, "192.0.2.1", false, "address not allowed"},
+		{"allowed address", Rule{Allow: []string{"192.0.2.1"}}, "192.0.2.1", true, ""},
+		{"mapped IPv4", Rule{Allow: []string{"192.0.2.0/24"}}, "::ffff:192.0.2.1", true, ""},
+		{"IPv6 range", Rule{Allow: []string{"2001:db8::/32"}}, "2001:db8::1", true, ""},
+		{"deny wins", Rule{Allow: []string{"10.0.0.0/8"}, Deny: []string{"10.0.0.5"}}, "10.0.0.5", false, "address denied"},
+		{"invalid client", Rule{Deny: []string{"10.0.0.5"}}, "unknown", false, "invalid client address"},
+		{"allowed country", Rule{AllowCountries: []string{"DE"}}, "198.51.100.7", true, ""},
+		{"other country", Rule{AllowCountries: []string{"DE"}}, "203.0.113.9", false, "country not allowed"},
+		{"unresolved country allowlist", Rule{AllowCountries: []string{"DE"}}, "192.0.2.1", false, "country not allowed"},
+		{"denied country", Rule{DenyCountries: []string{"ru"}}, "203.0.113.9", false, "country denied"},
+		{"unresolved country denylist", Rule{DenyCountries: []string{"RU"}}, "192.0.2.1", true, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f, err := NewFilter(tt.rule, geo, nil, zap.NewNop().Sugar())
+			if err != nil {
+				t.Fatalf("NewFilter() error = %v", err)
+			}
+			got, reason, _ := f.Check(context.Background(), tt.ip)
+			if got != tt.want || reason != tt.reason {
+				t.Errorf("Check(%q) = %v, %q, want %v, %q", tt.ip, got, reason, tt.want, tt.reason)
+			}
+		})
+	}
+}
+
+func TestNewFilterErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+		geo  GeoResolver
+	}{
+		{"invalid range", Rule{Allow: []string{"10.0.0.0/33"}}, nil},
+		{"invalid address", Rule{Deny: []string{"10.0.0"}}, nil},
+		{"countries without resolver", Rule{DenyCountries: []string{"RU"}}, nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewFilter(tt.rule, tt.geo, nil, zap.NewNop().Sugar()); err == nil {
+				t.Error("NewFilter() error = nil, want an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package jobs
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// descriptors are the shorthands accepted in place of five cron fields.
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// cronField describes the values of one cron field.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string
+}
+
+var cronFields = []cronField{
+	{name: "minute", min: 0, max: 59},
+	{name: "hour", min: 0, max: 23},
+	{name: "day of month", min: 1, max: 31},
+	{name: "month", min: 1, max: 12, names: []string{"", "jan", "feb", "mar", "apr", "may", "jun", "jul", "aug", "sep", "oct", "nov", "dec"}},
+	{name: "day of week", min: 0, max: 7, names: []string{"sun", "mon", "tue", "wed", "thu", "fri", "sat"}},
+}
+
+// Schedule is a parsed cron expression.
+type Schedule struct {
+	spec string
+
+	// bit sets of the matching values of each field
+	minute, hour, dom, month, dow uint64
+
+	// a restricted day of month or week matches either, as in cron
+	domAny, dowAny bool
+}
+
+// ParseSchedule parses a cron expression of five fields: minute, hour,
+// day of month, month and day of week. Fields take *, values, ranges such
+// as 1-5, lists such as 1,15 and steps such as */10; months and days of
+// the week may be named, as in JAN or MON-FRI. Descriptors such as @daily
+// and @hourly are accepted too.
+func ParseSchedule(spec string) (*Schedule, error) {
+	expr := strings.TrimSpace(spec)
+	if fields, ok := descriptors[strings.ToLower(expr)]; ok {
+		expr = fields
+	}
+	parts := strings.Fields(expr)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("invalid schedule %q: want 5 fields, got %d", spec, len(parts))
+	}
+
+	sets := make([]uint64, len(parts))
+	for i, part := range parts {
+		set, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+		sets[i] = set
+	}
+
+	s := &Schedule{
+		spec:   spec,
+		minute: sets[0],
+		hour:   sets[1],
+		dom:    sets[2],
+		month:  sets[3],
+		dow:    sets[4],
+		domAny: parts[2] == "*" || strings.HasPrefix(parts[2], "*/"),
+		dowAny: parts[4] == "*" || strings.HasPrefix(parts[4], "*/"),
+	}
+	// 7 is another name for Sunday
+	if s.dow&(1<<7) != 0 {
+		s.dow |= 1
+	}
+	return s, nil
+}
+
+// parseCronField returns the bit set of the values a field matches.
+func parseCronField(part string, field cronField) (uint64, error) {
+	var set uint64
+	for item := range strings.SplitSeq(part, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			n, err := strconv.Atoi(stepPart)
+			if err != nil || n < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, field.name)
+			}
+			step = n
+		}
+
+		low, high := field.min, field.max
+		switch {
+		case rangePart == "*":
+		case strings.Contains(rangePart, "-"):
+			from, to, _ := strings.Cut(rangePart, "-")
+			var err error
+			if low, err = cronValue(from, field); err != nil {
+				return 0, err
+			}
+			if high, err = cronValue(to, field); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q in %s", rangePart, field.name)
+			}
+		default:
+			value, err := cronValue(rangePart, field)
+			if err != nil {
+				return 0, err
+			}
+			low = value
+			if !hasStep {
+				high = value
+			}
+		}
+
+		for v := low; v <= high; v += step {
+			set |= 1 << v
+		}
+	}
+	return set, nil
+}
+
+// cronValue parses a number or name of a field.
+func cronValue(s string, field cronField) (int, error) {
+	for i, name := range field.names {
+		if name != "" && strings.EqualFold(s, name) {
+			return i, nil
+		}
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil || n < field.min || n > field.max {
+		return 0, fmt.Errorf("invalid %s %q", field.name, s)
+	}
+	return n, nil
+}
+
+// String returns the expression the schedule was parsed from.
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first time after t matching the schedule, in t's
+// location, or the zero time when none falls within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		switch {
+		case s.month&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hour&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minute&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+// matchesDay reports whether the day of t matches the schedule.
+func (s *Schedule) matchesDay(t time.Time) bool {
+	dom := s.dom&(1<<uint(t.Day())) != 0
+	dow := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
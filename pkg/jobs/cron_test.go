@@ -0,0 +1,69 @@
+package jobs
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseSchedule(t *testing.T) {
+	tests := []struct {
+		spec    string
+		wantErr bool
+	}{
+		{"0 3 * * *", false},
+		{"*/15 9-17 * * MON-FRI", false},
+		{"0 0 1,15 jan,jul 7", false},
+		{"@hourly", false},
+		{"0 3 * *", true},
+		{"60 * * * *", true},
+		{"* 24 * * *", true},
+		{"0 0 0 * *", true},
+		{"*/0 * * * *", true},
+		{"5-1 * * * *", true},
+		{"0 0 * foo *", true},
+		{"@sometimes", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			if _, err := ParseSchedule(tt.spec); (err != nil) != tt.wantErr {
+				t.Errorf("ParseSchedule() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestScheduleNext(t *testing.T) {
+	// 2025-01-15 is a Wednesday.
+	from := time.Date(2025, 1, 15, 10, 20, 30, 0, time.UTC)
+
+	tests := []struct {
+		name string
+		spec string
+		want time.Time
+	}{
+		{"daily at 3", "0 3 * * *", time.Date(2025, 1, 16, 3, 0, 0, 0, time.UTC)},
+		{"every 15 minutes", "*/15 * * * *", time.Date(2025, 1, 15, 10, 30, 0, 0, time.UTC)},
+		{"every minute", "* * * * *", time.Date(2025, 1, 15, 10, 21, 0, 0, time.UTC)},
+		{"hourly", "@hourly", time.Date(2025, 1, 15, 11, 0, 0, 0, time.UTC)},
+		{"weekdays", "0 0 * * MON-FRI", time.Date(2025, 1, 16, 0, 0, 0, 0, time.UTC)},
+		{"sunday as 7", "0 0 * * 7", time.Date(2025, 1, 19, 0, 0, 0, 0, time.UTC)},
+		{"day of month or week", "0 0 1,15 * 5", time.Date(2025, 1, 17, 0, 0, 0, 0, time.UTC)},
+		{"day of month and any week", "0 0 1 */1 *", time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"next year", "0 0 1 1 *", time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{"leap day", "0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"never", "0 0 30 2 *", time.Time{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseSchedule(tt.spec)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := schedule.Next(from); !got.Equal(tt.want) {
+				t.Errorf("Next() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
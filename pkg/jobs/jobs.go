@@ -0,0 +1,321 @@
+// Package jobs runs background tasks at fixed intervals or on cron
+// schedules.
+package jobs
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"go.uber.org/zap"
+)
+
+// Job is a task run periodically in the background.
+type Job struct {
+	// Name identifies the job in logs and status reports.
+	Name string
+
+	// Interval is the time between runs. The first run is one interval
+	// after the runner starts.
+	Interval time.Duration
+
+	// Schedule is a cron expression, such as "0 3 * * *" for 3 AM every
+	// day, on which the job runs instead of every Interval. See
+	// ParseSchedule.
+	Schedule string
+
+	// Location is the time zone of Schedule.
+	// Default: time.Local
+	Location *time.Location
+
+	// Run performs the task. Its context is canceled when the runner stops.
+	Run func(ctx context.Context) error
+}
+
+// Status describes a job's last run.
+type Status struct {
+	Name       string     `json:"name"`
+	Interval   string     `json:"interval,omitempty"`
+	Schedule   string     `json:"schedule,omitempty"`
+	Disabled   bool       `json:"disabled"`
+	Running    bool       `json:"running"`
+	LastRun    *time.Time `json:"last_run,omitempty"`
+	NextRun    *time.Time `json:"next_run,omitempty"`
+	DurationMs int64      `json:"duration_ms"`
+	Error      string     `json:"error,omitempty"`
+}
+
+// State is whether a job is disabled.
+type State struct {
+	Name     string `json:"name"`
+	Disabled bool   `json:"disabled"`
+}
+
+// StateFunc is called when a job is disabled or enabled.
+type StateFunc func(state State)
+
+// Lease lets one of several instances run each job.
+type Lease interface {
+	// Acquire takes or renews the named lease for ttl, reporting whether
+	// this instance holds it.
+	Acquire(ctx context.Context, name string, ttl time.Duration) (bool, error)
+}
+
+// job is a registered job with its state.
+type job struct {
+	Job
+	schedule *Schedule
+	status   Status
+}
+
+// Runner runs registered jobs until it is stopped. A job's runs never
+// overlap.
+type Runner struct {
+	logger *zap.SugaredLogger
+	lease  Lease
+
+	mu      sync.Mutex
+	jobs    []*job
+	ctx     context.Context
+	cancel  context.CancelFunc
+	wg      sync.WaitGroup
+	onState StateFunc
+}
+
+// NewRunner creates a job runner.
+func NewRunner(logger *zap.SugaredLogger) *Runner {
+	return &Runner{logger: logger}
+}
+
+// SetLease makes each run wait for the job's lease, so only the instance
+// holding it runs the job. Call it before Start.
+func (r *Runner) SetLease(lease Lease) {
+	r.lease = lease
+}
+
+// OnStateChange sets a function called when a job is disabled or
+// enabled, such as one telling other instances.
+func (r *Runner) OnStateChange(fn StateFunc) {
+	r.onState = fn
+}
+
+// Add registers a job. Jobs added to a started runner start at once.
+func (r *Runner) Add(j Job) error {
+	if j.Name == "" || j.Run == nil || (j.Interval <= 0) == (j.Schedule == "") {
+		return fmt.Errorf("job needs a name, a run function and either a positive interval or a schedule")
+	}
+	added := &job{Job: j, status: Status{Name: j.Name}}
+	if j.Schedule != "" {
+		schedule, err := ParseSchedule(j.Schedule)
+		if err != nil {
+			return err
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("schedule %q never runs", j.Schedule)
+		}
+		if added.Location == nil {
+			added.Location = time.Local
+		}
+		added.schedule = schedule
+		added.status.Schedule = j.Schedule
+	} else {
+		added.status.Interval = j.Interval.String()
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.jobs {
+		if existing.Name == j.Name {
+			return fmt.Errorf("duplicate job: %s", j.Name)
+		}
+	}
+	r.jobs = append(r.jobs, added)
+	if r.cancel != nil {
+		r.wg.Add(1)
+		go r.loop(r.ctx, added)
+	}
+	return nil
+}
+
+// Start runs the registered jobs in the background. It does nothing when
+// the runner is already started.
+func (r *Runner) Start(ctx context.Context) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.cancel != nil {
+		return
+	}
+
+	r.ctx, r.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	for _, j := range r.jobs {
+		r.wg.Add(1)
+		go r.loop(r.ctx, j)
+	}
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (r *Runner) Stop() {
+	r.mu.Lock()
+	cancel := r.cancel
+	r.cancel = nil
+	r.mu.Unlock()
+
+	if cancel != nil {
+		cancel()
+		r.wg.Wait()
+	}
+}
+
+// Jobs reports the registered jobs.
+func (r *Runner) Jobs() []Status {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	statuses := make([]Status, 0, len(r.jobs))
+	for _, j := range r.jobs {
+		statuses = append(statuses, j.status)
+	}
+	return statuses
+}
+
+// Disable stops running a job until it is enabled again.
+func (r *Runner) Disable(name string) (*Status, error) {
+	return r.setDisabled(name, true, true)
+}
+
+// Enable resumes running a job.
+func (r *Runner) Enable(name string) (*Status, error) {
+	return r.setDisabled(name, false, true)
+}
+
+// SetState applies a job's state, such as one changed on another
+// instance, without calling the OnStateChange function.
+func (r *Runner) SetState(state State) error {
+	_, err := r.setDisabled(state.Name, state.Disabled, false)
+	return err
+}
+
+// setDisabled disables or enables a job, calling the OnStateChange
+// function when notify is set.
+func (r *Runner) setDisabled(name string, disabled, notify bool) (*Status, error) {
+	r.mu.Lock()
+	var found *job
+	for _, j := range r.jobs {
+		if j.Name == name {
+			found = j
+		}
+	}
+	if found == nil {
+		r.mu.Unlock()
+		return nil, apperror.ErrNotFound.WithMessagef("Job '%s' not found", name)
+	}
+	found.status.Disabled = disabled
+	status := found.status
+	r.mu.Unlock()
+
+	if notify && r.onState != nil {
+		r.onState(State{Name: name, Disabled: disabled})
+	}
+	return &status, nil
+}
+
+// loop runs a job at its interval or on its schedule until ctx is done.
+func (r *Runner) loop(ctx context.Context, j *job) {
+	defer r.wg.Done()
+	if j.schedule != nil {
+		r.loopSchedule(ctx, j)
+		return
+	}
+
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+	r.setNextRun(j, time.Now().Add(j.Interval))
+	for {
+		select {
+		case <-ticker.C:
+			r.setNextRun(j, time.Now().Add(j.Interval))
+			r.run(ctx, j, j.Interval+j.Interval/2)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// loopSchedule runs a job at the times of its schedule. The lease is held
+// for half the time to the next run, so one instance runs each time even
+// when instances start it moments apart.
+func (r *Runner) loopSchedule(ctx context.Context, j *job) {
+	for {
+		next := j.schedule.Next(time.Now().In(j.Location))
+		if next.IsZero() {
+			return
+		}
+		r.setNextRun(j, next)
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+			ttl := time.Minute
+			if after := j.schedule.Next(next); !after.IsZero() {
+				ttl = max(ttl, after.Sub(next)/2)
+			}
+			r.run(ctx, j, ttl)
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		}
+	}
+}
+
+// setNextRun records when a job runs next.
+func (r *Runner) setNextRun(j *job, next time.Time) {
+	r.mu.Lock()
+	j.status.NextRun = &next
+	r.mu.Unlock()
+}
+
+// run runs a job once and records the outcome, unless it is disabled.
+// With a lease, a run whose lease another instance holds is skipped.
+func (r *Runner) run(ctx context.Context, j *job, ttl time.Duration) {
+	r.mu.Lock()
+	disabled := j.status.Disabled
+	r.mu.Unlock()
+	if disabled {
+		return
+	}
+
+	if r.lease != nil {
+		held, err := r.lease.Acquire(ctx, "job:"+j.Name, ttl)
+		if err != nil {
+			if ctx.Err() == nil {
+				r.logger.Warnw("Failed to acquire job lease", "job", j.Name, "error", err)
+			}
+			return
+		}
+		if !held {
+			return
+		}
+	}
+
+	start := time.Now()
+	r.mu.Lock()
+	j.status.Running = true
+	r.mu.Unlock()
+
+	err := j.Run(ctx)
+
+	r.mu.Lock()
+	j.status.Running = false
+	j.status.LastRun = &start
+	j.status.DurationMs = time.Since(start).Milliseconds()
+	j.status.Error = ""
+	if err != nil {
+		j.status.Error = err.Error()
+	}
+	r.mu.Unlock()
+
+	if err != nil && ctx.Err() == nil {
+		r.logger.Errorw("Job failed", "job", j.Name, "error", err)
+	}
+}
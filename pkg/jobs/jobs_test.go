@@ -0,0 +1,147 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+func TestRunnerAdd(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+
+	tests := []struct {
+		name    string
+		job     Job
+		wantErr bool
+	}{
+		{"valid", Job{Name: "a", Interval: time.Minute, Run: noop}, false},
+		{"duplicate", Job{Name: "a", Interval: time.Minute, Run: noop}, true},
+		{"no name", Job{Interval: time.Minute, Run: noop}, true},
+		{"no interval", Job{Name: "b", Run: noop}, true},
+		{"no run", Job{Name: "c", Interval: time.Minute}, true},
+		{"schedule", Job{Name: "d", Schedule: "0 3 * * *", Run: noop}, false},
+		{"interval and schedule", Job{Name: "e", Interval: time.Minute, Schedule: "@daily", Run: noop}, true},
+		{"invalid schedule", Job{Name: "f", Schedule: "0 3 * *", Run: noop}, true},
+		{"schedule never runs", Job{Name: "g", Schedule: "0 0 30 2 *", Run: noop}, true},
+	}
+
+	r := NewRunner(zap.NewNop().Sugar())
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := r.Add(tt.job); (err != nil) != tt.wantErr {
+				t.Errorf("Add() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunnerRuns(t *testing.T) {
+	var runs atomic.Int32
+	r := NewRunner(zap.NewNop().Sugar())
+	err := r.Add(Job{Name: "tick", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+		runs.Add(1)
+		return errors.New("boom")
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+
+	if runs.Load() < 2 {
+		t.Fatalf("job ran %d times, want at least 2", runs.Load())
+	}
+	status := r.Jobs()[0]
+	if status.LastRun == nil || status.Error != "boom" {
+		t.Errorf("status = %+v, want the last run's error", status)
+	}
+}
+
+// leaseFunc grants the leases for which it returns true.
+type leaseFunc func(name string) bool
+
+func (f leaseFunc) Acquire(_ context.Context, name string, _ time.Duration) (bool, error) {
+	return f(name), nil
+}
+
+func TestRunnerLease(t *testing.T) {
+	var held, other atomic.Int32
+	r := NewRunner(zap.NewNop().Sugar())
+	r.SetLease(leaseFunc(func(name string) bool { return name == "job:held" }))
+	for name, runs := range map[string]*atomic.Int32{"held": &held, "other": &other} {
+		err := r.Add(Job{Name: name, Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+			runs.Add(1)
+			return nil
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r.Start(context.Background())
+	deadline := time.Now().Add(time.Second)
+	for held.Load() < 2 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+
+	if held.Load() < 2 {
+		t.Errorf("leased job ran %d times, want at least 2", held.Load())
+	}
+	if other.Load() != 0 {
+		t.Errorf("job leased elsewhere ran %d times, want 0", other.Load())
+	}
+}
+
+func TestRunnerDisable(t *testing.T) {
+	var runs atomic.Int32
+	var states []State
+	r := NewRunner(zap.NewNop().Sugar())
+	r.OnStateChange(func(state State) { states = append(states, state) })
+	err := r.Add(Job{Name: "tick", Interval: 5 * time.Millisecond, Run: func(context.Context) error {
+		runs.Add(1)
+		return nil
+	}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status, err := r.Disable("tick")
+	if err != nil || !status.Disabled {
+		t.Fatalf("Disable() = %+v, %v, want a disabled job", status, err)
+	}
+	if _, err := r.Disable("missing"); err == nil {
+		t.Error("Disable() of an unknown job succeeded")
+	}
+
+	r.Start(context.Background())
+	time.Sleep(30 * time.Millisecond)
+	if runs.Load() != 0 {
+		t.Errorf("disabled job ran %d times", runs.Load())
+	}
+
+	if err := r.SetState(State{Name: "tick"}); err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(time.Second)
+	for runs.Load() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	r.Stop()
+
+	if runs.Load() == 0 {
+		t.Error("enabled job did not run")
+	}
+	if len(states) != 1 || !states[0].Disabled {
+		t.Errorf("state changes = %+v, want only the disable", states)
+	}
+}
@@ -0,0 +1,398 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// DefaultMaxBodyBytes caps the size of a JSON-RPC message.
+const DefaultMaxBodyBytes = 1 << 20
+
+// JSON-RPC error codes.
+const (
+	codeParseError     = -32700
+	codeInvalidRequest = -32600
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+	codeInternalError  = -32603
+)
+
+// supportedVersions lists the protocol revisions the server accepts from
+// clients, newest first.
+var supportedVersions = []string{ProtocolVersion, "2025-03-26", "2024-11-05"}
+
+// rpcRequest is a JSON-RPC request or notification.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params"`
+}
+
+// rpcResponse is a JSON-RPC response.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is a JSON-RPC protocol error.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// callResult is the result of a tools/call request. Failed calls are
+// results with IsError set, so agents can read the error and retry.
+type callResult struct {
+	Content           []content `json:"content"`
+	StructuredContent any       `json:"structuredContent,omitempty"`
+	IsError           bool      `json:"isError,omitempty"`
+}
+
+// content is a block of tool output.
+type content struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// Handler serves MCP over the Streamable HTTP transport, answering each
+// JSON-RPC message with a single JSON response.
+type Handler struct {
+	config        Config
+	service       *collection.Service
+	schemaManager *schema.Manager
+	limiter       *limiter
+	logger        *zap.SugaredLogger
+
+	// mu guards the rate limits in config, which SetLimits replaces
+	mu sync.RWMutex
+}
+
+// NewHandler creates a new MCP handler.
+func NewHandler(config Config, service *collection.Service, schemaManager *schema.Manager, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		config:        config,
+		service:       service,
+		schemaManager: schemaManager,
+		limiter:       newLimiter(),
+		logger:        logger,
+	}
+}
+
+// SetLimits replaces the rate limits, such as on a config reload. Calls
+// already counted stay in their windows.
+func (h *Handler) SetLimits(rate Limit, tools map[string]Limit) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.config.RateLimit = rate
+	h.config.ToolLimits = tools
+}
+
+// toolLimit returns the rate limit of a tool.
+func (h *Handler) toolLimit(name string) Limit {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	if limit, ok := h.config.ToolLimits[name]; ok {
+		return limit
+	}
+	return h.config.RateLimit
+}
+
+// RegisterRoutes registers MCP routes on a Gin router group.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.POST("", h.Serve)
+	rg.GET("/tools", h.Manifest)
+}
+
+// Manifest handles GET /mcp/tools requests, listing the tools the caller
+// may use with their input schemas.
+func (h *Handler) Manifest(c *gin.Context) {
+	tools, err := h.tools(c.Request.Context())
+	if err != nil {
+		requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to list tools", "error", err)
+		response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+		return
+	}
+	response.JSON(c, http.StatusOK, response.Success(map[string]any{"tools": tools}))
+}
+
+// Serve handles POST /mcp requests, each holding one JSON-RPC message.
+// Notifications are acknowledged with 202 and no body.
+func (h *Handler) Serve(c *gin.Context) {
+	body, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, DefaultMaxBodyBytes))
+	if err != nil {
+		h.reply(c, nil, nil, &rpcError{Code: codeInvalidRequest, Message: "Failed to read request body"})
+		return
+	}
+
+	var req rpcRequest
+	if err := json.Unmarshal(body, &req); err != nil {
+		h.reply(c, nil, nil, &rpcError{Code: codeParseError, Message: "Parse error"})
+		return
+	}
+	if req.JSONRPC != "2.0" || req.Method == "" {
+		h.reply(c, req.ID, nil, &rpcError{Code: codeInvalidRequest, Message: "Invalid request"})
+		return
+	}
+	if len(req.ID) == 0 {
+		c.Status(http.StatusAccepted)
+		return
+	}
+
+	result, rpcErr := h.dispatch(c, req)
+	h.reply(c, req.ID, result, rpcErr)
+}
+
+// dispatch runs a JSON-RPC method.
+func (h *Handler) dispatch(c *gin.Context, req rpcRequest) (any, *rpcError) {
+	ctx := c.Request.Context()
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		_ = json.Unmarshal(req.Params, &params)
+		version := ProtocolVersion
+		if slices.Contains(supportedVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{"listChanged": false}},
+			"serverInfo":      map[string]any{"name": "tugo", "title": "TuGo collections"},
+		}, nil
+	case "ping":
+		return map[string]any{}, nil
+	case "tools/list":
+		tools, err := h.tools(ctx)
+		if err != nil {
+			requestlog.Logger(ctx, h.logger).Errorw("Failed to list tools", "error", err)
+			return nil, &rpcError{Code: codeInternalError, Message: "Failed to list tools"}
+		}
+		return map[string]any{"tools": tools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil || params.Name == "" {
+			return nil, &rpcError{Code: codeInvalidParams, Message: "Invalid params"}
+		}
+		return h.call(c, params.Name, params.Arguments)
+	}
+	return nil, &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("Method not found: %s", req.Method)}
+}
+
+// tools returns the tools the caller may use.
+func (h *Handler) tools(ctx context.Context) ([]Tool, error) {
+	tools := buildTools(h.config, h.schemaManager.GetCollections())
+	if h.config.Permissions == nil {
+		return tools, nil
+	}
+
+	user, _ := auth.GetUserFromContext(ctx)
+	allowed := tools[:0]
+	for _, tool := range tools {
+		result, err := h.config.Permissions.Check(ctx, user, tool.collection, tool.action())
+		if err != nil {
+			return nil, err
+		}
+		if result.Allowed {
+			allowed = append(allowed, tool)
+		}
+	}
+	return allowed, nil
+}
+
+// call runs a tool after checking the caller's permission and rate limit.
+func (h *Handler) call(c *gin.Context, name string, arguments json.RawMessage) (any, *rpcError) {
+	ctx := c.Request.Context()
+	var tool *Tool
+	for _, t := range buildTools(h.config, h.schemaManager.GetCollections()) {
+		if t.Name == name {
+			tool = &t
+			break
+		}
+	}
+	if tool == nil {
+		return nil, &rpcError{Code: codeInvalidParams, Message: fmt.Sprintf("Unknown tool: %s", name)}
+	}
+
+	user, _ := auth.GetUserFromContext(ctx)
+	if h.config.Permissions != nil {
+		result, err := h.config.Permissions.Check(ctx, user, tool.collection, tool.action())
+		if err != nil {
+			requestlog.Logger(ctx, h.logger).Errorw("Permission check failed", "tool", name, "error", err)
+			return nil, &rpcError{Code: codeInternalError, Message: "Permission check failed"}
+		}
+		if !result.Allowed {
+			return errorResult(apperror.ErrForbidden.WithMessage(result.Reason)), nil
+		}
+	}
+
+	caller := "ip:" + c.ClientIP()
+	if user != nil {
+		caller = "user:" + user.ID
+	}
+	limit := h.toolLimit(name)
+	if ok, retry := h.limiter.allow(caller+"|"+name, limit); !ok {
+		seconds := int(retry.Round(time.Second) / time.Second)
+		return errorResult(&apperror.AppError{
+			Code:    "RATE_LIMITED",
+			Message: fmt.Sprintf("Rate limit of %d calls per %s exceeded for %s; retry in %ds", limit.Calls, limit.Per, name, max(seconds, 1)),
+			Details: map[string]any{"retry_after": max(seconds, 1)},
+		}), nil
+	}
+
+	var args map[string]any
+	if len(arguments) > 0 && string(arguments) != "null" {
+		decoder := json.NewDecoder(bytes.NewReader(arguments))
+		decoder.UseNumber()
+		if err := decoder.Decode(&args); err != nil {
+			return errorResult(apperror.ErrBadRequest.WithMessage("Arguments must be an object")), nil
+		}
+	}
+
+	output, err := h.run(ctx, tool, args)
+	if err != nil {
+		appErr, ok := apperror.AsAppError(err)
+		if !ok {
+			requestlog.Logger(ctx, h.logger).Errorw("Unexpected error", "tool", name, "error", err)
+			appErr = apperror.ErrInternalServer
+		}
+		return errorResult(appErr), nil
+	}
+	return successResult(output)
+}
+
+// run executes a tool with its arguments.
+func (h *Handler) run(ctx context.Context, tool *Tool, args map[string]any) (any, error) {
+	switch tool.kind {
+	case KindList:
+		params, err := listParams(args)
+		if err != nil {
+			return nil, err
+		}
+		list, err := h.service.List(ctx, collection.ListParams{CollectionName: tool.collection, QueryParams: params})
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"items": list.Items, "pagination": list.Pagination}, nil
+	case KindGet:
+		id, ok := args["id"]
+		if !ok || id == nil {
+			return nil, apperror.ErrBadRequest.WithMessage("Argument id is required")
+		}
+		item, err := h.service.Get(ctx, tool.collection, fmt.Sprint(id), nil)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"item": item}, nil
+	default:
+		if args == nil {
+			args = map[string]any{}
+		}
+		item, err := h.service.Create(ctx, tool.collection, args)
+		if err != nil {
+			return nil, err
+		}
+		return map[string]any{"item": item}, nil
+	}
+}
+
+// listParams converts list tool arguments to REST list query parameters.
+func listParams(args map[string]any) (map[string][]string, error) {
+	params := map[string][]string{}
+	for key, value := range args {
+		switch key {
+		case "filter":
+			filters, ok := value.(map[string]any)
+			if !ok {
+				return nil, apperror.ErrBadRequest.WithMessage("Argument filter must be an object")
+			}
+			for field, v := range filters {
+				params["filter["+field+"]"] = []string{queryValue(v)}
+			}
+		case "sort", "limit", "page", "fields":
+			params[key] = []string{queryValue(value)}
+		default:
+			return nil, apperror.ErrBadRequest.WithMessagef("Unknown argument '%s'", key)
+		}
+	}
+	return params, nil
+}
+
+// queryValue formats an argument as a query parameter value, joining
+// lists with commas.
+func queryValue(v any) string {
+	switch v := v.(type) {
+	case []any:
+		parts := make([]string, 0, len(v))
+		for _, item := range v {
+			parts = append(parts, queryValue(item))
+		}
+		return strings.Join(parts, ",")
+	case json.Number:
+		return v.String()
+	case bool:
+		return strconv.FormatBool(v)
+	case nil:
+		return ""
+	}
+	return fmt.Sprint(v)
+}
+
+// successResult returns a tool result holding output as text and as
+// structured content.
+func successResult(output any) (any, *rpcError) {
+	data, err := json.Marshal(output)
+	if err != nil {
+		return nil, &rpcError{Code: codeInternalError, Message: "Failed to encode result"}
+	}
+	return callResult{
+		Content:           []content{{Type: "text", Text: string(data)}},
+		StructuredContent: json.RawMessage(data),
+	}, nil
+}
+
+// errorResult returns a failed tool result describing err.
+func errorResult(err *apperror.AppError) callResult {
+	body := response.ErrorBody{Code: err.Code, Message: err.Message, Details: err.Details}
+	return callResult{
+		Content:           []content{{Type: "text", Text: err.Message}},
+		StructuredContent: map[string]any{"error": body},
+		IsError:           true,
+	}
+}
+
+// reply writes a JSON-RPC response.
+func (h *Handler) reply(c *gin.Context, id json.RawMessage, result any, rpcErr *rpcError) {
+	if len(id) == 0 {
+		id = json.RawMessage("null")
+	}
+	res := rpcResponse{JSONRPC: "2.0", ID: id, Result: result, Error: rpcErr}
+	if rpcErr != nil {
+		res.Result = nil
+	}
+	c.JSON(http.StatusOK, res)
+}
@@ -0,0 +1,60 @@
+package mcp
+
+import (
+	"sync"
+	"time"
+)
+
+// limiter counts calls per key in fixed windows.
+type limiter struct {
+	mu      sync.Mutex
+	windows map[string]*window
+	now     func() time.Time
+}
+
+// window is the call count of a key since start.
+type window struct {
+	start time.Time
+	per   time.Duration
+	calls int
+}
+
+// newLimiter creates an empty limiter.
+func newLimiter() *limiter {
+	return &limiter{windows: make(map[string]*window), now: time.Now}
+}
+
+// allow records a call for key under limit, returning false and the time
+// until the window resets when the limit is reached.
+func (l *limiter) allow(key string, limit Limit) (bool, time.Duration) {
+	if limit.Calls <= 0 || limit.Per <= 0 {
+		return true, 0
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	w, ok := l.windows[key]
+	if !ok || now.Sub(w.start) >= limit.Per {
+		if !ok && len(l.windows) >= 10000 {
+			l.prune(now)
+		}
+		w = &window{start: now, per: limit.Per}
+		l.windows[key] = w
+	}
+	if w.calls >= limit.Calls {
+		return false, w.start.Add(limit.Per).Sub(now)
+	}
+	w.calls++
+	return true, 0
+}
+
+// prune drops expired windows.
+func (l *limiter) prune(now time.Time) {
+	for key, w := range l.windows {
+		if now.Sub(w.start) >= w.per {
+			delete(l.windows, key)
+		}
+	}
+}
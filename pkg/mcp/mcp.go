@@ -0,0 +1,268 @@
+// Package mcp exposes collections as tools for LLM agents over the Model
+// Context Protocol. Each collection gets list, get and create tools whose
+// input schemas are built from its fields; calls run through the collection
+// service with the caller's permissions and per-tool rate limits.
+package mcp
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// ProtocolVersion is the MCP revision the server implements.
+const ProtocolVersion = "2025-06-18"
+
+// Tool kinds, the prefixes of tool names such as list_posts.
+const (
+	KindList   = "list"
+	KindGet    = "get"
+	KindCreate = "create"
+)
+
+// Config configures the MCP endpoint.
+type Config struct {
+	// Enabled serves MCP at POST /mcp and the tool manifest at GET /mcp/tools.
+	Enabled bool
+
+	// Collections limits the tools to these collections, by API name.
+	// Empty means every exposed collection.
+	Collections []string
+
+	// Kinds limits the tools offered per collection to "list", "get" and
+	// "create". Empty means all three.
+	Kinds []string
+
+	// Permissions checks tool calls against the collection policies and
+	// hides the tools a caller may not use. Nil skips the checks.
+	Permissions *permission.Checker
+
+	// RateLimit caps the calls each caller makes to each tool. Callers are
+	// identified by user ID, or by client IP when unauthenticated.
+	// Default: no limit
+	RateLimit Limit
+
+	// ToolLimits overrides RateLimit for tools by name, such as
+	// {"create_orders": {Calls: 5, Per: time.Minute}}.
+	ToolLimits map[string]Limit
+}
+
+// Limit allows Calls calls per window of Per. A zero Calls means no limit.
+type Limit struct {
+	Calls int
+	Per   time.Duration
+}
+
+// Tool describes a tool in tools/list responses and the manifest.
+type Tool struct {
+	Name        string          `json:"name"`
+	Title       string          `json:"title,omitempty"`
+	Description string          `json:"description"`
+	InputSchema map[string]any  `json:"inputSchema"`
+	Annotations ToolAnnotations `json:"annotations"`
+
+	collection string
+	kind       string
+}
+
+// ToolAnnotations hint at how a tool behaves.
+type ToolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	IdempotentHint  bool `json:"idempotentHint"`
+	OpenWorldHint   bool `json:"openWorldHint"`
+}
+
+// action returns the permission a tool call needs.
+func (t *Tool) action() permission.Action {
+	if t.kind == KindCreate {
+		return permission.ActionCreate
+	}
+	return permission.ActionRead
+}
+
+// buildTools returns the tools of the configured collections, sorted by name.
+func buildTools(config Config, collections []*schema.Collection) []Tool {
+	kinds := config.Kinds
+	if len(kinds) == 0 {
+		kinds = []string{KindList, KindGet, KindCreate}
+	}
+
+	var tools []Tool
+	for _, col := range collections {
+		if len(config.Collections) > 0 && !slices.Contains(config.Collections, col.Name) {
+			continue
+		}
+		for _, kind := range kinds {
+			switch kind {
+			case KindList:
+				tools = append(tools, Tool{
+					Name:        KindList + "_" + col.Name,
+					Title:       "List " + col.Name,
+					Description: fmt.Sprintf("List records of the %s collection, filtered, sorted and paginated.", col.Name),
+					InputSchema: listSchema(col),
+					Annotations: ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+				})
+			case KindGet:
+				tools = append(tools, Tool{
+					Name:        KindGet + "_" + col.Name,
+					Title:       "Get " + col.Name,
+					Description: fmt.Sprintf("Get one record of the %s collection by its %s.", col.Name, col.PrimaryKey),
+					InputSchema: getSchema(col),
+					Annotations: ToolAnnotations{ReadOnlyHint: true, IdempotentHint: true},
+				})
+			case KindCreate:
+				tools = append(tools, Tool{
+					Name:        KindCreate + "_" + col.Name,
+					Title:       "Create " + col.Name,
+					Description: fmt.Sprintf("Create a record in the %s collection and return it.", col.Name),
+					InputSchema: createSchema(col),
+				})
+			default:
+				continue
+			}
+			tools[len(tools)-1].collection = col.Name
+			tools[len(tools)-1].kind = kind
+		}
+	}
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+	return tools
+}
+
+// listSchema describes the arguments of a list tool.
+func listSchema(col *schema.Collection) map[string]any {
+	names := fieldNames(col)
+	operators := "eq, ne, gt, gte, lt, lte, like, in, null or notnull"
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"filter": map[string]any{
+				"type": "object",
+				"description": "Field filters, such as {\"status\": \"open\"}. Append :op to a field for other comparisons, " +
+					"such as {\"price:gte\": 10}, with op one of " + operators + ". Fields: " + strings.Join(names, ", ") + ".",
+				"additionalProperties": map[string]any{"type": []string{"string", "number", "boolean"}},
+			},
+			"sort": map[string]any{
+				"type":        "string",
+				"description": "Comma-separated fields to sort by, prefixed with - for descending order, such as \"-created_at\".",
+			},
+			"fields": map[string]any{
+				"type":        "array",
+				"description": "Fields to return. Empty returns every field.",
+				"items":       map[string]any{"type": "string", "enum": names},
+			},
+			"limit": map[string]any{"type": "integer", "minimum": 1, "description": "Records per page."},
+			"page":  map[string]any{"type": "integer", "minimum": 1, "description": "Page number, starting at 1."},
+		},
+		"additionalProperties": false,
+	}
+}
+
+// getSchema describes the arguments of a get tool.
+func getSchema(col *schema.Collection) map[string]any {
+	return map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"id": map[string]any{
+				"type":        []string{"string", "integer"},
+				"description": fmt.Sprintf("The %s of the record.", col.PrimaryKey),
+			},
+		},
+		"required":             []string{"id"},
+		"additionalProperties": false,
+	}
+}
+
+// createSchema describes the record a create tool takes. Generated primary
+// keys and auto-filled fields are left out; other fields that are not
+// nullable are required, as validation requires them.
+func createSchema(col *schema.Collection) map[string]any {
+	auto := col.AutoFields
+	properties := map[string]any{}
+	required := []string{}
+	for i := range col.Fields {
+		field := &col.Fields[i]
+		if field.IsPrimaryKey && field.DefaultValue != nil {
+			continue
+		}
+		switch field.Name {
+		case auto.CreatedAt, auto.CreatedBy, auto.UpdatedAt, auto.UpdatedBy:
+			continue
+		}
+		properties[field.Name] = fieldSchema(field)
+		if _, slug := col.Slugs[field.Name]; !field.IsNullable && !field.IsPrimaryKey && !slug {
+			required = append(required, field.Name)
+		}
+	}
+	return map[string]any{
+		"type":                 "object",
+		"properties":           properties,
+		"required":             required,
+		"additionalProperties": false,
+	}
+}
+
+// fieldSchema describes the values a field takes.
+func fieldSchema(field *schema.Field) map[string]any {
+	s := map[string]any{}
+	var types []string
+	switch field.DataType {
+	case "int":
+		types = []string{"integer"}
+	case "float":
+		types = []string{"number"}
+	case "decimal":
+		types = []string{"string", "number"}
+		s["description"] = "Decimal number, as a string to keep its exact digits."
+	case "boolean":
+		types = []string{"boolean"}
+	case "json":
+		// Any JSON value
+	case "uuid":
+		types = []string{"string"}
+		s["format"] = "uuid"
+	case "timestamp":
+		types = []string{"string"}
+		s["format"] = "date-time"
+	case "date":
+		types = []string{"string"}
+		s["format"] = "date"
+	case "time":
+		types = []string{"string"}
+		s["format"] = "time"
+	default:
+		types = []string{"string"}
+		if field.MaxLength != nil && *field.MaxLength > 0 {
+			s["maxLength"] = *field.MaxLength
+		}
+	}
+
+	if fk := field.ForeignKey; fk != nil {
+		s["description"] = fmt.Sprintf("References %s.%s.", fk.Table, fk.Column)
+	}
+	if len(types) > 0 {
+		if field.IsNullable {
+			types = append(types, "null")
+		}
+		if len(types) == 1 {
+			s["type"] = types[0]
+		} else {
+			s["type"] = types
+		}
+	}
+	return s
+}
+
+// fieldNames returns the names of a collection's fields.
+func fieldNames(col *schema.Collection) []string {
+	names := make([]string, 0, len(col.Fields))
+	for _, field := range col.Fields {
+		names = append(names, field.Name)
+	}
+	return names
+}
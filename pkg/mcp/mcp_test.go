@@ -0,0 +1,111 @@
+package mcp
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+func TestFieldSchema(t *testing.T) {
+	maxLength := 80
+	tests := []struct {
+		name  string
+		field schema.Field
+		want  map[string]any
+	}{
+		{"integer", schema.Field{DataType: "int"}, map[string]any{"type": "integer"}},
+		{"nullable float", schema.Field{DataType: "float", IsNullable: true}, map[string]any{"type": []string{"number", "null"}}},
+		{"string with max length", schema.Field{DataType: "string", MaxLength: &maxLength}, map[string]any{"type": "string", "maxLength": 80}},
+		{"timestamp", schema.Field{DataType: "timestamp"}, map[string]any{"type": "string", "format": "date-time"}},
+		{"json", schema.Field{DataType: "json", IsNullable: true}, map[string]any{}},
+		{
+			"foreign key",
+			schema.Field{DataType: "uuid", ForeignKey: &schema.ForeignKeyInfo{Table: "api_users", Column: "id"}},
+			map[string]any{"type": "string", "format": "uuid", "description": "References api_users.id."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := fieldSchema(&tt.field); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("fieldSchema() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildTools(t *testing.T) {
+	def := "now()"
+	posts := &schema.Collection{
+		Name:       "posts",
+		PrimaryKey: "id",
+		AutoFields: schema.AutoFields{CreatedAt: "created_at"},
+		Slugs:      map[string]string{"slug": "title"},
+		Fields: []schema.Field{
+			{Name: "id", DataType: "int", IsPrimaryKey: true, DefaultValue: &def},
+			{Name: "title", DataType: "string"},
+			{Name: "slug", DataType: "string"},
+			{Name: "body", DataType: "string", IsNullable: true},
+			{Name: "status", DataType: "string", DefaultValue: &def},
+			{Name: "created_at", DataType: "timestamp"},
+		},
+	}
+	tags := &schema.Collection{Name: "tags", PrimaryKey: "id"}
+
+	tools := buildTools(Config{Collections: []string{"posts"}, Kinds: []string{KindList, KindCreate}}, []*schema.Collection{tags, posts})
+
+	var names []string
+	for _, tool := range tools {
+		names = append(names, tool.Name)
+	}
+	if want := []string{"create_posts", "list_posts"}; !reflect.DeepEqual(names, want) {
+		t.Fatalf("tool names = %v, want %v", names, want)
+	}
+
+	create := tools[0].InputSchema
+	if got, want := create["required"], []string{"title", "status"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("create required = %v, want %v", got, want)
+	}
+	properties := create["properties"].(map[string]any)
+	for _, name := range []string{"id", "created_at"} {
+		if _, ok := properties[name]; ok {
+			t.Errorf("create schema has generated field %s", name)
+		}
+	}
+	if tools[1].action() != "read" || tools[0].action() != "create" {
+		t.Errorf("actions = %s, %s", tools[1].action(), tools[0].action())
+	}
+}
+
+func TestLimiter(t *testing.T) {
+	now := time.Unix(0, 0)
+	l := newLimiter()
+	l.now = func() time.Time { return now }
+	limit := Limit{Calls: 2, Per: time.Minute}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		key     string
+		want    bool
+	}{
+		{"first call", 0, "a", true},
+		{"second call", 10 * time.Second, "a", true},
+		{"over limit", 10 * time.Second, "a", false},
+		{"other key", 0, "b", true},
+		{"next window", 40 * time.Second, "a", true},
+	}
+
+	for _, step := range steps {
+		now = now.Add(step.advance)
+		if got, _ := l.allow(step.key, limit); got != step.want {
+			t.Errorf("%s: allow() = %v, want %v", step.name, got, step.want)
+		}
+	}
+
+	if ok, _ := l.allow("a", Limit{}); !ok {
+		t.Error("allow() with no limit = false, want true")
+	}
+}
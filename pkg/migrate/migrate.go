@@ -3,6 +3,7 @@ package migrate
 import (
 	"context"
 	"crypto/sha256"
+	"database/sql"
 	"embed"
 	"encoding/hex"
 	"fmt"
@@ -11,12 +12,25 @@ import (
 	"time"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
 	"go.uber.org/zap"
 )
 
-//go:embed sql/*.sql
+//go:embed sql/*.sql sql/mysql/*.sql sql/sqlite/*.sql
 var sqlFiles embed.FS
 
+// DefaultLockTimeout bounds the wait for another instance's migrations.
+const DefaultLockTimeout = time.Minute
+
+// Lock names: the PostgreSQL advisory lock key spells "tugo".
+const (
+	advisoryLockKey int64 = 0x7475676f
+	mysqlLockName         = "tugo_migrations"
+)
+
+// lockRetryInterval is the pause between PostgreSQL lock attempts.
+const lockRetryInterval = 250 * time.Millisecond
+
 // Migration represents a single migration.
 type Migration struct {
 	Version     string
@@ -43,17 +57,43 @@ type Migrator struct {
 	db        *sqlx.DB
 	logger    *zap.SugaredLogger
 	tableName string
+	dialect   dialect.Dialect
+	fake      bool
+
+	lockTimeout     time.Duration
+	strictChecksums bool
 }
 
 // NewMigrator creates a new migrator.
+// The SQL dialect is derived from the connection's driver name.
 func NewMigrator(db *sqlx.DB, logger *zap.SugaredLogger) *Migrator {
 	return &Migrator{
 		db:        db,
 		logger:    logger,
 		tableName: "tugo_migrations",
+		dialect:   dialect.ForDriver(db.DriverName()),
 	}
 }
 
+// SetFake makes the migrator record migrations and rollbacks in the
+// migration table without running their SQL, for schemas that were
+// changed by hand.
+func (m *Migrator) SetFake(fake bool) {
+	m.fake = fake
+}
+
+// SetLockTimeout sets how long the migrator waits for another instance
+// holding the migration lock. Zero uses DefaultLockTimeout.
+func (m *Migrator) SetLockTimeout(timeout time.Duration) {
+	m.lockTimeout = timeout
+}
+
+// SetStrictChecksums makes an applied migration whose file changed fail
+// MigrateUp and MigrateTo instead of logging a warning.
+func (m *Migrator) SetStrictChecksums(strict bool) {
+	m.strictChecksums = strict
+}
+
 // EnsureMigrationTable creates the migration tracking table if it doesn't exist.
 func (m *Migrator) EnsureMigrationTable(ctx context.Context) error {
 	query := fmt.Sprintf(`
@@ -67,6 +107,31 @@ func (m *Migrator) EnsureMigrationTable(ctx context.Context) error {
 		)
 	`, m.tableName)
 
+	switch m.dialect.Name() {
+	case dialect.MySQL:
+		query = fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id BIGINT AUTO_INCREMENT PRIMARY KEY,
+				version VARCHAR(50) NOT NULL UNIQUE,
+				name VARCHAR(255) NOT NULL,
+				checksum VARCHAR(64) NOT NULL,
+				applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				execution_ms BIGINT DEFAULT 0
+			)
+		`, m.tableName)
+	case dialect.SQLite:
+		query = fmt.Sprintf(`
+			CREATE TABLE IF NOT EXISTS %s (
+				id INTEGER PRIMARY KEY AUTOINCREMENT,
+				version VARCHAR(50) NOT NULL UNIQUE,
+				name VARCHAR(255) NOT NULL,
+				checksum VARCHAR(64) NOT NULL,
+				applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+				execution_ms BIGINT DEFAULT 0
+			)
+		`, m.tableName)
+	}
+
 	_, err := m.db.ExecContext(ctx, query)
 	return err
 }
@@ -87,9 +152,22 @@ func (m *Migrator) GetAppliedMigrations(ctx context.Context) (map[string]Migrati
 	return result, nil
 }
 
+// migrationsDir returns the embedded migrations directory for the dialect.
+func (m *Migrator) migrationsDir() string {
+	switch m.dialect.Name() {
+	case dialect.MySQL:
+		return "sql/mysql"
+	case dialect.SQLite:
+		return "sql/sqlite"
+	default:
+		return "sql"
+	}
+}
+
 // LoadMigrations loads all migration files.
 func (m *Migrator) LoadMigrations() ([]Migration, error) {
-	entries, err := sqlFiles.ReadDir("sql")
+	dir := m.migrationsDir()
+	entries, err := sqlFiles.ReadDir(dir)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read migrations directory: %w", err)
 	}
@@ -106,7 +184,7 @@ func (m *Migrator) LoadMigrations() ([]Migration, error) {
 			continue
 		}
 
-		content, err := sqlFiles.ReadFile("sql/" + name)
+		content, err := sqlFiles.ReadFile(dir + "/" + name)
 		if err != nil {
 			return nil, fmt.Errorf("failed to read migration file %s: %w", name, err)
 		}
@@ -141,7 +219,7 @@ func (m *Migrator) LoadMigrations() ([]Migration, error) {
 
 		if direction == "up" {
 			migrations[version].UpSQL = string(content)
-			migrations[version].Checksum = checksumSQL(string(content))
+			migrations[version].Checksum = Checksum(string(content))
 		} else {
 			migrations[version].DownSQL = string(content)
 		}
@@ -161,24 +239,253 @@ func (m *Migrator) LoadMigrations() ([]Migration, error) {
 
 // MigrateUp runs all pending migrations.
 func (m *Migrator) MigrateUp(ctx context.Context) error {
-	if err := m.EnsureMigrationTable(ctx); err != nil {
-		return fmt.Errorf("failed to ensure migration table: %w", err)
+	return m.withLock(ctx, func() error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+		if len(migrations) == 0 {
+			return nil
+		}
+		return m.migrateUp(ctx, migrations, applied, migrations[len(migrations)-1].Version)
+	})
+}
+
+// MigrateDown rolls back the last migration.
+func (m *Migrator) MigrateDown(ctx context.Context) error {
+	return m.withLock(ctx, func() error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+
+		// Find the last applied migration
+		var lastVersion string
+		for version := range applied {
+			if version > lastVersion {
+				lastVersion = version
+			}
+		}
+		if lastVersion == "" {
+			return nil
+		}
+
+		var target *Migration
+		for i := range migrations {
+			if migrations[i].Version == lastVersion {
+				target = &migrations[i]
+				break
+			}
+		}
+		if target == nil {
+			return fmt.Errorf("no down migration found for version %s", lastVersion)
+		}
+		return m.rollback(ctx, []Migration{*target})
+	})
+}
+
+// MigrateDownTo rolls back every applied migration newer than version, the
+// newest first, in one transaction. An empty version rolls back all of
+// them. Nothing is rolled back when one of them has no down migration.
+func (m *Migrator) MigrateDownTo(ctx context.Context, version string) error {
+	return m.withLock(ctx, func() error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(migrations, version); err != nil {
+			return err
+		}
+		return m.rollback(ctx, newerApplied(migrations, applied, version))
+	})
+}
+
+// MigrateTo migrates up or down so that exactly the migrations up to and
+// including version are applied. An empty version rolls back all of them.
+func (m *Migrator) MigrateTo(ctx context.Context, version string) error {
+	return m.withLock(ctx, func() error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(migrations, version); err != nil {
+			return err
+		}
+
+		if err := m.rollback(ctx, newerApplied(migrations, applied, version)); err != nil {
+			return err
+		}
+		if version == "" {
+			return nil
+		}
+		return m.migrateUp(ctx, migrations, applied, version)
+	})
+}
+
+// Force records the migrations up to and including version as applied and
+// the newer ones as not applied, without running any SQL. It repairs the
+// migration table after a migration failed halfway, such as on MySQL where
+// DDL is not transactional. An empty version removes the records of all
+// known migrations.
+func (m *Migrator) Force(ctx context.Context, version string) error {
+	return m.withLock(ctx, func() error {
+		migrations, applied, err := m.load(ctx)
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(migrations, version); err != nil {
+			return err
+		}
+
+		tx, err := m.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		defer tx.Rollback()
+
+		query := fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.tableName)
+		for _, mig := range newerApplied(migrations, applied, version) {
+			if _, err := tx.ExecContext(ctx, tx.Rebind(query), mig.Version); err != nil {
+				return fmt.Errorf("failed to remove migration record %s: %w", mig.Version, err)
+			}
+		}
+		for _, mig := range migrations {
+			if mig.Version > version {
+				break
+			}
+			if _, ok := applied[mig.Version]; ok {
+				continue
+			}
+			if err := m.recordMigration(ctx, tx, mig, 0); err != nil {
+				return fmt.Errorf("failed to record migration %s: %w", mig.Version, err)
+			}
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+
+		m.logger.Warnw("Forced migration version", "version", version)
+		return nil
+	})
+}
+
+// withLock runs fn while holding the migration lock, so instances starting
+// together do not run the same migrations. PostgreSQL uses a session
+// advisory lock and MySQL a named lock; SQLite needs none. The lock holds a
+// connection of its own, so the pool must allow at least two.
+func (m *Migrator) withLock(ctx context.Context, fn func() error) error {
+	timeout := m.lockTimeout
+	if timeout <= 0 {
+		timeout = DefaultLockTimeout
 	}
 
-	applied, err := m.GetAppliedMigrations(ctx)
+	var unlock string
+	var key any
+	switch m.dialect.Name() {
+	case dialect.Postgres:
+		unlock, key = "SELECT pg_advisory_unlock($1)", advisoryLockKey
+	case dialect.MySQL:
+		unlock, key = "SELECT RELEASE_LOCK(?)", mysqlLockName
+	default:
+		return fn()
+	}
+
+	// The lock belongs to a session, so hold one connection throughout
+	conn, err := m.db.Connx(ctx)
 	if err != nil {
+		return fmt.Errorf("failed to acquire migration lock: %w", err)
+	}
+	defer conn.Close()
+
+	if err := m.lock(ctx, conn, timeout); err != nil {
 		return err
 	}
+	defer func() {
+		if _, err := conn.ExecContext(context.WithoutCancel(ctx), unlock, key); err != nil {
+			m.logger.Warnw("Failed to release migration lock", "error", err)
+		}
+	}()
+
+	return fn()
+}
+
+// lock acquires the migration lock on conn within timeout.
+func (m *Migrator) lock(ctx context.Context, conn *sqlx.Conn, timeout time.Duration) error {
+	timeoutErr := fmt.Errorf("timed out after %s waiting for the migration lock; another instance may be running migrations", timeout)
+
+	if m.dialect.Name() == dialect.MySQL {
+		var acquired sql.NullInt64
+		seconds := max(int(timeout.Seconds()), 1)
+		if err := conn.GetContext(ctx, &acquired, "SELECT GET_LOCK(?, ?)", mysqlLockName, seconds); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired.Int64 != 1 {
+			return timeoutErr
+		}
+		return nil
+	}
+
+	deadline := time.Now().Add(timeout)
+	for {
+		var acquired bool
+		if err := conn.GetContext(ctx, &acquired, "SELECT pg_try_advisory_lock($1)", advisoryLockKey); err != nil {
+			return fmt.Errorf("failed to acquire migration lock: %w", err)
+		}
+		if acquired {
+			return nil
+		}
+		if time.Now().After(deadline) {
+			return timeoutErr
+		}
+		m.logger.Infow("Waiting for migration lock")
+		select {
+		case <-time.After(lockRetryInterval):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// load ensures the migration table and returns the known and the applied
+// migrations. Records of unknown versions, such as the admin API's schema
+// changes, are left out.
+func (m *Migrator) load(ctx context.Context) ([]Migration, map[string]MigrationRecord, error) {
+	if err := m.EnsureMigrationTable(ctx); err != nil {
+		return nil, nil, fmt.Errorf("failed to ensure migration table: %w", err)
+	}
+
+	records, err := m.GetAppliedMigrations(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
 
 	migrations, err := m.LoadMigrations()
 	if err != nil {
-		return err
+		return nil, nil, err
+	}
+
+	applied := make(map[string]MigrationRecord, len(records))
+	for _, mig := range migrations {
+		if record, ok := records[mig.Version]; ok {
+			applied[mig.Version] = record
+		}
 	}
+	return migrations, applied, nil
+}
 
+// migrateUp runs the pending migrations up to and including version, each
+// in its own transaction together with its record.
+func (m *Migrator) migrateUp(ctx context.Context, migrations []Migration, applied map[string]MigrationRecord, version string) error {
 	for _, mig := range migrations {
+		if mig.Version > version {
+			break
+		}
 		if _, ok := applied[mig.Version]; ok {
 			// Check for checksum mismatch
 			if applied[mig.Version].Checksum != mig.Checksum {
+				if m.strictChecksums {
+					return fmt.Errorf("migration %s checksum mismatch: the applied migration was changed", mig.Version)
+				}
 				m.logger.Warnw("Migration checksum mismatch",
 					"version", mig.Version,
 					"expected", mig.Checksum,
@@ -191,18 +498,27 @@ func (m *Migrator) MigrateUp(ctx context.Context) error {
 			continue
 		}
 
-		m.logger.Infow("Running migration", "version", mig.Version, "name", mig.Name)
+		m.logger.Infow("Running migration", "version", mig.Version, "name", mig.Name, "fake", m.fake)
 
 		start := time.Now()
-		if err := m.runMigration(ctx, mig.UpSQL); err != nil {
+		tx, err := m.db.BeginTxx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if err := m.runScript(ctx, tx, mig.UpSQL); err != nil {
+			tx.Rollback()
 			return fmt.Errorf("migration %s failed: %w", mig.Version, err)
 		}
 		executionMs := time.Since(start).Milliseconds()
 
 		// Record migration
-		if err := m.recordMigration(ctx, mig, executionMs); err != nil {
+		if err := m.recordMigration(ctx, tx, mig, executionMs); err != nil {
+			tx.Rollback()
 			return fmt.Errorf("failed to record migration %s: %w", mig.Version, err)
 		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %s failed: %w", mig.Version, err)
+		}
 
 		m.logger.Infow("Migration completed", "version", mig.Version, "duration_ms", executionMs)
 	}
@@ -210,82 +526,130 @@ func (m *Migrator) MigrateUp(ctx context.Context) error {
 	return nil
 }
 
-// MigrateDown rolls back the last migration.
-func (m *Migrator) MigrateDown(ctx context.Context) error {
-	applied, err := m.GetAppliedMigrations(ctx)
-	if err != nil {
-		return err
-	}
-
-	if len(applied) == 0 {
+// rollback runs the down migrations of targets in order and removes their
+// records, all in one transaction.
+func (m *Migrator) rollback(ctx context.Context, targets []Migration) error {
+	if len(targets) == 0 {
 		return nil
 	}
-
-	// Find the last applied migration
-	var lastVersion string
-	for version := range applied {
-		if version > lastVersion {
-			lastVersion = version
+	for _, target := range targets {
+		if target.DownSQL == "" {
+			return fmt.Errorf("no down migration found for version %s", target.Version)
 		}
 	}
 
-	migrations, err := m.LoadMigrations()
+	tx, err := m.db.BeginTxx(ctx, nil)
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
-	var target *Migration
-	for i := range migrations {
-		if migrations[i].Version == lastVersion {
-			target = &migrations[i]
-			break
+	query := tx.Rebind(fmt.Sprintf("DELETE FROM %s WHERE version = ?", m.tableName))
+	for _, target := range targets {
+		m.logger.Infow("Rolling back migration", "version", target.Version, "name", target.Name, "fake", m.fake)
+
+		if err := m.runScript(ctx, tx, target.DownSQL); err != nil {
+			return fmt.Errorf("rollback %s failed: %w", target.Version, err)
 		}
-	}
 
-	if target == nil || target.DownSQL == "" {
-		return fmt.Errorf("no down migration found for version %s", lastVersion)
+		// Remove migration record
+		if _, err := tx.ExecContext(ctx, query, target.Version); err != nil {
+			return fmt.Errorf("failed to remove migration record: %w", err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
 	}
 
-	m.logger.Infow("Rolling back migration", "version", target.Version, "name", target.Name)
+	for _, target := range targets {
+		m.logger.Infow("Rollback completed", "version", target.Version)
+	}
+	return nil
+}
 
-	if err := m.runMigration(ctx, target.DownSQL); err != nil {
-		return fmt.Errorf("rollback %s failed: %w", target.Version, err)
+// runScript executes a migration script in tx, or nothing in fake mode.
+func (m *Migrator) runScript(ctx context.Context, tx *sqlx.Tx, sql string) error {
+	if m.fake {
+		return nil
 	}
 
-	// Remove migration record
-	query := fmt.Sprintf("DELETE FROM %s WHERE version = $1", m.tableName)
-	if _, err := m.db.ExecContext(ctx, query, target.Version); err != nil {
-		return fmt.Errorf("failed to remove migration record: %w", err)
+	// Only lib/pq reliably runs multi-statement scripts in one Exec
+	statements := []string{sql}
+	if m.dialect.Name() != dialect.Postgres {
+		statements = SplitStatements(sql)
 	}
 
-	m.logger.Infow("Rollback completed", "version", target.Version)
+	for _, stmt := range statements {
+		if _, err := tx.ExecContext(ctx, stmt); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// runMigration executes a migration SQL.
-func (m *Migrator) runMigration(ctx context.Context, sql string) error {
-	tx, err := m.db.BeginTxx(ctx, nil)
-	if err != nil {
-		return err
+// checkVersion returns an error unless version is empty or a known
+// migration.
+func checkVersion(migrations []Migration, version string) error {
+	if version == "" {
+		return nil
 	}
+	for _, mig := range migrations {
+		if mig.Version == version {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown migration version %s", version)
+}
 
-	_, err = tx.ExecContext(ctx, sql)
-	if err != nil {
-		tx.Rollback()
-		return err
+// newerApplied returns the applied migrations newer than version, the
+// newest first.
+func newerApplied(migrations []Migration, applied map[string]MigrationRecord, version string) []Migration {
+	var newer []Migration
+	for i := len(migrations) - 1; i >= 0; i-- {
+		mig := migrations[i]
+		if mig.Version <= version {
+			break
+		}
+		if _, ok := applied[mig.Version]; ok {
+			newer = append(newer, mig)
+		}
 	}
+	return newer
+}
+
+// SplitStatements splits a SQL script into statements on trailing semicolons.
+// Comment-only lines are dropped.
+func SplitStatements(script string) []string {
+	var statements []string
+	var current strings.Builder
 
-	return tx.Commit()
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+		current.WriteString(line)
+		current.WriteString("\n")
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSpace(current.String()))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+	return statements
 }
 
 // recordMigration records a successful migration.
-func (m *Migrator) recordMigration(ctx context.Context, mig Migration, executionMs int64) error {
+func (m *Migrator) recordMigration(ctx context.Context, tx *sqlx.Tx, mig Migration, executionMs int64) error {
 	query := fmt.Sprintf(`
 		INSERT INTO %s (version, name, checksum, execution_ms)
-		VALUES ($1, $2, $3, $4)
+		VALUES (?, ?, ?, ?)
 	`, m.tableName)
 
-	_, err := m.db.ExecContext(ctx, query, mig.Version, mig.Name, mig.Checksum, executionMs)
+	_, err := tx.ExecContext(ctx, tx.Rebind(query), mig.Version, mig.Name, mig.Checksum, executionMs)
 	return err
 }
 
@@ -334,8 +698,8 @@ type MigrationStatus struct {
 	ExecutionMs int64
 }
 
-// checksumSQL generates a checksum for SQL content.
-func checksumSQL(sql string) string {
+// Checksum returns the SHA-256 checksum of a SQL script.
+func Checksum(sql string) string {
 	// Normalize whitespace for consistent checksums
 	normalized := strings.TrimSpace(sql)
 	hash := sha256.Sum256([]byte(normalized))
@@ -0,0 +1,3 @@
+-- TuGo Record Revisions Migration (Down)
+
+DROP TABLE IF EXISTS tugo_revisions;
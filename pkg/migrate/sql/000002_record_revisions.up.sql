@@ -0,0 +1,18 @@
+-- TuGo Record Revisions Migration (Up)
+-- Stores full previous versions of records for collections with history enabled
+
+CREATE TABLE IF NOT EXISTS tugo_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    revision INTEGER NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    data JSONB NOT NULL,
+    user_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (collection, item_id, revision)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_revisions_item ON tugo_revisions(collection, item_id);
+CREATE INDEX IF NOT EXISTS idx_tugo_revisions_created_at ON tugo_revisions(created_at);
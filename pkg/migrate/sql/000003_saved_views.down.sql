@@ -0,0 +1,3 @@
+-- TuGo Saved Views Migration (Down)
+
+DROP TABLE IF EXISTS tugo_views;
@@ -0,0 +1,14 @@
+-- TuGo Saved Views Migration (Up)
+-- Stores named query presets that clients apply with ?view=name
+
+CREATE TABLE IF NOT EXISTS tugo_views (
+    id BIGSERIAL PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    definition JSONB NOT NULL,
+    roles JSONB NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (collection, name)
+);
@@ -0,0 +1,14 @@
+-- TuGo Stored Queries Migration (Up)
+-- Stores read-only SQL statements exposed at /queries/:name
+
+CREATE TABLE IF NOT EXISTS tugo_queries (
+    id BIGSERIAL PRIMARY KEY,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    statement TEXT NOT NULL,
+    params JSONB NOT NULL DEFAULT '[]',
+    roles JSONB NOT NULL DEFAULT '[]',
+    max_rows INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
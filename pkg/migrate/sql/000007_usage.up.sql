@@ -0,0 +1,15 @@
+-- TuGo Usage Migration (Up)
+-- Stores per-collection request counts in time buckets
+
+CREATE TABLE IF NOT EXISTS tugo_usage (
+    id BIGSERIAL PRIMARY KEY,
+    bucket_start TIMESTAMP WITH TIME ZONE NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    client_errors BIGINT NOT NULL DEFAULT 0,
+    latency TEXT NOT NULL
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_usage_bucket ON tugo_usage(bucket_start);
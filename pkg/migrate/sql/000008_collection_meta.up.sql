@@ -0,0 +1,15 @@
+-- TuGo Collection Metadata Migration (Up)
+-- Stores display names, descriptions and icons of collections and fields
+
+CREATE TABLE IF NOT EXISTS tugo_collection_meta (
+    id BIGSERIAL PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    field VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    icon VARCHAR(100) NOT NULL DEFAULT '',
+    display_order INT,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (collection, field)
+);
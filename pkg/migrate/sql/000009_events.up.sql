@@ -0,0 +1,16 @@
+-- TuGo Events Migration (Up)
+-- Stores the ordered change feed of collection writes
+
+CREATE TABLE IF NOT EXISTS tugo_events (
+    id BIGSERIAL PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    data JSONB NOT NULL,
+    user_id VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_events_collection ON tugo_events(collection, id);
+CREATE INDEX IF NOT EXISTS idx_tugo_events_created_at ON tugo_events(created_at);
@@ -0,0 +1,10 @@
+-- TuGo Event Cursors Migration (Up)
+-- Tracks how far each publisher has relayed the change feed
+
+CREATE TABLE IF NOT EXISTS tugo_event_cursors (
+    name VARCHAR(255) PRIMARY KEY,
+    last_id BIGINT NOT NULL DEFAULT 0,
+    owner VARCHAR(255),
+    lease_until BIGINT,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
@@ -0,0 +1,15 @@
+-- TuGo Snapshots Migration (Up)
+-- Lists the collection snapshots kept in storage for point-in-time restores
+
+CREATE TABLE IF NOT EXISTS tugo_snapshots (
+    id VARCHAR(36) PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    rows_count BIGINT NOT NULL DEFAULT 0,
+    size BIGINT NOT NULL DEFAULT 0,
+    note TEXT NOT NULL DEFAULT '',
+    created_by VARCHAR(255),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_snapshots_collection ON tugo_snapshots(collection, created_at);
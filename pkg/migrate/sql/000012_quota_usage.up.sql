@@ -0,0 +1,13 @@
+-- TuGo Quota Usage Migration (Up)
+-- Stores increments of per-user monthly request and byte counts
+
+CREATE TABLE IF NOT EXISTS tugo_quota_usage (
+    id BIGSERIAL PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_quota_usage_period ON tugo_quota_usage(period, user_id);
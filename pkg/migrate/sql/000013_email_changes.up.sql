@@ -0,0 +1,13 @@
+-- TuGo Email Changes Migration (Up)
+-- Stores email changes awaiting confirmation from the new address
+
+CREATE TABLE IF NOT EXISTS tugo_email_changes (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    email VARCHAR(255) NOT NULL,
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_email_changes_user_id ON tugo_email_changes(user_id);
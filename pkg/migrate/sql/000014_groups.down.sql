@@ -0,0 +1,5 @@
+-- TuGo Groups Migration (Down)
+
+DROP TABLE IF EXISTS tugo_group_permissions;
+DROP TABLE IF EXISTS tugo_user_groups;
+DROP TABLE IF EXISTS tugo_groups;
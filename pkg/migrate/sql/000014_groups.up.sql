@@ -0,0 +1,35 @@
+-- TuGo Groups Migration (Up)
+-- Adds user groups, their members and their permission policies
+
+CREATE TABLE IF NOT EXISTS tugo_groups (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description VARCHAR(500),
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+CREATE TABLE IF NOT EXISTS tugo_user_groups (
+    user_id VARCHAR(255) NOT NULL,
+    group_id UUID NOT NULL REFERENCES tugo_groups(id) ON DELETE CASCADE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    PRIMARY KEY (user_id, group_id)
+);
+
+CREATE TABLE IF NOT EXISTS tugo_group_permissions (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    group_id UUID NOT NULL REFERENCES tugo_groups(id) ON DELETE CASCADE,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    filter JSONB DEFAULT '{}',
+    field_permissions JSONB DEFAULT '{}',
+    validation JSONB,
+    presets JSONB DEFAULT '{}',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE(group_id, collection, action)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_user_groups_group_id ON tugo_user_groups(group_id);
+CREATE INDEX IF NOT EXISTS idx_tugo_group_permissions_collection ON tugo_group_permissions(collection);
@@ -0,0 +1,3 @@
+-- TuGo Pending Changes Migration (Down)
+
+DROP TABLE IF EXISTS tugo_pending_changes;
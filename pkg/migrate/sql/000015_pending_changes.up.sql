@@ -0,0 +1,20 @@
+-- TuGo Pending Changes Migration (Up)
+-- Stores collection writes awaiting approval
+
+CREATE TABLE IF NOT EXISTS tugo_pending_changes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    item_id VARCHAR(255),
+    data JSONB NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    submitted_by VARCHAR(255),
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    reviewed_at TIMESTAMP WITH TIME ZONE
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_pending_changes_status ON tugo_pending_changes(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_pending_changes_collection ON tugo_pending_changes(collection);
@@ -0,0 +1,3 @@
+-- TuGo Comments Migration (Down)
+
+DROP TABLE IF EXISTS tugo_comments;
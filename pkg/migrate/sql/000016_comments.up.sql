@@ -0,0 +1,18 @@
+-- TuGo Comments Migration (Up)
+-- Stores threaded comments on collection records
+
+CREATE TABLE IF NOT EXISTS tugo_comments (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    parent_id UUID REFERENCES tugo_comments(id) ON DELETE CASCADE,
+    user_id VARCHAR(255),
+    body TEXT NOT NULL,
+    mentions JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_comments_item ON tugo_comments(collection, item_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_comments_parent ON tugo_comments(parent_id);
@@ -0,0 +1,3 @@
+-- TuGo Notifications Migration (Down)
+
+DROP TABLE IF EXISTS tugo_notifications;
@@ -0,0 +1,20 @@
+-- TuGo Notifications Migration (Up)
+-- Stores in-app notifications for users
+
+CREATE TABLE IF NOT EXISTS tugo_notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    title VARCHAR(500) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    collection VARCHAR(255),
+    item_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    data JSONB,
+    read_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW()
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_notifications_user ON tugo_notifications(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_notifications_unread ON tugo_notifications(user_id, read_at);
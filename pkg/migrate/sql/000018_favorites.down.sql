@@ -0,0 +1,3 @@
+-- TuGo Favorites Migration (Down)
+
+DROP TABLE IF EXISTS tugo_favorites;
@@ -0,0 +1,15 @@
+-- TuGo Favorites Migration (Up)
+-- Stores the collection items users starred
+
+CREATE TABLE IF NOT EXISTS tugo_favorites (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id VARCHAR(255) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT NOW(),
+    UNIQUE (user_id, collection, item_id)
+);
+
+-- Create indexes
+CREATE INDEX IF NOT EXISTS idx_tugo_favorites_user ON tugo_favorites(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_favorites_item ON tugo_favorites(collection, item_id);
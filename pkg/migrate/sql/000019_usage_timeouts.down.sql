@@ -0,0 +1,3 @@
+-- TuGo Usage Timeouts Migration (Down)
+
+ALTER TABLE tugo_usage DROP COLUMN IF EXISTS timeouts;
@@ -0,0 +1,4 @@
+-- TuGo Usage Timeouts Migration (Up)
+-- Counts requests that exceeded their deadline
+
+ALTER TABLE tugo_usage ADD COLUMN IF NOT EXISTS timeouts BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+-- TuGo Leases Migration (Up)
+-- Lets one instance of a cluster at a time run each periodic job
+
+CREATE TABLE IF NOT EXISTS tugo_leases (
+    name VARCHAR(255) PRIMARY KEY,
+    owner VARCHAR(255) NOT NULL,
+    lease_until BIGINT NOT NULL
+);
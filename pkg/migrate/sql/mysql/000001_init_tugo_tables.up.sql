@@ -0,0 +1,205 @@
+-- TuGo System Tables Migration (Up, MySQL/MariaDB)
+-- Creates all required system tables for TuGo
+-- UUIDs are stored as CHAR(36); updated_at is maintained with ON UPDATE instead of triggers
+
+-- ============================================================================
+-- ROLES TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_roles (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description VARCHAR(500),
+    is_system BOOLEAN DEFAULT FALSE,
+    permissions JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+-- Insert default roles
+INSERT IGNORE INTO tugo_roles (id, name, description, is_system, permissions) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'admin', 'Full administrative access', TRUE, '{"*": ["create", "read", "update", "delete"]}'),
+    ('00000000-0000-0000-0000-000000000002', 'user', 'Standard user access', TRUE, '{"*": ["read"]}'),
+    ('00000000-0000-0000-0000-000000000003', 'guest', 'Limited guest access', TRUE, '{"*": []}');
+
+-- ============================================================================
+-- USERS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_users (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    username VARCHAR(100) UNIQUE NOT NULL,
+    email VARCHAR(255) UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role_id CHAR(36),
+    status VARCHAR(50) DEFAULT 'active',
+    totp_secret VARCHAR(255),
+    totp_enabled BOOLEAN DEFAULT FALSE,
+    metadata JSON,
+    last_login_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tugo_users_role_id (role_id),
+    INDEX idx_tugo_users_status (status),
+    FOREIGN KEY (role_id) REFERENCES tugo_roles(id) ON DELETE SET NULL
+);
+
+-- ============================================================================
+-- SESSIONS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_sessions (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id CHAR(36) NOT NULL,
+    token VARCHAR(500) UNIQUE NOT NULL,
+    refresh_token VARCHAR(500) UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    refresh_expires_at TIMESTAMP NULL,
+    user_agent VARCHAR(500),
+    ip_address VARCHAR(45),
+    is_revoked BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tugo_sessions_user_id (user_id),
+    INDEX idx_tugo_sessions_expires_at (expires_at),
+    FOREIGN KEY (user_id) REFERENCES tugo_users(id) ON DELETE CASCADE
+);
+
+-- ============================================================================
+-- FILES TABLE (Storage Metadata)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_files (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    filename VARCHAR(500) NOT NULL,
+    original_filename VARCHAR(500),
+    path VARCHAR(1000) NOT NULL,
+    mimetype VARCHAR(255),
+    size BIGINT,
+    storage_provider VARCHAR(100) DEFAULT 'local',
+    bucket VARCHAR(255),
+    metadata JSON,
+    uploaded_by CHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tugo_files_filename (filename),
+    INDEX idx_tugo_files_mimetype (mimetype),
+    INDEX idx_tugo_files_storage_provider (storage_provider),
+    INDEX idx_tugo_files_uploaded_by (uploaded_by),
+    FOREIGN KEY (uploaded_by) REFERENCES tugo_users(id) ON DELETE SET NULL
+);
+
+-- ============================================================================
+-- COLLECTIONS TABLE (Schema Metadata)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_collections (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    name VARCHAR(255) UNIQUE NOT NULL,
+    table_name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    hidden BOOLEAN DEFAULT FALSE,
+    singleton BOOLEAN DEFAULT FALSE,
+    icon VARCHAR(100),
+    note TEXT,
+    display_template VARCHAR(500),
+    archive_field VARCHAR(100),
+    archive_value VARCHAR(100),
+    sort_field VARCHAR(100),
+    accountability VARCHAR(50) DEFAULT 'all',
+    metadata JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tugo_collections_table_name (table_name)
+);
+
+-- ============================================================================
+-- FIELDS TABLE (Field Metadata)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_fields (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    collection_id CHAR(36) NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    data_type VARCHAR(100) NOT NULL,
+    postgres_type VARCHAR(100),
+    is_nullable BOOLEAN DEFAULT TRUE,
+    is_unique BOOLEAN DEFAULT FALSE,
+    is_primary_key BOOLEAN DEFAULT FALSE,
+    default_value TEXT,
+    max_length INT,
+    `precision` INT,
+    scale INT,
+    hidden BOOLEAN DEFAULT FALSE,
+    readonly BOOLEAN DEFAULT FALSE,
+    required BOOLEAN DEFAULT FALSE,
+    sort INT DEFAULT 0,
+    width VARCHAR(50) DEFAULT 'full',
+    note TEXT,
+    validation JSON,
+    display_options JSON,
+    metadata JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE (collection_id, name),
+    INDEX idx_tugo_fields_name (name),
+    FOREIGN KEY (collection_id) REFERENCES tugo_collections(id) ON DELETE CASCADE
+);
+
+-- ============================================================================
+-- RELATIONSHIPS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_relationships (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    collection_id CHAR(36) NOT NULL,
+    field_name VARCHAR(255) NOT NULL,
+    related_collection_id CHAR(36),
+    related_collection VARCHAR(255),
+    relationship_type VARCHAR(50) NOT NULL,
+    junction_table VARCHAR(255),
+    junction_field VARCHAR(255),
+    one_field VARCHAR(255),
+    many_field VARCHAR(255),
+    one_deselect_action VARCHAR(50) DEFAULT 'nullify',
+    sort_field VARCHAR(255),
+    metadata JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    INDEX idx_tugo_relationships_type (relationship_type),
+    FOREIGN KEY (collection_id) REFERENCES tugo_collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (related_collection_id) REFERENCES tugo_collections(id) ON DELETE CASCADE
+);
+
+-- ============================================================================
+-- PERMISSIONS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_permissions (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    role_id CHAR(36) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    filter JSON,
+    field_permissions JSON,
+    validation JSON,
+    presets JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE (role_id, collection, action),
+    INDEX idx_tugo_permissions_collection (collection),
+    INDEX idx_tugo_permissions_action (action),
+    FOREIGN KEY (role_id) REFERENCES tugo_roles(id) ON DELETE CASCADE
+);
+
+-- ============================================================================
+-- AUDIT LOG TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_audit_log (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id CHAR(36),
+    action VARCHAR(50) NOT NULL,
+    collection VARCHAR(255),
+    item_id VARCHAR(255),
+    changes JSON,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tugo_audit_log_user_id (user_id),
+    INDEX idx_tugo_audit_log_action (action),
+    INDEX idx_tugo_audit_log_collection (collection),
+    INDEX idx_tugo_audit_log_created_at (created_at),
+    FOREIGN KEY (user_id) REFERENCES tugo_users(id) ON DELETE SET NULL
+);
@@ -0,0 +1,3 @@
+-- TuGo Record Revisions Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_revisions;
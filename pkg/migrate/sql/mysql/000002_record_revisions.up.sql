@@ -0,0 +1,15 @@
+-- TuGo Record Revisions Migration (Up, MySQL/MariaDB)
+-- Stores full previous versions of records for collections with history enabled
+
+CREATE TABLE IF NOT EXISTS tugo_revisions (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    revision INT NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    data JSON NOT NULL,
+    user_id VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tugo_revisions_item_revision (collection, item_id, revision),
+    INDEX idx_tugo_revisions_created_at (created_at)
+);
@@ -0,0 +1,3 @@
+-- TuGo Saved Views Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_views;
@@ -0,0 +1,14 @@
+-- TuGo Saved Views Migration (Up, MySQL/MariaDB)
+-- Stores named query presets that clients apply with ?view=name
+
+CREATE TABLE IF NOT EXISTS tugo_views (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    description TEXT NOT NULL,
+    definition JSON NOT NULL,
+    roles JSON NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tugo_views_collection_name (collection, name)
+);
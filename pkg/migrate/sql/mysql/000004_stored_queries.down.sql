@@ -0,0 +1,3 @@
+-- TuGo Stored Queries Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_queries;
@@ -0,0 +1,15 @@
+-- TuGo Stored Queries Migration (Up, MySQL/MariaDB)
+-- Stores read-only SQL statements exposed at /queries/:name
+
+CREATE TABLE IF NOT EXISTS tugo_queries (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(64) NOT NULL,
+    description TEXT NOT NULL,
+    statement TEXT NOT NULL,
+    params JSON NOT NULL,
+    roles JSON NOT NULL,
+    max_rows INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tugo_queries_name (name)
+);
@@ -0,0 +1,13 @@
+-- TuGo Email Templates Migration (Up, MySQL/MariaDB)
+-- Stores Go templates for notification emails
+
+CREATE TABLE IF NOT EXISTS tugo_templates (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(100) NOT NULL,
+    subject TEXT NOT NULL,
+    text_body TEXT NOT NULL,
+    html_body TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tugo_templates_name (name)
+);
@@ -0,0 +1,3 @@
+-- TuGo Webhook Deliveries Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_webhook_deliveries;
@@ -0,0 +1,17 @@
+-- TuGo Webhook Deliveries Migration (Up, MySQL/MariaDB)
+-- Stores webhook delivery history for inspection and redelivery
+
+CREATE TABLE IF NOT EXISTS tugo_webhook_deliveries (
+    id VARCHAR(36) PRIMARY KEY,
+    webhook_id VARCHAR(100) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    payload JSON NOT NULL,
+    status_code INT NOT NULL DEFAULT 0,
+    response TEXT NOT NULL,
+    error TEXT NOT NULL,
+    latency_ms BIGINT NOT NULL DEFAULT 0,
+    redelivery_of VARCHAR(36),
+    created_at TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_tugo_webhook_deliveries_webhook (webhook_id, created_at)
+);
@@ -0,0 +1,3 @@
+-- TuGo Usage Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_usage;
@@ -0,0 +1,13 @@
+-- TuGo Usage Migration (Up, MySQL/MariaDB)
+-- Stores per-collection request counts in time buckets
+
+CREATE TABLE IF NOT EXISTS tugo_usage (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    bucket_start TIMESTAMP(6) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    errors BIGINT NOT NULL DEFAULT 0,
+    client_errors BIGINT NOT NULL DEFAULT 0,
+    latency TEXT NOT NULL,
+    INDEX idx_tugo_usage_bucket (bucket_start)
+);
@@ -0,0 +1,15 @@
+-- TuGo Collection Metadata Migration (Up, MySQL/MariaDB)
+-- Stores display names, descriptions and icons of collections and fields
+
+CREATE TABLE IF NOT EXISTS tugo_collection_meta (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    field VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL,
+    icon VARCHAR(100) NOT NULL DEFAULT '',
+    display_order INT,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tugo_collection_meta_collection_field (collection, field)
+);
@@ -0,0 +1,3 @@
+-- TuGo Events Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_events;
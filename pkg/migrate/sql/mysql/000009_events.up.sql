@@ -0,0 +1,14 @@
+-- TuGo Events Migration (Up, MySQL/MariaDB)
+-- Stores the ordered change feed of collection writes
+
+CREATE TABLE IF NOT EXISTS tugo_events (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    data JSON NOT NULL,
+    user_id VARCHAR(255),
+    created_at TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_tugo_events_collection (collection, id),
+    INDEX idx_tugo_events_created_at (created_at)
+);
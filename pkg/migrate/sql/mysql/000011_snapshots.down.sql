@@ -0,0 +1,3 @@
+-- TuGo Snapshots Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_snapshots;
@@ -0,0 +1,14 @@
+-- TuGo Snapshots Migration (Up, MySQL/MariaDB)
+-- Lists the collection snapshots kept in storage for point-in-time restores
+
+CREATE TABLE IF NOT EXISTS tugo_snapshots (
+    id VARCHAR(36) PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    path TEXT NOT NULL,
+    rows_count BIGINT NOT NULL DEFAULT 0,
+    size BIGINT NOT NULL DEFAULT 0,
+    note TEXT NOT NULL,
+    created_by VARCHAR(255),
+    created_at TIMESTAMP(6) DEFAULT CURRENT_TIMESTAMP(6),
+    INDEX idx_tugo_snapshots_collection (collection, created_at)
+);
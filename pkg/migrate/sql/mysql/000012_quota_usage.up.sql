@@ -0,0 +1,11 @@
+-- TuGo Quota Usage Migration (Up, MySQL/MariaDB)
+-- Stores increments of per-user monthly request and byte counts
+
+CREATE TABLE IF NOT EXISTS tugo_quota_usage (
+    id BIGINT AUTO_INCREMENT PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    requests BIGINT NOT NULL DEFAULT 0,
+    bytes BIGINT NOT NULL DEFAULT 0,
+    INDEX idx_tugo_quota_usage_period (period, user_id)
+);
@@ -0,0 +1,3 @@
+-- TuGo Email Changes Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_email_changes;
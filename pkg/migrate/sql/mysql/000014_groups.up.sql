@@ -0,0 +1,35 @@
+-- TuGo Groups Migration (Up, MySQL/MariaDB)
+-- Adds user groups, their members and their permission policies
+
+CREATE TABLE IF NOT EXISTS tugo_groups (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tugo_user_groups (
+    user_id VARCHAR(255) NOT NULL,
+    group_id CHAR(36) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id),
+    INDEX idx_tugo_user_groups_group_id (group_id),
+    FOREIGN KEY (group_id) REFERENCES tugo_groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tugo_group_permissions (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    group_id CHAR(36) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    filter JSON,
+    field_permissions JSON,
+    validation JSON,
+    presets JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP ON UPDATE CURRENT_TIMESTAMP,
+    UNIQUE (group_id, collection, action),
+    INDEX idx_tugo_group_permissions_collection (collection),
+    FOREIGN KEY (group_id) REFERENCES tugo_groups(id) ON DELETE CASCADE
+);
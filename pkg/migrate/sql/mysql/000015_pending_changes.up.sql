@@ -0,0 +1,18 @@
+-- TuGo Pending Changes Migration (Up, MySQL/MariaDB)
+-- Stores collection writes awaiting approval
+
+CREATE TABLE IF NOT EXISTS tugo_pending_changes (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    item_id VARCHAR(255),
+    data JSON NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    submitted_by VARCHAR(255),
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP NULL,
+    INDEX idx_tugo_pending_changes_status (status, created_at),
+    INDEX idx_tugo_pending_changes_collection (collection)
+);
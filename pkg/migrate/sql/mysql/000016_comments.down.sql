@@ -0,0 +1,3 @@
+-- TuGo Comments Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_comments;
@@ -0,0 +1,17 @@
+-- TuGo Comments Migration (Up, MySQL/MariaDB)
+-- Stores threaded comments on collection records
+
+CREATE TABLE IF NOT EXISTS tugo_comments (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    parent_id CHAR(36),
+    user_id VARCHAR(255),
+    body TEXT NOT NULL,
+    mentions JSON,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tugo_comments_item (collection, item_id, created_at),
+    INDEX idx_tugo_comments_parent (parent_id),
+    FOREIGN KEY (parent_id) REFERENCES tugo_comments(id) ON DELETE CASCADE
+);
@@ -0,0 +1,3 @@
+-- TuGo Notifications Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_notifications;
@@ -0,0 +1,18 @@
+-- TuGo Notifications Migration (Up, MySQL/MariaDB)
+-- Stores in-app notifications for users
+
+CREATE TABLE IF NOT EXISTS tugo_notifications (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    title VARCHAR(500) NOT NULL,
+    body TEXT NOT NULL,
+    collection VARCHAR(255),
+    item_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    data JSON,
+    read_at TIMESTAMP NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_tugo_notifications_user (user_id, created_at),
+    INDEX idx_tugo_notifications_unread (user_id, read_at)
+);
@@ -0,0 +1,3 @@
+-- TuGo Favorites Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_favorites;
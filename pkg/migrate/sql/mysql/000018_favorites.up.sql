@@ -0,0 +1,13 @@
+-- TuGo Favorites Migration (Up, MySQL/MariaDB)
+-- Stores the collection items users starred
+
+CREATE TABLE IF NOT EXISTS tugo_favorites (
+    id CHAR(36) PRIMARY KEY DEFAULT (UUID()),
+    user_id VARCHAR(255) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE KEY uq_tugo_favorites (user_id, collection, item_id),
+    INDEX idx_tugo_favorites_user (user_id, created_at),
+    INDEX idx_tugo_favorites_item (collection, item_id)
+);
@@ -0,0 +1,3 @@
+-- TuGo Usage Timeouts Migration (Down, MySQL/MariaDB)
+
+ALTER TABLE tugo_usage DROP COLUMN timeouts;
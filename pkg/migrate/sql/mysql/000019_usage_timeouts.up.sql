@@ -0,0 +1,4 @@
+-- TuGo Usage Timeouts Migration (Up, MySQL/MariaDB)
+-- Counts requests that exceeded their deadline
+
+ALTER TABLE tugo_usage ADD COLUMN timeouts BIGINT NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+-- TuGo Leases Migration (Down, MySQL/MariaDB)
+
+DROP TABLE IF EXISTS tugo_leases;
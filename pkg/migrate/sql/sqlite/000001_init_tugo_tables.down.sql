@@ -0,0 +1,13 @@
+-- TuGo System Tables Migration (Down, SQLite)
+-- Drops all system tables created by TuGo
+
+-- Drop tables in reverse order of creation (respecting foreign key constraints)
+DROP TABLE IF EXISTS tugo_audit_log;
+DROP TABLE IF EXISTS tugo_permissions;
+DROP TABLE IF EXISTS tugo_relationships;
+DROP TABLE IF EXISTS tugo_fields;
+DROP TABLE IF EXISTS tugo_collections;
+DROP TABLE IF EXISTS tugo_files;
+DROP TABLE IF EXISTS tugo_sessions;
+DROP TABLE IF EXISTS tugo_users;
+DROP TABLE IF EXISTS tugo_roles;
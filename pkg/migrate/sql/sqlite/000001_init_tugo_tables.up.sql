@@ -0,0 +1,213 @@
+-- TuGo System Tables Migration (Up, SQLite)
+-- Creates all required system tables for TuGo
+-- UUIDs are stored as TEXT and generated by the application; JSON is stored as TEXT
+
+-- ============================================================================
+-- ROLES TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_roles (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description VARCHAR(500),
+    is_system BOOLEAN DEFAULT FALSE,
+    permissions TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Insert default roles
+INSERT OR IGNORE INTO tugo_roles (id, name, description, is_system, permissions) VALUES
+    ('00000000-0000-0000-0000-000000000001', 'admin', 'Full administrative access', TRUE, '{"*": ["create", "read", "update", "delete"]}'),
+    ('00000000-0000-0000-0000-000000000002', 'user', 'Standard user access', TRUE, '{"*": ["read"]}'),
+    ('00000000-0000-0000-0000-000000000003', 'guest', 'Limited guest access', TRUE, '{"*": []}');
+
+-- ============================================================================
+-- USERS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_users (
+    id TEXT PRIMARY KEY,
+    username VARCHAR(100) UNIQUE NOT NULL,
+    email VARCHAR(255) UNIQUE,
+    password_hash VARCHAR(255) NOT NULL,
+    role_id TEXT,
+    status VARCHAR(50) DEFAULT 'active',
+    totp_secret VARCHAR(255),
+    totp_enabled BOOLEAN DEFAULT FALSE,
+    metadata TEXT,
+    last_login_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (role_id) REFERENCES tugo_roles(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_users_role_id ON tugo_users(role_id);
+CREATE INDEX IF NOT EXISTS idx_tugo_users_status ON tugo_users(status);
+
+-- ============================================================================
+-- SESSIONS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_sessions (
+    id TEXT PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    token VARCHAR(500) UNIQUE NOT NULL,
+    refresh_token VARCHAR(500) UNIQUE,
+    expires_at TIMESTAMP NOT NULL,
+    refresh_expires_at TIMESTAMP,
+    user_agent VARCHAR(500),
+    ip_address VARCHAR(45),
+    is_revoked BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES tugo_users(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_sessions_user_id ON tugo_sessions(user_id);
+CREATE INDEX IF NOT EXISTS idx_tugo_sessions_expires_at ON tugo_sessions(expires_at);
+
+-- ============================================================================
+-- FILES TABLE (Storage Metadata)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_files (
+    id TEXT PRIMARY KEY,
+    filename VARCHAR(500) NOT NULL,
+    original_filename VARCHAR(500),
+    path VARCHAR(1000) NOT NULL,
+    mimetype VARCHAR(255),
+    size BIGINT,
+    storage_provider VARCHAR(100) DEFAULT 'local',
+    bucket VARCHAR(255),
+    metadata TEXT,
+    uploaded_by TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (uploaded_by) REFERENCES tugo_users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_files_filename ON tugo_files(filename);
+CREATE INDEX IF NOT EXISTS idx_tugo_files_mimetype ON tugo_files(mimetype);
+CREATE INDEX IF NOT EXISTS idx_tugo_files_storage_provider ON tugo_files(storage_provider);
+CREATE INDEX IF NOT EXISTS idx_tugo_files_uploaded_by ON tugo_files(uploaded_by);
+
+-- ============================================================================
+-- COLLECTIONS TABLE (Schema Metadata)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_collections (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(255) UNIQUE NOT NULL,
+    table_name VARCHAR(255) NOT NULL,
+    enabled BOOLEAN DEFAULT TRUE,
+    hidden BOOLEAN DEFAULT FALSE,
+    singleton BOOLEAN DEFAULT FALSE,
+    icon VARCHAR(100),
+    note TEXT,
+    display_template VARCHAR(500),
+    archive_field VARCHAR(100),
+    archive_value VARCHAR(100),
+    sort_field VARCHAR(100),
+    accountability VARCHAR(50) DEFAULT 'all',
+    metadata TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_collections_table_name ON tugo_collections(table_name);
+
+-- ============================================================================
+-- FIELDS TABLE (Field Metadata)
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_fields (
+    id TEXT PRIMARY KEY,
+    collection_id TEXT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    data_type VARCHAR(100) NOT NULL,
+    postgres_type VARCHAR(100),
+    is_nullable BOOLEAN DEFAULT TRUE,
+    is_unique BOOLEAN DEFAULT FALSE,
+    is_primary_key BOOLEAN DEFAULT FALSE,
+    default_value TEXT,
+    max_length INT,
+    "precision" INT,
+    scale INT,
+    hidden BOOLEAN DEFAULT FALSE,
+    readonly BOOLEAN DEFAULT FALSE,
+    required BOOLEAN DEFAULT FALSE,
+    sort INT DEFAULT 0,
+    width VARCHAR(50) DEFAULT 'full',
+    note TEXT,
+    validation TEXT,
+    display_options TEXT,
+    metadata TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (collection_id, name),
+    FOREIGN KEY (collection_id) REFERENCES tugo_collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_fields_name ON tugo_fields(name);
+
+-- ============================================================================
+-- RELATIONSHIPS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_relationships (
+    id TEXT PRIMARY KEY,
+    collection_id TEXT NOT NULL,
+    field_name VARCHAR(255) NOT NULL,
+    related_collection_id TEXT,
+    related_collection VARCHAR(255),
+    relationship_type VARCHAR(50) NOT NULL,
+    junction_table VARCHAR(255),
+    junction_field VARCHAR(255),
+    one_field VARCHAR(255),
+    many_field VARCHAR(255),
+    one_deselect_action VARCHAR(50) DEFAULT 'nullify',
+    sort_field VARCHAR(255),
+    metadata TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (collection_id) REFERENCES tugo_collections(id) ON DELETE CASCADE,
+    FOREIGN KEY (related_collection_id) REFERENCES tugo_collections(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_relationships_type ON tugo_relationships(relationship_type);
+
+-- ============================================================================
+-- PERMISSIONS TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_permissions (
+    id TEXT PRIMARY KEY,
+    role_id TEXT NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    filter TEXT,
+    field_permissions TEXT,
+    validation TEXT,
+    presets TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (role_id, collection, action),
+    FOREIGN KEY (role_id) REFERENCES tugo_roles(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_permissions_collection ON tugo_permissions(collection);
+CREATE INDEX IF NOT EXISTS idx_tugo_permissions_action ON tugo_permissions(action);
+
+-- ============================================================================
+-- AUDIT LOG TABLE
+-- ============================================================================
+CREATE TABLE IF NOT EXISTS tugo_audit_log (
+    id TEXT PRIMARY KEY,
+    user_id TEXT,
+    action VARCHAR(50) NOT NULL,
+    collection VARCHAR(255),
+    item_id VARCHAR(255),
+    changes TEXT,
+    ip_address VARCHAR(45),
+    user_agent VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (user_id) REFERENCES tugo_users(id) ON DELETE SET NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_audit_log_user_id ON tugo_audit_log(user_id);
+CREATE INDEX IF NOT EXISTS idx_tugo_audit_log_action ON tugo_audit_log(action);
+CREATE INDEX IF NOT EXISTS idx_tugo_audit_log_collection ON tugo_audit_log(collection);
+CREATE INDEX IF NOT EXISTS idx_tugo_audit_log_created_at ON tugo_audit_log(created_at);
@@ -0,0 +1,3 @@
+-- TuGo Record Revisions Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_revisions;
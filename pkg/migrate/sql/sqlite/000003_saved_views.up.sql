@@ -0,0 +1,14 @@
+-- TuGo Saved Views Migration (Up, SQLite)
+-- Stores named query presets that clients apply with ?view=name
+
+CREATE TABLE IF NOT EXISTS tugo_views (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection VARCHAR(255) NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    definition TEXT NOT NULL,
+    roles TEXT NOT NULL DEFAULT '[]',
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (collection, name)
+);
@@ -0,0 +1,3 @@
+-- TuGo Stored Queries Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_queries;
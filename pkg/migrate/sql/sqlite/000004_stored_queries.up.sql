@@ -0,0 +1,14 @@
+-- TuGo Stored Queries Migration (Up, SQLite)
+-- Stores read-only SQL statements exposed at /queries/:name
+
+CREATE TABLE IF NOT EXISTS tugo_queries (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name VARCHAR(64) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    statement TEXT NOT NULL,
+    params TEXT NOT NULL DEFAULT '[]',
+    roles TEXT NOT NULL DEFAULT '[]',
+    max_rows INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- TuGo Email Templates Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_templates;
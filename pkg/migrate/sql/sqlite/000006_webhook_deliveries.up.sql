@@ -0,0 +1,18 @@
+-- TuGo Webhook Deliveries Migration (Up, SQLite)
+-- Stores webhook delivery history for inspection and redelivery
+
+CREATE TABLE IF NOT EXISTS tugo_webhook_deliveries (
+    id VARCHAR(36) PRIMARY KEY,
+    webhook_id VARCHAR(100) NOT NULL,
+    event VARCHAR(50) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    payload TEXT NOT NULL,
+    status_code INTEGER NOT NULL DEFAULT 0,
+    response TEXT NOT NULL DEFAULT '',
+    error TEXT NOT NULL DEFAULT '',
+    latency_ms INTEGER NOT NULL DEFAULT 0,
+    redelivery_of VARCHAR(36),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_webhook_deliveries_webhook ON tugo_webhook_deliveries(webhook_id, created_at);
@@ -0,0 +1,3 @@
+-- TuGo Usage Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_usage;
@@ -0,0 +1,14 @@
+-- TuGo Usage Migration (Up, SQLite)
+-- Stores per-collection request counts in time buckets
+
+CREATE TABLE IF NOT EXISTS tugo_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    bucket_start TIMESTAMP NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    errors INTEGER NOT NULL DEFAULT 0,
+    client_errors INTEGER NOT NULL DEFAULT 0,
+    latency TEXT NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_usage_bucket ON tugo_usage(bucket_start);
@@ -0,0 +1,3 @@
+-- TuGo Collection Metadata Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_collection_meta;
@@ -0,0 +1,15 @@
+-- TuGo Collection Metadata Migration (Up, SQLite)
+-- Stores display names, descriptions and icons of collections and fields
+
+CREATE TABLE IF NOT EXISTS tugo_collection_meta (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection VARCHAR(255) NOT NULL,
+    field VARCHAR(255) NOT NULL DEFAULT '',
+    display_name VARCHAR(255) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    icon VARCHAR(100) NOT NULL DEFAULT '',
+    display_order INTEGER,
+    hidden BOOLEAN NOT NULL DEFAULT FALSE,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (collection, field)
+);
@@ -0,0 +1,3 @@
+-- TuGo Events Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_events;
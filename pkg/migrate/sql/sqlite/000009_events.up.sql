@@ -0,0 +1,15 @@
+-- TuGo Events Migration (Up, SQLite)
+-- Stores the ordered change feed of collection writes
+
+CREATE TABLE IF NOT EXISTS tugo_events (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    data TEXT NOT NULL,
+    user_id VARCHAR(255),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_events_collection ON tugo_events(collection, id);
+CREATE INDEX IF NOT EXISTS idx_tugo_events_created_at ON tugo_events(created_at);
@@ -0,0 +1,3 @@
+-- TuGo Event Cursors Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_event_cursors;
@@ -0,0 +1,10 @@
+-- TuGo Event Cursors Migration (Up, SQLite)
+-- Tracks how far each publisher has relayed the change feed
+
+CREATE TABLE IF NOT EXISTS tugo_event_cursors (
+    name VARCHAR(255) PRIMARY KEY,
+    last_id INTEGER NOT NULL DEFAULT 0,
+    owner VARCHAR(255),
+    lease_until INTEGER,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
@@ -0,0 +1,3 @@
+-- TuGo Snapshots Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_snapshots;
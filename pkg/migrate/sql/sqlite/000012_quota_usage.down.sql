@@ -0,0 +1,3 @@
+-- TuGo Quota Usage Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_quota_usage;
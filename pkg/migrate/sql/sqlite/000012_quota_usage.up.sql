@@ -0,0 +1,12 @@
+-- TuGo Quota Usage Migration (Up, SQLite)
+-- Stores increments of per-user monthly request and byte counts
+
+CREATE TABLE IF NOT EXISTS tugo_quota_usage (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id VARCHAR(255) NOT NULL,
+    period VARCHAR(7) NOT NULL,
+    requests INTEGER NOT NULL DEFAULT 0,
+    bytes INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_quota_usage_period ON tugo_quota_usage(period, user_id);
@@ -0,0 +1,3 @@
+-- TuGo Email Changes Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_email_changes;
@@ -0,0 +1,36 @@
+-- TuGo Groups Migration (Up, SQLite)
+-- Adds user groups, their members and their permission policies
+
+CREATE TABLE IF NOT EXISTS tugo_groups (
+    id TEXT PRIMARY KEY,
+    name VARCHAR(100) UNIQUE NOT NULL,
+    description VARCHAR(500),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS tugo_user_groups (
+    user_id VARCHAR(255) NOT NULL,
+    group_id TEXT NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    PRIMARY KEY (user_id, group_id),
+    FOREIGN KEY (group_id) REFERENCES tugo_groups(id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS tugo_group_permissions (
+    id TEXT PRIMARY KEY,
+    group_id TEXT NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(50) NOT NULL,
+    filter TEXT,
+    field_permissions TEXT,
+    validation TEXT,
+    presets TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (group_id, collection, action),
+    FOREIGN KEY (group_id) REFERENCES tugo_groups(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_user_groups_group_id ON tugo_user_groups(group_id);
+CREATE INDEX IF NOT EXISTS idx_tugo_group_permissions_collection ON tugo_group_permissions(collection);
@@ -0,0 +1,3 @@
+-- TuGo Pending Changes Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_pending_changes;
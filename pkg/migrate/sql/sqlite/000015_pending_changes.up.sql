@@ -0,0 +1,19 @@
+-- TuGo Pending Changes Migration (Up, SQLite)
+-- Stores collection writes awaiting approval
+
+CREATE TABLE IF NOT EXISTS tugo_pending_changes (
+    id TEXT PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    action VARCHAR(20) NOT NULL,
+    item_id VARCHAR(255),
+    data TEXT NOT NULL,
+    status VARCHAR(20) NOT NULL DEFAULT 'pending',
+    submitted_by VARCHAR(255),
+    reviewed_by VARCHAR(255),
+    review_note TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    reviewed_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_pending_changes_status ON tugo_pending_changes(status, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_pending_changes_collection ON tugo_pending_changes(collection);
@@ -0,0 +1,3 @@
+-- TuGo Comments Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_comments;
@@ -0,0 +1,18 @@
+-- TuGo Comments Migration (Up, SQLite)
+-- Stores threaded comments on collection records
+
+CREATE TABLE IF NOT EXISTS tugo_comments (
+    id TEXT PRIMARY KEY,
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    parent_id TEXT,
+    user_id VARCHAR(255),
+    body TEXT NOT NULL,
+    mentions TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (parent_id) REFERENCES tugo_comments(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_comments_item ON tugo_comments(collection, item_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_comments_parent ON tugo_comments(parent_id);
@@ -0,0 +1,19 @@
+-- TuGo Notifications Migration (Up, SQLite)
+-- Stores in-app notifications for users
+
+CREATE TABLE IF NOT EXISTS tugo_notifications (
+    id TEXT PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    type VARCHAR(100) NOT NULL,
+    title VARCHAR(500) NOT NULL,
+    body TEXT NOT NULL DEFAULT '',
+    collection VARCHAR(255),
+    item_id VARCHAR(255),
+    actor_id VARCHAR(255),
+    data TEXT,
+    read_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_notifications_user ON tugo_notifications(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_notifications_unread ON tugo_notifications(user_id, read_at);
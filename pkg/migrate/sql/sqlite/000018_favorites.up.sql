@@ -0,0 +1,14 @@
+-- TuGo Favorites Migration (Up, SQLite)
+-- Stores the collection items users starred
+
+CREATE TABLE IF NOT EXISTS tugo_favorites (
+    id TEXT PRIMARY KEY,
+    user_id VARCHAR(255) NOT NULL,
+    collection VARCHAR(255) NOT NULL,
+    item_id VARCHAR(255) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    UNIQUE (user_id, collection, item_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_tugo_favorites_user ON tugo_favorites(user_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_tugo_favorites_item ON tugo_favorites(collection, item_id);
@@ -0,0 +1,3 @@
+-- TuGo Usage Timeouts Migration (Down, SQLite)
+
+ALTER TABLE tugo_usage DROP COLUMN timeouts;
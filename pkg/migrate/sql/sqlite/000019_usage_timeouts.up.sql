@@ -0,0 +1,4 @@
+-- TuGo Usage Timeouts Migration (Up, SQLite)
+-- Counts requests that exceeded their deadline
+
+ALTER TABLE tugo_usage ADD COLUMN timeouts INTEGER NOT NULL DEFAULT 0;
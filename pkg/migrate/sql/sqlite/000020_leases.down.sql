@@ -0,0 +1,3 @@
+-- TuGo Leases Migration (Down, SQLite)
+
+DROP TABLE IF EXISTS tugo_leases;
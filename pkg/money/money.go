@@ -0,0 +1,98 @@
+// Package money converts amounts of money between major units, such as
+// 12.50, and minor units, such as 1250, without floating point arithmetic.
+package money
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// currencyDigits holds the number of minor unit digits of ISO 4217
+// currencies that do not use two.
+var currencyDigits = map[string]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0, "KRW": 0, "PYG": 0,
+	"RWF": 0, "UGX": 0, "UYI": 0, "VND": 0, "VUV": 0, "XAF": 0, "XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+	"CLF": 4, "UYW": 4,
+}
+
+// twoDigitCurrencies lists the ISO 4217 currencies with two minor unit digits.
+const twoDigitCurrencies = "AED AFN ALL AMD ANG AOA ARS AUD AWG AZN BAM BBD BDT BGN BMD BND BOB BOV BRL BSD " +
+	"BTN BWP BYN BZD CAD CDF CHE CHF CHW CNY COP COU CRC CUC CUP CVE CZK DKK DOP DZD EGP ERN ETB EUR FJD " +
+	"FKP GBP GEL GHS GIP GMD GTQ GYD HKD HNL HTG HUF IDR ILS INR IRR JMD KES KGS KHR KPW KYD KZT LAK LBP " +
+	"LKR LRD LSL MAD MDL MGA MKD MMK MNT MOP MRU MUR MVR MWK MXN MXV MYR MZN NAD NGN NIO NOK NPR NZD PAB " +
+	"PEN PGK PHP PKR PLN QAR RON RSD RUB SAR SBD SCR SDG SEK SGD SHP SLE SLL SOS SRD SSP STN SVC SYP SZL " +
+	"THB TJS TMT TOP TRY TTD TWD TZS UAH USD USN UYU UZS VED VES WST XCD XCG YER ZAR ZMW ZWG ZWL"
+
+func init() {
+	for _, code := range strings.Fields(twoDigitCurrencies) {
+		currencyDigits[code] = 2
+	}
+}
+
+// Digits returns the number of minor unit digits of an ISO 4217 currency
+// code, and whether the code is known. Codes are upper case.
+func Digits(currency string) (int, bool) {
+	digits, ok := currencyDigits[currency]
+	return digits, ok
+}
+
+// ParseMajor parses an amount in major units, such as "12.5", to minor
+// units of a currency with the given digits. Amounts finer than the minor
+// unit are rejected rather than rounded.
+func ParseMajor(amount string, digits int) (*big.Int, error) {
+	amount = strings.TrimSpace(amount)
+	r, ok := new(big.Rat).SetString(amount)
+	if !ok || !isPlainDecimal(amount) {
+		return nil, fmt.Errorf("'%s' is not a decimal amount", amount)
+	}
+	r.Mul(r, new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(digits)), nil)))
+	if !r.IsInt() {
+		return nil, fmt.Errorf("'%s' has more than %d decimal places", amount, digits)
+	}
+	return new(big.Int).Set(r.Num()), nil
+}
+
+// ParseMinor parses an amount in minor units, such as "1250".
+func ParseMinor(amount string) (*big.Int, error) {
+	minor, ok := new(big.Int).SetString(strings.TrimSpace(amount), 10)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a whole number of minor units", amount)
+	}
+	return minor, nil
+}
+
+// FormatMajor writes an amount in minor units in major units with exactly
+// the given digits, as "12.50" for 1250 and two digits.
+func FormatMajor(minor *big.Int, digits int) string {
+	s := new(big.Int).Abs(minor).String()
+	if digits > 0 {
+		if len(s) <= digits {
+			s = strings.Repeat("0", digits-len(s)+1) + s
+		}
+		s = s[:len(s)-digits] + "." + s[len(s)-digits:]
+	}
+	if minor.Sign() < 0 {
+		s = "-" + s
+	}
+	return s
+}
+
+// isPlainDecimal reports whether s is an optionally signed decimal without
+// an exponent, such as "-12.50" or ".5".
+func isPlainDecimal(s string) bool {
+	s = strings.TrimLeft(s, "+-")
+	digits, dot := 0, false
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			digits++
+		case c == '.' && !dot:
+			dot = true
+		default:
+			return false
+		}
+	}
+	return digits > 0
+}
@@ -0,0 +1,84 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestDigits(t *testing.T) {
+	tests := []struct {
+		currency string
+		want     int
+		wantOK   bool
+	}{
+		{"USD", 2, true},
+		{"JPY", 0, true},
+		{"KWD", 3, true},
+		{"CLF", 4, true},
+		{"usd", 0, false},
+		{"XYZ", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.currency, func(t *testing.T) {
+			got, ok := Digits(tt.currency)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Digits(%q) = %d, %v, want %d, %v", tt.currency, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestParseMajor(t *testing.T) {
+	tests := []struct {
+		amount  string
+		digits  int
+		want    string
+		wantErr bool
+	}{
+		{"12.5", 2, "1250", false},
+		{"12.50", 2, "1250", false},
+		{"12.5000", 2, "1250", false},
+		{"-0.01", 2, "-1", false},
+		{".5", 2, "50", false},
+		{"1500", 0, "1500", false},
+		{"1.234", 3, "1234", false},
+		{"12.345", 2, "", true},
+		{"1e3", 2, "", true},
+		{"abc", 2, "", true},
+		{"", 2, "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount, func(t *testing.T) {
+			got, err := ParseMajor(tt.amount, tt.digits)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseMajor(%q) error = %v, wantErr %v", tt.amount, err, tt.wantErr)
+			}
+			if err == nil && got.String() != tt.want {
+				t.Errorf("ParseMajor(%q) = %s, want %s", tt.amount, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatMajor(t *testing.T) {
+	tests := []struct {
+		minor  int64
+		digits int
+		want   string
+	}{
+		{1250, 2, "12.50"},
+		{5, 2, "0.05"},
+		{-5, 2, "-0.05"},
+		{0, 2, "0.00"},
+		{1500, 0, "1500"},
+		{1234, 3, "1.234"},
+	}
+
+	for _, tt := range tests {
+		if got := FormatMajor(big.NewInt(tt.minor), tt.digits); got != tt.want {
+			t.Errorf("FormatMajor(%d, %d) = %q, want %q", tt.minor, tt.digits, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+// Package notify sends templated email through pluggable mailers, for
+// record notifications and host code such as password reset flows.
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"mime"
+	"mime/quotedprintable"
+	"strings"
+	"time"
+)
+
+// Message is an email ready to send. At least one of Text and HTML is set.
+type Message struct {
+	From    string
+	To      []string
+	Subject string
+	Text    string
+	HTML    string
+}
+
+// Mailer delivers messages.
+type Mailer interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// validate checks that a message can be delivered.
+func (m *Message) validate() error {
+	if m.From == "" {
+		return fmt.Errorf("message has no sender")
+	}
+	if len(m.To) == 0 {
+		return fmt.Errorf("message has no recipients")
+	}
+	if m.Text == "" && m.HTML == "" {
+		return fmt.Errorf("message has no body")
+	}
+	return nil
+}
+
+// mimeBytes encodes the message as a MIME document, with a
+// multipart/alternative body when it has both text and HTML.
+func (m *Message) mimeBytes(now time.Time) []byte {
+	var buf bytes.Buffer
+	header := func(name, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", name, value)
+	}
+	header("From", m.From)
+	header("To", strings.Join(m.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header("Date", now.Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+
+	part := func(contentType, body string) {
+		header("Content-Type", contentType+"; charset=utf-8")
+		header("Content-Transfer-Encoding", "quoted-printable")
+		buf.WriteString("\r\n")
+		w := quotedprintable.NewWriter(&buf)
+		w.Write([]byte(body))
+		w.Close()
+		buf.WriteString("\r\n")
+	}
+
+	switch {
+	case m.Text != "" && m.HTML != "":
+		boundary := newBoundary()
+		header("Content-Type", fmt.Sprintf("multipart/alternative; boundary=%q", boundary))
+		buf.WriteString("\r\n")
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		part("text/plain", m.Text)
+		fmt.Fprintf(&buf, "--%s\r\n", boundary)
+		part("text/html", m.HTML)
+		fmt.Fprintf(&buf, "--%s--\r\n", boundary)
+	case m.HTML != "":
+		part("text/html", m.HTML)
+	default:
+		part("text/plain", m.Text)
+	}
+	return buf.Bytes()
+}
+
+// newBoundary returns a random MIME boundary.
+func newBoundary() string {
+	b := make([]byte, 12)
+	rand.Read(b)
+	return "tugo-" + hex.EncodeToString(b)
+}
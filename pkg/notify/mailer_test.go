@@ -0,0 +1,126 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testMessage() *Message {
+	return &Message{
+		From:    "app@example.com",
+		To:      []string{"ann@example.com"},
+		Subject: "Héllo",
+		Text:    "plain",
+		HTML:    "<b>rich</b>",
+	}
+}
+
+func TestMIMEBytes(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		html  string
+		wants []string
+	}{
+		{"alternative", "plain", "<b>rich</b>", []string{"multipart/alternative", "text/plain", "text/html", "plain", "<b>rich</b>"}},
+		{"text only", "plain", "", []string{"Content-Type: text/plain; charset=utf-8", "plain"}},
+		{"html only", "", "<b>rich</b>", []string{"Content-Type: text/html; charset=utf-8", "<b>rich</b>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage()
+			msg.Text, msg.HTML = tt.text, tt.html
+			got := string(msg.mimeBytes(time.Now()))
+			for _, want := range append(tt.wants, "Subject: =?utf-8?q?H=C3=A9llo?=", "To: ann@example.com") {
+				if !strings.Contains(got, want) {
+					t.Errorf("message missing %q:\n%s", want, got)
+				}
+			}
+		})
+	}
+}
+
+func TestSendGridMailer(t *testing.T) {
+	var body map[string]any
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		json.NewDecoder(r.Body).Decode(&body)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	defer server.Close()
+
+	m, err := NewSendGridMailer(SendGridConfig{APIKey: "key", Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if body["subject"] != "Héllo" || len(body["content"].([]any)) != 2 {
+		t.Errorf("body = %v", body)
+	}
+
+	bad, _ := NewSendGridMailer(SendGridConfig{APIKey: "wrong", Endpoint: server.URL})
+	if err := bad.Send(context.Background(), testMessage()); err == nil {
+		t.Error("Send() with a rejected key succeeded")
+	}
+}
+
+func TestSESMailer(t *testing.T) {
+	var auth, path string
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		auth, path = r.Header.Get("Authorization"), r.URL.Path
+		body, _ = io.ReadAll(r.Body)
+		w.Write([]byte(`{"MessageId":"1"}`))
+	}))
+	defer server.Close()
+
+	m, err := NewSESMailer(SESConfig{Region: "eu-west-1", AccessKeyID: "AKID", SecretAccessKey: "secret", Endpoint: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send() error = %v", err)
+	}
+	if path != "/v2/email/outbound-emails" {
+		t.Errorf("path = %q", path)
+	}
+	if !strings.HasPrefix(auth, "AWS4-HMAC-SHA256 Credential=AKID/") || !strings.Contains(auth, "/eu-west-1/ses/aws4_request") {
+		t.Errorf("Authorization = %q", auth)
+	}
+	if !strings.Contains(string(body), `"ToAddresses":["ann@example.com"]`) {
+		t.Errorf("body = %s", body)
+	}
+}
+
+func TestMessageValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Message)
+	}{
+		{"no sender", func(m *Message) { m.From = "" }},
+		{"no recipients", func(m *Message) { m.To = nil }},
+		{"no body", func(m *Message) { m.Text, m.HTML = "", "" }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			msg := testMessage()
+			tt.modify(msg)
+			if err := msg.validate(); err == nil {
+				t.Error("validate() = nil, want error")
+			}
+		})
+	}
+}
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Record actions that can trigger notifications.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// DefaultSendTimeout bounds each record notification.
+const DefaultSendTimeout = 30 * time.Second
+
+// Rule sends a templated email when a collection record changes. The
+// template receives the collection name as .Collection, the action as
+// .Action and the record as .Record.
+type Rule struct {
+	// On lists the actions that trigger the rule: "create", "update" or
+	// "delete". Empty means all of them.
+	On []string
+
+	// Template names the template to render.
+	Template string
+
+	// To lists fixed recipient addresses.
+	To []string
+
+	// ToField names a record field holding a recipient address.
+	ToField string
+}
+
+// matches reports whether the rule fires for action.
+func (r Rule) matches(action string) bool {
+	return len(r.On) == 0 || slices.Contains(r.On, action)
+}
+
+// recipients returns the rule's addresses for a record.
+func (r Rule) recipients(record map[string]any) []string {
+	to := slices.Clone(r.To)
+	if r.ToField != "" {
+		if addr, ok := record[r.ToField].(string); ok && addr != "" {
+			to = append(to, addr)
+		}
+	}
+	return to
+}
+
+// Notifier renders templates and sends them through a mailer.
+type Notifier struct {
+	mailer    Mailer
+	templates TemplateStore
+	from      string
+	logger    *zap.SugaredLogger
+
+	mu    sync.RWMutex
+	rules map[string][]Rule
+
+	// pending tracks record notifications still being sent
+	pending sync.WaitGroup
+}
+
+// NewNotifier creates a new notifier sending from the given address.
+func NewNotifier(mailer Mailer, templates TemplateStore, from string, logger *zap.SugaredLogger) *Notifier {
+	return &Notifier{
+		mailer:    mailer,
+		templates: templates,
+		from:      from,
+		logger:    logger,
+	}
+}
+
+// SetRules replaces the notification rules, keyed by collection API or
+// table name.
+func (n *Notifier) SetRules(rules map[string][]Rule) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.rules = rules
+}
+
+// Send renders a template with data and mails it to the given addresses.
+func (n *Notifier) Send(ctx context.Context, templateName string, to []string, data any) error {
+	tmpl, err := n.templates.Get(ctx, templateName)
+	if err != nil {
+		return err
+	}
+	msg, err := tmpl.Render(data)
+	if err != nil {
+		return err
+	}
+	msg.From = n.from
+	msg.To = to
+	return n.mailer.Send(ctx, msg)
+}
+
+// Watches reports whether any rule of the collection fires for action.
+func (n *Notifier) Watches(collection *schema.Collection, action string) bool {
+	for _, rule := range n.rulesFor(collection) {
+		if rule.matches(action) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyRecord sends the notifications of the collection's rules matching
+// action in the background. Failures are logged.
+func (n *Notifier) NotifyRecord(ctx context.Context, collection *schema.Collection, action string, record map[string]any) {
+	data := map[string]any{
+		"Collection": collection.Name,
+		"Action":     action,
+		"Record":     record,
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	for _, rule := range n.rulesFor(collection) {
+		to := rule.recipients(record)
+		if !rule.matches(action) || len(to) == 0 {
+			continue
+		}
+		n.pending.Add(1)
+		go func(rule Rule) {
+			defer n.pending.Done()
+			ctx, cancel := context.WithTimeout(ctx, DefaultSendTimeout)
+			defer cancel()
+			if err := n.Send(ctx, rule.Template, to, data); err != nil {
+				n.logger.Errorw("Failed to send record notification",
+					"collection", collection.Name, "action", action, "template", rule.Template, "error", err)
+			}
+		}(rule)
+	}
+}
+
+// Wait blocks until the record notifications in flight are sent.
+func (n *Notifier) Wait() {
+	n.pending.Wait()
+}
+
+// rulesFor returns the rules configured for a collection.
+func (n *Notifier) rulesFor(collection *schema.Collection) []Rule {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	if rules, ok := n.rules[collection.Name]; ok {
+		return rules
+	}
+	return n.rules[collection.TableName]
+}
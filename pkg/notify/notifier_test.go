@@ -0,0 +1,147 @@
+package notify
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+type memoryStore map[string]*Template
+
+func (s memoryStore) Get(ctx context.Context, name string) (*Template, error) {
+	return s[name], nil
+}
+
+type recordingMailer struct {
+	mu   sync.Mutex
+	sent []*Message
+}
+
+func (m *recordingMailer) Send(ctx context.Context, msg *Message) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, msg)
+	return nil
+}
+
+func TestTemplateRender(t *testing.T) {
+	tmpl := &Template{
+		Name:    "welcome",
+		Subject: "  Welcome, {{.Name}}\n",
+		Text:    "Hi {{.Name}}",
+		HTML:    "<p>Hi {{.Name}}</p>",
+	}
+
+	msg, err := tmpl.Render(map[string]any{"Name": "<Ann>"})
+	if err != nil {
+		t.Fatalf("Render() error = %v", err)
+	}
+	if msg.Subject != "Welcome, <Ann>" {
+		t.Errorf("Subject = %q", msg.Subject)
+	}
+	if msg.Text != "Hi <Ann>" {
+		t.Errorf("Text = %q", msg.Text)
+	}
+	if msg.HTML != "<p>Hi &lt;Ann&gt;</p>" {
+		t.Errorf("HTML = %q", msg.HTML)
+	}
+}
+
+func TestDirStore(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "reset.subject.tmpl"), []byte("Reset"), 0o644)
+	os.WriteFile(filepath.Join(dir, "reset.txt.tmpl"), []byte("Code {{.Code}}"), 0o644)
+	store := NewDirStore(dir)
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		wantErr bool
+	}{
+		{"found", "reset", false},
+		{"missing", "welcome", true},
+		{"path traversal", "../reset", true},
+		{"hidden", ".reset", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := store.Get(context.Background(), tt.tmpl)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Get() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && (got.Subject != "Reset" || got.Text != "Code {{.Code}}" || got.HTML != "") {
+				t.Errorf("Get() = %+v", got)
+			}
+		})
+	}
+}
+
+func TestNotifyRecord(t *testing.T) {
+	collection := &schema.Collection{Name: "orders", TableName: "api_orders"}
+	templates := memoryStore{"order": {Name: "order", Subject: "Order {{.Action}}", Text: "{{.Record.id}}"}}
+
+	tests := []struct {
+		name   string
+		rules  map[string][]Rule
+		action string
+		want   [][]string
+	}{
+		{
+			name:   "matching action",
+			rules:  map[string][]Rule{"orders": {{On: []string{ActionCreate}, Template: "order", To: []string{"ops@example.com"}}}},
+			action: ActionCreate,
+			want:   [][]string{{"ops@example.com"}},
+		},
+		{
+			name:   "other action",
+			rules:  map[string][]Rule{"orders": {{On: []string{ActionDelete}, Template: "order", To: []string{"ops@example.com"}}}},
+			action: ActionCreate,
+		},
+		{
+			name:   "recipient field",
+			rules:  map[string][]Rule{"orders": {{Template: "order", ToField: "email"}}},
+			action: ActionUpdate,
+			want:   [][]string{{"buyer@example.com"}},
+		},
+		{
+			name:   "table name key",
+			rules:  map[string][]Rule{"api_orders": {{Template: "order", To: []string{"ops@example.com"}, ToField: "email"}}},
+			action: ActionUpdate,
+			want:   [][]string{{"ops@example.com", "buyer@example.com"}},
+		},
+		{
+			name:   "no recipients",
+			rules:  map[string][]Rule{"orders": {{Template: "order", ToField: "missing"}}},
+			action: ActionCreate,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mailer := &recordingMailer{}
+			n := NewNotifier(mailer, templates, "app@example.com", zap.NewNop().Sugar())
+			n.SetRules(tt.rules)
+
+			n.NotifyRecord(context.Background(), collection, tt.action, map[string]any{"id": 7, "email": "buyer@example.com"})
+			n.Wait()
+
+			var got [][]string
+			for _, msg := range mailer.sent {
+				if msg.From != "app@example.com" || msg.Subject != "Order "+tt.action || msg.Text != "7" {
+					t.Errorf("sent %+v", msg)
+				}
+				got = append(got, msg.To)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("recipients = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
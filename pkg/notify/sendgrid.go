@@ -0,0 +1,112 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// DefaultSendGridEndpoint is the SendGrid v3 mail send URL.
+const DefaultSendGridEndpoint = "https://api.sendgrid.com/v3/mail/send"
+
+// SendGridConfig holds configuration for a SendGrid mailer.
+type SendGridConfig struct {
+	// APIKey is a SendGrid API key with mail send access.
+	APIKey string
+
+	// Endpoint overrides the mail send URL.
+	// Default: DefaultSendGridEndpoint
+	Endpoint string
+
+	// Client is the HTTP client used for requests.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+// SendGridMailer sends messages through the SendGrid web API.
+type SendGridMailer struct {
+	apiKey   string
+	endpoint string
+	client   *http.Client
+}
+
+// NewSendGridMailer creates a new SendGrid mailer.
+func NewSendGridMailer(cfg SendGridConfig) (*SendGridMailer, error) {
+	if cfg.APIKey == "" {
+		return nil, fmt.Errorf("SendGrid API key is required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = DefaultSendGridEndpoint
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &SendGridMailer{apiKey: cfg.APIKey, endpoint: cfg.Endpoint, client: cfg.Client}, nil
+}
+
+// sendGridAddress is an address in a SendGrid request.
+type sendGridAddress struct {
+	Email string `json:"email"`
+}
+
+// sendGridContent is a body part in a SendGrid request.
+type sendGridContent struct {
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// Send delivers a message.
+func (m *SendGridMailer) Send(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	to := make([]sendGridAddress, len(msg.To))
+	for i, addr := range msg.To {
+		to[i] = sendGridAddress{Email: addr}
+	}
+	var content []sendGridContent
+	if msg.Text != "" {
+		content = append(content, sendGridContent{Type: "text/plain", Value: msg.Text})
+	}
+	if msg.HTML != "" {
+		content = append(content, sendGridContent{Type: "text/html", Value: msg.HTML})
+	}
+	body, err := json.Marshal(map[string]any{
+		"personalizations": []map[string]any{{"to": to}},
+		"from":             sendGridAddress{Email: msg.From},
+		"subject":          msg.Subject,
+		"content":          content,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode mail: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	req.Header.Set("Content-Type", "application/json")
+	return doMailRequest(m.client, req, "SendGrid")
+}
+
+// doMailRequest sends a mail API request and turns a non-2xx response into
+// an error carrying the start of the response body.
+func doMailRequest(client *http.Client, req *http.Request, service string) error {
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send mail via %s: %w", service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("%s rejected mail with status %d: %s", service, resp.StatusCode, bytes.TrimSpace(detail))
+	}
+	io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -0,0 +1,155 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// SESConfig holds configuration for an Amazon SES mailer.
+type SESConfig struct {
+	// Region is the AWS region of the SES endpoint, such as "us-east-1".
+	Region string
+
+	// AccessKeyID, SecretAccessKey and SessionToken are the AWS credentials.
+	// SessionToken is only needed for temporary credentials.
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+
+	// Endpoint overrides the SES API base URL.
+	// Default: https://email.<Region>.amazonaws.com
+	Endpoint string
+
+	// Client is the HTTP client used for requests.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+// SESMailer sends messages through the Amazon SES v2 API.
+type SESMailer struct {
+	config SESConfig
+}
+
+// NewSESMailer creates a new SES mailer.
+func NewSESMailer(cfg SESConfig) (*SESMailer, error) {
+	if cfg.Region == "" {
+		return nil, fmt.Errorf("SES region is required")
+	}
+	if cfg.AccessKeyID == "" || cfg.SecretAccessKey == "" {
+		return nil, fmt.Errorf("SES credentials are required")
+	}
+	if cfg.Endpoint == "" {
+		cfg.Endpoint = fmt.Sprintf("https://email.%s.amazonaws.com", cfg.Region)
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &SESMailer{config: cfg}, nil
+}
+
+// Send delivers a message.
+func (m *SESMailer) Send(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	content := map[string]any{}
+	if msg.Text != "" {
+		content["Text"] = map[string]string{"Data": msg.Text, "Charset": "UTF-8"}
+	}
+	if msg.HTML != "" {
+		content["Html"] = map[string]string{"Data": msg.HTML, "Charset": "UTF-8"}
+	}
+	body, err := json.Marshal(map[string]any{
+		"FromEmailAddress": msg.From,
+		"Destination":      map[string]any{"ToAddresses": msg.To},
+		"Content": map[string]any{
+			"Simple": map[string]any{
+				"Subject": map[string]string{"Data": msg.Subject, "Charset": "UTF-8"},
+				"Body":    content,
+			},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode mail: %w", err)
+	}
+
+	url := strings.TrimSuffix(m.config.Endpoint, "/") + "/v2/email/outbound-emails"
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	m.sign(req, body, time.Now().UTC())
+	return doMailRequest(m.config.Client, req, "SES")
+}
+
+// sign adds an AWS Signature Version 4 Authorization header to req.
+func (m *SESMailer) sign(req *http.Request, body []byte, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	day := now.Format("20060102")
+	payloadHash := sha256Hex(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if m.config.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", m.config.SessionToken)
+	}
+
+	signed := []string{"content-type", "host", "x-amz-content-sha256", "x-amz-date"}
+	if m.config.SessionToken != "" {
+		signed = append(signed, "x-amz-security-token")
+	}
+	var headers strings.Builder
+	for _, name := range signed {
+		value := req.Header.Get(name)
+		if name == "host" {
+			value = req.URL.Host
+		}
+		headers.WriteString(name + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(signed, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	canonical := strings.Join([]string{
+		req.Method, path, req.URL.RawQuery, headers.String(), signedHeaders, payloadHash,
+	}, "\n")
+
+	scope := day + "/" + m.config.Region + "/ses/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256", amzDate, scope, sha256Hex([]byte(canonical)),
+	}, "\n")
+
+	key := hmacSHA256([]byte("AWS4"+m.config.SecretAccessKey), day)
+	key = hmacSHA256(key, m.config.Region)
+	key = hmacSHA256(key, "ses")
+	key = hmacSHA256(key, "aws4_request")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		m.config.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// sha256Hex returns the hex SHA-256 digest of b.
+func sha256Hex(b []byte) string {
+	sum := sha256.Sum256(b)
+	return hex.EncodeToString(sum[:])
+}
+
+// hmacSHA256 returns the HMAC-SHA256 of data under key.
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
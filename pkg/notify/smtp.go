@@ -0,0 +1,71 @@
+package notify
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// DefaultSMTPPort is the submission port used when SMTPConfig.Port is zero.
+const DefaultSMTPPort = 587
+
+// SMTPConfig holds configuration for an SMTP mailer.
+type SMTPConfig struct {
+	// Host is the SMTP server host name.
+	Host string
+
+	// Port is the SMTP server port.
+	// Default: 587
+	Port int
+
+	// Username and Password enable PLAIN authentication when set.
+	Username string
+	Password string
+}
+
+// SMTPMailer sends messages through an SMTP server, upgrading to TLS when
+// the server offers STARTTLS.
+type SMTPMailer struct {
+	addr string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer creates a new SMTP mailer.
+func NewSMTPMailer(cfg SMTPConfig) (*SMTPMailer, error) {
+	if cfg.Host == "" {
+		return nil, fmt.Errorf("SMTP host is required")
+	}
+	if cfg.Port == 0 {
+		cfg.Port = DefaultSMTPPort
+	}
+
+	m := &SMTPMailer{addr: net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port))}
+	if cfg.Username != "" {
+		m.auth = smtp.PlainAuth("", cfg.Username, cfg.Password, cfg.Host)
+	}
+	return m, nil
+}
+
+// Send delivers a message. The context bounds the whole exchange.
+func (m *SMTPMailer) Send(ctx context.Context, msg *Message) error {
+	if err := msg.validate(); err != nil {
+		return err
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(m.addr, m.auth, msg.From, msg.To, msg.mimeBytes(time.Now()))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("failed to send mail: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,143 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Template holds the Go template sources of an email. Subject and Text use
+// text/template; HTML uses html/template so data is escaped.
+type Template struct {
+	Name    string `db:"name"`
+	Subject string `db:"subject"`
+	Text    string `db:"text_body"`
+	HTML    string `db:"html_body"`
+}
+
+// TemplateStore looks up templates by name.
+type TemplateStore interface {
+	Get(ctx context.Context, name string) (*Template, error)
+}
+
+// Render executes the template with data.
+func (t *Template) Render(data any) (*Message, error) {
+	msg := &Message{}
+	var err error
+	if msg.Subject, err = executeText(t.Name+".subject", t.Subject, data); err != nil {
+		return nil, err
+	}
+	msg.Subject = strings.TrimSpace(msg.Subject)
+	if msg.Text, err = executeText(t.Name+".txt", t.Text, data); err != nil {
+		return nil, err
+	}
+	if t.HTML != "" {
+		tmpl, err := htmltemplate.New(t.Name + ".html").Option("missingkey=zero").Parse(t.HTML)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse template %s: %w", t.Name, err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, data); err != nil {
+			return nil, fmt.Errorf("failed to render template %s: %w", t.Name, err)
+		}
+		msg.HTML = buf.String()
+	}
+	return msg, nil
+}
+
+// executeText executes a text/template source, returning "" for an empty one.
+func executeText(name, source string, data any) (string, error) {
+	if source == "" {
+		return "", nil
+	}
+	tmpl, err := template.New(name).Option("missingkey=zero").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.String(), nil
+}
+
+// DirStore reads templates from a directory. A template named "welcome" is
+// made of welcome.subject.tmpl, welcome.txt.tmpl and welcome.html.tmpl, of
+// which the body files are optional as long as one exists. Files are read
+// on every lookup, so edits apply without a restart.
+type DirStore struct {
+	dir string
+}
+
+// NewDirStore creates a template store reading from dir.
+func NewDirStore(dir string) *DirStore {
+	return &DirStore{dir: dir}
+}
+
+// Get reads a template by name.
+func (s *DirStore) Get(ctx context.Context, name string) (*Template, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || strings.HasPrefix(name, ".") {
+		return nil, apperror.ErrBadRequest.WithMessagef("Invalid template name '%s'", name)
+	}
+
+	t := &Template{Name: name}
+	parts := []struct {
+		suffix string
+		dst    *string
+	}{
+		{".subject.tmpl", &t.Subject},
+		{".txt.tmpl", &t.Text},
+		{".html.tmpl", &t.HTML},
+	}
+	for _, part := range parts {
+		b, err := os.ReadFile(filepath.Join(s.dir, name+part.suffix))
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read template %s: %w", name, err)
+		}
+		*part.dst = string(b)
+	}
+	if t.Text == "" && t.HTML == "" {
+		return nil, apperror.ErrNotFound.WithMessagef("Template '%s' not found", name)
+	}
+	return t, nil
+}
+
+// DBStore reads templates from the tugo_templates table.
+type DBStore struct {
+	db *sqlx.DB
+}
+
+// NewDBStore creates a template store backed by tugo_templates.
+func NewDBStore(db *sqlx.DB) *DBStore {
+	return &DBStore{db: db}
+}
+
+// Get reads a template by name.
+func (s *DBStore) Get(ctx context.Context, name string) (*Template, error) {
+	query := `
+		SELECT name, subject, text_body, html_body
+		FROM tugo_templates
+		WHERE name = ?
+	`
+	var t Template
+	if err := s.db.GetContext(ctx, &t, s.db.Rebind(query), name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Template '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to get template: %w", err)
+	}
+	return &t, nil
+}
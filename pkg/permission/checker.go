@@ -3,40 +3,56 @@ package permission
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"sync"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/jmoiron/sqlx"
 	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/cache"
 	"go.uber.org/zap"
 )
 
+// policyGenerationKey holds the generation of cached policies; clearing the
+// cache starts a new generation, so instances sharing a store all see it.
+const policyGenerationKey = "policy_gen"
+
 // Checker handles permission checking for collections.
 type Checker struct {
-	db     *sqlx.DB
-	store  *PolicyStore
-	logger *zap.SugaredLogger
-	cache  *policyCache
-}
-
-// policyCache caches policies by role ID.
-type policyCache struct {
-	mu       sync.RWMutex
-	policies map[string][]Policy // roleID -> policies
+	db       *sqlx.DB
+	store    *PolicyStore
+	groups   *GroupStore
+	logger   *zap.SugaredLogger
+	cache    cache.Store
+	cacheTTL time.Duration
 }
 
 // NewChecker creates a new permission checker.
+// Policies are cached in process memory until SetCache is called.
 func NewChecker(db *sqlx.DB, logger *zap.SugaredLogger) *Checker {
 	return &Checker{
 		db:     db,
 		store:  NewPolicyStore(db),
+		groups: NewGroupStore(db),
 		logger: logger,
-		cache: &policyCache{
-			policies: make(map[string][]Policy),
-		},
+		cache:  cache.NewMemoryStore(0),
 	}
 }
 
+// SetCache sets the store caching policies loaded with LoadRolePolicies.
+// A ttl of zero keeps them until ClearCache is called.
+func (c *Checker) SetCache(store cache.Store, ttl time.Duration) {
+	c.cache = store
+	c.cacheTTL = ttl
+}
+
+// Groups returns the store of user groups and their policies.
+func (c *Checker) Groups() *GroupStore {
+	return c.groups
+}
+
 // CheckResult contains the result of a permission check.
 type CheckResult struct {
 	Allowed    bool
@@ -47,6 +63,9 @@ type CheckResult struct {
 }
 
 // Check checks if a user has permission to perform an action on a collection.
+// The policies of the user's role and groups are combined: any of them
+// grants the action, and the user sees the rows and fields any of them
+// allows.
 func (c *Checker) Check(ctx context.Context, user *auth.User, collection string, action Action) (*CheckResult, error) {
 	if user == nil {
 		return &CheckResult{
@@ -75,32 +94,202 @@ func (c *Checker) Check(ctx context.Context, user *auth.User, collection string,
 		if err != nil {
 			return nil, fmt.Errorf("failed to get wildcard policy: %w", err)
 		}
-
-		if policy == nil {
-			return &CheckResult{
-				Allowed: false,
-				Reason:  fmt.Sprintf("no permission for %s on %s", action, collection),
-			}, nil
-		}
 	}
 
-	// Parse policy
-	parsed, err := ParsePolicy(policy)
+	var policies []Policy
+	if policy != nil {
+		policies = append(policies, *policy)
+	}
+	groupPolicies, err := c.getGroupPolicies(ctx, user.ID, collection, action)
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse policy: %w", err)
+		return nil, fmt.Errorf("failed to get group policies: %w", err)
+	}
+	policies = append(policies, groupPolicies...)
+
+	if len(policies) == 0 {
+		return &CheckResult{
+			Allowed: false,
+			Reason:  fmt.Sprintf("no permission for %s on %s", action, collection),
+		}, nil
 	}
 
+	// Parse policies
+	parsed := make([]*ParsedPolicy, len(policies))
+	for i := range policies {
+		parsed[i], err = ParsePolicy(&policies[i])
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse policy: %w", err)
+		}
+	}
+	merged := mergePolicies(parsed)
+
 	// Resolve filter variables
-	resolvedFilter := c.resolveFilterVariables(parsed.FilterMap, user)
+	resolvedFilter := c.resolveFilterVariables(merged.FilterMap, user, requestClaims(ctx))
 
 	return &CheckResult{
 		Allowed:    true,
 		Filter:     resolvedFilter,
-		FieldPerms: parsed.FieldPermissionsMap,
-		Presets:    parsed.PresetsMap,
+		FieldPerms: merged.FieldPermissionsMap,
+		Presets:    merged.PresetsMap,
 	}, nil
 }
 
+// getGroupPolicies returns the policies of a user's groups for a collection
+// and action, falling back to each group's wildcard policy.
+func (c *Checker) getGroupPolicies(ctx context.Context, userID, collection string, action Action) ([]Policy, error) {
+	if userID == "" {
+		return nil, nil
+	}
+	policies, err := c.groups.UserPolicies(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	exact := make(map[string]Policy)
+	wildcard := make(map[string]Policy)
+	for _, p := range policies {
+		if p.Action != action {
+			continue
+		}
+		switch p.Collection {
+		case collection:
+			exact[p.GroupID] = p
+		case "*":
+			wildcard[p.GroupID] = p
+		}
+	}
+	for groupID, p := range wildcard {
+		if _, ok := exact[groupID]; !ok {
+			exact[groupID] = p
+		}
+	}
+
+	result := make([]Policy, 0, len(exact))
+	for _, p := range policies {
+		if match, ok := exact[p.GroupID]; ok && match.ID == p.ID {
+			result = append(result, p)
+		}
+	}
+	return result, nil
+}
+
+// mergePolicies combines policies granting the same action. Rows any filter
+// matches are visible, unfiltered policies lifting the filter. Fields any
+// policy permits are permitted, and read-only when every policy permitting
+// them marks them so. Presets of earlier policies win.
+func mergePolicies(policies []*ParsedPolicy) *ParsedPolicy {
+	if len(policies) == 1 {
+		return policies[0]
+	}
+
+	merged := &ParsedPolicy{}
+	var filters []any
+	unfiltered := false
+	for _, p := range policies {
+		if len(p.FilterMap) == 0 {
+			unfiltered = true
+			continue
+		}
+		filters = append(filters, p.FilterMap)
+	}
+	switch {
+	case unfiltered:
+	case len(filters) == 1:
+		merged.FilterMap = filters[0].(map[string]any)
+	default:
+		merged.FilterMap = map[string]any{"_or": filters}
+	}
+
+	merged.FieldPermissionsMap = mergeFieldPermissions(policies)
+
+	for _, p := range policies {
+		for key, value := range p.PresetsMap {
+			if _, ok := merged.PresetsMap[key]; ok {
+				continue
+			}
+			if merged.PresetsMap == nil {
+				merged.PresetsMap = make(map[string]any)
+			}
+			merged.PresetsMap[key] = value
+		}
+	}
+	return merged
+}
+
+// mergeFieldPermissions returns the field permissions permitting the fields
+// any of policies permits.
+func mergeFieldPermissions(policies []*ParsedPolicy) FieldPermissions {
+	var merged FieldPermissions
+	permitted := func(field string) bool {
+		for _, p := range policies {
+			if permitsField(p.FieldPermissionsMap, field) {
+				return true
+			}
+		}
+		return false
+	}
+
+	restricted := true
+	for _, p := range policies {
+		if len(p.FieldPermissionsMap.Allowed) == 0 {
+			restricted = false
+		}
+	}
+
+	seen := make(map[string]bool)
+	for _, p := range policies {
+		perms := p.FieldPermissionsMap
+		if restricted {
+			for _, field := range perms.Allowed {
+				if !seen[field] && permitted(field) {
+					merged.Allowed = append(merged.Allowed, field)
+				}
+				seen[field] = true
+			}
+		} else {
+			for _, field := range perms.Denied {
+				if !seen[field] && !permitted(field) {
+					merged.Denied = append(merged.Denied, field)
+				}
+				seen[field] = true
+			}
+		}
+	}
+
+	seen = make(map[string]bool)
+	for _, p := range policies {
+		for _, field := range p.FieldPermissionsMap.ReadOnly {
+			if seen[field] {
+				continue
+			}
+			seen[field] = true
+			readOnly := true
+			for _, other := range policies {
+				if permitsField(other.FieldPermissionsMap, field) && !contains(other.FieldPermissionsMap.ReadOnly, field) {
+					readOnly = false
+				}
+			}
+			if readOnly {
+				merged.ReadOnly = append(merged.ReadOnly, field)
+			}
+		}
+	}
+	return merged
+}
+
+// permitsField reports whether field permissions permit reading a field.
+func permitsField(perms FieldPermissions, field string) bool {
+	if contains(perms.Denied, field) {
+		return false
+	}
+	return len(perms.Allowed) == 0 || contains(perms.Allowed, field)
+}
+
+// Permits reports whether the field permissions permit reading field.
+func (p FieldPermissions) Permits(field string) bool {
+	return permitsField(p, field)
+}
+
 // CheckWithData checks permission and validates data against policy.
 func (c *Checker) CheckWithData(ctx context.Context, user *auth.User, collection string, action Action, data map[string]any) (*CheckResult, error) {
 	result, err := c.Check(ctx, user, collection, action)
@@ -125,7 +314,7 @@ func (c *Checker) CheckWithData(ctx context.Context, user *auth.User, collection
 		for key, value := range result.Presets {
 			// Only apply preset if field is not provided
 			if _, exists := data[key]; !exists {
-				data[key] = c.resolvePresetValue(value, user)
+				data[key] = c.resolvePresetValue(value, user, requestClaims(ctx))
 			}
 		}
 	}
@@ -166,11 +355,7 @@ func (c *Checker) FilterAllowedFields(data map[string]any, perms FieldPermission
 // getPolicy retrieves a policy from cache or database.
 func (c *Checker) getPolicy(ctx context.Context, roleID, collection string, action Action) (*Policy, error) {
 	// Try to get from cache first
-	c.cache.mu.RLock()
-	policies, ok := c.cache.policies[roleID]
-	c.cache.mu.RUnlock()
-
-	if ok {
+	if policies, ok := c.cachedPolicies(ctx, roleID); ok {
 		for i := range policies {
 			if policies[i].Collection == collection && policies[i].Action == action {
 				return &policies[i], nil
@@ -195,18 +380,53 @@ func (c *Checker) LoadRolePolicies(ctx context.Context, roleID string) error {
 		return err
 	}
 
-	c.cache.mu.Lock()
-	c.cache.policies[roleID] = policies
-	c.cache.mu.Unlock()
-
-	return nil
+	data, err := json.Marshal(policies)
+	if err != nil {
+		return err
+	}
+	return c.cache.Set(ctx, c.policyKey(ctx, roleID), data, c.cacheTTL)
 }
 
 // ClearCache clears the policy cache.
 func (c *Checker) ClearCache() {
-	c.cache.mu.Lock()
-	c.cache.policies = make(map[string][]Policy)
-	c.cache.mu.Unlock()
+	if err := ResetPolicyCache(context.Background(), c.cache); err != nil {
+		c.logger.Warnw("Failed to clear policy cache", "error", err)
+	}
+}
+
+// ResetPolicyCache drops the policies cached in store by every checker
+// using it.
+func ResetPolicyCache(ctx context.Context, store cache.Store) error {
+	gen := strconv.FormatInt(time.Now().UnixNano(), 10)
+	return store.Set(ctx, policyGenerationKey, []byte(gen), 0)
+}
+
+// policyKey returns the cache key of a role's policies in the current generation.
+func (c *Checker) policyKey(ctx context.Context, roleID string) string {
+	gen, err := c.cache.Get(ctx, policyGenerationKey)
+	if err != nil {
+		gen = []byte("0")
+	}
+	return "policy:" + string(gen) + ":" + roleID
+}
+
+// cachedPolicies returns the cached policies of a role. Cache failures are
+// logged and treated as misses.
+func (c *Checker) cachedPolicies(ctx context.Context, roleID string) ([]Policy, bool) {
+	data, err := c.cache.Get(ctx, c.policyKey(ctx, roleID))
+	if err != nil {
+		if !errors.Is(err, cache.ErrMiss) {
+			c.logger.Warnw("Failed to read policy cache", "role_id", roleID, "error", err)
+		}
+		return nil, false
+	}
+
+	var policies []Policy
+	if err := json.Unmarshal(data, &policies); err != nil {
+		c.logger.Warnw("Invalid cached policies", "role_id", roleID, "error", err)
+		return nil, false
+	}
+	return policies, true
 }
 
 // checkFieldPermissions validates that data doesn't contain disallowed fields.
@@ -231,34 +451,46 @@ func (c *Checker) checkFieldPermissions(data map[string]any, perms FieldPermissi
 	return nil
 }
 
+// ClaimVariablePrefix prefixes filter variables reading custom claims, such
+// as $CLAIM.department_id.
+const ClaimVariablePrefix = "$CLAIM."
+
+// requestClaims returns the custom claims of the request in ctx.
+func requestClaims(ctx context.Context) map[string]any {
+	if claims, ok := auth.GetClaimsFromContext(ctx); ok && claims != nil {
+		return claims.Extra
+	}
+	return nil
+}
+
 // resolveFilterVariables replaces variables in filter with actual values.
-func (c *Checker) resolveFilterVariables(filter map[string]any, user *auth.User) map[string]any {
+func (c *Checker) resolveFilterVariables(filter map[string]any, user *auth.User, claims map[string]any) map[string]any {
 	if filter == nil {
 		return nil
 	}
 
 	result := make(map[string]any)
 	for key, value := range filter {
-		result[key] = c.resolveValue(value, user)
+		result[key] = c.resolveValue(value, user, claims)
 	}
 	return result
 }
 
 // resolveValue resolves a single value, handling nested maps and variables.
-func (c *Checker) resolveValue(value any, user *auth.User) any {
+func (c *Checker) resolveValue(value any, user *auth.User, claims map[string]any) any {
 	switch v := value.(type) {
 	case string:
-		return c.resolveVariable(v, user)
+		return c.resolveVariable(v, user, claims)
 	case map[string]any:
 		result := make(map[string]any)
 		for k, val := range v {
-			result[k] = c.resolveValue(val, user)
+			result[k] = c.resolveValue(val, user, claims)
 		}
 		return result
 	case []any:
 		result := make([]any, len(v))
 		for i, val := range v {
-			result[i] = c.resolveValue(val, user)
+			result[i] = c.resolveValue(val, user, claims)
 		}
 		return result
 	default:
@@ -266,8 +498,13 @@ func (c *Checker) resolveValue(value any, user *auth.User) any {
 	}
 }
 
-// resolveVariable resolves special variables.
-func (c *Checker) resolveVariable(value string, user *auth.User) any {
+// resolveVariable resolves special variables. Claims missing from the
+// request resolve to nil.
+func (c *Checker) resolveVariable(value string, user *auth.User, claims map[string]any) any {
+	if name, ok := strings.CutPrefix(value, ClaimVariablePrefix); ok {
+		return claims[name]
+	}
+
 	switch value {
 	case "$USER_ID", "$CURRENT_USER":
 		return user.ID
@@ -285,9 +522,9 @@ func (c *Checker) resolveVariable(value string, user *auth.User) any {
 }
 
 // resolvePresetValue resolves preset values.
-func (c *Checker) resolvePresetValue(value any, user *auth.User) any {
+func (c *Checker) resolvePresetValue(value any, user *auth.User, claims map[string]any) any {
 	if strVal, ok := value.(string); ok {
-		return c.resolveVariable(strVal, user)
+		return c.resolveVariable(strVal, user, claims)
 	}
 	return value
 }
@@ -0,0 +1,116 @@
+package permission
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMergePolicies(t *testing.T) {
+	own := map[string]any{"owner": map[string]any{"_eq": "$USER_ID"}}
+	public := map[string]any{"public": map[string]any{"_eq": true}}
+
+	tests := []struct {
+		name        string
+		policies    []*ParsedPolicy
+		wantFilter  map[string]any
+		wantPresets map[string]any
+	}{
+		{
+			name:       "single policy",
+			policies:   []*ParsedPolicy{{FilterMap: own}},
+			wantFilter: own,
+		},
+		{
+			name:       "filters are combined with _or",
+			policies:   []*ParsedPolicy{{FilterMap: own}, {FilterMap: public}},
+			wantFilter: map[string]any{"_or": []any{own, public}},
+		},
+		{
+			name:       "an empty filter counts as unfiltered",
+			policies:   []*ParsedPolicy{{FilterMap: own}, {FilterMap: map[string]any{}}},
+			wantFilter: nil,
+		},
+		{
+			name:       "an unfiltered policy lifts all filters",
+			policies:   []*ParsedPolicy{{FilterMap: own}, {}, {FilterMap: public}},
+			wantFilter: nil,
+		},
+		{
+			name: "presets of earlier policies win",
+			policies: []*ParsedPolicy{
+				{FilterMap: own, PresetsMap: map[string]any{"status": "draft"}},
+				{FilterMap: own, PresetsMap: map[string]any{"status": "published", "owner": "$USER_ID"}},
+			},
+			wantFilter:  map[string]any{"_or": []any{own, own}},
+			wantPresets: map[string]any{"status": "draft", "owner": "$USER_ID"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := mergePolicies(tt.policies)
+			if !reflect.DeepEqual(merged.FilterMap, tt.wantFilter) {
+				t.Errorf("FilterMap = %v, want %v", merged.FilterMap, tt.wantFilter)
+			}
+			if !reflect.DeepEqual(merged.PresetsMap, tt.wantPresets) {
+				t.Errorf("PresetsMap = %v, want %v", merged.PresetsMap, tt.wantPresets)
+			}
+		})
+	}
+}
+
+func TestMergeFieldPermissions(t *testing.T) {
+	tests := []struct {
+		name  string
+		perms []FieldPermissions
+		want  FieldPermissions
+	}{
+		{
+			name:  "allowed fields are united",
+			perms: []FieldPermissions{{Allowed: []string{"id", "title"}}, {Allowed: []string{"id", "body"}}},
+			want:  FieldPermissions{Allowed: []string{"id", "title", "body"}},
+		},
+		{
+			name:  "an allowed field denied by its policy stays out",
+			perms: []FieldPermissions{{Allowed: []string{"id", "title"}, Denied: []string{"title"}}, {Allowed: []string{"id"}}},
+			want:  FieldPermissions{Allowed: []string{"id"}},
+		},
+		{
+			name:  "a field is denied when every policy denies it",
+			perms: []FieldPermissions{{Denied: []string{"secret", "salary"}}, {Denied: []string{"secret"}}},
+			want:  FieldPermissions{Denied: []string{"secret"}},
+		},
+		{
+			name:  "an unrestricted policy permits what others deny",
+			perms: []FieldPermissions{{Denied: []string{"secret"}}, {}},
+			want:  FieldPermissions{},
+		},
+		{
+			name:  "a field outside an allow list stays denied",
+			perms: []FieldPermissions{{Allowed: []string{"id"}}, {Denied: []string{"secret"}}},
+			want:  FieldPermissions{Denied: []string{"secret"}},
+		},
+		{
+			name:  "read-only when every policy permitting the field marks it so",
+			perms: []FieldPermissions{{ReadOnly: []string{"status"}}, {Denied: []string{"status"}}},
+			want:  FieldPermissions{ReadOnly: []string{"status"}},
+		},
+		{
+			name:  "writable when any policy permitting the field does not mark it",
+			perms: []FieldPermissions{{ReadOnly: []string{"status", "owner"}}, {ReadOnly: []string{"owner"}}},
+			want:  FieldPermissions{ReadOnly: []string{"owner"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policies := make([]*ParsedPolicy, len(tt.perms))
+			for i, perms := range tt.perms {
+				policies[i] = &ParsedPolicy{FieldPermissionsMap: perms}
+			}
+			if got := mergeFieldPermissions(policies); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeFieldPermissions() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
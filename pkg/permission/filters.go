@@ -3,18 +3,38 @@ package permission
 import (
 	"fmt"
 	"strings"
+
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
 // FilterBuilder builds SQL WHERE clauses from permission filters.
 type FilterBuilder struct {
 	paramOffset int
+	dialect     dialect.Dialect
+	inline      func(field string, value any) string
 }
 
 // NewFilterBuilder creates a new filter builder.
 func NewFilterBuilder(paramOffset int) *FilterBuilder {
 	return &FilterBuilder{
 		paramOffset: paramOffset,
+		dialect:     dialect.Default(),
+	}
+}
+
+// WithDialect sets the SQL dialect used for placeholders and LIKE operators.
+func (fb *FilterBuilder) WithDialect(d dialect.Dialect) *FilterBuilder {
+	if d != nil {
+		fb.dialect = d
 	}
+	return fb
+}
+
+// WithInline renders values with fn instead of placeholders, for statements
+// that cannot take parameters such as policy definitions.
+func (fb *FilterBuilder) WithInline(fn func(field string, value any) string) *FilterBuilder {
+	fb.inline = fn
+	return fb
 }
 
 // Build converts a permission filter to SQL WHERE clause.
@@ -119,8 +139,9 @@ func (fb *FilterBuilder) buildFieldCondition(field string, value any) (string, [
 	}
 
 	// Default to equality
-	fb.paramOffset++
-	return fmt.Sprintf("%s = $%d", sanitizeIdentifier(field), fb.paramOffset), []any{value}
+	var args []any
+	sanitizedField := sanitizeIdentifier(field)
+	return fmt.Sprintf("%s = %s", sanitizedField, fb.bind(sanitizedField, value, &args)), args
 }
 
 // buildOperatorCondition builds a condition with operators.
@@ -132,36 +153,22 @@ func (fb *FilterBuilder) buildOperatorCondition(field string, ops map[string]any
 	for op, value := range ops {
 		switch op {
 		case "_eq":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s = $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s = %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_ne", "_neq":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s != $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s != %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_gt":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s > $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s > %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_gte":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s >= $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s >= %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_lt":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s < $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s < %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_lte":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s <= $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s <= %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_in":
 			if arr, ok := value.([]any); ok && len(arr) > 0 {
 				placeholders := make([]string, len(arr))
 				for i, v := range arr {
-					fb.paramOffset++
-					placeholders[i] = fmt.Sprintf("$%d", fb.paramOffset)
-					args = append(args, v)
+					placeholders[i] = fb.bind(sanitizedField, v, &args)
 				}
 				conditions = append(conditions, fmt.Sprintf("%s IN (%s)", sanitizedField, strings.Join(placeholders, ", ")))
 			}
@@ -169,28 +176,18 @@ func (fb *FilterBuilder) buildOperatorCondition(field string, ops map[string]any
 			if arr, ok := value.([]any); ok && len(arr) > 0 {
 				placeholders := make([]string, len(arr))
 				for i, v := range arr {
-					fb.paramOffset++
-					placeholders[i] = fmt.Sprintf("$%d", fb.paramOffset)
-					args = append(args, v)
+					placeholders[i] = fb.bind(sanitizedField, v, &args)
 				}
 				conditions = append(conditions, fmt.Sprintf("%s NOT IN (%s)", sanitizedField, strings.Join(placeholders, ", ")))
 			}
 		case "_like", "_contains":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s ILIKE $%d", sanitizedField, fb.paramOffset))
-			args = append(args, "%"+fmt.Sprint(value)+"%")
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", sanitizedField, fb.dialect.LikeOperator(), fb.bind(sanitizedField, "%"+fmt.Sprint(value)+"%", &args)))
 		case "_nlike", "_not_contains":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s NOT ILIKE $%d", sanitizedField, fb.paramOffset))
-			args = append(args, "%"+fmt.Sprint(value)+"%")
+			conditions = append(conditions, fmt.Sprintf("%s NOT %s %s", sanitizedField, fb.dialect.LikeOperator(), fb.bind(sanitizedField, "%"+fmt.Sprint(value)+"%", &args)))
 		case "_starts_with":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s ILIKE $%d", sanitizedField, fb.paramOffset))
-			args = append(args, fmt.Sprint(value)+"%")
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", sanitizedField, fb.dialect.LikeOperator(), fb.bind(sanitizedField, fmt.Sprint(value)+"%", &args)))
 		case "_ends_with":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s ILIKE $%d", sanitizedField, fb.paramOffset))
-			args = append(args, "%"+fmt.Sprint(value))
+			conditions = append(conditions, fmt.Sprintf("%s %s %s", sanitizedField, fb.dialect.LikeOperator(), fb.bind(sanitizedField, "%"+fmt.Sprint(value), &args)))
 		case "_null", "_is_null":
 			if boolVal, ok := value.(bool); ok {
 				if boolVal {
@@ -203,21 +200,14 @@ func (fb *FilterBuilder) buildOperatorCondition(field string, ops map[string]any
 			conditions = append(conditions, fmt.Sprintf("%s IS NOT NULL", sanitizedField))
 		case "_between":
 			if arr, ok := value.([]any); ok && len(arr) == 2 {
-				fb.paramOffset++
-				lowParam := fb.paramOffset
-				fb.paramOffset++
-				highParam := fb.paramOffset
-				conditions = append(conditions, fmt.Sprintf("%s BETWEEN $%d AND $%d", sanitizedField, lowParam, highParam))
-				args = append(args, arr[0], arr[1])
+				low := fb.bind(sanitizedField, arr[0], &args)
+				high := fb.bind(sanitizedField, arr[1], &args)
+				conditions = append(conditions, fmt.Sprintf("%s BETWEEN %s AND %s", sanitizedField, low, high))
 			}
 		case "_regex":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s ~ $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s ~ %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		case "_iregex":
-			fb.paramOffset++
-			conditions = append(conditions, fmt.Sprintf("%s ~* $%d", sanitizedField, fb.paramOffset))
-			args = append(args, value)
+			conditions = append(conditions, fmt.Sprintf("%s ~* %s", sanitizedField, fb.bind(sanitizedField, value, &args)))
 		}
 	}
 
@@ -228,6 +218,17 @@ func (fb *FilterBuilder) buildOperatorCondition(field string, ops map[string]any
 	return strings.Join(conditions, " AND "), args
 }
 
+// bind returns the SQL for a value compared with field. The value is added to
+// args behind a placeholder unless values are rendered inline.
+func (fb *FilterBuilder) bind(field string, value any, args *[]any) string {
+	if fb.inline != nil {
+		return fb.inline(field, value)
+	}
+	fb.paramOffset++
+	*args = append(*args, value)
+	return fb.dialect.Placeholder(fb.paramOffset)
+}
+
 // GetParamOffset returns the current parameter offset.
 func (fb *FilterBuilder) GetParamOffset() int {
 	return fb.paramOffset
@@ -0,0 +1,300 @@
+package permission
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Group is a set of users sharing permission policies on top of their role.
+type Group struct {
+	ID          string    `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	Description string    `db:"description" json:"description,omitempty"`
+	CreatedAt   time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt   time.Time `db:"updated_at" json:"updated_at"`
+}
+
+// GroupMember is a user's membership of a group.
+type GroupMember struct {
+	UserID    string    `db:"user_id" json:"user_id"`
+	GroupID   string    `db:"group_id" json:"group_id"`
+	CreatedAt time.Time `db:"created_at" json:"created_at"`
+}
+
+// GroupStore persists groups, their members and their policies in
+// tugo_groups, tugo_user_groups and tugo_group_permissions.
+type GroupStore struct {
+	db *sqlx.DB
+}
+
+// NewGroupStore creates a new group store.
+func NewGroupStore(db *sqlx.DB) *GroupStore {
+	return &GroupStore{db: db}
+}
+
+// List returns all groups ordered by name.
+func (s *GroupStore) List(ctx context.Context) ([]Group, error) {
+	query := `
+		SELECT id, name, COALESCE(description, '') AS description, created_at, updated_at
+		FROM tugo_groups
+		ORDER BY name
+	`
+	groups := make([]Group, 0)
+	if err := s.db.SelectContext(ctx, &groups, query); err != nil {
+		return nil, fmt.Errorf("failed to list groups: %w", err)
+	}
+	return groups, nil
+}
+
+// Get returns a group by ID.
+func (s *GroupStore) Get(ctx context.Context, id string) (*Group, error) {
+	query := `
+		SELECT id, name, COALESCE(description, '') AS description, created_at, updated_at
+		FROM tugo_groups
+		WHERE id = ?
+	`
+	var group Group
+	if err := s.db.GetContext(ctx, &group, s.db.Rebind(query), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Group '%s' not found", id)
+		}
+		return nil, fmt.Errorf("failed to get group: %w", err)
+	}
+	return &group, nil
+}
+
+// Create stores a new group.
+func (s *GroupStore) Create(ctx context.Context, group *Group) error {
+	if group.ID == "" {
+		group.ID = uuid.New().String()
+	}
+	now := time.Now()
+	group.CreatedAt = now
+	group.UpdatedAt = now
+
+	query := `
+		INSERT INTO tugo_groups (id, name, description, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), group.ID, group.Name, group.Description, now, now); err != nil {
+		if isDuplicateKeyError(err) {
+			return apperror.ErrConflict.WithMessagef("Group '%s' already exists", group.Name)
+		}
+		return fmt.Errorf("failed to create group: %w", err)
+	}
+	return nil
+}
+
+// Update replaces a group's name and description.
+func (s *GroupStore) Update(ctx context.Context, group *Group) error {
+	group.UpdatedAt = time.Now()
+
+	query := `
+		UPDATE tugo_groups
+		SET name = ?, description = ?, updated_at = ?
+		WHERE id = ?
+	`
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), group.Name, group.Description, group.UpdatedAt, group.ID)
+	if err != nil {
+		if isDuplicateKeyError(err) {
+			return apperror.ErrConflict.WithMessagef("Group '%s' already exists", group.Name)
+		}
+		return fmt.Errorf("failed to update group: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("Group '%s' not found", group.ID)
+	}
+	return nil
+}
+
+// Delete removes a group with its memberships and policies.
+func (s *GroupStore) Delete(ctx context.Context, id string) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	defer tx.Rollback()
+
+	// SQLite only cascades with foreign keys enabled
+	for _, table := range []string{"tugo_user_groups", "tugo_group_permissions"} {
+		if _, err := tx.ExecContext(ctx, tx.Rebind("DELETE FROM "+table+" WHERE group_id = ?"), id); err != nil {
+			return fmt.Errorf("failed to delete group: %w", err)
+		}
+	}
+	res, err := tx.ExecContext(ctx, tx.Rebind("DELETE FROM tugo_groups WHERE id = ?"), id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("Group '%s' not found", id)
+	}
+	return tx.Commit()
+}
+
+// Members returns the members of a group, oldest first.
+func (s *GroupStore) Members(ctx context.Context, groupID string) ([]GroupMember, error) {
+	query := `
+		SELECT user_id, group_id, created_at
+		FROM tugo_user_groups
+		WHERE group_id = ?
+		ORDER BY created_at, user_id
+	`
+	members := make([]GroupMember, 0)
+	if err := s.db.SelectContext(ctx, &members, s.db.Rebind(query), groupID); err != nil {
+		return nil, fmt.Errorf("failed to list group members: %w", err)
+	}
+	return members, nil
+}
+
+// AddMember adds a user to a group.
+func (s *GroupStore) AddMember(ctx context.Context, groupID, userID string) (*GroupMember, error) {
+	if _, err := s.Get(ctx, groupID); err != nil {
+		return nil, err
+	}
+
+	member := &GroupMember{UserID: userID, GroupID: groupID, CreatedAt: time.Now()}
+	query := `INSERT INTO tugo_user_groups (user_id, group_id, created_at) VALUES (?, ?, ?)`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), userID, groupID, member.CreatedAt); err != nil {
+		if isDuplicateKeyError(err) {
+			return nil, apperror.ErrConflict.WithMessagef("User '%s' is already a member", userID)
+		}
+		return nil, fmt.Errorf("failed to add group member: %w", err)
+	}
+	return member, nil
+}
+
+// RemoveMember removes a user from a group.
+func (s *GroupStore) RemoveMember(ctx context.Context, groupID, userID string) error {
+	query := `DELETE FROM tugo_user_groups WHERE group_id = ? AND user_id = ?`
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), groupID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to remove group member: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("User '%s' is not a member", userID)
+	}
+	return nil
+}
+
+// Policies returns the policies of a group.
+func (s *GroupStore) Policies(ctx context.Context, groupID string) ([]Policy, error) {
+	query := `
+		SELECT id, group_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at
+		FROM tugo_group_permissions
+		WHERE group_id = ?
+		ORDER BY collection, action
+	`
+	var rows []policyRow
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), groupID); err != nil {
+		return nil, fmt.Errorf("failed to list group policies: %w", err)
+	}
+	return toPolicies(rows), nil
+}
+
+// UserGroupIDs returns the IDs of the groups a user belongs to.
+func (s *GroupStore) UserGroupIDs(ctx context.Context, userID string) ([]string, error) {
+	query := `SELECT group_id FROM tugo_user_groups WHERE user_id = ? ORDER BY group_id`
+	ids := make([]string, 0)
+	if err := s.db.SelectContext(ctx, &ids, s.db.Rebind(query), userID); err != nil {
+		return nil, fmt.Errorf("failed to load user groups: %w", err)
+	}
+	return ids, nil
+}
+
+// UserPolicies returns the policies of every group a user belongs to.
+func (s *GroupStore) UserPolicies(ctx context.Context, userID string) ([]Policy, error) {
+	query := `
+		SELECT p.id, p.group_id, p.collection, p.action, p.filter, p.field_permissions, p.validation, p.presets, p.created_at, p.updated_at
+		FROM tugo_group_permissions p
+		JOIN tugo_user_groups m ON m.group_id = p.group_id
+		WHERE m.user_id = ?
+		ORDER BY p.group_id, p.collection, p.action
+	`
+	var rows []policyRow
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), userID); err != nil {
+		return nil, fmt.Errorf("failed to load group policies: %w", err)
+	}
+	return toPolicies(rows), nil
+}
+
+// SetPolicy creates or replaces the policy of a group for a collection and
+// action.
+func (s *GroupStore) SetPolicy(ctx context.Context, policy *Policy) error {
+	if _, err := s.Get(ctx, policy.GroupID); err != nil {
+		return err
+	}
+	if policy.ID == "" {
+		policy.ID = uuid.New().String()
+	}
+	now := time.Now()
+	policy.CreatedAt = now
+	policy.UpdatedAt = now
+
+	query := `
+		INSERT INTO tugo_group_permissions (id, group_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (group_id, collection, action)
+		DO UPDATE SET filter = EXCLUDED.filter, field_permissions = EXCLUDED.field_permissions,
+		              validation = EXCLUDED.validation, presets = EXCLUDED.presets, updated_at = EXCLUDED.updated_at
+	`
+	if s.db.DriverName() == "mysql" {
+		query = `
+		INSERT INTO tugo_group_permissions (id, group_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE filter = VALUES(filter), field_permissions = VALUES(field_permissions),
+		              validation = VALUES(validation), presets = VALUES(presets), updated_at = VALUES(updated_at)
+	`
+	}
+
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
+		policy.ID, policy.GroupID, policy.Collection, policy.Action,
+		nullJSON(policy.Filter), nullJSON(policy.FieldPermissions), nullJSON(policy.Validation), nullJSON(policy.Presets),
+		now, now)
+	if err != nil {
+		return fmt.Errorf("failed to set group policy: %w", err)
+	}
+
+	// A replaced policy keeps its ID
+	query = `SELECT id, created_at FROM tugo_group_permissions WHERE group_id = ? AND collection = ? AND action = ?`
+	row := s.db.QueryRowxContext(ctx, s.db.Rebind(query), policy.GroupID, policy.Collection, policy.Action)
+	if err := row.Scan(&policy.ID, &policy.CreatedAt); err != nil {
+		return fmt.Errorf("failed to set group policy: %w", err)
+	}
+	return nil
+}
+
+// DeletePolicy removes a policy of a group.
+func (s *GroupStore) DeletePolicy(ctx context.Context, groupID, id string) error {
+	query := `DELETE FROM tugo_group_permissions WHERE group_id = ? AND id = ?`
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), groupID, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete group policy: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("Policy '%s' not found", id)
+	}
+	return nil
+}
+
+// nullJSON stores empty JSON columns as NULL.
+func nullJSON(data []byte) any {
+	if len(data) == 0 {
+		return nil
+	}
+	return string(data)
+}
+
+// isDuplicateKeyError reports whether err is a unique constraint violation.
+func isDuplicateKeyError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "23505") || strings.Contains(msg, "duplicate key") ||
+		strings.Contains(msg, "Error 1062") || strings.Contains(msg, "UNIQUE constraint failed")
+}
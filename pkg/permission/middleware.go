@@ -15,6 +15,10 @@ type ContextKey string
 const (
 	// CheckResultKey is the context key for the permission check result.
 	CheckResultKey ContextKey = "tugo_permission_result"
+
+	// PublicAccessKey is set in the context of anonymous requests to an
+	// action a collection makes public.
+	PublicAccessKey ContextKey = "tugo_public_access"
 )
 
 // Middleware returns a Gin middleware that checks permissions.
@@ -22,6 +26,10 @@ func Middleware(checker *Checker) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		// Get user from context
 		user, ok := c.Get(string(auth.UserContextKey))
+		if !ok && c.GetBool(string(PublicAccessKey)) {
+			c.Next()
+			return
+		}
 		if !ok {
 			response.Unauthorized(c, "authentication required")
 			c.Abort()
@@ -35,8 +43,7 @@ func Middleware(checker *Checker) gin.HandlerFunc {
 			return
 		}
 
-		// Determine action from HTTP method
-		action := methodToAction(c.Request.Method)
+		action := RequestAction(c)
 
 		// Get collection from route parameter
 		collection := c.Param("collection")
@@ -171,6 +178,31 @@ func GetCheckResult(c *gin.Context) *CheckResult {
 	return nil
 }
 
+// RequestAction returns the action a collection request performs, from
+// its method and route.
+func RequestAction(c *gin.Context) Action {
+	// POST /:collection/batch, find-duplicates and query-token only read
+	// records, comments and favorites need read access to their record;
+	// reordering, restoring and transitions update records, and merging
+	// removes them
+	switch {
+	case strings.HasSuffix(c.FullPath(), "/:collection/batch"),
+		strings.HasSuffix(c.FullPath(), "/:collection/find-duplicates"),
+		strings.HasSuffix(c.FullPath(), "/:collection/query-token"),
+		strings.HasSuffix(c.FullPath(), "/:id/comments"),
+		strings.HasSuffix(c.FullPath(), "/comments/:comment_id"),
+		strings.HasSuffix(c.FullPath(), "/:id/favorite"):
+		return ActionRead
+	case strings.HasSuffix(c.FullPath(), "/:collection/reorder"),
+		strings.HasSuffix(c.FullPath(), "/revisions/:rev/restore"),
+		strings.HasSuffix(c.FullPath(), "/:id/transition"):
+		return ActionUpdate
+	case strings.HasSuffix(c.FullPath(), "/:collection/merge"):
+		return ActionDelete
+	}
+	return methodToAction(c.Request.Method)
+}
+
 // methodToAction converts HTTP method to permission action.
 func methodToAction(method string) Action {
 	switch method {
@@ -21,10 +21,12 @@ const (
 	ActionDelete Action = "delete"
 )
 
-// Policy represents a permission policy for a specific role and collection.
+// Policy represents a permission policy for a specific role or group and
+// collection.
 type Policy struct {
 	ID               string          `db:"id" json:"id"`
 	RoleID           string          `db:"role_id" json:"role_id"`
+	GroupID          string          `db:"group_id" json:"group_id,omitempty"`
 	Collection       string          `db:"collection" json:"collection"`
 	Action           Action          `db:"action" json:"action"`
 	Filter           json.RawMessage `db:"filter" json:"filter,omitempty"`
@@ -62,6 +64,48 @@ type FilterRule struct {
 	Variable string      `json:"variable,omitempty"` // $USER_ID, $ROLE_ID, $NOW, etc.
 }
 
+// policyRow scans a policy. Its JSON columns may be NULL, or text on SQLite,
+// neither of which json.RawMessage can scan.
+type policyRow struct {
+	ID               string    `db:"id"`
+	RoleID           string    `db:"role_id"`
+	GroupID          string    `db:"group_id"`
+	Collection       string    `db:"collection"`
+	Action           Action    `db:"action"`
+	Filter           []byte    `db:"filter"`
+	FieldPermissions []byte    `db:"field_permissions"`
+	Validation       []byte    `db:"validation"`
+	Presets          []byte    `db:"presets"`
+	CreatedAt        time.Time `db:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at"`
+}
+
+// toPolicy converts a scanned row to a policy.
+func (r policyRow) toPolicy() Policy {
+	return Policy{
+		ID:               r.ID,
+		RoleID:           r.RoleID,
+		GroupID:          r.GroupID,
+		Collection:       r.Collection,
+		Action:           r.Action,
+		Filter:           r.Filter,
+		FieldPermissions: r.FieldPermissions,
+		Validation:       r.Validation,
+		Presets:          r.Presets,
+		CreatedAt:        r.CreatedAt,
+		UpdatedAt:        r.UpdatedAt,
+	}
+}
+
+// toPolicies converts scanned rows to policies.
+func toPolicies(rows []policyRow) []Policy {
+	policies := make([]Policy, len(rows))
+	for i, r := range rows {
+		policies[i] = r.toPolicy()
+	}
+	return policies
+}
+
 // PolicyStore provides storage operations for policies.
 type PolicyStore struct {
 	db        *sqlx.DB
@@ -81,17 +125,18 @@ func (s *PolicyStore) GetByRoleAndCollection(ctx context.Context, roleID, collec
 	query := `
 		SELECT id, role_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at
 		FROM ` + s.tableName + `
-		WHERE role_id = $1 AND collection = $2 AND action = $3
+		WHERE role_id = ? AND collection = ? AND action = ?
 	`
 
-	var policy Policy
-	if err := s.db.GetContext(ctx, &policy, query, roleID, collection, action); err != nil {
+	var row policyRow
+	if err := s.db.GetContext(ctx, &row, s.db.Rebind(query), roleID, collection, action); err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return nil, nil // No policy found
 		}
 		return nil, err
 	}
 
+	policy := row.toPolicy()
 	return &policy, nil
 }
 
@@ -100,16 +145,16 @@ func (s *PolicyStore) GetByRole(ctx context.Context, roleID string) ([]Policy, e
 	query := `
 		SELECT id, role_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at
 		FROM ` + s.tableName + `
-		WHERE role_id = $1
+		WHERE role_id = ?
 		ORDER BY collection, action
 	`
 
-	var policies []Policy
-	if err := s.db.SelectContext(ctx, &policies, query, roleID); err != nil {
+	var rows []policyRow
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), roleID); err != nil {
 		return nil, err
 	}
 
-	return policies, nil
+	return toPolicies(rows), nil
 }
 
 // GetByCollection retrieves all policies for a collection.
@@ -117,16 +162,16 @@ func (s *PolicyStore) GetByCollection(ctx context.Context, collection string) ([
 	query := `
 		SELECT id, role_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at
 		FROM ` + s.tableName + `
-		WHERE collection = $1
+		WHERE collection = ?
 		ORDER BY role_id, action
 	`
 
-	var policies []Policy
-	if err := s.db.SelectContext(ctx, &policies, query, collection); err != nil {
+	var rows []policyRow
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), collection); err != nil {
 		return nil, err
 	}
 
-	return policies, nil
+	return toPolicies(rows), nil
 }
 
 // Create creates a new policy.
@@ -140,10 +185,10 @@ func (s *PolicyStore) Create(ctx context.Context, policy *Policy) error {
 
 	query := `
 		INSERT INTO ` + s.tableName + ` (id, role_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
 		policy.ID, policy.RoleID, policy.Collection, policy.Action,
 		policy.Filter, policy.FieldPermissions, policy.Validation, policy.Presets,
 		policy.CreatedAt, policy.UpdatedAt)
@@ -156,11 +201,11 @@ func (s *PolicyStore) Update(ctx context.Context, policy *Policy) error {
 
 	query := `
 		UPDATE ` + s.tableName + `
-		SET filter = $1, field_permissions = $2, validation = $3, presets = $4, updated_at = $5
-		WHERE id = $6
+		SET filter = ?, field_permissions = ?, validation = ?, presets = ?, updated_at = ?
+		WHERE id = ?
 	`
 
-	result, err := s.db.ExecContext(ctx, query,
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query),
 		policy.Filter, policy.FieldPermissions, policy.Validation, policy.Presets,
 		policy.UpdatedAt, policy.ID)
 	if err != nil {
@@ -177,9 +222,9 @@ func (s *PolicyStore) Update(ctx context.Context, policy *Policy) error {
 
 // Delete deletes a policy.
 func (s *PolicyStore) Delete(ctx context.Context, id string) error {
-	query := `DELETE FROM ` + s.tableName + ` WHERE id = $1`
+	query := `DELETE FROM ` + s.tableName + ` WHERE id = ?`
 
-	result, err := s.db.ExecContext(ctx, query, id)
+	result, err := s.db.ExecContext(ctx, s.db.Rebind(query), id)
 	if err != nil {
 		return err
 	}
@@ -202,13 +247,21 @@ func (s *PolicyStore) Upsert(ctx context.Context, policy *Policy) error {
 
 	query := `
 		INSERT INTO ` + s.tableName + ` (id, role_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT (role_id, collection, action)
 		DO UPDATE SET filter = EXCLUDED.filter, field_permissions = EXCLUDED.field_permissions,
 		              validation = EXCLUDED.validation, presets = EXCLUDED.presets, updated_at = EXCLUDED.updated_at
 	`
+	if s.db.DriverName() == "mysql" {
+		query = `
+		INSERT INTO ` + s.tableName + ` (id, role_id, collection, action, filter, field_permissions, validation, presets, created_at, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON DUPLICATE KEY UPDATE filter = VALUES(filter), field_permissions = VALUES(field_permissions),
+		              validation = VALUES(validation), presets = VALUES(presets), updated_at = VALUES(updated_at)
+	`
+	}
 
-	_, err := s.db.ExecContext(ctx, query,
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
 		policy.ID, policy.RoleID, policy.Collection, policy.Action,
 		policy.Filter, policy.FieldPermissions, policy.Validation, policy.Presets,
 		now, now)
@@ -0,0 +1,315 @@
+package permission
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// DefaultSettingPrefix namespaces the session variables set in RLS mode.
+const DefaultSettingPrefix = "tugo"
+
+// settingPrefixRegex restricts setting prefixes to identifiers.
+var settingPrefixRegex = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+
+// RLSConfig configures database-enforced permissions.
+type RLSConfig struct {
+	// Roles maps TuGo role names to database roles assumed with SET LOCAL ROLE.
+	Roles map[string]string
+
+	// DefaultRole is assumed by unmapped roles and anonymous requests.
+	// Empty keeps the connection's role.
+	DefaultRole string
+
+	// SettingPrefix namespaces the session variables.
+	// Default: "tugo"
+	SettingPrefix string
+}
+
+// RLS passes the requesting user to PostgreSQL so row-level security
+// policies can enforce permissions. Each transaction gets the variables
+// <prefix>.user_id, <prefix>.role, <prefix>.role_id, <prefix>.username,
+// <prefix>.email and <prefix>.group_ids, the comma-separated IDs of the
+// user's groups, empty for anonymous requests, and <prefix>.claim_<name>
+// for each custom claim.
+type RLS struct {
+	config RLSConfig
+	groups *GroupStore
+
+	// mu guards the role mapping in config, which SetRoles replaces
+	mu sync.RWMutex
+}
+
+// NewRLS creates a new row-level security session manager.
+func NewRLS(config RLSConfig) (*RLS, error) {
+	if config.SettingPrefix == "" {
+		config.SettingPrefix = DefaultSettingPrefix
+	}
+	if !settingPrefixRegex.MatchString(config.SettingPrefix) {
+		return nil, fmt.Errorf("invalid RLS setting prefix %q", config.SettingPrefix)
+	}
+	return &RLS{config: config}, nil
+}
+
+// SetGroups sets the store the groups of users are loaded from. Without
+// it, <prefix>.group_ids is always empty.
+func (r *RLS) SetGroups(groups *GroupStore) {
+	r.groups = groups
+}
+
+// SetRoles replaces the mapping of TuGo roles to database roles, such as
+// on a config reload. The setting prefix is fixed, as the generated
+// policies read it.
+func (r *RLS) SetRoles(roles map[string]string, defaultRole string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.config.Roles = roles
+	r.config.DefaultRole = defaultRole
+}
+
+// dbRole returns the database role assumed for a TuGo role.
+func (r *RLS) dbRole(role string) string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if mapped, ok := r.config.Roles[role]; ok && role != "" {
+		return mapped
+	}
+	return r.config.DefaultRole
+}
+
+// Setting returns the qualified name of a session variable.
+func (r *RLS) Setting(name string) string {
+	return r.config.SettingPrefix + "." + name
+}
+
+// Apply sets the role and session variables for the user in ctx on a transaction.
+func (r *RLS) Apply(ctx context.Context, tx *sqlx.Tx) error {
+	user, _ := auth.GetUserFromContext(ctx)
+	if user == nil {
+		user = &auth.User{}
+	}
+	role := user.Role
+
+	if dbRole := r.dbRole(role); dbRole != "" {
+		if _, err := tx.ExecContext(ctx, "SET LOCAL ROLE "+dialect.PostgresDialect{}.QuoteIdent(dbRole)); err != nil {
+			return fmt.Errorf("failed to set role: %w", err)
+		}
+	}
+
+	var groupIDs []string
+	if r.groups != nil && user.ID != "" {
+		ids, err := r.groups.UserGroupIDs(ctx, user.ID)
+		if err != nil {
+			return err
+		}
+		groupIDs = ids
+	}
+
+	query := "SELECT set_config($1, $2, true), set_config($3, $4, true), set_config($5, $6, true), " +
+		"set_config($7, $8, true), set_config($9, $10, true), set_config($11, $12, true)"
+	if _, err := tx.ExecContext(ctx, query,
+		r.Setting("user_id"), user.ID,
+		r.Setting("role"), role,
+		r.Setting("role_id"), user.RoleID,
+		r.Setting("username"), user.Username,
+		r.Setting("email"), user.Email,
+		r.Setting("group_ids"), strings.Join(groupIDs, ","),
+	); err != nil {
+		return fmt.Errorf("failed to set session variables: %w", err)
+	}
+
+	// Custom claims are read by $CLAIM variables as <prefix>.claim_<name>
+	claims, _ := auth.GetClaimsFromContext(ctx)
+	if claims == nil {
+		return nil
+	}
+	for name, value := range claims.Extra {
+		if !auth.ValidClaimName(name) || value == nil {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx, "SELECT set_config($1, $2, true)", r.Setting("claim_"+name), fmt.Sprint(value)); err != nil {
+			return fmt.Errorf("failed to set claim %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// PolicyTable describes a table policies are generated for.
+type PolicyTable struct {
+	Name  string
+	Types map[string]string // Column name to PostgreSQL type
+}
+
+// policyCommands maps actions to the commands their policies cover.
+var policyCommands = map[Action]string{
+	ActionRead:   "SELECT",
+	ActionCreate: "INSERT",
+	ActionUpdate: "UPDATE",
+	ActionDelete: "DELETE",
+}
+
+// policyGrant is a stored permission of a role or a group.
+type policyGrant struct {
+	Collection string `db:"collection"`
+	Action     Action `db:"action"`
+	Filter     string `db:"filter"`
+	Role       string `db:"role"`     // Empty for group permissions
+	GroupID    string `db:"group_id"` // Empty for role permissions
+}
+
+// GeneratePolicies returns statements enabling row-level security and creating
+// one policy per stored permission. Each policy applies when the role session
+// variable matches the permission's role, or the group IDs session variable
+// lists the permission's group, and its filter holds; filter variables such
+// as $CURRENT_USER read the session variables. Admins are not restricted.
+// Permissions for collections missing from tables are skipped.
+func (r *RLS) GeneratePolicies(ctx context.Context, db *sqlx.DB, tables map[string]PolicyTable) ([]string, error) {
+	query := `
+		SELECT p.collection, p.action, COALESCE(CAST(p.filter AS TEXT), '') AS filter, r.name AS role, '' AS group_id
+		FROM tugo_permissions p
+		JOIN tugo_roles r ON r.id = p.role_id
+		ORDER BY p.collection, r.name, p.action
+	`
+	var grants []policyGrant
+	if err := db.SelectContext(ctx, &grants, query); err != nil {
+		return nil, fmt.Errorf("failed to load permissions: %w", err)
+	}
+
+	query = `
+		SELECT collection, action, COALESCE(CAST(filter AS TEXT), '') AS filter, '' AS role, group_id
+		FROM tugo_group_permissions
+		ORDER BY collection, group_id, action
+	`
+	var groupGrants []policyGrant
+	if err := db.SelectContext(ctx, &groupGrants, query); err != nil {
+		return nil, fmt.Errorf("failed to load group permissions: %w", err)
+	}
+	return r.policyStatements(append(grants, groupGrants...), tables)
+}
+
+// policyStatements returns the statements GeneratePolicies runs for grants.
+func (r *RLS) policyStatements(grants []policyGrant, tables map[string]PolicyTable) ([]string, error) {
+	pg := dialect.PostgresDialect{}
+	byTable := make(map[string][]string)
+	for _, grant := range grants {
+		table, ok := tables[grant.Collection]
+		command, known := policyCommands[grant.Action]
+		if !ok || !known {
+			continue
+		}
+
+		holder := grant.Role
+		condition := fmt.Sprintf("current_setting(%s, true) = %s", quoteLiteral(r.Setting("role")), quoteLiteral(grant.Role))
+		name := pg.QuoteIdent(fmt.Sprintf("tugo_%s_%s", grant.Role, grant.Action))
+		if grant.GroupID != "" {
+			holder = "group " + grant.GroupID
+			condition = fmt.Sprintf("%s = ANY(string_to_array(current_setting(%s, true), ','))",
+				quoteLiteral(grant.GroupID), quoteLiteral(r.Setting("group_ids")))
+			name = pg.QuoteIdent(fmt.Sprintf("tugo_group_%s_%s", grant.GroupID, grant.Action))
+		}
+		if grant.Filter != "" {
+			var filter map[string]any
+			if err := json.Unmarshal([]byte(grant.Filter), &filter); err != nil {
+				return nil, fmt.Errorf("invalid filter for %s %s on %s: %w", holder, grant.Action, grant.Collection, err)
+			}
+			if where, _ := NewFilterBuilder(0).WithInline(r.policyValue(table)).Build(filter); where != "" {
+				condition += " AND (" + where + ")"
+			}
+		}
+
+		tableName := pg.QuoteIdent(table.Name)
+		clause := "USING (" + condition + ")"
+		switch grant.Action {
+		case ActionCreate:
+			clause = "WITH CHECK (" + condition + ")"
+		case ActionUpdate:
+			clause = "USING (" + condition + ") WITH CHECK (" + condition + ")"
+		}
+
+		byTable[table.Name] = append(byTable[table.Name],
+			fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s", name, tableName),
+			fmt.Sprintf("CREATE POLICY %s ON %s FOR %s %s", name, tableName, command, clause),
+		)
+	}
+
+	names := make([]string, 0, len(byTable))
+	for name := range byTable {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statements := make([]string, 0)
+	for _, name := range names {
+		tableName := pg.QuoteIdent(name)
+		adminPolicy := pg.QuoteIdent("tugo_admin_all")
+		statements = append(statements,
+			fmt.Sprintf("ALTER TABLE %s ENABLE ROW LEVEL SECURITY", tableName),
+			fmt.Sprintf("ALTER TABLE %s FORCE ROW LEVEL SECURITY", tableName),
+			fmt.Sprintf("DROP POLICY IF EXISTS %s ON %s", adminPolicy, tableName),
+			fmt.Sprintf("CREATE POLICY %s ON %s USING (current_setting(%s, true) = 'admin') WITH CHECK (current_setting(%s, true) = 'admin')",
+				adminPolicy, tableName, quoteLiteral(r.Setting("role")), quoteLiteral(r.Setting("role"))),
+		)
+		statements = append(statements, byTable[name]...)
+	}
+	return statements, nil
+}
+
+// policyValue renders filter values as literals cast to the column type,
+// reading filter variables from the session variables.
+func (r *RLS) policyValue(table PolicyTable) func(field string, value any) string {
+	return func(field string, value any) string {
+		var expr string
+		switch v := value.(type) {
+		case nil:
+			return "NULL"
+		case string:
+			expr = quoteLiteral(v)
+			var setting string
+			switch v {
+			case "$USER_ID", "$CURRENT_USER":
+				setting = "user_id"
+			case "$ROLE_ID", "$CURRENT_ROLE":
+				setting = "role_id"
+			case "$ROLE", "$ROLE_NAME":
+				setting = "role"
+			case "$USERNAME":
+				setting = "username"
+			case "$EMAIL":
+				setting = "email"
+			default:
+				if name, ok := strings.CutPrefix(v, ClaimVariablePrefix); ok && auth.ValidClaimName(name) {
+					setting = "claim_" + name
+				}
+			}
+			if setting != "" {
+				// Anonymous requests leave variables empty; NULL keeps casts valid
+				expr = fmt.Sprintf("NULLIF(current_setting(%s, true), '')", quoteLiteral(r.Setting(setting)))
+			}
+		case bool:
+			expr = quoteLiteral(strconv.FormatBool(v))
+		case float64:
+			expr = quoteLiteral(strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			expr = quoteLiteral(fmt.Sprint(v))
+		}
+
+		if typ := sanitizeIdentifier(table.Types[field]); typ != "" {
+			return "CAST(" + expr + " AS " + typ + ")"
+		}
+		return expr
+	}
+}
+
+// quoteLiteral quotes a PostgreSQL string literal.
+func quoteLiteral(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
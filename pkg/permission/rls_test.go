@@ -0,0 +1,75 @@
+package permission
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPolicyStatements(t *testing.T) {
+	rls, err := NewRLS(RLSConfig{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tables := map[string]PolicyTable{
+		"docs": {Name: "api_docs", Types: map[string]string{"owner": "uuid"}},
+	}
+
+	tests := []struct {
+		name    string
+		grants  []policyGrant
+		want    []string
+		notWant []string
+	}{
+		{
+			name:   "role permission",
+			grants: []policyGrant{{Collection: "docs", Action: ActionRead, Role: "user"}},
+			want: []string{
+				`ALTER TABLE "api_docs" ENABLE ROW LEVEL SECURITY`,
+				`CREATE POLICY "tugo_user_read" ON "api_docs" FOR SELECT USING (current_setting('tugo.role', true) = 'user')`,
+			},
+			notWant: []string{"tugo_group_"},
+		},
+		{
+			name:   "permission granted only through a group",
+			grants: []policyGrant{{Collection: "docs", Action: ActionRead, GroupID: "g1"}},
+			want: []string{
+				`ALTER TABLE "api_docs" ENABLE ROW LEVEL SECURITY`,
+				`CREATE POLICY "tugo_group_g1_read" ON "api_docs" FOR SELECT USING ('g1' = ANY(string_to_array(current_setting('tugo.group_ids', true), ',')))`,
+			},
+			notWant: []string{"tugo.role', true) = ''"},
+		},
+		{
+			name:   "group permission with a filter",
+			grants: []policyGrant{{Collection: "docs", Action: ActionUpdate, GroupID: "g1", Filter: `{"owner": {"_eq": "$USER_ID"}}`}},
+			want: []string{
+				`CREATE POLICY "tugo_group_g1_update" ON "api_docs" FOR UPDATE USING ('g1' = ANY(string_to_array(current_setting('tugo.group_ids', true), ',')) AND (`,
+				`CAST(NULLIF(current_setting('tugo.user_id', true), '') AS uuid)`,
+			},
+		},
+		{
+			name:    "unknown collection",
+			grants:  []policyGrant{{Collection: "missing", Action: ActionRead, GroupID: "g1"}},
+			notWant: []string{"CREATE POLICY"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			statements, err := rls.policyStatements(tt.grants, tables)
+			if err != nil {
+				t.Fatalf("policyStatements() error = %v", err)
+			}
+			sql := strings.Join(statements, ";\n")
+			for _, want := range tt.want {
+				if !strings.Contains(sql, want) {
+					t.Errorf("statements missing %q:\n%s", want, sql)
+				}
+			}
+			for _, notWant := range tt.notWant {
+				if strings.Contains(sql, notWant) {
+					t.Errorf("statements contain %q:\n%s", notWant, sql)
+				}
+			}
+		})
+	}
+}
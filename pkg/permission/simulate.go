@@ -0,0 +1,126 @@
+package permission
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+)
+
+// Simulation is the decision the permission checker would make for a request.
+type Simulation struct {
+	Collection string `json:"collection"`
+	Action     Action `json:"action"`
+	Allowed    bool   `json:"allowed"`
+	Reason     string `json:"reason,omitempty"`
+
+	// Filter is the row-level filter with its variables resolved for the user.
+	Filter           map[string]any   `json:"filter,omitempty"`
+	FieldPermissions FieldPermissions `json:"field_permissions"`
+	Presets          map[string]any   `json:"presets,omitempty"`
+
+	// StrippedFields lists the fields removed from the item for reads, or
+	// from the body for writes, by the field permissions.
+	StrippedFields []string `json:"stripped_fields"`
+
+	// Body is the request body with presets applied, for creates and updates.
+	Body map[string]any `json:"body,omitempty"`
+}
+
+// Simulate returns what the checker would decide for a request by user,
+// without running it. The collection and action are derived from method and
+// path as the permission middleware derives them. For reads, fields returns
+// the fields of the collection so the ones hidden from the user are reported.
+func (c *Checker) Simulate(ctx context.Context, user *auth.User, method, path string, body map[string]any, fields func(collection string) []string) (*Simulation, error) {
+	collection := extractCollectionFromPath(path)
+	if collection == "" {
+		return nil, apperror.ErrBadRequest.WithMessagef("No collection in path '%s'", path)
+	}
+
+	sim := &Simulation{
+		Collection:     collection,
+		Action:         requestAction(method, path),
+		StrippedFields: []string{},
+	}
+
+	var data map[string]any
+	if sim.Action == ActionCreate || sim.Action == ActionUpdate {
+		data = make(map[string]any, len(body))
+		for k, v := range body {
+			data[k] = v
+		}
+	}
+
+	result, err := c.Check(ctx, user, collection, sim.Action)
+	if err != nil {
+		return nil, err
+	}
+	sim.Allowed, sim.Reason = result.Allowed, result.Reason
+	if !result.Allowed {
+		return sim, nil
+	}
+	sim.Filter, sim.FieldPermissions, sim.Presets = result.Filter, result.FieldPerms, result.Presets
+
+	if data == nil {
+		names := fields(collection)
+		item := make(map[string]any, len(names))
+		for _, f := range names {
+			item[f] = nil
+		}
+		sim.StrippedFields = strippedFields(item, c.FilterAllowedFields(item, result.FieldPerms, ActionRead))
+		return sim, nil
+	}
+
+	sim.StrippedFields = strippedFields(data, c.FilterAllowedFields(data, result.FieldPerms, sim.Action))
+	checked, err := c.CheckWithData(ctx, user, collection, sim.Action, data)
+	if err != nil {
+		return nil, err
+	}
+	if !checked.Allowed {
+		sim.Allowed, sim.Reason = false, checked.Reason
+		return sim, nil
+	}
+	sim.Body = data
+	return sim, nil
+}
+
+// RoleID returns the ID of the role with the given name.
+func (c *Checker) RoleID(ctx context.Context, name string) (string, error) {
+	var id string
+	if err := c.db.GetContext(ctx, &id, c.db.Rebind("SELECT id FROM tugo_roles WHERE name = ?"), name); err != nil {
+		return "", err
+	}
+	return id, nil
+}
+
+// requestAction returns the action of a request as the permission middleware
+// decides it from the matched route.
+func requestAction(method, path string) Action {
+	path = strings.TrimSuffix(path, "/")
+	parts := strings.Split(path, "/")
+	switch {
+	case strings.HasSuffix(path, "/batch"), strings.HasSuffix(path, "/find-duplicates"),
+		strings.HasSuffix(path, "/query-token"):
+		return ActionRead
+	case strings.HasSuffix(path, "/reorder"),
+		len(parts) >= 3 && parts[len(parts)-1] == "restore" && parts[len(parts)-3] == "revisions":
+		return ActionUpdate
+	case strings.HasSuffix(path, "/merge"):
+		return ActionDelete
+	}
+	return methodToAction(strings.ToUpper(method))
+}
+
+// strippedFields returns the sorted keys of before missing from after.
+func strippedFields(before, after map[string]any) []string {
+	stripped := []string{}
+	for k := range before {
+		if _, ok := after[k]; !ok {
+			stripped = append(stripped, k)
+		}
+	}
+	sort.Strings(stripped)
+	return stripped
+}
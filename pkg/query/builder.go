@@ -2,8 +2,11 @@ package query
 
 import (
 	"fmt"
+	"sort"
 	"strconv"
 	"strings"
+
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
 // Pagination holds pagination parameters.
@@ -13,18 +16,57 @@ type Pagination struct {
 	Offset int
 }
 
+// PaginationLimits holds the default and maximum page size.
+type PaginationLimits struct {
+	// DefaultLimit is used when the request does not specify a limit.
+	DefaultLimit int
+
+	// MaxLimit caps the requested limit.
+	MaxLimit int
+}
+
+// DefaultPaginationLimits returns the built-in page size limits.
+func DefaultPaginationLimits() PaginationLimits {
+	return PaginationLimits{
+		DefaultLimit: 20,
+		MaxLimit:     100,
+	}
+}
+
+// Normalize fills zero values from defaults and keeps DefaultLimit within MaxLimit.
+func (l PaginationLimits) Normalize() PaginationLimits {
+	defaults := DefaultPaginationLimits()
+	if l.MaxLimit <= 0 {
+		l.MaxLimit = defaults.MaxLimit
+	}
+	if l.DefaultLimit <= 0 {
+		l.DefaultLimit = defaults.DefaultLimit
+	}
+	if l.DefaultLimit > l.MaxLimit {
+		l.DefaultLimit = l.MaxLimit
+	}
+	return l
+}
+
 // DefaultPagination returns default pagination.
 func DefaultPagination() Pagination {
 	return Pagination{
 		Page:   1,
-		Limit:  20,
+		Limit:  DefaultPaginationLimits().DefaultLimit,
 		Offset: 0,
 	}
 }
 
 // ParsePagination parses page and limit from query params.
 func ParsePagination(params map[string][]string) Pagination {
-	p := DefaultPagination()
+	return ParsePaginationWithLimits(params, DefaultPaginationLimits())
+}
+
+// ParsePaginationWithLimits parses page and limit from query params using the given limits.
+// Limits above MaxLimit are capped.
+func ParsePaginationWithLimits(params map[string][]string, limits PaginationLimits) Pagination {
+	limits = limits.Normalize()
+	p := Pagination{Page: 1, Limit: limits.DefaultLimit}
 
 	if pageStr, ok := params["page"]; ok && len(pageStr) > 0 {
 		if page, err := strconv.Atoi(pageStr[0]); err == nil && page > 0 {
@@ -34,9 +76,9 @@ func ParsePagination(params map[string][]string) Pagination {
 
 	if limitStr, ok := params["limit"]; ok && len(limitStr) > 0 {
 		if limit, err := strconv.Atoi(limitStr[0]); err == nil && limit > 0 {
-			// Cap at 100 to prevent abuse
-			if limit > 100 {
-				limit = 100
+			// Cap to prevent abuse
+			if limit > limits.MaxLimit {
+				limit = limits.MaxLimit
 			}
 			p.Limit = limit
 		}
@@ -55,6 +97,11 @@ type Builder struct {
 	pagination  Pagination
 	args        []any
 	paramOffset int
+	dialect     dialect.Dialect
+	joins       map[string]Join
+	expansions  []Expansion
+	projections []Projection
+	selected    bool
 }
 
 // NewBuilder creates a new query builder.
@@ -64,17 +111,44 @@ func NewBuilder(tableName string) *Builder {
 		selectCols:  []string{"*"},
 		pagination:  DefaultPagination(),
 		paramOffset: 1,
+		dialect:     dialect.Default(),
+	}
+}
+
+// WithDialect sets the SQL dialect used for placeholders and operators.
+func (b *Builder) WithDialect(d dialect.Dialect) *Builder {
+	if d != nil {
+		b.dialect = d
+	}
+	return b
+}
+
+// WithJoins registers relations that related-field sorts may join.
+// Only relations referenced by a sort are joined.
+func (b *Builder) WithJoins(joins []Join) *Builder {
+	b.joins = make(map[string]Join, len(joins))
+	for _, j := range joins {
+		b.joins[j.Name] = j
 	}
+	return b
 }
 
 // Select sets the columns to select.
 func (b *Builder) Select(cols ...string) *Builder {
 	if len(cols) > 0 {
 		b.selectCols = cols
+		b.selected = true
 	}
 	return b
 }
 
+// WithProjections adds computed fields after the selected columns. Without
+// columns set by Select, only the projections are selected.
+func (b *Builder) WithProjections(projections []Projection) *Builder {
+	b.projections = projections
+	return b
+}
+
 // Where adds filter conditions.
 func (b *Builder) Where(filters []Filter) *Builder {
 	b.filters = filters
@@ -98,17 +172,38 @@ func (b *Builder) BuildSelect() (string, []any) {
 	var sb strings.Builder
 	args := make([]any, 0)
 
+	// Qualify base-table columns once related tables are joined
+	joins := b.sortJoins()
+	qualifier := ""
+	if len(joins) > 0 {
+		qualifier = b.tableName
+	}
+
 	// SELECT clause
 	sb.WriteString("SELECT ")
-	sb.WriteString(strings.Join(b.selectCols, ", "))
+	cols := make([]string, 0, len(b.selectCols)+len(b.projections))
+	if b.selected || len(b.projections) == 0 {
+		cols = append(cols, b.qualifiedSelectCols(qualifier)...)
+	}
+	for _, p := range b.projections {
+		cols = append(cols, p.SQL(b.dialect, qualifier))
+	}
+	sb.WriteString(strings.Join(append(cols, b.expansionCols()...), ", "))
 
 	// FROM clause
 	sb.WriteString(" FROM ")
-	sb.WriteString(b.tableName)
+	sb.WriteString(b.dialect.QuoteIdent(b.tableName))
+
+	// JOIN clauses for related-field sorts
+	for _, j := range joins {
+		sb.WriteString(fmt.Sprintf(" LEFT JOIN %s AS %s ON %s = %s",
+			b.dialect.QuoteIdent(j.Table), b.dialect.QuoteIdent(j.Alias()),
+			quoteColumn(b.dialect, j.Alias(), j.ForeignColumn), quoteColumn(b.dialect, b.tableName, j.LocalColumn)))
+	}
 
 	// WHERE clause
 	if len(b.filters) > 0 {
-		whereSQL, whereArgs := FiltersToSQL(b.filters, b.paramOffset)
+		whereSQL, whereArgs := filtersToSQL(b.dialect, b.filters, b.paramOffset, qualifier)
 		if whereSQL != "" {
 			sb.WriteString(" WHERE ")
 			sb.WriteString(whereSQL)
@@ -117,9 +212,15 @@ func (b *Builder) BuildSelect() (string, []any) {
 		}
 	}
 
-	// ORDER BY clause
-	if len(b.sorts) > 0 {
-		orderSQL := SortsToSQL(b.sorts)
+	// ORDER BY clause, dropping related sorts whose relation is not joined
+	sorts := make([]Sort, 0, len(b.sorts))
+	for _, s := range b.sorts {
+		if s.Relation == "" || containsJoin(joins, s.Relation) {
+			sorts = append(sorts, s)
+		}
+	}
+	if len(sorts) > 0 {
+		orderSQL := sortsToSQL(b.dialect, sorts, qualifier)
 		if orderSQL != "" {
 			sb.WriteString(" ORDER BY ")
 			sb.WriteString(orderSQL)
@@ -132,16 +233,62 @@ func (b *Builder) BuildSelect() (string, []any) {
 	return sb.String(), args
 }
 
+// sortJoins returns the registered joins referenced by related-field sorts.
+func (b *Builder) sortJoins() []Join {
+	joins := make([]Join, 0)
+	seen := make(map[string]bool)
+	for _, s := range b.sorts {
+		if s.Relation == "" || seen[s.Relation] {
+			continue
+		}
+		j, ok := b.joins[s.Relation]
+		if !ok || sanitizeIdentifier(j.Name) == "" || sanitizeIdentifier(j.Table) == "" ||
+			sanitizeIdentifier(j.LocalColumn) == "" || sanitizeIdentifier(j.ForeignColumn) == "" {
+			continue
+		}
+		seen[s.Relation] = true
+		joins = append(joins, j)
+	}
+	return joins
+}
+
+// containsJoin reports whether joins includes the named relation.
+func containsJoin(joins []Join, name string) bool {
+	for _, j := range joins {
+		if j.Name == name {
+			return true
+		}
+	}
+	return false
+}
+
+// qualifiedSelectCols quotes the selected columns and prefixes them with the
+// qualifier.
+func (b *Builder) qualifiedSelectCols(qualifier string) []string {
+	cols := make([]string, len(b.selectCols))
+	for i, col := range b.selectCols {
+		switch {
+		case col != "*":
+			cols[i] = quoteColumn(b.dialect, qualifier, col)
+		case qualifier != "":
+			cols[i] = b.dialect.QuoteIdent(qualifier) + ".*"
+		default:
+			cols[i] = col
+		}
+	}
+	return cols
+}
+
 // BuildCount builds a COUNT query.
 func (b *Builder) BuildCount() (string, []any) {
 	var sb strings.Builder
 	args := make([]any, 0)
 
 	sb.WriteString("SELECT COUNT(*) FROM ")
-	sb.WriteString(b.tableName)
+	sb.WriteString(b.dialect.QuoteIdent(b.tableName))
 
 	if len(b.filters) > 0 {
-		whereSQL, whereArgs := FiltersToSQL(b.filters, 1)
+		whereSQL, whereArgs := FiltersToSQLDialect(b.dialect, b.filters, 1)
 		if whereSQL != "" {
 			sb.WriteString(" WHERE ")
 			sb.WriteString(whereSQL)
@@ -157,18 +304,25 @@ func (b *Builder) BuildSelectByID(idColumn string) (string, []any) {
 	var sb strings.Builder
 
 	sb.WriteString("SELECT ")
-	sb.WriteString(strings.Join(b.selectCols, ", "))
+	sb.WriteString(strings.Join(b.qualifiedSelectCols(""), ", "))
 	sb.WriteString(" FROM ")
-	sb.WriteString(b.tableName)
+	sb.WriteString(b.dialect.QuoteIdent(b.tableName))
 	sb.WriteString(" WHERE ")
-	sb.WriteString(idColumn)
-	sb.WriteString(" = $1")
+	sb.WriteString(b.dialect.QuoteIdent(idColumn))
+	sb.WriteString(" = ")
+	sb.WriteString(b.dialect.Placeholder(1))
 
 	return sb.String(), nil
 }
 
 // BuildInsert builds an INSERT query.
 func BuildInsert(tableName string, data map[string]any) (string, []any) {
+	return BuildInsertDialect(dialect.Default(), tableName, data)
+}
+
+// BuildInsertDialect builds an INSERT query for a dialect.
+// RETURNING * is only appended when the dialect supports it.
+func BuildInsertDialect(d dialect.Dialect, tableName string, data map[string]any) (string, []any) {
 	columns := make([]string, 0, len(data))
 	placeholders := make([]string, 0, len(data))
 	args := make([]any, 0, len(data))
@@ -178,24 +332,67 @@ func BuildInsert(tableName string, data map[string]any) (string, []any) {
 		if sanitizeIdentifier(col) == "" {
 			continue
 		}
-		columns = append(columns, col)
-		placeholders = append(placeholders, fmt.Sprintf("$%d", i))
+		columns = append(columns, d.QuoteIdent(col))
+		placeholders = append(placeholders, d.Placeholder(i))
 		args = append(args, val)
 		i++
 	}
 
 	query := fmt.Sprintf(
-		"INSERT INTO %s (%s) VALUES (%s) RETURNING *",
-		tableName,
+		"INSERT INTO %s (%s) VALUES (%s)",
+		d.QuoteIdent(tableName),
 		strings.Join(columns, ", "),
 		strings.Join(placeholders, ", "),
 	)
+	if d.SupportsReturning() {
+		query += " RETURNING *"
+	}
 
 	return query, args
 }
 
+// BuildInsertManyDialect builds a multi-row INSERT of rows holding values for
+// columns in order. Columns that are not valid identifiers are skipped along
+// with their values. No RETURNING clause is added.
+func BuildInsertManyDialect(d dialect.Dialect, tableName string, columns []string, rows [][]any) (string, []any) {
+	keep := make([]int, 0, len(columns))
+	names := make([]string, 0, len(columns))
+	for i, col := range columns {
+		if sanitizeIdentifier(col) == "" {
+			continue
+		}
+		keep = append(keep, i)
+		names = append(names, d.QuoteIdent(col))
+	}
+
+	tuples := make([]string, 0, len(rows))
+	args := make([]any, 0, len(rows)*len(keep))
+	placeholders := make([]string, len(keep))
+	for _, row := range rows {
+		for n, i := range keep {
+			args = append(args, row[i])
+			placeholders[n] = d.Placeholder(len(args))
+		}
+		tuples = append(tuples, "("+strings.Join(placeholders, ", ")+")")
+	}
+
+	query := fmt.Sprintf(
+		"INSERT INTO %s (%s) VALUES %s",
+		d.QuoteIdent(tableName),
+		strings.Join(names, ", "),
+		strings.Join(tuples, ", "),
+	)
+	return query, args
+}
+
 // BuildUpdate builds an UPDATE query.
 func BuildUpdate(tableName string, idColumn string, id any, data map[string]any) (string, []any) {
+	return BuildUpdateDialect(dialect.Default(), tableName, idColumn, id, data)
+}
+
+// BuildUpdateDialect builds an UPDATE query for a dialect.
+// RETURNING * is only appended when the dialect supports it.
+func BuildUpdateDialect(d dialect.Dialect, tableName string, idColumn string, id any, data map[string]any) (string, []any) {
 	setClauses := make([]string, 0, len(data))
 	args := make([]any, 0, len(data)+1)
 	i := 1
@@ -208,7 +405,7 @@ func BuildUpdate(tableName string, idColumn string, id any, data map[string]any)
 		if col == idColumn {
 			continue
 		}
-		setClauses = append(setClauses, fmt.Sprintf("%s = $%d", col, i))
+		setClauses = append(setClauses, fmt.Sprintf("%s = %s", d.QuoteIdent(col), d.Placeholder(i)))
 		args = append(args, val)
 		i++
 	}
@@ -217,19 +414,115 @@ func BuildUpdate(tableName string, idColumn string, id any, data map[string]any)
 	args = append(args, id)
 
 	query := fmt.Sprintf(
-		"UPDATE %s SET %s WHERE %s = $%d RETURNING *",
-		tableName,
+		"UPDATE %s SET %s WHERE %s = %s",
+		d.QuoteIdent(tableName),
 		strings.Join(setClauses, ", "),
-		idColumn,
-		i,
+		d.QuoteIdent(idColumn),
+		d.Placeholder(i),
 	)
+	if d.SupportsReturning() {
+		query += " RETURNING *"
+	}
 
 	return query, args
 }
 
+// BuildUpdateWhereDialect builds an UPDATE of the rows matching filters,
+// setting the columns of data in name order.
+func BuildUpdateWhereDialect(d dialect.Dialect, tableName string, data map[string]any, filters []Filter) (string, []any) {
+	columns := make([]string, 0, len(data))
+	for col := range data {
+		if sanitizeIdentifier(col) != "" {
+			columns = append(columns, col)
+		}
+	}
+	sort.Strings(columns)
+
+	setClauses := make([]string, 0, len(columns))
+	args := make([]any, 0, len(columns))
+	for _, col := range columns {
+		args = append(args, data[col])
+		setClauses = append(setClauses, fmt.Sprintf("%s = %s", d.QuoteIdent(col), d.Placeholder(len(args))))
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET %s", d.QuoteIdent(tableName), strings.Join(setClauses, ", "))
+	if whereSQL, whereArgs := FiltersToSQLDialect(d, filters, len(args)+1); whereSQL != "" {
+		query += " WHERE " + whereSQL
+		args = append(args, whereArgs...)
+	}
+	return query, args
+}
+
+// BuildDeleteWhereDialect builds a DELETE of the rows matching filters.
+func BuildDeleteWhereDialect(d dialect.Dialect, tableName string, filters []Filter) (string, []any) {
+	query := "DELETE FROM " + d.QuoteIdent(tableName)
+	whereSQL, args := FiltersToSQLDialect(d, filters, 1)
+	if whereSQL != "" {
+		query += " WHERE " + whereSQL
+	}
+	return query, args
+}
+
 // BuildDelete builds a DELETE query.
 func BuildDelete(tableName string, idColumn string) string {
-	return fmt.Sprintf("DELETE FROM %s WHERE %s = $1", tableName, idColumn)
+	return BuildDeleteDialect(dialect.Default(), tableName, idColumn)
+}
+
+// BuildDeleteDialect builds a DELETE query for a dialect.
+func BuildDeleteDialect(d dialect.Dialect, tableName string, idColumn string) string {
+	return fmt.Sprintf("DELETE FROM %s WHERE %s = %s", d.QuoteIdent(tableName), d.QuoteIdent(idColumn), d.Placeholder(1))
+}
+
+// BuildMaxDialect builds a query for the largest value of an integer
+// column, or zero when the table is empty.
+func BuildMaxDialect(d dialect.Dialect, tableName string, column string) string {
+	return fmt.Sprintf("SELECT COALESCE(MAX(%s), 0) FROM %s", d.QuoteIdent(column), d.QuoteIdent(tableName))
+}
+
+// BuildSetColumnDialect builds an UPDATE of one column of a row, taking the
+// value and the ID as its two parameters.
+func BuildSetColumnDialect(d dialect.Dialect, tableName string, idColumn string, column string) string {
+	return fmt.Sprintf("UPDATE %s SET %s = %s WHERE %s = %s",
+		d.QuoteIdent(tableName), d.QuoteIdent(column), d.Placeholder(1), d.QuoteIdent(idColumn), d.Placeholder(2))
+}
+
+// BuildSliceDialect builds a query for the length of a column of a row and
+// a slice of it, taking the 1-based offset, the length and the ID as its
+// parameters.
+func BuildSliceDialect(d dialect.Dialect, tableName string, idColumn string, column string) string {
+	col := d.QuoteIdent(column)
+	return fmt.Sprintf("SELECT LENGTH(%s), SUBSTR(%s, %s, %s) FROM %s WHERE %s = %s",
+		col, col, d.Placeholder(1), d.Placeholder(2), d.QuoteIdent(tableName), d.QuoteIdent(idColumn), d.Placeholder(3))
+}
+
+// BuildCountDialect builds a COUNT query matching each of columns to a
+// parameter, in order. A non-empty excludeColumn adds a final parameter the
+// rows must not match there.
+func BuildCountDialect(d dialect.Dialect, tableName string, columns []string, excludeColumn string) string {
+	conditions := make([]string, 0, len(columns)+1)
+	for _, column := range columns {
+		conditions = append(conditions, fmt.Sprintf("%s = %s", d.QuoteIdent(column), d.Placeholder(len(conditions)+1)))
+	}
+	if excludeColumn != "" {
+		conditions = append(conditions, fmt.Sprintf("%s != %s", d.QuoteIdent(excludeColumn), d.Placeholder(len(conditions)+1)))
+	}
+
+	querySQL := "SELECT COUNT(*) FROM " + d.QuoteIdent(tableName)
+	if len(conditions) > 0 {
+		querySQL += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	return querySQL
+}
+
+// BuildCountInScopeDialect builds a COUNT query for the row whose ID is the
+// parameter after the scopeParams parameters of scope, a condition it must
+// also match when non-empty.
+func BuildCountInScopeDialect(d dialect.Dialect, tableName string, idColumn string, scope string, scopeParams int) string {
+	where := fmt.Sprintf("%s = %s", d.QuoteIdent(idColumn), d.Placeholder(scopeParams+1))
+	if scope != "" {
+		where = "(" + scope + ") AND " + where
+	}
+	return fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", d.QuoteIdent(tableName), where)
 }
 
 // ParseExpand parses the expand query parameter.
@@ -0,0 +1,360 @@
+package query
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+func TestParsePaginationWithLimits(t *testing.T) {
+	tests := []struct {
+		name       string
+		params     map[string][]string
+		limits     PaginationLimits
+		wantPage   int
+		wantLimit  int
+		wantOffset int
+	}{
+		{
+			name:       "built-in defaults",
+			params:     map[string][]string{},
+			wantPage:   1,
+			wantLimit:  20,
+			wantOffset: 0,
+		},
+		{
+			name:       "custom default limit",
+			params:     map[string][]string{"page": {"3"}},
+			limits:     PaginationLimits{DefaultLimit: 10},
+			wantPage:   3,
+			wantLimit:  10,
+			wantOffset: 20,
+		},
+		{
+			name:      "limit capped at built-in max",
+			params:    map[string][]string{"limit": {"500"}},
+			wantPage:  1,
+			wantLimit: 100,
+		},
+		{
+			name:      "raised max allows large pages",
+			params:    map[string][]string{"limit": {"1000"}},
+			limits:    PaginationLimits{MaxLimit: 1000},
+			wantPage:  1,
+			wantLimit: 1000,
+		},
+		{
+			name:      "lowered max caps limit",
+			params:    map[string][]string{"limit": {"50"}},
+			limits:    PaginationLimits{MaxLimit: 25},
+			wantPage:  1,
+			wantLimit: 25,
+		},
+		{
+			name:      "default above max is clamped",
+			params:    map[string][]string{},
+			limits:    PaginationLimits{DefaultLimit: 50, MaxLimit: 25},
+			wantPage:  1,
+			wantLimit: 25,
+		},
+		{
+			name:      "invalid values ignored",
+			params:    map[string][]string{"page": {"0"}, "limit": {"abc"}},
+			wantPage:  1,
+			wantLimit: 20,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := ParsePaginationWithLimits(tt.params, tt.limits)
+			if p.Page != tt.wantPage || p.Limit != tt.wantLimit || p.Offset != tt.wantOffset {
+				t.Errorf("ParsePaginationWithLimits() = %+v, want page=%d limit=%d offset=%d",
+					p, tt.wantPage, tt.wantLimit, tt.wantOffset)
+			}
+		})
+	}
+}
+
+func TestOptionsValidator_PaginationLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  *PaginationLimits
+		limit   int
+		wantErr bool
+	}{
+		{name: "within default max", limit: 100},
+		{name: "above default max", limit: 101, wantErr: true},
+		{name: "zero limit", limit: 0, wantErr: true},
+		{name: "within raised max", limits: &PaginationLimits{MaxLimit: 1000}, limit: 1000},
+		{name: "above lowered max", limits: &PaginationLimits{MaxLimit: 25}, limit: 26, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			v := NewOptionsValidator(nil)
+			if tt.limits != nil {
+				v.WithPaginationLimits(*tt.limits)
+			}
+			opts := DefaultOptions().WithPagination(1, tt.limit)
+			err := v.ValidateOptions(opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateOptions() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestOptionsValidator_CostLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		cost    CostLimits
+		opts    Options
+		wantErr bool
+	}{
+		{name: "no limits", opts: DefaultOptions().WithPagination(1000, 100)},
+		{name: "offset within max", cost: CostLimits{MaxOffset: 1000}, opts: DefaultOptions().WithPagination(11, 100)},
+		{name: "offset beyond max", cost: CostLimits{MaxOffset: 1000}, opts: DefaultOptions().WithPagination(12, 100), wantErr: true},
+		{name: "expand within max", cost: CostLimits{MaxExpand: 2}, opts: Options{Expand: []string{"author", "category"}}},
+		{name: "expand beyond max", cost: CostLimits{MaxExpand: 1}, opts: Options{Expand: []string{"author", "category"}}, wantErr: true},
+		{
+			name: "search on indexed field",
+			cost: CostLimits{SearchFields: []string{"title"}},
+			opts: Options{Filters: []Filter{{Field: "title", Operator: OpLike, Value: "%go%"}}},
+		},
+		{
+			name:    "search on unindexed field",
+			cost:    CostLimits{SearchFields: []string{"title"}},
+			opts:    Options{Filters: []Filter{{Field: "body", Operator: OpLike, Value: "%go%"}}},
+			wantErr: true,
+		},
+		{
+			name: "equality on unindexed field",
+			cost: CostLimits{SearchFields: []string{"title"}},
+			opts: Options{Filters: []Filter{{Field: "body", Operator: OpEqual, Value: "go"}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := NewOptionsValidator(nil).WithCostLimits(tt.cost).ValidateCost(tt.opts)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateCost() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBuildInsertManyDialect(t *testing.T) {
+	rows := [][]any{{"a", 1}, {"b", 2}}
+
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		columns []string
+		want    string
+		args    int
+	}{
+		{
+			name:    "postgres",
+			dialect: dialect.PostgresDialect{},
+			columns: []string{"name", "age"},
+			want:    `INSERT INTO "api_cats" ("name", "age") VALUES ($1, $2), ($3, $4)`,
+			args:    4,
+		},
+		{
+			name:    "mysql",
+			dialect: dialect.MySQLDialect{},
+			columns: []string{"name", "age"},
+			want:    "INSERT INTO `api_cats` (`name`, `age`) VALUES (?, ?), (?, ?)",
+			args:    4,
+		},
+		{
+			name:    "invalid column skipped",
+			dialect: dialect.PostgresDialect{},
+			columns: []string{"name", "age;"},
+			want:    `INSERT INTO "api_cats" ("name") VALUES ($1), ($2)`,
+			args:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := BuildInsertManyDialect(tt.dialect, "api_cats", tt.columns, rows)
+			if sql != tt.want {
+				t.Errorf("expected SQL %q, got %q", tt.want, sql)
+			}
+			if len(args) != tt.args {
+				t.Errorf("expected %d args, got %d", tt.args, len(args))
+			}
+		})
+	}
+}
+
+func TestBuildUpdateWhereDialect(t *testing.T) {
+	data := map[string]any{"status": "archived", "note": "bulk", "bad;": 1}
+
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		filters []Filter
+		want    string
+		args    int
+	}{
+		{
+			name:    "postgres",
+			dialect: dialect.PostgresDialect{},
+			filters: []Filter{{Field: "status", Operator: OpEqual, Value: "draft"}},
+			want:    `UPDATE "api_posts" SET "note" = $1, "status" = $2 WHERE "status" = $3`,
+			args:    3,
+		},
+		{
+			name:    "mysql",
+			dialect: dialect.MySQLDialect{},
+			filters: []Filter{{Field: "views", Operator: OpLessThan, Value: 10}},
+			want:    "UPDATE `api_posts` SET `note` = ?, `status` = ? WHERE `views` < ?",
+			args:    3,
+		},
+		{
+			name:    "no filters",
+			dialect: dialect.PostgresDialect{},
+			want:    `UPDATE "api_posts" SET "note" = $1, "status" = $2`,
+			args:    2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := BuildUpdateWhereDialect(tt.dialect, "api_posts", data, tt.filters)
+			if sql != tt.want {
+				t.Errorf("expected SQL %q, got %q", tt.want, sql)
+			}
+			if len(args) != tt.args {
+				t.Errorf("expected %d args, got %d", tt.args, len(args))
+			}
+		})
+	}
+}
+
+func TestBuildDeleteWhereDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		filters []Filter
+		want    string
+		args    int
+	}{
+		{
+			name:    "postgres",
+			dialect: dialect.PostgresDialect{},
+			filters: []Filter{{Field: "status", Operator: OpEqual, Value: "spam"}, {Field: "views", Operator: OpLessThan, Value: 1}},
+			want:    `DELETE FROM "api_posts" WHERE "status" = $1 AND "views" < $2`,
+			args:    2,
+		},
+		{
+			name:    "mysql",
+			dialect: dialect.MySQLDialect{},
+			filters: []Filter{{Field: "status", Operator: OpEqual, Value: "spam"}},
+			want:    "DELETE FROM `api_posts` WHERE `status` = ?",
+			args:    1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := BuildDeleteWhereDialect(tt.dialect, "api_posts", tt.filters)
+			if sql != tt.want {
+				t.Errorf("expected SQL %q, got %q", tt.want, sql)
+			}
+			if len(args) != tt.args {
+				t.Errorf("expected %d args, got %d", tt.args, len(args))
+			}
+		})
+	}
+}
+
+func FuzzIdentifiers(f *testing.F) {
+	for _, seed := range []string{
+		"name",
+		"order",
+		"id; DROP TABLE users; --",
+		`name" = '' OR "1`,
+		"name` = '' OR `1",
+		"",
+		"ünïcode",
+	} {
+		f.Add(seed)
+	}
+
+	f.Fuzz(func(t *testing.T, name string) {
+		valid := sanitizeIdentifier(name) != ""
+
+		// Filters quote valid names and never emit invalid ones
+		where, _ := FiltersToSQLDialect(dialect.PostgresDialect{}, []Filter{{Field: name, Operator: OpEqual, Value: "x"}}, 1)
+		want := `"" = $1`
+		if valid {
+			want = `"` + name + `" = $1`
+		}
+		if where != want {
+			t.Fatalf("filter SQL = %q, want %q", where, want)
+		}
+
+		// Sorts drop invalid names
+		order := SortsToSQLDialect(dialect.MySQLDialect{}, []Sort{{Field: name, Direction: SortAsc}})
+		if valid && order != "`"+name+"` ASC" || !valid && order != "" {
+			t.Fatalf("sort SQL = %q", order)
+		}
+
+		// Writes skip invalid columns and quote the rest
+		insert, args := BuildInsertDialect(dialect.SQLiteDialect{}, "api_posts", map[string]any{name: 1})
+		if valid != (len(args) == 1) {
+			t.Fatalf("insert args = %v for %q", args, name)
+		}
+		if !valid && insert != `INSERT INTO "api_posts" () VALUES () RETURNING *` {
+			t.Fatalf("insert SQL = %q", insert)
+		}
+
+		// Single-column statements quote any name as one identifier, shown as I
+		for _, d := range []dialect.Dialect{dialect.PostgresDialect{}, dialect.MySQLDialect{}} {
+			p := d.Placeholder
+			for _, tt := range []struct{ sql, want string }{
+				{BuildMaxDialect(d, name, name), "SELECT COALESCE(MAX(I), 0) FROM I"},
+				{BuildSetColumnDialect(d, name, name, name), "UPDATE I SET I = " + p(1) + " WHERE I = " + p(2)},
+				{BuildSliceDialect(d, name, name, name), "SELECT LENGTH(I), SUBSTR(I, " + p(1) + ", " + p(2) + ") FROM I WHERE I = " + p(3)},
+				{BuildCountDialect(d, name, []string{name, name}, name+"_id"), "SELECT COUNT(*) FROM I WHERE I = " + p(1) + " AND I = " + p(2) + " AND I != " + p(3)},
+				{BuildCountInScopeDialect(d, name, name, "", 0), "SELECT COUNT(*) FROM I WHERE I = " + p(1)},
+			} {
+				if got := identifiersOf(d, tt.sql); got != tt.want {
+					t.Fatalf("%s SQL = %q, want %q", d.Name(), tt.sql, tt.want)
+				}
+			}
+		}
+	})
+}
+
+// identifiersOf replaces the quoted identifiers of querySQL with I. An
+// unterminated identifier is left as is.
+func identifiersOf(d dialect.Dialect, querySQL string) string {
+	quote := d.QuoteIdent("")[0]
+	var sb strings.Builder
+	for i := 0; i < len(querySQL); i++ {
+		if querySQL[i] != quote {
+			sb.WriteByte(querySQL[i])
+			continue
+		}
+		end := i + 1
+		for end < len(querySQL) && (querySQL[end] != quote || end+1 < len(querySQL) && querySQL[end+1] == quote) {
+			if querySQL[end] == quote {
+				end++
+			}
+			end++
+		}
+		if end >= len(querySQL) {
+			return querySQL
+		}
+		sb.WriteString("I")
+		i = end
+	}
+	return sb.String()
+}
@@ -0,0 +1,87 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// ExpansionColumnPrefix prefixes the result columns holding embedded relations.
+const ExpansionColumnPrefix = "__expand_"
+
+// Expansion embeds a to-one relation in each selected row as a JSON object,
+// fetched by a correlated subquery instead of a separate query.
+type Expansion struct {
+	// Name is the key the related object is returned under.
+	Name string
+
+	// Table is the related table name.
+	Table string
+
+	// LocalColumn is the foreign key column on the base table.
+	LocalColumn string
+
+	// ForeignColumn is the referenced column on the related table.
+	ForeignColumn string
+
+	// Fields lists the related columns to include. PostgreSQL includes
+	// the whole row instead.
+	Fields []string
+}
+
+// Column returns the result column holding the embedded object.
+func (e Expansion) Column() string {
+	return ExpansionColumnPrefix + e.Name
+}
+
+// WithExpansions embeds to-one relations in the rows returned by BuildSelect.
+func (b *Builder) WithExpansions(expansions []Expansion) *Builder {
+	b.expansions = expansions
+	return b
+}
+
+// expansionCols returns the select expressions of the valid expansions.
+func (b *Builder) expansionCols() []string {
+	cols := make([]string, 0, len(b.expansions))
+	for _, e := range b.expansions {
+		if sanitizeIdentifier(e.Name) == "" || sanitizeIdentifier(e.Table) == "" ||
+			sanitizeIdentifier(e.LocalColumn) == "" || sanitizeIdentifier(e.ForeignColumn) == "" {
+			continue
+		}
+		if expr := expansionSQL(b.dialect, b.tableName, e); expr != "" {
+			cols = append(cols, expr)
+		}
+	}
+	return cols
+}
+
+// expansionSQL builds the correlated subquery selecting a related row as JSON.
+func expansionSQL(d dialect.Dialect, baseTable string, e Expansion) string {
+	alias := "exp_" + e.Name
+
+	var object string
+	if d.Name() == dialect.Postgres {
+		object = "row_to_json(" + d.QuoteIdent(alias) + ")"
+	} else {
+		pairs := make([]string, 0, len(e.Fields)*2)
+		for _, f := range e.Fields {
+			if sanitizeIdentifier(f) == "" {
+				continue
+			}
+			pairs = append(pairs, "'"+f+"'", quoteColumn(d, alias, f))
+		}
+		if len(pairs) == 0 {
+			return ""
+		}
+		fn := "json_object"
+		if d.Name() == dialect.MySQL {
+			fn = "JSON_OBJECT"
+		}
+		object = fn + "(" + strings.Join(pairs, ", ") + ")"
+	}
+
+	return fmt.Sprintf("(SELECT %s FROM %s AS %s WHERE %s = %s LIMIT 1) AS %s",
+		object, d.QuoteIdent(e.Table), d.QuoteIdent(alias), quoteColumn(d, alias, e.ForeignColumn),
+		quoteColumn(d, baseTable, e.LocalColumn), d.QuoteIdent(e.Column()))
+}
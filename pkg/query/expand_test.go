@@ -0,0 +1,64 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+func TestBuilder_Expansions(t *testing.T) {
+	author := Expansion{
+		Name:          "author",
+		Table:         "api_authors",
+		LocalColumn:   "author_id",
+		ForeignColumn: "id",
+		Fields:        []string{"id", "name"},
+	}
+
+	tests := []struct {
+		name       string
+		dialect    dialect.Dialect
+		expansions []Expansion
+		want       string
+	}{
+		{
+			name:       "postgres embeds whole row",
+			dialect:    dialect.PostgresDialect{},
+			expansions: []Expansion{author},
+			want: `SELECT *, (SELECT row_to_json("exp_author") FROM "api_authors" AS "exp_author"` +
+				` WHERE "exp_author"."id" = "api_posts"."author_id" LIMIT 1) AS "__expand_author"` +
+				` FROM "api_posts" LIMIT 20 OFFSET 0`,
+		},
+		{
+			name:       "sqlite lists fields",
+			dialect:    dialect.SQLiteDialect{},
+			expansions: []Expansion{author},
+			want: `SELECT *, (SELECT json_object('id', "exp_author"."id", 'name', "exp_author"."name")` +
+				` FROM "api_authors" AS "exp_author" WHERE "exp_author"."id" = "api_posts"."author_id" LIMIT 1) AS "__expand_author"` +
+				` FROM "api_posts" LIMIT 20 OFFSET 0`,
+		},
+		{
+			name:       "mysql lists fields",
+			dialect:    dialect.MySQLDialect{},
+			expansions: []Expansion{author},
+			want: "SELECT *, (SELECT JSON_OBJECT('id', `exp_author`.`id`, 'name', `exp_author`.`name`)" +
+				" FROM `api_authors` AS `exp_author` WHERE `exp_author`.`id` = `api_posts`.`author_id` LIMIT 1) AS `__expand_author`" +
+				" FROM `api_posts` LIMIT 20 OFFSET 0",
+		},
+		{
+			name:       "invalid identifiers skipped",
+			dialect:    dialect.PostgresDialect{},
+			expansions: []Expansion{{Name: "x;", Table: "api_authors", LocalColumn: "author_id", ForeignColumn: "id"}},
+			want:       `SELECT * FROM "api_posts" LIMIT 20 OFFSET 0`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _ := NewBuilder("api_posts").WithDialect(tt.dialect).WithExpansions(tt.expansions).BuildSelect()
+			if sql != tt.want {
+				t.Errorf("expected SQL %q, got %q", tt.want, sql)
+			}
+		})
+	}
+}
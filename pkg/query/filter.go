@@ -3,9 +3,11 @@ package query
 import (
 	"fmt"
 	"regexp"
+	"sort"
 	"strings"
 
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
 // FilterOperator represents a filter comparison operator.
@@ -22,6 +24,17 @@ const (
 	OpIn           FilterOperator = "in"
 	OpIsNull       FilterOperator = "null"
 	OpIsNotNull    FilterOperator = "notnull"
+
+	// OpCosineLessThan matches pgvector values whose cosine distance to a
+	// vector is below a maximum, with a VectorDistance value.
+	OpCosineLessThan FilterOperator = "_cosine_lt"
+
+	// OpCaseInsensitiveEqual matches values equal to a string ignoring case.
+	OpCaseInsensitiveEqual FilterOperator = "_ieq"
+
+	// OpSimilar matches values whose pg_trgm similarity to a string reaches
+	// a threshold, with a Similarity value.
+	OpSimilar FilterOperator = "_similar"
 )
 
 // operatorSQL maps operators to SQL operators.
@@ -36,6 +49,10 @@ var operatorSQL = map[FilterOperator]string{
 	OpIn:           "IN",
 	OpIsNull:       "IS NULL",
 	OpIsNotNull:    "IS NOT NULL",
+
+	OpCosineLessThan:       "<=>",
+	OpCaseInsensitiveEqual: "=",
+	OpSimilar:              "%",
 }
 
 // Filter represents a single filter condition.
@@ -45,6 +62,54 @@ type Filter struct {
 	Value    any
 }
 
+// VectorDistance is the value of a vector distance filter: the vector
+// compared with, in pgvector's text form, and the maximum distance.
+type VectorDistance struct {
+	Vector string
+	Max    float64
+}
+
+// DefaultSimilarityThreshold is pg_trgm's default similarity threshold.
+// Similarity filters with a threshold at least this high can use trigram
+// indexes.
+const DefaultSimilarityThreshold = 0.3
+
+// Similarity is the value of a similarity filter: the text compared with
+// and the minimum similarity, between 0 and 1.
+type Similarity struct {
+	Text      string
+	Threshold float64
+}
+
+// filterKeyRegex matches filter[field] and filter[field:op] parameter names.
+var filterKeyRegex = regexp.MustCompile(`^filter\[([a-zA-Z_][a-zA-Z0-9_]*)(?::([a-z_]+))?\]$`)
+
+// IsFilterKey reports whether a query parameter name has the form
+// filter[field] or filter[field:op].
+func IsFilterKey(key string) bool {
+	return filterKeyRegex.MatchString(key)
+}
+
+// FilterKeyField returns the field of a filter[field] or filter[field:op]
+// parameter name.
+func FilterKeyField(key string) (string, bool) {
+	matches := filterKeyRegex.FindStringSubmatch(key)
+	if matches == nil {
+		return "", false
+	}
+	return matches[1], true
+}
+
+// FilterOperators returns the names of the supported filter operators.
+func FilterOperators() []string {
+	ops := make([]string, 0, len(operatorSQL))
+	for op := range operatorSQL {
+		ops = append(ops, string(op))
+	}
+	sort.Strings(ops)
+	return ops
+}
+
 // FilterParser parses filter query parameters.
 type FilterParser struct {
 	allowedFields map[string]bool
@@ -63,10 +128,9 @@ func NewFilterParser(allowedFields []string) *FilterParser {
 // Expected format: filter[field]=value or filter[field:op]=value
 func (p *FilterParser) Parse(params map[string][]string) ([]Filter, error) {
 	filters := make([]Filter, 0)
-	filterRegex := regexp.MustCompile(`^filter\[([a-zA-Z_][a-zA-Z0-9_]*)(?::([a-z]+))?\]$`)
 
 	for key, values := range params {
-		matches := filterRegex.FindStringSubmatch(key)
+		matches := filterKeyRegex.FindStringSubmatch(key)
 		if matches == nil {
 			continue
 		}
@@ -84,7 +148,9 @@ func (p *FilterParser) Parse(params map[string][]string) ([]Filter, error) {
 
 		op := FilterOperator(opStr)
 		if _, ok := operatorSQL[op]; !ok {
-			return nil, apperror.ErrInvalidFilter.WithMessagef("Unknown operator '%s'", opStr)
+			return nil, apperror.ErrInvalidFilter.WithMessagef("Unknown operator '%s'", opStr).WithDetails(map[string]any{
+				"operators": FilterOperators(),
+			})
 		}
 
 		value := values[0]
@@ -100,6 +166,16 @@ func (p *FilterParser) Parse(params map[string][]string) ([]Filter, error) {
 
 // ToSQL converts filters to SQL WHERE conditions.
 func FiltersToSQL(filters []Filter, startParam int) (string, []any) {
+	return FiltersToSQLDialect(dialect.Default(), filters, startParam)
+}
+
+// FiltersToSQLDialect converts filters to SQL WHERE conditions for a dialect.
+func FiltersToSQLDialect(d dialect.Dialect, filters []Filter, startParam int) (string, []any) {
+	return filtersToSQL(d, filters, startParam, "")
+}
+
+// filtersToSQL converts filters to SQL, qualifying fields when qualifier is set.
+func filtersToSQL(d dialect.Dialect, filters []Filter, startParam int, qualifier string) (string, []any) {
 	if len(filters) == 0 {
 		return "", nil
 	}
@@ -109,7 +185,7 @@ func FiltersToSQL(filters []Filter, startParam int) (string, []any) {
 	paramNum := startParam
 
 	for _, f := range filters {
-		condition, filterArgs := filterToSQL(f, paramNum)
+		condition, filterArgs := filterToSQL(d, f, paramNum, qualifier)
 		conditions = append(conditions, condition)
 		args = append(args, filterArgs...)
 		paramNum += len(filterArgs)
@@ -119,8 +195,8 @@ func FiltersToSQL(filters []Filter, startParam int) (string, []any) {
 }
 
 // filterToSQL converts a single filter to SQL.
-func filterToSQL(f Filter, paramNum int) (string, []any) {
-	field := sanitizeIdentifier(f.Field)
+func filterToSQL(d dialect.Dialect, f Filter, paramNum int, qualifier string) (string, []any) {
+	field := quoteColumn(d, qualifier, sanitizeIdentifier(f.Field))
 
 	switch f.Operator {
 	case OpIsNull:
@@ -130,22 +206,54 @@ func filterToSQL(f Filter, paramNum int) (string, []any) {
 		return fmt.Sprintf("%s IS NOT NULL", field), nil
 
 	case OpLike:
-		return fmt.Sprintf("%s ILIKE $%d", field, paramNum), []any{"%" + f.Value.(string) + "%"}
+		return fmt.Sprintf("%s %s %s", field, d.LikeOperator(), d.Placeholder(paramNum)), []any{"%" + f.Value.(string) + "%"}
 
 	case OpIn:
-		values := strings.Split(f.Value.(string), ",")
-		placeholders := make([]string, len(values))
-		args := make([]any, len(values))
-		for i, v := range values {
-			placeholders[i] = fmt.Sprintf("$%d", paramNum+i)
-			args[i] = strings.TrimSpace(v)
+		args, ok := f.Value.([]any)
+		if !ok {
+			values := strings.Split(f.Value.(string), ",")
+			args = make([]any, len(values))
+			for i, v := range values {
+				args[i] = strings.TrimSpace(v)
+			}
+		}
+		placeholders := make([]string, len(args))
+		for i := range args {
+			placeholders[i] = d.Placeholder(paramNum + i)
 		}
 		return fmt.Sprintf("%s IN (%s)", field, strings.Join(placeholders, ", ")), args
 
+	case OpCosineLessThan:
+		distance, _ := f.Value.(VectorDistance)
+		return fmt.Sprintf("(%s <=> %s::vector) < %s", field, d.Placeholder(paramNum), d.Placeholder(paramNum+1)),
+			[]any{distance.Vector, distance.Max}
+
+	case OpCaseInsensitiveEqual:
+		return fmt.Sprintf("LOWER(%s) = LOWER(%s)", field, d.Placeholder(paramNum)), []any{f.Value}
+
+	case OpSimilar:
+		similar, _ := f.Value.(Similarity)
+		condition := fmt.Sprintf("similarity(%s, %s) >= %s", field, d.Placeholder(paramNum), d.Placeholder(paramNum+1))
+		if similar.Threshold < DefaultSimilarityThreshold {
+			return condition, []any{similar.Text, similar.Threshold}
+		}
+		// The % operator lets trigram indexes narrow the rows down first.
+		return fmt.Sprintf("(%s %% %s AND %s)", field, d.Placeholder(paramNum+2), condition),
+			[]any{similar.Text, similar.Threshold, similar.Text}
+
 	default:
 		sqlOp := operatorSQL[f.Operator]
-		return fmt.Sprintf("%s %s $%d", field, sqlOp, paramNum), []any{f.Value}
+		return fmt.Sprintf("%s %s %s", field, sqlOp, d.Placeholder(paramNum)), []any{f.Value}
+	}
+}
+
+// quoteColumn quotes a column for a dialect, qualified by a table name or
+// alias when one is given.
+func quoteColumn(d dialect.Dialect, qualifier, column string) string {
+	if qualifier == "" {
+		return d.QuoteIdent(column)
 	}
+	return d.QuoteIdent(qualifier) + "." + d.QuoteIdent(column)
 }
 
 // sanitizeIdentifier ensures a field name is safe for SQL.
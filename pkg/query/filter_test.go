@@ -2,6 +2,8 @@ package query
 
 import (
 	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
 func TestFilterParser_Parse(t *testing.T) {
@@ -198,7 +200,7 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "name", Operator: OpEqual, Value: "John"},
 			},
 			startParam: 1,
-			wantSQL:    "name = $1",
+			wantSQL:    `"name" = $1`,
 			wantArgs:   1,
 		},
 		{
@@ -207,7 +209,7 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "deleted_at", Operator: OpIsNull, Value: "true"},
 			},
 			startParam: 1,
-			wantSQL:    "deleted_at IS NULL",
+			wantSQL:    `"deleted_at" IS NULL`,
 			wantArgs:   0,
 		},
 		{
@@ -216,7 +218,7 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "email", Operator: OpIsNotNull, Value: "true"},
 			},
 			startParam: 1,
-			wantSQL:    "email IS NOT NULL",
+			wantSQL:    `"email" IS NOT NULL`,
 			wantArgs:   0,
 		},
 		{
@@ -225,7 +227,7 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "name", Operator: OpLike, Value: "john"},
 			},
 			startParam: 1,
-			wantSQL:    "name ILIKE $1",
+			wantSQL:    `"name" ILIKE $1`,
 			wantArgs:   1,
 		},
 		{
@@ -234,7 +236,34 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "status", Operator: OpIn, Value: "active,pending"},
 			},
 			startParam: 1,
-			wantSQL:    "status IN ($1, $2)",
+			wantSQL:    `"status" IN ($1, $2)`,
+			wantArgs:   2,
+		},
+		{
+			name: "case-insensitive equality lowers both sides",
+			filters: []Filter{
+				{Field: "email", Operator: OpCaseInsensitiveEqual, Value: "Ann@Example.com"},
+			},
+			startParam: 1,
+			wantSQL:    `LOWER("email") = LOWER($1)`,
+			wantArgs:   1,
+		},
+		{
+			name: "similarity filter uses trigram operator",
+			filters: []Filter{
+				{Field: "name", Operator: OpSimilar, Value: Similarity{Text: "jon", Threshold: 0.5}},
+			},
+			startParam: 1,
+			wantSQL:    `("name" % $3 AND similarity("name", $1) >= $2)`,
+			wantArgs:   3,
+		},
+		{
+			name: "low similarity threshold skips trigram operator",
+			filters: []Filter{
+				{Field: "name", Operator: OpSimilar, Value: Similarity{Text: "jon", Threshold: 0.1}},
+			},
+			startParam: 1,
+			wantSQL:    `similarity("name", $1) >= $2`,
 			wantArgs:   2,
 		},
 		{
@@ -244,7 +273,7 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "price", Operator: OpGreaterThan, Value: "100"},
 			},
 			startParam: 1,
-			wantSQL:    "status = $1 AND price > $2",
+			wantSQL:    `"status" = $1 AND "price" > $2`,
 			wantArgs:   2,
 		},
 		{
@@ -253,7 +282,7 @@ func TestFiltersToSQL(t *testing.T) {
 				{Field: "name", Operator: OpEqual, Value: "test"},
 			},
 			startParam: 5,
-			wantSQL:    "name = $5",
+			wantSQL:    `"name" = $5`,
 			wantArgs:   1,
 		},
 	}
@@ -299,3 +328,40 @@ func TestSanitizeIdentifier(t *testing.T) {
 		})
 	}
 }
+
+func TestFiltersToSQLDialect(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		filters []Filter
+		wantSQL string
+	}{
+		{
+			name:    "postgres placeholders and ILIKE",
+			dialect: dialect.PostgresDialect{},
+			filters: []Filter{
+				{Field: "name", Operator: OpLike, Value: "john"},
+				{Field: "status", Operator: OpIn, Value: "a,b"},
+			},
+			wantSQL: `"name" ILIKE $1 AND "status" IN ($2, $3)`,
+		},
+		{
+			name:    "mysql placeholders and LIKE",
+			dialect: dialect.MySQLDialect{},
+			filters: []Filter{
+				{Field: "name", Operator: OpLike, Value: "john"},
+				{Field: "status", Operator: OpIn, Value: "a,b"},
+			},
+			wantSQL: "`name` LIKE ? AND `status` IN (?, ?)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, _ := FiltersToSQLDialect(tt.dialect, tt.filters, 1)
+			if sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+		})
+	}
+}
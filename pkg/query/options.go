@@ -131,7 +131,7 @@ func (o Options) ToQueryParams() url.Values {
 	if o.Pagination.Page > 1 {
 		params.Set("page", strconv.Itoa(o.Pagination.Page))
 	}
-	if o.Pagination.Limit != 20 {
+	if o.Pagination.Limit != DefaultPaginationLimits().DefaultLimit {
 		params.Set("limit", strconv.Itoa(o.Pagination.Limit))
 	}
 
@@ -0,0 +1,354 @@
+package query
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// MaxProjections caps the computed fields of one request.
+const MaxProjections = 16
+
+// maxProjectionLength and maxProjectionDepth bound the size and nesting of
+// a projection expression.
+const (
+	maxProjectionLength = 256
+	maxProjectionDepth  = 16
+)
+
+// plainFieldRegex matches field list entries that name a field, optionally
+// excluded with a - prefix.
+var plainFieldRegex = regexp.MustCompile(`^-?[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// projectionAliasRegex matches the names computed fields may be given.
+var projectionAliasRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]{0,62}$`)
+
+// projectionFunc describes a function allowed in projections.
+type projectionFunc struct {
+	minArgs, maxArgs int
+}
+
+// projectionFuncs lists the functions allowed in projections, available
+// under the same name in PostgreSQL, MySQL and SQLite.
+var projectionFuncs = map[string]projectionFunc{
+	"lower":    {1, 1},
+	"upper":    {1, 1},
+	"trim":     {1, 1},
+	"length":   {1, 1},
+	"abs":      {1, 1},
+	"round":    {1, 2},
+	"coalesce": {2, 8},
+}
+
+// Projection is a computed field: an expression over the fields of a row,
+// returned under Alias.
+type Projection struct {
+	Alias string
+
+	// Fields lists the columns the expression reads.
+	Fields []string
+
+	expr projectionNode
+}
+
+// SQL returns the select expression of the projection, its columns
+// prefixed with the qualifier when one is given.
+func (p Projection) SQL(d dialect.Dialect, qualifier string) string {
+	return p.expr.sql(d, qualifier) + " AS " + d.QuoteIdent(p.Alias)
+}
+
+// IsPlainField reports whether a field list entry names a field rather
+// than a projection.
+func IsPlainField(entry string) bool {
+	return plainFieldRegex.MatchString(strings.TrimSpace(entry))
+}
+
+// SplitFields splits a comma-separated field list, keeping the commas of
+// function arguments within their entry.
+func SplitFields(value string) []string {
+	var entries []string
+	depth, start := 0, 0
+	for i, r := range value {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				entries = append(entries, value[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(entries, value[start:])
+}
+
+// ParseProjection parses a projection such as "price*1.2 as with_tax" or
+// "upper(status)". Expressions combine fields, numbers, + - * /,
+// parentheses and the functions lower, upper, trim, length, abs, round and
+// coalesce. Without "as", the projection is named after its expression
+// with spaces removed. resolve returns the column of a field name, or
+// false for unknown fields.
+func ParseProjection(entry string, resolve func(name string) (string, bool)) (Projection, error) {
+	entry = strings.TrimSpace(entry)
+	if len(entry) > maxProjectionLength {
+		return Projection{}, apperror.ErrBadRequest.WithMessagef("Projection is longer than %d characters", maxProjectionLength)
+	}
+	tokens, err := tokenizeProjection(entry)
+	if err != nil {
+		return Projection{}, apperror.ErrBadRequest.WithMessagef("Invalid projection '%s': %s", entry, err)
+	}
+
+	alias := ""
+	if n := len(tokens); n >= 3 && tokens[n-2].kind == tokenIdent && strings.EqualFold(tokens[n-2].text, "as") {
+		if tokens[n-1].kind != tokenIdent || !projectionAliasRegex.MatchString(tokens[n-1].text) {
+			return Projection{}, apperror.ErrBadRequest.WithMessagef("Invalid projection name '%s'", tokens[n-1].text)
+		}
+		alias, tokens = tokens[n-1].text, tokens[:n-2]
+	}
+
+	p := &projectionParser{tokens: tokens, resolve: resolve}
+	expr, err := p.parseExpr(0)
+	if err == nil && p.pos < len(p.tokens) {
+		err = fmt.Errorf("unexpected '%s'", p.tokens[p.pos].text)
+	}
+	if err != nil {
+		return Projection{}, apperror.ErrBadRequest.WithMessagef("Invalid projection '%s': %s", entry, err)
+	}
+
+	if alias == "" {
+		texts := make([]string, len(tokens))
+		for i, t := range tokens {
+			texts[i] = t.text
+		}
+		alias = strings.Join(texts, "")
+	}
+	return Projection{Alias: alias, Fields: p.fields, expr: expr}, nil
+}
+
+// projectionTokenKind classifies the tokens of a projection.
+type projectionTokenKind int
+
+const (
+	tokenIdent projectionTokenKind = iota
+	tokenNumber
+	tokenSymbol
+)
+
+// projectionToken is a token of a projection.
+type projectionToken struct {
+	kind projectionTokenKind
+	text string
+}
+
+// projectionTokenRegex matches the next token of a projection.
+var projectionTokenRegex = regexp.MustCompile(`^(?:([a-zA-Z_][a-zA-Z0-9_]*)|([0-9]+(?:\.[0-9]+)?)|([-+*/(),]))`)
+
+// tokenizeProjection splits a projection into identifiers, numbers and
+// symbols, rejecting any other character.
+func tokenizeProjection(s string) ([]projectionToken, error) {
+	var tokens []projectionToken
+	for {
+		s = strings.TrimLeft(s, " \t")
+		if s == "" {
+			return tokens, nil
+		}
+		m := projectionTokenRegex.FindStringSubmatch(s)
+		switch {
+		case m == nil:
+			return nil, fmt.Errorf("unexpected '%c'", s[0])
+		case m[1] != "":
+			tokens = append(tokens, projectionToken{tokenIdent, m[1]})
+		case m[2] != "":
+			tokens = append(tokens, projectionToken{tokenNumber, m[2]})
+		default:
+			tokens = append(tokens, projectionToken{tokenSymbol, m[3]})
+		}
+		s = s[len(m[0]):]
+	}
+}
+
+// projectionParser parses projection tokens by recursive descent.
+type projectionParser struct {
+	tokens  []projectionToken
+	pos     int
+	resolve func(string) (string, bool)
+	fields  []string
+}
+
+// peek returns the text of the next symbol, or "" at the end or before
+// another kind of token.
+func (p *projectionParser) peek() string {
+	if p.pos < len(p.tokens) && p.tokens[p.pos].kind == tokenSymbol {
+		return p.tokens[p.pos].text
+	}
+	return ""
+}
+
+// parseExpr parses terms joined by + and -.
+func (p *projectionParser) parseExpr(depth int) (projectionNode, error) {
+	if depth > maxProjectionDepth {
+		return nil, fmt.Errorf("expression is nested too deeply")
+	}
+	left, err := p.parseTerm(depth)
+	for err == nil && (p.peek() == "+" || p.peek() == "-") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		var right projectionNode
+		if right, err = p.parseTerm(depth); err == nil {
+			left = projectionBinary{op: op, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseTerm parses factors joined by * and /.
+func (p *projectionParser) parseTerm(depth int) (projectionNode, error) {
+	left, err := p.parseFactor(depth)
+	for err == nil && (p.peek() == "*" || p.peek() == "/") {
+		op := p.tokens[p.pos].text
+		p.pos++
+		var right projectionNode
+		if right, err = p.parseFactor(depth); err == nil {
+			left = projectionBinary{op: op, left: left, right: right}
+		}
+	}
+	return left, err
+}
+
+// parseFactor parses a number, a field, a function call, a negation or a
+// parenthesized expression.
+func (p *projectionParser) parseFactor(depth int) (projectionNode, error) {
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	t := p.tokens[p.pos]
+	p.pos++
+
+	switch {
+	case t.kind == tokenNumber:
+		return projectionNumber(t.text), nil
+
+	case t.text == "-":
+		operand, err := p.parseFactor(depth + 1)
+		return projectionNegation{operand}, err
+
+	case t.text == "(":
+		inner, err := p.parseExpr(depth + 1)
+		if err == nil && p.peek() != ")" {
+			err = fmt.Errorf("missing ')'")
+		}
+		p.pos++
+		return inner, err
+
+	case t.kind == tokenIdent && p.peek() == "(":
+		return p.parseCall(strings.ToLower(t.text), depth)
+
+	case t.kind == tokenIdent:
+		column, ok := p.resolve(t.text)
+		if !ok {
+			return nil, fmt.Errorf("unknown field '%s'", t.text)
+		}
+		p.fields = append(p.fields, column)
+		return projectionField(column), nil
+	}
+	return nil, fmt.Errorf("unexpected '%s'", t.text)
+}
+
+// parseCall parses the arguments of an allowed function.
+func (p *projectionParser) parseCall(name string, depth int) (projectionNode, error) {
+	fn, ok := projectionFuncs[name]
+	if !ok {
+		return nil, fmt.Errorf("function '%s' is not allowed", name)
+	}
+	p.pos++ // (
+
+	var args []projectionNode
+	for p.peek() != ")" {
+		if len(args) > 0 {
+			if p.peek() != "," {
+				return nil, fmt.Errorf("missing ')' after arguments of %s", name)
+			}
+			p.pos++
+		}
+		arg, err := p.parseExpr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+	p.pos++ // )
+
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, fmt.Errorf("%s takes %s", name, argCount(fn))
+	}
+	return projectionCall{name: name, args: args}, nil
+}
+
+// argCount describes the number of arguments a function takes.
+func argCount(fn projectionFunc) string {
+	switch {
+	case fn.minArgs == fn.maxArgs && fn.minArgs == 1:
+		return "1 argument"
+	case fn.minArgs == fn.maxArgs:
+		return fmt.Sprintf("%d arguments", fn.minArgs)
+	default:
+		return fmt.Sprintf("%d to %d arguments", fn.minArgs, fn.maxArgs)
+	}
+}
+
+// projectionNode is a parsed projection expression.
+type projectionNode interface {
+	sql(d dialect.Dialect, qualifier string) string
+}
+
+type (
+	projectionNumber   string
+	projectionField    string
+	projectionNegation struct{ operand projectionNode }
+	projectionBinary   struct {
+		op          string
+		left, right projectionNode
+	}
+	projectionCall struct {
+		name string
+		args []projectionNode
+	}
+)
+
+func (n projectionNumber) sql(dialect.Dialect, string) string { return string(n) }
+
+func (n projectionField) sql(d dialect.Dialect, qualifier string) string {
+	return quoteColumn(d, qualifier, string(n))
+}
+
+func (n projectionNegation) sql(d dialect.Dialect, qualifier string) string {
+	return "(-" + n.operand.sql(d, qualifier) + ")"
+}
+
+// sql divides by NULLIF(divisor, 0), so division by zero yields NULL
+// instead of failing the query.
+func (n projectionBinary) sql(d dialect.Dialect, qualifier string) string {
+	left, right := n.left.sql(d, qualifier), n.right.sql(d, qualifier)
+	if n.op == "/" {
+		right = "NULLIF(" + right + ", 0)"
+	}
+	return "(" + left + " " + n.op + " " + right + ")"
+}
+
+// sql uses CHAR_LENGTH for length on MySQL, whose LENGTH counts bytes.
+func (n projectionCall) sql(d dialect.Dialect, qualifier string) string {
+	args := make([]string, len(n.args))
+	for i, arg := range n.args {
+		args[i] = arg.sql(d, qualifier)
+	}
+	name := strings.ToUpper(n.name)
+	if name == "LENGTH" && d.Name() == dialect.MySQL {
+		name = "CHAR_LENGTH"
+	}
+	return name + "(" + strings.Join(args, ", ") + ")"
+}
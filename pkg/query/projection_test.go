@@ -0,0 +1,101 @@
+package query
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+func TestParseProjection(t *testing.T) {
+	resolve := func(name string) (string, bool) {
+		switch name {
+		case "price", "qty", "status", "nickname", "name":
+			return name, true
+		case "cost":
+			return "unit_cost", true
+		}
+		return "", false
+	}
+
+	tests := []struct {
+		name      string
+		entry     string
+		dialect   dialect.Dialect
+		wantSQL   string
+		wantAlias string
+		wantErr   bool
+	}{
+		{"arithmetic with alias", "price*1.2 as price_with_tax", dialect.PostgresDialect{}, `("price" * 1.2) AS "price_with_tax"`, "price_with_tax", false},
+		{"default alias", "upper(status)", dialect.PostgresDialect{}, `UPPER("status") AS "upper(status)"`, "upper(status)", false},
+		{"precedence", "price + qty * 2 AS total", dialect.PostgresDialect{}, `("price" + ("qty" * 2)) AS "total"`, "total", false},
+		{"parentheses", "(price + 1) * qty as t", dialect.PostgresDialect{}, `(("price" + 1) * "qty") AS "t"`, "t", false},
+		{"negation", "-price as neg", dialect.PostgresDialect{}, `(-"price") AS "neg"`, "neg", false},
+		{"division guards zero", "price / qty as unit", dialect.PostgresDialect{}, `("price" / NULLIF("qty", 0)) AS "unit"`, "unit", false},
+		{"function arguments", "round(price * 1.2, 2) as p", dialect.PostgresDialect{}, `ROUND(("price" * 1.2), 2) AS "p"`, "p", false},
+		{"coalesce", "coalesce(nickname, name) as display", dialect.PostgresDialect{}, `COALESCE("nickname", "name") AS "display"`, "display", false},
+		{"resolved column", "cost * qty as spent", dialect.PostgresDialect{}, `("unit_cost" * "qty") AS "spent"`, "spent", false},
+		{"mysql length", "length(name) as n", dialect.MySQLDialect{}, "CHAR_LENGTH(`name`) AS `n`", "n", false},
+		{"unknown field", "secret * 2 as x", dialect.PostgresDialect{}, "", "", true},
+		{"unknown function", "pg_sleep(10) as x", dialect.PostgresDialect{}, "", "", true},
+		{"string literal", "coalesce(name, 'x') as x", dialect.PostgresDialect{}, "", "", true},
+		{"statement injection", "price; DROP TABLE x", dialect.PostgresDialect{}, "", "", true},
+		{"wrong argument count", "upper(name, status) as x", dialect.PostgresDialect{}, "", "", true},
+		{"unbalanced parentheses", "(price + 1 as x", dialect.PostgresDialect{}, "", "", true},
+		{"trailing operator", "price * as x", dialect.PostgresDialect{}, "", "", true},
+		{"invalid alias", "price * 2 as 2x", dialect.PostgresDialect{}, "", "", true},
+		{"too deep", strings.Repeat("(", 20) + "price" + strings.Repeat(")", 20) + " as x", dialect.PostgresDialect{}, "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p, err := ParseProjection(tt.entry, resolve)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseProjection() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if p.Alias != tt.wantAlias {
+				t.Errorf("Alias = %q, want %q", p.Alias, tt.wantAlias)
+			}
+			if got := p.SQL(tt.dialect, ""); got != tt.wantSQL {
+				t.Errorf("SQL() = %q, want %q", got, tt.wantSQL)
+			}
+		})
+	}
+}
+
+func TestSplitFields(t *testing.T) {
+	got := SplitFields("id,name, round(price, 2) as p,coalesce(a,b)")
+	want := []string{"id", "name", " round(price, 2) as p", "coalesce(a,b)"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("SplitFields() = %q, want %q", got, want)
+	}
+}
+
+func TestBuildSelectProjections(t *testing.T) {
+	p, err := ParseProjection("price * 2 as double", func(name string) (string, bool) { return name, true })
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		builder *Builder
+		want    string
+	}{
+		{"after columns", NewBuilder("items").Select("id").WithProjections([]Projection{p}), `SELECT "id", ("price" * 2) AS "double" FROM "items"`},
+		{"projections only", NewBuilder("items").WithProjections([]Projection{p}), `SELECT ("price" * 2) AS "double" FROM "items"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, _ := tt.builder.BuildSelect()
+			if !strings.HasPrefix(got, tt.want) {
+				t.Errorf("BuildSelect() = %q, want prefix %q", got, tt.want)
+			}
+		})
+	}
+}
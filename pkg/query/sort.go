@@ -2,9 +2,11 @@ package query
 
 import (
 	"fmt"
+	"regexp"
 	"strings"
 
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
 // SortDirection represents sort order.
@@ -15,15 +17,86 @@ const (
 	SortDesc SortDirection = "DESC"
 )
 
+// NullsOrder controls where NULL values are placed.
+type NullsOrder string
+
+const (
+	NullsDefault NullsOrder = ""
+	NullsFirst   NullsOrder = "FIRST"
+	NullsLast    NullsOrder = "LAST"
+)
+
+// sortFunctions whitelists the expressions a sort field may be wrapped in.
+var sortFunctions = map[string]string{
+	"lower": "LOWER",
+	"upper": "UPPER",
+}
+
 // Sort represents a single sort specification.
 type Sort struct {
 	Field     string
 	Direction SortDirection
+
+	// Relation names a joined to-one relation when sorting by a related field.
+	Relation string
+
+	// Nulls places NULL values first or last.
+	Nulls NullsOrder
+
+	// Function wraps the field in a whitelisted expression (e.g. "lower").
+	Function string
+
+	// Random shuffles rows instead of ordering by Field.
+	// When Seed is set, Field holds the row key used for a stable shuffle.
+	Random bool
+	Seed   string
+
+	// Vector orders by cosine distance to this vector, in pgvector's text
+	// form, nearest first unless Direction is descending.
+	Vector string
+}
+
+// RandomSortKeyword selects random ordering: ?sort=random or ?sort=random:<seed>.
+const RandomSortKeyword = "random"
+
+// seedRegex restricts random seeds to characters safe to inline as a literal.
+var seedRegex = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+
+// vectorRegex restricts sort vectors to numbers safe to inline as a literal.
+var vectorRegex = regexp.MustCompile(`^\[[0-9eE.,+-]+\]$`)
+
+// Join describes a to-one relation that can be joined for sorting.
+type Join struct {
+	// Name is the relation name used in sort params (e.g. "author").
+	Name string
+
+	// Table is the related table name.
+	Table string
+
+	// LocalColumn is the foreign key column on the base table.
+	LocalColumn string
+
+	// ForeignColumn is the referenced column on the related table.
+	ForeignColumn string
+
+	// Fields lists the related fields allowed for sorting.
+	Fields []string
+}
+
+// Alias returns the table alias used for the joined relation.
+func (j Join) Alias() string {
+	return joinAlias(j.Name)
+}
+
+// joinAlias returns the table alias for a relation name.
+func joinAlias(relation string) string {
+	return "rel_" + relation
 }
 
 // SortParser parses sort query parameters.
 type SortParser struct {
 	allowedFields map[string]bool
+	joins         map[string]Join
 }
 
 // NewSortParser creates a new sort parser.
@@ -35,8 +108,19 @@ func NewSortParser(allowedFields []string) *SortParser {
 	return &SortParser{allowedFields: fieldMap}
 }
 
+// WithJoins enables sorting by fields of the given relations (sort=author.name).
+func (p *SortParser) WithJoins(joins []Join) *SortParser {
+	p.joins = make(map[string]Join, len(joins))
+	for _, j := range joins {
+		p.joins[j.Name] = j
+	}
+	return p
+}
+
 // Parse parses sort parameter.
 // Expected format: ?sort=-created_at,name (- prefix for DESC)
+// Fields may be wrapped in lower()/upper() and followed by :asc|:desc and
+// :nullsfirst|:nullslast modifiers, e.g. ?sort=lower(name):asc:nullslast
 func (p *SortParser) Parse(sortParam string) ([]Sort, error) {
 	if sortParam == "" {
 		return nil, nil
@@ -51,6 +135,15 @@ func (p *SortParser) Parse(sortParam string) ([]Sort, error) {
 			continue
 		}
 
+		// Random ordering: random or random:<seed>
+		if sort, ok, err := p.parseRandom(part); ok {
+			if err != nil {
+				return nil, err
+			}
+			sorts = append(sorts, sort)
+			continue
+		}
+
 		direction := SortAsc
 		field := part
 
@@ -61,38 +154,186 @@ func (p *SortParser) Parse(sortParam string) ([]Sort, error) {
 			field = part[1:]
 		}
 
-		// Validate field name
-		if sanitizeIdentifier(field) == "" {
-			return nil, apperror.ErrInvalidSort.WithMessagef("Invalid field name '%s'", field)
+		// Modifiers: field:asc:nullslast
+		field, modifiers, _ := strings.Cut(field, ":")
+		nulls := NullsDefault
+		if modifiers != "" {
+			for _, mod := range strings.Split(modifiers, ":") {
+				switch strings.ToLower(mod) {
+				case "asc":
+					direction = SortAsc
+				case "desc":
+					direction = SortDesc
+				case "nullsfirst":
+					nulls = NullsFirst
+				case "nullslast":
+					nulls = NullsLast
+				default:
+					return nil, apperror.ErrInvalidSort.WithMessagef("Unknown sort modifier '%s'", mod)
+				}
+			}
 		}
 
-		// Validate against allowed fields
-		if len(p.allowedFields) > 0 && !p.allowedFields[field] {
-			return nil, apperror.ErrInvalidSort.WithMessagef("Field '%s' is not allowed for sorting", field)
+		// Expression: lower(field)
+		function := ""
+		if open := strings.Index(field, "("); open != -1 && strings.HasSuffix(field, ")") {
+			function = strings.ToLower(field[:open])
+			if _, ok := sortFunctions[function]; !ok {
+				return nil, apperror.ErrInvalidSort.WithMessagef("Sort function '%s' is not allowed", field[:open])
+			}
+			field = field[open+1 : len(field)-1]
 		}
 
-		sorts = append(sorts, Sort{
-			Field:     field,
-			Direction: direction,
-		})
+		var sort Sort
+		if relation, relField, ok := strings.Cut(field, "."); ok {
+			// Related field: relation.field
+			related, err := p.parseRelated(relation, relField, direction)
+			if err != nil {
+				return nil, err
+			}
+			sort = related
+		} else {
+			// Validate field name
+			if sanitizeIdentifier(field) == "" {
+				return nil, apperror.ErrInvalidSort.WithMessagef("Invalid field name '%s'", field)
+			}
+
+			// Validate against allowed fields
+			if len(p.allowedFields) > 0 && !p.allowedFields[field] {
+				return nil, apperror.ErrInvalidSort.WithMessagef("Field '%s' is not allowed for sorting", field)
+			}
+
+			sort = Sort{
+				Field:     field,
+				Direction: direction,
+			}
+		}
+
+		sort.Nulls = nulls
+		sort.Function = function
+		sorts = append(sorts, sort)
 	}
 
 	return sorts, nil
 }
 
+// parseRandom parses random and random:<seed> sorts.
+// A column literally named "random" takes precedence over the keyword.
+func (p *SortParser) parseRandom(part string) (Sort, bool, error) {
+	keyword, seed, hasSeed := strings.Cut(part, ":")
+	if !strings.EqualFold(keyword, RandomSortKeyword) || (!hasSeed && p.allowedFields[keyword]) {
+		return Sort{}, false, nil
+	}
+	if hasSeed && !seedRegex.MatchString(seed) {
+		return Sort{}, true, apperror.ErrInvalidSort.WithMessagef("Invalid random seed '%s'", seed)
+	}
+	return Sort{Random: true, Seed: seed, Direction: SortAsc}, true, nil
+}
+
+// parseRelated validates a relation.field sort against the configured joins.
+func (p *SortParser) parseRelated(relation, field string, direction SortDirection) (Sort, error) {
+	join, ok := p.joins[relation]
+	if !ok {
+		return Sort{}, apperror.ErrInvalidSort.WithMessagef("Relation '%s' is not available for sorting", relation)
+	}
+
+	if sanitizeIdentifier(field) == "" {
+		return Sort{}, apperror.ErrInvalidSort.WithMessagef("Invalid field name '%s.%s'", relation, field)
+	}
+
+	if len(join.Fields) > 0 {
+		allowed := false
+		for _, f := range join.Fields {
+			if f == field {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			return Sort{}, apperror.ErrInvalidSort.WithMessagef("Field '%s.%s' is not allowed for sorting", relation, field)
+		}
+	}
+
+	return Sort{Field: field, Direction: direction, Relation: relation}, nil
+}
+
 // SortsToSQL converts sorts to SQL ORDER BY clause.
+// Related sorts reference the relation's join alias.
 func SortsToSQL(sorts []Sort) string {
+	return SortsToSQLDialect(dialect.Default(), sorts)
+}
+
+// SortsToSQLDialect converts sorts to SQL ORDER BY clause for a dialect.
+// NULLS FIRST/LAST is emulated with IS NULL ordering where unsupported.
+func SortsToSQLDialect(d dialect.Dialect, sorts []Sort) string {
+	return sortsToSQL(d, sorts, "")
+}
+
+// sortsToSQL converts sorts to SQL, qualifying base-table fields when qualifier is set.
+func sortsToSQL(d dialect.Dialect, sorts []Sort, qualifier string) string {
 	if len(sorts) == 0 {
 		return ""
 	}
 
-	parts := make([]string, len(sorts))
-	for i, s := range sorts {
+	parts := make([]string, 0, len(sorts))
+	for _, s := range sorts {
+		if s.Random {
+			if s.Seed != "" && !seedRegex.MatchString(s.Seed) {
+				continue
+			}
+			key, quotedQualifier := sanitizeIdentifier(s.Field), ""
+			if key != "" {
+				key = d.QuoteIdent(key)
+			}
+			if qualifier != "" {
+				quotedQualifier = d.QuoteIdent(qualifier)
+			}
+			parts = append(parts, d.RandomOrder(quotedQualifier, key, s.Seed))
+			continue
+		}
+
 		field := sanitizeIdentifier(s.Field)
 		if field == "" {
 			continue
 		}
-		parts[i] = fmt.Sprintf("%s %s", field, s.Direction)
+		if s.Relation != "" {
+			if sanitizeIdentifier(s.Relation) == "" {
+				continue
+			}
+			field = quoteColumn(d, joinAlias(s.Relation), field)
+		} else {
+			field = quoteColumn(d, qualifier, field)
+		}
+
+		if s.Function != "" {
+			fn, ok := sortFunctions[s.Function]
+			if !ok {
+				continue
+			}
+			field = fmt.Sprintf("%s(%s)", fn, field)
+		}
+		if s.Vector != "" {
+			if !vectorRegex.MatchString(s.Vector) {
+				continue
+			}
+			field = fmt.Sprintf("%s <=> '%s'::vector", field, s.Vector)
+		}
+
+		direction := SortAsc
+		if s.Direction == SortDesc {
+			direction = SortDesc
+		}
+
+		switch {
+		case s.Nulls == NullsDefault:
+			parts = append(parts, fmt.Sprintf("%s %s", field, direction))
+		case d.SupportsNullsOrdering():
+			parts = append(parts, fmt.Sprintf("%s %s NULLS %s", field, direction, s.Nulls))
+		case s.Nulls == NullsFirst:
+			parts = append(parts, fmt.Sprintf("%s IS NULL DESC, %s %s", field, field, direction))
+		default:
+			parts = append(parts, fmt.Sprintf("%s IS NULL ASC, %s %s", field, field, direction))
+		}
 	}
 
 	return strings.Join(parts, ", ")
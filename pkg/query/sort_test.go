@@ -2,6 +2,8 @@ package query
 
 import (
 	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
 func TestSortParser_Parse(t *testing.T) {
@@ -130,14 +132,14 @@ func TestSortsToSQL(t *testing.T) {
 			sorts: []Sort{
 				{Field: "name", Direction: SortAsc},
 			},
-			wantSQL: "name ASC",
+			wantSQL: `"name" ASC`,
 		},
 		{
 			name: "single descending",
 			sorts: []Sort{
 				{Field: "created_at", Direction: SortDesc},
 			},
-			wantSQL: "created_at DESC",
+			wantSQL: `"created_at" DESC`,
 		},
 		{
 			name: "multiple sorts",
@@ -145,7 +147,7 @@ func TestSortsToSQL(t *testing.T) {
 				{Field: "created_at", Direction: SortDesc},
 				{Field: "name", Direction: SortAsc},
 			},
-			wantSQL: "created_at DESC, name ASC",
+			wantSQL: `"created_at" DESC, "name" ASC`,
 		},
 	}
 
@@ -196,3 +198,305 @@ func TestDefaultSort(t *testing.T) {
 		})
 	}
 }
+
+func TestSortParser_ParseRelated(t *testing.T) {
+	joins := []Join{
+		{Name: "author", Table: "api_authors", LocalColumn: "author_id", ForeignColumn: "id", Fields: []string{"id", "name"}},
+	}
+
+	tests := []struct {
+		name      string
+		sortParam string
+		wantErr   bool
+		checkSort func(sorts []Sort) bool
+	}{
+		{
+			name:      "related field descending",
+			sortParam: "-author.name",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Relation == "author" && sorts[0].Field == "name" && sorts[0].Direction == SortDesc
+			},
+		},
+		{
+			name:      "mixed base and related fields",
+			sortParam: "author.name,title",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Relation == "author" && sorts[1].Relation == "" && sorts[1].Field == "title"
+			},
+		},
+		{
+			name:      "unknown relation",
+			sortParam: "category.priority",
+			wantErr:   true,
+		},
+		{
+			name:      "disallowed related field",
+			sortParam: "author.password",
+			wantErr:   true,
+		},
+		{
+			name:      "invalid related field",
+			sortParam: "author.name;DROP",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorts, err := NewSortParser([]string{"title"}).WithJoins(joins).Parse(tt.sortParam)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.checkSort != nil && !tt.checkSort(sorts) {
+				t.Errorf("sort check failed: %+v", sorts)
+			}
+		})
+	}
+}
+
+func TestBuilder_RelatedSort(t *testing.T) {
+	joins := []Join{
+		{Name: "author", Table: "api_authors", LocalColumn: "author_id", ForeignColumn: "id"},
+		{Name: "category", Table: "api_categories", LocalColumn: "category_id", ForeignColumn: "id"},
+	}
+
+	sql, args := NewBuilder("api_posts").
+		WithJoins(joins).
+		Where([]Filter{{Field: "status", Operator: OpEqual, Value: "published"}}).
+		OrderBy([]Sort{
+			{Field: "name", Direction: SortAsc, Relation: "author"},
+			{Field: "id", Direction: SortDesc},
+		}).
+		BuildSelect()
+
+	want := `SELECT "api_posts".* FROM "api_posts"` +
+		` LEFT JOIN "api_authors" AS "rel_author" ON "rel_author"."id" = "api_posts"."author_id"` +
+		` WHERE "api_posts"."status" = $1` +
+		` ORDER BY "rel_author"."name" ASC, "api_posts"."id" DESC LIMIT 20 OFFSET 0`
+	if sql != want {
+		t.Errorf("expected SQL %q, got %q", want, sql)
+	}
+	if len(args) != 1 {
+		t.Errorf("expected 1 arg, got %d", len(args))
+	}
+}
+
+func TestSortParser_ParseModifiers(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortParam string
+		wantErr   bool
+		checkSort func(sorts []Sort) bool
+	}{
+		{
+			name:      "nulls last",
+			sortParam: "name:asc:nullslast",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Field == "name" && sorts[0].Direction == SortAsc && sorts[0].Nulls == NullsLast
+			},
+		},
+		{
+			name:      "desc modifier with nulls first",
+			sortParam: "name:nullsfirst:desc",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Direction == SortDesc && sorts[0].Nulls == NullsFirst
+			},
+		},
+		{
+			name:      "lower expression",
+			sortParam: "-lower(name)",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Field == "name" && sorts[0].Function == "lower" && sorts[0].Direction == SortDesc
+			},
+		},
+		{
+			name:      "unknown modifier",
+			sortParam: "name:sideways",
+			wantErr:   true,
+		},
+		{
+			name:      "function not whitelisted",
+			sortParam: "md5(name)",
+			wantErr:   true,
+		},
+		{
+			name:      "injection inside function",
+			sortParam: "lower(name) desc; DROP TABLE x)",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorts, err := NewSortParser([]string{"name"}).Parse(tt.sortParam)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.checkSort != nil && !tt.checkSort(sorts) {
+				t.Errorf("sort check failed: %+v", sorts)
+			}
+		})
+	}
+}
+
+func TestSortsToSQLDialect_Modifiers(t *testing.T) {
+	sorts := []Sort{
+		{Field: "name", Direction: SortAsc, Function: "lower", Nulls: NullsLast},
+		{Field: "rank", Direction: SortDesc, Nulls: NullsFirst},
+	}
+
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		wantSQL string
+	}{
+		{
+			name:    "postgres",
+			dialect: dialect.PostgresDialect{},
+			wantSQL: `LOWER("name") ASC NULLS LAST, "rank" DESC NULLS FIRST`,
+		},
+		{
+			name:    "mysql emulation",
+			dialect: dialect.MySQLDialect{},
+			wantSQL: "LOWER(`name`) IS NULL ASC, LOWER(`name`) ASC, `rank` IS NULL DESC, `rank` DESC",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sql := SortsToSQLDialect(tt.dialect, sorts); sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+		})
+	}
+}
+
+func TestSortParser_ParseRandom(t *testing.T) {
+	tests := []struct {
+		name      string
+		sortParam string
+		allowed   []string
+		wantErr   bool
+		checkSort func(sorts []Sort) bool
+	}{
+		{
+			name:      "random",
+			sortParam: "random",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Random && sorts[0].Seed == ""
+			},
+		},
+		{
+			name:      "seeded random",
+			sortParam: "random:seed123",
+			checkSort: func(sorts []Sort) bool {
+				return sorts[0].Random && sorts[0].Seed == "seed123"
+			},
+		},
+		{
+			name:      "column named random wins",
+			sortParam: "random",
+			allowed:   []string{"random"},
+			checkSort: func(sorts []Sort) bool {
+				return !sorts[0].Random && sorts[0].Field == "random"
+			},
+		},
+		{
+			name:      "unsafe seed",
+			sortParam: "random:x');DROP TABLE y;--",
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sorts, err := NewSortParser(tt.allowed).Parse(tt.sortParam)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if tt.checkSort != nil && !tt.checkSort(sorts) {
+				t.Errorf("sort check failed: %+v", sorts)
+			}
+		})
+	}
+}
+
+func TestSortsToSQLDialect_Random(t *testing.T) {
+	tests := []struct {
+		name    string
+		dialect dialect.Dialect
+		sort    Sort
+		wantSQL string
+	}{
+		{
+			name:    "postgres random",
+			dialect: dialect.PostgresDialect{},
+			sort:    Sort{Random: true, Field: "id"},
+			wantSQL: "random()",
+		},
+		{
+			name:    "postgres seeded",
+			dialect: dialect.PostgresDialect{},
+			sort:    Sort{Random: true, Field: "id", Seed: "abc"},
+			wantSQL: `md5("id"::text || 'abc')`,
+		},
+		{
+			name:    "mysql seeded",
+			dialect: dialect.MySQLDialect{},
+			sort:    Sort{Random: true, Field: "id", Seed: "abc"},
+			wantSQL: "MD5(CONCAT(`id`, 'abc'))",
+		},
+		{
+			name:    "unsafe seed dropped",
+			dialect: dialect.PostgresDialect{},
+			sort:    Sort{Random: true, Field: "id", Seed: "a'b"},
+			wantSQL: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sql := SortsToSQLDialect(tt.dialect, []Sort{tt.sort}); sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+		})
+	}
+}
+
+func TestSortsToSQL_Vector(t *testing.T) {
+	tests := []struct {
+		name    string
+		sort    Sort
+		wantSQL string
+	}{
+		{"nearest first", Sort{Field: "embedding", Vector: "[0.1,-0.2,3e-05]"}, `"embedding" <=> '[0.1,-0.2,3e-05]'::vector ASC`},
+		{"farthest first", Sort{Field: "embedding", Vector: "[1,2]", Direction: SortDesc}, `"embedding" <=> '[1,2]'::vector DESC`},
+		{"unsafe vector dropped", Sort{Field: "embedding", Vector: "[1]'; --"}, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if sql := SortsToSQL([]Sort{tt.sort}); sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+		})
+	}
+}
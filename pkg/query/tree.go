@@ -0,0 +1,78 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// TreeDepthColumn is the column tree queries add with each row's depth below the root.
+const TreeDepthColumn = "tugo_depth"
+
+// TreeQuery describes a recursive query over a self-referencing table.
+type TreeQuery struct {
+	Table        string
+	PrimaryKey   string // Column the parent column references
+	ParentColumn string
+
+	// RootID selects descendants of a record; nil selects from the top-level rows.
+	RootID any
+
+	// Scope is an optional condition limiting visible rows, with placeholders numbered from 1.
+	Scope     string
+	ScopeArgs []any
+
+	MaxDepth int
+	Sorts    []Sort // Sibling order
+	Limit    int
+}
+
+// BuildTreeSelect builds a recursive CTE returning rows below the root with
+// their depth, shallowest first. The scope applies at every level, so a row it
+// hides also hides its descendants. MaxDepth bounds the recursion, which keeps
+// cyclic data from looping forever.
+func BuildTreeSelect(d dialect.Dialect, q TreeQuery) (string, []any) {
+	table := sanitizeIdentifier(q.Table)
+	pk := sanitizeIdentifier(q.PrimaryKey)
+	parent := sanitizeIdentifier(q.ParentColumn)
+	if table == "" || pk == "" || parent == "" {
+		return "", nil
+	}
+	table, pk, parent = d.QuoteIdent(table), d.QuoteIdent(pk), d.QuoteIdent(parent)
+
+	var sb strings.Builder
+	args := make([]any, 0, len(q.ScopeArgs)+1)
+
+	// Rows outside the scope are removed before recursing
+	sb.WriteString("WITH RECURSIVE tugo_scope AS (SELECT * FROM ")
+	sb.WriteString(table)
+	if q.Scope != "" {
+		sb.WriteString(" WHERE ")
+		sb.WriteString(q.Scope)
+		args = append(args, q.ScopeArgs...)
+	}
+	sb.WriteString("), ")
+
+	sb.WriteString(fmt.Sprintf("tugo_tree AS (SELECT t.*, 1 AS %s FROM tugo_scope t WHERE ", TreeDepthColumn))
+	if q.RootID == nil {
+		sb.WriteString(fmt.Sprintf("t.%s IS NULL", parent))
+	} else {
+		sb.WriteString(fmt.Sprintf("t.%s = %s", parent, d.Placeholder(len(args)+1)))
+		args = append(args, q.RootID)
+	}
+	sb.WriteString(fmt.Sprintf(" UNION ALL SELECT t.*, tugo_tree.%s + 1 FROM tugo_scope t JOIN tugo_tree ON t.%s = tugo_tree.%s WHERE tugo_tree.%s < %d)",
+		TreeDepthColumn, parent, pk, TreeDepthColumn, q.MaxDepth))
+
+	sb.WriteString(" SELECT * FROM tugo_tree ORDER BY ")
+	sb.WriteString(TreeDepthColumn)
+	if orderSQL := sortsToSQL(d, q.Sorts, ""); orderSQL != "" {
+		sb.WriteString(", ")
+		sb.WriteString(orderSQL)
+	}
+	if q.Limit > 0 {
+		sb.WriteString(fmt.Sprintf(" LIMIT %d", q.Limit))
+	}
+
+	return sb.String(), args
+}
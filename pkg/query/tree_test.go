@@ -0,0 +1,82 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+func TestBuildTreeSelect(t *testing.T) {
+	tests := []struct {
+		name     string
+		dialect  dialect.Dialect
+		query    TreeQuery
+		wantSQL  string
+		wantArgs int
+	}{
+		{
+			name:    "top-level rows",
+			dialect: dialect.PostgresDialect{},
+			query: TreeQuery{
+				Table: "categories", PrimaryKey: "id", ParentColumn: "parent_id",
+				MaxDepth: 3,
+				Sorts:    []Sort{{Field: "id", Direction: SortAsc}},
+			},
+			wantSQL: `WITH RECURSIVE tugo_scope AS (SELECT * FROM "categories"), ` +
+				`tugo_tree AS (SELECT t.*, 1 AS tugo_depth FROM tugo_scope t WHERE t."parent_id" IS NULL ` +
+				`UNION ALL SELECT t.*, tugo_tree.tugo_depth + 1 FROM tugo_scope t JOIN tugo_tree ON t."parent_id" = tugo_tree."id" ` +
+				`WHERE tugo_tree.tugo_depth < 3) SELECT * FROM tugo_tree ORDER BY tugo_depth, "id" ASC`,
+		},
+		{
+			name:    "scoped descendants number root after scope",
+			dialect: dialect.PostgresDialect{},
+			query: TreeQuery{
+				Table: "categories", PrimaryKey: "id", ParentColumn: "parent_id",
+				RootID:    "7",
+				Scope:     "owner_id = $1",
+				ScopeArgs: []any{"u1"},
+				MaxDepth:  1,
+				Limit:     10,
+			},
+			wantSQL: `WITH RECURSIVE tugo_scope AS (SELECT * FROM "categories" WHERE owner_id = $1), ` +
+				`tugo_tree AS (SELECT t.*, 1 AS tugo_depth FROM tugo_scope t WHERE t."parent_id" = $2 ` +
+				`UNION ALL SELECT t.*, tugo_tree.tugo_depth + 1 FROM tugo_scope t JOIN tugo_tree ON t."parent_id" = tugo_tree."id" ` +
+				`WHERE tugo_tree.tugo_depth < 1) SELECT * FROM tugo_tree ORDER BY tugo_depth LIMIT 10`,
+			wantArgs: 2,
+		},
+		{
+			name:    "mysql placeholders",
+			dialect: dialect.MySQLDialect{},
+			query: TreeQuery{
+				Table: "categories", PrimaryKey: "id", ParentColumn: "parent_id",
+				RootID:   1,
+				MaxDepth: 2,
+			},
+			wantSQL: "WITH RECURSIVE tugo_scope AS (SELECT * FROM `categories`), " +
+				"tugo_tree AS (SELECT t.*, 1 AS tugo_depth FROM tugo_scope t WHERE t.`parent_id` = ? " +
+				"UNION ALL SELECT t.*, tugo_tree.tugo_depth + 1 FROM tugo_scope t JOIN tugo_tree ON t.`parent_id` = tugo_tree.`id` " +
+				"WHERE tugo_tree.tugo_depth < 2) SELECT * FROM tugo_tree ORDER BY tugo_depth",
+			wantArgs: 1,
+		},
+		{
+			name:    "invalid identifier",
+			dialect: dialect.PostgresDialect{},
+			query: TreeQuery{
+				Table: "categories", PrimaryKey: "id", ParentColumn: "parent_id; DROP TABLE x",
+			},
+			wantSQL: "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, args := BuildTreeSelect(tt.dialect, tt.query)
+			if sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if len(args) != tt.wantArgs {
+				t.Errorf("expected %d args, got %d", tt.wantArgs, len(args))
+			}
+		})
+	}
+}
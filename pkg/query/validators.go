@@ -3,7 +3,11 @@ package query
 import (
 	"fmt"
 	"regexp"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/google/uuid"
 )
 
 // FieldValidator validates field names for SQL injection prevention and correctness.
@@ -41,16 +45,17 @@ func (v *FieldValidator) ValidateField(field string) error {
 		return fmt.Errorf("field '%s' contains invalid characters", field)
 	}
 
-	// Check reserved words
+	// Known fields are quoted, so only unknown ones may not be reserved words
+	if len(v.allowedFields) > 0 {
+		if !v.allowedFields[field] {
+			return fmt.Errorf("field '%s' is not allowed", field)
+		}
+		return nil
+	}
 	if v.reservedWords[field] {
 		return fmt.Errorf("field '%s' is a reserved SQL word", field)
 	}
 
-	// Check if in allowed list (if list is provided)
-	if len(v.allowedFields) > 0 && !v.allowedFields[field] {
-		return fmt.Errorf("field '%s' is not allowed", field)
-	}
-
 	return nil
 }
 
@@ -84,6 +89,7 @@ func (v *FieldValidator) SanitizeFields(fields []string) []string {
 // FilterValidator validates filter operations.
 type FilterValidator struct {
 	fieldValidator *FieldValidator
+	fieldTypes     map[string]string
 }
 
 // NewFilterValidator creates a new filter validator.
@@ -123,6 +129,57 @@ func (v *FilterValidator) ValidateFilters(filters []Filter) error {
 	return nil
 }
 
+// WithFieldTypes sets the abstract data types of the fields, such as int or
+// uuid, that CoerceFilters checks filter values against.
+func (v *FilterValidator) WithFieldTypes(types map[string]string) *FilterValidator {
+	v.fieldTypes = types
+	return v
+}
+
+// CoerceFilters validates filters and converts their values to the types of
+// their fields: int and float values become numbers, boolean values become
+// bools, and uuid, timestamp and date values are checked but stay strings.
+// In values become a list of converted values. Values of other types and
+// pattern filters are left as they are.
+func (v *FilterValidator) CoerceFilters(filters []Filter) ([]Filter, error) {
+	result := make([]Filter, len(filters))
+	for i, f := range filters {
+		if err := v.ValidateFilter(f); err != nil {
+			return nil, err
+		}
+
+		dataType := v.fieldTypes[f.Field]
+		raw, ok := f.Value.(string)
+		if !ok || dataType == "" {
+			result[i] = f
+			continue
+		}
+
+		switch f.Operator {
+		case OpLike, OpIsNull, OpIsNotNull:
+		case OpIn:
+			parts := strings.Split(raw, ",")
+			values := make([]any, len(parts))
+			for j, part := range parts {
+				value, err := coerceFilterValue(dataType, strings.TrimSpace(part))
+				if err != nil {
+					return nil, fmt.Errorf("invalid value for filter '%s': %w", f.Field, err)
+				}
+				values[j] = value
+			}
+			f.Value = values
+		default:
+			value, err := coerceFilterValue(dataType, raw)
+			if err != nil {
+				return nil, fmt.Errorf("invalid value for filter '%s': %w", f.Field, err)
+			}
+			f.Value = value
+		}
+		result[i] = f
+	}
+	return result, nil
+}
+
 // SortValidator validates sort operations.
 type SortValidator struct {
 	fieldValidator *FieldValidator
@@ -137,6 +194,26 @@ func NewSortValidator(allowedFields []string) *SortValidator {
 
 // ValidateSort validates a single sort specification.
 func (v *SortValidator) ValidateSort(s Sort) error {
+	if s.Random {
+		if s.Seed != "" && !seedRegex.MatchString(s.Seed) {
+			return fmt.Errorf("invalid random seed '%s'", s.Seed)
+		}
+		return nil
+	}
+	if s.Function != "" {
+		if _, ok := sortFunctions[s.Function]; !ok {
+			return fmt.Errorf("sort function '%s' is not allowed", s.Function)
+		}
+	}
+	switch s.Nulls {
+	case NullsDefault, NullsFirst, NullsLast:
+	default:
+		return fmt.Errorf("invalid nulls ordering '%s'", s.Nulls)
+	}
+	if s.Relation != "" {
+		// Related fields are validated against the join by the parser
+		return nil
+	}
 	return v.fieldValidator.ValidateField(s.Field)
 }
 
@@ -164,6 +241,9 @@ func isValidFilterOperator(op FilterOperator) bool {
 		OpLike:         true,
 		OpIsNull:       true,
 		OpIsNotNull:    true,
+		OpCosineLessThan: true,
+		OpCaseInsensitiveEqual: true,
+		OpSimilar: true,
 	}
 	return validOps[op]
 }
@@ -209,6 +289,66 @@ func validateFilterValue(operator FilterOperator, value any) error {
 	return nil
 }
 
+// filterTimeLayouts are the layouts accepted for timestamp filter values.
+var filterTimeLayouts = []string{
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05",
+	"2006-01-02 15:04:05Z07:00",
+	"2006-01-02 15:04:05.999999999",
+	time.DateOnly,
+}
+
+// coerceFilterValue converts a filter value to a field's abstract data type.
+func coerceFilterValue(dataType, value string) (any, error) {
+	switch dataType {
+	case "int":
+		n, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not an integer", value)
+		}
+		return n, nil
+
+	case "float":
+		n, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a number", value)
+		}
+		return n, nil
+
+	case "decimal":
+		// Decimals stay strings so no precision is lost
+		if _, err := strconv.ParseFloat(value, 64); err != nil {
+			return nil, fmt.Errorf("'%s' is not a number", value)
+		}
+
+	case "boolean":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return nil, fmt.Errorf("'%s' is not a boolean; use true or false", value)
+		}
+		return b, nil
+
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid UUID", value)
+		}
+
+	case "timestamp":
+		for _, layout := range filterTimeLayouts {
+			if _, err := time.Parse(layout, value); err == nil {
+				return value, nil
+			}
+		}
+		return nil, fmt.Errorf("'%s' is not a valid timestamp; use RFC 3339 or YYYY-MM-DD", value)
+
+	case "date":
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return nil, fmt.Errorf("'%s' is not a valid date; use YYYY-MM-DD", value)
+		}
+	}
+	return value, nil
+}
+
 // getReservedWords returns a map of SQL reserved words.
 func getReservedWords() map[string]bool {
 	return map[string]bool{
@@ -228,11 +368,28 @@ func getReservedWords() map[string]bool {
 	}
 }
 
+// CostLimits restricts query patterns that are expensive to execute.
+// Zero values disable the corresponding check.
+type CostLimits struct {
+	// MaxOffset rejects pages starting beyond this many rows.
+	MaxOffset int
+
+	// MaxExpand caps the number of relations expanded in one request.
+	MaxExpand int
+
+	// SearchFields lists the fields backed by an index suitable for pattern
+	// matching, such as a trigram index. When set, like filters on other
+	// fields are rejected.
+	SearchFields []string
+}
+
 // OptionsValidator validates complete query options.
 type OptionsValidator struct {
 	fieldValidator  *FieldValidator
 	filterValidator *FilterValidator
 	sortValidator   *SortValidator
+	limits          PaginationLimits
+	cost            CostLimits
 }
 
 // NewOptionsValidator creates a new options validator.
@@ -241,7 +398,46 @@ func NewOptionsValidator(allowedFields []string) *OptionsValidator {
 		fieldValidator:  NewFieldValidator(allowedFields),
 		filterValidator: NewFilterValidator(allowedFields),
 		sortValidator:   NewSortValidator(allowedFields),
+		limits:          DefaultPaginationLimits(),
+	}
+}
+
+// WithPaginationLimits sets the page size limits enforced by ValidateOptions.
+func (v *OptionsValidator) WithPaginationLimits(limits PaginationLimits) *OptionsValidator {
+	v.limits = limits.Normalize()
+	return v
+}
+
+// WithCostLimits sets the expensive query limits enforced by ValidateOptions.
+func (v *OptionsValidator) WithCostLimits(cost CostLimits) *OptionsValidator {
+	v.cost = cost
+	return v
+}
+
+// ValidateCost checks options against the cost limits.
+func (v *OptionsValidator) ValidateCost(opts Options) error {
+	if v.cost.MaxOffset > 0 && opts.Pagination.Offset > v.cost.MaxOffset {
+		return fmt.Errorf("offset %d exceeds the maximum of %d; narrow the query with filters instead",
+			opts.Pagination.Offset, v.cost.MaxOffset)
+	}
+
+	if v.cost.MaxExpand > 0 && len(opts.Expand) > v.cost.MaxExpand {
+		return fmt.Errorf("at most %d relations may be expanded", v.cost.MaxExpand)
 	}
+
+	if len(v.cost.SearchFields) > 0 {
+		searchable := make(map[string]bool, len(v.cost.SearchFields))
+		for _, f := range v.cost.SearchFields {
+			searchable[strings.ToLower(f)] = true
+		}
+		for _, f := range opts.Filters {
+			if f.Operator == OpLike && !searchable[strings.ToLower(f.Field)] {
+				return fmt.Errorf("field '%s' is not indexed for pattern search", f.Field)
+			}
+		}
+	}
+
+	return nil
 }
 
 // ValidateOptions validates all query options.
@@ -264,8 +460,8 @@ func (v *OptionsValidator) ValidateOptions(opts Options) error {
 	}
 
 	// Validate pagination
-	if opts.Pagination.Limit < 0 || opts.Pagination.Limit > 1000 {
-		return fmt.Errorf("limit must be between 0 and 1000")
+	if opts.Pagination.Limit < 1 || opts.Pagination.Limit > v.limits.MaxLimit {
+		return fmt.Errorf("limit must be between 1 and %d", v.limits.MaxLimit)
 	}
 	if opts.Pagination.Page < 1 {
 		return fmt.Errorf("page must be at least 1")
@@ -278,7 +474,7 @@ func (v *OptionsValidator) ValidateOptions(opts Options) error {
 		}
 	}
 
-	return nil
+	return v.ValidateCost(opts)
 }
 
 // ValidateExpand validates expand/relation fields.
@@ -0,0 +1,119 @@
+package query
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFilterValidator_CoerceFilters(t *testing.T) {
+	types := map[string]string{
+		"id":         "uuid",
+		"age":        "int",
+		"price":      "decimal",
+		"active":     "boolean",
+		"created_at": "timestamp",
+		"name":       "string",
+	}
+
+	tests := []struct {
+		name      string
+		filter    Filter
+		wantValue any
+		wantErr   bool
+	}{
+		{
+			name:      "int",
+			filter:    Filter{Field: "age", Operator: OpGreaterThan, Value: "18"},
+			wantValue: int64(18),
+		},
+		{
+			name:    "invalid int",
+			filter:  Filter{Field: "age", Operator: OpEqual, Value: "eighteen"},
+			wantErr: true,
+		},
+		{
+			name:      "boolean",
+			filter:    Filter{Field: "active", Operator: OpEqual, Value: "true"},
+			wantValue: true,
+		},
+		{
+			name:    "invalid boolean",
+			filter:  Filter{Field: "active", Operator: OpEqual, Value: "yes"},
+			wantErr: true,
+		},
+		{
+			name:      "uuid stays a string",
+			filter:    Filter{Field: "id", Operator: OpEqual, Value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8"},
+			wantValue: "6ba7b810-9dad-11d1-80b4-00c04fd430c8",
+		},
+		{
+			name:    "invalid uuid",
+			filter:  Filter{Field: "id", Operator: OpEqual, Value: "42"},
+			wantErr: true,
+		},
+		{
+			name:      "decimal stays a string",
+			filter:    Filter{Field: "price", Operator: OpLessThan, Value: "9.99"},
+			wantValue: "9.99",
+		},
+		{
+			name:      "timestamp",
+			filter:    Filter{Field: "created_at", Operator: OpGreaterEqual, Value: "2024-01-02T03:04:05Z"},
+			wantValue: "2024-01-02T03:04:05Z",
+		},
+		{
+			name:      "date on timestamp",
+			filter:    Filter{Field: "created_at", Operator: OpEqual, Value: "2024-01-02"},
+			wantValue: "2024-01-02",
+		},
+		{
+			name:    "invalid timestamp",
+			filter:  Filter{Field: "created_at", Operator: OpEqual, Value: "yesterday"},
+			wantErr: true,
+		},
+		{
+			name:      "in list",
+			filter:    Filter{Field: "age", Operator: OpIn, Value: "1, 2,3"},
+			wantValue: []any{int64(1), int64(2), int64(3)},
+		},
+		{
+			name:    "invalid in list",
+			filter:  Filter{Field: "id", Operator: OpIn, Value: "6ba7b810-9dad-11d1-80b4-00c04fd430c8,x"},
+			wantErr: true,
+		},
+		{
+			name:      "like is not coerced",
+			filter:    Filter{Field: "age", Operator: OpLike, Value: "1"},
+			wantValue: "1",
+		},
+		{
+			name:      "null is not coerced",
+			filter:    Filter{Field: "id", Operator: OpIsNull, Value: "true"},
+			wantValue: "true",
+		},
+		{
+			name:      "string",
+			filter:    Filter{Field: "name", Operator: OpEqual, Value: "anything"},
+			wantValue: "anything",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			validator := NewFilterValidator(nil).WithFieldTypes(types)
+			filters, err := validator.CoerceFilters([]Filter{tt.filter})
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got %v", filters)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(filters[0].Value, tt.wantValue) {
+				t.Errorf("expected value %#v, got %#v", tt.wantValue, filters[0].Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,37 @@
+package quota
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// Handler serves users their quota usage.
+type Handler struct {
+	meter *Meter
+}
+
+// NewHandler creates a new quota usage handler.
+func NewHandler(meter *Meter) *Handler {
+	return &Handler{meter: meter}
+}
+
+// GetUsage handles GET /auth/usage requests, returning the authenticated
+// user's usage and quota in the current month.
+func (h *Handler) GetUsage(c *gin.Context) {
+	user := auth.GetUser(c)
+	if user == nil {
+		response.JSON(c, http.StatusUnauthorized, response.FromAppError(apperror.ErrUnauthorized))
+		return
+	}
+	c.JSON(http.StatusOK, response.Success(h.meter.Usage(user.ID, user.Role)))
+}
+
+// RegisterRoutes registers the usage route on a group of authenticated
+// routes.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("/usage", h.GetUsage)
+}
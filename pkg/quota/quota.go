@@ -0,0 +1,343 @@
+// Package quota meters the requests and bytes of authenticated users
+// against monthly quotas.
+package quota
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// DefaultFlushInterval is how often metered usage is persisted.
+const DefaultFlushInterval = 10 * time.Second
+
+// ErrQuotaExceeded is returned once a user has used up a monthly quota.
+var ErrQuotaExceeded = &apperror.AppError{
+	Code:       "QUOTA_EXCEEDED",
+	Message:    "Monthly quota exceeded",
+	HTTPStatus: http.StatusTooManyRequests,
+}
+
+// Limit is a monthly quota. Zero fields are unlimited.
+type Limit struct {
+	Requests int64 `json:"requests,omitempty"`
+	Bytes    int64 `json:"bytes,omitempty"`
+}
+
+// Config configures quota metering.
+type Config struct {
+	// Enabled meters authenticated collection requests and serves
+	// GET /auth/usage.
+	Enabled bool
+
+	// Default is the quota of users whose role has none in Roles.
+	Default Limit
+
+	// Roles maps role names to their quotas, such as {"free": {Requests:
+	// 10000}}.
+	Roles map[string]Limit
+
+	// FlushInterval is how often usage is persisted and the usage of other
+	// instances is loaded.
+	// Default: 10 seconds
+	FlushInterval time.Duration
+}
+
+// Usage is a user's metered usage in the current month.
+type Usage struct {
+	UserID   string `json:"user_id"`
+	Period   string `json:"period"`
+	Requests int64  `json:"requests"`
+	Bytes    int64  `json:"bytes"`
+	Limit    Limit  `json:"limit"`
+	// Reset is when the next month's quota starts.
+	Reset time.Time `json:"reset"`
+}
+
+// counts are the requests and bytes of one user.
+type counts struct {
+	requests int64
+	bytes    int64
+}
+
+// pendingKey identifies the usage of a user in a month not yet persisted.
+type pendingKey struct {
+	period string
+	userID string
+}
+
+// Meter counts requests and bytes per user and month. Counts are kept in
+// memory and persisted periodically, so instances sharing a database see
+// each other's usage within a flush interval.
+type Meter struct {
+	config Config
+	store  *Store
+	logger *zap.SugaredLogger
+
+	mu      sync.Mutex
+	period  string
+	totals  map[string]*counts
+	pending map[pendingKey]*counts
+	roles   map[string]string
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewMeter creates a meter. A nil store keeps usage in memory only.
+func NewMeter(config Config, store *Store, logger *zap.SugaredLogger) *Meter {
+	if config.FlushInterval <= 0 {
+		config.FlushInterval = DefaultFlushInterval
+	}
+	return &Meter{
+		config:  config,
+		store:   store,
+		logger:  logger,
+		period:  period(time.Now()),
+		totals:  make(map[string]*counts),
+		pending: make(map[pendingKey]*counts),
+		roles:   make(map[string]string),
+	}
+}
+
+// period returns the month of t, such as "2024-05".
+func period(t time.Time) string {
+	return t.UTC().Format("2006-01")
+}
+
+// reset returns the start of the month after t.
+func reset(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+}
+
+// limit returns the quota of a role.
+func (m *Meter) limit(role string) Limit {
+	if limit, ok := m.config.Roles[role]; ok {
+		return limit
+	}
+	return m.config.Default
+}
+
+// SetLimits replaces the default and role quotas, such as on a config
+// reload. Usage counted so far is kept.
+func (m *Meter) SetLimits(def Limit, roles map[string]Limit) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.config.Default = def
+	m.config.Roles = roles
+}
+
+// Middleware meters the requests of authenticated users on the routes it
+// wraps. It sets X-RateLimit-Limit, X-RateLimit-Remaining and
+// X-RateLimit-Reset for the request quota and X-Quota-Remaining for the
+// byte quota, and rejects requests with 429 once either is used up.
+// Anonymous requests are not metered.
+func (m *Meter) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		user := auth.GetUser(c)
+		if user == nil {
+			c.Next()
+			return
+		}
+
+		now := time.Now()
+		usage := m.Usage(user.ID, user.Role)
+		exceeded := (usage.Limit.Requests > 0 && usage.Requests >= usage.Limit.Requests) ||
+			(usage.Limit.Bytes > 0 && usage.Bytes >= usage.Limit.Bytes)
+		if !exceeded {
+			usage.Requests++
+		}
+		setHeaders(c, usage)
+		if exceeded {
+			c.Header("Retry-After", strconv.FormatInt(int64(usage.Reset.Sub(now).Seconds())+1, 10))
+			response.JSON(c, http.StatusTooManyRequests, response.FromAppError(ErrQuotaExceeded))
+			c.Abort()
+			return
+		}
+
+		c.Next()
+
+		bytes := max(c.Request.ContentLength, 0) + int64(max(c.Writer.Size(), 0))
+		m.Record(user.ID, user.Role, 1, bytes, now)
+	}
+}
+
+// setHeaders sends the quota headers of usage, counting the current
+// request.
+func setHeaders(c *gin.Context, usage Usage) {
+	if usage.Limit.Requests > 0 {
+		c.Header("X-RateLimit-Limit", strconv.FormatInt(usage.Limit.Requests, 10))
+		c.Header("X-RateLimit-Remaining", strconv.FormatInt(max(usage.Limit.Requests-usage.Requests, 0), 10))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(usage.Reset.Unix(), 10))
+	}
+	if usage.Limit.Bytes > 0 {
+		c.Header("X-Quota-Remaining", strconv.FormatInt(max(usage.Limit.Bytes-usage.Bytes, 0), 10))
+	}
+}
+
+// Record adds requests and bytes to a user's usage in the month of at.
+// Usage of a past month is dropped.
+func (m *Meter) Record(userID, role string, requests, bytes int64, at time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.rollover(at)
+	if period(at) != m.period {
+		return
+	}
+	m.roles[userID] = role
+	add(m.totals, userID, requests, bytes)
+	if m.store != nil {
+		add(m.pending, pendingKey{m.period, userID}, requests, bytes)
+	}
+}
+
+// add adds requests and bytes to the counts of key in m.
+func add[K comparable](m map[K]*counts, key K, requests, bytes int64) {
+	if m[key] == nil {
+		m[key] = &counts{}
+	}
+	m[key].requests += requests
+	m[key].bytes += bytes
+}
+
+// rollover starts a new month when at is past the current one. Pending
+// usage of the finished month is still persisted. Callers hold m.mu.
+func (m *Meter) rollover(at time.Time) {
+	if p := period(at); p > m.period {
+		m.period = p
+		m.totals = make(map[string]*counts)
+	}
+}
+
+// Usage returns a user's usage in the current month. An empty role uses
+// the role last metered for the user.
+func (m *Meter) Usage(userID, role string) Usage {
+	now := time.Now()
+	m.mu.Lock()
+	m.rollover(now)
+	if role == "" {
+		role = m.roles[userID]
+	}
+	usage := Usage{UserID: userID, Period: m.period, Limit: m.limit(role), Reset: reset(now)}
+	if c := m.totals[userID]; c != nil {
+		usage.Requests, usage.Bytes = c.requests, c.bytes
+	}
+	m.mu.Unlock()
+	return usage
+}
+
+// Start loads the current month's usage and begins persisting it. It does
+// nothing without a store.
+func (m *Meter) Start(ctx context.Context) error {
+	if m.store == nil {
+		return nil
+	}
+	if err := m.load(ctx); err != nil {
+		return err
+	}
+
+	m.stop = make(chan struct{})
+	m.done = make(chan struct{})
+	go m.flushLoop()
+	return nil
+}
+
+// Close persists pending usage and stops flushing.
+func (m *Meter) Close() {
+	if m.stop == nil {
+		return
+	}
+	close(m.stop)
+	<-m.done
+	m.stop = nil
+}
+
+// flushLoop persists usage and reloads the totals once per interval.
+func (m *Meter) flushLoop() {
+	defer close(m.done)
+	ticker := time.NewTicker(m.config.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			if m.flush(ctx) {
+				if err := m.load(ctx); err != nil {
+					m.logger.Errorw("Failed to load quota usage", "error", err)
+				}
+			}
+			cancel()
+		case <-m.stop:
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			m.flush(ctx)
+			cancel()
+			return
+		}
+	}
+}
+
+// flush saves the pending usage as increments. Failed increments are kept
+// for the next flush. It reports whether the store is reachable.
+func (m *Meter) flush(ctx context.Context) bool {
+	m.mu.Lock()
+	pending := m.pending
+	m.pending = make(map[pendingKey]*counts)
+	m.mu.Unlock()
+
+	rows := make([]Row, 0, len(pending))
+	for key, c := range pending {
+		rows = append(rows, Row{UserID: key.userID, Period: key.period, Requests: c.requests, Bytes: c.bytes})
+	}
+	if len(rows) == 0 {
+		return true
+	}
+	if err := m.store.Save(ctx, rows); err != nil {
+		m.logger.Errorw("Failed to persist quota usage", "users", len(rows), "error", err)
+		m.mu.Lock()
+		for key, c := range pending {
+			add(m.pending, key, c.requests, c.bytes)
+		}
+		m.mu.Unlock()
+		return false
+	}
+	return true
+}
+
+// load replaces the totals with the persisted usage of the current month,
+// plus the usage not yet persisted.
+func (m *Meter) load(ctx context.Context) error {
+	m.mu.Lock()
+	p := m.period
+	m.mu.Unlock()
+
+	rows, err := m.store.Load(ctx, p)
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.period != p {
+		return nil
+	}
+	m.totals = make(map[string]*counts, len(rows))
+	for _, row := range rows {
+		add(m.totals, row.UserID, row.Requests, row.Bytes)
+	}
+	for key, c := range m.pending {
+		if key.period == p {
+			add(m.totals, key.userID, c.requests, c.bytes)
+		}
+	}
+	return nil
+}
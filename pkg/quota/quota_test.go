@@ -0,0 +1,93 @@
+package quota
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/auth"
+	"go.uber.org/zap"
+)
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		config        Config
+		role          string
+		requests      int
+		wantStatus    int
+		wantRemaining string
+		wantBytesLeft string
+	}{
+		{"unlimited", Config{}, "user", 3, http.StatusOK, "", ""},
+		{"within quota", Config{Default: Limit{Requests: 3}}, "user", 2, http.StatusOK, "1", ""},
+		{"last request", Config{Default: Limit{Requests: 3}}, "user", 3, http.StatusOK, "0", ""},
+		{"requests used up", Config{Default: Limit{Requests: 3}}, "user", 4, http.StatusTooManyRequests, "0", ""},
+		{"role quota", Config{Default: Limit{Requests: 1}, Roles: map[string]Limit{"pro": {Requests: 10}}}, "pro", 4, http.StatusOK, "6", ""},
+		{"bytes left", Config{Default: Limit{Bytes: 100}}, "user", 2, http.StatusOK, "", "95"},
+		{"bytes used up", Config{Default: Limit{Bytes: 10}}, "user", 3, http.StatusTooManyRequests, "", "0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			meter := NewMeter(tt.config, nil, zap.NewNop().Sugar())
+			router := gin.New()
+			router.Use(func(c *gin.Context) {
+				c.Set("user", &auth.User{ID: "u1", Role: tt.role})
+			}, meter.Middleware())
+			router.GET("/items", func(c *gin.Context) { c.String(http.StatusOK, "hello") })
+
+			var w *httptest.ResponseRecorder
+			for range tt.requests {
+				w = httptest.NewRecorder()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/items", nil))
+			}
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if got := w.Header().Get("X-RateLimit-Remaining"); got != tt.wantRemaining {
+				t.Errorf("X-RateLimit-Remaining = %q, want %q", got, tt.wantRemaining)
+			}
+			if got := w.Header().Get("X-Quota-Remaining"); got != tt.wantBytesLeft {
+				t.Errorf("X-Quota-Remaining = %q, want %q", got, tt.wantBytesLeft)
+			}
+		})
+	}
+}
+
+func TestSetLimits(t *testing.T) {
+	meter := NewMeter(Config{Default: Limit{Requests: 1}}, nil, zap.NewNop().Sugar())
+	meter.Record("u1", "user", 1, 10, time.Now())
+
+	meter.SetLimits(Limit{Requests: 5}, map[string]Limit{"pro": {Requests: 50}})
+	if usage := meter.Usage("u1", "user"); usage.Limit.Requests != 5 || usage.Requests != 1 {
+		t.Errorf("Usage() = %+v, want the new default limit and the usage so far", usage)
+	}
+	if usage := meter.Usage("u1", "pro"); usage.Limit.Requests != 50 {
+		t.Errorf("Usage() of a pro user = %+v, want the new role limit", usage)
+	}
+}
+
+func TestRollover(t *testing.T) {
+	meter := NewMeter(Config{}, nil, zap.NewNop().Sugar())
+	may := time.Date(2024, 5, 31, 23, 0, 0, 0, time.UTC)
+	meter.period = period(may)
+	meter.Record("u1", "user", 1, 10, may)
+	meter.Record("u1", "user", 1, 10, may.Add(2*time.Hour))
+	meter.Record("u1", "user", 1, 10, may)
+
+	if meter.period != "2024-06" {
+		t.Fatalf("period = %q, want 2024-06", meter.period)
+	}
+	if got := meter.totals["u1"]; got == nil || got.requests != 1 || got.bytes != 10 {
+		t.Errorf("totals = %+v, want 1 request of 10 bytes", got)
+	}
+	if got := reset(may); !got.Equal(time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("reset = %v, want 2024-06-01", got)
+	}
+}
@@ -0,0 +1,62 @@
+package quota
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Row is usage of one user in one month, or an increment of it.
+type Row struct {
+	UserID   string `db:"user_id"`
+	Period   string `db:"period"`
+	Requests int64  `db:"requests"`
+	Bytes    int64  `db:"bytes"`
+}
+
+// Store persists usage increments in tugo_quota_usage.
+type Store struct {
+	db *sqlx.DB
+}
+
+// NewStore creates a new quota store.
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// Save inserts increments in one transaction.
+func (s *Store) Save(ctx context.Context, rows []Row) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := tx.Rebind(`
+		INSERT INTO tugo_quota_usage (user_id, period, requests, bytes)
+		VALUES (?, ?, ?, ?)
+	`)
+	for _, row := range rows {
+		if _, err := tx.ExecContext(ctx, query, row.UserID, row.Period, row.Requests, row.Bytes); err != nil {
+			return fmt.Errorf("failed to save quota usage: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Load returns the usage of each user in a month, summing the increments
+// saved by all instances.
+func (s *Store) Load(ctx context.Context, period string) ([]Row, error) {
+	query := `
+		SELECT user_id, period, SUM(requests) AS requests, SUM(bytes) AS bytes
+		FROM tugo_quota_usage
+		WHERE period = ?
+		GROUP BY user_id, period
+	`
+	var rows []Row
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), period); err != nil {
+		return nil, fmt.Errorf("failed to load quota usage: %w", err)
+	}
+	return rows, nil
+}
@@ -0,0 +1,383 @@
+// Package requestlog assigns correlation IDs to requests and writes
+// structured access logs with sensitive fields redacted.
+package requestlog
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Request logging defaults.
+const (
+	DefaultHeader       = "X-Request-ID"
+	DefaultMaxBodyBytes = 4096
+
+	// maxIDLength bounds incoming request IDs.
+	maxIDLength = 128
+
+	// redacted replaces the values of redacted fields.
+	redacted = "[REDACTED]"
+)
+
+// DefaultRedactFields are the fields redacted when none are configured.
+var DefaultRedactFields = []string{
+	"password", "new_password", "current_password", "token", "access_token",
+	"refresh_token", "secret", "api_key", "authorization", "totp_code",
+}
+
+// Config configures request IDs and access logging.
+type Config struct {
+	// Header carries the request ID in requests and responses. Incoming IDs
+	// are kept when they are printable ASCII of at most 128 bytes; otherwise
+	// a new one is generated.
+	// Default: "X-Request-ID"
+	Header string
+
+	// AccessLog writes one log line per request.
+	AccessLog bool
+
+	// LogBodies adds JSON request bodies to access log lines, with redacted
+	// fields masked. Bodies over MaxBodyBytes are left out.
+	LogBodies bool
+
+	// MaxBodyBytes is the largest request body logged or passed to OnError.
+	// Default: 4096
+	MaxBodyBytes int
+
+	// RedactFields are the body and query parameter names whose values are
+	// masked in logs, matched case-insensitively at any depth.
+	// Default: DefaultRedactFields
+	RedactFields []string
+
+	// UserID returns the ID of the requesting user for log lines and the
+	// error hook.
+	UserID func(ctx context.Context) string
+
+	// OnError is called after a request fails with a 5xx status, with the
+	// error recorded on the Gin context.
+	OnError func(ctx context.Context, err error, meta RequestMeta)
+}
+
+// RequestMeta describes a request to the error hook. Query and Body are
+// redacted.
+type RequestMeta struct {
+	RequestID string
+	Method    string
+	Path      string
+	Route     string // Matched route pattern, e.g. /api/v1/:collection/:id
+	Query     string
+	Body      any // Decoded JSON body, nil if it was not JSON or too large
+	Status    int
+	ClientIP  string
+	UserAgent string
+	UserID    string
+
+	// Collection is the collection addressed by the route, if any.
+	Collection string
+
+	// Action is "read", "create", "update" or "delete", from the method.
+	Action string
+}
+
+type contextKey struct{}
+
+// WithID returns a context carrying a request ID.
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// ID returns the request ID in ctx, or "" if there is none.
+func ID(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// Logger returns logger annotated with the request ID in ctx.
+func Logger(ctx context.Context, logger *zap.SugaredLogger) *zap.SugaredLogger {
+	if id := ID(ctx); id != "" {
+		return logger.With("request_id", id)
+	}
+	return logger
+}
+
+// NewID generates a random request ID.
+func NewID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Middleware assigns each request an ID and echoes it in the response
+// header. Once the request completes it writes the access log line and
+// calls the error hook, if enabled.
+// A request that already has an ID in its context was handled by an outer
+// instance, which logs it instead.
+func Middleware(config Config, logger *zap.SugaredLogger) gin.HandlerFunc {
+	if config.Header == "" {
+		config.Header = DefaultHeader
+	}
+	if config.MaxBodyBytes <= 0 {
+		config.MaxBodyBytes = DefaultMaxBodyBytes
+	}
+	if config.RedactFields == nil {
+		config.RedactFields = DefaultRedactFields
+	}
+	redactor := NewRedactor(config.RedactFields)
+
+	return func(c *gin.Context) {
+		if ID(c.Request.Context()) != "" {
+			c.Next()
+			return
+		}
+
+		id := c.GetHeader(config.Header)
+		if !validID(id) {
+			id = NewID()
+		}
+		c.Request = c.Request.WithContext(WithID(c.Request.Context(), id))
+		c.Header(config.Header, id)
+
+		if !config.AccessLog && config.OnError == nil {
+			c.Next()
+			return
+		}
+
+		var body []byte
+		if config.LogBodies || config.OnError != nil {
+			body = peekBody(c, config.MaxBodyBytes)
+		}
+
+		start := time.Now()
+		c.Next()
+		elapsed := time.Since(start)
+
+		meta := RequestMeta{
+			RequestID:  id,
+			Method:     c.Request.Method,
+			Path:       c.Request.URL.Path,
+			Route:      c.FullPath(),
+			Status:     c.Writer.Status(),
+			ClientIP:   c.ClientIP(),
+			UserAgent:  c.Request.UserAgent(),
+			Collection: c.Param("collection"),
+			Action:     methodAction(c.Request.Method),
+		}
+		if meta.Collection == "" && strings.Contains(meta.Route, "/collections/:name") {
+			meta.Collection = c.Param("name")
+		}
+		if c.Request.URL.RawQuery != "" {
+			meta.Query = redactor.Query(c.Request.URL.RawQuery)
+		}
+		if body != nil {
+			meta.Body = redactor.Body(body)
+		}
+		if config.UserID != nil {
+			meta.UserID = config.UserID(c.Request.Context())
+		}
+
+		if config.AccessLog {
+			logAccess(logger, meta, elapsed, c.Writer.Size(), config.LogBodies)
+		}
+		if config.OnError != nil && meta.Status >= 500 {
+			config.OnError(c.Request.Context(), requestError(c), meta)
+		}
+	}
+}
+
+// logAccess writes an access log line at a level matching the status.
+func logAccess(logger *zap.SugaredLogger, meta RequestMeta, elapsed time.Duration, size int, withBody bool) {
+	fields := []any{
+		"request_id", meta.RequestID,
+		"method", meta.Method,
+		"path", meta.Path,
+		"status", meta.Status,
+		"duration_ms", elapsed.Milliseconds(),
+		"bytes", size,
+		"client_ip", meta.ClientIP,
+	}
+	if meta.Query != "" {
+		fields = append(fields, "query", meta.Query)
+	}
+	if meta.UserID != "" {
+		fields = append(fields, "user_id", meta.UserID)
+	}
+	if withBody && meta.Body != nil {
+		fields = append(fields, "body", meta.Body)
+	}
+
+	switch {
+	case meta.Status >= 500:
+		logger.Errorw("Request", fields...)
+	case meta.Status >= 400:
+		logger.Warnw("Request", fields...)
+	default:
+		logger.Infow("Request", fields...)
+	}
+}
+
+// requestError returns the last error recorded on the context, or one
+// describing the status if the handler recorded none.
+func requestError(c *gin.Context) error {
+	if last := c.Errors.Last(); last != nil {
+		return last.Err
+	}
+	return errors.New(http.StatusText(c.Writer.Status()))
+}
+
+// methodAction maps an HTTP method to the permission action it performs.
+func methodAction(method string) string {
+	switch method {
+	case http.MethodPost:
+		return "create"
+	case http.MethodPut, http.MethodPatch:
+		return "update"
+	case http.MethodDelete:
+		return "delete"
+	default:
+		return "read"
+	}
+}
+
+// validID reports whether an incoming request ID can be reused.
+func validID(id string) bool {
+	if id == "" || len(id) > maxIDLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// peekBody returns a JSON request body of at most limit bytes, leaving it
+// unread for the handler. Other and larger bodies return nil.
+func peekBody(c *gin.Context, limit int) []byte {
+	if c.Request.Body == nil || !strings.Contains(c.ContentType(), "json") {
+		return nil
+	}
+	if c.Request.ContentLength > int64(limit) {
+		return nil
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, int64(limit)+1))
+	c.Request.Body = struct {
+		io.Reader
+		io.Closer
+	}{io.MultiReader(bytes.NewReader(body), c.Request.Body), c.Request.Body}
+	if err != nil || len(body) == 0 || len(body) > limit {
+		return nil
+	}
+	return body
+}
+
+// Redactor masks the values of sensitive fields.
+type Redactor struct {
+	fields map[string]bool
+}
+
+// NewRedactor creates a redactor for the given field names.
+func NewRedactor(fields []string) *Redactor {
+	r := &Redactor{fields: make(map[string]bool, len(fields))}
+	for _, f := range fields {
+		r.fields[strings.ToLower(f)] = true
+	}
+	return r
+}
+
+// Value returns a copy of a decoded JSON value with redacted fields masked.
+func (r *Redactor) Value(v any) any {
+	switch val := v.(type) {
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			if r.fields[strings.ToLower(k)] {
+				out[k] = redacted
+			} else {
+				out[k] = r.Value(item)
+			}
+		}
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = r.Value(item)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// Body returns a JSON body with redacted fields masked. Bodies that are not
+// valid JSON are replaced entirely, as they cannot be inspected.
+func (r *Redactor) Body(body []byte) any {
+	var v any
+	if err := json.Unmarshal(body, &v); err != nil {
+		return redacted
+	}
+	return r.Value(v)
+}
+
+// Query returns a decoded query string with redacted parameters masked.
+// Bracketed parameters such as filter[password:eq] match on the field name.
+func (r *Redactor) Query(rawQuery string) string {
+	values, err := url.ParseQuery(rawQuery)
+	if err != nil {
+		return redacted
+	}
+
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var sb strings.Builder
+	for _, k := range keys {
+		for _, v := range values[k] {
+			if sb.Len() > 0 {
+				sb.WriteByte('&')
+			}
+			if r.redactsParam(k) {
+				v = redacted
+			}
+			sb.WriteString(k + "=" + v)
+		}
+	}
+	return sb.String()
+}
+
+// redactsParam reports whether a query parameter names a redacted field.
+func (r *Redactor) redactsParam(key string) bool {
+	key = strings.ToLower(key)
+	if r.fields[key] {
+		return true
+	}
+	open := strings.IndexByte(key, '[')
+	if open < 0 || !strings.HasSuffix(key, "]") {
+		return false
+	}
+	field := key[open+1 : len(key)-1]
+	if i := strings.IndexByte(field, ':'); i >= 0 {
+		field = field[:i]
+	}
+	return r.fields[field]
+}
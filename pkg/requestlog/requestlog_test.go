@@ -0,0 +1,194 @@
+package requestlog
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestRedactor_Body(t *testing.T) {
+	r := NewRedactor(DefaultRedactFields)
+	tests := []struct {
+		name string
+		body string
+		want any
+	}{
+		{"plain", `{"name":"a"}`, map[string]any{"name": "a"}},
+		{"password", `{"username":"a","Password":"secret"}`, map[string]any{"username": "a", "Password": redacted}},
+		{"nested", `{"user":{"token":"t","id":1}}`, map[string]any{"user": map[string]any{"token": redacted, "id": float64(1)}}},
+		{"array", `[{"api_key":"k"},{"ok":true}]`, []any{map[string]any{"api_key": redacted}, map[string]any{"ok": true}}},
+		{"invalid", `{"password":"sec`, redacted},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := r.Body([]byte(tt.body)); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Body() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRedactor_Query(t *testing.T) {
+	r := NewRedactor(DefaultRedactFields)
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"page=2&limit=10", "limit=10&page=2"},
+		{"token=abc&page=1", "page=1&token=" + redacted},
+		{"filter[password:eq]=x&filter[name]=y", "filter[name]=y&filter[password:eq]=" + redacted},
+		{"filter%5Bemail%5D=a%40b.c", "filter[email]=a@b.c"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			if got := r.Query(tt.query); got != tt.want {
+				t.Errorf("Query() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	router := gin.New()
+	router.Use(Middleware(Config{AccessLog: true, LogBodies: true}, zap.New(core).Sugar()))
+	router.POST("/login", func(c *gin.Context) {
+		var body map[string]any
+		if err := c.ShouldBindJSON(&body); err != nil || body["password"] != "hunter2" {
+			t.Errorf("handler body = %v, %v", body, err)
+		}
+		c.String(http.StatusOK, ID(c.Request.Context()))
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"generated", "", false},
+		{"propagated", "abc-123", true},
+		{"invalid", "bad id", false},
+		{"too long", strings.Repeat("a", maxIDLength+1), false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logs.TakeAll()
+			req := httptest.NewRequest(http.MethodPost, "/login", strings.NewReader(`{"username":"a","password":"hunter2"}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.incoming != "" {
+				req.Header.Set(DefaultHeader, tt.incoming)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			id := w.Header().Get(DefaultHeader)
+			if id == "" || w.Body.String() != id {
+				t.Fatalf("header ID = %q, context ID = %q", id, w.Body.String())
+			}
+			if tt.keep != (id == tt.incoming) {
+				t.Errorf("ID = %q, incoming %q", id, tt.incoming)
+			}
+
+			entries := logs.TakeAll()
+			if len(entries) != 1 {
+				t.Fatalf("got %d log entries, want 1", len(entries))
+			}
+			fields := entries[0].ContextMap()
+			if fields["request_id"] != id {
+				t.Errorf("logged request_id = %v, want %q", fields["request_id"], id)
+			}
+			logged, _ := json.Marshal(fields["body"])
+			if strings.Contains(string(logged), "hunter2") {
+				t.Errorf("logged body leaks password: %s", logged)
+			}
+		})
+	}
+}
+
+func TestMiddleware_OnError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errFailed := errors.New("connection reset")
+
+	var calls []RequestMeta
+	var reported error
+	router := gin.New()
+	router.Use(Middleware(Config{
+		UserID: func(context.Context) string { return "u1" },
+		OnError: func(ctx context.Context, err error, meta RequestMeta) {
+			if ID(ctx) != meta.RequestID {
+				t.Errorf("context ID = %q, meta ID = %q", ID(ctx), meta.RequestID)
+			}
+			reported = err
+			calls = append(calls, meta)
+		},
+	}, zap.NewNop().Sugar()))
+	router.PATCH("/:collection/:id", func(c *gin.Context) {
+		if c.Param("id") == "fail" {
+			_ = c.Error(errFailed)
+			c.Status(http.StatusInternalServerError)
+			return
+		}
+		c.Status(http.StatusNotFound)
+	})
+
+	tests := []struct {
+		path   string
+		called bool
+	}{
+		{"/posts/missing", false},
+		{"/posts/fail?token=abc", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			calls = nil
+			req := httptest.NewRequest(http.MethodPatch, tt.path, strings.NewReader(`{"password":"p","title":"t"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(httptest.NewRecorder(), req)
+
+			if got := len(calls) == 1; got != tt.called {
+				t.Fatalf("OnError called %d times, want called = %v", len(calls), tt.called)
+			}
+			if !tt.called {
+				return
+			}
+
+			meta := calls[0]
+			want := RequestMeta{
+				RequestID:  meta.RequestID,
+				Method:     http.MethodPatch,
+				Path:       "/posts/fail",
+				Route:      "/:collection/:id",
+				Query:      "token=" + redacted,
+				Body:       map[string]any{"password": redacted, "title": "t"},
+				Status:     http.StatusInternalServerError,
+				ClientIP:   meta.ClientIP,
+				UserAgent:  meta.UserAgent,
+				UserID:     "u1",
+				Collection: "posts",
+				Action:     "update",
+			}
+			if !reflect.DeepEqual(meta, want) {
+				t.Errorf("meta = %+v, want %+v", meta, want)
+			}
+			if reported != errFailed {
+				t.Errorf("err = %v, want %v", reported, errFailed)
+			}
+		})
+	}
+}
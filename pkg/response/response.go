@@ -1,8 +1,14 @@
 package response
 
 import (
+	"bytes"
+	"io"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/format"
+	"github.com/thienel/tugo/pkg/requestlog"
 )
 
 // Response is the standard API response structure.
@@ -10,6 +16,9 @@ type Response struct {
 	Success bool       `json:"success"`
 	Data    any        `json:"data,omitempty"`
 	Error   *ErrorBody `json:"error,omitempty"`
+
+	// Meta carries information about the request itself, such as debug output.
+	Meta map[string]any `json:"meta,omitempty"`
 }
 
 // ErrorBody contains error details.
@@ -17,6 +26,9 @@ type ErrorBody struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details any    `json:"details,omitempty"`
+
+	// RequestID correlates the error with the request's log lines.
+	RequestID string `json:"request_id,omitempty"`
 }
 
 // ListData wraps list responses with pagination.
@@ -116,10 +128,79 @@ func NewPagination(page, limit, total int) *Pagination {
 // Gin helper functions for common HTTP responses
 
 // JSON sends a JSON response with status code.
+// Error responses carry the request ID, if any.
 func JSON(c *gin.Context, status int, resp Response) {
+	annotate(c, status, &resp)
 	c.JSON(status, resp)
 }
 
+// Abort sends a JSON response with status code and stops the handler chain.
+func Abort(c *gin.Context, status int, resp Response) {
+	annotate(c, status, &resp)
+	c.AbortWithStatusJSON(status, resp)
+}
+
+// Negotiate sends a response in the format preferred by the Accept header,
+// choosing among JSON and the enabled formats. JSON is the default.
+func Negotiate(c *gin.Context, status int, resp Response, formats format.Config) {
+	if !formats.Enabled() {
+		JSON(c, status, resp)
+		return
+	}
+
+	annotate(c, status, &resp)
+	c.Header("Vary", "Accept")
+	switch c.NegotiateFormat(formats.Offered()...) {
+	case format.MIMEXML, format.MIMEXML2:
+		c.Render(status, encoded{contentType: format.MIMEXML + "; charset=utf-8", data: resp, encode: func(w io.Writer, v any) error {
+			return format.EncodeXML(w, "response", v)
+		}})
+	case format.MIMEMsgPack, format.MIMEMsgPack2:
+		c.Render(status, encoded{contentType: format.MIMEMsgPack, data: resp, encode: format.EncodeMsgPack})
+	default:
+		c.JSON(status, resp)
+	}
+}
+
+// encoded renders data with a format encoder, buffering the output so an
+// encoding failure does not leave a partial body.
+type encoded struct {
+	contentType string
+	data        any
+	encode      func(io.Writer, any) error
+}
+
+// Render implements render.Render.
+func (e encoded) Render(w http.ResponseWriter) error {
+	var buf bytes.Buffer
+	if err := e.encode(&buf, e.data); err != nil {
+		return err
+	}
+	e.WriteContentType(w)
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// WriteContentType implements render.Render.
+func (e encoded) WriteContentType(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", e.contentType)
+}
+
+// annotate sets the request ID on an error response. Server errors are
+// recorded on the context for the error hook unless the handler recorded
+// the underlying error itself.
+func annotate(c *gin.Context, status int, resp *Response) {
+	if resp.Error == nil {
+		return
+	}
+	if resp.Error.RequestID == "" {
+		resp.Error.RequestID = requestlog.ID(c.Request.Context())
+	}
+	if status >= 500 && len(c.Errors) == 0 {
+		_ = c.Error(&apperror.AppError{Code: resp.Error.Code, Message: resp.Error.Message, HTTPStatus: status})
+	}
+}
+
 // OK sends a 200 OK response with data.
 func OK(c *gin.Context, data any) {
 	c.JSON(200, Success(data))
@@ -137,40 +218,40 @@ func NoContent(c *gin.Context) {
 
 // BadRequest sends a 400 Bad Request response.
 func BadRequest(c *gin.Context, message string) {
-	c.JSON(400, Error(apperror.CodeBadRequest, message))
+	JSON(c, 400, Error(apperror.CodeBadRequest, message))
 }
 
 // Unauthorized sends a 401 Unauthorized response.
 func Unauthorized(c *gin.Context, message string) {
-	c.JSON(401, Error(apperror.CodeUnauthorized, message))
+	JSON(c, 401, Error(apperror.CodeUnauthorized, message))
 }
 
 // Forbidden sends a 403 Forbidden response.
 func Forbidden(c *gin.Context, message string) {
-	c.JSON(403, Error(apperror.CodeForbidden, message))
+	JSON(c, 403, Error(apperror.CodeForbidden, message))
 }
 
 // NotFound sends a 404 Not Found response.
 func NotFound(c *gin.Context, message string) {
-	c.JSON(404, Error(apperror.CodeNotFound, message))
+	JSON(c, 404, Error(apperror.CodeNotFound, message))
 }
 
 // Conflict sends a 409 Conflict response.
 func Conflict(c *gin.Context, message string) {
-	c.JSON(409, Error(apperror.CodeConflict, message))
+	JSON(c, 409, Error(apperror.CodeConflict, message))
 }
 
 // ValidationError sends a 422 Unprocessable Entity response.
 func ValidationError(c *gin.Context, message string, details any) {
-	c.JSON(422, ErrorWithDetails(apperror.CodeValidation, message, details))
+	JSON(c, 422, ErrorWithDetails(apperror.CodeValidation, message, details))
 }
 
 // InternalError sends a 500 Internal Server Error response.
 func InternalError(c *gin.Context, message string) {
-	c.JSON(500, Error(apperror.CodeInternalServer, message))
+	JSON(c, 500, Error(apperror.CodeInternalServer, message))
 }
 
 // HandleAppError sends appropriate response based on AppError.
 func HandleAppError(c *gin.Context, err *apperror.AppError) {
-	c.JSON(err.HTTPStatus, FromAppError(err))
+	JSON(c, err.HTTPStatus, FromAppError(err))
 }
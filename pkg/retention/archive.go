@@ -0,0 +1,372 @@
+package retention
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/storage"
+)
+
+// Archive keeps the rows a retention rule deletes. Rows are moved to a
+// shadow table, or exported to Storage as gzip-compressed NDJSON when it
+// is set.
+type Archive struct {
+	// Table is the shadow table, created with the collection's columns on
+	// first use.
+	// Default: the collection table with an "_archive" suffix
+	Table string `json:"table,omitempty"`
+
+	// Storage receives one file per deleted batch instead of a table.
+	Storage storage.Provider `json:"-"`
+
+	// Directory is the storage directory of the exported files.
+	// Default: "archive/<collection>"
+	Directory string `json:"directory,omitempty"`
+}
+
+// table returns the shadow table of a collection.
+func (a *Archive) table(collection *schema.Collection) string {
+	if a.Table != "" {
+		return a.Table
+	}
+	return collection.TableName + "_archive"
+}
+
+// directory returns the storage directory of a collection's exports.
+func (a *Archive) directory(collection *schema.Collection) string {
+	if a.Directory != "" {
+		return strings.Trim(a.Directory, "/")
+	}
+	return "archive/" + collection.Name
+}
+
+// ArchiveEntry is a retention run that archived rows, from tugo_audit_log.
+type ArchiveEntry struct {
+	ID         string          `db:"id" json:"id"`
+	Collection string          `db:"collection" json:"collection"`
+	Changes    json.RawMessage `db:"-" json:"changes"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+
+	RawChanges []byte `db:"changes" json:"-"`
+}
+
+// RestoreRequest selects archived rows to restore. Rows in a shadow table
+// are chosen by the rule's field, From inclusive and To exclusive, both
+// optional. Exported rows are restored a file at a time.
+type RestoreRequest struct {
+	From *time.Time `json:"from,omitempty"`
+	To   *time.Time `json:"to,omitempty"`
+	File string     `json:"file,omitempty"`
+}
+
+// ensureArchiveTable creates a shadow table with the columns of a
+// collection's table.
+func (e *Enforcer) ensureArchiveTable(ctx context.Context, collection *schema.Collection, table string) error {
+	query := fmt.Sprintf("CREATE TABLE IF NOT EXISTS %s AS SELECT * FROM %s WHERE 1 = 0",
+		e.dialect.QuoteIdent(table), e.dialect.QuoteIdent(collection.TableName))
+	if _, err := e.db.ExecContext(ctx, query); err != nil {
+		return fmt.Errorf("failed to create archive table: %w", err)
+	}
+	return nil
+}
+
+// exportBatch exports up to batch rows older than cutoff to the rule's
+// storage and deletes them. It returns how many rows it removed and the
+// storage path of the export.
+func (e *Enforcer) exportBatch(ctx context.Context, collection *schema.Collection, rule Rule, cutoff time.Time, batch int) (int, string, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s WHERE %s < %s LIMIT %d",
+		e.columnList(collection), e.dialect.QuoteIdent(collection.TableName), e.dialect.QuoteIdent(rule.Field),
+		e.dialect.Placeholder(1), batch)
+	rows, err := e.db.QueryxContext(ctx, query, cutoff)
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to select rows: %w", err)
+	}
+	defer rows.Close()
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	binary := binaryFields(collection)
+	var ids []any
+	for rows.Next() {
+		row := make(map[string]any)
+		if err := rows.MapScan(row); err != nil {
+			return 0, "", fmt.Errorf("failed to scan row: %w", err)
+		}
+		ids = append(ids, row[collection.PrimaryKey])
+		for name, v := range row {
+			if b, ok := v.([]byte); ok {
+				if binary[name] {
+					row[name] = base64.StdEncoding.EncodeToString(b)
+				} else {
+					row[name] = string(b)
+				}
+			}
+		}
+		if err := enc.Encode(row); err != nil {
+			return 0, "", fmt.Errorf("failed to encode row: %w", err)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return 0, "", fmt.Errorf("failed to read rows: %w", err)
+	}
+	rows.Close()
+	if len(ids) == 0 {
+		return 0, "", nil
+	}
+	if err := gz.Close(); err != nil {
+		return 0, "", fmt.Errorf("failed to compress rows: %w", err)
+	}
+
+	name := fmt.Sprintf("%s-%s.ndjson.gz", collection.Name, time.Now().UTC().Format("20060102T150405.000000000Z"))
+	info, err := rule.Archive.Storage.Upload(ctx, &buf, name, &storage.UploadOptions{
+		ContentType:  "application/gzip",
+		Directory:    rule.Archive.directory(collection),
+		PreserveName: true,
+	})
+	if err != nil {
+		return 0, "", fmt.Errorf("failed to upload archive: %w", err)
+	}
+
+	n, err := e.deleteRows(ctx, collection, ids, "")
+	return n, info.StoragePath, err
+}
+
+// Archives lists the retention runs that archived rows, newest first,
+// optionally for one collection.
+func (e *Enforcer) Archives(ctx context.Context, collection string, limit int) ([]ArchiveEntry, error) {
+	query := `
+		SELECT id, collection, changes, created_at
+		FROM tugo_audit_log
+		WHERE action = ? AND (? = '' OR collection = ?)
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	entries := make([]ArchiveEntry, 0)
+	if err := e.db.SelectContext(ctx, &entries, e.db.Rebind(query), AuditArchiveAction, collection, collection, limit); err != nil {
+		return nil, fmt.Errorf("failed to list archives: %w", err)
+	}
+	for i := range entries {
+		entries[i].Changes = entries[i].RawChanges
+	}
+	return entries, nil
+}
+
+// Restore moves archived rows of a collection back into its table and
+// returns how many were restored. Restored rows leave the shadow table;
+// exported files are kept.
+func (e *Enforcer) Restore(ctx context.Context, name string, req RestoreRequest) (int64, error) {
+	collection := e.collection(name)
+	if collection == nil {
+		return 0, apperror.ErrCollectionNotFound.WithMessage("Collection not found: " + name)
+	}
+	rule, ok := e.rule(collection)
+	if !ok || rule.Archive == nil {
+		return 0, apperror.ErrBadRequest.WithMessagef("Collection '%s' has no retention archive", name)
+	}
+
+	var restored int64
+	var err error
+	changes := map[string]any{}
+	if rule.Archive.Storage != nil {
+		if req.File == "" {
+			return 0, apperror.ErrBadRequest.WithMessage("file is required for archives in storage")
+		}
+		dir := rule.Archive.directory(collection)
+		file := path.Clean(req.File)
+		if !strings.HasPrefix(file, dir+"/") {
+			return 0, apperror.ErrBadRequest.WithMessagef("file must be in %s", dir)
+		}
+		restored, err = e.restoreFile(ctx, collection, rule.Archive.Storage, file)
+		changes["file"] = file
+	} else {
+		table := rule.Archive.table(collection)
+		restored, err = e.restoreTable(ctx, collection, rule.Field, table, req)
+		changes["table"] = table
+		if req.From != nil {
+			changes["from"] = req.From
+		}
+		if req.To != nil {
+			changes["to"] = req.To
+		}
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if restored > 0 {
+		changes["restored"] = restored
+		if err := e.writeAudit(ctx, AuditRestoreAction, collection, changes); err != nil {
+			e.logger.Errorw("Failed to audit restore", "collection", collection.Name, "error", err)
+		}
+	}
+	return restored, nil
+}
+
+// restoreTable moves rows from the shadow table back in one transaction.
+func (e *Enforcer) restoreTable(ctx context.Context, collection *schema.Collection, field, table string, req RestoreRequest) (int64, error) {
+	var conditions []string
+	var args []any
+	field = e.dialect.QuoteIdent(field)
+	if req.From != nil {
+		args = append(args, req.From.UTC())
+		conditions = append(conditions, fmt.Sprintf("%s >= %s", field, e.dialect.Placeholder(len(args))))
+	}
+	if req.To != nil {
+		args = append(args, req.To.UTC())
+		conditions = append(conditions, fmt.Sprintf("%s < %s", field, e.dialect.Placeholder(len(args))))
+	}
+	where := ""
+	if len(conditions) > 0 {
+		where = " WHERE " + strings.Join(conditions, " AND ")
+	}
+
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	columns := e.columnList(collection)
+	copyQuery := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s%s",
+		e.dialect.QuoteIdent(collection.TableName), columns, columns, e.dialect.QuoteIdent(table), where)
+	res, err := tx.ExecContext(ctx, copyQuery, args...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to restore rows: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("DELETE FROM %s%s", e.dialect.QuoteIdent(table), where), args...); err != nil {
+		return 0, fmt.Errorf("failed to remove restored rows from archive: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// restoreFile inserts the rows of an exported file in one transaction.
+func (e *Enforcer) restoreFile(ctx context.Context, collection *schema.Collection, provider storage.Provider, file string) (int64, error) {
+	reader, err := provider.Download(ctx, file)
+	if err != nil {
+		return 0, apperror.ErrNotFound.WithMessagef("Archive file '%s' not found", file)
+	}
+	defer reader.Close()
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read archive: %w", err)
+	}
+	defer gz.Close()
+
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	fields := make(map[string]string, len(collection.Fields))
+	for _, f := range collection.Fields {
+		fields[f.Name] = f.DataType
+	}
+
+	var restored int64
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	for scanner.Scan() {
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.UseNumber()
+		var row map[string]any
+		if err := dec.Decode(&row); err != nil {
+			return 0, fmt.Errorf("failed to decode archived row: %w", err)
+		}
+
+		columns := make([]string, 0, len(row))
+		for name := range row {
+			if _, ok := fields[name]; ok {
+				columns = append(columns, name)
+			}
+		}
+		sort.Strings(columns)
+		values := make([]any, len(columns))
+		quoted := make([]string, len(columns))
+		for i, name := range columns {
+			if values[i], err = decodeValue(fields[name], row[name]); err != nil {
+				return 0, fmt.Errorf("failed to decode %s: %w", name, err)
+			}
+			quoted[i] = e.dialect.QuoteIdent(name)
+		}
+
+		query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+			e.dialect.QuoteIdent(collection.TableName), strings.Join(quoted, ", "), e.placeholders(1, len(columns)))
+		if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+			return 0, fmt.Errorf("failed to restore row: %w", err)
+		}
+		restored++
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read archive: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	return restored, nil
+}
+
+// decodeValue converts an exported value back for a field of dataType.
+func decodeValue(dataType string, v any) (any, error) {
+	s, ok := v.(string)
+	if !ok {
+		if n, ok := v.(json.Number); ok {
+			return n.String(), nil
+		}
+		return v, nil
+	}
+	switch dataType {
+	case "binary":
+		return base64.StdEncoding.DecodeString(s)
+	case "timestamp":
+		if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+			return t, nil
+		}
+	}
+	return s, nil
+}
+
+// collection returns the collection with an API or table name, or nil.
+func (e *Enforcer) collection(name string) *schema.Collection {
+	for _, c := range e.collections() {
+		if c.Name == name || c.TableName == name {
+			return c
+		}
+	}
+	return nil
+}
+
+// columnList returns the comma-separated quoted columns of a collection.
+func (e *Enforcer) columnList(collection *schema.Collection) string {
+	names := make([]string, len(collection.Fields))
+	for i, f := range collection.Fields {
+		names[i] = e.dialect.QuoteIdent(f.Name)
+	}
+	return strings.Join(names, ", ")
+}
+
+// binaryFields returns the names of the collection's binary fields.
+func binaryFields(collection *schema.Collection) map[string]bool {
+	fields := make(map[string]bool)
+	for _, f := range collection.Fields {
+		if f.DataType == "binary" {
+			fields[f.Name] = true
+		}
+	}
+	return fields
+}
@@ -0,0 +1,313 @@
+// Package retention deletes collection rows older than configured ages.
+package retention
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Defaults for rules and scheduled runs.
+const (
+	DefaultBatchSize = 1000
+	DefaultInterval  = time.Hour
+)
+
+// tugo_audit_log actions of retention runs and restores.
+const (
+	AuditAction        = "retention.delete"
+	AuditArchiveAction = "retention.archive"
+	AuditRestoreAction = "retention.restore"
+)
+
+// Rule removes the rows of a collection whose timestamp field is older
+// than MaxAge. Rows with a NULL timestamp are kept.
+type Rule struct {
+	// Field is the timestamp field compared, such as "created_at".
+	Field string `json:"field"`
+
+	// MaxAge is how long rows are kept.
+	MaxAge time.Duration `json:"max_age"`
+
+	// BatchSize bounds the rows deleted per statement.
+	// Default: 1000
+	BatchSize int `json:"batch_size,omitempty"`
+
+	// Archive keeps the deleted rows so they can be restored. Nil deletes
+	// them for good.
+	Archive *Archive `json:"archive,omitempty"`
+}
+
+// MarshalJSON writes MaxAge as a duration string such as "720h0m0s".
+func (r Rule) MarshalJSON() ([]byte, error) {
+	type rule Rule
+	return json.Marshal(struct {
+		rule
+		MaxAge string `json:"max_age"`
+	}{rule(r), r.MaxAge.String()})
+}
+
+// Result reports a rule's run on one collection. In a dry run Matched is
+// the number of rows that would be deleted and nothing is deleted.
+type Result struct {
+	Collection string    `json:"collection"`
+	Field      string    `json:"field"`
+	Cutoff     time.Time `json:"cutoff"`
+	DryRun     bool      `json:"dry_run"`
+	Matched    int64     `json:"matched"`
+	Deleted    int64     `json:"deleted"`
+	Error      string    `json:"error,omitempty"`
+
+	// ArchiveTable or ArchiveFiles tell where archived rows were kept.
+	ArchiveTable string   `json:"archive_table,omitempty"`
+	ArchiveFiles []string `json:"archive_files,omitempty"`
+}
+
+// Enforcer applies retention rules to collections.
+type Enforcer struct {
+	db          *sqlx.DB
+	dialect     dialect.Dialect
+	collections func() []*schema.Collection
+	logger      *zap.SugaredLogger
+
+	mu    sync.RWMutex
+	rules map[string]Rule
+}
+
+// NewEnforcer creates an enforcer for the collections returned by
+// collections.
+func NewEnforcer(db *sqlx.DB, collections func() []*schema.Collection, logger *zap.SugaredLogger) *Enforcer {
+	return &Enforcer{
+		db:          db,
+		dialect:     dialect.ForDriver(db.DriverName()),
+		collections: collections,
+		logger:      logger,
+		rules:       make(map[string]Rule),
+	}
+}
+
+// SetRules replaces the rules, keyed by collection API or table name.
+func (e *Enforcer) SetRules(rules map[string]Rule) {
+	e.mu.Lock()
+	e.rules = rules
+	e.mu.Unlock()
+}
+
+// Rules returns the rules, keyed by collection API or table name.
+func (e *Enforcer) Rules() map[string]Rule {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return e.rules
+}
+
+// rule returns the rule of a collection.
+func (e *Enforcer) rule(collection *schema.Collection) (Rule, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	if rule, ok := e.rules[collection.Name]; ok {
+		return rule, true
+	}
+	rule, ok := e.rules[collection.TableName]
+	return rule, ok
+}
+
+// Run applies every rule, or only counts the matching rows when dryRun is
+// set. A failing collection does not stop the others; the errors are
+// returned together with the results.
+func (e *Enforcer) Run(ctx context.Context, dryRun bool) ([]Result, error) {
+	results := make([]Result, 0)
+	var errs []error
+	for _, collection := range e.collections() {
+		rule, ok := e.rule(collection)
+		if !ok {
+			continue
+		}
+		result, err := e.apply(ctx, collection, rule, dryRun)
+		if err != nil {
+			result.Error = err.Error()
+			errs = append(errs, fmt.Errorf("%s: %w", collection.Name, err))
+		}
+		results = append(results, result)
+	}
+	return results, errors.Join(errs...)
+}
+
+// apply runs a rule on a collection.
+func (e *Enforcer) apply(ctx context.Context, collection *schema.Collection, rule Rule, dryRun bool) (Result, error) {
+	result := Result{
+		Collection: collection.Name,
+		Field:      rule.Field,
+		Cutoff:     time.Now().Add(-rule.MaxAge).UTC(),
+		DryRun:     dryRun,
+	}
+	if rule.MaxAge <= 0 {
+		return result, fmt.Errorf("retention max age must be positive")
+	}
+	if !hasField(collection, rule.Field) {
+		return result, fmt.Errorf("retention field %q not found", rule.Field)
+	}
+	if collection.PrimaryKey == "" {
+		return result, fmt.Errorf("retention requires a primary key")
+	}
+
+	if dryRun {
+		query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s < %s",
+			e.dialect.QuoteIdent(collection.TableName), e.dialect.QuoteIdent(rule.Field), e.dialect.Placeholder(1))
+		if err := e.db.GetContext(ctx, &result.Matched, query, result.Cutoff); err != nil {
+			return result, fmt.Errorf("failed to count rows: %w", err)
+		}
+		return result, nil
+	}
+
+	batch := rule.BatchSize
+	if batch <= 0 {
+		batch = DefaultBatchSize
+	}
+	if rule.Archive != nil && rule.Archive.Storage == nil {
+		result.ArchiveTable = rule.Archive.table(collection)
+		if err := e.ensureArchiveTable(ctx, collection, result.ArchiveTable); err != nil {
+			return result, err
+		}
+	}
+
+	var err error
+	for {
+		var n int
+		if rule.Archive != nil && rule.Archive.Storage != nil {
+			var path string
+			n, path, err = e.exportBatch(ctx, collection, rule, result.Cutoff, batch)
+			if path != "" {
+				result.ArchiveFiles = append(result.ArchiveFiles, path)
+			}
+		} else {
+			n, err = e.removeBatch(ctx, collection, rule.Field, result.ArchiveTable, result.Cutoff, batch)
+		}
+		result.Deleted += int64(n)
+		if err != nil || n < batch {
+			break
+		}
+	}
+	result.Matched = result.Deleted
+
+	// Audit what was deleted, even when a later batch failed
+	if result.Deleted > 0 {
+		if auditErr := e.audit(ctx, collection, rule, result); auditErr != nil {
+			e.logger.Errorw("Failed to audit retention", "collection", collection.Name, "error", auditErr)
+		}
+		e.logger.Infow("Retention deleted rows", "collection", collection.Name, "deleted", result.Deleted, "cutoff", result.Cutoff)
+	}
+	return result, err
+}
+
+// removeBatch deletes up to batch rows older than cutoff, first copying
+// them to archiveTable when it is set, and returns how many it removed.
+func (e *Enforcer) removeBatch(ctx context.Context, collection *schema.Collection, field, archiveTable string, cutoff time.Time, batch int) (int, error) {
+	selectQuery := fmt.Sprintf("SELECT %s FROM %s WHERE %s < %s LIMIT %d",
+		e.dialect.QuoteIdent(collection.PrimaryKey), e.dialect.QuoteIdent(collection.TableName),
+		e.dialect.QuoteIdent(field), e.dialect.Placeholder(1), batch)
+	var ids []any
+	if err := e.db.SelectContext(ctx, &ids, selectQuery, cutoff); err != nil {
+		return 0, fmt.Errorf("failed to select rows: %w", err)
+	}
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	return e.deleteRows(ctx, collection, ids, archiveTable)
+}
+
+// deleteRows deletes the rows with the given primary keys in one
+// transaction, first copying them to archiveTable when it is set.
+func (e *Enforcer) deleteRows(ctx context.Context, collection *schema.Collection, ids []any, archiveTable string) (int, error) {
+	tx, err := e.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	table, pk := e.dialect.QuoteIdent(collection.TableName), e.dialect.QuoteIdent(collection.PrimaryKey)
+	in := e.placeholders(1, len(ids))
+	if archiveTable != "" {
+		columns := e.columnList(collection)
+		copyQuery := fmt.Sprintf("INSERT INTO %s (%s) SELECT %s FROM %s WHERE %s IN (%s)",
+			e.dialect.QuoteIdent(archiveTable), columns, columns, table, pk, in)
+		if _, err := tx.ExecContext(ctx, copyQuery, ids...); err != nil {
+			return 0, fmt.Errorf("failed to archive rows: %w", err)
+		}
+	}
+	deleteQuery := fmt.Sprintf("DELETE FROM %s WHERE %s IN (%s)", table, pk, in)
+	res, err := tx.ExecContext(ctx, deleteQuery, ids...)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete rows: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit: %w", err)
+	}
+	n, _ := res.RowsAffected()
+	return int(n), nil
+}
+
+// placeholders returns n comma-separated placeholders numbered from first.
+func (e *Enforcer) placeholders(first, n int) string {
+	placeholders := make([]string, n)
+	for i := range placeholders {
+		placeholders[i] = e.dialect.Placeholder(first + i)
+	}
+	return strings.Join(placeholders, ", ")
+}
+
+// audit records a retention delete in tugo_audit_log.
+func (e *Enforcer) audit(ctx context.Context, collection *schema.Collection, rule Rule, result Result) error {
+	changes := map[string]any{
+		"field":   rule.Field,
+		"max_age": rule.MaxAge.String(),
+		"cutoff":  result.Cutoff,
+		"deleted": result.Deleted,
+	}
+	action := AuditAction
+	if rule.Archive != nil {
+		action = AuditArchiveAction
+		if result.ArchiveTable != "" {
+			changes["archive_table"] = result.ArchiveTable
+		}
+		if len(result.ArchiveFiles) > 0 {
+			changes["archive_files"] = result.ArchiveFiles
+		}
+	}
+	return e.writeAudit(ctx, action, collection, changes)
+}
+
+// writeAudit adds an entry to tugo_audit_log.
+func (e *Enforcer) writeAudit(ctx context.Context, action string, collection *schema.Collection, changes map[string]any) error {
+	data, err := json.Marshal(changes)
+	if err != nil {
+		return err
+	}
+	query := `
+		INSERT INTO tugo_audit_log (id, action, collection, changes, created_at)
+		VALUES (?, ?, ?, ?, ?)
+	`
+	_, err = e.db.ExecContext(ctx, e.db.Rebind(query),
+		uuid.NewString(), action, collection.Name, string(data), time.Now().UTC())
+	return err
+}
+
+// hasField reports whether a collection has a field.
+func hasField(collection *schema.Collection, name string) bool {
+	for _, field := range collection.Fields {
+		if field.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,63 @@
+package retention
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+func TestRuleMarshalJSON(t *testing.T) {
+	data, err := json.Marshal(Rule{Field: "created_at", MaxAge: 90 * 24 * time.Hour})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"field":"created_at","max_age":"2160h0m0s"}`
+	if string(data) != want {
+		t.Errorf("Marshal() = %s, want %s", data, want)
+	}
+}
+
+func TestEnforcerInvalidRules(t *testing.T) {
+	logs := &schema.Collection{
+		Name:       "logs",
+		TableName:  "api_logs",
+		PrimaryKey: "id",
+		Fields:     []schema.Field{{Name: "id"}, {Name: "created_at"}},
+	}
+	keyless := &schema.Collection{Name: "events", TableName: "api_events", Fields: []schema.Field{{Name: "created_at"}}}
+
+	tests := []struct {
+		name       string
+		collection *schema.Collection
+		rule       Rule
+		wantErr    string
+	}{
+		{"no max age", logs, Rule{Field: "created_at"}, "max age"},
+		{"unknown field", logs, Rule{Field: "deleted_at", MaxAge: time.Hour}, "not found"},
+		{"no primary key", keyless, Rule{Field: "created_at", MaxAge: time.Hour}, "primary key"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// the rule is rejected before the database is used
+			db := sqlx.NewDb(&sql.DB{}, "postgres")
+			e := NewEnforcer(db, func() []*schema.Collection { return []*schema.Collection{tt.collection} }, zap.NewNop().Sugar())
+			e.SetRules(map[string]Rule{tt.collection.TableName: tt.rule})
+
+			results, err := e.Run(context.Background(), false)
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Fatalf("Run() error = %v, want %q", err, tt.wantErr)
+			}
+			if len(results) != 1 || results[0].Error == "" {
+				t.Errorf("results = %+v, want one failed result", results)
+			}
+		})
+	}
+}
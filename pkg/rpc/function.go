@@ -0,0 +1,184 @@
+// Package rpc exposes PostgreSQL functions and procedures as API endpoints.
+package rpc
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// Routine kinds.
+const (
+	KindFunction  = "function"
+	KindProcedure = "procedure"
+)
+
+// Function is an introspected database function or procedure.
+type Function struct {
+	Name        string  `json:"name"`    // Name exposed at /rpc/:function, without the prefix
+	Routine     string  `json:"routine"` // Database name
+	Kind        string  `json:"kind"`
+	Description string  `json:"description,omitempty"`
+	Params      []Param `json:"params"`
+	ReturnType  string  `json:"return_type"`
+	ReturnsSet  bool    `json:"returns_set"`
+
+	// Composite is true when the function returns rows rather than a single value.
+	Composite bool `json:"composite"`
+
+	schema string
+}
+
+// Param is an input argument of a function.
+type Param struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	HasDefault bool   `json:"has_default"`
+
+	schema string
+	array  bool
+}
+
+// routineRow is a row of the routine introspection query.
+type routineRow struct {
+	SpecificName string `db:"specific_name"`
+	Schema       string `db:"routine_schema"`
+	Name         string `db:"routine_name"`
+	Type         string `db:"routine_type"`
+	ReturnType   string `db:"return_type"`
+	ReturnsSet   bool   `db:"returns_set"`
+	Composite    bool   `db:"composite"`
+	Description  string `db:"description"`
+}
+
+// paramRow is a row of the parameter introspection query.
+type paramRow struct {
+	SpecificName string `db:"specific_name"`
+	Mode         string `db:"parameter_mode"`
+	Name         string `db:"parameter_name"`
+	DataType     string `db:"data_type"`
+	UDTSchema    string `db:"udt_schema"`
+	UDTName      string `db:"udt_name"`
+	HasDefault   bool   `db:"has_default"`
+}
+
+// Introspect loads the functions and procedures in the current schema whose
+// names start with prefix, keyed by exposed name. Overloads share a key.
+func Introspect(ctx context.Context, db *sqlx.DB, prefix string) (map[string][]*Function, error) {
+	routinesQuery := `
+		SELECT r.specific_name, r.routine_schema, r.routine_name, r.routine_type,
+			COALESCE(r.type_udt_name, '') AS return_type,
+			p.proretset AS returns_set,
+			(t.typtype = 'c' OR r.data_type = 'record') AS composite,
+			COALESCE(obj_description(p.oid, 'pg_proc'), '') AS description
+		FROM information_schema.routines r
+		JOIN pg_proc p ON r.specific_name = p.proname || '_' || p.oid
+		LEFT JOIN pg_type t ON t.oid = p.prorettype
+		WHERE r.routine_schema = current_schema()
+			AND r.routine_type IN ('FUNCTION', 'PROCEDURE')
+			AND starts_with(r.routine_name, $1)
+		ORDER BY r.routine_name, r.specific_name
+	`
+	var routines []routineRow
+	if err := db.SelectContext(ctx, &routines, routinesQuery, prefix); err != nil {
+		return nil, fmt.Errorf("failed to introspect functions: %w", err)
+	}
+
+	paramsQuery := `
+		SELECT specific_name, parameter_mode, COALESCE(parameter_name, '') AS parameter_name,
+			data_type, udt_schema, udt_name, parameter_default IS NOT NULL AS has_default
+		FROM information_schema.parameters
+		WHERE specific_schema = current_schema()
+		ORDER BY specific_name, ordinal_position
+	`
+	var params []paramRow
+	if err := db.SelectContext(ctx, &params, paramsQuery); err != nil {
+		return nil, fmt.Errorf("failed to introspect function parameters: %w", err)
+	}
+
+	byRoutine := make(map[string][]paramRow)
+	for _, p := range params {
+		byRoutine[p.SpecificName] = append(byRoutine[p.SpecificName], p)
+	}
+
+	functions := make(map[string][]*Function)
+	for _, r := range routines {
+		fn := &Function{
+			Name:        strings.TrimPrefix(r.Name, prefix),
+			Routine:     r.Name,
+			Kind:        strings.ToLower(r.Type),
+			Description: r.Description,
+			Params:      make([]Param, 0),
+			ReturnType:  r.ReturnType,
+			ReturnsSet:  r.ReturnsSet,
+			Composite:   r.Composite,
+			schema:      r.Schema,
+		}
+		if fn.Kind == KindProcedure {
+			fn.ReturnType = ""
+			fn.Composite = true
+		}
+
+		usable := true
+		for _, p := range byRoutine[r.SpecificName] {
+			if p.Mode == "OUT" {
+				continue
+			}
+			// Arguments are bound by name, so unnamed ones cannot be supplied
+			if p.Name == "" {
+				usable = false
+				break
+			}
+			fn.Params = append(fn.Params, Param{
+				Name:       p.Name,
+				Type:       strings.TrimPrefix(p.UDTName, "_"),
+				HasDefault: p.HasDefault,
+				schema:     p.UDTSchema,
+				array:      p.DataType == "ARRAY",
+			})
+		}
+		if !usable || fn.Name == "" {
+			continue
+		}
+		functions[fn.Name] = append(functions[fn.Name], fn)
+	}
+	return functions, nil
+}
+
+// matches reports whether args supply every required parameter and nothing else.
+func (f *Function) matches(args map[string]any) bool {
+	known := make(map[string]bool, len(f.Params))
+	for _, p := range f.Params {
+		known[p.Name] = true
+		if _, ok := args[p.Name]; !ok && !p.HasDefault {
+			return false
+		}
+	}
+	for name := range args {
+		if !known[name] {
+			return false
+		}
+	}
+	return true
+}
+
+// typeName returns the parameter type for a cast, qualified outside pg_catalog.
+func (p Param) typeName() string {
+	pg := dialect.PostgresDialect{}
+	name := pg.QuoteIdent(p.Type)
+	if p.schema != "" && p.schema != "pg_catalog" {
+		name = pg.QuoteIdent(p.schema) + "." + name
+	}
+	if p.array {
+		name += "[]"
+	}
+	return name
+}
+
+// isJSON reports whether the parameter is a json or jsonb value.
+func (p Param) isJSON() bool {
+	return !p.array && (p.Type == "json" || p.Type == "jsonb")
+}
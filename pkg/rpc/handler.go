@@ -0,0 +1,73 @@
+package rpc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// Handler handles HTTP requests for function calls.
+type Handler struct {
+	service *Service
+	logger  *zap.SugaredLogger
+}
+
+// NewHandler creates a new RPC handler.
+func NewHandler(service *Service, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// List handles GET /rpc requests.
+func (h *Handler) List(c *gin.Context) {
+	c.JSON(http.StatusOK, response.Success(h.service.List()))
+}
+
+// Call handles POST /rpc/:function requests.
+// The body is a JSON object of named arguments and may be omitted.
+func (h *Handler) Call(c *gin.Context) {
+	args := make(map[string]any)
+	decoder := json.NewDecoder(c.Request.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(&args); err != nil && !errors.Is(err, io.EOF) {
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Request body must be a JSON object of arguments"),
+		))
+		return
+	}
+
+	result, err := h.service.Call(c.Request.Context(), c.Param("function"), args)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// RegisterRoutes registers RPC routes on a Gin router group.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.List)
+	rg.POST("/:function", h.Call)
+}
+
+// handleError converts errors to HTTP responses.
+func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if appErr, ok := apperror.AsAppError(err); ok {
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+}
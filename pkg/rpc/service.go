@@ -0,0 +1,358 @@
+package rpc
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"go.uber.org/zap"
+)
+
+// DefaultPrefix is the function name prefix exposed when none is configured.
+const DefaultPrefix = "api_fn_"
+
+// Config configures function invocation.
+type Config struct {
+	// Prefix selects the functions to expose; it is stripped from their names.
+	// Default: "api_fn_"
+	Prefix string
+
+	// StatementTimeout aborts calls running longer than this.
+	// Default: 0 (no timeout)
+	StatementTimeout time.Duration
+}
+
+// Service introspects and calls database functions.
+type Service struct {
+	db      *sqlx.DB
+	config  Config
+	logger  *zap.SugaredLogger
+	session func(ctx context.Context, tx *sqlx.Tx) error
+
+	mu        sync.RWMutex
+	functions map[string][]*Function
+}
+
+// NewService creates a new RPC service. Call Refresh to load functions.
+func NewService(db *sqlx.DB, config Config, logger *zap.SugaredLogger) *Service {
+	if config.Prefix == "" {
+		config.Prefix = DefaultPrefix
+	}
+	return &Service{
+		db:        db,
+		config:    config,
+		logger:    logger,
+		functions: make(map[string][]*Function),
+	}
+}
+
+// SetSessionHook sets a hook run at the start of every call transaction.
+func (s *Service) SetSessionHook(hook func(ctx context.Context, tx *sqlx.Tx) error) {
+	s.session = hook
+}
+
+// Refresh reloads the exposed functions from the database.
+func (s *Service) Refresh(ctx context.Context) error {
+	functions, err := Introspect(ctx, s.db, s.config.Prefix)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.functions = functions
+	s.mu.Unlock()
+
+	s.logger.Infow("Functions loaded", "count", len(functions), "prefix", s.config.Prefix)
+	return nil
+}
+
+// List returns the exposed functions ordered by name.
+func (s *Service) List() []*Function {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	list := make([]*Function, 0, len(s.functions))
+	for _, overloads := range s.functions {
+		list = append(list, overloads...)
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// Call invokes a function with named arguments. Set-returning functions
+// return a slice, row-returning functions an object and others a single value.
+func (s *Service) Call(ctx context.Context, name string, args map[string]any) (any, error) {
+	fn, err := s.resolve(name, args)
+	if err != nil {
+		return nil, err
+	}
+
+	query, values, err := buildCall(fn, args)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []map[string]any
+	err = s.withTx(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		rows, err = scanRows(ctx, tx, query, values)
+		return err
+	})
+	if err != nil {
+		return nil, s.callError(ctx, fn, err)
+	}
+
+	switch {
+	case fn.ReturnType == "void":
+		return nil, nil
+	case fn.Composite && fn.ReturnsSet:
+		return rows, nil
+	case fn.Composite:
+		if len(rows) == 0 {
+			return nil, nil
+		}
+		return rows[0], nil
+	case fn.ReturnsSet:
+		values := make([]any, len(rows))
+		for i, row := range rows {
+			values[i] = scalar(row)
+		}
+		return values, nil
+	default:
+		if len(rows) == 0 {
+			return nil, nil
+		}
+		return scalar(rows[0]), nil
+	}
+}
+
+// resolve picks the overload of a function matching the argument names.
+func (s *Service) resolve(name string, args map[string]any) (*Function, error) {
+	s.mu.RLock()
+	overloads := s.functions[name]
+	s.mu.RUnlock()
+
+	if len(overloads) == 0 {
+		return nil, apperror.ErrNotFound.WithMessagef("Function '%s' not found", name)
+	}
+
+	var match *Function
+	for _, fn := range overloads {
+		if !fn.matches(args) {
+			continue
+		}
+		if match != nil {
+			return nil, apperror.ErrBadRequest.WithMessagef("Arguments match more than one overload of '%s'", name)
+		}
+		match = fn
+	}
+	if match == nil {
+		return nil, apperror.ErrBadRequest.WithMessagef("Arguments do not match function '%s'", name).
+			WithDetails(overloads)
+	}
+	return match, nil
+}
+
+// buildCall builds the statement invoking a function with named arguments.
+// Values are bound as text and cast to the parameter type in SQL, so any type
+// PostgreSQL can parse is accepted regardless of the driver.
+func buildCall(fn *Function, args map[string]any) (string, []any, error) {
+	pg := dialect.PostgresDialect{}
+	exprs := make([]string, 0, len(args))
+	values := make([]any, 0, len(args))
+
+	for _, p := range fn.Params {
+		v, ok := args[p.Name]
+		if !ok {
+			continue
+		}
+
+		text, err := argText(p, v)
+		if err != nil {
+			return "", nil, err
+		}
+		values = append(values, text)
+
+		placeholder := fmt.Sprintf("$%d::text", len(values))
+		expr := placeholder + "::" + p.typeName()
+		if p.array && text != nil {
+			expr = fmt.Sprintf("ARRAY(SELECT jsonb_array_elements_text(%s::jsonb))::%s", placeholder, p.typeName())
+		}
+		exprs = append(exprs, pg.QuoteIdent(p.Name)+" => "+expr)
+	}
+
+	target := fmt.Sprintf("%s.%s(%s)", pg.QuoteIdent(fn.schema), pg.QuoteIdent(fn.Routine), strings.Join(exprs, ", "))
+	switch {
+	case fn.Kind == KindProcedure:
+		return "CALL " + target, values, nil
+	case fn.ReturnType == "void":
+		return "SELECT " + target, values, nil
+	default:
+		return "SELECT * FROM " + target, values, nil
+	}
+}
+
+// argText converts a JSON argument to its text representation.
+func argText(p Param, v any) (any, error) {
+	if v == nil {
+		return nil, nil
+	}
+
+	if p.isJSON() || p.array {
+		if _, ok := v.([]any); p.array && !ok {
+			return nil, apperror.ErrBadRequest.WithMessagef("Argument '%s' must be an array", p.Name)
+		}
+		b, err := json.Marshal(v)
+		if err != nil {
+			return nil, apperror.ErrBadRequest.WithMessagef("Argument '%s' is not valid JSON", p.Name)
+		}
+		return string(b), nil
+	}
+
+	switch val := v.(type) {
+	case string:
+		return val, nil
+	case json.Number:
+		return val.String(), nil
+	case float64:
+		return strconv.FormatFloat(val, 'f', -1, 64), nil
+	case bool:
+		return strconv.FormatBool(val), nil
+	default:
+		return nil, apperror.ErrBadRequest.WithMessagef("Argument '%s' must be a %s", p.Name, p.Type)
+	}
+}
+
+// scanRows runs a query and scans all rows, decoding JSON columns.
+func scanRows(ctx context.Context, tx *sqlx.Tx, query string, args []any) ([]map[string]any, error) {
+	rows, err := tx.QueryxContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+	jsonColumns := make(map[string]bool)
+	for _, t := range types {
+		if name := strings.ToLower(t.DatabaseTypeName()); name == "json" || name == "jsonb" {
+			jsonColumns[t.Name()] = true
+		}
+	}
+
+	result := make([]map[string]any, 0)
+	for rows.Next() {
+		row := make(map[string]any)
+		if err := rows.MapScan(row); err != nil {
+			return nil, err
+		}
+		for k, v := range row {
+			row[k] = columnValue(v, jsonColumns[k])
+		}
+		result = append(result, row)
+	}
+	return result, rows.Err()
+}
+
+// columnValue normalizes a scanned value, decoding JSON text.
+func columnValue(v any, isJSON bool) any {
+	var raw []byte
+	switch val := v.(type) {
+	case []byte:
+		raw = val
+	case string:
+		if !isJSON {
+			return val
+		}
+		raw = []byte(val)
+	default:
+		return v
+	}
+
+	if isJSON {
+		var decoded any
+		if err := json.Unmarshal(raw, &decoded); err == nil {
+			return decoded
+		}
+	}
+	return string(raw)
+}
+
+// withTx runs fn in a transaction with the statement timeout applied.
+func (s *Service) withTx(ctx context.Context, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if s.session != nil {
+		if err := s.session(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if timeout := s.config.StatementTimeout; timeout > 0 {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+			return err
+		}
+	}
+	if err := fn(ctx, tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// callError maps a database error to an AppError. Exceptions raised by the
+// function and invalid input are reported to the client with their message.
+func (s *Service) callError(ctx context.Context, fn *Function, err error) error {
+	code, message := pgError(err)
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return apperror.ErrRequestCanceled.WithError(err)
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) || code == "57014":
+		return apperror.ErrTimeout.WithError(err)
+	case code == "23505":
+		return apperror.ErrConflict.WithMessage(message)
+	case code == "42501":
+		return apperror.ErrForbidden.WithMessagef("Permission denied for function '%s'", fn.Name)
+	case code == "P0001" || strings.HasPrefix(code, "22") || strings.HasPrefix(code, "23"):
+		return apperror.ErrBadRequest.WithMessage(message)
+	}
+
+	requestlog.Logger(ctx, s.logger).Errorw("Function call failed", "function", fn.Routine, "error", err)
+	return apperror.ErrInternalServer.WithMessagef("Function '%s' failed", fn.Name)
+}
+
+// pgError returns the SQLSTATE code and message of a PostgreSQL error.
+func pgError(err error) (string, string) {
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		return string(pqErr.Code), pqErr.Message
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code, pgErr.Message
+	}
+	return "", ""
+}
+
+// scalar returns the value of a single-column row.
+func scalar(row map[string]any) any {
+	for _, v := range row {
+		return v
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package rpc
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestBuildCall(t *testing.T) {
+	total := &Function{
+		Name: "order_total", Routine: "api_fn_order_total", Kind: KindFunction,
+		ReturnType: "numeric", schema: "public",
+		Params: []Param{
+			{Name: "order_id", Type: "int4"},
+			{Name: "discount", Type: "numeric", HasDefault: true},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		fn         *Function
+		args       map[string]any
+		wantSQL    string
+		wantValues []any
+		wantErr    bool
+	}{
+		{
+			name:       "named text-cast arguments",
+			fn:         total,
+			args:       map[string]any{"order_id": json.Number("7"), "discount": "0.5"},
+			wantSQL:    `SELECT * FROM "public"."api_fn_order_total"("order_id" => $1::text::"int4", "discount" => $2::text::"numeric")`,
+			wantValues: []any{"7", "0.5"},
+		},
+		{
+			name:       "defaulted argument omitted",
+			fn:         total,
+			args:       map[string]any{"order_id": nil},
+			wantSQL:    `SELECT * FROM "public"."api_fn_order_total"("order_id" => $1::text::"int4")`,
+			wantValues: []any{nil},
+		},
+		{
+			name: "array, json and custom type arguments",
+			fn: &Function{
+				Routine: "api_fn_tag", Kind: KindFunction, ReturnType: "void", schema: "app",
+				Params: []Param{
+					{Name: "ids", Type: "int8", array: true, schema: "pg_catalog"},
+					{Name: "meta", Type: "jsonb", schema: "pg_catalog"},
+					{Name: "mood", Type: "mood", schema: "app"},
+				},
+			},
+			args: map[string]any{"ids": []any{json.Number("1"), json.Number("2")}, "meta": "x", "mood": "happy"},
+			wantSQL: `SELECT "app"."api_fn_tag"("ids" => ARRAY(SELECT jsonb_array_elements_text($1::text::jsonb))::"int8"[], ` +
+				`"meta" => $2::text::"jsonb", "mood" => $3::text::"app"."mood")`,
+			wantValues: []any{"[1,2]", `"x"`, "happy"},
+		},
+		{
+			name:       "procedure",
+			fn:         &Function{Routine: "api_fn_archive", Kind: KindProcedure, schema: "public", Params: []Param{{Name: "done", Type: "bool"}}},
+			args:       map[string]any{"done": true},
+			wantSQL:    `CALL "public"."api_fn_archive"("done" => $1::text::"bool")`,
+			wantValues: []any{"true"},
+		},
+		{
+			name:    "object for scalar parameter",
+			fn:      total,
+			args:    map[string]any{"order_id": map[string]any{"a": 1}},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, values, err := buildCall(tt.fn, tt.args)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got SQL %q", sql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(values, tt.wantValues) {
+				t.Errorf("expected values %v, got %v", tt.wantValues, values)
+			}
+		})
+	}
+}
@@ -0,0 +1,74 @@
+package schema
+
+// Duplicate match modes.
+const (
+	MatchExact           = "exact"
+	MatchCaseInsensitive = "iexact"
+	MatchSimilar         = "similar"
+)
+
+// DefaultSimilarityThreshold is the trigram similarity similar matches need
+// when none is configured, the same as pg_trgm's default.
+const DefaultSimilarityThreshold = 0.3
+
+// DuplicateMatch is a rule comparing a field of a candidate item with the
+// stored items when looking for duplicates.
+type DuplicateMatch struct {
+	// Field is the field compared.
+	Field string
+
+	// Mode is "exact" (default), "iexact" for a case-insensitive comparison
+	// or "similar" for trigram similarity.
+	Mode string
+
+	// Threshold is the similarity from 0 to 1 a similar match needs.
+	// Default: DefaultSimilarityThreshold
+	Threshold float64
+}
+
+// duplicateMatches resolves the duplicate match rules of a collection,
+// keeping rules on existing fields. Case-insensitive and similar matches
+// apply to string fields only.
+func (m *Manager) duplicateMatches(tableName, apiName string, fields []Field) []DuplicateMatch {
+	var configured []DuplicateMatch
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.DuplicateMatch) > 0 {
+			configured = cfg.DuplicateMatch
+			break
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	types := make(map[string]string, len(fields))
+	for _, f := range fields {
+		types[f.Name] = f.DataType
+	}
+	result := make([]DuplicateMatch, 0, len(configured))
+	for _, match := range configured {
+		if match.Mode == "" {
+			match.Mode = MatchExact
+		}
+		if match.Mode == MatchSimilar && match.Threshold == 0 {
+			match.Threshold = DefaultSimilarityThreshold
+		}
+
+		switch {
+		case types[match.Field] == "":
+			m.logger.Warnw("Skipping duplicate match on unknown field", "collection", apiName, "field", match.Field)
+		case match.Mode != MatchExact && match.Mode != MatchCaseInsensitive && match.Mode != MatchSimilar:
+			m.logger.Warnw("Skipping duplicate match with unknown mode", "collection", apiName, "field", match.Field, "mode", match.Mode)
+		case match.Mode != MatchExact && types[match.Field] != "string":
+			m.logger.Warnw("Skipping duplicate match that needs a string field", "collection", apiName, "field", match.Field, "mode", match.Mode)
+		case match.Mode == MatchSimilar && (match.Threshold < 0 || match.Threshold > 1):
+			m.logger.Warnw("Skipping duplicate match with a threshold outside 0 to 1", "collection", apiName, "field", match.Field, "threshold", match.Threshold)
+		default:
+			result = append(result, match)
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
@@ -0,0 +1,60 @@
+package schema
+
+import "github.com/thienel/tugo/pkg/idgen"
+
+// snowflakeTypes lists the column types wide enough for snowflake IDs.
+var snowflakeTypes = map[string]bool{"int8": true, "bigint": true, "integer": true}
+
+// idGeneration resolves how a collection's primary key is generated on
+// create. A strategy applies to a single primary key column without a
+// database default whose type holds the strategy's IDs.
+func (m *Manager) idGeneration(tableName, apiName string, collection *Collection) *idgen.Properties {
+	var strategy string
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && cfg.IDStrategy != "" {
+			strategy = cfg.IDStrategy
+			break
+		}
+	}
+	if strategy == "" {
+		return nil
+	}
+
+	props, ok := idgen.Describe(strategy)
+	if !ok {
+		m.logger.Warnw("Skipping unknown ID strategy", "collection", apiName, "strategy", strategy)
+		return nil
+	}
+
+	var key *Field
+	keys := 0
+	for i, f := range collection.Fields {
+		if f.IsPrimaryKey {
+			key = &collection.Fields[i]
+			keys++
+		}
+	}
+	switch {
+	case keys != 1:
+		m.logger.Warnw("Skipping ID strategy without a single primary key column", "collection", apiName, "strategy", strategy)
+		return nil
+	case key.DefaultValue != nil:
+		m.logger.Warnw("Skipping ID strategy for a primary key with a database default", "collection", apiName, "strategy", strategy)
+		return nil
+	}
+
+	var fits bool
+	switch props.Type {
+	case "integer":
+		fits = key.DataType == "int" && snowflakeTypes[key.PostgresType]
+	case "uuid":
+		fits = key.DataType == "uuid" || (key.DataType == "string" && (key.MaxLength == nil || *key.MaxLength >= props.Length))
+	default:
+		fits = key.DataType == "string" && (key.MaxLength == nil || *key.MaxLength >= props.Length)
+	}
+	if !fits {
+		m.logger.Warnw("Skipping ID strategy whose IDs do not fit the primary key column", "collection", apiName, "strategy", strategy, "type", key.PostgresType)
+		return nil
+	}
+	return &props
+}
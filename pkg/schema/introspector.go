@@ -4,20 +4,44 @@ import (
 	"context"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
 )
 
-// Introspector queries PostgreSQL for schema information.
-type Introspector struct {
+// Introspector reads table metadata from the database catalog.
+type Introspector interface {
+	GetTables(ctx context.Context, prefix string) ([]string, error)
+	GetColumns(ctx context.Context, tableName string) ([]PostgresColumnInfo, error)
+	GetPrimaryKeys(ctx context.Context, tableName string) ([]PostgresPrimaryKeyInfo, error)
+	GetForeignKeys(ctx context.Context, tableName string) ([]PostgresForeignKeyInfo, error)
+	GetUniqueColumns(ctx context.Context, tableName string) ([]PostgresUniqueInfo, error)
+	GetAllForeignKeys(ctx context.Context, prefix string) ([]PostgresForeignKeyInfo, error)
+	TableExists(ctx context.Context, tableName string) (bool, error)
+}
+
+// NewIntrospector creates an Introspector matching the connection's driver.
+func NewIntrospector(db *sqlx.DB) Introspector {
+	switch dialect.ForDriver(db.DriverName()).Name() {
+	case dialect.MySQL:
+		return NewMySQLIntrospector(db)
+	case dialect.SQLite:
+		return NewSQLiteIntrospector(db)
+	default:
+		return NewPostgresIntrospector(db)
+	}
+}
+
+// PostgresIntrospector queries PostgreSQL for schema information.
+type PostgresIntrospector struct {
 	db *sqlx.DB
 }
 
-// NewIntrospector creates a new Introspector.
-func NewIntrospector(db *sqlx.DB) *Introspector {
-	return &Introspector{db: db}
+// NewPostgresIntrospector creates a new PostgresIntrospector.
+func NewPostgresIntrospector(db *sqlx.DB) *PostgresIntrospector {
+	return &PostgresIntrospector{db: db}
 }
 
 // GetTables returns all table names matching the given prefix.
-func (i *Introspector) GetTables(ctx context.Context, prefix string) ([]string, error) {
+func (i *PostgresIntrospector) GetTables(ctx context.Context, prefix string) ([]string, error) {
 	query := `
 		SELECT table_name
 		FROM information_schema.tables
@@ -35,7 +59,7 @@ func (i *Introspector) GetTables(ctx context.Context, prefix string) ([]string,
 }
 
 // GetColumns returns column information for a table.
-func (i *Introspector) GetColumns(ctx context.Context, tableName string) ([]PostgresColumnInfo, error) {
+func (i *PostgresIntrospector) GetColumns(ctx context.Context, tableName string) ([]PostgresColumnInfo, error) {
 	query := `
 		SELECT
 			table_name,
@@ -61,7 +85,7 @@ func (i *Introspector) GetColumns(ctx context.Context, tableName string) ([]Post
 }
 
 // GetPrimaryKeys returns primary key columns for a table.
-func (i *Introspector) GetPrimaryKeys(ctx context.Context, tableName string) ([]PostgresPrimaryKeyInfo, error) {
+func (i *PostgresIntrospector) GetPrimaryKeys(ctx context.Context, tableName string) ([]PostgresPrimaryKeyInfo, error) {
 	query := `
 		SELECT
 			tc.table_name,
@@ -83,7 +107,7 @@ func (i *Introspector) GetPrimaryKeys(ctx context.Context, tableName string) ([]
 }
 
 // GetForeignKeys returns foreign key information for a table.
-func (i *Introspector) GetForeignKeys(ctx context.Context, tableName string) ([]PostgresForeignKeyInfo, error) {
+func (i *PostgresIntrospector) GetForeignKeys(ctx context.Context, tableName string) ([]PostgresForeignKeyInfo, error) {
 	query := `
 		SELECT
 			tc.constraint_name,
@@ -115,10 +139,12 @@ func (i *Introspector) GetForeignKeys(ctx context.Context, tableName string) ([]
 	return fks, nil
 }
 
-// GetUniqueColumns returns columns with unique constraints.
-func (i *Introspector) GetUniqueColumns(ctx context.Context, tableName string) ([]PostgresUniqueInfo, error) {
+// GetUniqueColumns returns the columns of unique constraints, in
+// constraint order.
+func (i *PostgresIntrospector) GetUniqueColumns(ctx context.Context, tableName string) ([]PostgresUniqueInfo, error) {
 	query := `
 		SELECT
+			tc.constraint_name,
 			tc.table_name,
 			kcu.column_name
 		FROM information_schema.table_constraints tc
@@ -128,6 +154,7 @@ func (i *Introspector) GetUniqueColumns(ctx context.Context, tableName string) (
 		WHERE tc.constraint_type = 'UNIQUE'
 		AND tc.table_schema = 'public'
 		AND tc.table_name = $1
+		ORDER BY tc.constraint_name, kcu.ordinal_position
 	`
 	var uniques []PostgresUniqueInfo
 	err := i.db.SelectContext(ctx, &uniques, query, tableName)
@@ -138,7 +165,7 @@ func (i *Introspector) GetUniqueColumns(ctx context.Context, tableName string) (
 }
 
 // GetAllForeignKeys returns all foreign keys in the database.
-func (i *Introspector) GetAllForeignKeys(ctx context.Context, prefix string) ([]PostgresForeignKeyInfo, error) {
+func (i *PostgresIntrospector) GetAllForeignKeys(ctx context.Context, prefix string) ([]PostgresForeignKeyInfo, error) {
 	query := `
 		SELECT
 			tc.constraint_name,
@@ -171,7 +198,7 @@ func (i *Introspector) GetAllForeignKeys(ctx context.Context, prefix string) ([]
 }
 
 // TableExists checks if a table exists.
-func (i *Introspector) TableExists(ctx context.Context, tableName string) (bool, error) {
+func (i *PostgresIntrospector) TableExists(ctx context.Context, tableName string) (bool, error) {
 	query := `
 		SELECT EXISTS (
 			SELECT FROM information_schema.tables
@@ -180,9 +207,48 @@ func (i *Introspector) TableExists(ctx context.Context, tableName string) (bool,
 		)
 	`
 	var exists bool
-	err := i.db.GetContext(ctx, &exists, query)
+	err := i.db.GetContext(ctx, &exists, query, tableName)
 	if err != nil {
 		return false, err
 	}
 	return exists, nil
 }
+
+// GetExtensions returns the names of the installed extensions.
+func (i *PostgresIntrospector) GetExtensions(ctx context.Context) ([]string, error) {
+	query := `
+		SELECT extname
+		FROM pg_extension
+		ORDER BY extname
+	`
+	var extensions []string
+	err := i.db.SelectContext(ctx, &extensions, query)
+	if err != nil {
+		return nil, err
+	}
+	return extensions, nil
+}
+
+// GetTrigramColumns returns the columns of a table with a GIN or GiST
+// trigram index.
+func (i *PostgresIntrospector) GetTrigramColumns(ctx context.Context, tableName string) ([]string, error) {
+	query := `
+		SELECT DISTINCT a.attname
+		FROM pg_index x
+		JOIN pg_class t ON t.oid = x.indrelid
+		JOIN pg_namespace n ON n.oid = t.relnamespace
+		CROSS JOIN LATERAL unnest(x.indkey::int2[], x.indclass::oid[]) AS k(attnum, opclass)
+		JOIN pg_opclass o ON o.oid = k.opclass
+		JOIN pg_attribute a ON a.attrelid = t.oid AND a.attnum = k.attnum
+		WHERE n.nspname = 'public'
+		AND t.relname = $1
+		AND o.opcname IN ('gin_trgm_ops', 'gist_trgm_ops')
+		ORDER BY a.attname
+	`
+	var columns []string
+	err := i.db.SelectContext(ctx, &columns, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
@@ -0,0 +1,160 @@
+package schema
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// MySQLIntrospector queries MySQL/MariaDB for schema information.
+// Tables are read from the database selected by the connection.
+type MySQLIntrospector struct {
+	db *sqlx.DB
+}
+
+// NewMySQLIntrospector creates a new MySQLIntrospector.
+func NewMySQLIntrospector(db *sqlx.DB) *MySQLIntrospector {
+	return &MySQLIntrospector{db: db}
+}
+
+// GetTables returns all table names matching the given prefix.
+func (i *MySQLIntrospector) GetTables(ctx context.Context, prefix string) ([]string, error) {
+	query := `
+		SELECT table_name
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		AND table_type = 'BASE TABLE'
+		AND table_name LIKE ?
+		ORDER BY table_name
+	`
+	var tables []string
+	err := i.db.SelectContext(ctx, &tables, query, prefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// GetColumns returns column information for a table.
+// tinyint(1) columns are reported as bool to match MySQL's boolean convention.
+func (i *MySQLIntrospector) GetColumns(ctx context.Context, tableName string) ([]PostgresColumnInfo, error) {
+	query := `
+		SELECT
+			table_name AS table_name,
+			column_name AS column_name,
+			data_type AS data_type,
+			CASE WHEN column_type = 'tinyint(1)' THEN 'bool' ELSE data_type END AS udt_name,
+			is_nullable AS is_nullable,
+			column_default AS column_default,
+			character_maximum_length AS character_maximum_length,
+			numeric_precision AS numeric_precision,
+			numeric_scale AS numeric_scale
+		FROM information_schema.columns
+		WHERE table_schema = DATABASE()
+		AND table_name = ?
+		ORDER BY ordinal_position
+	`
+	var columns []PostgresColumnInfo
+	err := i.db.SelectContext(ctx, &columns, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return columns, nil
+}
+
+// GetPrimaryKeys returns primary key columns for a table.
+func (i *MySQLIntrospector) GetPrimaryKeys(ctx context.Context, tableName string) ([]PostgresPrimaryKeyInfo, error) {
+	query := `
+		SELECT
+			table_name AS table_name,
+			column_name AS column_name
+		FROM information_schema.key_column_usage
+		WHERE constraint_name = 'PRIMARY'
+		AND table_schema = DATABASE()
+		AND table_name = ?
+	`
+	var pks []PostgresPrimaryKeyInfo
+	err := i.db.SelectContext(ctx, &pks, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return pks, nil
+}
+
+// GetForeignKeys returns foreign key information for a table.
+func (i *MySQLIntrospector) GetForeignKeys(ctx context.Context, tableName string) ([]PostgresForeignKeyInfo, error) {
+	return i.foreignKeys(ctx, "kcu.table_name = ?", tableName)
+}
+
+// GetUniqueColumns returns the columns of unique constraints, in
+// constraint order.
+func (i *MySQLIntrospector) GetUniqueColumns(ctx context.Context, tableName string) ([]PostgresUniqueInfo, error) {
+	query := `
+		SELECT
+			tc.constraint_name AS constraint_name,
+			tc.table_name AS table_name,
+			kcu.column_name AS column_name
+		FROM information_schema.table_constraints tc
+		JOIN information_schema.key_column_usage kcu
+			ON tc.constraint_name = kcu.constraint_name
+			AND tc.table_schema = kcu.table_schema
+			AND tc.table_name = kcu.table_name
+		WHERE tc.constraint_type = 'UNIQUE'
+		AND tc.table_schema = DATABASE()
+		AND tc.table_name = ?
+		ORDER BY tc.constraint_name, kcu.ordinal_position
+	`
+	var uniques []PostgresUniqueInfo
+	err := i.db.SelectContext(ctx, &uniques, query, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return uniques, nil
+}
+
+// GetAllForeignKeys returns all foreign keys in the database.
+func (i *MySQLIntrospector) GetAllForeignKeys(ctx context.Context, prefix string) ([]PostgresForeignKeyInfo, error) {
+	return i.foreignKeys(ctx, "kcu.table_name LIKE ?", prefix+"%")
+}
+
+// TableExists checks if a table exists.
+func (i *MySQLIntrospector) TableExists(ctx context.Context, tableName string) (bool, error) {
+	query := `
+		SELECT COUNT(*) > 0
+		FROM information_schema.tables
+		WHERE table_schema = DATABASE()
+		AND table_name = ?
+	`
+	var exists bool
+	err := i.db.GetContext(ctx, &exists, query, tableName)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// foreignKeys runs the foreign key query with the given table condition.
+func (i *MySQLIntrospector) foreignKeys(ctx context.Context, condition string, arg any) ([]PostgresForeignKeyInfo, error) {
+	query := `
+		SELECT
+			kcu.constraint_name AS constraint_name,
+			kcu.table_name AS table_name,
+			kcu.column_name AS column_name,
+			kcu.referenced_table_name AS foreign_table_name,
+			kcu.referenced_column_name AS foreign_column_name,
+			rc.delete_rule AS delete_rule,
+			rc.update_rule AS update_rule
+		FROM information_schema.key_column_usage kcu
+		JOIN information_schema.referential_constraints rc
+			ON kcu.constraint_name = rc.constraint_name
+			AND kcu.table_schema = rc.constraint_schema
+		WHERE kcu.referenced_table_name IS NOT NULL
+		AND kcu.table_schema = DATABASE()
+		AND ` + condition
+	var fks []PostgresForeignKeyInfo
+	err := i.db.SelectContext(ctx, &fks, query, arg)
+	if err != nil {
+		return nil, err
+	}
+	return fks, nil
+}
@@ -0,0 +1,208 @@
+package schema
+
+import (
+	"context"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// SQLiteIntrospector reads schema information using SQLite pragmas.
+type SQLiteIntrospector struct {
+	db *sqlx.DB
+}
+
+// NewSQLiteIntrospector creates a new SQLiteIntrospector.
+func NewSQLiteIntrospector(db *sqlx.DB) *SQLiteIntrospector {
+	return &SQLiteIntrospector{db: db}
+}
+
+// GetTables returns all table names matching the given prefix.
+func (i *SQLiteIntrospector) GetTables(ctx context.Context, prefix string) ([]string, error) {
+	query := `
+		SELECT name
+		FROM sqlite_master
+		WHERE type = 'table'
+		AND name NOT LIKE 'sqlite_%'
+		AND name LIKE ?
+		ORDER BY name
+	`
+	var tables []string
+	err := i.db.SelectContext(ctx, &tables, query, prefix+"%")
+	if err != nil {
+		return nil, err
+	}
+	return tables, nil
+}
+
+// GetColumns returns column information for a table.
+// Declared types such as VARCHAR(255) are split into a base type and length.
+func (i *SQLiteIntrospector) GetColumns(ctx context.Context, tableName string) ([]PostgresColumnInfo, error) {
+	query := `
+		SELECT
+			? AS table_name,
+			name AS column_name,
+			type AS data_type,
+			type AS udt_name,
+			CASE WHEN "notnull" = 1 OR pk > 0 THEN 'NO' ELSE 'YES' END AS is_nullable,
+			dflt_value AS column_default
+		FROM pragma_table_info(?)
+		ORDER BY cid
+	`
+	var columns []PostgresColumnInfo
+	err := i.db.SelectContext(ctx, &columns, query, tableName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range columns {
+		baseType, length := parseSQLiteType(columns[idx].DataType)
+		columns[idx].UDTName = baseType
+		columns[idx].CharMaxLength = length
+		columns[idx].NumPrecision, columns[idx].NumScale = parseSQLitePrecision(columns[idx].DataType)
+	}
+	return columns, nil
+}
+
+// GetPrimaryKeys returns primary key columns for a table.
+func (i *SQLiteIntrospector) GetPrimaryKeys(ctx context.Context, tableName string) ([]PostgresPrimaryKeyInfo, error) {
+	query := `
+		SELECT ? AS table_name, name AS column_name
+		FROM pragma_table_info(?)
+		WHERE pk > 0
+		ORDER BY pk
+	`
+	var pks []PostgresPrimaryKeyInfo
+	err := i.db.SelectContext(ctx, &pks, query, tableName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return pks, nil
+}
+
+// GetForeignKeys returns foreign key information for a table.
+// References without an explicit column resolve to the parent's primary key.
+func (i *SQLiteIntrospector) GetForeignKeys(ctx context.Context, tableName string) ([]PostgresForeignKeyInfo, error) {
+	query := `
+		SELECT
+			'fk_' || ? || '_' || id AS constraint_name,
+			? AS table_name,
+			"from" AS column_name,
+			"table" AS foreign_table_name,
+			COALESCE("to", '') AS foreign_column_name,
+			on_delete AS delete_rule,
+			on_update AS update_rule
+		FROM pragma_foreign_key_list(?)
+	`
+	var fks []PostgresForeignKeyInfo
+	err := i.db.SelectContext(ctx, &fks, query, tableName, tableName, tableName)
+	if err != nil {
+		return nil, err
+	}
+
+	for idx := range fks {
+		if fks[idx].ForeignColumnName != "" {
+			continue
+		}
+		pks, err := i.GetPrimaryKeys(ctx, fks[idx].ForeignTableName)
+		if err != nil {
+			return nil, err
+		}
+		if len(pks) > 0 {
+			fks[idx].ForeignColumnName = pks[0].ColumnName
+		}
+	}
+	return fks, nil
+}
+
+// GetUniqueColumns returns the columns of unique indexes, in index order.
+// Partial indexes are skipped, as they only apply to some rows.
+func (i *SQLiteIntrospector) GetUniqueColumns(ctx context.Context, tableName string) ([]PostgresUniqueInfo, error) {
+	query := `
+		SELECT il.name AS constraint_name, ? AS table_name, ii.name AS column_name
+		FROM pragma_index_list(?) il
+		JOIN pragma_index_info(il.name) ii
+		WHERE il."unique" = 1
+		AND il.origin != 'pk'
+		AND il.partial = 0
+		ORDER BY il.name, ii.seqno
+	`
+	var uniques []PostgresUniqueInfo
+	err := i.db.SelectContext(ctx, &uniques, query, tableName, tableName)
+	if err != nil {
+		return nil, err
+	}
+	return uniques, nil
+}
+
+// GetAllForeignKeys returns all foreign keys for tables matching the prefix.
+func (i *SQLiteIntrospector) GetAllForeignKeys(ctx context.Context, prefix string) ([]PostgresForeignKeyInfo, error) {
+	tables, err := i.GetTables(ctx, prefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var all []PostgresForeignKeyInfo
+	for _, table := range tables {
+		fks, err := i.GetForeignKeys(ctx, table)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, fks...)
+	}
+	return all, nil
+}
+
+// TableExists checks if a table exists.
+func (i *SQLiteIntrospector) TableExists(ctx context.Context, tableName string) (bool, error) {
+	query := `SELECT COUNT(*) > 0 FROM sqlite_master WHERE type = 'table' AND name = ?`
+	var exists bool
+	err := i.db.GetContext(ctx, &exists, query, tableName)
+	if err != nil {
+		return false, err
+	}
+	return exists, nil
+}
+
+// parseSQLiteType splits a declared type like "VARCHAR(255)" into "varchar" and 255.
+func parseSQLiteType(declared string) (string, *int) {
+	declared = strings.ToLower(strings.TrimSpace(declared))
+	base, args, found := strings.Cut(declared, "(")
+	base = strings.TrimSpace(base)
+	if !found {
+		return base, nil
+	}
+
+	args = strings.TrimSuffix(strings.TrimSpace(args), ")")
+	if strings.Contains(args, ",") {
+		return base, nil
+	}
+	length, err := strconv.Atoi(strings.TrimSpace(args))
+	if err != nil {
+		return base, nil
+	}
+	return base, &length
+}
+
+// parseSQLitePrecision returns the precision and scale of a declared type
+// such as "DECIMAL(10,2)", or nil for types declared without both.
+func parseSQLitePrecision(declared string) (*int, *int) {
+	_, args, found := strings.Cut(declared, "(")
+	if !found {
+		return nil, nil
+	}
+	p, sc, found := strings.Cut(strings.TrimSuffix(strings.TrimSpace(args), ")"), ",")
+	if !found {
+		return nil, nil
+	}
+	precision, err := strconv.Atoi(strings.TrimSpace(p))
+	if err != nil {
+		return nil, nil
+	}
+	scale, err := strconv.Atoi(strings.TrimSpace(sc))
+	if err != nil {
+		return nil, nil
+	}
+	return &precision, &scale
+}
@@ -2,6 +2,8 @@ package schema
 
 import (
 	"context"
+	"regexp"
+	"sort"
 	"strings"
 	"sync"
 	"time"
@@ -28,18 +30,120 @@ type ManagerConfig struct {
 	AutoDiscover bool
 	Blacklist    []string
 	Config       map[string]CollectionConfig
+
+	// StatementTimeout is the default per-statement timeout for collection queries.
+	// Zero disables the timeout.
+	StatementTimeout time.Duration
+
+	// RequestTimeout is the default deadline of collection requests.
+	// Zero disables the deadline.
+	RequestTimeout time.Duration
+
+	// DefaultLimit and MaxLimit are the default list page size bounds.
+	DefaultLimit int
+	MaxLimit     int
+
+	// MaxOffset and MaxExpand are the default expensive query limits.
+	// Zero disables them.
+	MaxOffset int
+	MaxExpand int
+
+	// MaxBodyBytes is the default request body limit for collections.
+	MaxBodyBytes int64
+
+	// AutoFields names the columns filled in on write for every collection.
+	AutoFields AutoFields
+
+	// StrictParams rejects unknown query parameters on list requests.
+	StrictParams bool
 }
 
 // CollectionConfig holds per-collection configuration.
 type CollectionConfig struct {
 	Enabled      bool
 	PublicFields []string
+
+	// StatementTimeout overrides ManagerConfig.StatementTimeout when non-zero.
+	StatementTimeout time.Duration
+
+	// RequestTimeout overrides ManagerConfig.RequestTimeout when non-zero.
+	RequestTimeout time.Duration
+
+	// DefaultLimit and MaxLimit override the manager's page size bounds when non-zero.
+	DefaultLimit int
+	MaxLimit     int
+
+	// History enables record revisions for the collection.
+	History bool
+
+	// Comments enables threaded comments on the collection's items.
+	Comments bool
+
+	// Favorites lets users star the collection's items.
+	Favorites bool
+
+	// MaxOffset and MaxExpand override the manager's query limits when non-zero.
+	MaxOffset int
+	MaxExpand int
+
+	// SearchFields restricts like filters to indexed fields.
+	SearchFields []string
+
+	// DefaultFilter and DefaultSort apply to lists that do not filter the
+	// same fields or sort.
+	DefaultFilter map[string]string
+	DefaultSort   string
+
+	// Aliases maps columns to the names the API uses for them.
+	Aliases map[string]string
+
+	// MaxBodyBytes overrides ManagerConfig.MaxBodyBytes when non-zero.
+	MaxBodyBytes int64
+
+	// AutoFields overrides ManagerConfig.AutoFields when non-nil.
+	AutoFields *AutoFields
+
+	// ImmutableFields lists fields that updates may not change.
+	ImmutableFields []string
+
+	// Slugs maps slug fields to their source fields.
+	Slugs map[string]string
+
+	// StrictParams overrides ManagerConfig.StrictParams when non-nil.
+	StrictParams *bool
+
+	// Translations lists fields also stored per locale in the table's
+	// translations table.
+	Translations []string
+
+	// Money maps money fields to their currency.
+	Money map[string]Money
+
+	// DuplicateMatch lists the rules used to find duplicates of an item.
+	DuplicateMatch []DuplicateMatch
+
+	// Embeddings maps vector fields to the text fields they embed.
+	Embeddings map[string]string
+
+	// IDStrategy generates the primary key on create, one of the idgen
+	// strategies.
+	IDStrategy string
+
+	// Public lists the actions allowed without authentication.
+	Public []string
+
+	// Approval holds creates and updates for review when non-nil.
+	Approval *Approval
+
+	// Lifecycle adds a status lifecycle when non-nil. Empty settings take
+	// the defaults of DefaultLifecycle.
+	Lifecycle *Lifecycle
 }
 
 // Manager handles schema discovery and metadata management.
 type Manager struct {
 	db           *sqlx.DB
-	introspector *Introspector
+	introspector Introspector
 	config       ManagerConfig
 	logger       *zap.SugaredLogger
 
@@ -79,6 +183,42 @@ func (m *Manager) Refresh(ctx context.Context) error {
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	return m.refresh(ctx)
+}
+
+// Reconfigure replaces the manager configuration and rediscovers collections.
+// The collections are discovered on a staged manager and swapped in at once,
+// so readers see either the old or the new collections, never a mix. check,
+// if non-nil, is called with the staged manager; if it fails, or the tables
+// cannot be listed, the previous configuration is kept.
+func (m *Manager) Reconfigure(ctx context.Context, config ManagerConfig, check func(staged *Manager) error) error {
+	staged := &Manager{
+		db:           m.db,
+		introspector: m.introspector,
+		config:       config,
+		logger:       m.logger,
+	}
+	if err := staged.refresh(ctx); err != nil {
+		return err
+	}
+	if check != nil {
+		if err := check(staged); err != nil {
+			return err
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.config = staged.config
+	m.collections = staged.collections
+	m.relationships = staged.relationships
+	m.lastRefresh = staged.lastRefresh
+	return nil
+}
+
+// refresh rebuilds the collections. The caller must hold m.mu.
+func (m *Manager) refresh(ctx context.Context) error {
 	m.logger.Info("Refreshing schema...")
 
 	// Get all tables matching prefix
@@ -94,12 +234,18 @@ func (m *Manager) Refresh(ctx context.Context) error {
 	m.collections = make(map[string]*Collection)
 	m.relationships = make(map[string][]Relationship)
 
+	extensions := m.extensions(ctx)
+
 	// Process each table
 	for _, tableName := range tables {
 		if m.isBlacklisted(tableName) {
 			m.logger.Debugw("Skipping blacklisted table", "table", tableName)
 			continue
 		}
+		if m.isTranslationsTable(tableName) {
+			m.logger.Debugw("Skipping translations table", "table", tableName)
+			continue
+		}
 
 		apiName := m.tableToAPIName(tableName)
 		enabled := m.isEnabled(tableName, apiName)
@@ -115,6 +261,29 @@ func (m *Manager) Refresh(ctx context.Context) error {
 			continue
 		}
 
+		collection.StatementTimeout = m.statementTimeout(tableName, apiName)
+		collection.RequestTimeout = m.requestTimeout(tableName, apiName)
+		collection.DefaultLimit, collection.MaxLimit = m.pageLimits(tableName, apiName)
+		collection.History = m.historyEnabled(tableName, apiName)
+		collection.Comments = m.commentsEnabled(tableName, apiName)
+		collection.Favorites = m.favoritesEnabled(tableName, apiName)
+		m.applyCostLimits(collection, tableName, apiName)
+		collection.DefaultFilter, collection.DefaultSort = m.listDefaults(tableName, apiName)
+		collection.Aliases = m.aliases(tableName, apiName, collection.Fields)
+		collection.MaxBodyBytes = m.maxBodyBytes(tableName, apiName)
+		collection.AutoFields = m.autoFields(tableName, apiName).existing(collection.Fields)
+		collection.Slugs = m.slugs(tableName, apiName, collection.Fields)
+		collection.StrictParams = m.strictParams(tableName, apiName)
+		collection.Translations = m.translations(ctx, tableName, apiName, collection.Fields)
+		collection.Money = m.money(tableName, apiName, collection.Fields)
+		collection.DuplicateMatch = m.duplicateMatches(tableName, apiName, collection.Fields)
+		collection.Embeddings = m.embeddings(tableName, apiName, collection.Fields)
+		collection.TextSearch = m.textSearch(ctx, tableName, apiName, extensions)
+		collection.IDGeneration = m.idGeneration(tableName, apiName, collection)
+		collection.PublicActions = m.publicActions(tableName, apiName)
+		collection.Approval = m.approval(tableName, apiName)
+		collection.Lifecycle = m.lifecycle(tableName, apiName, collection.Fields)
+
 		m.collections[apiName] = collection
 		m.logger.Debugw("Discovered collection", "collection", apiName, "fields", len(collection.Fields))
 	}
@@ -213,10 +382,7 @@ func (m *Manager) introspectTable(ctx context.Context, tableName, apiName string
 	if err != nil {
 		return nil, err
 	}
-	uniqueSet := make(map[string]bool)
-	for _, u := range uniques {
-		uniqueSet[u.ColumnName] = true
-	}
+	uniqueSet, uniqueConstraints := groupUniques(uniques)
 
 	// Get foreign keys
 	fks, err := m.introspector.GetForeignKeys(ctx, tableName)
@@ -272,9 +438,39 @@ func (m *Manager) introspectTable(ctx context.Context, tableName, apiName string
 		PrimaryKey: primaryKey,
 		CreatedAt:  time.Now(),
 		UpdatedAt:  time.Now(),
+
+		UniqueConstraints: uniqueConstraints,
 	}, nil
 }
 
+// groupUniques groups unique constraint columns by constraint. Columns
+// unique on their own are returned as a set, constraints spanning several
+// columns as a list.
+func groupUniques(uniques []PostgresUniqueInfo) (map[string]bool, []UniqueConstraint) {
+	var constraints []UniqueConstraint
+	index := make(map[string]int)
+	for _, u := range uniques {
+		i, ok := index[u.ConstraintName]
+		if !ok {
+			i = len(constraints)
+			index[u.ConstraintName] = i
+			constraints = append(constraints, UniqueConstraint{Name: u.ConstraintName})
+		}
+		constraints[i].Fields = append(constraints[i].Fields, u.ColumnName)
+	}
+
+	single := make(map[string]bool)
+	var composite []UniqueConstraint
+	for _, c := range constraints {
+		if len(c.Fields) == 1 {
+			single[c.Fields[0]] = true
+		} else {
+			composite = append(composite, c)
+		}
+	}
+	return single, composite
+}
+
 // buildRelationships creates relationship metadata from foreign keys.
 func (m *Manager) buildRelationships(ctx context.Context) error {
 	for apiName, collection := range m.collections {
@@ -369,8 +565,373 @@ func (m *Manager) isEnabled(tableName, apiName string) bool {
 	}
 }
 
+// statementTimeout resolves the statement timeout for a collection.
+func (m *Manager) statementTimeout(tableName, apiName string) time.Duration {
+	if cfg, ok := m.config.Config[apiName]; ok && cfg.StatementTimeout > 0 {
+		return cfg.StatementTimeout
+	}
+	if cfg, ok := m.config.Config[tableName]; ok && cfg.StatementTimeout > 0 {
+		return cfg.StatementTimeout
+	}
+	return m.config.StatementTimeout
+}
+
+// requestTimeout resolves the request deadline for a collection.
+func (m *Manager) requestTimeout(tableName, apiName string) time.Duration {
+	if cfg, ok := m.config.Config[apiName]; ok && cfg.RequestTimeout > 0 {
+		return cfg.RequestTimeout
+	}
+	if cfg, ok := m.config.Config[tableName]; ok && cfg.RequestTimeout > 0 {
+		return cfg.RequestTimeout
+	}
+	return m.config.RequestTimeout
+}
+
+// pageLimits resolves the default and maximum page size for a collection.
+func (m *Manager) pageLimits(tableName, apiName string) (int, int) {
+	defaultLimit, maxLimit := m.config.DefaultLimit, m.config.MaxLimit
+	for _, key := range []string{tableName, apiName} {
+		if cfg, ok := m.config.Config[key]; ok {
+			if cfg.DefaultLimit > 0 {
+				defaultLimit = cfg.DefaultLimit
+			}
+			if cfg.MaxLimit > 0 {
+				maxLimit = cfg.MaxLimit
+			}
+		}
+	}
+	return defaultLimit, maxLimit
+}
+
+// applyCostLimits resolves the expensive query limits for a collection.
+func (m *Manager) applyCostLimits(collection *Collection, tableName, apiName string) {
+	collection.MaxOffset, collection.MaxExpand = m.config.MaxOffset, m.config.MaxExpand
+	for _, key := range []string{tableName, apiName} {
+		if cfg, ok := m.config.Config[key]; ok {
+			if cfg.MaxOffset > 0 {
+				collection.MaxOffset = cfg.MaxOffset
+			}
+			if cfg.MaxExpand > 0 {
+				collection.MaxExpand = cfg.MaxExpand
+			}
+			if len(cfg.SearchFields) > 0 {
+				collection.SearchFields = cfg.SearchFields
+			}
+			if len(cfg.ImmutableFields) > 0 {
+				collection.ImmutableFields = cfg.ImmutableFields
+			}
+		}
+	}
+}
+
+// listDefaults resolves the default filter and sort of a collection's
+// lists.
+func (m *Manager) listDefaults(tableName, apiName string) (map[string]string, string) {
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && (len(cfg.DefaultFilter) > 0 || cfg.DefaultSort != "") {
+			return cfg.DefaultFilter, cfg.DefaultSort
+		}
+	}
+	return nil, ""
+}
+
+// aliasRegex matches the names aliases may take, which filter[...]
+// parameters accept.
+var aliasRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// aliases resolves the API names of a collection's columns, skipping
+// missing columns and names taken by another field or alias.
+func (m *Manager) aliases(tableName, apiName string, fields []Field) map[string]string {
+	var configured map[string]string
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.Aliases) > 0 {
+			configured = cfg.Aliases
+			break
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	taken := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		taken[f.Name] = true
+	}
+	columns := make([]string, 0, len(configured))
+	for column := range configured {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	aliases := make(map[string]string, len(configured))
+	for _, column := range columns {
+		alias := configured[column]
+		if !taken[column] || alias == "" || (taken[alias] && alias != column) || !aliasRegex.MatchString(alias) {
+			m.logger.Warnw("Skipping alias that needs an existing column and a free name", "collection", apiName, "column", column, "alias", alias)
+			continue
+		}
+		if alias != column {
+			aliases[column] = alias
+			taken[alias] = true
+		}
+	}
+	return aliases
+}
+
+// commentsEnabled reports whether comments are enabled for a collection.
+func (m *Manager) commentsEnabled(tableName, apiName string) bool {
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok {
+			return cfg.Comments
+		}
+	}
+	return false
+}
+
+// favoritesEnabled reports whether favorites are enabled for a collection.
+func (m *Manager) favoritesEnabled(tableName, apiName string) bool {
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok {
+			return cfg.Favorites
+		}
+	}
+	return false
+}
+
+// historyEnabled reports whether record revisions are enabled for a collection.
+func (m *Manager) historyEnabled(tableName, apiName string) bool {
+	if cfg, ok := m.config.Config[apiName]; ok {
+		return cfg.History
+	}
+	if cfg, ok := m.config.Config[tableName]; ok {
+		return cfg.History
+	}
+	return false
+}
+
+// autoFields resolves the auto-filled columns for a collection.
+func (m *Manager) autoFields(tableName, apiName string) AutoFields {
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && cfg.AutoFields != nil {
+			return *cfg.AutoFields
+		}
+	}
+	return m.config.AutoFields
+}
+
+// strictParams resolves whether a collection rejects unknown query parameters.
+func (m *Manager) strictParams(tableName, apiName string) bool {
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && cfg.StrictParams != nil {
+			return *cfg.StrictParams
+		}
+	}
+	return m.config.StrictParams
+}
+
+// publicActions resolves the actions a collection allows without
+// authentication, skipping unknown ones.
+func (m *Manager) publicActions(tableName, apiName string) []string {
+	for _, key := range []string{apiName, tableName} {
+		cfg, ok := m.config.Config[key]
+		if !ok || len(cfg.Public) == 0 {
+			continue
+		}
+		actions := make([]string, 0, len(cfg.Public))
+		for _, action := range cfg.Public {
+			switch action {
+			case "read", "create", "update", "delete":
+				actions = append(actions, action)
+			default:
+				m.logger.Warnw("Skipping unknown public action", "collection", apiName, "action", action)
+			}
+		}
+		return actions
+	}
+	return nil
+}
+
+// approval resolves the approval workflow of a collection, skipping
+// unknown actions.
+func (m *Manager) approval(tableName, apiName string) *Approval {
+	for _, key := range []string{apiName, tableName} {
+		cfg, ok := m.config.Config[key]
+		if !ok || cfg.Approval == nil {
+			continue
+		}
+		approval := &Approval{BypassRoles: cfg.Approval.BypassRoles}
+		for _, action := range cfg.Approval.Actions {
+			switch action {
+			case "create", "update":
+				approval.Actions = append(approval.Actions, action)
+			default:
+				m.logger.Warnw("Skipping unknown approval action", "collection", apiName, "action", action)
+			}
+		}
+		if len(approval.Actions) == 0 {
+			approval.Actions = []string{"create", "update"}
+		}
+		return approval
+	}
+	return nil
+}
+
+// DefaultLifecycle is the lifecycle of collections configuring none of its
+// settings: draft → review → published in a status field, with drafts
+// sent for review, reviews sent back or published, and published items
+// withdrawn to draft.
+var DefaultLifecycle = Lifecycle{
+	Field:     "status",
+	States:    []string{"draft", "review", "published"},
+	Published: "published",
+	Transitions: []Transition{
+		{From: "draft", To: "review"},
+		{From: "review", To: "draft"},
+		{From: "review", To: "published"},
+		{From: "published", To: "draft"},
+	},
+}
+
+// lifecycle resolves the status lifecycle of a collection, filling in
+// defaults. Custom states without transitions may move between any two
+// states. Lifecycles whose fields are missing are skipped.
+func (m *Manager) lifecycle(tableName, apiName string, fields []Field) *Lifecycle {
+	var configured *Lifecycle
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && cfg.Lifecycle != nil {
+			configured = cfg.Lifecycle
+			break
+		}
+	}
+	if configured == nil {
+		return nil
+	}
+
+	lifecycle := &Lifecycle{
+		Field:          configured.Field,
+		States:         configured.States,
+		Published:      configured.Published,
+		PublishAtField: configured.PublishAtField,
+	}
+	if lifecycle.Field == "" {
+		lifecycle.Field = DefaultLifecycle.Field
+	}
+	if len(lifecycle.States) == 0 {
+		lifecycle.States = DefaultLifecycle.States
+		if len(configured.Transitions) == 0 {
+			lifecycle.Transitions = DefaultLifecycle.Transitions
+		}
+	}
+	if lifecycle.Published == "" {
+		lifecycle.Published = DefaultLifecycle.Published
+		if !lifecycle.HasState(lifecycle.Published) {
+			lifecycle.Published = lifecycle.States[len(lifecycle.States)-1]
+		}
+	}
+
+	has := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		has[f.Name] = true
+	}
+	if !has[lifecycle.Field] || !lifecycle.HasState(lifecycle.Published) {
+		m.logger.Warnw("Skipping lifecycle that needs its status field and published state", "collection", apiName, "field", lifecycle.Field, "published", lifecycle.Published)
+		return nil
+	}
+	if lifecycle.PublishAtField != "" && !has[lifecycle.PublishAtField] {
+		m.logger.Warnw("Skipping missing publish_at field of lifecycle", "collection", apiName, "field", lifecycle.PublishAtField)
+		lifecycle.PublishAtField = ""
+	}
+
+	for _, t := range configured.Transitions {
+		if !lifecycle.HasState(t.From) || !lifecycle.HasState(t.To) || t.From == t.To {
+			m.logger.Warnw("Skipping invalid lifecycle transition", "collection", apiName, "from", t.From, "to", t.To)
+			continue
+		}
+		lifecycle.Transitions = append(lifecycle.Transitions, t)
+	}
+	if len(lifecycle.Transitions) == 0 && len(configured.Transitions) == 0 {
+		for _, from := range lifecycle.States {
+			for _, to := range lifecycle.States {
+				if from != to {
+					lifecycle.Transitions = append(lifecycle.Transitions, Transition{From: from, To: to})
+				}
+			}
+		}
+	}
+	return lifecycle
+}
+
+// slugs resolves the slug fields of a collection, keeping pairs whose
+// fields both exist.
+func (m *Manager) slugs(tableName, apiName string, fields []Field) map[string]string {
+	var configured map[string]string
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.Slugs) > 0 {
+			configured = cfg.Slugs
+			break
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	has := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		has[f.Name] = true
+	}
+	slugs := make(map[string]string, len(configured))
+	for field, source := range configured {
+		if has[field] && has[source] {
+			slugs[field] = source
+		}
+	}
+	return slugs
+}
+
+// embeddings resolves the embedded fields of a collection, keeping vector
+// fields whose source field exists.
+func (m *Manager) embeddings(tableName, apiName string, fields []Field) map[string]string {
+	var configured map[string]string
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.Embeddings) > 0 {
+			configured = cfg.Embeddings
+			break
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	types := make(map[string]string, len(fields))
+	for _, f := range fields {
+		types[f.Name] = f.DataType
+	}
+	embeddings := make(map[string]string, len(configured))
+	for field, source := range configured {
+		if types[field] != "vector" || types[source] == "" {
+			m.logger.Warnw("Skipping embedding that needs a vector field and a source field", "collection", apiName, "field", field, "source", source)
+			continue
+		}
+		embeddings[field] = source
+	}
+	return embeddings
+}
+
+// maxBodyBytes resolves the request body limit for a collection.
+func (m *Manager) maxBodyBytes(tableName, apiName string) int64 {
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && cfg.MaxBodyBytes != 0 {
+			return cfg.MaxBodyBytes
+		}
+	}
+	return m.config.MaxBodyBytes
+}
+
 // GetPublicFields returns the public fields for a collection.
 func (m *Manager) GetPublicFields(collectionName string) []string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
 	if cfg, ok := m.config.Config[collectionName]; ok {
 		return cfg.PublicFields
 	}
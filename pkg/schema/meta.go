@@ -0,0 +1,137 @@
+package schema
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Meta holds the human-facing annotations of a collection or of one of its
+// fields, for generated admin UIs and docs. Hidden only hides the collection
+// or field from those; the API still serves it.
+type Meta struct {
+	Collection   string `db:"collection" json:"-"`
+	Field        string `db:"field" json:"-"` // Empty for the collection itself
+	DisplayName  string `db:"display_name" json:"display_name,omitempty"`
+	Description  string `db:"description" json:"description,omitempty"`
+	Icon         string `db:"icon" json:"icon,omitempty"`
+	DisplayOrder *int   `db:"display_order" json:"display_order,omitempty"`
+	Hidden       bool   `db:"hidden" json:"hidden"`
+}
+
+// CollectionMeta is the metadata of a collection and of its fields by name.
+type CollectionMeta struct {
+	Meta
+	Fields map[string]Meta `json:"fields"`
+}
+
+// MetaStore persists collection and field metadata in tugo_collection_meta.
+type MetaStore struct {
+	db *sqlx.DB
+}
+
+// NewMetaStore creates a new metadata store.
+func NewMetaStore(db *sqlx.DB) *MetaStore {
+	return &MetaStore{db: db}
+}
+
+// List returns the metadata of every annotated collection by name.
+func (s *MetaStore) List(ctx context.Context) (map[string]*CollectionMeta, error) {
+	query := `
+		SELECT collection, field, display_name, description, icon, display_order, hidden
+		FROM tugo_collection_meta
+	`
+	var rows []Meta
+	if err := s.db.SelectContext(ctx, &rows, query); err != nil {
+		return nil, fmt.Errorf("failed to list collection metadata: %w", err)
+	}
+
+	metas := make(map[string]*CollectionMeta)
+	for _, row := range rows {
+		meta, ok := metas[row.Collection]
+		if !ok {
+			meta = &CollectionMeta{Meta: Meta{Collection: row.Collection}, Fields: make(map[string]Meta)}
+			metas[row.Collection] = meta
+		}
+		if row.Field == "" {
+			meta.Meta = row
+		} else {
+			meta.Fields[row.Field] = row
+		}
+	}
+	return metas, nil
+}
+
+// Get returns the metadata of a collection, empty when it has none.
+func (s *MetaStore) Get(ctx context.Context, collection string) (*CollectionMeta, error) {
+	query := `
+		SELECT collection, field, display_name, description, icon, display_order, hidden
+		FROM tugo_collection_meta
+		WHERE collection = ?
+	`
+	var rows []Meta
+	if err := s.db.SelectContext(ctx, &rows, s.db.Rebind(query), collection); err != nil {
+		return nil, fmt.Errorf("failed to get collection metadata: %w", err)
+	}
+
+	meta := &CollectionMeta{Meta: Meta{Collection: collection}, Fields: make(map[string]Meta)}
+	for _, row := range rows {
+		if row.Field == "" {
+			meta.Meta = row
+		} else {
+			meta.Fields[row.Field] = row
+		}
+	}
+	return meta, nil
+}
+
+// Set stores the metadata of a collection, or of a field when meta.Field is
+// set, replacing what was stored before.
+func (s *MetaStore) Set(ctx context.Context, meta *Meta) error {
+	query := `
+		INSERT INTO tugo_collection_meta (collection, field, display_name, description, icon, display_order, hidden, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON CONFLICT (collection, field)
+		DO UPDATE SET display_name = EXCLUDED.display_name, description = EXCLUDED.description, icon = EXCLUDED.icon,
+		              display_order = EXCLUDED.display_order, hidden = EXCLUDED.hidden, updated_at = EXCLUDED.updated_at
+	`
+	if s.db.DriverName() == "mysql" {
+		query = `
+		INSERT INTO tugo_collection_meta (collection, field, display_name, description, icon, display_order, hidden, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, CURRENT_TIMESTAMP)
+		ON DUPLICATE KEY UPDATE display_name = VALUES(display_name), description = VALUES(description), icon = VALUES(icon),
+		              display_order = VALUES(display_order), hidden = VALUES(hidden), updated_at = VALUES(updated_at)
+	`
+	}
+
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query),
+		meta.Collection, meta.Field, meta.DisplayName, meta.Description, meta.Icon, meta.DisplayOrder, meta.Hidden); err != nil {
+		return fmt.Errorf("failed to set collection metadata: %w", err)
+	}
+	return nil
+}
+
+// Delete removes the metadata of a field, or of a collection and all its
+// fields when field is empty.
+func (s *MetaStore) Delete(ctx context.Context, collection, field string) error {
+	query := "DELETE FROM tugo_collection_meta WHERE collection = ?"
+	args := []any{collection}
+	if field != "" {
+		query += " AND field = ?"
+		args = append(args, field)
+	}
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), args...); err != nil {
+		return fmt.Errorf("failed to delete collection metadata: %w", err)
+	}
+	return nil
+}
+
+// RenameField moves the metadata of a field to its new name.
+func (s *MetaStore) RenameField(ctx context.Context, collection, field, newName string) error {
+	query := "UPDATE tugo_collection_meta SET field = ? WHERE collection = ? AND field = ?"
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), newName, collection, field); err != nil {
+		return fmt.Errorf("failed to rename field metadata: %w", err)
+	}
+	return nil
+}
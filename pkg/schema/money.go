@@ -0,0 +1,56 @@
+package schema
+
+import "github.com/thienel/tugo/pkg/money"
+
+// Money marks a numeric field as an amount of money in one currency, or in
+// the currency held by a sibling field of each item. Decimal fields hold
+// major units, such as 12.50; integer fields hold minor units, such as 1250.
+type Money struct {
+	// Currency is the ISO 4217 code of every amount, such as "USD".
+	Currency string
+
+	// CurrencyField names the field holding each item's currency code.
+	CurrencyField string
+}
+
+// money resolves the money fields of a collection, keeping decimal and
+// integer fields with a known fixed currency or an existing currency field.
+func (m *Manager) money(tableName, apiName string, fields []Field) map[string]Money {
+	var configured map[string]Money
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.Money) > 0 {
+			configured = cfg.Money
+			break
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	types := make(map[string]string, len(fields))
+	for _, f := range fields {
+		types[f.Name] = f.DataType
+	}
+	result := make(map[string]Money, len(configured))
+	for field, cfg := range configured {
+		if dataType := types[field]; dataType != "decimal" && dataType != "int" {
+			m.logger.Warnw("Skipping money field that is not a decimal or integer field", "collection", apiName, "field", field)
+			continue
+		}
+		_, known := money.Digits(cfg.Currency)
+		switch {
+		case cfg.Currency != "" && cfg.CurrencyField != "":
+			m.logger.Warnw("Skipping money field with both a currency and a currency field", "collection", apiName, "field", field)
+		case cfg.Currency != "" && !known:
+			m.logger.Warnw("Skipping money field with an unknown currency", "collection", apiName, "field", field, "currency", cfg.Currency)
+		case cfg.Currency == "" && types[cfg.CurrencyField] == "":
+			m.logger.Warnw("Skipping money field without a currency field", "collection", apiName, "field", field, "currency_field", cfg.CurrencyField)
+		default:
+			result[field] = cfg
+		}
+	}
+	if len(result) == 0 {
+		return nil
+	}
+	return result
+}
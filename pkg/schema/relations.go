@@ -0,0 +1,174 @@
+package schema
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Relation types.
+const (
+	RelationManyToOne  = "many_to_one"
+	RelationOneToMany  = "one_to_many"
+	RelationManyToMany = "many_to_many"
+)
+
+// Relation describes a link between a collection and a related collection,
+// derived from the foreign keys of both and of any junction between them.
+type Relation struct {
+	Type              string `json:"type"`
+	RelatedCollection string `json:"related_collection"`
+
+	// Field is the column of this collection the relation goes through: the
+	// foreign key of a many-to-one relation, or the referenced column of the
+	// others. RelatedField is the matching column of the related collection.
+	Field        string `json:"field"`
+	RelatedField string `json:"related_field"`
+
+	// Expand is the name to expand a many-to-one relation by.
+	Expand string `json:"expand,omitempty"`
+
+	// JunctionTable links both sides of a many-to-many relation through its
+	// JunctionField, referencing this collection, and JunctionRelatedField,
+	// referencing the related one.
+	JunctionTable        string `json:"junction_table,omitempty"`
+	JunctionCollection   string `json:"junction_collection,omitempty"`
+	JunctionField        string `json:"junction_field,omitempty"`
+	JunctionRelatedField string `json:"junction_related_field,omitempty"`
+
+	OnDelete string `json:"on_delete,omitempty"`
+	OnUpdate string `json:"on_update,omitempty"`
+}
+
+// Relations returns the relations of a collection: many-to-one for its own
+// foreign keys, one-to-many for foreign keys of other collections referencing
+// it, and many-to-many through junction collections. A junction is a
+// collection with exactly two foreign keys and no fields besides them and
+// its primary key. Foreign keys to tables that are not collections are left
+// out.
+func (m *Manager) Relations(collectionName string) ([]Relation, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	collection, ok := m.collections[collectionName]
+	if !ok {
+		return nil, apperror.ErrCollectionNotFound.WithMessagef("Collection '%s' not found", collectionName)
+	}
+
+	byTable := make(map[string]*Collection, len(m.collections))
+	for _, c := range m.collections {
+		byTable[c.TableName] = c
+	}
+
+	relations := make([]Relation, 0)
+	for _, f := range collection.Fields {
+		if related := relatedCollection(byTable, f); related != nil {
+			relations = append(relations, Relation{
+				Type:              RelationManyToOne,
+				RelatedCollection: related.Name,
+				Field:             f.Name,
+				RelatedField:      f.ForeignKey.Column,
+				Expand:            strings.TrimSuffix(f.Name, "_id"),
+				OnDelete:          f.ForeignKey.OnDelete,
+				OnUpdate:          f.ForeignKey.OnUpdate,
+			})
+		}
+	}
+
+	for _, other := range m.collections {
+		keys := foreignKeys(byTable, other)
+		for _, f := range keys {
+			if f.ForeignKey.Table != collection.TableName {
+				continue
+			}
+			relations = append(relations, Relation{
+				Type:              RelationOneToMany,
+				RelatedCollection: other.Name,
+				Field:             f.ForeignKey.Column,
+				RelatedField:      f.Name,
+				OnDelete:          f.ForeignKey.OnDelete,
+				OnUpdate:          f.ForeignKey.OnUpdate,
+			})
+		}
+
+		if !isJunction(other, keys) {
+			continue
+		}
+		for i, f := range keys {
+			far := keys[1-i]
+			if f.ForeignKey.Table != collection.TableName {
+				continue
+			}
+			relations = append(relations, Relation{
+				Type:                 RelationManyToMany,
+				RelatedCollection:    byTable[far.ForeignKey.Table].Name,
+				Field:                f.ForeignKey.Column,
+				RelatedField:         far.ForeignKey.Column,
+				JunctionTable:        other.TableName,
+				JunctionCollection:   other.Name,
+				JunctionField:        f.Name,
+				JunctionRelatedField: far.Name,
+			})
+		}
+	}
+
+	sort.SliceStable(relations, func(i, j int) bool {
+		a, b := relations[i], relations[j]
+		if a.Type != b.Type {
+			return relationOrder[a.Type] < relationOrder[b.Type]
+		}
+		if a.RelatedCollection != b.RelatedCollection {
+			return a.RelatedCollection < b.RelatedCollection
+		}
+		if a.JunctionTable != b.JunctionTable {
+			return a.JunctionTable < b.JunctionTable
+		}
+		if a.Field != b.Field {
+			return a.Field < b.Field
+		}
+		return a.RelatedField < b.RelatedField
+	})
+	return relations, nil
+}
+
+// relationOrder lists relation types in the order Relations returns them.
+var relationOrder = map[string]int{
+	RelationManyToOne:  0,
+	RelationOneToMany:  1,
+	RelationManyToMany: 2,
+}
+
+// relatedCollection returns the collection a field references, or nil when
+// it is not a foreign key to a collection.
+func relatedCollection(byTable map[string]*Collection, f Field) *Collection {
+	if f.ForeignKey == nil {
+		return nil
+	}
+	return byTable[f.ForeignKey.Table]
+}
+
+// foreignKeys returns the fields of a collection referencing collections.
+func foreignKeys(byTable map[string]*Collection, c *Collection) []Field {
+	var keys []Field
+	for _, f := range c.Fields {
+		if relatedCollection(byTable, f) != nil {
+			keys = append(keys, f)
+		}
+	}
+	return keys
+}
+
+// isJunction reports whether a collection only links two collections
+// through keys, its foreign keys to collections.
+func isJunction(c *Collection, keys []Field) bool {
+	if len(keys) != 2 {
+		return false
+	}
+	for _, f := range c.Fields {
+		if f.ForeignKey == nil && !f.IsPrimaryKey && f.Name != c.PrimaryKey {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,57 @@
+package schema
+
+import "context"
+
+// TrigramExtension is the PostgreSQL extension providing similarity
+// search.
+const TrigramExtension = "pg_trgm"
+
+// TextSearch describes the text search support of a collection's table.
+type TextSearch struct {
+	// Trigram reports whether the pg_trgm extension is installed.
+	Trigram bool
+
+	// TrigramFields lists the fields with a trigram index.
+	TrigramFields []string
+}
+
+// TextSearchIntrospector is implemented by introspectors that can report
+// the extensions and text search indexes of the database.
+type TextSearchIntrospector interface {
+	GetExtensions(ctx context.Context) ([]string, error)
+	GetTrigramColumns(ctx context.Context, tableName string) ([]string, error)
+}
+
+// extensions returns the installed database extensions, or nil when the
+// database has none or they cannot be listed.
+func (m *Manager) extensions(ctx context.Context) map[string]bool {
+	introspector, ok := m.introspector.(TextSearchIntrospector)
+	if !ok {
+		return nil
+	}
+	names, err := introspector.GetExtensions(ctx)
+	if err != nil {
+		m.logger.Warnw("Failed to list database extensions", "error", err)
+		return nil
+	}
+	extensions := make(map[string]bool, len(names))
+	for _, name := range names {
+		extensions[name] = true
+	}
+	return extensions
+}
+
+// textSearch detects the text search support of a table.
+func (m *Manager) textSearch(ctx context.Context, tableName, apiName string, extensions map[string]bool) TextSearch {
+	if !extensions[TrigramExtension] {
+		return TextSearch{}
+	}
+	search := TextSearch{Trigram: true}
+	columns, err := m.introspector.(TextSearchIntrospector).GetTrigramColumns(ctx, tableName)
+	if err != nil {
+		m.logger.Warnw("Failed to list trigram indexes", "collection", apiName, "error", err)
+		return search
+	}
+	search.TrigramFields = columns
+	return search
+}
@@ -0,0 +1,99 @@
+package schema
+
+import (
+	"context"
+	"strings"
+)
+
+// TranslationsSuffix ends the name of the table holding the translations of
+// a collection's table, as in api_posts_translations.
+const TranslationsSuffix = "_translations"
+
+// Columns of a translations table besides the translated fields.
+const (
+	TranslationItemColumn   = "item_id"
+	TranslationLocaleColumn = "locale"
+)
+
+// Translations describes the fields of a collection whose values are also
+// stored per locale in a sidecar table.
+type Translations struct {
+	Table  string
+	Fields []string
+}
+
+// Has reports whether a field is translated.
+func (t *Translations) Has(field string) bool {
+	if t == nil {
+		return false
+	}
+	for _, f := range t.Fields {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// translations resolves the translated fields of a collection, keeping those
+// that are columns of both the collection and its translations table. It
+// returns nil when none are configured or the table cannot be used.
+func (m *Manager) translations(ctx context.Context, tableName, apiName string, fields []Field) *Translations {
+	var configured []string
+	for _, key := range []string{apiName, tableName} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.Translations) > 0 {
+			configured = cfg.Translations
+			break
+		}
+	}
+	if len(configured) == 0 {
+		return nil
+	}
+
+	table := tableName + TranslationsSuffix
+	columns, err := m.introspector.GetColumns(ctx, table)
+	if err != nil || len(columns) == 0 {
+		m.logger.Warnw("Translations table not found; translations disabled", "collection", apiName, "table", table, "error", err)
+		return nil
+	}
+	has := make(map[string]bool, len(columns))
+	for _, c := range columns {
+		has[c.ColumnName] = true
+	}
+	if !has[TranslationItemColumn] || !has[TranslationLocaleColumn] {
+		m.logger.Warnw("Translations table needs item_id and locale columns; translations disabled", "collection", apiName, "table", table)
+		return nil
+	}
+
+	own := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		own[f.Name] = true
+	}
+	translations := &Translations{Table: table}
+	for _, field := range configured {
+		if !own[field] || !has[field] {
+			m.logger.Warnw("Skipping translated field missing from the collection or its translations table", "collection", apiName, "field", field)
+			continue
+		}
+		translations.Fields = append(translations.Fields, field)
+	}
+	if len(translations.Fields) == 0 {
+		return nil
+	}
+	return translations
+}
+
+// isTranslationsTable reports whether a table holds the translations of a
+// configured collection, so it is not served as a collection of its own.
+func (m *Manager) isTranslationsTable(tableName string) bool {
+	base, ok := strings.CutSuffix(tableName, TranslationsSuffix)
+	if !ok {
+		return false
+	}
+	for _, key := range []string{m.tableToAPIName(base), base} {
+		if cfg, ok := m.config.Config[key]; ok && len(cfg.Translations) > 0 {
+			return true
+		}
+	}
+	return false
+}
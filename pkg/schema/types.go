@@ -1,6 +1,10 @@
 package schema
 
-import "time"
+import (
+	"time"
+
+	"github.com/thienel/tugo/pkg/idgen"
+)
 
 // Collection represents a discovered database table/collection.
 type Collection struct {
@@ -12,6 +16,244 @@ type Collection struct {
 	PrimaryKey string    `json:"primary_key,omitempty"`
 	CreatedAt  time.Time `db:"created_at" json:"created_at"`
 	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
+
+	// StatementTimeout bounds each query against the collection; zero means none.
+	StatementTimeout time.Duration `json:"-"`
+
+	// RequestTimeout bounds each request to the collection; zero means none.
+	RequestTimeout time.Duration `json:"-"`
+
+	// DefaultLimit and MaxLimit bound list page sizes; zero uses the built-in defaults.
+	DefaultLimit int `json:"-"`
+	MaxLimit     int `json:"-"`
+
+	// History enables storing previous record versions in tugo_revisions.
+	History bool `json:"history,omitempty"`
+
+	// Comments enables threaded comments on items in tugo_comments.
+	Comments bool `json:"comments,omitempty"`
+
+	// Favorites lets users star items, stored in tugo_favorites.
+	Favorites bool `json:"favorites,omitempty"`
+
+	// MaxOffset, MaxExpand and SearchFields limit expensive list queries; zero values disable them.
+	MaxOffset    int      `json:"-"`
+	MaxExpand    int      `json:"-"`
+	SearchFields []string `json:"-"`
+
+	// DefaultFilter maps "field" or "field:op" to a value filtering lists
+	// that do not filter the field themselves, and DefaultSort orders
+	// lists that do not sort.
+	DefaultFilter map[string]string `json:"default_filter,omitempty"`
+	DefaultSort   string            `json:"default_sort,omitempty"`
+
+	// Aliases maps columns to the names the API uses for them in items,
+	// filters and sorts.
+	Aliases map[string]string `json:"aliases,omitempty"`
+
+	// MaxBodyBytes caps request bodies for the collection; zero uses the default, negative means none.
+	MaxBodyBytes int64 `json:"-"`
+
+	// AutoFields names the columns the server fills in on write, limited to
+	// columns the table has.
+	AutoFields AutoFields `json:"-"`
+
+	// ImmutableFields lists fields that cannot change once an item is created.
+	ImmutableFields []string `json:"immutable_fields,omitempty"`
+
+	// Slugs maps slug fields to the fields they are generated from on create.
+	Slugs map[string]string `json:"-"`
+
+	// StrictParams rejects list requests with unknown query parameters.
+	StrictParams bool `json:"-"`
+
+	// Translations holds the fields translated per locale, or nil.
+	Translations *Translations `json:"-"`
+
+	// Money maps money fields to their currency.
+	Money map[string]Money `json:"-"`
+
+	// DuplicateMatch lists the rules used to find duplicates of an item.
+	DuplicateMatch []DuplicateMatch `json:"-"`
+
+	// Embeddings maps vector fields to the text fields they embed.
+	Embeddings map[string]string `json:"-"`
+
+	// UniqueConstraints lists the unique constraints spanning several
+	// columns. Single-column constraints mark the field IsUnique instead.
+	UniqueConstraints []UniqueConstraint `json:"unique_constraints,omitempty"`
+
+	// TextSearch describes the trigram search support of the table.
+	TextSearch TextSearch `json:"-"`
+
+	// IDGeneration describes how the primary key is generated on create,
+	// or nil when the database or the client provides it.
+	IDGeneration *idgen.Properties `json:"id_generation,omitempty"`
+
+	// PublicActions lists the actions allowed without authentication.
+	PublicActions []string `json:"-"`
+
+	// Approval holds creates and updates for review, or nil.
+	Approval *Approval `json:"approval,omitempty"`
+
+	// Lifecycle holds the status lifecycle of the items, or nil.
+	Lifecycle *Lifecycle `json:"lifecycle,omitempty"`
+}
+
+// UniqueConstraint is a unique constraint over a combination of columns.
+type UniqueConstraint struct {
+	Name   string   `json:"name"`
+	Fields []string `json:"fields"`
+}
+
+// Approval configures the approval workflow of a collection. Reviewed
+// writes are stored as pending changes until a reviewer approves them.
+type Approval struct {
+	// Actions lists the reviewed actions, "create" and "update". Empty
+	// reviews both.
+	Actions []string `json:"actions"`
+
+	// BypassRoles lists the roles whose writes apply at once. Admins
+	// always bypass review.
+	BypassRoles []string `json:"bypass_roles,omitempty"`
+}
+
+// Reviews reports whether an action of role needs approval.
+func (a *Approval) Reviews(action, role string) bool {
+	if a == nil || role == "admin" {
+		return false
+	}
+	reviewed := len(a.Actions) == 0
+	for _, act := range a.Actions {
+		if act == action {
+			reviewed = true
+		}
+	}
+	for _, r := range a.BypassRoles {
+		if r == role {
+			return false
+		}
+	}
+	return reviewed
+}
+
+// Lifecycle configures the status lifecycle of a content collection, such
+// as draft → review → published. The status field changes only through
+// transitions, and public readers see published items only.
+type Lifecycle struct {
+	// Field is the status column.
+	Field string `json:"field"`
+
+	// States lists the states. New items start in the first.
+	States []string `json:"states"`
+
+	// Published is the state shown to public readers.
+	Published string `json:"published"`
+
+	// Transitions lists the allowed changes of state.
+	Transitions []Transition `json:"transitions"`
+
+	// PublishAtField is a timestamp column holding when an item was or
+	// will be published, or "". Items scheduled for a later time are
+	// published when it passes.
+	PublishAtField string `json:"publish_at_field,omitempty"`
+}
+
+// Transition is an allowed change of lifecycle state.
+type Transition struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+
+	// Roles lists the roles that may make the transition. Empty allows
+	// every role; admins may always.
+	Roles []string `json:"roles,omitempty"`
+}
+
+// Initial returns the state of new items.
+func (l *Lifecycle) Initial() string {
+	if len(l.States) == 0 {
+		return ""
+	}
+	return l.States[0]
+}
+
+// HasState reports whether state is one of the lifecycle's states.
+func (l *Lifecycle) HasState(state string) bool {
+	for _, s := range l.States {
+		if s == state {
+			return true
+		}
+	}
+	return false
+}
+
+// Transition returns the transition from one state to another, or nil
+// when it is not allowed.
+func (l *Lifecycle) Transition(from, to string) *Transition {
+	for i := range l.Transitions {
+		if l.Transitions[i].From == from && l.Transitions[i].To == to {
+			return &l.Transitions[i]
+		}
+	}
+	return nil
+}
+
+// Permits reports whether role may make the transition.
+func (t *Transition) Permits(role string) bool {
+	if len(t.Roles) == 0 || role == "admin" {
+		return true
+	}
+	for _, r := range t.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// AutoFields names the columns filled in on write from the server clock and
+// the authenticated user. Empty names are not filled.
+type AutoFields struct {
+	// CreatedAt and CreatedBy are set on create and cannot be changed later.
+	CreatedAt string
+	CreatedBy string
+
+	// UpdatedAt and UpdatedBy are set on create and update.
+	UpdatedAt string
+	UpdatedBy string
+}
+
+// DefaultAutoFields is the conventional set of auto-filled columns.
+var DefaultAutoFields = AutoFields{
+	CreatedAt: "created_at",
+	CreatedBy: "created_by",
+	UpdatedAt: "updated_at",
+	UpdatedBy: "updated_by",
+}
+
+// IsZero reports whether no column is filled in.
+func (a AutoFields) IsZero() bool {
+	return a == AutoFields{}
+}
+
+// existing keeps the names of columns present in fields.
+func (a AutoFields) existing(fields []Field) AutoFields {
+	has := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		has[f.Name] = true
+	}
+	keep := func(name string) string {
+		if has[name] {
+			return name
+		}
+		return ""
+	}
+	return AutoFields{
+		CreatedAt: keep(a.CreatedAt),
+		CreatedBy: keep(a.CreatedBy),
+		UpdatedAt: keep(a.UpdatedAt),
+		UpdatedBy: keep(a.UpdatedBy),
+	}
 }
 
 // Field represents a column in a table.
@@ -85,8 +327,9 @@ type PostgresPrimaryKeyInfo struct {
 
 // PostgresUniqueInfo represents unique constraint info.
 type PostgresUniqueInfo struct {
-	TableName  string `db:"table_name"`
-	ColumnName string `db:"column_name"`
+	ConstraintName string `db:"constraint_name"`
+	TableName      string `db:"table_name"`
+	ColumnName     string `db:"column_name"`
 }
 
 // DataTypeMap maps PostgreSQL types to abstract types.
@@ -124,6 +367,26 @@ var DataTypeMap = map[string]string{
 	"jsonb":                       "json",
 	"bytea":                       "binary",
 	"interval":                    "interval",
+	"vector":                      "vector", // pgvector
+
+	// MySQL/MariaDB types
+	"tinyint":    "int",
+	"mediumint":  "int",
+	"int":        "int",
+	"float":      "float",
+	"double":     "float",
+	"tinytext":   "string",
+	"mediumtext": "string",
+	"longtext":   "string",
+	"enum":       "string",
+	"datetime":   "timestamp",
+	"year":       "int",
+	"binary":     "binary",
+	"varbinary":  "binary",
+	"blob":       "binary",
+	"tinyblob":   "binary",
+	"mediumblob": "binary",
+	"longblob":   "binary",
 }
 
 // MapPostgresType converts a PostgreSQL type to an abstract type.
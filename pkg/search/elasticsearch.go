@@ -0,0 +1,186 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ElasticsearchConfig holds configuration for an Elasticsearch engine.
+type ElasticsearchConfig struct {
+	// URL is the address of the cluster, such as "http://localhost:9200".
+	URL string
+
+	// APIKey authenticates with an encoded API key. Username and Password
+	// are used instead when it is empty.
+	APIKey string
+
+	// Username and Password authenticate with basic auth.
+	Username string
+	Password string
+
+	// Client is the HTTP client used for requests.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+// Elasticsearch mirrors collections into Elasticsearch indexes, also
+// usable with OpenSearch. Writes are refreshed before they return, so they
+// show up in the next search.
+type Elasticsearch struct {
+	client client
+}
+
+// NewElasticsearch creates a new Elasticsearch engine.
+func NewElasticsearch(cfg ElasticsearchConfig) (*Elasticsearch, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("Elasticsearch URL is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	c := client{service: "Elasticsearch", baseURL: strings.TrimSuffix(cfg.URL, "/"), http: cfg.Client}
+	switch {
+	case cfg.APIKey != "":
+		c.auth = func(req *http.Request) {
+			req.Header.Set("Authorization", "ApiKey "+cfg.APIKey)
+		}
+	case cfg.Username != "":
+		c.auth = func(req *http.Request) {
+			req.SetBasicAuth(cfg.Username, cfg.Password)
+		}
+	}
+	return &Elasticsearch{client: c}, nil
+}
+
+// elasticsearchTypes maps abstract data types to Elasticsearch field types.
+// Other fields are mapped dynamically.
+var elasticsearchTypes = map[string]string{
+	"string":    "text",
+	"uuid":      "keyword",
+	"int":       "long",
+	"float":     "double",
+	"decimal":   "double",
+	"boolean":   "boolean",
+	"timestamp": "date",
+	"date":      "date",
+}
+
+// properties returns the field mappings of an index.
+func properties(m Mapping) map[string]any {
+	props := make(map[string]any, len(m.Fields))
+	for name, dataType := range m.Fields {
+		if t, ok := elasticsearchTypes[dataType]; ok {
+			props[name] = map[string]any{"type": t}
+		}
+	}
+	return props
+}
+
+// EnsureIndex creates the index with mappings of the collection's fields,
+// or adds the mappings of new fields to an existing index. Fields whose
+// type changed keep their mapping until the index is recreated.
+func (e *Elasticsearch) EnsureIndex(ctx context.Context, m Mapping) error {
+	path := "/" + url.PathEscape(m.Index)
+	err := e.client.do(ctx, http.MethodPut, path, map[string]any{
+		"mappings": map[string]any{"properties": properties(m)},
+	}, nil)
+	var status *statusError
+	if !errors.As(err, &status) || status.status != http.StatusBadRequest ||
+		!bytes.Contains(status.detail, []byte("resource_already_exists_exception")) {
+		return err
+	}
+
+	err = e.client.do(ctx, http.MethodPut, path+"/_mapping", map[string]any{"properties": properties(m)}, nil)
+	if errors.As(err, &status) && status.status == http.StatusBadRequest {
+		return nil
+	}
+	return err
+}
+
+// bulk sends bulk actions and fails when any of them failed.
+func (e *Elasticsearch) bulk(ctx context.Context, body []byte) error {
+	var result struct {
+		Errors bool `json:"errors"`
+		Items  []map[string]struct {
+			ID    string          `json:"_id"`
+			Error json.RawMessage `json:"error"`
+		} `json:"items"`
+	}
+	if err := e.client.do(ctx, http.MethodPost, "/_bulk?refresh=wait_for", ndjson(body), &result); err != nil {
+		return err
+	}
+	if !result.Errors {
+		return nil
+	}
+	for _, item := range result.Items {
+		for action, r := range item {
+			if len(r.Error) > 0 {
+				return fmt.Errorf("Elasticsearch failed to %s document %s: %s", action, r.ID, r.Error)
+			}
+		}
+	}
+	return fmt.Errorf("Elasticsearch bulk request failed")
+}
+
+// Upsert adds or replaces documents.
+func (e *Elasticsearch) Upsert(ctx context.Context, m Mapping, docs []map[string]any) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, doc := range docs {
+		action := map[string]any{"index": map[string]any{"_index": m.Index, "_id": fmt.Sprint(doc[m.PrimaryKey])}}
+		if err := encoder.Encode(action); err != nil {
+			return err
+		}
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode document: %w", err)
+		}
+	}
+	return e.bulk(ctx, buf.Bytes())
+}
+
+// Delete removes documents by ID. Missing documents are not an error.
+func (e *Elasticsearch) Delete(ctx context.Context, m Mapping, ids []string) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, id := range ids {
+		if err := encoder.Encode(map[string]any{"delete": map[string]any{"_index": m.Index, "_id": id}}); err != nil {
+			return err
+		}
+	}
+	return e.bulk(ctx, buf.Bytes())
+}
+
+// Search returns the IDs of the documents matching term, best first.
+func (e *Elasticsearch) Search(ctx context.Context, m Mapping, term string, limit int) ([]string, error) {
+	match := map[string]any{"query": term}
+	if len(m.Searchable) > 0 {
+		match["fields"] = m.Searchable
+	}
+	var result struct {
+		Hits struct {
+			Hits []struct {
+				ID string `json:"_id"`
+			} `json:"hits"`
+		} `json:"hits"`
+	}
+	err := e.client.do(ctx, http.MethodPost, "/"+url.PathEscape(m.Index)+"/_search", map[string]any{
+		"query":   map[string]any{"multi_match": match},
+		"size":    limit,
+		"_source": false,
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, len(result.Hits.Hits))
+	for i, hit := range result.Hits.Hits {
+		ids[i] = hit.ID
+	}
+	return ids, nil
+}
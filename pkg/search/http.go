@@ -0,0 +1,84 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// client sends JSON requests to a search engine's HTTP API.
+type client struct {
+	service string
+	baseURL string
+	http    *http.Client
+	auth    func(req *http.Request)
+}
+
+// statusError is a non-2xx response of the engine.
+type statusError struct {
+	service string
+	status  int
+	detail  []byte
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("%s returned status %d: %s", e.service, e.status, bytes.TrimSpace(e.detail))
+}
+
+// do sends a request with body, encoded as JSON unless it is already raw
+// bytes, and decodes the response into out when it is not nil. Non-2xx
+// responses are returned as a *statusError.
+func (c *client) do(ctx context.Context, method, path string, body any, out any) error {
+	var reader io.Reader
+	contentType := "application/json"
+	switch b := body.(type) {
+	case nil:
+	case ndjson:
+		reader = bytes.NewReader(b)
+		contentType = "application/x-ndjson"
+	default:
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("failed to encode %s request: %w", c.service, err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if reader != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
+	if c.auth != nil {
+		c.auth(req)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to reach %s: %w", c.service, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		detail, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return &statusError{service: c.service, status: resp.StatusCode, detail: detail}
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	decoder := json.NewDecoder(resp.Body)
+	decoder.UseNumber()
+	if err := decoder.Decode(out); err != nil {
+		return fmt.Errorf("failed to decode %s response: %w", c.service, err)
+	}
+	return nil
+}
+
+// ndjson is a request body of newline-delimited JSON, sent as is.
+type ndjson []byte
@@ -0,0 +1,141 @@
+package search
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/thienel/tugo/pkg/events"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Indexer keeps the engine's indexes in step with the collections. It is an
+// events.Publisher, so writes reach the engine through the change feed's
+// relay, which retries them while the engine is down, and it serves
+// ?search= queries as a collection.Searcher.
+type Indexer struct {
+	engine        Engine
+	indexes       []Index
+	maxHits       int
+	schemaManager *schema.Manager
+	logger        *zap.SugaredLogger
+}
+
+// NewIndexer creates an indexer for config, looking collections up in
+// schemaManager.
+func NewIndexer(config Config, schemaManager *schema.Manager, logger *zap.SugaredLogger) (*Indexer, error) {
+	if config.Engine == nil {
+		return nil, fmt.Errorf("search engine is required")
+	}
+	for _, index := range config.Indexes {
+		if index.Collection == "" {
+			return nil, fmt.Errorf("search index collection is required")
+		}
+	}
+	if config.MaxHits <= 0 {
+		config.MaxHits = DefaultMaxHits
+	}
+	return &Indexer{
+		engine:        config.Engine,
+		indexes:       config.Indexes,
+		maxHits:       config.MaxHits,
+		schemaManager: schemaManager,
+		logger:        logger,
+	}, nil
+}
+
+// Name names the indexer's relay cursor.
+func (x *Indexer) Name() string {
+	return "search"
+}
+
+// Collections returns the names of the indexed collections.
+func (x *Indexer) Collections() []string {
+	return collections(x.indexes)
+}
+
+// index returns the index mirroring collection.
+func (x *Indexer) index(collection *schema.Collection) (Index, bool) {
+	for _, index := range x.indexes {
+		if index.matches(collection) {
+			return index, true
+		}
+	}
+	return Index{}, false
+}
+
+// Sync creates the indexes of collections or updates their mappings, such
+// as after a schema change.
+func (x *Indexer) Sync(ctx context.Context, collections []*schema.Collection) error {
+	var errs []error
+	for _, collection := range collections {
+		index, ok := x.index(collection)
+		if !ok {
+			continue
+		}
+		if err := x.engine.EnsureIndex(ctx, mappingFor(collection, index)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to sync index of %s: %w", collection.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Publish applies a collection write to the engine.
+func (x *Indexer) Publish(ctx context.Context, event events.Event) error {
+	collection, err := x.schemaManager.GetCollection(event.Collection)
+	if err != nil {
+		return nil
+	}
+	index, ok := x.index(collection)
+	if !ok {
+		return nil
+	}
+	m := mappingFor(collection, index)
+
+	if event.Action == "delete" {
+		return x.engine.Delete(ctx, m, []string{event.ItemID})
+	}
+
+	var record map[string]any
+	decoder := json.NewDecoder(bytes.NewReader(event.Data))
+	decoder.UseNumber()
+	if err := decoder.Decode(&record); err != nil {
+		x.logger.Errorw("Skipping undecodable event", "collection", event.Collection, "id", event.ID, "error", err)
+		return nil
+	}
+	return x.engine.Upsert(ctx, m, []map[string]any{document(m, record)})
+}
+
+// Reindex adds items of collection to its index, such as to fill a new
+// index with existing items.
+func (x *Indexer) Reindex(ctx context.Context, collection *schema.Collection, items []map[string]any) error {
+	index, ok := x.index(collection)
+	if !ok || len(items) == 0 {
+		return nil
+	}
+	m := mappingFor(collection, index)
+	docs := make([]map[string]any, len(items))
+	for i, item := range items {
+		docs[i] = document(m, item)
+	}
+	return x.engine.Upsert(ctx, m, docs)
+}
+
+// Searches reports whether collection's ?search= queries are served by the
+// engine.
+func (x *Indexer) Searches(collection *schema.Collection) bool {
+	index, ok := x.index(collection)
+	return ok && index.Serve
+}
+
+// Search returns the IDs of collection's items matching term, best first.
+func (x *Indexer) Search(ctx context.Context, collection *schema.Collection, term string) ([]string, error) {
+	index, ok := x.index(collection)
+	if !ok {
+		return nil, fmt.Errorf("collection %s is not indexed", collection.Name)
+	}
+	return x.engine.Search(ctx, mappingFor(collection, index), term, x.maxHits)
+}
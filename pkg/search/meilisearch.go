@@ -0,0 +1,101 @@
+package search
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// MeilisearchConfig holds configuration for a Meilisearch engine.
+type MeilisearchConfig struct {
+	// URL is the address of the Meilisearch server, such as
+	// "http://localhost:7700".
+	URL string
+
+	// APIKey is a key allowed to manage indexes, documents and search.
+	APIKey string
+
+	// Client is the HTTP client used for requests.
+	// Default: http.DefaultClient
+	Client *http.Client
+}
+
+// Meilisearch mirrors collections into Meilisearch indexes. Meilisearch
+// applies writes asynchronously, so they show up in searches shortly after
+// they are accepted.
+type Meilisearch struct {
+	client client
+}
+
+// NewMeilisearch creates a new Meilisearch engine.
+func NewMeilisearch(cfg MeilisearchConfig) (*Meilisearch, error) {
+	if cfg.URL == "" {
+		return nil, fmt.Errorf("Meilisearch URL is required")
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	c := client{service: "Meilisearch", baseURL: strings.TrimSuffix(cfg.URL, "/"), http: cfg.Client}
+	if cfg.APIKey != "" {
+		c.auth = func(req *http.Request) {
+			req.Header.Set("Authorization", "Bearer "+cfg.APIKey)
+		}
+	}
+	return &Meilisearch{client: c}, nil
+}
+
+// EnsureIndex creates the index, which Meilisearch ignores when it exists,
+// and sets its searchable fields.
+func (e *Meilisearch) EnsureIndex(ctx context.Context, m Mapping) error {
+	err := e.client.do(ctx, http.MethodPost, "/indexes", map[string]any{
+		"uid":        m.Index,
+		"primaryKey": m.PrimaryKey,
+	}, nil)
+	if err != nil {
+		return err
+	}
+
+	searchable := m.Searchable
+	if len(searchable) == 0 {
+		searchable = []string{"*"}
+	}
+	return e.client.do(ctx, http.MethodPatch, "/indexes/"+url.PathEscape(m.Index)+"/settings", map[string]any{
+		"searchableAttributes": searchable,
+	}, nil)
+}
+
+// Upsert adds or replaces documents.
+func (e *Meilisearch) Upsert(ctx context.Context, m Mapping, docs []map[string]any) error {
+	path := "/indexes/" + url.PathEscape(m.Index) + "/documents?primaryKey=" + url.QueryEscape(m.PrimaryKey)
+	return e.client.do(ctx, http.MethodPost, path, docs, nil)
+}
+
+// Delete removes documents by ID.
+func (e *Meilisearch) Delete(ctx context.Context, m Mapping, ids []string) error {
+	return e.client.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(m.Index)+"/documents/delete-batch", ids, nil)
+}
+
+// Search returns the IDs of the documents matching term, best first.
+func (e *Meilisearch) Search(ctx context.Context, m Mapping, term string, limit int) ([]string, error) {
+	var result struct {
+		Hits []map[string]any `json:"hits"`
+	}
+	err := e.client.do(ctx, http.MethodPost, "/indexes/"+url.PathEscape(m.Index)+"/search", map[string]any{
+		"q":                    term,
+		"limit":                limit,
+		"attributesToRetrieve": []string{m.PrimaryKey},
+	}, &result)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(result.Hits))
+	for _, hit := range result.Hits {
+		if id, ok := hit[m.PrimaryKey]; ok && id != nil {
+			ids = append(ids, fmt.Sprint(id))
+		}
+	}
+	return ids, nil
+}
@@ -0,0 +1,142 @@
+// Package search mirrors collections into an external search engine such
+// as Meilisearch or Elasticsearch and serves relevance-ranked ?search=
+// queries from it.
+package search
+
+import (
+	"context"
+	"slices"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+// DefaultMaxHits caps the matches a search takes from the engine when none
+// is configured.
+const DefaultMaxHits = 1000
+
+// Config configures search-engine sync.
+type Config struct {
+	// Engine receives the documents of the indexed collections, such as a
+	// Meilisearch or Elasticsearch engine. Nil disables search sync.
+	Engine Engine
+
+	// Indexes lists the collections mirrored into the engine.
+	Indexes []Index
+
+	// MaxHits caps the matches of a ?search= query served by the engine;
+	// pages beyond them are empty. Default: DefaultMaxHits
+	MaxHits int
+}
+
+// Index mirrors a collection into an index of the engine.
+type Index struct {
+	// Collection is the API or table name of the collection.
+	Collection string
+
+	// Name names the index. Default: the collection's API name
+	Name string
+
+	// Searchable lists the fields matched by queries. Default: the
+	// collection's SearchFields, else all its string fields
+	Searchable []string
+
+	// Serve answers the collection's ?search= queries from the engine,
+	// ranking items by relevance unless a sort is given.
+	Serve bool
+}
+
+// Mapping describes an index as it is created in the engine.
+type Mapping struct {
+	// Index is the index name.
+	Index string
+
+	// PrimaryKey is the document ID field.
+	PrimaryKey string
+
+	// Searchable lists the fields matched by queries.
+	Searchable []string
+
+	// Fields maps the indexed fields to their abstract data types.
+	Fields map[string]string
+}
+
+// Engine is an external search engine.
+type Engine interface {
+	// EnsureIndex creates the index or updates its settings and field
+	// mappings to match the collection.
+	EnsureIndex(ctx context.Context, m Mapping) error
+
+	// Upsert adds or replaces documents, keyed by their primary key.
+	Upsert(ctx context.Context, m Mapping, docs []map[string]any) error
+
+	// Delete removes documents by ID.
+	Delete(ctx context.Context, m Mapping, ids []string) error
+
+	// Search returns the IDs of the documents matching term, best first.
+	Search(ctx context.Context, m Mapping, term string, limit int) ([]string, error)
+}
+
+// mappingFor builds the mapping of a collection's index. Binary and vector
+// fields are not indexed.
+func mappingFor(collection *schema.Collection, index Index) Mapping {
+	m := Mapping{
+		Index:      index.Name,
+		PrimaryKey: collection.PrimaryKey,
+		Fields:     make(map[string]string, len(collection.Fields)),
+	}
+	if m.Index == "" {
+		m.Index = collection.Name
+	}
+
+	var strings []string
+	for _, f := range collection.Fields {
+		switch f.DataType {
+		case "binary", "vector":
+			continue
+		case "string":
+			strings = append(strings, f.Name)
+		}
+		m.Fields[f.Name] = f.DataType
+	}
+
+	searchable := index.Searchable
+	if len(searchable) == 0 {
+		searchable = collection.SearchFields
+	}
+	if len(searchable) == 0 {
+		searchable = strings
+	}
+	for _, name := range searchable {
+		if _, ok := m.Fields[name]; ok {
+			m.Searchable = append(m.Searchable, name)
+		}
+	}
+	return m
+}
+
+// document keeps the indexed fields of a record.
+func document(m Mapping, record map[string]any) map[string]any {
+	doc := make(map[string]any, len(m.Fields))
+	for name, value := range record {
+		if _, ok := m.Fields[name]; ok {
+			doc[name] = value
+		}
+	}
+	return doc
+}
+
+// matches reports whether index mirrors collection.
+func (index Index) matches(collection *schema.Collection) bool {
+	return index.Collection == collection.Name || index.Collection == collection.TableName
+}
+
+// collections returns the collection names of indexes.
+func collections(indexes []Index) []string {
+	names := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		if !slices.Contains(names, index.Collection) {
+			names = append(names, index.Collection)
+		}
+	}
+	return names
+}
@@ -0,0 +1,187 @@
+package search
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/schema"
+)
+
+var articles = &schema.Collection{
+	Name:       "articles",
+	TableName:  "api_articles",
+	PrimaryKey: "id",
+	Fields: []schema.Field{
+		{Name: "id", DataType: "int"},
+		{Name: "title", DataType: "string"},
+		{Name: "body", DataType: "string"},
+		{Name: "cover", DataType: "binary"},
+		{Name: "embedding", DataType: "vector"},
+	},
+}
+
+func TestMappingFor(t *testing.T) {
+	tests := []struct {
+		name           string
+		collection     *schema.Collection
+		index          Index
+		wantIndex      string
+		wantSearchable []string
+	}{
+		{"string fields", articles, Index{Collection: "articles"}, "articles", []string{"title", "body"}},
+		{"configured", articles, Index{Collection: "api_articles", Name: "blog", Searchable: []string{"title", "cover"}}, "blog", []string{"title"}},
+		{
+			"search fields",
+			&schema.Collection{Name: "tags", PrimaryKey: "id", SearchFields: []string{"label"}, Fields: []schema.Field{{Name: "id", DataType: "int"}, {Name: "label", DataType: "string"}, {Name: "note", DataType: "string"}}},
+			Index{Collection: "tags"},
+			"tags",
+			[]string{"label"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := mappingFor(tt.collection, tt.index)
+			if m.Index != tt.wantIndex || m.PrimaryKey != "id" {
+				t.Errorf("mappingFor() index = %s, key = %s", m.Index, m.PrimaryKey)
+			}
+			if !reflect.DeepEqual(m.Searchable, tt.wantSearchable) {
+				t.Errorf("mappingFor() searchable = %v, want %v", m.Searchable, tt.wantSearchable)
+			}
+		})
+	}
+
+	m := mappingFor(articles, Index{})
+	doc := document(m, map[string]any{"id": 1, "title": "a", "cover": []byte("x"), "embedding": "[1]"})
+	if want := map[string]any{"id": 1, "title": "a"}; !reflect.DeepEqual(doc, want) {
+		t.Errorf("document() = %v, want %v", doc, want)
+	}
+}
+
+// request is a request received by a fake engine.
+type request struct {
+	Method string
+	Path   string
+	Body   string
+}
+
+// fakeServer records requests and answers them with the response for their
+// path, or an empty object.
+func fakeServer(t *testing.T, responses map[string]string) (*httptest.Server, *[]request) {
+	var requests []request
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		requests = append(requests, request{r.Method, r.URL.RequestURI(), string(body)})
+		if resp, ok := responses[r.Method+" "+r.URL.Path]; ok {
+			if status, rest, ok := strings.Cut(resp, "|"); ok && status == "400" {
+				w.WriteHeader(http.StatusBadRequest)
+				resp = rest
+			}
+			io.WriteString(w, resp)
+			return
+		}
+		io.WriteString(w, "{}")
+	}))
+	t.Cleanup(server.Close)
+	return server, &requests
+}
+
+func TestMeilisearch(t *testing.T) {
+	server, requests := fakeServer(t, map[string]string{
+		"POST /indexes/articles/search": `{"hits": [{"id": 3}, {"id": 12345678901234}]}`,
+	})
+	engine, err := NewMeilisearch(MeilisearchConfig{URL: server.URL + "/"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := mappingFor(articles, Index{})
+	ctx := context.Background()
+
+	if err := engine.EnsureIndex(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Upsert(ctx, m, []map[string]any{{"id": 3, "title": "Go"}}); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Delete(ctx, m, []string{"4"}); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := engine.Search(ctx, m, "go", 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"3", "12345678901234"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Search() = %v, want %v", ids, want)
+	}
+
+	want := []request{
+		{"POST", "/indexes", `{"primaryKey":"id","uid":"articles"}`},
+		{"PATCH", "/indexes/articles/settings", `{"searchableAttributes":["title","body"]}`},
+		{"POST", "/indexes/articles/documents?primaryKey=id", `[{"id":3,"title":"Go"}]`},
+		{"POST", "/indexes/articles/documents/delete-batch", `["4"]`},
+		{"POST", "/indexes/articles/search", `{"attributesToRetrieve":["id"],"limit":10,"q":"go"}`},
+	}
+	if !reflect.DeepEqual(*requests, want) {
+		t.Errorf("requests = %v, want %v", *requests, want)
+	}
+}
+
+func TestElasticsearch(t *testing.T) {
+	server, requests := fakeServer(t, map[string]string{
+		"PUT /articles":          `400|{"error": {"type": "resource_already_exists_exception"}}`,
+		"POST /articles/_search": `{"hits": {"hits": [{"_id": "7"}, {"_id": "2"}]}}`,
+		"POST /_bulk":            `{"errors": false, "items": []}`,
+	})
+	engine, err := NewElasticsearch(ElasticsearchConfig{URL: server.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := mappingFor(articles, Index{})
+	ctx := context.Background()
+
+	if err := engine.EnsureIndex(ctx, m); err != nil {
+		t.Fatal(err)
+	}
+	if err := engine.Upsert(ctx, m, []map[string]any{{"id": json.Number("3"), "title": "Go"}}); err != nil {
+		t.Fatal(err)
+	}
+	ids, err := engine.Search(ctx, m, "go", 5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"7", "2"}; !reflect.DeepEqual(ids, want) {
+		t.Errorf("Search() = %v, want %v", ids, want)
+	}
+
+	got := *requests
+	if len(got) != 4 {
+		t.Fatalf("requests = %v, want 4", got)
+	}
+	if got[1].Method != "PUT" || got[1].Path != "/articles/_mapping" || !strings.Contains(got[1].Body, `"title":{"type":"text"}`) {
+		t.Errorf("mapping update = %v", got[1])
+	}
+	bulk := `{"index":{"_id":"3","_index":"articles"}}` + "\n" + `{"id":3,"title":"Go"}` + "\n"
+	if got[2].Path != "/_bulk?refresh=wait_for" || got[2].Body != bulk {
+		t.Errorf("bulk request = %v, want body %q", got[2], bulk)
+	}
+	if !strings.Contains(got[3].Body, `"multi_match":{"fields":["title","body"],"query":"go"}`) {
+		t.Errorf("search request = %v", got[3])
+	}
+}
+
+func TestElasticsearchBulkErrors(t *testing.T) {
+	server, _ := fakeServer(t, map[string]string{
+		"POST /_bulk": `{"errors": true, "items": [{"index": {"_id": "3", "error": {"type": "mapper_parsing_exception"}}}]}`,
+	})
+	engine, _ := NewElasticsearch(ElasticsearchConfig{URL: server.URL})
+	err := engine.Upsert(context.Background(), mappingFor(articles, Index{}), []map[string]any{{"id": 3}})
+	if err == nil || !strings.Contains(err.Error(), "mapper_parsing_exception") {
+		t.Errorf("Upsert() error = %v, want mapper_parsing_exception", err)
+	}
+}
@@ -0,0 +1,525 @@
+// Package snapshot dumps collections to a storage provider and restores
+// them to the point the dump was taken, a safety net before risky schema
+// changes that needs no full database backup.
+package snapshot
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/storage"
+	"go.uber.org/zap"
+)
+
+// Defaults for snapshot retention.
+const (
+	DefaultDirectory = "snapshots"
+	DefaultKeep      = 10
+)
+
+// Restore modes.
+const (
+	// ModeReplace deletes the collection's rows before restoring.
+	ModeReplace = "replace"
+
+	// ModeAppend inserts the snapshot's rows next to the current ones.
+	ModeAppend = "append"
+)
+
+// Config configures collection snapshots.
+type Config struct {
+	// Storage receives the snapshot files. Nil disables snapshots.
+	Storage storage.Provider
+
+	// Directory is the storage directory of the snapshots, which are kept
+	// in a subdirectory per collection.
+	// Default: DefaultDirectory
+	Directory string
+
+	// Keep is how many snapshots of a collection are kept; older ones are
+	// deleted when a snapshot is taken.
+	// Default: DefaultKeep
+	Keep int
+
+	// MaxAge deletes snapshots older than this when a snapshot is taken.
+	// Zero keeps them until Keep is reached.
+	MaxAge time.Duration
+}
+
+// Snapshot is a dump of a collection's rows, stored as gzip-compressed
+// NDJSON: a header line describing the fields, then one line per row.
+type Snapshot struct {
+	ID         string    `db:"id" json:"id"`
+	Collection string    `db:"collection" json:"collection"`
+	Path       string    `db:"path" json:"path"`
+	Rows       int64     `db:"rows_count" json:"rows"`
+	Size       int64     `db:"size" json:"size"`
+	Note       string    `db:"note" json:"note,omitempty"`
+	CreatedBy  *string   `db:"created_by" json:"created_by,omitempty"`
+	CreatedAt  time.Time `db:"created_at" json:"created_at"`
+}
+
+// header is the first line of a snapshot file.
+type header struct {
+	Collection string            `json:"collection"`
+	Table      string            `json:"table"`
+	Fields     map[string]string `json:"fields"`
+	CreatedAt  time.Time         `json:"created_at"`
+}
+
+// RestoreResult reports a restore.
+type RestoreResult struct {
+	Restored int64  `json:"restored"`
+	Deleted  int64  `json:"deleted"`
+	Mode     string `json:"mode"`
+
+	// Backup is the snapshot of the rows the restore replaced.
+	Backup *Snapshot `json:"backup,omitempty"`
+
+	// SkippedFields are fields of the snapshot the collection no longer
+	// has.
+	SkippedFields []string `json:"skipped_fields,omitempty"`
+}
+
+// Service takes and restores snapshots.
+type Service struct {
+	db          *sqlx.DB
+	dialect     dialect.Dialect
+	config      Config
+	collections func() []*schema.Collection
+	logger      *zap.SugaredLogger
+}
+
+// NewService creates a snapshot service for the collections returned by
+// collections.
+func NewService(db *sqlx.DB, config Config, collections func() []*schema.Collection, logger *zap.SugaredLogger) *Service {
+	if config.Directory == "" {
+		config.Directory = DefaultDirectory
+	}
+	config.Directory = strings.Trim(config.Directory, "/")
+	if config.Keep <= 0 {
+		config.Keep = DefaultKeep
+	}
+	return &Service{
+		db:          db,
+		dialect:     dialect.ForDriver(db.DriverName()),
+		config:      config,
+		collections: collections,
+		logger:      logger,
+	}
+}
+
+// collection returns the collection with an API or table name.
+func (s *Service) collection(name string) (*schema.Collection, error) {
+	for _, c := range s.collections() {
+		if c.Name == name || c.TableName == name {
+			return c, nil
+		}
+	}
+	return nil, apperror.ErrCollectionNotFound.WithMessage("Collection not found: " + name)
+}
+
+// Create dumps the rows of a collection to storage.
+func (s *Service) Create(ctx context.Context, name, note string, createdBy *string) (*Snapshot, error) {
+	collection, err := s.collection(name)
+	if err != nil {
+		return nil, err
+	}
+	snapshot, err := s.create(ctx, collection, note, createdBy)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.prune(ctx, collection); err != nil {
+		s.logger.Warnw("Failed to prune snapshots", "collection", collection.Name, "error", err)
+	}
+	return snapshot, nil
+}
+
+// create dumps and records a snapshot of collection.
+func (s *Service) create(ctx context.Context, collection *schema.Collection, note string, createdBy *string) (*Snapshot, error) {
+	now := time.Now().UTC()
+	h := header{
+		Collection: collection.Name,
+		Table:      collection.TableName,
+		Fields:     make(map[string]string, len(collection.Fields)),
+		CreatedAt:  now,
+	}
+	for _, f := range collection.Fields {
+		h.Fields[f.Name] = f.DataType
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	enc := json.NewEncoder(gz)
+	if err := enc.Encode(h); err != nil {
+		return nil, fmt.Errorf("failed to encode snapshot header: %w", err)
+	}
+	rows, err := s.dump(ctx, collection, enc)
+	if err != nil {
+		return nil, err
+	}
+	if err := gz.Close(); err != nil {
+		return nil, fmt.Errorf("failed to compress snapshot: %w", err)
+	}
+
+	snapshot := &Snapshot{
+		ID:         uuid.NewString(),
+		Collection: collection.Name,
+		Rows:       rows,
+		Size:       int64(buf.Len()),
+		Note:       note,
+		CreatedBy:  createdBy,
+		CreatedAt:  now,
+	}
+	file := fmt.Sprintf("%s-%s.ndjson.gz", now.Format("20060102T150405.000000000Z"), snapshot.ID[:8])
+	info, err := s.config.Storage.Upload(ctx, &buf, file, &storage.UploadOptions{
+		ContentType:  "application/gzip",
+		Directory:    s.config.Directory + "/" + collection.Name,
+		PreserveName: true,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+	snapshot.Path = info.StoragePath
+
+	query := `
+		INSERT INTO tugo_snapshots (id, collection, path, rows_count, size, note, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err = s.db.ExecContext(ctx, s.db.Rebind(query), snapshot.ID, snapshot.Collection, snapshot.Path,
+		snapshot.Rows, snapshot.Size, snapshot.Note, snapshot.CreatedBy, snapshot.CreatedAt)
+	if err != nil {
+		if delErr := s.config.Storage.Delete(ctx, snapshot.Path); delErr != nil {
+			s.logger.Warnw("Failed to delete unrecorded snapshot", "path", snapshot.Path, "error", delErr)
+		}
+		return nil, fmt.Errorf("failed to record snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// dump writes the rows of a collection to enc and returns their number.
+func (s *Service) dump(ctx context.Context, collection *schema.Collection, enc *json.Encoder) (int64, error) {
+	query := fmt.Sprintf("SELECT %s FROM %s", s.columnList(collection.Fields), s.dialect.QuoteIdent(collection.TableName))
+	if collection.PrimaryKey != "" {
+		query += " ORDER BY " + s.dialect.QuoteIdent(collection.PrimaryKey)
+	}
+	rows, err := s.db.QueryxContext(ctx, query)
+	if err != nil {
+		return 0, fmt.Errorf("failed to select rows: %w", err)
+	}
+	defer rows.Close()
+
+	binary := make(map[string]bool)
+	for _, f := range collection.Fields {
+		if f.DataType == "binary" {
+			binary[f.Name] = true
+		}
+	}
+
+	var n int64
+	for rows.Next() {
+		row := make(map[string]any)
+		if err := rows.MapScan(row); err != nil {
+			return 0, fmt.Errorf("failed to scan row: %w", err)
+		}
+		for name, v := range row {
+			if b, ok := v.([]byte); ok {
+				if binary[name] {
+					row[name] = base64.StdEncoding.EncodeToString(b)
+				} else {
+					row[name] = string(b)
+				}
+			}
+		}
+		if err := enc.Encode(row); err != nil {
+			return 0, fmt.Errorf("failed to encode row: %w", err)
+		}
+		n++
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read rows: %w", err)
+	}
+	return n, nil
+}
+
+// List returns the snapshots of a collection, newest first.
+func (s *Service) List(ctx context.Context, name string) ([]Snapshot, error) {
+	collection, err := s.collection(name)
+	if err != nil {
+		return nil, err
+	}
+	return s.list(ctx, collection.Name)
+}
+
+// list returns the snapshots of a collection by API name, newest first.
+func (s *Service) list(ctx context.Context, collection string) ([]Snapshot, error) {
+	query := `
+		SELECT id, collection, path, rows_count, size, note, created_by, created_at
+		FROM tugo_snapshots
+		WHERE collection = ?
+		ORDER BY created_at DESC, id
+	`
+	snapshots := make([]Snapshot, 0)
+	if err := s.db.SelectContext(ctx, &snapshots, s.db.Rebind(query), collection); err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	return snapshots, nil
+}
+
+// get returns a snapshot of a collection.
+func (s *Service) get(ctx context.Context, collection, id string) (*Snapshot, error) {
+	query := `
+		SELECT id, collection, path, rows_count, size, note, created_by, created_at
+		FROM tugo_snapshots
+		WHERE collection = ? AND id = ?
+	`
+	var snapshot Snapshot
+	if err := s.db.GetContext(ctx, &snapshot, s.db.Rebind(query), collection, id); err != nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Snapshot '%s' not found", id)
+	}
+	return &snapshot, nil
+}
+
+// Delete removes a snapshot and its file.
+func (s *Service) Delete(ctx context.Context, name, id string) error {
+	collection, err := s.collection(name)
+	if err != nil {
+		return err
+	}
+	snapshot, err := s.get(ctx, collection.Name, id)
+	if err != nil {
+		return err
+	}
+	return s.delete(ctx, *snapshot)
+}
+
+// delete removes a snapshot's file and record.
+func (s *Service) delete(ctx context.Context, snapshot Snapshot) error {
+	if err := s.config.Storage.Delete(ctx, snapshot.Path); err != nil {
+		if exists, existsErr := s.config.Storage.Exists(ctx, snapshot.Path); existsErr != nil || exists {
+			return fmt.Errorf("failed to delete snapshot file: %w", err)
+		}
+	}
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM tugo_snapshots WHERE id = ?"), snapshot.ID); err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+	return nil
+}
+
+// prune deletes the snapshots of a collection beyond Keep or older than
+// MaxAge.
+func (s *Service) prune(ctx context.Context, collection *schema.Collection) error {
+	snapshots, err := s.list(ctx, collection.Name)
+	if err != nil {
+		return err
+	}
+	cutoff := time.Time{}
+	if s.config.MaxAge > 0 {
+		cutoff = time.Now().Add(-s.config.MaxAge)
+	}
+	for i, snapshot := range snapshots {
+		if i < s.config.Keep && !snapshot.CreatedAt.Before(cutoff) {
+			continue
+		}
+		if err := s.delete(ctx, snapshot); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Restore puts the rows of a snapshot back into its collection in one
+// transaction. In ModeReplace, the default, the current rows are first
+// saved to a new snapshot and then deleted.
+func (s *Service) Restore(ctx context.Context, name, id, mode string, createdBy *string) (*RestoreResult, error) {
+	collection, err := s.collection(name)
+	if err != nil {
+		return nil, err
+	}
+	switch mode {
+	case "":
+		mode = ModeReplace
+	case ModeReplace, ModeAppend:
+	default:
+		return nil, apperror.ErrBadRequest.WithMessagef("mode must be %s or %s", ModeReplace, ModeAppend)
+	}
+	snapshot, err := s.get(ctx, collection.Name, id)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &RestoreResult{Mode: mode}
+	if mode == ModeReplace {
+		note := fmt.Sprintf("Before restoring snapshot %s", snapshot.ID)
+		if result.Backup, err = s.create(ctx, collection, note, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
+	reader, err := s.config.Storage.Download(ctx, snapshot.Path)
+	if err != nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Snapshot file '%s' not found", snapshot.Path)
+	}
+	defer reader.Close()
+	gz, err := gzip.NewReader(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	defer gz.Close()
+
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if mode == ModeReplace {
+		res, err := tx.ExecContext(ctx, "DELETE FROM "+s.dialect.QuoteIdent(collection.TableName))
+		if err != nil {
+			return nil, apperror.ErrConflict.WithMessage("Failed to delete the current rows; other rows may reference them").WithError(err)
+		}
+		result.Deleted, _ = res.RowsAffected()
+	}
+
+	fields := make(map[string]string, len(collection.Fields))
+	for _, f := range collection.Fields {
+		fields[f.Name] = f.DataType
+	}
+	scanner := bufio.NewScanner(gz)
+	scanner.Buffer(make([]byte, 64*1024), 64*1024*1024)
+	first := true
+	for scanner.Scan() {
+		dec := json.NewDecoder(bytes.NewReader(scanner.Bytes()))
+		dec.UseNumber()
+		if first {
+			first = false
+			var h header
+			if err := dec.Decode(&h); err != nil {
+				return nil, fmt.Errorf("failed to decode snapshot header: %w", err)
+			}
+			for name := range h.Fields {
+				if _, ok := fields[name]; !ok {
+					result.SkippedFields = append(result.SkippedFields, name)
+				}
+			}
+			sort.Strings(result.SkippedFields)
+			continue
+		}
+
+		var row map[string]any
+		if err := dec.Decode(&row); err != nil {
+			return nil, fmt.Errorf("failed to decode snapshot row: %w", err)
+		}
+		if err := s.insert(ctx, tx, collection, fields, row); err != nil {
+			return nil, err
+		}
+		result.Restored++
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	if err := s.resetSequence(ctx, tx, collection); err != nil {
+		return nil, err
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+	return result, nil
+}
+
+// insert inserts a snapshot row, keeping the fields the collection has.
+func (s *Service) insert(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection, fields map[string]string, row map[string]any) error {
+	columns := make([]string, 0, len(row))
+	for name := range row {
+		if _, ok := fields[name]; ok {
+			columns = append(columns, name)
+		}
+	}
+	sort.Strings(columns)
+
+	values := make([]any, len(columns))
+	quoted := make([]string, len(columns))
+	placeholders := make([]string, len(columns))
+	for i, name := range columns {
+		value, err := decodeValue(fields[name], row[name])
+		if err != nil {
+			return fmt.Errorf("failed to decode %s: %w", name, err)
+		}
+		values[i] = value
+		quoted[i] = s.dialect.QuoteIdent(name)
+		placeholders[i] = s.dialect.Placeholder(i + 1)
+	}
+
+	query := fmt.Sprintf("INSERT INTO %s (%s) VALUES (%s)",
+		s.dialect.QuoteIdent(collection.TableName), strings.Join(quoted, ", "), strings.Join(placeholders, ", "))
+	if _, err := tx.ExecContext(ctx, query, values...); err != nil {
+		return apperror.ErrConflict.WithMessage("Failed to restore a row; it may conflict with a current row").WithError(err)
+	}
+	return nil
+}
+
+// resetSequence moves a PostgreSQL serial primary key's sequence past the
+// restored IDs, so later inserts do not reuse them.
+func (s *Service) resetSequence(ctx context.Context, tx *sqlx.Tx, collection *schema.Collection) error {
+	if s.dialect.Name() != dialect.Postgres || collection.PrimaryKey == "" {
+		return nil
+	}
+	for _, f := range collection.Fields {
+		if f.Name != collection.PrimaryKey || f.DataType != "int" {
+			continue
+		}
+		table, pk := s.dialect.QuoteIdent(collection.TableName), s.dialect.QuoteIdent(collection.PrimaryKey)
+		query := fmt.Sprintf(`
+			SELECT setval(seq, GREATEST((SELECT COALESCE(MAX(%s), 0) FROM %s), 1))
+			FROM pg_get_serial_sequence($1, $2) AS seq
+			WHERE seq IS NOT NULL
+		`, pk, table)
+		if _, err := tx.ExecContext(ctx, query, table, collection.PrimaryKey); err != nil {
+			return fmt.Errorf("failed to reset ID sequence: %w", err)
+		}
+	}
+	return nil
+}
+
+// decodeValue converts a dumped value back for a field of dataType.
+func decodeValue(dataType string, v any) (any, error) {
+	switch val := v.(type) {
+	case json.Number:
+		return val.String(), nil
+	case string:
+		switch dataType {
+		case "binary":
+			return base64.StdEncoding.DecodeString(val)
+		case "timestamp":
+			if t, err := time.Parse(time.RFC3339Nano, val); err == nil {
+				return t, nil
+			}
+		}
+	case map[string]any, []any:
+		data, err := json.Marshal(val)
+		return string(data), err
+	}
+	return v, nil
+}
+
+// columnList returns the comma-separated quoted columns of fields.
+func (s *Service) columnList(fields []schema.Field) string {
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i] = s.dialect.QuoteIdent(f.Name)
+	}
+	return strings.Join(names, ", ")
+}
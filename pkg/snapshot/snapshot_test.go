@@ -0,0 +1,40 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestDecodeValue(t *testing.T) {
+	ts := time.Date(2024, 3, 1, 12, 30, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		dataType string
+		value    any
+		want     any
+	}{
+		{"string", "string", "hello", "hello"},
+		{"number", "int", json.Number("42"), "42"},
+		{"null", "string", nil, nil},
+		{"bool", "boolean", true, true},
+		{"binary", "binary", "AAEC", []byte{0, 1, 2}},
+		{"timestamp", "timestamp", ts.Format(time.RFC3339Nano), ts},
+		{"unparsed timestamp", "timestamp", "2024-03-01", "2024-03-01"},
+		{"json object", "json", map[string]any{"a": json.Number("1")}, `{"a":1}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeValue(tt.dataType, tt.value)
+			if err != nil {
+				t.Fatalf("decodeValue() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("decodeValue() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,13 +1,17 @@
 package storage
 
 import (
+	"errors"
+	"io"
 	"mime"
+	"mime/multipart"
 	"net/http"
 	"path/filepath"
 	"strconv"
 
 	"github.com/gin-gonic/gin"
 	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
 	"github.com/thienel/tugo/pkg/response"
 	"go.uber.org/zap"
 )
@@ -19,6 +23,15 @@ type Handler struct {
 	config  HandlerConfig
 }
 
+// Multipart limits besides the file itself.
+const (
+	// maxFieldBytes caps each form field before the file part.
+	maxFieldBytes = 4096
+
+	// maxFormOverhead allows for part headers and fields on top of the file.
+	maxFormOverhead = 64 << 10
+)
+
 // HandlerConfig configures the file handler.
 type HandlerConfig struct {
 	// MaxUploadSize is the maximum upload size in bytes.
@@ -50,36 +63,66 @@ func NewHandler(manager *Manager, logger *zap.SugaredLogger, config HandlerConfi
 }
 
 // Upload handles POST /files/upload requests.
+// The multipart body is streamed to the provider rather than buffered, so
+// the directory and provider fields must precede the file part.
 func (h *Handler) Upload(c *gin.Context) {
-	// Parse multipart form
-	if err := c.Request.ParseMultipartForm(h.config.MaxUploadSize); err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
-			apperror.ErrBadRequest.WithMessage("Failed to parse form"),
-		))
-		return
+	maxSize := h.config.MaxUploadSize
+	if maxSize > 0 {
+		if c.Request.ContentLength > maxSize+maxFormOverhead {
+			h.tooLarge(c)
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxSize+maxFormOverhead)
 	}
 
-	file, header, err := c.Request.FormFile("file")
+	reader, err := c.Request.MultipartReader()
 	if err != nil {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
-			apperror.ErrBadRequest.WithMessage("No file provided"),
+		response.JSON(c, http.StatusBadRequest, response.FromAppError(
+			apperror.ErrBadRequest.WithMessage("Failed to parse form"),
 		))
 		return
 	}
-	defer file.Close()
 
-	// Check file size
-	if header.Size > h.config.MaxUploadSize {
-		c.JSON(http.StatusBadRequest, response.FromAppError(
-			apperror.ErrBadRequest.WithMessage("File too large"),
-		))
-		return
+	// Read form fields up to the file part
+	fields := make(map[string]string)
+	var file *multipart.Part
+	for {
+		part, err := reader.NextPart()
+		if errors.Is(err, io.EOF) {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessage("No file provided"),
+			))
+			return
+		}
+		if err != nil {
+			h.formError(c, err)
+			return
+		}
+
+		if part.FormName() == "file" && part.FileName() != "" {
+			file = part
+			break
+		}
+		value, err := io.ReadAll(io.LimitReader(part, maxFieldBytes+1))
+		if err != nil {
+			h.formError(c, err)
+			return
+		}
+		if len(value) > maxFieldBytes {
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
+				apperror.ErrBadRequest.WithMessagef("Form field '%s' is too long", part.FormName()),
+			))
+			return
+		}
+		fields[part.FormName()] = string(value)
 	}
+	defer file.Close()
 
 	// Detect content type
-	contentType := header.Header.Get("Content-Type")
+	filename := file.FileName()
+	contentType := file.Header.Get("Content-Type")
 	if contentType == "" {
-		ext := filepath.Ext(header.Filename)
+		ext := filepath.Ext(filename)
 		contentType = mime.TypeByExtension(ext)
 		if contentType == "" {
 			contentType = "application/octet-stream"
@@ -96,31 +139,35 @@ func (h *Handler) Upload(c *gin.Context) {
 			}
 		}
 		if !allowed {
-			c.JSON(http.StatusBadRequest, response.FromAppError(
+			response.JSON(c, http.StatusBadRequest, response.FromAppError(
 				apperror.ErrBadRequest.WithMessage("File type not allowed"),
 			))
 			return
 		}
 	}
 
-	// Get optional directory from form
-	directory := c.PostForm("directory")
-
-	// Get optional provider from form
-	provider := c.PostForm("provider")
+	// Get optional directory and provider from form
+	directory := fields["directory"]
+	provider := fields["provider"]
 	if provider == "" {
 		provider = h.config.DefaultProvider
 	}
 
 	// Upload file
-	record, err := h.manager.Upload(c.Request.Context(), provider, file, header.Filename, &UploadOptions{
+	record, err := h.manager.Upload(c.Request.Context(), provider, file, filename, &UploadOptions{
 		ContentType: contentType,
-		MaxSize:     h.config.MaxUploadSize,
+		MaxSize:     maxSize,
 		Directory:   directory,
 	})
+	var maxErr *http.MaxBytesError
+	if errors.Is(err, ErrFileTooLarge) || errors.As(err, &maxErr) {
+		h.tooLarge(c)
+		return
+	}
 	if err != nil {
-		h.logger.Errorw("Failed to upload file", "error", err)
-		c.JSON(http.StatusInternalServerError, response.FromAppError(
+		_ = c.Error(err)
+		requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to upload file", "error", err)
+		response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 			apperror.ErrInternalServer.WithMessage("Failed to upload file"),
 		))
 		return
@@ -141,8 +188,8 @@ func (h *Handler) Download(c *gin.Context) {
 
 	reader, record, err := h.manager.Download(c.Request.Context(), fileID)
 	if err != nil {
-		h.logger.Warnw("Failed to download file", "id", fileID, "error", err)
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to download file", "id", fileID, "error", err)
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrNotFound.WithMessage("File not found"),
 		))
 		return
@@ -164,7 +211,7 @@ func (h *Handler) Get(c *gin.Context) {
 
 	record, err := h.manager.GetFileRecord(c.Request.Context(), fileID)
 	if err != nil {
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrNotFound.WithMessage("File not found"),
 		))
 		return
@@ -179,8 +226,8 @@ func (h *Handler) Delete(c *gin.Context) {
 
 	err := h.manager.Delete(c.Request.Context(), fileID)
 	if err != nil {
-		h.logger.Warnw("Failed to delete file", "id", fileID, "error", err)
-		c.JSON(http.StatusNotFound, response.FromAppError(
+		requestlog.Logger(c.Request.Context(), h.logger).Warnw("Failed to delete file", "id", fileID, "error", err)
+		response.JSON(c, http.StatusNotFound, response.FromAppError(
 			apperror.ErrNotFound.WithMessage("File not found"),
 		))
 		return
@@ -210,8 +257,9 @@ func (h *Handler) List(c *gin.Context) {
 
 	records, total, err := h.manager.ListFiles(c.Request.Context(), limit, offset)
 	if err != nil {
-		h.logger.Errorw("Failed to list files", "error", err)
-		c.JSON(http.StatusInternalServerError, response.FromAppError(
+		_ = c.Error(err)
+		requestlog.Logger(c.Request.Context(), h.logger).Errorw("Failed to list files", "error", err)
+		response.JSON(c, http.StatusInternalServerError, response.FromAppError(
 			apperror.ErrInternalServer.WithMessage("Failed to list files"),
 		))
 		return
@@ -228,3 +276,22 @@ func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
 	rg.GET("/:id/info", h.Get)
 	rg.DELETE("/:id", h.Delete)
 }
+
+// formError responds to a failure reading the multipart body.
+func (h *Handler) formError(c *gin.Context, err error) {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		h.tooLarge(c)
+		return
+	}
+	response.JSON(c, http.StatusBadRequest, response.FromAppError(
+		apperror.ErrBadRequest.WithMessage("Failed to parse form"),
+	))
+}
+
+// tooLarge responds that the upload exceeds MaxUploadSize.
+func (h *Handler) tooLarge(c *gin.Context) {
+	response.JSON(c, http.StatusRequestEntityTooLarge, response.FromAppError(
+		apperror.ErrPayloadTooLarge.WithMessagef("File exceeds %d bytes", h.config.MaxUploadSize),
+	))
+}
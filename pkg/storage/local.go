@@ -86,7 +86,7 @@ func (l *Local) Upload(ctx context.Context, file io.Reader, filename string, opt
 		}
 		if size > opts.MaxSize {
 			os.Remove(fullPath)
-			return nil, fmt.Errorf("file size exceeds maximum allowed (%d bytes)", opts.MaxSize)
+			return nil, fmt.Errorf("%w: maximum is %d bytes", ErrFileTooLarge, opts.MaxSize)
 		}
 	} else {
 		size, err = io.Copy(dst, file)
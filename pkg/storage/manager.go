@@ -169,10 +169,10 @@ type FileRecord struct {
 func (m *Manager) saveFileRecord(ctx context.Context, record *FileRecord) error {
 	query := `
 		INSERT INTO tugo_files (id, filename, storage_path, provider, size, content_type, url, created_at, updated_at)
-		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
 	`
 	now := time.Now()
-	_, err := m.db.ExecContext(ctx, query,
+	_, err := m.db.ExecContext(ctx, m.db.Rebind(query),
 		record.ID,
 		record.Filename,
 		record.StoragePath,
@@ -193,8 +193,8 @@ func (m *Manager) GetFileRecord(ctx context.Context, fileID string) (*FileRecord
 	}
 
 	var record FileRecord
-	query := `SELECT * FROM tugo_files WHERE id = $1`
-	err := m.db.GetContext(ctx, &record, query, fileID)
+	query := `SELECT * FROM tugo_files WHERE id = ?`
+	err := m.db.GetContext(ctx, &record, m.db.Rebind(query), fileID)
 	if err != nil {
 		return nil, fmt.Errorf("file not found: %w", err)
 	}
@@ -203,8 +203,8 @@ func (m *Manager) GetFileRecord(ctx context.Context, fileID string) (*FileRecord
 
 // deleteFileRecord deletes a file record from the database.
 func (m *Manager) deleteFileRecord(ctx context.Context, fileID string) error {
-	query := `DELETE FROM tugo_files WHERE id = $1`
-	_, err := m.db.ExecContext(ctx, query, fileID)
+	query := `DELETE FROM tugo_files WHERE id = ?`
+	_, err := m.db.ExecContext(ctx, m.db.Rebind(query), fileID)
 	return err
 }
 
@@ -223,8 +223,8 @@ func (m *Manager) ListFiles(ctx context.Context, limit, offset int) ([]*FileReco
 
 	// Get files
 	var records []*FileRecord
-	query := `SELECT * FROM tugo_files ORDER BY created_at DESC LIMIT $1 OFFSET $2`
-	if err := m.db.SelectContext(ctx, &records, query, limit, offset); err != nil {
+	query := `SELECT * FROM tugo_files ORDER BY created_at DESC LIMIT ? OFFSET ?`
+	if err := m.db.SelectContext(ctx, &records, m.db.Rebind(query), limit, offset); err != nil {
 		return nil, 0, err
 	}
 
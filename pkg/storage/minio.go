@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"path/filepath"
@@ -114,13 +115,11 @@ func (m *MinIO) Upload(ctx context.Context, file io.Reader, filename string, opt
 		UserMetadata: opts.Metadata,
 	}
 
-	// Upload with size limit if specified
-	var size int64 = -1
-	if opts.MaxSize > 0 {
-		size = opts.MaxSize
+	// Stream with unknown size, failing once the size limit is passed
+	info, err := m.client.PutObject(ctx, m.bucket, objectName, limitSize(file, opts.MaxSize), -1, putOpts)
+	if errors.Is(err, ErrFileTooLarge) {
+		return nil, fmt.Errorf("%w: maximum is %d bytes", ErrFileTooLarge, opts.MaxSize)
 	}
-
-	info, err := m.client.PutObject(ctx, m.bucket, objectName, file, size, putOpts)
 	if err != nil {
 		return nil, fmt.Errorf("failed to upload file: %w", err)
 	}
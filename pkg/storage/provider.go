@@ -2,10 +2,14 @@ package storage
 
 import (
 	"context"
+	"errors"
 	"io"
 	"time"
 )
 
+// ErrFileTooLarge is returned when an upload exceeds UploadOptions.MaxSize.
+var ErrFileTooLarge = errors.New("file too large")
+
 // Provider is the interface for file storage backends.
 type Provider interface {
 	// Upload stores a file and returns the storage path.
@@ -76,3 +80,31 @@ func DefaultUploadOptions() *UploadOptions {
 		Metadata:     make(map[string]string),
 	}
 }
+
+// sizeLimitedReader fails with ErrFileTooLarge once more than max bytes are read.
+type sizeLimitedReader struct {
+	r    io.Reader
+	left int64
+}
+
+// limitSize wraps r to fail with ErrFileTooLarge beyond max bytes.
+// A max of zero or less means no limit.
+func limitSize(r io.Reader, max int64) io.Reader {
+	if max <= 0 {
+		return r
+	}
+	return &sizeLimitedReader{r: r, left: max}
+}
+
+// Read implements io.Reader.
+func (l *sizeLimitedReader) Read(p []byte) (int, error) {
+	if int64(len(p)) > l.left+1 {
+		p = p[:l.left+1]
+	}
+	n, err := l.r.Read(p)
+	l.left -= int64(n)
+	if l.left < 0 {
+		return n, ErrFileTooLarge
+	}
+	return n, err
+}
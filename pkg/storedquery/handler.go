@@ -0,0 +1,107 @@
+package storedquery
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+// Handler handles HTTP requests for stored queries.
+type Handler struct {
+	service *Service
+	logger  *zap.SugaredLogger
+}
+
+// NewHandler creates a new stored query handler.
+func NewHandler(service *Service, logger *zap.SugaredLogger) *Handler {
+	return &Handler{
+		service: service,
+		logger:  logger,
+	}
+}
+
+// Info is the public description of a query. The SQL is not exposed.
+type Info struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Params      []Param  `json:"params"`
+	Columns     []Column `json:"columns,omitempty"`
+}
+
+// List handles GET /queries requests.
+func (h *Handler) List(c *gin.Context) {
+	queries, err := h.service.ListAllowed(c.Request.Context())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	infos := make([]Info, len(queries))
+	for i, q := range queries {
+		infos[i] = info(&q)
+	}
+	c.JSON(http.StatusOK, response.Success(infos))
+}
+
+// Execute handles GET /queries/:name requests.
+func (h *Handler) Execute(c *gin.Context) {
+	result, err := h.service.Execute(c.Request.Context(), c.Param("name"), c.Request.URL.Query())
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// Schema handles GET /queries/:name/schema requests.
+func (h *Handler) Schema(c *gin.Context) {
+	ctx := c.Request.Context()
+	q, err := h.service.authorize(ctx, c.Param("name"))
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	cols, err := h.service.describe(ctx, q)
+	if err != nil {
+		h.handleError(c, err)
+		return
+	}
+
+	result := info(q)
+	result.Columns = cols
+	c.JSON(http.StatusOK, response.Success(result))
+}
+
+// RegisterRoutes registers stored query routes on a Gin router group.
+func (h *Handler) RegisterRoutes(rg *gin.RouterGroup) {
+	rg.GET("", h.List)
+	rg.GET("/:name", h.Execute)
+	rg.GET("/:name/schema", h.Schema)
+}
+
+// handleError converts errors to HTTP responses.
+func (h *Handler) handleError(c *gin.Context, err error) {
+	_ = c.Error(err)
+	if appErr, ok := apperror.AsAppError(err); ok {
+		response.JSON(c, appErr.HTTPStatus, response.FromAppError(appErr))
+		return
+	}
+
+	requestlog.Logger(c.Request.Context(), h.logger).Errorw("Unexpected error", "error", err)
+	response.JSON(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+}
+
+// info returns the public description of a query.
+func info(q *Query) Info {
+	params := q.Params
+	if params == nil {
+		params = []Param{}
+	}
+	return Info{Name: q.Name, Description: q.Description, Params: params}
+}
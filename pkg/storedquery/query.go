@@ -0,0 +1,327 @@
+// Package storedquery exposes registered read-only SQL statements as API endpoints.
+package storedquery
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+// Query sources.
+const (
+	SourceConfig = "config"
+	SourceAPI    = "api"
+)
+
+// Parameter types.
+const (
+	TypeString    = "string"
+	TypeInt       = "int"
+	TypeFloat     = "float"
+	TypeBool      = "bool"
+	TypeDate      = "date"
+	TypeTimestamp = "timestamp"
+	TypeUUID      = "uuid"
+)
+
+// DefaultMaxRows caps the rows returned by a query that sets no MaxRows.
+const DefaultMaxRows = 1000
+
+var (
+	nameRegex  = regexp.MustCompile(`^[a-zA-Z0-9_-]{1,64}$`)
+	paramRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Query is a named, parameterized read-only SQL statement.
+// Parameters are referenced in SQL as :name.
+type Query struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	SQL         string   `json:"sql"`
+	Params      []Param  `json:"params,omitempty"`
+	Roles       []string `json:"roles,omitempty"` // Empty allows every role
+	MaxRows     int      `json:"max_rows,omitempty"`
+	Source      string   `json:"source"`
+}
+
+// Param declares a query parameter.
+type Param struct {
+	Name     string  `json:"name"`
+	Type     string  `json:"type"`
+	Required bool    `json:"required,omitempty"`
+	Default  *string `json:"default,omitempty"`
+}
+
+// Column describes a result column.
+type Column struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+// Result holds the rows returned by a query.
+type Result struct {
+	Columns   []Column         `json:"columns"`
+	Rows      []map[string]any `json:"rows"`
+	Truncated bool             `json:"truncated"`
+}
+
+// Validate checks the query's name, parameters and SQL.
+func (q *Query) Validate() error {
+	if !nameRegex.MatchString(q.Name) {
+		return apperror.ErrBadRequest.WithMessage("Query names may only contain letters, digits, '_' and '-' (max 64)")
+	}
+	if q.MaxRows < 0 {
+		return apperror.ErrBadRequest.WithMessage("max_rows must not be negative")
+	}
+
+	declared := make(map[string]bool, len(q.Params))
+	for _, p := range q.Params {
+		if !paramRegex.MatchString(p.Name) {
+			return apperror.ErrBadRequest.WithMessagef("Invalid parameter name '%s'", p.Name)
+		}
+		if declared[p.Name] {
+			return apperror.ErrBadRequest.WithMessagef("Parameter '%s' is declared twice", p.Name)
+		}
+		if !validType(p.Type) {
+			return apperror.ErrBadRequest.WithMessagef("Parameter '%s' has unknown type '%s'", p.Name, p.Type)
+		}
+		if p.Default != nil {
+			if _, err := coerce(p, *p.Default); err != nil {
+				return err
+			}
+		}
+		declared[p.Name] = true
+	}
+
+	_, names, err := compile(dialect.Default(), q.SQL)
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if !declared[name] {
+			return apperror.ErrBadRequest.WithMessagef("Parameter ':%s' is not declared", name)
+		}
+	}
+	return nil
+}
+
+// maxRows returns the row cap for the query.
+func (q *Query) maxRows() int {
+	if q.MaxRows > 0 {
+		return q.MaxRows
+	}
+	return DefaultMaxRows
+}
+
+// allows reports whether a role may run the query.
+func (q *Query) allows(role string) bool {
+	if len(q.Roles) == 0 || role == "admin" {
+		return true
+	}
+	for _, r := range q.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// bind converts request values to typed arguments in parameter order.
+// Missing optional parameters use their default, or NULL.
+func (q *Query) bind(values map[string][]string) (map[string]any, error) {
+	args := make(map[string]any, len(q.Params))
+	for _, p := range q.Params {
+		raw, ok := "", false
+		if v, exists := values[p.Name]; exists && len(v) > 0 {
+			raw, ok = v[0], true
+		} else if p.Default != nil {
+			raw, ok = *p.Default, true
+		}
+
+		if !ok {
+			if p.Required {
+				return nil, apperror.ErrBadRequest.WithMessagef("Parameter '%s' is required", p.Name)
+			}
+			args[p.Name] = nil
+			continue
+		}
+
+		value, err := coerce(p, raw)
+		if err != nil {
+			return nil, err
+		}
+		args[p.Name] = value
+	}
+	return args, nil
+}
+
+// validType reports whether t is a known parameter type.
+func validType(t string) bool {
+	switch t {
+	case TypeString, TypeInt, TypeFloat, TypeBool, TypeDate, TypeTimestamp, TypeUUID:
+		return true
+	}
+	return false
+}
+
+// coerce parses a raw value as the parameter's type.
+func coerce(p Param, raw string) (any, error) {
+	invalid := func() error {
+		return apperror.ErrBadRequest.WithMessagef("Parameter '%s' must be a valid %s", p.Name, p.Type)
+	}
+
+	switch p.Type {
+	case TypeInt:
+		v, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil {
+			return nil, invalid()
+		}
+		return v, nil
+	case TypeFloat:
+		v, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return nil, invalid()
+		}
+		return v, nil
+	case TypeBool:
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, invalid()
+		}
+		return v, nil
+	case TypeDate:
+		if _, err := time.Parse("2006-01-02", raw); err != nil {
+			return nil, invalid()
+		}
+		return raw, nil
+	case TypeTimestamp:
+		v, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return nil, invalid()
+		}
+		return v, nil
+	case TypeUUID:
+		v, err := uuid.Parse(raw)
+		if err != nil {
+			return nil, invalid()
+		}
+		return v.String(), nil
+	default:
+		return raw, nil
+	}
+}
+
+// compile replaces :name parameters with dialect placeholders and returns the
+// parameter names in placeholder order. It rejects anything but a single
+// SELECT or WITH statement. String literals, quoted identifiers, comments and
+// PostgreSQL :: casts are left untouched.
+func compile(d dialect.Dialect, sql string) (string, []string, error) {
+	var sb strings.Builder
+	names := make([]string, 0)
+	end := len(sql)
+
+	for i := 0; i < end; {
+		c := sql[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`':
+			j := i + 1
+			for j < end && sql[j] != c {
+				j++
+			}
+			if j >= end {
+				return "", nil, apperror.ErrBadRequest.WithMessage("Unterminated quote in SQL")
+			}
+			sb.WriteString(sql[i : j+1])
+			i = j + 1
+
+		case c == '-' && i+1 < end && sql[i+1] == '-':
+			j := strings.IndexByte(sql[i:], '\n')
+			if j < 0 {
+				j = end - i
+			}
+			sb.WriteString(sql[i : i+j])
+			i += j
+
+		case c == '/' && i+1 < end && sql[i+1] == '*':
+			j := strings.Index(sql[i+2:], "*/")
+			if j < 0 {
+				return "", nil, apperror.ErrBadRequest.WithMessage("Unterminated comment in SQL")
+			}
+			sb.WriteString(sql[i : i+j+4])
+			i += j + 4
+
+		case c == ':' && i+1 < end && sql[i+1] == ':':
+			sb.WriteString("::")
+			i += 2
+
+		case c == ':' && i+1 < end && isIdentStart(sql[i+1]):
+			j := i + 1
+			for j < end && isIdentPart(sql[j]) {
+				j++
+			}
+			names = append(names, sql[i+1:j])
+			sb.WriteString(d.Placeholder(len(names)))
+			i = j
+
+		case c == ';':
+			if strings.TrimSpace(sql[i+1:]) != "" {
+				return "", nil, apperror.ErrBadRequest.WithMessage("SQL must be a single statement")
+			}
+			end = i
+
+		default:
+			sb.WriteByte(c)
+			i++
+		}
+	}
+
+	compiled := strings.TrimSpace(sb.String())
+	if !isReadStatement(compiled) {
+		return "", nil, apperror.ErrBadRequest.WithMessage("SQL must be a SELECT or WITH statement")
+	}
+	return compiled, names, nil
+}
+
+// isReadStatement reports whether SQL starts with SELECT or WITH, ignoring
+// leading comments and parentheses.
+func isReadStatement(sql string) bool {
+	for {
+		sql = strings.TrimLeft(sql, " \t\r\n(")
+		switch {
+		case strings.HasPrefix(sql, "--"):
+			i := strings.IndexByte(sql, '\n')
+			if i < 0 {
+				return false
+			}
+			sql = sql[i+1:]
+		case strings.HasPrefix(sql, "/*"):
+			i := strings.Index(sql, "*/")
+			if i < 0 {
+				return false
+			}
+			sql = sql[i+2:]
+		default:
+			words := strings.FieldsFunc(sql, func(r rune) bool {
+				return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z')
+			})
+			if len(words) == 0 {
+				return false
+			}
+			word := strings.ToUpper(words[0])
+			return word == "SELECT" || word == "WITH"
+		}
+	}
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+}
+
+func isIdentPart(c byte) bool {
+	return isIdentStart(c) || c >= '0' && c <= '9'
+}
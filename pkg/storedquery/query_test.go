@@ -0,0 +1,87 @@
+package storedquery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/thienel/tugo/pkg/dialect"
+)
+
+func TestCompile(t *testing.T) {
+	tests := []struct {
+		name      string
+		dialect   dialect.Dialect
+		sql       string
+		wantSQL   string
+		wantNames []string
+		wantErr   bool
+	}{
+		{
+			name:      "postgres placeholders",
+			dialect:   dialect.PostgresDialect{},
+			sql:       "SELECT * FROM orders WHERE status = :status AND total >= :min",
+			wantSQL:   "SELECT * FROM orders WHERE status = $1 AND total >= $2",
+			wantNames: []string{"status", "min"},
+		},
+		{
+			name:      "repeated parameter and cast",
+			dialect:   dialect.PostgresDialect{},
+			sql:       "SELECT :day::date, created_at::date FROM t WHERE (:day IS NULL OR day = :day)",
+			wantSQL:   "SELECT $1::date, created_at::date FROM t WHERE ($2 IS NULL OR day = $3)",
+			wantNames: []string{"day", "day", "day"},
+		},
+		{
+			name:      "literals and comments are untouched",
+			dialect:   dialect.MySQLDialect{},
+			sql:       "SELECT ':a', \"b:c\" /* :d */ FROM t WHERE x = :x -- :e\n;",
+			wantSQL:   "SELECT ':a', \"b:c\" /* :d */ FROM t WHERE x = ? -- :e",
+			wantNames: []string{"x"},
+		},
+		{
+			name:      "with statement",
+			dialect:   dialect.PostgresDialect{},
+			sql:       "-- report\nWITH x AS (SELECT 1) SELECT * FROM x",
+			wantSQL:   "-- report\nWITH x AS (SELECT 1) SELECT * FROM x",
+			wantNames: []string{},
+		},
+		{
+			name:    "multiple statements",
+			dialect: dialect.PostgresDialect{},
+			sql:     "SELECT 1; DROP TABLE orders",
+			wantErr: true,
+		},
+		{
+			name:    "write statement",
+			dialect: dialect.PostgresDialect{},
+			sql:     "DELETE FROM orders",
+			wantErr: true,
+		},
+		{
+			name:    "unterminated literal",
+			dialect: dialect.PostgresDialect{},
+			sql:     "SELECT 'abc",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sql, names, err := compile(tt.dialect, tt.sql)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected error, got SQL %q", sql)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if sql != tt.wantSQL {
+				t.Errorf("expected SQL %q, got %q", tt.wantSQL, sql)
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("expected names %v, got %v", tt.wantNames, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,405 @@
+package storedquery
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Config configures stored query execution.
+type Config struct {
+	// StatementTimeout aborts queries running longer than this.
+	// Default: 0 (no timeout)
+	StatementTimeout time.Duration
+}
+
+// Service registers and runs stored queries.
+type Service struct {
+	db      *sqlx.DB
+	dialect dialect.Dialect
+	store   *Store
+	config  Config
+	logger  *zap.SugaredLogger
+	session func(ctx context.Context, tx *sqlx.Tx) error
+
+	// Queries registered from configuration; these cannot be changed through the API
+	registered map[string]*Query
+}
+
+// NewService creates a new stored query service.
+// A nil store limits the service to queries registered from configuration.
+func NewService(db *sqlx.DB, store *Store, config Config, logger *zap.SugaredLogger) *Service {
+	return &Service{
+		db:         db,
+		dialect:    dialect.ForDriver(db.DriverName()),
+		store:      store,
+		config:     config,
+		logger:     logger,
+		registered: make(map[string]*Query),
+	}
+}
+
+// SetSessionHook sets a hook run at the start of every query transaction.
+func (s *Service) SetSessionHook(hook func(ctx context.Context, tx *sqlx.Tx) error) {
+	s.session = hook
+}
+
+// Register adds a query from configuration.
+func (s *Service) Register(q Query) error {
+	q.Source = SourceConfig
+	if err := q.Validate(); err != nil {
+		return fmt.Errorf("invalid query '%s': %w", q.Name, err)
+	}
+	if _, exists := s.registered[q.Name]; exists {
+		return fmt.Errorf("query '%s' is registered twice", q.Name)
+	}
+	s.registered[q.Name] = &q
+	return nil
+}
+
+// List returns all queries ordered by name.
+func (s *Service) List(ctx context.Context) ([]Query, error) {
+	queries := make([]Query, 0, len(s.registered))
+	for _, q := range s.registered {
+		queries = append(queries, *q)
+	}
+
+	if s.store != nil {
+		stored, err := s.store.List(ctx)
+		if err != nil {
+			return nil, apperror.ErrInternalServer.WithError(err)
+		}
+		for _, q := range stored {
+			if _, shadowed := s.registered[q.Name]; !shadowed {
+				queries = append(queries, q)
+			}
+		}
+	}
+
+	sort.Slice(queries, func(i, j int) bool { return queries[i].Name < queries[j].Name })
+	return queries, nil
+}
+
+// ListAllowed returns the queries the calling user may run.
+func (s *Service) ListAllowed(ctx context.Context) ([]Query, error) {
+	queries, err := s.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	role := userRole(ctx)
+	allowed := make([]Query, 0, len(queries))
+	for _, q := range queries {
+		if q.allows(role) {
+			allowed = append(allowed, q)
+		}
+	}
+	return allowed, nil
+}
+
+// Get returns a query by name. Configured queries take precedence over stored ones.
+func (s *Service) Get(ctx context.Context, name string) (*Query, error) {
+	if q, ok := s.registered[name]; ok {
+		copied := *q
+		return &copied, nil
+	}
+	if s.store == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Query '%s' not found", name)
+	}
+
+	q, err := s.store.Get(ctx, name)
+	if err != nil {
+		return nil, storeError(err)
+	}
+	return q, nil
+}
+
+// Create validates and stores a new query.
+func (s *Service) Create(ctx context.Context, q *Query) (*Query, error) {
+	if err := s.writable(q.Name); err != nil {
+		return nil, err
+	}
+	q.Source = SourceAPI
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Create(ctx, q); err != nil {
+		return nil, storeError(err)
+	}
+	return s.Get(ctx, q.Name)
+}
+
+// Update validates and replaces a stored query.
+func (s *Service) Update(ctx context.Context, q *Query) (*Query, error) {
+	if err := s.writable(q.Name); err != nil {
+		return nil, err
+	}
+	q.Source = SourceAPI
+	if err := q.Validate(); err != nil {
+		return nil, err
+	}
+
+	if err := s.store.Update(ctx, q); err != nil {
+		return nil, storeError(err)
+	}
+	return s.Get(ctx, q.Name)
+}
+
+// Delete removes a stored query.
+func (s *Service) Delete(ctx context.Context, name string) error {
+	if err := s.writable(name); err != nil {
+		return err
+	}
+	return storeError(s.store.Delete(ctx, name))
+}
+
+// Execute runs a query with parameters taken from request values.
+// At most the query's MaxRows rows are returned; Truncated reports whether more exist.
+func (s *Service) Execute(ctx context.Context, name string, values map[string][]string) (*Result, error) {
+	q, err := s.authorize(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	named, err := q.bind(values)
+	if err != nil {
+		return nil, err
+	}
+
+	stmt, names, err := compile(s.dialect, q.SQL)
+	if err != nil {
+		return nil, err
+	}
+	args := make([]any, len(names))
+	for i, n := range names {
+		args[i] = named[n]
+	}
+
+	limit := q.maxRows()
+	result := &Result{Rows: make([]map[string]any, 0)}
+	err = s.readOnly(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		rows, err := tx.QueryxContext(ctx, stmt, args...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		if result.Columns, err = columns(rows); err != nil {
+			return err
+		}
+		for rows.Next() {
+			if len(result.Rows) == limit {
+				result.Truncated = true
+				break
+			}
+			row := make(map[string]any)
+			if err := rows.MapScan(row); err != nil {
+				return err
+			}
+			for k, v := range row {
+				if b, ok := v.([]byte); ok {
+					row[k] = string(b)
+				}
+			}
+			result.Rows = append(result.Rows, row)
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, s.queryError(ctx, q, err)
+	}
+
+	inferColumnTypes(result)
+	return result, nil
+}
+
+// Describe returns the result columns of a query without fetching rows.
+func (s *Service) Describe(ctx context.Context, name string) ([]Column, error) {
+	q, err := s.authorize(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	return s.describe(ctx, q)
+}
+
+// describe runs a query with NULL parameters and no rows to read its columns.
+func (s *Service) describe(ctx context.Context, q *Query) ([]Column, error) {
+	stmt, names, err := compile(s.dialect, q.SQL)
+	if err != nil {
+		return nil, err
+	}
+
+	var cols []Column
+	err = s.readOnly(ctx, func(ctx context.Context, tx *sqlx.Tx) error {
+		rows, err := tx.QueryxContext(ctx, "SELECT * FROM ("+stmt+"\n) tugo_q LIMIT 0", make([]any, len(names))...)
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		cols, err = columns(rows)
+		return err
+	})
+	if err != nil {
+		return nil, s.queryError(ctx, q, err)
+	}
+
+	result := &Result{Columns: cols}
+	inferColumnTypes(result)
+	return result.Columns, nil
+}
+
+// authorize loads a query and checks the calling user's role against it.
+func (s *Service) authorize(ctx context.Context, name string) (*Query, error) {
+	q, err := s.Get(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if !q.allows(userRole(ctx)) {
+		return nil, apperror.ErrForbidden.WithMessagef("Query '%s' is not available to your role", name)
+	}
+	return q, nil
+}
+
+// writable reports an error if a query cannot be changed through the API.
+func (s *Service) writable(name string) error {
+	if s.store == nil {
+		return apperror.ErrBadRequest.WithMessage("Stored queries are not enabled")
+	}
+	if _, ok := s.registered[name]; ok {
+		return apperror.ErrConflict.WithMessagef("Query '%s' is defined in configuration", name)
+	}
+	return nil
+}
+
+// readOnly runs fn in a transaction that is always rolled back, so a statement
+// that slips past the SELECT check cannot persist changes. PostgreSQL and MySQL
+// additionally start the transaction READ ONLY.
+func (s *Service) readOnly(ctx context.Context, fn func(ctx context.Context, tx *sqlx.Tx) error) error {
+	timeout := s.config.StatementTimeout
+	pg := s.dialect.Name() == dialect.Postgres
+	if timeout > 0 && !pg {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+
+	tx, err := s.db.BeginTxx(ctx, &sql.TxOptions{ReadOnly: s.dialect.Name() != dialect.SQLite})
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if s.session != nil {
+		if err := s.session(ctx, tx); err != nil {
+			return err
+		}
+	}
+	if timeout > 0 && pg {
+		if _, err := tx.ExecContext(ctx, fmt.Sprintf("SET LOCAL statement_timeout = %d", timeout.Milliseconds())); err != nil {
+			return err
+		}
+	}
+	return fn(ctx, tx)
+}
+
+// queryError maps an execution failure to an AppError. Database messages are
+// logged rather than returned, as they may reveal the query's SQL.
+func (s *Service) queryError(ctx context.Context, q *Query, err error) error {
+	if apperror.IsAppError(err) {
+		return err
+	}
+
+	msg := err.Error()
+	switch {
+	case errors.Is(err, context.Canceled) || errors.Is(ctx.Err(), context.Canceled):
+		return apperror.ErrRequestCanceled.WithError(err)
+	case errors.Is(err, context.DeadlineExceeded) || errors.Is(ctx.Err(), context.DeadlineExceeded) ||
+		strings.Contains(msg, "57014") || strings.Contains(msg, "Error 3024"):
+		return apperror.ErrTimeout.WithError(err)
+	case strings.Contains(msg, "25006") || strings.Contains(msg, "Error 1792"):
+		return apperror.ErrForbidden.WithMessagef("Query '%s' attempted to modify data", q.Name)
+	}
+
+	requestlog.Logger(ctx, s.logger).Errorw("Stored query failed", "query", q.Name, "error", err)
+	return apperror.ErrInternalServer.WithMessagef("Query '%s' failed", q.Name)
+}
+
+// columns describes the columns of a result set.
+func columns(rows *sqlx.Rows) ([]Column, error) {
+	types, err := rows.ColumnTypes()
+	if err != nil {
+		return nil, err
+	}
+
+	cols := make([]Column, len(types))
+	for i, t := range types {
+		cols[i] = Column{Name: t.Name()}
+		if dbType := strings.ToLower(t.DatabaseTypeName()); dbType != "" {
+			cols[i].Type = schema.MapPostgresType(dbType)
+		}
+	}
+	return cols, nil
+}
+
+// inferColumnTypes fills in types the driver did not report, such as SQLite
+// expressions, from the first non-null value.
+func inferColumnTypes(result *Result) {
+	for i, col := range result.Columns {
+		if col.Type != "" {
+			continue
+		}
+		result.Columns[i].Type = "string"
+		for _, row := range result.Rows {
+			if v := row[col.Name]; v != nil {
+				result.Columns[i].Type = valueType(v)
+				break
+			}
+		}
+	}
+}
+
+// valueType returns the abstract type of a scanned value.
+func valueType(v any) string {
+	switch v.(type) {
+	case int64, int32, int:
+		return "int"
+	case float64, float32:
+		return "float"
+	case bool:
+		return "boolean"
+	case time.Time:
+		return "timestamp"
+	default:
+		return "string"
+	}
+}
+
+// userRole returns the role of the user in the context, if any.
+func userRole(ctx context.Context) string {
+	if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+		return user.Role
+	}
+	return ""
+}
+
+// storeError passes AppErrors through and wraps other store failures.
+func storeError(err error) error {
+	if err == nil || apperror.IsAppError(err) {
+		return err
+	}
+	return apperror.ErrInternalServer.WithError(err)
+}
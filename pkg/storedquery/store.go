@@ -0,0 +1,177 @@
+package storedquery
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Store persists queries registered through the admin API in tugo_queries.
+type Store struct {
+	db *sqlx.DB
+}
+
+// NewStore creates a new query store.
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// record is a row of tugo_queries.
+type record struct {
+	Name        string `db:"name"`
+	Description string `db:"description"`
+	Statement   string `db:"statement"`
+	Params      []byte `db:"params"`
+	Roles       []byte `db:"roles"`
+	MaxRows     int    `db:"max_rows"`
+}
+
+// List returns the stored queries ordered by name.
+func (s *Store) List(ctx context.Context) ([]Query, error) {
+	query := `
+		SELECT name, description, statement, params, roles, max_rows
+		FROM tugo_queries
+		ORDER BY name
+	`
+	records := make([]record, 0)
+	if err := s.db.SelectContext(ctx, &records, query); err != nil {
+		return nil, fmt.Errorf("failed to list queries: %w", err)
+	}
+
+	queries := make([]Query, 0, len(records))
+	for _, r := range records {
+		q, err := r.decode()
+		if err != nil {
+			return nil, err
+		}
+		queries = append(queries, *q)
+	}
+	return queries, nil
+}
+
+// Get returns a stored query by name.
+func (s *Store) Get(ctx context.Context, name string) (*Query, error) {
+	query := `
+		SELECT name, description, statement, params, roles, max_rows
+		FROM tugo_queries
+		WHERE name = ?
+	`
+	var r record
+	if err := s.db.GetContext(ctx, &r, s.db.Rebind(query), name); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Query '%s' not found", name)
+		}
+		return nil, fmt.Errorf("failed to get query: %w", err)
+	}
+	return r.decode()
+}
+
+// Create stores a new query.
+func (s *Store) Create(ctx context.Context, q *Query) error {
+	params, roles, err := encode(q)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO tugo_queries (name, description, statement, params, roles, max_rows)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+	if _, err := s.db.ExecContext(ctx, s.db.Rebind(query), q.Name, q.Description, q.SQL, params, roles, q.MaxRows); err != nil {
+		if isDuplicateKeyError(err) {
+			return apperror.ErrConflict.WithMessagef("Query '%s' already exists", q.Name)
+		}
+		return fmt.Errorf("failed to create query: %w", err)
+	}
+	return nil
+}
+
+// Update replaces a stored query.
+func (s *Store) Update(ctx context.Context, q *Query) error {
+	params, roles, err := encode(q)
+	if err != nil {
+		return err
+	}
+
+	query := `
+		UPDATE tugo_queries
+		SET description = ?, statement = ?, params = ?, roles = ?, max_rows = ?, updated_at = CURRENT_TIMESTAMP
+		WHERE name = ?
+	`
+	res, err := s.db.ExecContext(ctx, s.db.Rebind(query), q.Description, q.SQL, params, roles, q.MaxRows, q.Name)
+	if err != nil {
+		return fmt.Errorf("failed to update query: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("Query '%s' not found", q.Name)
+	}
+	return nil
+}
+
+// Delete removes a stored query.
+func (s *Store) Delete(ctx context.Context, name string) error {
+	res, err := s.db.ExecContext(ctx, s.db.Rebind("DELETE FROM tugo_queries WHERE name = ?"), name)
+	if err != nil {
+		return fmt.Errorf("failed to delete query: %w", err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return apperror.ErrNotFound.WithMessagef("Query '%s' not found", name)
+	}
+	return nil
+}
+
+// encode marshals a query's parameters and roles for storage.
+func encode(q *Query) (string, string, error) {
+	params := q.Params
+	if params == nil {
+		params = []Param{}
+	}
+	paramsJSON, err := json.Marshal(params)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode query params: %w", err)
+	}
+	roles := q.Roles
+	if roles == nil {
+		roles = []string{}
+	}
+	rolesJSON, err := json.Marshal(roles)
+	if err != nil {
+		return "", "", fmt.Errorf("failed to encode query roles: %w", err)
+	}
+	return string(paramsJSON), string(rolesJSON), nil
+}
+
+// decode converts a stored row to a query.
+func (r record) decode() (*Query, error) {
+	q := &Query{
+		Name:        r.Name,
+		Description: r.Description,
+		SQL:         r.Statement,
+		MaxRows:     r.MaxRows,
+		Source:      SourceAPI,
+	}
+	if len(r.Params) > 0 {
+		if err := json.Unmarshal(r.Params, &q.Params); err != nil {
+			return nil, fmt.Errorf("failed to decode query '%s' params: %w", r.Name, err)
+		}
+	}
+	if len(r.Roles) > 0 {
+		if err := json.Unmarshal(r.Roles, &q.Roles); err != nil {
+			return nil, fmt.Errorf("failed to decode query '%s' roles: %w", r.Name, err)
+		}
+	}
+	return q, nil
+}
+
+// isDuplicateKeyError checks if an error is a unique constraint violation.
+func isDuplicateKeyError(err error) bool {
+	msg := err.Error()
+	return strings.Contains(msg, "23505") || strings.Contains(msg, "duplicate key") ||
+		strings.Contains(msg, "Error 1062") || strings.Contains(msg, "UNIQUE constraint failed")
+}
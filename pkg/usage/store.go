@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Row is the usage of one collection in one bucket.
+type Row struct {
+	Start      time.Time
+	Collection string
+	Counts     Counts
+}
+
+// Store persists finished buckets in tugo_usage.
+type Store struct {
+	db *sqlx.DB
+}
+
+// NewStore creates a new usage store.
+func NewStore(db *sqlx.DB) *Store {
+	return &Store{db: db}
+}
+
+// record is a row of tugo_usage.
+type record struct {
+	BucketStart  time.Time `db:"bucket_start"`
+	Collection   string    `db:"collection"`
+	Requests     int64     `db:"requests"`
+	Errors       int64     `db:"errors"`
+	ClientErrors int64     `db:"client_errors"`
+	Timeouts     int64     `db:"timeouts"`
+	Latency      []byte    `db:"latency"`
+}
+
+// Save inserts rows in one transaction.
+func (s *Store) Save(ctx context.Context, rows []Row) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	query := tx.Rebind(`
+		INSERT INTO tugo_usage (bucket_start, collection, requests, errors, client_errors, timeouts, latency)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`)
+	for _, row := range rows {
+		latency, err := json.Marshal(row.Counts.Latency)
+		if err != nil {
+			return fmt.Errorf("failed to encode latency: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, row.Start.UTC(), row.Collection,
+			row.Counts.Requests, row.Counts.Errors, row.Counts.ClientErrors, row.Counts.Timeouts, string(latency)); err != nil {
+			return fmt.Errorf("failed to save usage: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// Load returns the rows of buckets starting at or after since. Rows saved
+// by several instances for the same bucket are all returned.
+func (s *Store) Load(ctx context.Context, since time.Time) ([]Row, error) {
+	query := `
+		SELECT bucket_start, collection, requests, errors, client_errors, timeouts, latency
+		FROM tugo_usage
+		WHERE bucket_start >= ?
+	`
+	var records []record
+	if err := s.db.SelectContext(ctx, &records, s.db.Rebind(query), since.UTC()); err != nil {
+		return nil, fmt.Errorf("failed to load usage: %w", err)
+	}
+
+	rows := make([]Row, 0, len(records))
+	for _, r := range records {
+		row := Row{
+			Start:      r.BucketStart,
+			Collection: r.Collection,
+			Counts:     Counts{Requests: r.Requests, Errors: r.Errors, ClientErrors: r.ClientErrors, Timeouts: r.Timeouts},
+		}
+		if err := json.Unmarshal(r.Latency, &row.Counts.Latency); err != nil {
+			return nil, fmt.Errorf("failed to decode latency: %w", err)
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
@@ -0,0 +1,385 @@
+// Package usage tracks request counts, error rates, timeouts and latencies
+// of the generated collection endpoints in time buckets.
+package usage
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"go.uber.org/zap"
+)
+
+// Defaults for Config.
+const (
+	DefaultBucket    = time.Minute
+	DefaultRetention = 24 * time.Hour
+)
+
+// latencyBounds are the upper bounds in milliseconds of the latency
+// histogram; a last, open bucket holds slower requests.
+var latencyBounds = []float64{1, 2, 5, 10, 25, 50, 100, 250, 500, 1000, 2500, 5000, 10000}
+
+// Config configures usage tracking.
+type Config struct {
+	// Enabled turns on tracking and GET /admin/usage.
+	Enabled bool
+
+	// Bucket is the width of the time buckets.
+	// Default: 1 minute
+	Bucket time.Duration
+
+	// Retention is how long buckets are kept in memory.
+	// Default: 24 hours
+	Retention time.Duration
+
+	// Persist writes finished buckets to tugo_usage and loads the retained
+	// ones on start, so history survives restarts.
+	Persist bool
+}
+
+// Counts are the requests to one collection in a bucket.
+type Counts struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ClientErrors int64   `json:"client_errors"`
+	Timeouts     int64   `json:"timeouts"`
+	Latency      []int64 `json:"latency"`
+}
+
+// add merges other into c.
+func (c *Counts) add(other *Counts) {
+	c.Requests += other.Requests
+	c.Errors += other.Errors
+	c.ClientErrors += other.ClientErrors
+	c.Timeouts += other.Timeouts
+	if c.Latency == nil {
+		c.Latency = make([]int64, len(latencyBounds)+1)
+	}
+	for i, n := range other.Latency {
+		if i < len(c.Latency) {
+			c.Latency[i] += n
+		}
+	}
+}
+
+// Stats summarizes Counts.
+type Stats struct {
+	Requests     int64   `json:"requests"`
+	Errors       int64   `json:"errors"`
+	ClientErrors int64   `json:"client_errors"`
+	Timeouts     int64   `json:"timeouts"`
+	ErrorRate    float64 `json:"error_rate"`
+	TimeoutRate  float64 `json:"timeout_rate"`
+	// P95Ms is the upper bound of the latency histogram bucket holding the
+	// 95th percentile, or -1 when it is above the last bound.
+	P95Ms float64 `json:"p95_ms"`
+}
+
+// stats summarizes the counts.
+func (c *Counts) stats() Stats {
+	s := Stats{Requests: c.Requests, Errors: c.Errors, ClientErrors: c.ClientErrors, Timeouts: c.Timeouts}
+	if c.Requests == 0 {
+		return s
+	}
+	s.ErrorRate = float64(c.Errors) / float64(c.Requests)
+	s.TimeoutRate = float64(c.Timeouts) / float64(c.Requests)
+
+	target := (c.Requests*95 + 99) / 100
+	var seen int64
+	for i, n := range c.Latency {
+		seen += n
+		if seen >= target {
+			if i < len(latencyBounds) {
+				s.P95Ms = latencyBounds[i]
+			} else {
+				s.P95Ms = -1
+			}
+			break
+		}
+	}
+	return s
+}
+
+// bucket holds the counts of one time slot. pending holds the counts not
+// yet persisted.
+type bucket struct {
+	start   time.Time
+	counts  map[string]*Counts
+	pending map[string]*Counts
+}
+
+// Tracker records collection requests in a ring of time buckets.
+type Tracker struct {
+	bucket    time.Duration
+	retention time.Duration
+	store     *Store
+	known     func(collection string) bool
+	logger    *zap.SugaredLogger
+
+	mu   sync.Mutex
+	ring []bucket
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewTracker creates a tracker. Only requests to collections for which
+// known returns true are recorded. A nil store disables persistence.
+func NewTracker(config Config, store *Store, known func(collection string) bool, logger *zap.SugaredLogger) *Tracker {
+	if config.Bucket <= 0 {
+		config.Bucket = DefaultBucket
+	}
+	if config.Retention <= 0 {
+		config.Retention = DefaultRetention
+	}
+	size := int(config.Retention / config.Bucket)
+	if size < 1 {
+		size = 1
+	}
+	return &Tracker{
+		bucket:    config.Bucket,
+		retention: config.Retention,
+		store:     store,
+		known:     known,
+		logger:    logger,
+		ring:      make([]bucket, size),
+	}
+}
+
+// Middleware records the requests of the routes it wraps by their
+// :collection parameter.
+func (t *Tracker) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		collection := c.Param("collection")
+		if collection == "" || (t.known != nil && !t.known(collection)) {
+			return
+		}
+		t.Record(collection, c.Writer.Status(), time.Since(start), start)
+	}
+}
+
+// Record adds a request to the bucket of at.
+func (t *Tracker) Record(collection string, status int, elapsed time.Duration, at time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	b := t.slot(at.Truncate(t.bucket))
+	b.counts = observe(b.counts, collection, status, elapsed)
+	if t.store != nil {
+		b.pending = observe(b.pending, collection, status, elapsed)
+	}
+}
+
+// observe adds a request to the counts of collection in m, creating them.
+func observe(m map[string]*Counts, collection string, status int, elapsed time.Duration) map[string]*Counts {
+	if m == nil {
+		m = make(map[string]*Counts)
+	}
+	counts, ok := m[collection]
+	if !ok {
+		counts = &Counts{Latency: make([]int64, len(latencyBounds)+1)}
+		m[collection] = counts
+	}
+	counts.Requests++
+	if status == http.StatusGatewayTimeout {
+		counts.Timeouts++
+	}
+	switch {
+	case status >= http.StatusInternalServerError:
+		counts.Errors++
+	case status >= http.StatusBadRequest:
+		counts.ClientErrors++
+	}
+	ms := float64(elapsed) / float64(time.Millisecond)
+	counts.Latency[sort.SearchFloat64s(latencyBounds, ms)]++
+	return m
+}
+
+// slot returns the ring bucket for start, clearing it when it held an
+// older time. Callers hold t.mu.
+func (t *Tracker) slot(start time.Time) *bucket {
+	b := &t.ring[int(start.UnixNano()/int64(t.bucket))%len(t.ring)]
+	if !b.start.Equal(start) {
+		b.start = start
+		b.counts = nil
+		b.pending = nil
+	}
+	return b
+}
+
+// Start loads persisted buckets and begins flushing finished ones. It does
+// nothing without a store.
+func (t *Tracker) Start(ctx context.Context) error {
+	if t.store == nil {
+		return nil
+	}
+
+	now := time.Now()
+	since := now.Truncate(t.bucket).Add(-time.Duration(len(t.ring)-1) * t.bucket)
+	rows, err := t.store.Load(ctx, since)
+	if err != nil {
+		return err
+	}
+	t.mu.Lock()
+	for _, row := range rows {
+		b := t.slot(row.Start.Truncate(t.bucket))
+		if b.counts == nil {
+			b.counts = make(map[string]*Counts)
+		}
+		merge(b.counts, row.Collection, &row.Counts)
+	}
+	t.mu.Unlock()
+
+	t.stop = make(chan struct{})
+	t.done = make(chan struct{})
+	go t.flushLoop()
+	return nil
+}
+
+// Close persists all pending counts and stops flushing.
+func (t *Tracker) Close() {
+	if t.stop == nil {
+		return
+	}
+	close(t.stop)
+	<-t.done
+	t.stop = nil
+}
+
+// flushLoop writes finished buckets once per bucket width.
+func (t *Tracker) flushLoop() {
+	defer close(t.done)
+	ticker := time.NewTicker(t.bucket)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			t.flush(time.Now().Truncate(t.bucket))
+		case <-t.stop:
+			t.flush(time.Time{})
+			return
+		}
+	}
+}
+
+// flush persists the pending counts of buckets starting before the given
+// time, or of all buckets when it is zero. Rows are increments: a bucket
+// flushed twice, or by several instances, adds up on load.
+func (t *Tracker) flush(before time.Time) {
+	t.mu.Lock()
+	var rows []Row
+	for i := range t.ring {
+		b := &t.ring[i]
+		if len(b.pending) == 0 || (!before.IsZero() && !b.start.Before(before)) {
+			continue
+		}
+		for collection, counts := range b.pending {
+			rows = append(rows, Row{Start: b.start, Collection: collection, Counts: *counts})
+		}
+		b.pending = nil
+	}
+	t.mu.Unlock()
+
+	if len(rows) == 0 {
+		return
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := t.store.Save(ctx, rows); err != nil {
+		t.logger.Errorw("Failed to persist usage", "buckets", len(rows), "error", err)
+	}
+}
+
+// BucketReport holds the stats of one reported time step.
+type BucketReport struct {
+	Start       time.Time        `json:"start"`
+	Collections map[string]Stats `json:"collections"`
+}
+
+// Report is the usage of collections over a time range.
+type Report struct {
+	Since  time.Time        `json:"since"`
+	Step   string           `json:"step"`
+	Totals map[string]Stats `json:"totals"`
+	// Buckets lists the steps with requests, oldest first.
+	Buckets []BucketReport `json:"buckets"`
+}
+
+// Report aggregates the buckets since the given time into steps, rounded
+// up to a multiple of the bucket width and aligned to multiples of it. A non-empty collection limits the
+// report to it.
+func (t *Tracker) Report(since time.Time, step time.Duration, collection string) *Report {
+	if step < t.bucket {
+		step = t.bucket
+	}
+	step = (step + t.bucket - 1) / t.bucket * t.bucket
+	since = since.Truncate(t.bucket)
+	// slots not written to since a full turn of the ring are stale
+	oldest := time.Now().Truncate(t.bucket).Add(-time.Duration(len(t.ring)-1) * t.bucket)
+
+	totals := make(map[string]*Counts)
+	steps := make(map[time.Time]map[string]*Counts)
+
+	t.mu.Lock()
+	for i := range t.ring {
+		b := &t.ring[i]
+		if b.start.IsZero() || b.start.Before(since) || b.start.Before(oldest) {
+			continue
+		}
+		key := b.start.Truncate(step)
+		for name, counts := range b.counts {
+			if collection != "" && name != collection {
+				continue
+			}
+			merge(totals, name, counts)
+			if steps[key] == nil {
+				steps[key] = make(map[string]*Counts)
+			}
+			merge(steps[key], name, counts)
+		}
+	}
+	t.mu.Unlock()
+
+	report := &Report{Since: since, Step: step.String(), Totals: summarize(totals), Buckets: make([]BucketReport, 0, len(steps))}
+	for start, counts := range steps {
+		report.Buckets = append(report.Buckets, BucketReport{Start: start, Collections: summarize(counts)})
+	}
+	sort.Slice(report.Buckets, func(i, j int) bool {
+		return report.Buckets[i].Start.Before(report.Buckets[j].Start)
+	})
+	return report
+}
+
+// Bucket returns the bucket width.
+func (t *Tracker) Bucket() time.Duration {
+	return t.bucket
+}
+
+// Retention returns how long buckets are kept.
+func (t *Tracker) Retention() time.Duration {
+	return t.retention
+}
+
+// merge adds counts to the entry of name in m.
+func merge(m map[string]*Counts, name string, counts *Counts) {
+	if m[name] == nil {
+		m[name] = &Counts{}
+	}
+	m[name].add(counts)
+}
+
+// summarize turns counts into stats.
+func summarize(m map[string]*Counts) map[string]Stats {
+	stats := make(map[string]Stats, len(m))
+	for name, counts := range m {
+		stats[name] = counts.stats()
+	}
+	return stats
+}
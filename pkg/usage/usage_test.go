@@ -0,0 +1,91 @@
+package usage
+
+import (
+	"testing"
+	"time"
+)
+
+func TestCountsStats(t *testing.T) {
+	// latency buckets: <=1ms, <=2ms, <=5ms, ... , >10s
+	histogram := func(counts map[int]int64) []int64 {
+		latency := make([]int64, len(latencyBounds)+1)
+		for i, n := range counts {
+			latency[i] = n
+		}
+		return latency
+	}
+
+	tests := []struct {
+		name      string
+		counts    Counts
+		wantP95   float64
+		wantError float64
+		wantRate  float64
+	}{
+		{"empty", Counts{}, 0, 0, 0},
+		{"all fast", Counts{Requests: 10, Latency: histogram(map[int]int64{0: 10})}, 1, 0, 0},
+		{"slow tail", Counts{Requests: 100, Errors: 5, Timeouts: 2, Latency: histogram(map[int]int64{0: 94, 6: 6})}, 100, 0.05, 0.02},
+		{"under tail", Counts{Requests: 100, Latency: histogram(map[int]int64{0: 95, 6: 5})}, 1, 0, 0},
+		{"above bounds", Counts{Requests: 1, Latency: histogram(map[int]int64{len(latencyBounds): 1})}, -1, 0, 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tt.counts.stats()
+			if got.P95Ms != tt.wantP95 {
+				t.Errorf("P95Ms = %v, want %v", got.P95Ms, tt.wantP95)
+			}
+			if got.ErrorRate != tt.wantError {
+				t.Errorf("ErrorRate = %v, want %v", got.ErrorRate, tt.wantError)
+			}
+			if got.TimeoutRate != tt.wantRate {
+				t.Errorf("TimeoutRate = %v, want %v", got.TimeoutRate, tt.wantRate)
+			}
+		})
+	}
+}
+
+func TestTrackerReport(t *testing.T) {
+	tracker := NewTracker(Config{Bucket: time.Minute, Retention: time.Hour}, nil, nil, nil)
+	now := time.Now().Truncate(time.Minute)
+
+	tracker.Record("posts", 200, 3*time.Millisecond, now.Add(-30*time.Minute))
+	tracker.Record("posts", 500, 40*time.Millisecond, now.Add(-30*time.Minute))
+	tracker.Record("posts", 504, 5*time.Second, now.Add(-30*time.Minute))
+	tracker.Record("posts", 404, time.Millisecond, now)
+	tracker.Record("users", 200, time.Millisecond, now)
+
+	report := tracker.Report(now.Add(-time.Hour), 10*time.Minute, "")
+	posts := report.Totals["posts"]
+	if posts.Requests != 4 || posts.Errors != 2 || posts.ClientErrors != 1 || posts.Timeouts != 1 {
+		t.Errorf("posts totals = %+v", posts)
+	}
+	if len(report.Buckets) != 2 {
+		t.Fatalf("got %d buckets, want 2", len(report.Buckets))
+	}
+	if !report.Buckets[0].Start.Before(report.Buckets[1].Start) {
+		t.Error("buckets are not ordered oldest first")
+	}
+
+	report = tracker.Report(now.Add(-10*time.Minute), time.Minute, "posts")
+	if _, ok := report.Totals["users"]; ok {
+		t.Error("report includes a collection it was not asked for")
+	}
+	if got := report.Totals["posts"].Requests; got != 1 {
+		t.Errorf("posts requests since 10m = %d, want 1", got)
+	}
+}
+
+func TestTrackerRingReuse(t *testing.T) {
+	tracker := NewTracker(Config{Bucket: time.Minute, Retention: 10 * time.Minute}, nil, nil, nil)
+	now := time.Now().Truncate(time.Minute)
+
+	// the same slot a full turn of the ring apart
+	tracker.Record("posts", 200, time.Millisecond, now.Add(-10*time.Minute))
+	tracker.Record("posts", 200, time.Millisecond, now)
+
+	report := tracker.Report(now.Add(-time.Hour), time.Minute, "")
+	if got := report.Totals["posts"].Requests; got != 1 {
+		t.Errorf("requests = %d, want 1", got)
+	}
+}
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"net/mail"
 	"reflect"
@@ -419,6 +420,8 @@ func toFloat64(value interface{}) (float64, error) {
 		return float64(v), nil
 	case float64:
 		return v, nil
+	case json.Number:
+		return v.Float64()
 	default:
 		return 0, fmt.Errorf("not a number")
 	}
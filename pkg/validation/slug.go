@@ -0,0 +1,70 @@
+package validation
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// MaxSlugAttempts bounds the suffixes UniqueSlug tries before giving up.
+const MaxSlugAttempts = 100
+
+// slugReplacements transliterates letters that do not decompose into an
+// ASCII base letter and a mark.
+var slugReplacements = map[rune]string{
+	'đ': "d", 'Đ': "d", 'ð': "d", 'Ð': "d",
+	'ł': "l", 'Ł': "l",
+	'ø': "o", 'Ø': "o",
+	'ß': "ss",
+	'æ': "ae", 'Æ': "ae",
+	'œ': "oe", 'Œ': "oe",
+	'þ': "th", 'Þ': "th",
+}
+
+// Slugify turns s into a lowercase URL slug: accents are removed, ASCII
+// letters and digits are kept and every other run becomes one hyphen.
+func Slugify(s string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range norm.NFD.String(s) {
+		if unicode.Is(unicode.Mn, r) {
+			continue
+		}
+		if rep, ok := slugReplacements[r]; ok {
+			b.WriteString(rep)
+			hyphen = false
+			continue
+		}
+		r = unicode.ToLower(r)
+		if r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			b.WriteRune(r)
+			hyphen = false
+			continue
+		}
+		if !hyphen && b.Len() > 0 {
+			b.WriteByte('-')
+			hyphen = true
+		}
+	}
+	return strings.TrimSuffix(b.String(), "-")
+}
+
+// UniqueSlug returns base, or base with the first numeric suffix from -2
+// on, that is not yet used in the column.
+func UniqueSlug(ctx context.Context, checker UniqueChecker, table, column, base string) (string, error) {
+	candidate := base
+	for n := 2; n <= MaxSlugAttempts+1; n++ {
+		unique, err := checker.IsUnique(ctx, table, column, candidate, nil)
+		if err != nil {
+			return "", fmt.Errorf("failed to check slug: %w", err)
+		}
+		if unique {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, n)
+	}
+	return "", fmt.Errorf("no unique slug for %q after %d attempts", base, MaxSlugAttempts)
+}
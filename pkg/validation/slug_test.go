@@ -0,0 +1,61 @@
+package validation
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"simple", "Hello World", "hello-world"},
+		{"accents", "Crème Brûlée", "creme-brulee"},
+		{"vietnamese", "Đường phố Hà Nội", "duong-pho-ha-noi"},
+		{"german", "Straße", "strasse"},
+		{"punctuation runs", "  Go -- 1.25!  ", "go-1-25"},
+		{"digits", "Top 10 tips", "top-10-tips"},
+		{"no ascii", "日本語", ""},
+		{"empty", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Slugify(tt.input); got != tt.want {
+				t.Errorf("Slugify(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+type takenChecker map[string]bool
+
+func (c takenChecker) IsUnique(ctx context.Context, table, column string, value interface{}, excludeID interface{}) (bool, error) {
+	return !c[value.(string)], nil
+}
+
+func TestUniqueSlug(t *testing.T) {
+	tests := []struct {
+		name  string
+		taken takenChecker
+		want  string
+	}{
+		{"free", takenChecker{}, "post"},
+		{"taken", takenChecker{"post": true}, "post-2"},
+		{"several taken", takenChecker{"post": true, "post-2": true, "post-3": true}, "post-4"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := UniqueSlug(context.Background(), tt.taken, "posts", "slug", "post")
+			if err != nil {
+				t.Fatalf("UniqueSlug() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("UniqueSlug() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -3,8 +3,11 @@ package validation
 import (
 	"context"
 	"fmt"
+	"sort"
 
 	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/query"
 )
 
 // UniqueChecker is an interface for checking uniqueness.
@@ -15,35 +18,73 @@ type UniqueChecker interface {
 // DBUniqueChecker implements UniqueChecker using sqlx.
 type DBUniqueChecker struct {
 	db        *sqlx.DB
+	dialect   dialect.Dialect
 	idColumn  string
 }
 
-// NewDBUniqueChecker creates a new database unique checker.
+// NewDBUniqueChecker creates a new database unique checker. Its SQL dialect
+// is derived from the connection's driver name.
 func NewDBUniqueChecker(db *sqlx.DB, idColumn string) *DBUniqueChecker {
 	if idColumn == "" {
 		idColumn = "id"
 	}
 	return &DBUniqueChecker{
 		db:       db,
+		dialect:  dialect.ForDriver(db.DriverName()),
 		idColumn: idColumn,
 	}
 }
 
+// WithDialect sets the SQL dialect of the checker's queries.
+func (c *DBUniqueChecker) WithDialect(d dialect.Dialect) *DBUniqueChecker {
+	c.dialect = d
+	return c
+}
+
 // IsUnique checks if a value is unique in the database.
 func (c *DBUniqueChecker) IsUnique(ctx context.Context, table, column string, value interface{}, excludeID interface{}) (bool, error) {
 	var count int
-	var query string
+	var countSQL string
 	var args []interface{}
 
 	if excludeID != nil {
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1 AND %s != $2", table, column, c.idColumn)
+		countSQL = query.BuildCountDialect(c.dialect, table, []string{column}, c.idColumn)
 		args = []interface{}{value, excludeID}
 	} else {
-		query = fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", table, column)
+		countSQL = query.BuildCountDialect(c.dialect, table, []string{column}, "")
 		args = []interface{}{value}
 	}
 
-	err := c.db.GetContext(ctx, &count, query, args...)
+	err := c.db.GetContext(ctx, &count, countSQL, args...)
+	if err != nil {
+		return false, err
+	}
+
+	return count == 0, nil
+}
+
+// IsUniqueTogether checks if a combination of column values is unique in
+// the database.
+func (c *DBUniqueChecker) IsUniqueTogether(ctx context.Context, table string, values map[string]interface{}, excludeID interface{}) (bool, error) {
+	columns := make([]string, 0, len(values))
+	for column := range values {
+		columns = append(columns, column)
+	}
+	sort.Strings(columns)
+
+	args := make([]interface{}, 0, len(columns)+1)
+	for _, column := range columns {
+		args = append(args, values[column])
+	}
+	excludeColumn := ""
+	if excludeID != nil {
+		excludeColumn = c.idColumn
+		args = append(args, excludeID)
+	}
+
+	var count int
+	countSQL := query.BuildCountDialect(c.dialect, table, columns, excludeColumn)
+	err := c.db.GetContext(ctx, &count, countSQL, args...)
 	if err != nil {
 		return false, err
 	}
@@ -118,8 +159,8 @@ func (e *Exists) Validate(ctx context.Context, value interface{}) error {
 	}
 
 	var count int
-	query := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s = $1", e.table, e.column)
-	err := e.db.GetContext(ctx, &count, query, value)
+	countSQL := query.BuildCountDialect(dialect.ForDriver(e.db.DriverName()), e.table, []string{e.column}, "")
+	err := e.db.GetContext(ctx, &count, countSQL, value)
 	if err != nil {
 		return fmt.Errorf("failed to check existence: %w", err)
 	}
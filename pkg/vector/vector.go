@@ -0,0 +1,114 @@
+// Package vector reads and writes the values of pgvector columns and
+// computes embeddings for them through a pluggable Embedder.
+package vector
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Vector is an embedding, the value of a pgvector column.
+type Vector []float32
+
+// Parse parses a vector in pgvector's text form, such as "[0.1,0.2,0.3]".
+func Parse(s string) (Vector, error) {
+	s = strings.TrimSpace(s)
+	inner, ok := strings.CutPrefix(s, "[")
+	if inner, ok = strings.CutSuffix(inner, "]"); !ok {
+		return nil, fmt.Errorf("'%s' is not a vector; use [x,y,...]", s)
+	}
+	if strings.TrimSpace(inner) == "" {
+		return nil, fmt.Errorf("vector must have at least one dimension")
+	}
+
+	parts := strings.Split(inner, ",")
+	v := make(Vector, len(parts))
+	for i, part := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(part), 32)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("'%s' is not a number", strings.TrimSpace(part))
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// FromValue converts a decoded JSON value, a list of numbers or a string in
+// pgvector's text form, to a vector.
+func FromValue(value any) (Vector, error) {
+	switch val := value.(type) {
+	case Vector:
+		return val, nil
+	case []float32:
+		return Vector(val), nil
+	case []float64:
+		v := make(Vector, len(val))
+		for i, f := range val {
+			v[i] = float32(f)
+		}
+		return v, nil
+	case string:
+		return Parse(val)
+	case []byte:
+		return Parse(string(val))
+	case []any:
+		if len(val) == 0 {
+			return nil, fmt.Errorf("vector must have at least one dimension")
+		}
+		v := make(Vector, len(val))
+		for i, item := range val {
+			var f float64
+			var err error
+			switch n := item.(type) {
+			case json.Number:
+				f, err = n.Float64()
+			case float64:
+				f = n
+			case int:
+				f = float64(n)
+			case int64:
+				f = float64(n)
+			default:
+				err = fmt.Errorf("not a number")
+			}
+			if err != nil {
+				return nil, fmt.Errorf("element %d is not a number", i)
+			}
+			v[i] = float32(f)
+		}
+		return v, nil
+	}
+	return nil, fmt.Errorf("must be a list of numbers")
+}
+
+// String returns the vector in pgvector's text form.
+func (v Vector) String() string {
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for i, f := range v {
+		if i > 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteString(strconv.FormatFloat(float64(f), 'g', -1, 32))
+	}
+	sb.WriteByte(']')
+	return sb.String()
+}
+
+// Embedder computes embeddings of texts, such as a client of the OpenAI or
+// a local model's embeddings API. It returns one vector per text, in order.
+type Embedder interface {
+	Embed(ctx context.Context, texts []string) ([]Vector, error)
+}
+
+// EmbedderFunc adapts a function to an Embedder.
+type EmbedderFunc func(ctx context.Context, texts []string) ([]Vector, error)
+
+// Embed calls f.
+func (f EmbedderFunc) Embed(ctx context.Context, texts []string) ([]Vector, error) {
+	return f(ctx, texts)
+}
@@ -0,0 +1,72 @@
+package vector
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    Vector
+		wantErr bool
+	}{
+		{"simple", "[0.1,0.2,0.3]", Vector{0.1, 0.2, 0.3}, false},
+		{"spaces", " [1, -2 ,3e2] ", Vector{1, -2, 300}, false},
+		{"no brackets", "1,2", nil, true},
+		{"empty", "[]", nil, true},
+		{"not a number", "[1,a]", nil, true},
+		{"nan", "[NaN]", nil, true},
+		{"inf", "[1,Inf]", nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Parse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFromValue(t *testing.T) {
+	tests := []struct {
+		name    string
+		value   any
+		want    Vector
+		wantErr bool
+	}{
+		{"json numbers", []any{json.Number("0.5"), json.Number("2")}, Vector{0.5, 2}, false},
+		{"floats", []any{0.5, 2.0}, Vector{0.5, 2}, false},
+		{"float64 slice", []float64{1, 2}, Vector{1, 2}, false},
+		{"text form", "[1,2]", Vector{1, 2}, false},
+		{"scanned bytes", []byte("[1,2]"), Vector{1, 2}, false},
+		{"empty list", []any{}, nil, true},
+		{"string element", []any{"1"}, nil, true},
+		{"object", map[string]any{}, nil, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := FromValue(tt.value)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("FromValue() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("FromValue() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVector_String(t *testing.T) {
+	if got, want := (Vector{0.1, -2, 3e-5}).String(), "[0.1,-2,3e-05]"; got != want {
+		t.Errorf("String() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,98 @@
+package webhook
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo/pkg/apperror"
+)
+
+// Delivery is one attempt to send an event to a webhook. RedeliveryOf is
+// set on manual redeliveries to the ID of the delivery they repeat.
+type Delivery struct {
+	ID           string          `db:"id" json:"id"`
+	WebhookID    string          `db:"webhook_id" json:"webhook_id"`
+	Event        string          `db:"event" json:"event"`
+	Collection   string          `db:"collection" json:"collection"`
+	Payload      json.RawMessage `db:"-" json:"payload"`
+	StatusCode   int             `db:"status_code" json:"status_code"`
+	Response     string          `db:"response" json:"response"`
+	Error        string          `db:"error" json:"error,omitempty"`
+	LatencyMs    int64           `db:"latency_ms" json:"latency_ms"`
+	RedeliveryOf *string         `db:"redelivery_of" json:"redelivery_of,omitempty"`
+	CreatedAt    time.Time       `db:"created_at" json:"created_at"`
+
+	RawPayload []byte `db:"payload" json:"-"`
+}
+
+// Succeeded reports whether the endpoint accepted the delivery.
+func (d *Delivery) Succeeded() bool {
+	return d.Error == "" && d.StatusCode >= 200 && d.StatusCode <= 299
+}
+
+// DeliveryStore persists delivery history in tugo_webhook_deliveries.
+type DeliveryStore struct {
+	db *sqlx.DB
+}
+
+// NewDeliveryStore creates a new delivery store.
+func NewDeliveryStore(db *sqlx.DB) *DeliveryStore {
+	return &DeliveryStore{db: db}
+}
+
+// Record stores a delivery.
+func (s *DeliveryStore) Record(ctx context.Context, d *Delivery) error {
+	query := `
+		INSERT INTO tugo_webhook_deliveries
+			(id, webhook_id, event, collection, payload, status_code, response, error, latency_ms, redelivery_of, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+	_, err := s.db.ExecContext(ctx, s.db.Rebind(query),
+		d.ID, d.WebhookID, d.Event, d.Collection, string(d.Payload), d.StatusCode, d.Response, d.Error, d.LatencyMs, d.RedeliveryOf, d.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record delivery: %w", err)
+	}
+	return nil
+}
+
+// List returns up to limit deliveries of a webhook, newest first.
+func (s *DeliveryStore) List(ctx context.Context, webhookID string, limit int) ([]Delivery, error) {
+	query := `
+		SELECT id, webhook_id, event, collection, payload, status_code, response, error, latency_ms, redelivery_of, created_at
+		FROM tugo_webhook_deliveries
+		WHERE webhook_id = ?
+		ORDER BY created_at DESC
+		LIMIT ?
+	`
+	deliveries := make([]Delivery, 0)
+	if err := s.db.SelectContext(ctx, &deliveries, s.db.Rebind(query), webhookID, limit); err != nil {
+		return nil, fmt.Errorf("failed to list deliveries: %w", err)
+	}
+	for i := range deliveries {
+		deliveries[i].Payload = deliveries[i].RawPayload
+	}
+	return deliveries, nil
+}
+
+// Get returns a delivery of a webhook.
+func (s *DeliveryStore) Get(ctx context.Context, webhookID, id string) (*Delivery, error) {
+	query := `
+		SELECT id, webhook_id, event, collection, payload, status_code, response, error, latency_ms, redelivery_of, created_at
+		FROM tugo_webhook_deliveries
+		WHERE webhook_id = ? AND id = ?
+	`
+	var d Delivery
+	if err := s.db.GetContext(ctx, &d, s.db.Rebind(query), webhookID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, apperror.ErrNotFound.WithMessagef("Delivery '%s' not found", id)
+		}
+		return nil, fmt.Errorf("failed to get delivery: %w", err)
+	}
+	d.Payload = d.RawPayload
+	return &d, nil
+}
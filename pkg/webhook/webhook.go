@@ -0,0 +1,436 @@
+// Package webhook posts collection record events to HTTP endpoints and
+// keeps a delivery history for inspection and redelivery.
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+// Headers set on every delivery.
+const (
+	SignatureHeader  = "X-Tugo-Signature"
+	DeliveryHeader   = "X-Tugo-Delivery"
+	EventHeader      = "X-Tugo-Event"
+	RedeliveryHeader = "X-Tugo-Redelivery"
+)
+
+// DefaultTimeout bounds each delivery.
+const DefaultTimeout = 10 * time.Second
+
+// maxResponseBytes caps the response body kept with a delivery.
+const maxResponseBytes = 4096
+
+// Webhook posts record events of some collections to a URL.
+type Webhook struct {
+	// ID identifies the webhook in the admin API.
+	ID string `json:"id"`
+
+	// URL receives a JSON POST for each event.
+	URL string `json:"url"`
+
+	// Collections limits the webhook to these collections, by API or table
+	// name. Empty means all collections.
+	Collections []string `json:"collections,omitempty"`
+
+	// Events limits the webhook to "create", "update" or "delete" events.
+	// Empty means all of them.
+	Events []string `json:"events,omitempty"`
+
+	// Secret signs payloads: the X-Tugo-Signature header holds
+	// "sha256=" and the hex HMAC-SHA256 of the body.
+	Secret string `json:"-"`
+
+	// Headers are added to every request.
+	Headers map[string]string `json:"-"`
+}
+
+// matches reports whether the webhook receives action events of collection.
+func (w *Webhook) matches(collection *schema.Collection, action string) bool {
+	if len(w.Events) > 0 && !slices.Contains(w.Events, action) {
+		return false
+	}
+	return len(w.Collections) == 0 ||
+		slices.Contains(w.Collections, collection.Name) ||
+		slices.Contains(w.Collections, collection.TableName)
+}
+
+// Status describes a webhook and whether it is disabled.
+type Status struct {
+	Webhook
+	Disabled      bool       `json:"disabled"`
+	DisabledUntil *time.Time `json:"disabled_until,omitempty"`
+}
+
+// Dispatcher delivers record events to webhooks.
+type Dispatcher struct {
+	store  *DeliveryStore
+	client *http.Client
+	logger *zap.SugaredLogger
+
+	// hooks is replaced, never modified, by SetWebhooks; disabled maps
+	// webhook IDs to when they resume, the zero time meaning until enabled
+	// again
+	mu       sync.RWMutex
+	hooks    []*Webhook
+	disabled map[string]time.Time
+
+	// pending tracks deliveries still being sent
+	pending sync.WaitGroup
+
+	// onFailure is called with failed deliveries when set
+	onFailure FailureFunc
+
+	// onState is called when a webhook is disabled or enabled
+	onState StateFunc
+}
+
+// FailureFunc is called with a delivery an endpoint did not accept.
+type FailureFunc func(ctx context.Context, hook Webhook, delivery *Delivery)
+
+// State is whether deliveries to a webhook are paused, and until when.
+type State struct {
+	ID       string     `json:"id"`
+	Disabled bool       `json:"disabled"`
+	Until    *time.Time `json:"until,omitempty"`
+}
+
+// StateFunc is called when a webhook is disabled or enabled.
+type StateFunc func(state State)
+
+// NewDispatcher creates a dispatcher for hooks, recording deliveries in store.
+func NewDispatcher(hooks []Webhook, store *DeliveryStore, logger *zap.SugaredLogger) (*Dispatcher, error) {
+	list, err := validate(hooks)
+	if err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		hooks:    list,
+		store:    store,
+		client:   &http.Client{Timeout: DefaultTimeout},
+		logger:   logger,
+		disabled: make(map[string]time.Time),
+	}, nil
+}
+
+// Validate checks that hooks have an ID and a URL and that their IDs are
+// unique, such as before SetWebhooks on a config reload.
+func Validate(hooks []Webhook) error {
+	_, err := validate(hooks)
+	return err
+}
+
+// validate checks that hooks have an ID and a URL and that their IDs are
+// unique, and returns copies of them.
+func validate(hooks []Webhook) ([]*Webhook, error) {
+	list := make([]*Webhook, 0, len(hooks))
+	seen := make(map[string]bool, len(hooks))
+	for i := range hooks {
+		hook := hooks[i]
+		if hook.ID == "" || hook.URL == "" {
+			return nil, fmt.Errorf("webhook %d needs an ID and a URL", i)
+		}
+		if seen[hook.ID] {
+			return nil, fmt.Errorf("duplicate webhook ID: %s", hook.ID)
+		}
+		seen[hook.ID] = true
+		list = append(list, &hook)
+	}
+	return list, nil
+}
+
+// SetWebhooks replaces the webhooks, such as on a config reload. Webhooks
+// keeping their ID keep their disabled state; deliveries in flight finish
+// with the webhook they started with.
+func (d *Dispatcher) SetWebhooks(hooks []Webhook) error {
+	list, err := validate(hooks)
+	if err != nil {
+		return err
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.hooks = list
+	for id := range d.disabled {
+		if !slices.ContainsFunc(list, func(hook *Webhook) bool { return hook.ID == id }) {
+			delete(d.disabled, id)
+		}
+	}
+	return nil
+}
+
+// OnFailure sets a function called with every failed delivery, such as
+// one notifying admins.
+func (d *Dispatcher) OnFailure(fn FailureFunc) {
+	d.onFailure = fn
+}
+
+// OnStateChange sets a function called when a webhook is disabled or
+// enabled, such as one telling other instances.
+func (d *Dispatcher) OnStateChange(fn StateFunc) {
+	d.onState = fn
+}
+
+// SetState applies a webhook's state, such as one changed on another
+// instance, without calling the OnStateChange function.
+func (d *Dispatcher) SetState(state State) error {
+	if d.webhook(state.ID) == nil {
+		return apperror.ErrNotFound.WithMessagef("Webhook '%s' not found", state.ID)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if !state.Disabled {
+		delete(d.disabled, state.ID)
+		return nil
+	}
+	var until time.Time
+	if state.Until != nil {
+		until = *state.Until
+	}
+	d.disabled[state.ID] = until
+	return nil
+}
+
+// Webhooks lists the webhooks with their disabled state.
+func (d *Dispatcher) Webhooks() []Status {
+	hooks := d.hookList()
+	statuses := make([]Status, 0, len(hooks))
+	for _, hook := range hooks {
+		statuses = append(statuses, d.status(hook))
+	}
+	return statuses
+}
+
+// Get returns the status of a webhook.
+func (d *Dispatcher) Get(id string) (*Status, error) {
+	hook := d.webhook(id)
+	if hook == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Webhook '%s' not found", id)
+	}
+	status := d.status(hook)
+	return &status, nil
+}
+
+// Disable stops deliveries to a webhook until the given time, or until it
+// is enabled again when until is zero. Events in the meantime are dropped.
+func (d *Dispatcher) Disable(id string, until time.Time) (*Status, error) {
+	if d.webhook(id) == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Webhook '%s' not found", id)
+	}
+	d.mu.Lock()
+	d.disabled[id] = until
+	d.mu.Unlock()
+	d.stateChanged(id)
+	return d.Get(id)
+}
+
+// Enable resumes deliveries to a webhook.
+func (d *Dispatcher) Enable(id string) (*Status, error) {
+	if d.webhook(id) == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Webhook '%s' not found", id)
+	}
+	d.mu.Lock()
+	delete(d.disabled, id)
+	d.mu.Unlock()
+	d.stateChanged(id)
+	return d.Get(id)
+}
+
+// Deliveries returns up to limit recent deliveries of a webhook.
+func (d *Dispatcher) Deliveries(ctx context.Context, id string, limit int) ([]Delivery, error) {
+	if d.webhook(id) == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Webhook '%s' not found", id)
+	}
+	return d.store.List(ctx, id, limit)
+}
+
+// Redeliver sends the payload of an earlier delivery again, even while the
+// webhook is disabled, and returns the new delivery.
+func (d *Dispatcher) Redeliver(ctx context.Context, id, deliveryID string) (*Delivery, error) {
+	hook := d.webhook(id)
+	if hook == nil {
+		return nil, apperror.ErrNotFound.WithMessagef("Webhook '%s' not found", id)
+	}
+	previous, err := d.store.Get(ctx, id, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+
+	delivery := &Delivery{
+		WebhookID:    id,
+		Event:        previous.Event,
+		Collection:   previous.Collection,
+		Payload:      previous.Payload,
+		RedeliveryOf: &previous.ID,
+	}
+	d.send(ctx, hook, delivery)
+	if err := d.store.Record(ctx, delivery); err != nil {
+		return nil, err
+	}
+	return delivery, nil
+}
+
+// Watches reports whether an enabled webhook receives action events of
+// collection.
+func (d *Dispatcher) Watches(collection *schema.Collection, action string) bool {
+	for _, hook := range d.hookList() {
+		if hook.matches(collection, action) && !d.isDisabled(hook.ID) {
+			return true
+		}
+	}
+	return false
+}
+
+// NotifyRecord delivers an event to the matching enabled webhooks in the
+// background. Failed deliveries are recorded and logged.
+func (d *Dispatcher) NotifyRecord(ctx context.Context, collection *schema.Collection, action string, record map[string]any) {
+	payload, err := json.Marshal(map[string]any{
+		"event":      action,
+		"collection": collection.Name,
+		"record":     record,
+		"timestamp":  time.Now().UTC(),
+	})
+	if err != nil {
+		d.logger.Errorw("Failed to encode webhook payload", "collection", collection.Name, "error", err)
+		return
+	}
+	ctx = context.WithoutCancel(ctx)
+
+	for _, hook := range d.hookList() {
+		if !hook.matches(collection, action) || d.isDisabled(hook.ID) {
+			continue
+		}
+		d.pending.Add(1)
+		go func(hook *Webhook) {
+			defer d.pending.Done()
+			delivery := &Delivery{
+				WebhookID:  hook.ID,
+				Event:      action,
+				Collection: collection.Name,
+				Payload:    payload,
+			}
+			d.send(ctx, hook, delivery)
+			if !delivery.Succeeded() {
+				d.logger.Warnw("Webhook delivery failed",
+					"webhook", hook.ID, "delivery", delivery.ID, "status", delivery.StatusCode, "error", delivery.Error)
+				if d.onFailure != nil {
+					d.onFailure(ctx, *hook, delivery)
+				}
+			}
+			if err := d.store.Record(ctx, delivery); err != nil {
+				d.logger.Errorw("Failed to record webhook delivery", "webhook", hook.ID, "error", err)
+			}
+		}(hook)
+	}
+}
+
+// Wait blocks until the deliveries in flight are done.
+func (d *Dispatcher) Wait() {
+	d.pending.Wait()
+}
+
+// send posts a delivery's payload and fills in its ID, outcome and latency.
+func (d *Dispatcher) send(ctx context.Context, hook *Webhook, delivery *Delivery) {
+	delivery.ID = uuid.NewString()
+	delivery.CreatedAt = time.Now().UTC()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, hook.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		delivery.Error = err.Error()
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(DeliveryHeader, delivery.ID)
+	req.Header.Set(EventHeader, delivery.Event)
+	if delivery.RedeliveryOf != nil {
+		req.Header.Set(RedeliveryHeader, "true")
+	}
+	if hook.Secret != "" {
+		req.Header.Set(SignatureHeader, Sign(hook.Secret, delivery.Payload))
+	}
+	for name, value := range hook.Headers {
+		req.Header.Set(name, value)
+	}
+
+	start := time.Now()
+	resp, err := d.client.Do(req)
+	if err != nil {
+		delivery.LatencyMs = time.Since(start).Milliseconds()
+		delivery.Error = err.Error()
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxResponseBytes))
+	delivery.LatencyMs = time.Since(start).Milliseconds()
+	delivery.StatusCode = resp.StatusCode
+	delivery.Response = string(body)
+}
+
+// Sign returns the signature header value of a payload.
+func Sign(secret string, payload []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(payload)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// hookList returns the current webhooks.
+func (d *Dispatcher) hookList() []*Webhook {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+	return d.hooks
+}
+
+// webhook returns the webhook with the given ID, or nil.
+func (d *Dispatcher) webhook(id string) *Webhook {
+	for _, hook := range d.hookList() {
+		if hook.ID == id {
+			return hook
+		}
+	}
+	return nil
+}
+
+// isDisabled reports whether deliveries to a webhook are paused.
+func (d *Dispatcher) isDisabled(id string) bool {
+	d.mu.RLock()
+	until, ok := d.disabled[id]
+	d.mu.RUnlock()
+	return ok && (until.IsZero() || time.Now().Before(until))
+}
+
+// stateChanged calls the OnStateChange function with a webhook's state.
+func (d *Dispatcher) stateChanged(id string) {
+	if d.onState == nil {
+		return
+	}
+	status := d.status(d.webhook(id))
+	d.onState(State{ID: id, Disabled: status.Disabled, Until: status.DisabledUntil})
+}
+
+// status describes a webhook.
+func (d *Dispatcher) status(hook *Webhook) Status {
+	status := Status{Webhook: *hook, Disabled: d.isDisabled(hook.ID)}
+	if status.Disabled {
+		d.mu.RLock()
+		until := d.disabled[hook.ID]
+		d.mu.RUnlock()
+		if !until.IsZero() {
+			status.DisabledUntil = &until
+		}
+	}
+	return status
+}
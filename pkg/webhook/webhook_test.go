@@ -0,0 +1,185 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/thienel/tugo/pkg/schema"
+	"go.uber.org/zap"
+)
+
+func TestWebhookMatches(t *testing.T) {
+	orders := &schema.Collection{Name: "orders", TableName: "api_orders"}
+
+	tests := []struct {
+		name   string
+		hook   Webhook
+		action string
+		want   bool
+	}{
+		{"all", Webhook{}, "create", true},
+		{"api name", Webhook{Collections: []string{"orders"}}, "update", true},
+		{"table name", Webhook{Collections: []string{"api_orders"}}, "update", true},
+		{"other collection", Webhook{Collections: []string{"users"}}, "update", false},
+		{"listed event", Webhook{Events: []string{"delete"}}, "delete", true},
+		{"other event", Webhook{Events: []string{"delete"}}, "create", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.hook.matches(orders, tt.action); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherDisable(t *testing.T) {
+	orders := &schema.Collection{Name: "orders"}
+	d, err := NewDispatcher([]Webhook{{ID: "erp", URL: "http://example.com"}}, nil, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.Disable("erp", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if d.Watches(orders, "create") {
+		t.Error("disabled webhook still watches")
+	}
+
+	if _, err := d.Enable("erp"); err != nil {
+		t.Fatal(err)
+	}
+	status, err := d.Disable("erp", time.Now().Add(-time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.Disabled || !d.Watches(orders, "create") {
+		t.Error("webhook paused until a past time is still disabled")
+	}
+
+	if _, err := d.Disable("missing", time.Time{}); err == nil {
+		t.Error("Disable() of an unknown webhook succeeded")
+	}
+}
+
+func TestDispatcherState(t *testing.T) {
+	orders := &schema.Collection{Name: "orders"}
+	logger := zap.NewNop().Sugar()
+	hooks := []Webhook{{ID: "erp", URL: "http://example.com"}}
+	d, _ := NewDispatcher(hooks, nil, logger)
+	replica, _ := NewDispatcher(hooks, nil, logger)
+
+	var changes []State
+	d.OnStateChange(func(state State) {
+		changes = append(changes, state)
+		if err := replica.SetState(state); err != nil {
+			t.Errorf("SetState() error = %v", err)
+		}
+	})
+	replica.OnStateChange(func(state State) {
+		t.Errorf("SetState() called OnStateChange with %+v", state)
+	})
+
+	if _, err := d.Disable("erp", time.Now().Add(time.Hour)); err != nil {
+		t.Fatal(err)
+	}
+	if replica.Watches(orders, "create") {
+		t.Error("replica still watches a webhook disabled elsewhere")
+	}
+	if _, err := d.Enable("erp"); err != nil {
+		t.Fatal(err)
+	}
+	if !replica.Watches(orders, "create") {
+		t.Error("replica does not watch a webhook enabled elsewhere")
+	}
+	if len(changes) != 2 || !changes[0].Disabled || changes[0].Until == nil || changes[1].Disabled {
+		t.Errorf("changes = %+v", changes)
+	}
+
+	if err := replica.SetState(State{ID: "missing"}); err == nil {
+		t.Error("SetState() of an unknown webhook succeeded")
+	}
+}
+
+func TestSetWebhooks(t *testing.T) {
+	orders := &schema.Collection{Name: "orders"}
+	d, err := NewDispatcher([]Webhook{{ID: "erp", URL: "http://example.com"}, {ID: "crm", URL: "http://example.com"}}, nil, zap.NewNop().Sugar())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Disable("erp", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := d.Disable("crm", time.Time{}); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.SetWebhooks([]Webhook{{ID: "erp", URL: "http://example.org"}, {ID: "billing", URL: "http://example.org"}}); err != nil {
+		t.Fatal(err)
+	}
+	statuses := d.Webhooks()
+	if len(statuses) != 2 || statuses[0].URL != "http://example.org" || !statuses[0].Disabled || statuses[1].Disabled {
+		t.Errorf("Webhooks() = %+v, want erp still disabled and billing enabled", statuses)
+	}
+	if _, err := d.Get("crm"); err == nil {
+		t.Error("removed webhook is still found")
+	}
+	if !d.Watches(orders, "create") {
+		t.Error("added webhook does not watch")
+	}
+
+	if err := d.SetWebhooks([]Webhook{{ID: "a", URL: "http://a"}, {ID: "a", URL: "http://b"}}); err == nil {
+		t.Error("SetWebhooks() with a duplicate ID succeeded")
+	}
+	if len(d.Webhooks()) != 2 {
+		t.Error("rejected webhooks replaced the old ones")
+	}
+}
+
+func TestNewDispatcherValidates(t *testing.T) {
+	tests := []struct {
+		name  string
+		hooks []Webhook
+	}{
+		{"missing ID", []Webhook{{URL: "http://example.com"}}},
+		{"missing URL", []Webhook{{ID: "a"}}},
+		{"duplicate ID", []Webhook{{ID: "a", URL: "http://a"}, {ID: "a", URL: "http://b"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := NewDispatcher(tt.hooks, nil, zap.NewNop().Sugar()); err == nil {
+				t.Error("NewDispatcher() = nil error")
+			}
+		})
+	}
+}
+
+func TestSend(t *testing.T) {
+	var signature, event string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		signature, event = r.Header.Get(SignatureHeader), r.Header.Get(EventHeader)
+		io.Copy(io.Discard, r.Body)
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("nope"))
+	}))
+	defer server.Close()
+
+	d, _ := NewDispatcher(nil, nil, zap.NewNop().Sugar())
+	payload := []byte(`{"event":"create"}`)
+	delivery := &Delivery{Event: "create", Payload: payload}
+	d.send(context.Background(), &Webhook{ID: "a", URL: server.URL, Secret: "s3cret"}, delivery)
+
+	if signature != Sign("s3cret", payload) || event != "create" {
+		t.Errorf("headers: signature %q, event %q", signature, event)
+	}
+	if delivery.ID == "" || delivery.StatusCode != http.StatusTeapot || delivery.Response != "nope" || delivery.Succeeded() {
+		t.Errorf("delivery = %+v", delivery)
+	}
+}
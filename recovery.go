@@ -0,0 +1,64 @@
+package tugo
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/apperror"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+)
+
+// PanicError is the error reported to Config.OnError when a handler panics.
+type PanicError struct {
+	// Value is the value passed to panic.
+	Value any
+
+	// Stack is the goroutine stack at the time of the panic.
+	Stack []byte
+}
+
+// Error implements the error interface.
+func (e *PanicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.Value)
+}
+
+// Unwrap returns the panic value if it is an error.
+func (e *PanicError) Unwrap() error {
+	err, _ := e.Value.(error)
+	return err
+}
+
+// recovery turns panics in TuGo handlers into 500 responses with the
+// standard error envelope, so mounted routes do not depend on the host's
+// recovery middleware. The panic is recorded for the error hook.
+func (e *Engine) recovery() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		defer func() {
+			rec := recover()
+			if rec == nil {
+				return
+			}
+			// Let net/http abort the response as intended
+			if err, ok := rec.(error); ok && errors.Is(err, http.ErrAbortHandler) {
+				panic(rec)
+			}
+
+			panicErr := &PanicError{Value: rec, Stack: debug.Stack()}
+			requestlog.Logger(c.Request.Context(), e.logger).Errorw("Panic recovered",
+				"panic", rec, "path", c.Request.URL.Path, "stack", string(panicErr.Stack))
+			_ = c.Error(panicErr)
+
+			// A partly written response cannot be replaced
+			if c.Writer.Written() {
+				c.Abort()
+				return
+			}
+			response.Abort(c, http.StatusInternalServerError, response.FromAppError(apperror.ErrInternalServer))
+		}()
+		c.Next()
+	}
+}
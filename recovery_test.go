@@ -0,0 +1,119 @@
+package tugo
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"go.uber.org/zap"
+)
+
+func TestRecovery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	errDown := errors.New("database down")
+
+	tests := []struct {
+		name       string
+		handler    gin.HandlerFunc
+		wantPanic  bool
+		wantStatus int
+		wantCode   string
+		wantValue  any // Panic value reported to OnError, nil for no report
+	}{
+		{
+			name:       "panic",
+			handler:    func(c *gin.Context) { panic("boom") },
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_ERROR",
+			wantValue:  "boom",
+		},
+		{
+			name:       "panic with an error",
+			handler:    func(c *gin.Context) { panic(errDown) },
+			wantStatus: http.StatusInternalServerError,
+			wantCode:   "INTERNAL_ERROR",
+			wantValue:  errDown,
+		},
+		{
+			name: "partly written response",
+			handler: func(c *gin.Context) {
+				c.String(http.StatusOK, "partial")
+				panic("boom")
+			},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:      "abort handler",
+			handler:   func(c *gin.Context) { panic(http.ErrAbortHandler) },
+			wantPanic: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var reported error
+			logger := zap.NewNop().Sugar()
+			e := &Engine{
+				logger: logger,
+				requestLog: requestlog.Middleware(requestlog.Config{
+					OnError: func(ctx context.Context, err error, meta requestlog.RequestMeta) { reported = err },
+				}, logger),
+			}
+			router := gin.New()
+			e.group(router.Group("/api/v1")).GET("/items", tt.handler)
+
+			w := httptest.NewRecorder()
+			rec := func() (rec any) {
+				defer func() { rec = recover() }()
+				router.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/items", nil))
+				return nil
+			}()
+
+			if tt.wantPanic {
+				if err, ok := rec.(error); !ok || !errors.Is(err, http.ErrAbortHandler) {
+					t.Fatalf("recovered %v, want http.ErrAbortHandler re-panicked", rec)
+				}
+				return
+			}
+			if rec != nil {
+				t.Fatalf("unexpected panic: %v", rec)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+
+			if tt.wantCode != "" {
+				var body response.Response
+				if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+					t.Fatalf("body %q is not the error envelope: %v", w.Body.String(), err)
+				}
+				if body.Success || body.Error == nil || body.Error.Code != tt.wantCode {
+					t.Errorf("body = %s, want error code %s", w.Body.String(), tt.wantCode)
+				}
+			}
+
+			if tt.wantValue == nil {
+				if reported != nil {
+					t.Errorf("OnError reported %v, want no report", reported)
+				}
+				return
+			}
+			var panicErr *PanicError
+			if !errors.As(reported, &panicErr) {
+				t.Fatalf("OnError reported %v, want a *PanicError", reported)
+			}
+			if panicErr.Value != tt.wantValue || len(panicErr.Stack) == 0 {
+				t.Errorf("PanicError = %v with %d stack bytes, want value %v and a stack", panicErr.Value, len(panicErr.Stack), tt.wantValue)
+			}
+			if err, ok := tt.wantValue.(error); ok && !errors.Is(reported, err) {
+				t.Errorf("OnError error does not unwrap to %v", err)
+			}
+		})
+	}
+}
@@ -5,20 +5,51 @@ import (
 	"fmt"
 	"net/http"
 	"os"
+	"slices"
+	"strconv"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
 	"github.com/jmoiron/sqlx"
 	_ "github.com/lib/pq"
 	"github.com/pquerna/otp"
 	"github.com/thienel/tlog"
 	"github.com/thienel/tugo/pkg/admin"
+	"github.com/thienel/tugo/pkg/apperror"
 	"github.com/thienel/tugo/pkg/auth"
+	"github.com/thienel/tugo/pkg/cache"
+	"github.com/thienel/tugo/pkg/cluster"
 	"github.com/thienel/tugo/pkg/collection"
+	"github.com/thienel/tugo/pkg/dialect"
+	"github.com/thienel/tugo/pkg/events"
+	"github.com/thienel/tugo/pkg/grpcapi"
+	"github.com/thienel/tugo/pkg/idgen"
+	"github.com/thienel/tugo/pkg/inbox"
+	"github.com/thienel/tugo/pkg/ingest"
+	"github.com/thienel/tugo/pkg/ipfilter"
+	"github.com/thienel/tugo/pkg/jobs"
+	"github.com/thienel/tugo/pkg/mcp"
 	"github.com/thienel/tugo/pkg/migrate"
+	"github.com/thienel/tugo/pkg/notify"
+	"github.com/thienel/tugo/pkg/permission"
+	"github.com/thienel/tugo/pkg/quota"
+	"github.com/thienel/tugo/pkg/requestlog"
+	"github.com/thienel/tugo/pkg/response"
+	"github.com/thienel/tugo/pkg/retention"
+	"github.com/thienel/tugo/pkg/rpc"
 	"github.com/thienel/tugo/pkg/schema"
+	"github.com/thienel/tugo/pkg/search"
+	"github.com/thienel/tugo/pkg/snapshot"
 	"github.com/thienel/tugo/pkg/storage"
+	"github.com/thienel/tugo/pkg/storedquery"
+	"github.com/thienel/tugo/pkg/usage"
 	"github.com/thienel/tugo/pkg/validation"
+	"github.com/thienel/tugo/pkg/webhook"
 	"go.uber.org/zap"
 )
 
@@ -27,12 +58,51 @@ type Engine struct {
 	config        Config
 	db            *sqlx.DB
 	ownsDB        bool
+	pgxPool       *pgxpool.Pool
+	ownsPool      bool
 	logger        *zap.SugaredLogger
 	router        *gin.Engine
 	schemaManager *schema.Manager
 	collService   *collection.Service
 	collHandler   *collection.Handler
 
+	// Stored queries
+	queryService *storedquery.Service
+	queryHandler *storedquery.Handler
+
+	// Database functions
+	rpcService *rpc.Service
+	rpcHandler *rpc.Handler
+
+	// gRPC API, nil unless GRPC.Addr is set
+	grpc *grpcapi.Server
+
+	// Row-level security, set in RLS permission mode
+	rls *permission.RLS
+
+	// Shared key-value cache
+	cache cache.Store
+
+	// Request ID and access log middleware
+	requestLog gin.HandlerFunc
+
+	// Collection endpoint usage, nil unless enabled
+	usage *usage.Tracker
+
+	// Monthly request quotas of users, nil unless enabled
+	quotas *quota.Meter
+
+	// Background jobs and the retention rules they run
+	jobs      *jobs.Runner
+	retention *retention.Enforcer
+	schedules atomic.Int64
+
+	// This instance's member of the cluster, nil unless enabled
+	cluster *cluster.Node
+
+	// Write locks of collections
+	locks *collection.Locks
+
 	// Auth components
 	authProvider   auth.Provider
 	userStore      auth.UserStore
@@ -40,6 +110,30 @@ type Engine struct {
 	totpManager    *auth.TOTPManager
 	authHandler    *auth.Handler
 	authMiddleware gin.HandlerFunc
+	optionalAuth   gin.HandlerFunc
+
+	// Email notifications, nil without a mailer
+	notifier *notify.Notifier
+
+	// Webhook deliveries, nil without webhooks
+	webhooks *webhook.Dispatcher
+
+	// In-app notifications, nil unless enabled
+	inbox        *inbox.Inbox
+	inboxHandler *inbox.Handler
+
+	// Change feed, nil unless events are stored or published
+	events        *events.Log
+	eventsHandler *events.Handler
+
+	// Search-engine sync, nil without a search engine
+	indexer *search.Indexer
+
+	// Inbound webhook endpoints, nil without ingest routes
+	ingestHandler *ingest.Handler
+
+	// Model Context Protocol tools, nil unless enabled
+	mcpHandler *mcp.Handler
 
 	// Storage components
 	storageManager *storage.Manager
@@ -70,6 +164,11 @@ func New(config Config) (*Engine, error) {
 		config.Server.Port = defaults.Server.Port
 	}
 
+	// Reject invalid IP rules before routes are mounted
+	if err := checkIPRules(config.Mount, config.GeoIP); err != nil {
+		return nil, err
+	}
+
 	// Initialize logger
 	_ = tlog.InitWithDefaults()
 	logger := tlog.S()
@@ -77,13 +176,35 @@ func New(config Config) (*Engine, error) {
 	// Initialize database connection
 	var db *sqlx.DB
 	var ownsDB bool
+	var pgxPool *pgxpool.Pool
+	var ownsPool bool
 	var err error
 
 	if config.DB != nil {
 		db = config.DB
 		ownsDB = false
+	} else if config.PgxPool != nil {
+		pgxPool = config.PgxPool
+		db = sqlx.NewDb(stdlib.OpenDBFromPool(pgxPool), "pgx")
+		ownsDB = true
+	} else if config.DatabaseURL != "" && config.Driver == "pgx" {
+		pgxPool, err = pgxpool.New(context.Background(), config.DatabaseURL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create pgx pool: %w", err)
+		}
+		if err := pgxPool.Ping(context.Background()); err != nil {
+			pgxPool.Close()
+			return nil, fmt.Errorf("failed to connect to database: %w", err)
+		}
+		db = sqlx.NewDb(stdlib.OpenDBFromPool(pgxPool), "pgx")
+		ownsDB = true
+		ownsPool = true
 	} else if config.DatabaseURL != "" {
-		db, err = sqlx.Connect("postgres", config.DatabaseURL)
+		d, dialectErr := dialect.Get(config.Driver)
+		if dialectErr != nil {
+			return nil, dialectErr
+		}
+		db, err = sqlx.Connect(d.DriverName(), config.DatabaseURL)
 		if err != nil {
 			return nil, fmt.Errorf("failed to connect to database: %w", err)
 		}
@@ -94,33 +215,117 @@ func New(config Config) (*Engine, error) {
 		db.SetMaxIdleConns(5)
 		db.SetConnMaxLifetime(5 * time.Minute)
 	} else {
-		return nil, fmt.Errorf("either DB or DatabaseURL must be provided")
-	}
-
-	// Create schema manager config
-	schemaConfig := schema.ManagerConfig{
-		Mode:         schema.DiscoveryMode(config.Discovery.Mode),
-		Prefix:       config.Discovery.Prefix,
-		AutoDiscover: config.Discovery.AutoDiscover,
-		Blacklist:    config.Discovery.Blacklist,
-		Config:       make(map[string]schema.CollectionConfig),
-	}
-
-	// Convert collection configs
-	for name, cfg := range config.Discovery.Config {
-		schemaConfig.Config[name] = schema.CollectionConfig{
-			Enabled:      cfg.Enabled,
-			PublicFields: cfg.PublicFields,
-		}
+		return nil, fmt.Errorf("one of DB, PgxPool or DatabaseURL must be provided")
 	}
 
 	// Create schema manager
-	schemaManager := schema.NewManager(db, schemaConfig, logger)
+	schemaManager := schema.NewManager(db, schemaManagerConfig(config), logger)
 
 	// Create repository and service
 	repo := collection.NewRepository(db)
+	repo.SetRetryConfig(config.Resilience.Retry)
+	repo.SetBreakerConfig(config.Resilience.Breaker)
+	if err := repo.SetTimestampConfig(config.Timestamps); err != nil {
+		return nil, err
+	}
+	repo.SetDecimalNumbers(config.Query.DecimalsAsNumbers)
 	collService := collection.NewService(repo, schemaManager, logger)
+	collService.SetRevisionStore(collection.NewRevisionStore(db))
+	collService.SetViewStore(collection.NewViewStore(db))
+	collService.SetApprovalStore(collection.NewApprovalStore(db))
+	collService.SetCommentStore(collection.NewCommentStore(db))
+	collService.SetFavoriteStore(collection.NewFavoriteStore(db))
+	permissions := config.Permissions.Checker
+	if permissions == nil {
+		permissions = config.Favorites.Permissions
+	}
+	collService.SetPermissions(permissions)
+	collService.SetSlowQueryThreshold(config.Query.SlowQueryThreshold)
+	collService.SetMaxExportRows(config.Query.MaxExportRows)
+	collService.SetQueryTokens(collection.NewQueryTokens(config.Query.TokenSecret, config.Query.TokenTTL))
+	if config.Query.TokenSecret == "" && config.Cluster.Enabled {
+		logger.Warnw("Query.TokenSecret is empty; query tokens are signed with a per-instance key and fail on other instances")
+	}
+	collService.SetBulkConfig(collection.BulkConfig{
+		CopyThreshold: config.Query.CopyThreshold,
+		MaxItems:      config.Query.MaxBatchItems,
+		MaxAffected:   config.Query.MaxAffected,
+	})
+	switch config.Query.ExpandStrategy {
+	case "", collection.ExpandStrategyQuery, collection.ExpandStrategyJoin, collection.ExpandStrategyAuto:
+	default:
+		return nil, fmt.Errorf("unknown expand strategy %q", config.Query.ExpandStrategy)
+	}
+	collService.SetExpandConfig(collection.ExpandConfig{
+		Strategy:    config.Query.ExpandStrategy,
+		JoinMaxRows: config.Query.ExpandJoinMaxRows,
+	})
 	collHandler := collection.NewHandler(collService, logger)
+	collHandler.SetFormats(config.Formats)
+
+	// Send email notifications and webhooks if configured
+	var notifiers collection.Notifiers
+	notifier, err := newNotifier(config, db, logger)
+	if err != nil {
+		return nil, err
+	}
+	if notifier != nil {
+		notifiers = append(notifiers, notifier)
+	}
+	var webhooks *webhook.Dispatcher
+	if len(config.Webhooks) > 0 {
+		if webhooks, err = webhook.NewDispatcher(config.Webhooks, webhook.NewDeliveryStore(db), logger); err != nil {
+			return nil, err
+		}
+		notifiers = append(notifiers, webhooks)
+	}
+	// Mirror collections into a search engine through the change feed
+	eventsConfig := config.Events
+	var indexer *search.Indexer
+	if config.Search.Engine != nil {
+		if indexer, err = search.NewIndexer(config.Search, schemaManager, logger); err != nil {
+			return nil, err
+		}
+		eventsConfig.Publishers = append(slices.Clone(eventsConfig.Publishers), indexer)
+		if len(eventsConfig.Collections) > 0 {
+			eventsConfig.Collections = append(slices.Clone(eventsConfig.Collections), indexer.Collections()...)
+		}
+		collService.SetSearcher(indexer)
+	}
+	var eventLog *events.Log
+	if eventsConfig.Enabled || len(eventsConfig.Publishers) > 0 {
+		var store *events.Store
+		if eventsConfig.Enabled {
+			store = events.NewStore(db)
+		}
+		eventLog = events.NewLog(eventsConfig, store, logger)
+		notifiers = append(notifiers, eventLog)
+	}
+	if len(notifiers) > 0 {
+		collService.SetNotifier(notifiers)
+	}
+	if config.Embedder != nil {
+		collService.SetEmbedder(config.Embedder)
+	}
+	ids, err := idgen.NewGenerator(config.IDs)
+	if err != nil {
+		return nil, err
+	}
+	collService.SetIDGenerator(ids)
+
+	// Refuse writes to collections locked during migrations
+	locks := collection.NewLocks()
+	collService.SetLocks(locks)
+
+	// Create stored query service and register configured queries
+	queryService := storedquery.NewService(db, storedquery.NewStore(db), storedquery.Config{
+		StatementTimeout: config.Query.StatementTimeout,
+	}, logger)
+	for _, q := range config.Queries {
+		if err := queryService.Register(q); err != nil {
+			return nil, err
+		}
+	}
 
 	// Create Gin router
 	gin.SetMode(gin.ReleaseMode)
@@ -137,12 +342,117 @@ func New(config Config) (*Engine, error) {
 		config:            config,
 		db:                db,
 		ownsDB:            ownsDB,
+		pgxPool:           pgxPool,
+		ownsPool:          ownsPool,
 		logger:            logger,
 		router:            router,
 		schemaManager:     schemaManager,
 		collService:       collService,
+		locks:             locks,
 		collHandler:       collHandler,
+		queryService:      queryService,
+		queryHandler:      storedquery.NewHandler(queryService, logger),
 		validatorRegistry: validatorRegistry,
+		notifier:          notifier,
+		webhooks:          webhooks,
+		events:            eventLog,
+		indexer:           indexer,
+		cache:             config.Cache,
+	}
+	if engine.cache == nil {
+		engine.cache = cache.NewMemoryStore(0)
+	}
+	if eventLog != nil && eventLog.Store() != nil {
+		engine.eventsHandler = events.NewHandler(eventLog.Store(), logger)
+	}
+	if len(config.Ingest) > 0 {
+		if engine.ingestHandler, err = ingest.NewHandler(config.Ingest, collService, schemaManager, logger); err != nil {
+			return nil, err
+		}
+	}
+	if config.MCP.Enabled {
+		engine.mcpHandler = mcp.NewHandler(config.MCP, collService, schemaManager, logger)
+	}
+
+	// Tag requests with correlation IDs and report server errors
+	logging := config.Logging
+	if logging.UserID == nil {
+		logging.UserID = func(ctx context.Context) string {
+			if user, ok := auth.GetUserFromContext(ctx); ok && user != nil {
+				return user.ID
+			}
+			return ""
+		}
+	}
+	if logging.OnError == nil {
+		logging.OnError = config.OnError
+	}
+	engine.requestLog = requestlog.Middleware(logging, logger)
+
+	// Track collection endpoint usage if configured
+	if config.Usage.Enabled {
+		var store *usage.Store
+		if config.Usage.Persist {
+			store = usage.NewStore(db)
+		}
+		engine.usage = usage.NewTracker(config.Usage, store, schemaManager.HasCollection, logger)
+	}
+
+	// Meter users' requests against their quotas if configured
+	if config.Quotas.Enabled {
+		engine.quotas = quota.NewMeter(config.Quotas, quota.NewStore(db), logger)
+	}
+
+	// Run retention rules in the background
+	engine.jobs = jobs.NewRunner(logger)
+	engine.retention = retention.NewEnforcer(db, schemaManager.GetCollections, logger)
+	engine.retention.SetRules(retentionRules(config))
+	if err := engine.jobs.Add(engine.retentionJob()); err != nil {
+		return nil, err
+	}
+	if err := engine.jobs.Add(engine.publishJob()); err != nil {
+		return nil, err
+	}
+
+	// Coordinate with other instances if configured
+	if config.Cluster.Enabled {
+		if err := engine.initCluster(); err != nil {
+			return nil, err
+		}
+	}
+
+	// Expose database functions if configured
+	if config.RPC.Enabled {
+		if dialect.ForDriver(db.DriverName()).Name() != dialect.Postgres {
+			return nil, fmt.Errorf("RPC requires PostgreSQL")
+		}
+		engine.rpcService = rpc.NewService(db, rpc.Config{
+			Prefix:           config.RPC.Prefix,
+			StatementTimeout: config.Query.StatementTimeout,
+		}, logger)
+		engine.rpcHandler = rpc.NewHandler(engine.rpcService, logger)
+	}
+
+	// Let the database enforce permissions if configured
+	switch config.Permissions.Mode {
+	case "", "app":
+	case "rls":
+		if dialect.ForDriver(db.DriverName()).Name() != dialect.Postgres {
+			return nil, fmt.Errorf("RLS permission mode requires PostgreSQL")
+		}
+		rls, err := permission.NewRLS(config.Permissions.RLS)
+		if err != nil {
+			return nil, err
+		}
+		rls.SetGroups(permission.NewGroupStore(db))
+		engine.rls = rls
+		repo.SetSessionHook(rls.Apply)
+		queryService.SetSessionHook(rls.Apply)
+		if engine.rpcService != nil {
+			engine.rpcService.SetSessionHook(rls.Apply)
+		}
+	default:
+		return nil, fmt.Errorf("unknown permission mode %q", config.Permissions.Mode)
 	}
 
 	// Initialize authentication if configured
@@ -152,8 +462,35 @@ func New(config Config) (*Engine, error) {
 		}
 	}
 
+	// Keep in-app notifications if enabled
+	if config.Inbox.Enabled {
+		if engine.userStore == nil {
+			return nil, fmt.Errorf("the notification inbox requires auth")
+		}
+		engine.inbox = inbox.NewInbox(config.Inbox, inbox.NewStore(db), logger)
+		engine.inbox.SetUserLookup(engine.userStore)
+		engine.inboxHandler = inbox.NewHandler(engine.inbox.Store(), logger)
+		collService.SetInbox(engine.inbox)
+		if webhooks != nil && len(config.Inbox.WebhookRecipients) > 0 {
+			webhooks.OnFailure(engine.webhookFailed)
+		}
+	}
+
+	// Serve collections over gRPC if configured
+	if config.GRPC.Addr != "" {
+		engine.grpc = grpcapi.NewServer(grpcapi.Config{
+			Service:     collService,
+			Provider:    engine.authProvider,
+			UserStore:   engine.userStore,
+			Permissions: config.GRPC.Permissions,
+			Options:     config.GRPC.Options,
+			Logger:      logger,
+		})
+	}
+
 	// Initialize storage if configured
-	if config.Storage.Default != "" || len(config.Storage.Providers) > 0 {
+	if config.Storage.Default != "" || len(config.Storage.Providers) > 0 ||
+		len(config.Storage.Local) > 0 || len(config.Storage.MinIO) > 0 {
 		if err := engine.initStorage(); err != nil {
 			return nil, fmt.Errorf("failed to initialize storage: %w", err)
 		}
@@ -165,6 +502,288 @@ func New(config Config) (*Engine, error) {
 	return engine, nil
 }
 
+// webhookFailed notifies the configured recipients of a failed webhook
+// delivery. The notification has no actor, so the user whose write
+// triggered the delivery is notified too.
+func (e *Engine) webhookFailed(_ context.Context, hook webhook.Webhook, delivery *webhook.Delivery) {
+	reason := delivery.Error
+	if reason == "" {
+		reason = fmt.Sprintf("Endpoint responded with status %d", delivery.StatusCode)
+	}
+	e.inbox.NotifyUsernames(context.Background(), e.config.Inbox.WebhookRecipients, inbox.Notification{
+		Type:       inbox.TypeWebhookFailure,
+		Title:      fmt.Sprintf("Webhook '%s' delivery failed", hook.ID),
+		Body:       reason,
+		Collection: &delivery.Collection,
+		Data: map[string]any{
+			"webhook_id":  hook.ID,
+			"delivery_id": delivery.ID,
+			"event":       delivery.Event,
+			"status_code": delivery.StatusCode,
+		},
+	})
+}
+
+// newNotifier creates the email notifier, or returns nil without a mailer.
+func newNotifier(config Config, db *sqlx.DB, logger *zap.SugaredLogger) (*notify.Notifier, error) {
+	rules := notificationRules(config)
+	if config.Notify.Mailer == nil {
+		if len(rules) > 0 {
+			return nil, fmt.Errorf("collection notifications require Notify.Mailer")
+		}
+		return nil, nil
+	}
+	if config.Notify.From == "" {
+		return nil, fmt.Errorf("Notify.From is required with a mailer")
+	}
+
+	templates := config.Notify.Templates
+	if templates == nil {
+		if config.Notify.TemplateDir != "" {
+			templates = notify.NewDirStore(config.Notify.TemplateDir)
+		} else {
+			templates = notify.NewDBStore(db)
+		}
+	}
+	notifier := notify.NewNotifier(config.Notify.Mailer, templates, config.Notify.From, logger)
+	notifier.SetRules(rules)
+	return notifier, nil
+}
+
+// notificationRules collects the notification rules of configured collections.
+func notificationRules(config Config) map[string][]notify.Rule {
+	rules := make(map[string][]notify.Rule)
+	for name, cfg := range config.Discovery.Config {
+		if len(cfg.Notifications) > 0 {
+			rules[name] = cfg.Notifications
+		}
+	}
+	return rules
+}
+
+// retentionRules collects the retention rules of configured collections.
+func retentionRules(config Config) map[string]retention.Rule {
+	rules := make(map[string]retention.Rule)
+	for name, cfg := range config.Discovery.Config {
+		if cfg.Retention != nil {
+			rules[name] = *cfg.Retention
+		}
+	}
+	return rules
+}
+
+// initCluster joins the instances sharing the database: jobs run under
+// leases, and changes other instances must apply are sent on the bus.
+func (e *Engine) initCluster() error {
+	config := e.config.Cluster
+	postgres := dialect.ForDriver(e.db.DriverName()).Name() == dialect.Postgres
+	kind := config.Bus
+	if kind == "" {
+		kind = cluster.BusCache
+		if postgres {
+			kind = cluster.BusNotify
+		}
+	}
+
+	var bus cluster.Bus
+	switch kind {
+	case cluster.BusNotify:
+		if !postgres {
+			return fmt.Errorf("the notify cluster bus requires PostgreSQL")
+		}
+		notifyBus, err := cluster.NewNotifyBus(e.db, e.pgxPool, e.config.DatabaseURL, config.Channel, e.logger)
+		if err != nil {
+			return err
+		}
+		bus = notifyBus
+	case cluster.BusCache:
+		if e.config.Cache == nil {
+			return fmt.Errorf("the cache cluster bus requires a shared Config.Cache")
+		}
+		bus = cluster.NewCacheBus(e.config.Cache, config.PollInterval)
+	default:
+		return fmt.Errorf("unknown cluster bus: %s", kind)
+	}
+
+	e.cluster = cluster.NewNode(bus, cluster.NewLeaseStore(e.db), e.logger)
+	e.jobs.SetLease(e.cluster)
+	e.jobs.OnStateChange(func(state jobs.State) {
+		e.broadcast(context.Background(), cluster.TopicJobs, state)
+	})
+	e.cluster.Handle(cluster.TopicJobs, func(_ context.Context, msg cluster.Message) {
+		var state jobs.State
+		err := msg.Decode(&state)
+		if err == nil {
+			err = e.jobs.SetState(state)
+		}
+		if err != nil {
+			e.logger.Warnw("Failed to apply job state", "error", err)
+		}
+	})
+
+	e.locks.OnChange(func(lock collection.Lock) {
+		e.broadcast(context.Background(), cluster.TopicLocks, lock)
+	})
+	e.cluster.Handle(cluster.TopicLocks, func(_ context.Context, msg cluster.Message) {
+		var lock collection.Lock
+		if err := msg.Decode(&lock); err != nil {
+			e.logger.Warnw("Failed to apply collection lock", "error", err)
+			return
+		}
+		e.locks.Set(lock)
+	})
+	e.cluster.Handle(cluster.TopicSchema, func(ctx context.Context, _ cluster.Message) {
+		if err := e.refreshSchema(ctx); err != nil {
+			e.logger.Warnw("Schema refresh failed", "error", err)
+		}
+	})
+	e.cluster.Handle(cluster.TopicPolicies, func(ctx context.Context, _ cluster.Message) {
+		if err := permission.ResetPolicyCache(ctx, e.cache); err != nil {
+			e.logger.Warnw("Failed to clear policy cache", "error", err)
+		}
+	})
+	if e.webhooks != nil {
+		e.webhooks.OnStateChange(func(state webhook.State) {
+			e.broadcast(context.Background(), cluster.TopicWebhooks, state)
+		})
+		e.cluster.Handle(cluster.TopicWebhooks, func(_ context.Context, msg cluster.Message) {
+			var state webhook.State
+			err := msg.Decode(&state)
+			if err == nil {
+				err = e.webhooks.SetState(state)
+			}
+			if err != nil {
+				e.logger.Warnw("Failed to apply webhook state", "error", err)
+			}
+		})
+	}
+	if source := e.config.ConfigSource; source != nil {
+		e.cluster.Handle(cluster.TopicConfig, func(ctx context.Context, _ cluster.Message) {
+			config, err := source(ctx)
+			if err == nil {
+				_, err = e.Reload(ctx, config)
+			}
+			if err != nil {
+				e.logger.Warnw("Failed to reload config", "error", err)
+			}
+		})
+	}
+	return nil
+}
+
+// broadcast tells the other instances of the cluster about a change. It
+// does nothing outside a cluster.
+func (e *Engine) broadcast(ctx context.Context, topic string, data any) {
+	if e.cluster == nil {
+		return
+	}
+	if err := e.cluster.Publish(ctx, topic, data); err != nil {
+		e.logger.Warnw("Failed to notify cluster", "topic", topic, "error", err)
+	}
+}
+
+// retentionJob returns the job that enforces the retention rules.
+func (e *Engine) retentionJob() jobs.Job {
+	interval := e.config.Retention.Interval
+	if interval <= 0 {
+		interval = retention.DefaultInterval
+	}
+	dryRun := e.config.Retention.DryRun
+	return jobs.Job{
+		Name:     "retention",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			results, err := e.retention.Run(ctx, dryRun)
+			if dryRun {
+				for _, result := range results {
+					if result.Matched > 0 {
+						e.logger.Infow("Retention dry run", "collection", result.Collection, "matched", result.Matched, "cutoff", result.Cutoff)
+					}
+				}
+			}
+			return err
+		},
+	}
+}
+
+// publishJob returns the job that publishes scheduled items of collection
+// lifecycles.
+func (e *Engine) publishJob() jobs.Job {
+	interval := e.config.Lifecycle.PublishInterval
+	if interval <= 0 {
+		interval = collection.DefaultPublishInterval
+	}
+	return jobs.Job{
+		Name:     "publish",
+		Interval: interval,
+		Run: func(ctx context.Context) error {
+			published, err := e.collService.PublishScheduled(ctx)
+			if published > 0 {
+				e.logger.Infow("Published scheduled items", "count", published)
+			}
+			return err
+		},
+	}
+}
+
+// schemaManagerConfig builds the schema manager configuration from config.
+func schemaManagerConfig(config Config) schema.ManagerConfig {
+	schemaConfig := schema.ManagerConfig{
+		Mode:         schema.DiscoveryMode(config.Discovery.Mode),
+		Prefix:       config.Discovery.Prefix,
+		AutoDiscover: config.Discovery.AutoDiscover,
+		Blacklist:    config.Discovery.Blacklist,
+		Config:       make(map[string]schema.CollectionConfig),
+
+		StatementTimeout: config.Query.StatementTimeout,
+		RequestTimeout:   config.Query.RequestTimeout,
+		DefaultLimit:     config.Query.DefaultLimit,
+		MaxLimit:         config.Query.MaxLimit,
+		MaxOffset:        config.Query.MaxOffset,
+		MaxExpand:        config.Query.MaxExpand,
+		MaxBodyBytes:     config.Query.MaxBodyBytes,
+		AutoFields:       config.AutoFields,
+		StrictParams:     config.Query.StrictParams,
+	}
+
+	// Convert collection configs
+	for name, cfg := range config.Discovery.Config {
+		schemaConfig.Config[name] = schema.CollectionConfig{
+			Enabled:      cfg.Enabled,
+			PublicFields: cfg.PublicFields,
+
+			StatementTimeout: cfg.StatementTimeout,
+			RequestTimeout:   cfg.RequestTimeout,
+			DefaultLimit:     cfg.DefaultLimit,
+			MaxLimit:         cfg.MaxLimit,
+			History:          cfg.History,
+			Comments:         cfg.Comments,
+			Favorites:        cfg.Favorites,
+			MaxOffset:        cfg.MaxOffset,
+			MaxExpand:        cfg.MaxExpand,
+			SearchFields:     cfg.SearchFields,
+			DefaultFilter:    cfg.DefaultFilter,
+			DefaultSort:      cfg.DefaultSort,
+			Aliases:          cfg.Aliases,
+			MaxBodyBytes:     cfg.MaxBodyBytes,
+			AutoFields:       cfg.AutoFields,
+			ImmutableFields:  cfg.ImmutableFields,
+			Slugs:            cfg.Slugs,
+			Embeddings:       cfg.Embeddings,
+			IDStrategy:       cfg.IDStrategy,
+			StrictParams:     cfg.StrictParams,
+			Translations:     cfg.Translations,
+			Money:            cfg.Money,
+			DuplicateMatch:   cfg.DuplicateMatch,
+			Public:           cfg.Public,
+			Approval:         cfg.Approval,
+			Lifecycle:        cfg.Lifecycle,
+		}
+	}
+
+	return schemaConfig
+}
+
 // initAuth initializes authentication components.
 func (e *Engine) initAuth() error {
 	// Use custom user store if provided, otherwise use default DBUserStore
@@ -181,7 +800,15 @@ func (e *Engine) initAuth() error {
 	}
 
 	// Create session store (for session-based auth)
-	e.sessionStore = auth.NewDBSessionStore(e.db, "tugo_sessions")
+	// A configured shared cache holds sessions too
+	e.sessionStore = e.config.Auth.SessionStore
+	switch {
+	case e.sessionStore != nil:
+	case e.config.Cache != nil:
+		e.sessionStore = auth.NewCacheSessionStore(e.config.Cache)
+	default:
+		e.sessionStore = auth.NewDBSessionStore(e.db, "tugo_sessions")
+	}
 
 	// Determine primary auth method
 	primaryMethod := "jwt"
@@ -215,6 +842,22 @@ func (e *Engine) initAuth() error {
 		e.authProvider = auth.NewJWTProvider(auth.DefaultJWTConfig(), e.userStore)
 	}
 
+	// Add custom claims to tokens
+	var enrich auth.ClaimsEnricher
+	if len(e.config.Auth.ClaimColumns) > 0 || e.config.Auth.ClaimsEnricher != nil {
+		var columns auth.ClaimsEnricher
+		if len(e.config.Auth.ClaimColumns) > 0 {
+			var err error
+			if columns, err = auth.ColumnClaims(e.db, "tugo_users", e.config.Auth.ClaimColumns); err != nil {
+				return err
+			}
+		}
+		enrich = auth.MergeClaims(columns, e.config.Auth.ClaimsEnricher)
+		if provider, ok := e.authProvider.(*auth.JWTProvider); ok {
+			provider.SetClaimsEnricher(enrich)
+		}
+	}
+
 	// Create TOTP manager if enabled
 	for _, method := range e.config.Auth.Methods {
 		if method == "totp" {
@@ -244,17 +887,55 @@ func (e *Engine) initAuth() error {
 		}
 	}
 
+	// Mail email change confirmations with the notifier by default
+	account := e.config.Auth.Account
+	if account.Mailer == nil && e.notifier != nil {
+		account.Mailer = e.notifier
+	}
+	if account.EmailChanges == nil {
+		account.EmailChanges = auth.NewEmailChangeStore(e.db)
+	}
+
 	// Create auth handler
 	e.authHandler = auth.NewHandler(auth.HandlerConfig{
 		Provider:      e.authProvider,
 		UserStore:     e.userStore,
 		TOTPManager:   e.totpManager,
 		SessionConfig: sessionConfigPtr,
+		SessionStore:  e.sessionStore,
+		Account:       account,
 		Logger:        e.logger,
 	})
 
+	// Verify signed requests if enabled
+	var signatures *auth.SignatureVerifier
+	if slices.Contains(e.config.Auth.Methods, "hmac") {
+		signatureConfig := e.config.Auth.Signatures
+		if signatureConfig.Keys == nil {
+			return fmt.Errorf("the hmac auth method requires Auth.Signatures.Keys")
+		}
+		if signatureConfig.Nonces == nil && e.config.Cache != nil {
+			signatureConfig.Nonces = e.config.Cache
+		}
+		signatures = auth.NewSignatureVerifier(signatureConfig)
+	}
+
 	// Create auth middleware
-	e.authMiddleware = auth.RequireAuth(e.authProvider, e.userStore, sessionConfigPtr)
+	e.authMiddleware = auth.Middleware(auth.MiddlewareConfig{
+		Provider:       e.authProvider,
+		UserStore:      e.userStore,
+		SessionConfig:  sessionConfigPtr,
+		Signatures:     signatures,
+		ClaimsEnricher: enrich,
+	})
+	e.optionalAuth = auth.Middleware(auth.MiddlewareConfig{
+		Provider:       e.authProvider,
+		UserStore:      e.userStore,
+		SessionConfig:  sessionConfigPtr,
+		Signatures:     signatures,
+		ClaimsEnricher: enrich,
+		Optional:       true,
+	})
 
 	e.logger.Infow("Authentication initialized", "methods", e.config.Auth.Methods)
 
@@ -263,26 +944,83 @@ func (e *Engine) initAuth() error {
 
 // initStorage initializes storage components.
 func (e *Engine) initStorage() error {
-	// Create storage manager
-	e.storageManager = storage.NewManager(e.config.Storage.Default, e.db)
+	cfg := e.config.Storage
+	providers := make(map[string]storage.Provider)
 
-	// Note: In a real implementation, you would initialize providers from config
-	// For now, we create a local storage provider if no providers are configured
-	if len(e.config.Storage.Providers) == 0 {
+	// Register custom provider implementations
+	for name, provider := range cfg.Providers {
+		if provider == nil {
+			return fmt.Errorf("storage provider %q is nil", name)
+		}
+		providers[name] = provider
+	}
+
+	// Build named local providers
+	for name, localCfg := range cfg.Local {
+		if _, exists := providers[name]; exists {
+			return fmt.Errorf("duplicate storage provider name: %s", name)
+		}
+		if localCfg.BasePath == "" {
+			localCfg.BasePath = "./uploads"
+		}
+		if localCfg.BaseURL == "" {
+			localCfg.BaseURL = "/api/v1/files"
+		}
+		local, err := storage.NewLocal(localCfg.BasePath, localCfg.BaseURL)
+		if err != nil {
+			return fmt.Errorf("failed to create local storage %q: %w", name, err)
+		}
+		providers[name] = local
+	}
+
+	// Build named MinIO providers
+	for name, minioCfg := range cfg.MinIO {
+		if _, exists := providers[name]; exists {
+			return fmt.Errorf("duplicate storage provider name: %s", name)
+		}
+		minioProvider, err := storage.NewMinIO(minioCfg)
+		if err != nil {
+			return fmt.Errorf("failed to create minio storage %q: %w", name, err)
+		}
+		providers[name] = minioProvider
+	}
+
+	// Fall back to a local provider if nothing is configured
+	if len(providers) == 0 {
 		local, err := storage.NewLocal("./uploads", "/api/v1/files")
 		if err != nil {
 			return fmt.Errorf("failed to create local storage: %w", err)
 		}
-		e.storageManager.RegisterProvider("local", local)
-		if e.config.Storage.Default == "" {
-			e.config.Storage.Default = "local"
+		providers["local"] = local
+	}
+
+	// Resolve the default provider
+	if cfg.Default == "" {
+		if len(providers) == 1 {
+			for name := range providers {
+				cfg.Default = name
+			}
+		} else if _, ok := providers["local"]; ok {
+			cfg.Default = "local"
+		} else {
+			return fmt.Errorf("storage default provider must be set when multiple providers are configured")
 		}
 	}
+	if _, ok := providers[cfg.Default]; !ok {
+		return fmt.Errorf("storage default provider not configured: %s", cfg.Default)
+	}
+	e.config.Storage.Default = cfg.Default
+
+	// Create storage manager
+	e.storageManager = storage.NewManager(cfg.Default, e.db)
+	for name, provider := range providers {
+		e.storageManager.RegisterProvider(name, provider)
+	}
 
 	// Create storage handler
 	e.storageHandler = storage.NewHandler(e.storageManager, e.logger, storage.DefaultHandlerConfig())
 
-	e.logger.Infow("Storage initialized", "default", e.config.Storage.Default)
+	e.logger.Infow("Storage initialized", "default", cfg.Default, "providers", len(providers))
 
 	return nil
 }
@@ -293,7 +1031,63 @@ func (e *Engine) initAdmin() {
 	executor := admin.NewSchemaExecutor(e.db)
 
 	// Create admin handler
-	e.adminHandler = admin.NewHandler(e.schemaManager, executor, e.logger, admin.DefaultHandlerConfig())
+	handlerConfig := admin.DefaultHandlerConfig()
+	handlerConfig.DisableDestructive = e.config.Admin.DisableDestructive
+	handlerConfig.ConfirmationSecret = e.config.Admin.ConfirmationSecret
+	handlerConfig.DisableSnapshots = e.config.Admin.DisableSnapshots
+	if e.config.Admin.ConfirmationTTL > 0 {
+		handlerConfig.ConfirmationTTL = e.config.Admin.ConfirmationTTL
+	}
+	e.adminHandler = admin.NewHandler(e.schemaManager, executor, e.logger, handlerConfig)
+	e.adminHandler.SetViewService(e.collService)
+	e.adminHandler.SetApprovals(e.collService)
+	e.adminHandler.SetQueryService(e.queryService)
+	e.adminHandler.SetStatsDB(e.db)
+	e.adminHandler.SetMetaStore(schema.NewMetaStore(e.db))
+	e.adminHandler.SetRetention(e.retention)
+	if e.config.Snapshots.Storage != nil {
+		e.adminHandler.SetSnapshots(snapshot.NewService(e.db, e.config.Snapshots, e.schemaManager.GetCollections, e.logger))
+	}
+	if e.usage != nil {
+		e.adminHandler.SetUsage(e.usage)
+	}
+	if e.cluster != nil {
+		e.adminHandler.SetCluster(e.cluster)
+	}
+	e.adminHandler.SetJobs(e.jobs)
+	e.adminHandler.SetLocks(e.locks)
+	if e.quotas != nil {
+		e.adminHandler.SetQuotas(e.quotas)
+	}
+	if e.sessionStore != nil {
+		e.adminHandler.SetSessions(e.sessionStore)
+	}
+	if e.webhooks != nil {
+		e.adminHandler.SetWebhooks(e.webhooks)
+	}
+	if e.rls != nil {
+		e.adminHandler.SetRLS(e.rls, e.db)
+	}
+
+	// Simulate requests against the policies the permission middleware reads
+	checker := permission.NewChecker(e.db, e.logger)
+	checker.SetCache(e.cache, 0)
+	e.adminHandler.SetPermissions(checker, e.userStore)
+	e.adminHandler.SetSchemaRefresher(e.RefreshSchema)
+	if source := e.config.ConfigSource; source != nil {
+		e.adminHandler.SetConfigReloader(func(ctx context.Context) ([]string, error) {
+			config, err := source(ctx)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load config: %w", err)
+			}
+			restart, err := e.Reload(ctx, config)
+			if err != nil {
+				return nil, err
+			}
+			e.broadcast(ctx, cluster.TopicConfig, nil)
+			return restart, nil
+		})
+	}
 
 	e.logger.Info("Admin handler initialized")
 }
@@ -304,7 +1098,10 @@ func (e *Engine) Init(ctx context.Context) error {
 
 	// Run migrations first
 	e.logger.Info("Running database migrations...")
-	if err := migrate.RunInternalMigrations(ctx, e.db, e.logger); err != nil {
+	migrator := migrate.NewMigrator(e.db, e.logger)
+	migrator.SetLockTimeout(e.config.Migrations.LockTimeout)
+	migrator.SetStrictChecksums(e.config.Migrations.StrictChecksums)
+	if err := migrator.MigrateUp(ctx); err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
 
@@ -329,6 +1126,16 @@ func (e *Engine) Init(ctx context.Context) error {
 	if err := e.schemaManager.Refresh(ctx); err != nil {
 		return fmt.Errorf("failed to refresh schema: %w", err)
 	}
+	if err := collection.CheckDefaults(e.schemaManager); err != nil {
+		return fmt.Errorf("invalid list defaults: %w", err)
+	}
+
+	// Load exposed database functions
+	if e.rpcService != nil {
+		if err := e.rpcService.Refresh(ctx); err != nil {
+			return fmt.Errorf("failed to load functions: %w", err)
+		}
+	}
 
 	// Build validators for discovered collections
 	collections := e.schemaManager.GetCollections()
@@ -342,6 +1149,45 @@ func (e *Engine) Init(ctx context.Context) error {
 		e.logger.Debugw("Collection", "name", c.Name, "table", c.TableName, "fields", len(c.Fields))
 	}
 
+	// Create or update the search indexes
+	e.syncSearch(ctx)
+
+	// Load persisted usage and start persisting it
+	if e.usage != nil {
+		if err := e.usage.Start(ctx); err != nil {
+			e.logger.Warnw("Failed to load usage", "error", err)
+		}
+	}
+
+	// Load this month's quota usage and start persisting it
+	if e.quotas != nil {
+		if err := e.quotas.Start(ctx); err != nil {
+			e.logger.Warnw("Failed to load quota usage", "error", err)
+		}
+	}
+
+	// Listen to the other instances before running jobs under leases
+	if e.cluster != nil {
+		e.cluster.Start(ctx)
+	}
+
+	// Start background jobs
+	e.jobs.Start(ctx)
+
+	// Serve the gRPC API
+	if e.grpc != nil {
+		if err := e.grpc.Start(e.config.GRPC.Addr); err != nil {
+			return err
+		}
+	}
+
+	// Relay stored events to publishers
+	if e.events != nil {
+		if err := e.events.Start(ctx); err != nil {
+			e.logger.Warnw("Failed to start event relay", "error", err)
+		}
+	}
+
 	// Start schema watcher if configured
 	if err := e.StartSchemaWatcher(ctx); err != nil {
 		e.logger.Warnw("Failed to start schema watcher", "error", err)
@@ -359,10 +1205,13 @@ func (e *Engine) Mount(rg *gin.RouterGroup) {
 
 // MountWithOptions mounts the TuGo API routes with custom options.
 func (e *Engine) MountWithOptions(rg *gin.RouterGroup, opts MountOptions) {
+	rg = e.group(rg, e.ipFilter(opts.IPRule)...)
+
 	// Mount auth routes if enabled
 	if e.authHandler != nil {
 		authGroup := rg.Group("/auth")
 		e.authHandler.RegisterRoutes(authGroup, e.authMiddleware)
+		e.mountQuotaUsage(authGroup)
 		e.logger.Infow("Auth routes mounted", "path", authGroup.BasePath())
 	}
 
@@ -373,8 +1222,52 @@ func (e *Engine) MountWithOptions(rg *gin.RouterGroup, opts MountOptions) {
 		e.logger.Infow("File routes mounted", "path", filesGroup.BasePath())
 	}
 
+	// Mount stored query routes
+	queriesGroup := rg.Group("/queries")
+	e.queryHandler.RegisterRoutes(queriesGroup)
+
+	// Mount database function routes if enabled
+	if e.rpcHandler != nil {
+		rpcGroup := rg.Group("/rpc")
+		e.rpcHandler.RegisterRoutes(rpcGroup)
+		e.logger.Infow("RPC routes mounted", "path", rpcGroup.BasePath())
+	}
+
+	// Mount the notifications and favorites of the request's user
+	if e.inboxHandler != nil {
+		e.inboxHandler.RegisterRoutes(rg.Group("/me/notifications", e.authMiddleware))
+	}
+	if e.authMiddleware != nil {
+		e.collHandler.RegisterFavoriteRoutes(rg.Group("/me/favorites", e.authMiddleware))
+	}
+
+	// Mount the change feed, readable by admins only
+	if e.eventsHandler != nil {
+		eventsGroup := rg.Group("/events")
+		if e.authMiddleware != nil {
+			eventsGroup.Use(e.authMiddleware, auth.RequireRole("admin"))
+		}
+		e.eventsHandler.RegisterRoutes(eventsGroup)
+		e.logger.Infow("Event routes mounted", "path", eventsGroup.BasePath())
+	}
+
+	// Mount inbound webhooks, authenticated by their signatures
+	if e.ingestHandler != nil {
+		e.ingestHandler.RegisterRoutes(rg.Group("/ingest"))
+	}
+
+	// Mount the agent tool endpoint, authenticated when auth is configured
+	if e.mcpHandler != nil {
+		mcpGroup := rg.Group("/mcp")
+		if e.authMiddleware != nil {
+			mcpGroup.Use(e.authMiddleware)
+		}
+		e.mcpHandler.RegisterRoutes(mcpGroup)
+		e.logger.Infow("MCP routes mounted", "path", mcpGroup.BasePath())
+	}
+
 	// Mount collection routes
-	e.collHandler.RegisterRoutes(rg)
+	e.collHandler.RegisterRoutesWithMiddleware(e.collectionGroup(rg), e.collectionMiddleware(opts))
 
 	// Auto-mount admin routes if configured
 	if opts.IncludeAdmin && e.adminHandler != nil {
@@ -382,7 +1275,7 @@ func (e *Engine) MountWithOptions(rg *gin.RouterGroup, opts MountOptions) {
 		if adminPath == "" {
 			adminPath = "/admin"
 		}
-		adminGroup := rg.Group(adminPath)
+		adminGroup := rg.Group(adminPath, e.ipFilter(opts.AdminIPRule)...)
 		if opts.RequireAdminAuth && e.authMiddleware != nil {
 			adminGroup.Use(e.authMiddleware)
 			adminGroup.Use(auth.RequireRole("admin"))
@@ -397,6 +1290,7 @@ func (e *Engine) MountWithOptions(rg *gin.RouterGroup, opts MountOptions) {
 // MountAdmin mounts admin API routes (should be protected).
 func (e *Engine) MountAdmin(rg *gin.RouterGroup) {
 	if e.adminHandler != nil {
+		rg = e.group(rg, e.ipFilter(e.config.Mount.AdminIPRule)...)
 		e.adminHandler.RegisterRoutes(rg)
 		e.logger.Infow("Admin routes mounted", "path", rg.BasePath())
 	}
@@ -404,10 +1298,13 @@ func (e *Engine) MountAdmin(rg *gin.RouterGroup) {
 
 // MountWithAuth mounts routes with authentication middleware.
 func (e *Engine) MountWithAuth(rg *gin.RouterGroup) {
+	rg = e.group(rg, e.ipFilter(e.config.Mount.IPRule)...)
+
 	// Mount auth routes if enabled (without auth middleware)
 	if e.authHandler != nil {
 		authGroup := rg.Group("/auth")
 		e.authHandler.RegisterRoutes(authGroup, e.authMiddleware)
+		e.mountQuotaUsage(authGroup)
 	}
 
 	// Apply auth middleware to protected routes
@@ -422,12 +1319,138 @@ func (e *Engine) MountWithAuth(rg *gin.RouterGroup) {
 		e.storageHandler.RegisterRoutes(filesGroup)
 	}
 
-	// Mount collection routes
-	e.collHandler.RegisterRoutes(protected)
+	// Mount stored query routes
+	e.queryHandler.RegisterRoutes(protected.Group("/queries"))
+
+	// Mount database function routes if enabled
+	if e.rpcHandler != nil {
+		e.rpcHandler.RegisterRoutes(protected.Group("/rpc"))
+	}
+
+	// Mount the notifications and favorites of the request's user
+	if e.inboxHandler != nil {
+		e.inboxHandler.RegisterRoutes(protected.Group("/me/notifications"))
+	}
+	e.collHandler.RegisterFavoriteRoutes(protected.Group("/me/favorites"))
+
+	// Mount the change feed, readable by admins only
+	if e.eventsHandler != nil {
+		e.eventsHandler.RegisterRoutes(protected.Group("/events", auth.RequireRole("admin")))
+	}
+
+	// Mount inbound webhooks, authenticated by their signatures
+	if e.ingestHandler != nil {
+		e.ingestHandler.RegisterRoutes(rg.Group("/ingest"))
+	}
+
+	// Mount the agent tool endpoint
+	if e.mcpHandler != nil {
+		e.mcpHandler.RegisterRoutes(protected.Group("/mcp"))
+	}
+
+	// Mount collection routes, authenticated unless the action is public
+	collections := rg.Group("")
+	if e.authMiddleware != nil {
+		collections.Use(e.collectionAuth())
+	}
+	e.collHandler.RegisterRoutesWithMiddleware(e.collectionGroup(collections), e.collectionMiddleware(e.config.Mount))
 
 	e.logger.Infow("TuGo routes mounted with auth", "path", rg.BasePath())
 }
 
+// collectionAuth returns the auth middleware of collection routes. Actions
+// a collection makes public authenticate optionally and mark the request
+// with permission.PublicAccessKey; others require authentication.
+func (e *Engine) collectionAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		collection, err := e.schemaManager.GetCollection(c.Param("collection"))
+		if err == nil && slices.Contains(collection.PublicActions, string(permission.RequestAction(c))) {
+			c.Set(string(permission.PublicAccessKey), true)
+			e.optionalAuth(c)
+			return
+		}
+		e.authMiddleware(c)
+	}
+}
+
+// group returns a group under rg that runs TuGo's request middleware and
+// handlers, so they apply to TuGo routes without touching the host's other
+// routes.
+func (e *Engine) group(rg *gin.RouterGroup, handlers ...gin.HandlerFunc) *gin.RouterGroup {
+	return rg.Group("", append([]gin.HandlerFunc{e.requestLog, e.recovery()}, handlers...)...)
+}
+
+// collectionGroup returns a group under rg that records usage when usage
+// tracking is enabled and meters quotas when they are.
+func (e *Engine) collectionGroup(rg *gin.RouterGroup) *gin.RouterGroup {
+	if e.usage != nil {
+		rg = rg.Group("", e.usage.Middleware())
+	}
+	if e.quotas != nil {
+		rg = rg.Group("", e.quotas.Middleware())
+	}
+	return rg
+}
+
+// mountQuotaUsage mounts GET /usage on the auth group when quotas are
+// enabled.
+func (e *Engine) mountQuotaUsage(authGroup *gin.RouterGroup) {
+	if e.quotas == nil || e.authMiddleware == nil {
+		return
+	}
+	quota.NewHandler(e.quotas).RegisterRoutes(authGroup.Group("", e.authMiddleware))
+}
+
+// collectionMiddleware returns the middleware of single collections,
+// starting with their IP rules.
+func (e *Engine) collectionMiddleware(opts MountOptions) collection.Middleware {
+	collections := make(map[string][]gin.HandlerFunc, len(opts.PerCollectionMiddleware)+len(opts.CollectionIPRules))
+	for name, rule := range opts.CollectionIPRules {
+		collections[name] = e.ipFilter(rule)
+	}
+	for name, handlers := range opts.PerCollectionMiddleware {
+		collections[name] = append(collections[name], handlers...)
+	}
+	return collection.Middleware{
+		Collections: collections,
+		Endpoints:   opts.PerEndpointMiddleware,
+	}
+}
+
+// ipFilter returns the middleware enforcing an IP rule, or none when the
+// rule is empty. A rule that cannot be enforced denies all requests.
+func (e *Engine) ipFilter(rule ipfilter.Rule) []gin.HandlerFunc {
+	if rule.IsZero() {
+		return nil
+	}
+	filter, err := ipfilter.NewFilter(rule, e.config.GeoIP, e.db, e.logger)
+	if err != nil {
+		e.logger.Errorw("Denying all requests of an invalid IP rule", "error", err)
+		return []gin.HandlerFunc{func(c *gin.Context) {
+			response.JSON(c, http.StatusForbidden, response.FromAppError(apperror.ErrForbidden))
+			c.Abort()
+		}}
+	}
+	return []gin.HandlerFunc{filter.Middleware()}
+}
+
+// checkIPRules returns the error of the first invalid IP rule of opts.
+func checkIPRules(opts MountOptions, geo ipfilter.GeoResolver) error {
+	rules := map[string]ipfilter.Rule{"IPRule": opts.IPRule, "AdminIPRule": opts.AdminIPRule}
+	for name, rule := range opts.CollectionIPRules {
+		rules["CollectionIPRules["+name+"]"] = rule
+	}
+	for name, rule := range rules {
+		if rule.IsZero() {
+			continue
+		}
+		if _, err := ipfilter.NewFilter(rule, geo, nil, nil); err != nil {
+			return fmt.Errorf("invalid Mount.%s: %w", name, err)
+		}
+	}
+	return nil
+}
+
 // Router returns the internal Gin router for standalone mode.
 func (e *Engine) Router() *gin.Engine {
 	return e.router
@@ -465,10 +1488,37 @@ func (e *Engine) Run(addr string) error {
 
 // Close cleans up resources.
 func (e *Engine) Close() error {
+	e.jobs.Stop()
+	if e.cluster != nil {
+		e.cluster.Stop()
+	}
+	if e.grpc != nil {
+		e.grpc.Stop()
+	}
+	if e.events != nil {
+		e.events.Stop()
+	}
+	if e.notifier != nil {
+		e.notifier.Wait()
+	}
+	if e.webhooks != nil {
+		e.webhooks.Wait()
+	}
+	if e.usage != nil {
+		e.usage.Close()
+	}
+	if e.quotas != nil {
+		e.quotas.Close()
+	}
+
+	var err error
 	if e.ownsDB && e.db != nil {
-		return e.db.Close()
+		err = e.db.Close()
 	}
-	return nil
+	if e.ownsPool && e.pgxPool != nil {
+		e.pgxPool.Close()
+	}
+	return err
 }
 
 // DB returns the database connection.
@@ -476,14 +1526,183 @@ func (e *Engine) DB() *sqlx.DB {
 	return e.db
 }
 
+// PgxPool returns the pgx connection pool, or nil when not using pgx.
+func (e *Engine) PgxPool() *pgxpool.Pool {
+	return e.pgxPool
+}
+
 // SchemaManager returns the schema manager.
 func (e *Engine) SchemaManager() *schema.Manager {
 	return e.schemaManager
 }
 
-// RefreshSchema re-discovers the database schema.
+// RefreshSchema re-discovers the database schema and exposed functions,
+// on every instance of a cluster.
 func (e *Engine) RefreshSchema(ctx context.Context) error {
-	return e.schemaManager.Refresh(ctx)
+	if err := e.refreshSchema(ctx); err != nil {
+		return err
+	}
+	e.broadcast(ctx, cluster.TopicSchema, nil)
+	return nil
+}
+
+// refreshSchema re-discovers the schema and exposed functions of this
+// instance.
+func (e *Engine) refreshSchema(ctx context.Context) error {
+	if err := e.schemaManager.Refresh(ctx); err != nil {
+		return err
+	}
+	e.syncSearch(ctx)
+	if e.rpcService != nil {
+		return e.rpcService.Refresh(ctx)
+	}
+	return nil
+}
+
+// Reload applies the reloadable parts of config to the running engine:
+// Discovery, including collection notification and retention rules, the
+// collection limits in Query (StatementTimeout, RequestTimeout,
+// DefaultLimit, MaxLimit, MaxOffset, MaxExpand and MaxBodyBytes), the role
+// mapping in Permissions.RLS, the limits in Quotas and the MCP rate limits,
+// and Webhooks. Collections are rediscovered and swapped in at once, so
+// requests see either the old or the new configuration. A config whose
+// default filters or sorts do not fit the rediscovered collections, or
+// whose webhooks are invalid, is rejected and the old one kept. Other
+// settings take effect on restart; Reload returns those of them that
+// changed and that it could not apply, such as Permissions.Mode.
+func (e *Engine) Reload(ctx context.Context, config Config) (restart []string, err error) {
+	defaults := DefaultConfig()
+	if config.Discovery.Prefix == "" {
+		config.Discovery.Prefix = defaults.Discovery.Prefix
+	}
+	if config.Discovery.Mode == "" {
+		config.Discovery.Mode = defaults.Discovery.Mode
+	}
+
+	err = e.schemaManager.Reconfigure(ctx, schemaManagerConfig(config), func(staged *schema.Manager) error {
+		if err := collection.CheckDefaults(staged); err != nil {
+			return apperror.ErrBadRequest.WithMessagef("Invalid list defaults: %v", err)
+		}
+		if e.webhooks != nil {
+			if err := webhook.Validate(config.Webhooks); err != nil {
+				return apperror.ErrBadRequest.WithMessagef("Invalid webhooks: %v", err)
+			}
+		}
+		return nil
+	})
+	if _, ok := apperror.AsAppError(err); ok {
+		return nil, err
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to reload config: %w", err)
+	}
+	if e.notifier != nil {
+		e.notifier.SetRules(notificationRules(config))
+	}
+	e.retention.SetRules(retentionRules(config))
+	if e.rls != nil {
+		e.rls.SetRoles(config.Permissions.RLS.Roles, config.Permissions.RLS.DefaultRole)
+	}
+	if e.quotas != nil {
+		e.quotas.SetLimits(config.Quotas.Default, config.Quotas.Roles)
+	}
+	if e.mcpHandler != nil {
+		e.mcpHandler.SetLimits(config.MCP.RateLimit, config.MCP.ToolLimits)
+	}
+	if e.webhooks != nil {
+		// Validated before the swap
+		_ = e.webhooks.SetWebhooks(config.Webhooks)
+	}
+	e.syncSearch(ctx)
+
+	restart = e.restartRequired(config)
+	e.logger.Infow("Config reloaded", "collections", len(e.schemaManager.GetCollections()), "restart_required", restart)
+	return restart, nil
+}
+
+// restartRequired returns the settings of config that differ from the
+// running ones but that Reload cannot apply.
+func (e *Engine) restartRequired(config Config) []string {
+	var settings []string
+	if permissionMode(config.Permissions.Mode) != permissionMode(e.config.Permissions.Mode) {
+		settings = append(settings, "Permissions.Mode")
+	}
+	if e.rls != nil && settingPrefix(config.Permissions.RLS.SettingPrefix) != settingPrefix(e.config.Permissions.RLS.SettingPrefix) {
+		settings = append(settings, "Permissions.RLS.SettingPrefix")
+	}
+	if config.Quotas.Enabled != (e.quotas != nil) {
+		settings = append(settings, "Quotas.Enabled")
+	}
+	if config.MCP.Enabled != (e.mcpHandler != nil) {
+		settings = append(settings, "MCP.Enabled")
+	}
+	if e.webhooks == nil && len(config.Webhooks) > 0 {
+		settings = append(settings, "Webhooks")
+	}
+	return settings
+}
+
+// permissionMode returns a Permissions.Mode with its default applied.
+func permissionMode(mode string) string {
+	if mode == "" {
+		return "app"
+	}
+	return mode
+}
+
+// settingPrefix returns a Permissions.RLS.SettingPrefix with its default
+// applied.
+func settingPrefix(prefix string) string {
+	if prefix == "" {
+		return permission.DefaultSettingPrefix
+	}
+	return prefix
+}
+
+// syncSearch brings the search indexes in line with the collections. Failures
+// are logged, as writes still reach indexes that exist.
+func (e *Engine) syncSearch(ctx context.Context) {
+	if e.indexer == nil {
+		return
+	}
+	if err := e.indexer.Sync(ctx, e.schemaManager.GetCollections()); err != nil {
+		e.logger.Warnw("Failed to sync search indexes", "error", err)
+	}
+}
+
+// ReindexSearch adds all items of a collection to its search index, such as
+// after the index is first configured for a collection with items.
+func (e *Engine) ReindexSearch(ctx context.Context, name string) error {
+	if e.indexer == nil {
+		return fmt.Errorf("search is not configured")
+	}
+	col, err := e.schemaManager.GetCollection(name)
+	if err != nil {
+		return err
+	}
+	if err := e.indexer.Sync(ctx, []*schema.Collection{col}); err != nil {
+		return err
+	}
+
+	for page := 1; ; page++ {
+		result, err := e.collService.List(ctx, collection.ListParams{
+			CollectionName: col.Name,
+			QueryParams: map[string][]string{
+				"sort":  {col.PrimaryKey},
+				"limit": {"100"},
+				"page":  {strconv.Itoa(page)},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		if len(result.Items) == 0 {
+			return nil
+		}
+		if err := e.indexer.Reindex(ctx, col, result.Items); err != nil {
+			return err
+		}
+	}
 }
 
 // GetCollections returns all discovered collections.
@@ -526,6 +1745,120 @@ func (e *Engine) ValidatorRegistry() *validation.ValidatorRegistry {
 	return e.validatorRegistry
 }
 
+// Notifier returns the email notifier, or nil when Notify.Mailer is not set.
+// Use its Send method to mail templates from application code.
+func (e *Engine) Notifier() *notify.Notifier {
+	return e.notifier
+}
+
+// Inbox returns the notification inbox, or nil when Inbox.Enabled is not
+// set. Use its Notify method to send notifications from application code.
+func (e *Engine) Inbox() *inbox.Inbox {
+	return e.inbox
+}
+
+// Webhooks returns the webhook dispatcher, or nil when none are configured.
+func (e *Engine) Webhooks() *webhook.Dispatcher {
+	return e.webhooks
+}
+
+// Usage returns the usage tracker, or nil when Usage.Enabled is not set.
+func (e *Engine) Usage() *usage.Tracker {
+	return e.usage
+}
+
+// Quotas returns the quota meter, or nil when Quotas.Enabled is not set.
+func (e *Engine) Quotas() *quota.Meter {
+	return e.quotas
+}
+
+// Jobs returns the background job runner. Jobs added before Init start
+// with it, and jobs added later start at once.
+func (e *Engine) Jobs() *jobs.Runner {
+	return e.jobs
+}
+
+// Schedule runs fn on a cron schedule, such as "0 3 * * *" for 3 AM every
+// day in the local time zone, and returns the name of the job, which the
+// admin API lists, disables and enables. In a cluster, one instance runs
+// each time. Use Jobs().Add for a named job or another time zone.
+func (e *Engine) Schedule(spec string, fn func(ctx context.Context) error) (string, error) {
+	name := "schedule-" + strconv.FormatInt(e.schedules.Add(1), 10)
+	if err := e.jobs.Add(jobs.Job{Name: name, Schedule: spec, Run: fn}); err != nil {
+		return "", err
+	}
+	return name, nil
+}
+
+// Locks returns the write locks of collections. Hosts can lock a
+// collection while running their own migrations or backfills with Hold.
+func (e *Engine) Locks() *collection.Locks {
+	return e.locks
+}
+
+// Cluster returns this instance's member of the cluster, or nil when
+// Cluster is not enabled. Hosts may send their own topics on it.
+func (e *Engine) Cluster() *cluster.Node {
+	return e.cluster
+}
+
+// InvalidatePolicies drops the policies cached in Cache, on every
+// instance of a cluster. Call it after changing role policies in
+// tugo_permissions.
+func (e *Engine) InvalidatePolicies(ctx context.Context) error {
+	if err := permission.ResetPolicyCache(ctx, e.cache); err != nil {
+		return fmt.Errorf("failed to clear policy cache: %w", err)
+	}
+	e.broadcast(ctx, cluster.TopicPolicies, nil)
+	return nil
+}
+
+// Retention returns the retention rule enforcer.
+func (e *Engine) Retention() *retention.Enforcer {
+	return e.retention
+}
+
+// QueryService returns the stored query service.
+func (e *Engine) QueryService() *storedquery.Service {
+	return e.queryService
+}
+
+// CollectionService returns the collection service, for handlers that
+// delegate to it.
+func (e *Engine) CollectionService() *collection.Service {
+	return e.collService
+}
+
+// OverrideHandler replaces one generated endpoint of a collection, such as
+// "create" for POST /orders, keeping the collection's other endpoints. The
+// handler may call collection.CallDefault to wrap the generated handler,
+// or use CollectionService to implement the endpoint.
+func (e *Engine) OverrideHandler(collectionName, endpoint string, handler gin.HandlerFunc) error {
+	return e.collHandler.Override(collectionName, endpoint, handler)
+}
+
+// GRPC returns the gRPC server, or nil unless GRPC.Addr is set.
+func (e *Engine) GRPC() *grpcapi.Server {
+	return e.grpc
+}
+
+// RLS returns the row-level security manager, or nil outside RLS permission mode.
+func (e *Engine) RLS() *permission.RLS {
+	return e.rls
+}
+
+// Cache returns the shared key-value cache: Config.Cache, or an in-memory
+// store when none is configured. Pass it to permission.Checker.SetCache to
+// share cached policies.
+func (e *Engine) Cache() cache.Store {
+	return e.cache
+}
+
+// RPCService returns the database function service, or nil if RPC is disabled.
+func (e *Engine) RPCService() *rpc.Service {
+	return e.rpcService
+}
+
 // AdminHandler returns the admin handler.
 func (e *Engine) AdminHandler() *admin.Handler {
 	return e.adminHandler
@@ -596,7 +1929,7 @@ func (e *Engine) getRoleID(ctx context.Context, roleName string) (string, error)
 	}
 
 	var roleID string
-	err := e.db.GetContext(ctx, &roleID, "SELECT id FROM tugo_roles WHERE name = $1", roleName)
+	err := e.db.GetContext(ctx, &roleID, e.db.Rebind("SELECT id FROM tugo_roles WHERE name = ?"), roleName)
 	if err != nil {
 		return "", fmt.Errorf("role '%s' not found: %w", roleName, err)
 	}
@@ -679,7 +2012,7 @@ func (w *SchemaWatcher) startPollMode(ctx context.Context) error {
 		for {
 			select {
 			case <-ticker.C:
-				if err := w.engine.RefreshSchema(ctx); err != nil {
+				if err := w.engine.refreshSchema(ctx); err != nil {
 					w.engine.logger.Warnw("Schema refresh failed", "error", err)
 				} else {
 					w.engine.logger.Debug("Schema refreshed via poll")
@@ -698,7 +2031,13 @@ func (w *SchemaWatcher) startPollMode(ctx context.Context) error {
 
 // startNotifyMode starts listening for PostgreSQL notifications.
 func (w *SchemaWatcher) startNotifyMode(ctx context.Context) error {
-	listener, err := NewPGListener(w.engine.db, w.config.Channel)
+	var listener *PGListener
+	var err error
+	if w.engine.pgxPool != nil {
+		listener, err = NewPGXListener(w.engine.pgxPool, w.config.Channel, w.engine.logger)
+	} else {
+		listener, err = NewPGListener(w.engine.db, w.config.Channel)
+	}
 	if err != nil {
 		return fmt.Errorf("failed to create listener: %w", err)
 	}
@@ -710,7 +2049,7 @@ func (w *SchemaWatcher) startNotifyMode(ctx context.Context) error {
 		for {
 			select {
 			case <-listener.Notify():
-				if err := w.engine.RefreshSchema(ctx); err != nil {
+				if err := w.engine.refreshSchema(ctx); err != nil {
 					w.engine.logger.Warnw("Schema refresh failed", "error", err)
 				} else {
 					w.engine.logger.Info("Schema refreshed via notification")
@@ -742,6 +2081,7 @@ type PGListener struct {
 	channel string
 	notify  chan struct{}
 	stopCh  chan struct{}
+	cancel  context.CancelFunc
 }
 
 // NewPGListener creates a new PostgreSQL listener.
@@ -781,6 +2121,47 @@ func (l *PGListener) listen() {
 	}
 }
 
+// NewPGXListener creates a listener using native LISTEN on a dedicated pgx connection.
+func NewPGXListener(pool *pgxpool.Pool, channel string, logger *zap.SugaredLogger) (*PGListener, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	conn, err := pool.Acquire(ctx)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{channel}.Sanitize()); err != nil {
+		conn.Release()
+		cancel()
+		return nil, err
+	}
+
+	l := &PGListener{
+		channel: channel,
+		notify:  make(chan struct{}, 10),
+		stopCh:  make(chan struct{}),
+		cancel:  cancel,
+	}
+
+	go func() {
+		defer conn.Release()
+		for {
+			if _, err := conn.Conn().WaitForNotification(ctx); err != nil {
+				if ctx.Err() == nil {
+					logger.Warnw("Schema listener stopped", "error", err)
+				}
+				return
+			}
+			select {
+			case l.notify <- struct{}{}:
+			default:
+			}
+		}
+	}()
+
+	return l, nil
+}
+
 // Notify returns the notification channel.
 func (l *PGListener) Notify() <-chan struct{} {
 	return l.notify
@@ -789,6 +2170,9 @@ func (l *PGListener) Notify() <-chan struct{} {
 // Close closes the listener.
 func (l *PGListener) Close() {
 	close(l.stopCh)
+	if l.cancel != nil {
+		l.cancel()
+	}
 }
 
 // StartSchemaWatcher starts the schema watcher if configured.
@@ -812,5 +2196,5 @@ func (e *Engine) StopSchemaWatcher() {
 
 // TriggerSchemaRefresh manually triggers a schema refresh.
 func (e *Engine) TriggerSchemaRefresh(ctx context.Context) error {
-	return e.schemaManager.Refresh(ctx)
+	return e.RefreshSchema(ctx)
 }
@@ -0,0 +1,65 @@
+package tugotest
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Column describes a column for CreateCollection.
+type Column struct {
+	Name       string
+	Type       string
+	PrimaryKey bool
+	Unique     bool
+	NotNull    bool
+	Default    string
+
+	// References is a collection name (without prefix) this column points to.
+	References string
+}
+
+// CreateCollection creates a prefixed table for the collection and refreshes the schema.
+func (h *Harness) CreateCollection(name string, columns ...Column) {
+	h.T.Helper()
+
+	defs := make([]string, 0, len(columns))
+	for _, col := range columns {
+		def := col.Name + " " + col.Type
+		if col.PrimaryKey {
+			def += " PRIMARY KEY"
+		}
+		if col.NotNull {
+			def += " NOT NULL"
+		}
+		if col.Unique {
+			def += " UNIQUE"
+		}
+		if col.Default != "" {
+			def += " DEFAULT " + col.Default
+		}
+		if col.References != "" {
+			def += " REFERENCES " + h.prefix + col.References
+		}
+		defs = append(defs, def)
+	}
+
+	h.Exec(fmt.Sprintf("CREATE TABLE %s%s (%s)", h.prefix, name, strings.Join(defs, ", ")))
+	h.Refresh()
+}
+
+// Insert inserts a row into a collection's table directly.
+func (h *Harness) Insert(collection string, data map[string]any) {
+	h.T.Helper()
+
+	cols := make([]string, 0, len(data))
+	placeholders := make([]string, 0, len(data))
+	args := make([]any, 0, len(data))
+	for col, val := range data {
+		cols = append(cols, col)
+		placeholders = append(placeholders, "?")
+		args = append(args, val)
+	}
+
+	h.Exec(fmt.Sprintf("INSERT INTO %s%s (%s) VALUES (%s)",
+		h.prefix, collection, strings.Join(cols, ", "), strings.Join(placeholders, ", ")), args...)
+}
@@ -0,0 +1,77 @@
+package tugotest
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"sync"
+
+	embeddedpostgres "github.com/fergusstrange/embedded-postgres"
+)
+
+// embedded is the PostgreSQL server started for harnesses without a server
+// URL when EnvEmbedded is set. It is shared while any of them runs.
+var embedded struct {
+	mu     sync.Mutex
+	server *embeddedpostgres.EmbeddedPostgres
+	dir    string
+	url    string
+	users  int
+}
+
+// startEmbeddedServer starts the shared embedded server, or joins the
+// running one, and returns its URL. Call release once done with it; the
+// last release stops the server and removes its files.
+func startEmbeddedServer() (serverURL string, release func(), err error) {
+	embedded.mu.Lock()
+	defer embedded.mu.Unlock()
+
+	if embedded.server == nil {
+		port, err := freePort()
+		if err != nil {
+			return "", nil, err
+		}
+		dir, err := os.MkdirTemp("", "tugotest-postgres-")
+		if err != nil {
+			return "", nil, err
+		}
+
+		config := embeddedpostgres.DefaultConfig().Port(port).RuntimePath(dir).Logger(io.Discard)
+		server := embeddedpostgres.NewDatabase(config)
+		if err := server.Start(); err != nil {
+			os.RemoveAll(dir)
+			return "", nil, fmt.Errorf("failed to start embedded PostgreSQL: %w", err)
+		}
+		embedded.server = server
+		embedded.dir = dir
+		embedded.url = config.GetConnectionURL() + "?sslmode=disable"
+	}
+	embedded.users++
+	return embedded.url, releaseEmbeddedServer, nil
+}
+
+// releaseEmbeddedServer stops the shared embedded server when no harness
+// uses it anymore.
+func releaseEmbeddedServer() {
+	embedded.mu.Lock()
+	defer embedded.mu.Unlock()
+
+	embedded.users--
+	if embedded.users > 0 || embedded.server == nil {
+		return
+	}
+	_ = embedded.server.Stop()
+	os.RemoveAll(embedded.dir)
+	embedded.server = nil
+}
+
+// freePort returns a TCP port free on localhost.
+func freePort() (uint32, error) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return 0, err
+	}
+	defer l.Close()
+	return uint32(l.Addr().(*net.TCPAddr).Port), nil
+}
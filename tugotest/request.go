@@ -0,0 +1,163 @@
+package tugotest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// Response wraps a recorded response with assertion helpers.
+type Response struct {
+	*httptest.ResponseRecorder
+	t    testing.TB
+	body map[string]any
+}
+
+// Do issues a request against the mounted routes.
+// body may be nil, an io.Reader, or any JSON-encodable value.
+func (h *Harness) Do(method, path string, body any) *Response {
+	h.T.Helper()
+
+	var reader io.Reader
+	switch b := body.(type) {
+	case nil:
+	case io.Reader:
+		reader = b
+	default:
+		data, err := json.Marshal(b)
+		if err != nil {
+			h.T.Fatalf("tugotest: failed to encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if h.token != "" {
+		req.Header.Set("Authorization", "Bearer "+h.token)
+	}
+
+	rec := httptest.NewRecorder()
+	h.Router.ServeHTTP(rec, req)
+	return &Response{ResponseRecorder: rec, t: h.T}
+}
+
+// Get issues a GET request.
+func (h *Harness) Get(path string) *Response {
+	h.T.Helper()
+	return h.Do(http.MethodGet, path, nil)
+}
+
+// Post issues a POST request with a JSON body.
+func (h *Harness) Post(path string, body any) *Response {
+	h.T.Helper()
+	return h.Do(http.MethodPost, path, body)
+}
+
+// Patch issues a PATCH request with a JSON body.
+func (h *Harness) Patch(path string, body any) *Response {
+	h.T.Helper()
+	return h.Do(http.MethodPatch, path, body)
+}
+
+// Delete issues a DELETE request.
+func (h *Harness) Delete(path string) *Response {
+	h.T.Helper()
+	return h.Do(http.MethodDelete, path, nil)
+}
+
+// JSON decodes the response envelope.
+func (r *Response) JSON() map[string]any {
+	r.t.Helper()
+	if r.body == nil {
+		r.body = make(map[string]any)
+		if err := json.Unmarshal(r.Body.Bytes(), &r.body); err != nil {
+			r.t.Fatalf("tugotest: response is not JSON: %v\n%s", err, r.Body.String())
+		}
+	}
+	return r.body
+}
+
+// Data returns the "data" field of the response envelope.
+func (r *Response) Data() any {
+	r.t.Helper()
+	return r.JSON()["data"]
+}
+
+// Item returns the "data" field as a single record.
+func (r *Response) Item() map[string]any {
+	r.t.Helper()
+	item, ok := r.Data().(map[string]any)
+	if !ok {
+		r.t.Fatalf("tugotest: response data is not an object: %s", r.Body.String())
+	}
+	return item
+}
+
+// Items returns the records of a list response.
+func (r *Response) Items() []map[string]any {
+	r.t.Helper()
+	data, ok := r.Data().(map[string]any)
+	if !ok {
+		r.t.Fatalf("tugotest: response data is not a list: %s", r.Body.String())
+	}
+	raw, _ := data["items"].([]any)
+	items := make([]map[string]any, 0, len(raw))
+	for _, v := range raw {
+		if m, ok := v.(map[string]any); ok {
+			items = append(items, m)
+		}
+	}
+	return items
+}
+
+// AssertStatus fails the test if the status code differs.
+func (r *Response) AssertStatus(code int) *Response {
+	r.t.Helper()
+	if r.Code != code {
+		r.t.Fatalf("tugotest: expected status %d, got %d\n%s", code, r.Code, r.Body.String())
+	}
+	return r
+}
+
+// AssertErrorCode fails the test if the error code differs.
+func (r *Response) AssertErrorCode(code string) *Response {
+	r.t.Helper()
+	errBody, _ := r.JSON()["error"].(map[string]any)
+	if errBody == nil || errBody["code"] != code {
+		r.t.Fatalf("tugotest: expected error code %q\n%s", code, r.Body.String())
+	}
+	return r
+}
+
+// AssertTotal fails the test if a list response's total differs.
+func (r *Response) AssertTotal(total int) *Response {
+	r.t.Helper()
+	data, _ := r.Data().(map[string]any)
+	pagination, _ := data["pagination"].(map[string]any)
+	if pagination == nil || int(pagination["total"].(float64)) != total {
+		r.t.Fatalf("tugotest: expected total %d\n%s", total, r.Body.String())
+	}
+	return r
+}
+
+// AssertField fails the test if the record field differs.
+func (r *Response) AssertField(field string, want any) *Response {
+	r.t.Helper()
+	got := r.Item()[field]
+	if normalize(got) != normalize(want) {
+		r.t.Fatalf("tugotest: expected %s = %v, got %v", field, want, got)
+	}
+	return r
+}
+
+// normalize renders values comparably across JSON number/string types.
+func normalize(v any) string {
+	b, _ := json.Marshal(v)
+	return string(b)
+}
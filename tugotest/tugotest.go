@@ -0,0 +1,277 @@
+// Package tugotest provides helpers for integration-testing code built on TuGo.
+//
+// A Harness spins up an engine against an ephemeral database, lets tests
+// define collections programmatically, and issues (authenticated) requests
+// against the mounted routes:
+//
+//	func TestProducts(t *testing.T) {
+//	    h := tugotest.New(t, tugotest.Options{})
+//	    h.CreateCollection("products",
+//	        tugotest.Column{Name: "id", Type: "SERIAL", PrimaryKey: true},
+//	        tugotest.Column{Name: "name", Type: "TEXT", NotNull: true},
+//	    )
+//	    h.Post("/api/v1/products", map[string]any{"name": "Widget"}).AssertStatus(http.StatusCreated)
+//	    h.Get("/api/v1/products").AssertStatus(http.StatusOK).AssertTotal(1)
+//	}
+//
+// The database server is taken from Options.DatabaseURL or the
+// TUGO_TEST_DATABASE_URL environment variable. When neither is set and
+// TUGO_TEST_EMBEDDED=1, an embedded PostgreSQL server is started and shared
+// by the test binary's harnesses; otherwise, or when it cannot start, tests
+// are skipped. Pass Options.DB to run against an existing connection
+// instead, e.g. an in-memory SQLite database.
+package tugotest
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/thienel/tugo"
+	"github.com/thienel/tugo/pkg/auth"
+)
+
+// EnvDatabaseURL is the environment variable holding the test database server URL.
+const EnvDatabaseURL = "TUGO_TEST_DATABASE_URL"
+
+// EnvEmbedded is the environment variable that, set to "1", starts an
+// embedded PostgreSQL server when no server URL is given. The server's
+// binaries are downloaded on first use.
+const EnvEmbedded = "TUGO_TEST_EMBEDDED"
+
+// Options configures a Harness.
+type Options struct {
+	// Config is the base engine configuration.
+	// DB and DatabaseURL are overwritten by the harness.
+	Config tugo.Config
+
+	// DB is an existing connection to use instead of an ephemeral database.
+	DB *sqlx.DB
+
+	// DatabaseURL is a PostgreSQL server URL used to create an ephemeral database.
+	// Default: $TUGO_TEST_DATABASE_URL, or else an embedded server when
+	// $TUGO_TEST_EMBEDDED is "1"
+	DatabaseURL string
+
+	// BasePath is where API routes are mounted.
+	// Default: "/api/v1"
+	BasePath string
+
+	// KeepDatabase skips dropping the ephemeral database on cleanup.
+	KeepDatabase bool
+}
+
+// Harness is a running engine wired for tests.
+type Harness struct {
+	T      testing.TB
+	Engine *tugo.Engine
+	DB     *sqlx.DB
+	Router *gin.Engine
+
+	prefix string
+	token  string
+}
+
+// New creates a Harness, initializes the engine and registers cleanup on t.
+func New(t testing.TB, opts Options) *Harness {
+	t.Helper()
+	ctx := context.Background()
+
+	if opts.BasePath == "" {
+		opts.BasePath = "/api/v1"
+	}
+
+	config := opts.Config
+	if config.Discovery.Prefix == "" {
+		config.Discovery.Prefix = "api_"
+	}
+	if config.Discovery.Mode == "" {
+		config.Discovery.Mode = "prefix"
+		config.Discovery.AutoDiscover = true
+	}
+
+	// Resolve the database connection
+	db := opts.DB
+	if db == nil {
+		serverURL := opts.DatabaseURL
+		if serverURL == "" {
+			serverURL = os.Getenv(EnvDatabaseURL)
+		}
+		if serverURL == "" {
+			if os.Getenv(EnvEmbedded) != "1" {
+				t.Skipf("tugotest: set %s, or %s=1 for an embedded server, to run integration tests", EnvDatabaseURL, EnvEmbedded)
+			}
+			embeddedURL, release, err := startEmbeddedServer()
+			if err != nil {
+				t.Skipf("tugotest: embedded PostgreSQL server unavailable, set %s to run integration tests: %v", EnvDatabaseURL, err)
+			}
+			t.Cleanup(release)
+			serverURL = embeddedURL
+		}
+
+		var err error
+		db, err = createEphemeralDatabase(ctx, serverURL)
+		if err != nil {
+			t.Fatalf("tugotest: %v", err)
+		}
+		dbName := currentDatabase(db)
+		t.Cleanup(func() {
+			db.Close()
+			if !opts.KeepDatabase {
+				dropDatabase(serverURL, dbName)
+			}
+		})
+	}
+	config.DB = db
+
+	engine, err := tugo.New(config)
+	if err != nil {
+		t.Fatalf("tugotest: failed to create engine: %v", err)
+	}
+	if err := engine.Init(ctx); err != nil {
+		t.Fatalf("tugotest: failed to initialize engine: %v", err)
+	}
+	t.Cleanup(func() {
+		engine.StopSchemaWatcher()
+		engine.Close()
+	})
+
+	router := gin.New()
+	engine.Mount(router.Group(opts.BasePath))
+
+	return &Harness{
+		T:      t,
+		Engine: engine,
+		DB:     db,
+		Router: router,
+		prefix: config.Discovery.Prefix,
+	}
+}
+
+// Exec runs a SQL statement, failing the test on error.
+func (h *Harness) Exec(query string, args ...any) {
+	h.T.Helper()
+	if _, err := h.DB.Exec(h.DB.Rebind(query), args...); err != nil {
+		h.T.Fatalf("tugotest: exec failed: %v\n%s", err, query)
+	}
+}
+
+// Refresh re-discovers the schema after manual DDL.
+func (h *Harness) Refresh() {
+	h.T.Helper()
+	if err := h.Engine.RefreshSchema(context.Background()); err != nil {
+		h.T.Fatalf("tugotest: schema refresh failed: %v", err)
+	}
+}
+
+// CreateUser creates a user with the given role name and returns it.
+func (h *Harness) CreateUser(username, password, role string) *auth.User {
+	h.T.Helper()
+	ctx := context.Background()
+
+	store := h.Engine.UserStore()
+	if store == nil {
+		h.T.Fatalf("tugotest: auth is not configured")
+	}
+
+	var roleID string
+	if err := h.DB.GetContext(ctx, &roleID, h.DB.Rebind("SELECT id FROM tugo_roles WHERE name = ?"), role); err != nil {
+		h.T.Fatalf("tugotest: role %q not found: %v", role, err)
+	}
+
+	hash, err := auth.HashPassword(password)
+	if err != nil {
+		h.T.Fatalf("tugotest: %v", err)
+	}
+
+	user := &auth.User{
+		ID:       uuid.NewString(),
+		Username: username,
+		Email:    username + "@example.test",
+		RoleID:   roleID,
+		Role:     role,
+		Status:   "active",
+	}
+	if err := store.Create(ctx, user, hash); err != nil {
+		h.T.Fatalf("tugotest: failed to create user: %v", err)
+	}
+	return user
+}
+
+// As returns a copy of the harness that authenticates requests as user.
+func (h *Harness) As(user *auth.User) *Harness {
+	h.T.Helper()
+	provider := h.Engine.AuthProvider()
+	if provider == nil {
+		h.T.Fatalf("tugotest: auth is not configured")
+	}
+
+	tokens, err := provider.GenerateTokens(context.Background(), user)
+	if err != nil {
+		h.T.Fatalf("tugotest: failed to generate tokens: %v", err)
+	}
+	return h.WithToken(tokens.AccessToken)
+}
+
+// WithToken returns a copy of the harness that sends the given bearer token.
+func (h *Harness) WithToken(token string) *Harness {
+	clone := *h
+	clone.token = token
+	return &clone
+}
+
+// createEphemeralDatabase creates a uniquely named database and connects to it.
+func createEphemeralDatabase(ctx context.Context, serverURL string) (*sqlx.DB, error) {
+	admin, err := sqlx.ConnectContext(ctx, "postgres", serverURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s: %w", EnvDatabaseURL, err)
+	}
+	defer admin.Close()
+
+	name := "tugotest_" + strings.ReplaceAll(uuid.NewString(), "-", "")[:16]
+	if _, err := admin.ExecContext(ctx, "CREATE DATABASE "+name); err != nil {
+		return nil, fmt.Errorf("failed to create database: %w", err)
+	}
+
+	dbURL, err := withDatabase(serverURL, name)
+	if err != nil {
+		return nil, err
+	}
+	return sqlx.ConnectContext(ctx, "postgres", dbURL)
+}
+
+// dropDatabase drops an ephemeral database, ignoring errors.
+func dropDatabase(serverURL, name string) {
+	if name == "" || !strings.HasPrefix(name, "tugotest_") {
+		return
+	}
+	admin, err := sqlx.Connect("postgres", serverURL)
+	if err != nil {
+		return
+	}
+	defer admin.Close()
+	_, _ = admin.Exec("DROP DATABASE IF EXISTS " + name + " WITH (FORCE)")
+}
+
+// currentDatabase returns the connected database name.
+func currentDatabase(db *sqlx.DB) string {
+	var name string
+	_ = db.Get(&name, "SELECT current_database()")
+	return name
+}
+
+// withDatabase replaces the database name in a postgres:// URL.
+func withDatabase(serverURL, name string) (string, error) {
+	u, err := url.Parse(serverURL)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return "", fmt.Errorf("%s must be a postgres:// URL", EnvDatabaseURL)
+	}
+	u.Path = "/" + name
+	return u.String(), nil
+}
@@ -0,0 +1,25 @@
+package tugotest
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/thienel/tugo"
+)
+
+func TestHarness(t *testing.T) {
+	h := New(t, Options{Config: tugo.Config{
+		Auth: tugo.AuthConfig{Methods: []string{"jwt"}, JWT: tugo.JWTConfig{Secret: "0123456789abcdef0123456789abcdef"}},
+	}})
+	h.CreateCollection("products",
+		Column{Name: "id", Type: "SERIAL", PrimaryKey: true},
+		Column{Name: "name", Type: "TEXT", NotNull: true},
+	)
+	h.Insert("products", map[string]any{"name": "Gadget"})
+
+	user := h.CreateUser("alice", "password123!", "user")
+	h.As(user).Post("/api/v1/products", map[string]any{"name": "Widget"}).
+		AssertStatus(http.StatusCreated).
+		AssertField("name", "Widget")
+	h.As(user).Get("/api/v1/products").AssertStatus(http.StatusOK).AssertTotal(2)
+}